@@ -0,0 +1,50 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+)
+
+// AgentHandler serves a kit agent: the context's history and the message's text parts become the conversation,
+// a string output is answered as a text part and any other output as a data part
+func AgentHandler[Output any](agent *kit.Agent[Output]) Handler {
+	return func(ctx context.Context, message Message, history []Message) ([]Part, error) {
+		messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(history)+1)
+		for _, m := range append(history, message) {
+			if m.Role == RoleAgent {
+				messages = append(messages, openai.AssistantMessage(conversationText(m)))
+			} else {
+				messages = append(messages, openai.UserMessage(conversationText(m)))
+			}
+		}
+
+		output, err := agent.Invoke(ctx, kit.InvokeConfig{Messages: messages})
+		if err != nil {
+			return nil, err
+		}
+
+		if text, ok := any(output).(string); ok {
+			return []Part{TextPart(text)}, nil
+		}
+		return []Part{DataPart(output)}, nil
+	}
+}
+
+// conversationText renders the text and data parts of a message, data parts as JSON
+func conversationText(message Message) string {
+	var parts []string
+	for _, part := range message.Parts {
+		switch part.Kind {
+		case "text":
+			parts = append(parts, part.Text)
+		case "data":
+			encoded, _ := json.Marshal(part.Data)
+			parts = append(parts, string(encoded))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
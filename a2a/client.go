@@ -0,0 +1,215 @@
+package a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Client talks to a remote A2A agent
+type Client struct {
+	url        string
+	httpClient *http.Client
+	nextID     atomic.Int64
+}
+
+// NewClient creates a client for the agent served at url, httpClient defaults to one with a 60s timeout
+// Streaming requests aren't bound by the timeout of the default client, only by their context
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &Client{url: strings.TrimSuffix(url, "/"), httpClient: httpClient}
+}
+
+// Card fetches the agent card
+func (c *Client) Card(ctx context.Context) (AgentCard, error) {
+	var card AgentCard
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/.well-known/agent-card.json", nil)
+	if err != nil {
+		return card, fmt.Errorf("failed to create agent card request: %w", err)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return card, fmt.Errorf("failed to fetch agent card: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return card, fmt.Errorf("agent card request returned %s", response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&card); err != nil {
+		return card, fmt.Errorf("failed to decode agent card: %w", err)
+	}
+	return card, nil
+}
+
+// SendMessage sends a message and waits for the task it started
+func (c *Client) SendMessage(ctx context.Context, message Message) (Task, error) {
+	var task Task
+	err := c.call(ctx, "message/send", MessageSendParams{Message: message}, &task)
+	return task, err
+}
+
+// GetTask fetches the current state of a task
+func (c *Client) GetTask(ctx context.Context, id string) (Task, error) {
+	var task Task
+	err := c.call(ctx, "tasks/get", TaskIDParams{ID: id}, &task)
+	return task, err
+}
+
+// CancelTask cancels a running task
+func (c *Client) CancelTask(ctx context.Context, id string) (Task, error) {
+	var task Task
+	err := c.call(ctx, "tasks/cancel", TaskIDParams{ID: id}, &task)
+	return task, err
+}
+
+// StreamEvent is one update of message/stream, exactly one field is set
+type StreamEvent struct {
+	Task           *Task
+	StatusUpdate   *TaskStatusUpdateEvent
+	ArtifactUpdate *TaskArtifactUpdateEvent
+}
+
+// StreamMessage sends a message and calls onEvent for every update until the final status update
+// An error returned by onEvent stops the stream and is returned
+func (c *Client) StreamMessage(ctx context.Context, message Message, onEvent func(StreamEvent) error) error {
+	response, err := c.post(ctx, "message/stream", MessageSendParams{Message: message}, true)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		// errors before the stream started are plain JSON-RPC responses
+		return decodeResponse(response.Body, nil)
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var result json.RawMessage
+		if err := decodeResponse(strings.NewReader(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &result); err != nil {
+			return err
+		}
+		event, err := decodeEvent(result)
+		if err != nil {
+			return err
+		}
+		if err := onEvent(event); err != nil {
+			return err
+		}
+		if event.StatusUpdate != nil && event.StatusUpdate.Final {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read A2A stream: %w", err)
+	}
+	return fmt.Errorf("A2A stream ended before the final status update")
+}
+
+func decodeEvent(result json.RawMessage) (StreamEvent, error) {
+	var kind struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(result, &kind); err != nil {
+		return StreamEvent{}, fmt.Errorf("failed to decode A2A event: %w", err)
+	}
+
+	var event StreamEvent
+	var target any
+	switch kind.Kind {
+	case "task":
+		event.Task = &Task{}
+		target = event.Task
+	case "status-update":
+		event.StatusUpdate = &TaskStatusUpdateEvent{}
+		target = event.StatusUpdate
+	case "artifact-update":
+		event.ArtifactUpdate = &TaskArtifactUpdateEvent{}
+		target = event.ArtifactUpdate
+	default:
+		return StreamEvent{}, fmt.Errorf("unknown A2A event kind %q", kind.Kind)
+	}
+	if err := json.Unmarshal(result, target); err != nil {
+		return StreamEvent{}, fmt.Errorf("failed to decode A2A %s event: %w", kind.Kind, err)
+	}
+	return event, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	response, err := c.post(ctx, method, params, false)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return decodeResponse(response.Body, result)
+}
+
+func (c *Client) post(ctx context.Context, method string, params any, stream bool) (*http.Response, error) {
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: encodedParams})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.httpClient
+	if stream {
+		request.Header.Set("Accept", "text/event-stream")
+		withoutTimeout := *httpClient
+		withoutTimeout.Timeout = 0
+		httpClient = &withoutTimeout
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", method, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s request returned %s", method, response.Status)
+	}
+	return response, nil
+}
+
+// decodeResponse reads a JSON-RPC response into result, returning its *Error if it failed
+func decodeResponse(body io.Reader, result any) error {
+	var response rpcResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode A2A response: %w", err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to decode A2A result: %w", err)
+	}
+	return nil
+}
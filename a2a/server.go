@@ -0,0 +1,436 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Handler answers a message, history holds the earlier messages of the same context, oldest first
+// The returned parts become the task's artifact and the agent's reply
+type Handler func(ctx context.Context, message Message, history []Message) ([]Part, error)
+
+// Server serves an agent over A2A: the agent card, message/send, message/stream, tasks/get and tasks/cancel
+// Every message starts a new task in its context (a conversation), tasks and contexts are kept in memory until
+// the Retention drops them
+type Server struct {
+	card         AgentCard
+	handler      Handler
+	retention    Retention
+	maxBodyBytes int64
+	now          func() time.Time
+
+	mu       sync.Mutex
+	tasks    map[string]*taskEntry
+	contexts map[string]*contextEntry
+	swept    time.Time
+}
+
+// Retention bounds the tasks and contexts a Server keeps in memory, running tasks and their contexts are always kept
+type Retention struct {
+	// TTL drops finished tasks and idle contexts after this long (defaults to 1 hour)
+	TTL time.Duration
+	// MaxTasks is the number of finished tasks kept, the oldest are dropped first (defaults to 10000)
+	MaxTasks int
+	// MaxContexts is the number of idle contexts kept, the least recently used are dropped first (defaults to 10000)
+	MaxContexts int
+}
+
+type taskEntry struct {
+	task   Task
+	cancel context.CancelFunc
+}
+
+// contextEntry is the conversation of a context
+type contextEntry struct {
+	history []Message
+	updated time.Time
+	running int // tasks of the context that haven't finished
+}
+
+// NewServer creates an A2A server for handler, missing card fields are filled with defaults
+func NewServer(card AgentCard, handler Handler) *Server {
+	if card.ProtocolVersion == "" {
+		card.ProtocolVersion = ProtocolVersion
+	}
+	if card.Version == "" {
+		card.Version = "1.0.0"
+	}
+	if len(card.DefaultInputModes) == 0 {
+		card.DefaultInputModes = []string{"text/plain"}
+	}
+	if len(card.DefaultOutputModes) == 0 {
+		card.DefaultOutputModes = []string{"text/plain"}
+	}
+	if card.Skills == nil {
+		card.Skills = []Skill{}
+	}
+	card.Capabilities.Streaming = true
+
+	s := &Server{
+		card:         card,
+		handler:      handler,
+		maxBodyBytes: 4 << 20,
+		now:          time.Now,
+		tasks:        make(map[string]*taskEntry),
+		contexts:     make(map[string]*contextEntry),
+	}
+	return s.WithRetention(Retention{})
+}
+
+// WithRetention sets how long and how many finished tasks and idle contexts are kept
+func (s *Server) WithRetention(retention Retention) *Server {
+	if retention.TTL <= 0 {
+		retention.TTL = time.Hour
+	}
+	if retention.MaxTasks <= 0 {
+		retention.MaxTasks = 10000
+	}
+	if retention.MaxContexts <= 0 {
+		retention.MaxContexts = 10000
+	}
+	s.retention = retention
+	return s
+}
+
+// WithMaxBodyBytes limits the size of JSON-RPC requests (defaults to 4 MiB)
+func (s *Server) WithMaxBodyBytes(n int64) *Server {
+	s.maxBodyBytes = n
+	return s
+}
+
+// ServeHTTP serves the agent card on GET /.well-known/agent-card.json (and the older agent.json)
+// and JSON-RPC requests on POST
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		if strings.HasSuffix(r.URL.Path, "/.well-known/agent-card.json") || strings.HasSuffix(r.URL.Path, "/.well-known/agent.json") {
+			writeJSON(w, s.card)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request rpcRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes)).Decode(&request); err != nil {
+		writeJSON(w, rpcResponse{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}})
+		return
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		writeJSON(w, rpcResponse{JSONRPC: "2.0", ID: request.ID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC request"}})
+		return
+	}
+
+	if request.Method == "message/stream" {
+		s.stream(w, r, request)
+		return
+	}
+
+	result, err := s.dispatch(r.Context(), request)
+	writeJSON(w, response(request.ID, result, err))
+}
+
+func (s *Server) dispatch(ctx context.Context, request rpcRequest) (any, error) {
+	switch request.Method {
+	case "message/send":
+		var params MessageSendParams
+		if err := decodeParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		taskCtx, entry, err := s.start(ctx, params.Message)
+		if err != nil {
+			return nil, err
+		}
+		return s.execute(taskCtx, entry, params.Message, nil), nil
+	case "tasks/get":
+		var params TaskIDParams
+		if err := decodeParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		entry, ok := s.tasks[params.ID]
+		if !ok {
+			return nil, &Error{Code: CodeTaskNotFound, Message: fmt.Sprintf("task %q not found", params.ID)}
+		}
+		return entry.task, nil
+	case "tasks/cancel":
+		var params TaskIDParams
+		if err := decodeParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.cancel(params.ID)
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", request.Method)}
+}
+
+// stream runs message/stream, writing every JSON-RPC response as a server-sent event
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, request rpcRequest) {
+	var params MessageSendParams
+	if err := decodeParams(request.Params, &params); err != nil {
+		writeJSON(w, response(request.ID, nil, err))
+		return
+	}
+	taskCtx, entry, err := s.start(r.Context(), params.Message)
+	if err != nil {
+		writeJSON(w, response(request.ID, nil, err))
+		return
+	}
+	id := entry.task.ID
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	emit := func(event any) {
+		encoded, err := json.Marshal(response(request.ID, event, nil))
+		if err != nil {
+			slog.Error("failed to encode A2A event", "task_id", id, "error", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", encoded); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	emit(s.snapshot(entry))
+	s.execute(taskCtx, entry, params.Message, emit)
+}
+
+// start registers a submitted task for message and returns the context it runs in
+func (s *Server) start(ctx context.Context, message Message) (context.Context, *taskEntry, error) {
+	if len(message.Parts) == 0 {
+		return nil, nil, &Error{Code: CodeInvalidParams, Message: "message has no parts"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evictLocked(now)
+
+	id := newID()
+	contextID := message.ContextID
+	if contextID == "" {
+		contextID = newID()
+	}
+	message.TaskID, message.ContextID = id, contextID
+
+	conversation := s.contexts[contextID]
+	if conversation == nil {
+		conversation = &contextEntry{}
+		s.contexts[contextID] = conversation
+	}
+	conversation.running++
+	conversation.updated = now
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	entry := &taskEntry{
+		task: Task{
+			Kind:      "task",
+			ID:        id,
+			ContextID: contextID,
+			Status:    TaskStatus{State: TaskSubmitted, Timestamp: now.UTC()},
+			History:   []Message{message},
+		},
+		cancel: cancel,
+	}
+	s.tasks[id] = entry
+	return taskCtx, entry, nil
+}
+
+// execute runs the handler for a task and returns it once finished, emit receives the stream events
+// (nil for message/send)
+func (s *Server) execute(ctx context.Context, entry *taskEntry, message Message, emit func(any)) Task {
+	s.mu.Lock()
+	id, contextID := entry.task.ID, entry.task.ContextID
+	message.TaskID, message.ContextID = id, contextID
+	conversation := s.contexts[contextID]
+	history := append([]Message(nil), conversation.history...)
+	s.mu.Unlock()
+	defer entry.cancel()
+	defer func() {
+		s.mu.Lock()
+		conversation.running--
+		conversation.updated = s.now()
+		s.mu.Unlock()
+	}()
+
+	if working, ok := s.transition(entry, TaskWorking, nil); ok && emit != nil {
+		emit(statusEvent(working, false))
+	}
+
+	parts, err := s.handler(ctx, message, history)
+
+	if err != nil {
+		reply := NewMessage(RoleAgent, TextPart(err.Error()))
+		reply.TaskID, reply.ContextID = id, contextID
+		// a task canceled while running stays canceled
+		failed, _ := s.transition(entry, TaskFailed, &reply)
+		if emit != nil {
+			emit(statusEvent(failed, true))
+		}
+		return failed
+	}
+
+	reply := NewMessage(RoleAgent, parts...)
+	reply.TaskID, reply.ContextID = id, contextID
+	artifact := Artifact{ArtifactID: newID(), Name: "result", Parts: parts}
+
+	s.mu.Lock()
+	stored := !entry.task.Status.State.Terminal()
+	if stored {
+		entry.task.Artifacts = append(entry.task.Artifacts, artifact)
+		entry.task.History = append(entry.task.History, reply)
+		conversation.history = append(conversation.history, message, reply)
+	}
+	s.mu.Unlock()
+
+	if stored && emit != nil {
+		emit(TaskArtifactUpdateEvent{Kind: "artifact-update", TaskID: id, ContextID: contextID, Artifact: artifact, LastChunk: true})
+	}
+	completed, _ := s.transition(entry, TaskCompleted, &reply)
+	if emit != nil {
+		emit(statusEvent(completed, true))
+	}
+	return completed
+}
+
+// transition moves a task to state unless it already reached a terminal one, e.g. it was canceled
+// It returns the task as it is afterwards and whether it moved
+func (s *Server) transition(entry *taskEntry, state TaskState, message *Message) (Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.task.Status.State.Terminal() {
+		return entry.task, false
+	}
+	entry.task.Status = TaskStatus{State: state, Message: message, Timestamp: s.now().UTC()}
+	return entry.task, true
+}
+
+// evictLocked drops finished tasks and idle contexts past the TTL, then the oldest ones over the limits
+// It sweeps at most once every tenth of the TTL unless a limit is exceeded
+func (s *Server) evictLocked(now time.Time) {
+	r := s.retention
+	if now.Sub(s.swept) < r.TTL/10 && len(s.tasks) <= r.MaxTasks && len(s.contexts) <= r.MaxContexts {
+		return
+	}
+	s.swept = now
+
+	finished := make([]*taskEntry, 0, len(s.tasks))
+	for id, entry := range s.tasks {
+		if !entry.task.Status.State.Terminal() {
+			continue
+		}
+		if now.Sub(entry.task.Status.Timestamp) > r.TTL {
+			delete(s.tasks, id)
+			continue
+		}
+		finished = append(finished, entry)
+	}
+	if excess := len(finished) - r.MaxTasks; excess > 0 {
+		slices.SortFunc(finished, func(a, b *taskEntry) int {
+			return a.task.Status.Timestamp.Compare(b.task.Status.Timestamp)
+		})
+		for _, entry := range finished[:excess] {
+			delete(s.tasks, entry.task.ID)
+		}
+	}
+
+	idle := make([]string, 0, len(s.contexts))
+	for id, conversation := range s.contexts {
+		if conversation.running > 0 {
+			continue
+		}
+		if now.Sub(conversation.updated) > r.TTL {
+			delete(s.contexts, id)
+			continue
+		}
+		idle = append(idle, id)
+	}
+	if excess := len(idle) - r.MaxContexts; excess > 0 {
+		slices.SortFunc(idle, func(a, b string) int {
+			return s.contexts[a].updated.Compare(s.contexts[b].updated)
+		})
+		for _, id := range idle[:excess] {
+			delete(s.contexts, id)
+		}
+	}
+}
+
+func (s *Server) cancel(id string) (Task, error) {
+	s.mu.Lock()
+	entry, ok := s.tasks[id]
+	s.mu.Unlock()
+	if !ok {
+		return Task{}, &Error{Code: CodeTaskNotFound, Message: fmt.Sprintf("task %q not found", id)}
+	}
+
+	task, ok := s.transition(entry, TaskCanceled, nil)
+	if !ok {
+		return Task{}, &Error{Code: CodeTaskNotCancelable, Message: fmt.Sprintf("task %q is already %s", id, task.Status.State)}
+	}
+	entry.cancel()
+	return task, nil
+}
+
+func (s *Server) snapshot(entry *taskEntry) Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return entry.task
+}
+
+func statusEvent(task Task, final bool) TaskStatusUpdateEvent {
+	return TaskStatusUpdateEvent{Kind: "status-update", TaskID: task.ID, ContextID: task.ContextID, Status: task.Status, Final: final}
+}
+
+func decodeParams(raw json.RawMessage, params any) error {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func response(id any, result any, err error) rpcResponse {
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		return rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	}
+
+	encoded, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return rpcResponse{JSONRPC: "2.0", ID: id, Error: &Error{Code: CodeInternalError, Message: marshalErr.Error()}}
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: id, Result: encoded}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to write A2A response", "error", err)
+	}
+}
+
+func newID() string {
+	return uuid.New().String()
+}
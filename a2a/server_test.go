@@ -0,0 +1,209 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler answers with the message text and how many earlier messages the context had
+func echoHandler(_ context.Context, message Message, history []Message) ([]Part, error) {
+	if message.Text() == "fail" {
+		return nil, errors.New("cannot do that")
+	}
+	return []Part{TextPart(strings.ToUpper(message.Text())), DataPart(map[string]int{"history": len(history)})}, nil
+}
+
+func TestServeAgentCard(t *testing.T) {
+	server := httptest.NewServer(NewServer(AgentCard{Name: "echo", URL: "http://localhost"}, echoHandler))
+	defer server.Close()
+
+	card, err := NewClient(server.URL, nil).Card(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "echo", card.Name)
+	require.Equal(t, ProtocolVersion, card.ProtocolVersion)
+	require.True(t, card.Capabilities.Streaming)
+	require.Equal(t, []string{"text/plain"}, card.DefaultInputModes)
+}
+
+func TestSendMessageKeepsContext(t *testing.T) {
+	server := httptest.NewServer(NewServer(AgentCard{Name: "echo"}, echoHandler))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+	ctx := context.Background()
+
+	task, err := client.SendMessage(ctx, NewMessage(RoleUser, TextPart("hello")))
+	require.NoError(t, err)
+	require.Equal(t, TaskCompleted, task.Status.State)
+	require.Equal(t, "HELLO", task.Status.Message.Text())
+	require.Len(t, task.Artifacts, 1)
+	require.Equal(t, map[string]any{"history": float64(0)}, task.Artifacts[0].Parts[1].Data)
+	require.Len(t, task.History, 2)
+
+	followUp := NewMessage(RoleUser, TextPart("again"))
+	followUp.ContextID = task.ContextID
+	second, err := client.SendMessage(ctx, followUp)
+	require.NoError(t, err)
+	require.Equal(t, task.ContextID, second.ContextID)
+	require.NotEqual(t, task.ID, second.ID)
+	require.Equal(t, map[string]any{"history": float64(2)}, second.Artifacts[0].Parts[1].Data)
+
+	fetched, err := client.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, TaskCompleted, fetched.Status.State)
+
+	failed, err := client.SendMessage(ctx, NewMessage(RoleUser, TextPart("fail")))
+	require.NoError(t, err)
+	require.Equal(t, TaskFailed, failed.Status.State)
+	require.Equal(t, "cannot do that", failed.Status.Message.Text())
+
+	_, err = client.GetTask(ctx, "missing")
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, CodeTaskNotFound, rpcErr.Code)
+
+	_, err = client.CancelTask(ctx, task.ID)
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, CodeTaskNotCancelable, rpcErr.Code)
+}
+
+func TestStreamMessage(t *testing.T) {
+	server := httptest.NewServer(NewServer(AgentCard{Name: "echo"}, echoHandler))
+	defer server.Close()
+
+	var kinds []string
+	var states []TaskState
+	err := NewClient(server.URL, nil).StreamMessage(context.Background(), NewMessage(RoleUser, TextPart("hi")),
+		func(event StreamEvent) error {
+			switch {
+			case event.Task != nil:
+				kinds = append(kinds, "task")
+				states = append(states, event.Task.Status.State)
+			case event.StatusUpdate != nil:
+				kinds = append(kinds, "status-update")
+				states = append(states, event.StatusUpdate.Status.State)
+			case event.ArtifactUpdate != nil:
+				kinds = append(kinds, "artifact-update")
+				require.Equal(t, "HI", event.ArtifactUpdate.Artifact.Parts[0].Text)
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"task", "status-update", "artifact-update", "status-update"}, kinds)
+	require.Equal(t, []TaskState{TaskSubmitted, TaskWorking, TaskCompleted}, states)
+}
+
+func TestCancelRunningTask(t *testing.T) {
+	started := make(chan string, 1)
+	handler := func(ctx context.Context, message Message, _ []Message) ([]Part, error) {
+		started <- message.TaskID
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	server := httptest.NewServer(NewServer(AgentCard{Name: "slow"}, handler))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	done := make(chan Task, 1)
+	go func() {
+		task, err := client.SendMessage(context.Background(), NewMessage(RoleUser, TextPart("work")))
+		require.NoError(t, err)
+		done <- task
+	}()
+
+	taskID := <-started
+	canceled, err := client.CancelTask(context.Background(), taskID)
+	require.NoError(t, err)
+	require.Equal(t, TaskCanceled, canceled.Status.State)
+	require.Equal(t, TaskCanceled, (<-done).Status.State)
+}
+
+func TestUnknownMethod(t *testing.T) {
+	server := httptest.NewServer(NewServer(AgentCard{Name: "echo"}, echoHandler))
+	defer server.Close()
+
+	err := NewClient(server.URL, nil).call(context.Background(), "tasks/resubscribe", TaskIDParams{ID: "x"}, nil)
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, CodeMethodNotFound, rpcErr.Code)
+}
+
+func TestServerEvictsFinishedTasksAndIdleContexts(t *testing.T) {
+	now := time.Now()
+	s := NewServer(AgentCard{Name: "echo"}, echoHandler).WithRetention(Retention{TTL: time.Minute, MaxTasks: 2})
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	send := func(contextID string) Task {
+		message := NewMessage(RoleUser, TextPart("hello"))
+		message.ContextID = contextID
+		result, err := s.dispatch(ctx, rpcRequest{Method: "message/send", Params: mustJSON(t, MessageSendParams{Message: message})})
+		require.NoError(t, err)
+		return result.(Task)
+	}
+
+	first := send("")
+	now = now.Add(time.Second)
+	second := send(first.ContextID)
+	now = now.Add(time.Second)
+	third := send("")
+	now = now.Add(time.Second)
+	send("")
+
+	// only the two most recent finished tasks are kept
+	s.mu.Lock()
+	require.NotContains(t, s.tasks, first.ID)
+	require.Contains(t, s.tasks, second.ID)
+	require.Contains(t, s.tasks, third.ID)
+	require.Len(t, s.contexts[first.ContextID].history, 4, "a context outlives its tasks")
+	s.mu.Unlock()
+
+	// past the TTL finished tasks and idle contexts are dropped, a running task and its context stay
+	started, release := make(chan struct{}), make(chan struct{})
+	s.handler = func(context.Context, Message, []Message) ([]Part, error) {
+		close(started)
+		<-release
+		return []Part{TextPart("done")}, nil
+	}
+	now = now.Add(2 * time.Minute)
+	done := make(chan Task)
+	go func() { done <- send("") }()
+	<-started
+
+	now = now.Add(2 * time.Minute)
+	s.handler = echoHandler
+	last := send("")
+
+	s.mu.Lock()
+	require.Len(t, s.tasks, 2)
+	require.Contains(t, s.tasks, last.ID)
+	require.Len(t, s.contexts, 2)
+	require.NotContains(t, s.contexts, first.ContextID)
+	s.mu.Unlock()
+
+	close(release)
+	require.Equal(t, TaskCompleted, (<-done).Status.State)
+}
+
+func TestServerLimitsRequestBody(t *testing.T) {
+	server := httptest.NewServer(NewServer(AgentCard{Name: "echo"}, echoHandler).WithMaxBodyBytes(1024))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, nil).SendMessage(context.Background(), NewMessage(RoleUser, TextPart(strings.Repeat("a", 2048))))
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, CodeParseError, rpcErr.Code)
+	require.Contains(t, rpcErr.Message, "request body too large")
+}
+
+func mustJSON(t *testing.T, v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,171 @@
+package a2a
+
+import "time"
+
+// ProtocolVersion is the A2A protocol version served and expected by this package
+const ProtocolVersion = "0.3.0"
+
+// AgentCard describes an agent to its clients, served at /.well-known/agent-card.json
+type AgentCard struct {
+	ProtocolVersion    string       `json:"protocolVersion"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description"`
+	URL                string       `json:"url"`
+	Version            string       `json:"version"`
+	Capabilities       Capabilities `json:"capabilities"`
+	DefaultInputModes  []string     `json:"defaultInputModes"`
+	DefaultOutputModes []string     `json:"defaultOutputModes"`
+	Skills             []Skill      `json:"skills"`
+}
+
+// Capabilities lists the optional protocol features an agent supports
+type Capabilities struct {
+	Streaming         bool `json:"streaming"`
+	PushNotifications bool `json:"pushNotifications"`
+}
+
+// Skill is something the agent can do, advertised in its card
+type Skill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// Role of a message sender
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAgent Role = "agent"
+)
+
+// Message is one turn of a conversation between a client and an agent
+type Message struct {
+	Kind      string `json:"kind"` // always "message"
+	MessageID string `json:"messageId"`
+	Role      Role   `json:"role"`
+	Parts     []Part `json:"parts"`
+	TaskID    string `json:"taskId,omitempty"`
+	ContextID string `json:"contextId,omitempty"`
+}
+
+// NewMessage creates a message with a new ID
+func NewMessage(role Role, parts ...Part) Message {
+	return Message{Kind: "message", MessageID: newID(), Role: role, Parts: parts}
+}
+
+// Text joins the text parts of the message
+func (m Message) Text() string {
+	var text string
+	for _, part := range m.Parts {
+		if part.Kind == "text" {
+			if text != "" {
+				text += "\n"
+			}
+			text += part.Text
+		}
+	}
+	return text
+}
+
+// Part is a piece of message or artifact content: text, structured data or a file
+type Part struct {
+	Kind string `json:"kind"` // "text", "data" or "file"
+	Text string `json:"text,omitempty"`
+	Data any    `json:"data,omitempty"`
+	File *File  `json:"file,omitempty"`
+}
+
+// File is inline file content (base64 Bytes) or a link to it
+type File struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// TextPart creates a text part
+func TextPart(text string) Part {
+	return Part{Kind: "text", Text: text}
+}
+
+// DataPart creates a structured data part
+func DataPart(data any) Part {
+	return Part{Kind: "data", Data: data}
+}
+
+// TaskState is the lifecycle state of a task
+type TaskState string
+
+const (
+	TaskSubmitted     TaskState = "submitted"
+	TaskWorking       TaskState = "working"
+	TaskInputRequired TaskState = "input-required"
+	TaskCompleted     TaskState = "completed"
+	TaskCanceled      TaskState = "canceled"
+	TaskFailed        TaskState = "failed"
+	TaskRejected      TaskState = "rejected"
+)
+
+// Terminal reports whether a task in this state can't change anymore
+func (s TaskState) Terminal() bool {
+	switch s {
+	case TaskCompleted, TaskCanceled, TaskFailed, TaskRejected:
+		return true
+	}
+	return false
+}
+
+// TaskStatus is the state of a task with an optional message from the agent
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Artifact is an output of a task
+type Artifact struct {
+	ArtifactID string `json:"artifactId"`
+	Name       string `json:"name,omitempty"`
+	Parts      []Part `json:"parts"`
+}
+
+// Task is a unit of work an agent performs for a message
+type Task struct {
+	Kind      string     `json:"kind"` // always "task"
+	ID        string     `json:"id"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	History   []Message  `json:"history,omitempty"`
+}
+
+// TaskStatusUpdateEvent is streamed whenever a task changes state, Final marks the last event of a stream
+type TaskStatusUpdateEvent struct {
+	Kind      string     `json:"kind"` // always "status-update"
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Final     bool       `json:"final"`
+}
+
+// TaskArtifactUpdateEvent is streamed when a task produces an artifact
+type TaskArtifactUpdateEvent struct {
+	Kind      string   `json:"kind"` // always "artifact-update"
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Artifact  Artifact `json:"artifact"`
+	LastChunk bool     `json:"lastChunk"`
+}
+
+// MessageSendParams are the params of message/send and message/stream
+type MessageSendParams struct {
+	Message Message `json:"message"`
+}
+
+// TaskIDParams are the params of tasks/get and tasks/cancel
+type TaskIDParams struct {
+	ID string `json:"id"`
+}
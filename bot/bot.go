@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+)
+
+// Bot answers chat messages with an agent, keeping a conversation per session
+// Platform adapters (NewSlack, NewTelegram) post a placeholder reply, edit it while tools run and then
+// replace it with the answer
+type Bot struct {
+	agent    *kit.Agent[string]
+	sessions SessionStore
+
+	thinking        string
+	errorMessage    string
+	minEditInterval time.Duration
+	timeout         time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// New creates a bot, sessions defaults to NewMemorySessions(0)
+func New(agent *kit.Agent[string], sessions SessionStore) *Bot {
+	if sessions == nil {
+		sessions = NewMemorySessions(0)
+	}
+	return &Bot{
+		agent:           agent,
+		sessions:        sessions,
+		thinking:        "Thinking…",
+		errorMessage:    "Sorry, something went wrong. Please try again.",
+		minEditInterval: time.Second,
+		timeout:         5 * time.Minute,
+		locks:           make(map[string]*sessionLock),
+	}
+}
+
+// WithMessages sets the placeholder posted while the agent works and the reply sent when it fails
+func (b *Bot) WithMessages(thinking, errorMessage string) *Bot {
+	b.thinking = thinking
+	b.errorMessage = errorMessage
+	return b
+}
+
+// WithMinEditInterval limits how often progress edits are sent, platforms rate limit edits (defaults to 1s)
+func (b *Bot) WithMinEditInterval(interval time.Duration) *Bot {
+	b.minEditInterval = interval
+	return b
+}
+
+// WithTimeout bounds each answer (defaults to 5m)
+func (b *Bot) WithTimeout(timeout time.Duration) *Bot {
+	b.timeout = timeout
+	return b
+}
+
+// incoming is a message received by an adapter
+type incoming struct {
+	session string
+	text    string
+	files   []kit.File
+}
+
+// conversation is where an adapter posts replies
+type conversation interface {
+	post(ctx context.Context, text string) (string, error)
+	edit(ctx context.Context, ref, text string) error
+	maxLength() int
+}
+
+// reply answers a message, messages of the same session are answered one at a time
+func (b *Bot) reply(ctx context.Context, chat conversation, message incoming) {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	unlock := b.lock(message.session)
+	defer unlock()
+
+	ref, err := chat.post(ctx, b.thinking)
+	if err != nil {
+		slog.Error("failed to post bot reply", "session", message.session, "error", err)
+		return
+	}
+
+	history, err := b.sessions.Load(ctx, message.session)
+	if err != nil {
+		slog.Error("failed to load bot session", "session", message.session, "error", err)
+		b.sendEdit(ctx, chat, ref, b.errorMessage)
+		return
+	}
+
+	progress := &progressCallback{bot: b, chat: chat, ref: ref, ctx: ctx}
+	messages := append(append([]openai.ChatCompletionMessageParamUnion(nil), history...), kit.UserMessage(message.text, message.files...))
+	answer, err := b.agent.Invoke(ctx, kit.InvokeConfig{
+		Messages:  messages,
+		Callbacks: []callback.AgentCallback{progress},
+	})
+	if err != nil {
+		slog.Error("bot agent failed", "session", message.session, "error", err)
+		b.sendEdit(ctx, chat, ref, b.errorMessage)
+		return
+	}
+	if strings.TrimSpace(answer) == "" {
+		answer = "…"
+	}
+
+	chunks := splitText(answer, chat.maxLength())
+	b.sendEdit(ctx, chat, ref, chunks[0])
+	for _, chunk := range chunks[1:] {
+		if _, err := chat.post(ctx, chunk); err != nil {
+			slog.Error("failed to post bot reply", "session", message.session, "error", err)
+			break
+		}
+	}
+
+	// attachments aren't stored, only a note about them
+	userText := message.text
+	for _, file := range message.files {
+		name := file.Name
+		if name == "" {
+			name = "image"
+		}
+		userText += fmt.Sprintf("\n[attached %s]", name)
+	}
+	history = append(history, openai.UserMessage(userText), openai.AssistantMessage(answer))
+	if err := b.sessions.Save(ctx, message.session, history); err != nil {
+		slog.Error("failed to save bot session", "session", message.session, "error", err)
+	}
+}
+
+func (b *Bot) sendEdit(ctx context.Context, chat conversation, ref, text string) {
+	if err := chat.edit(ctx, ref, text); err != nil {
+		slog.Error("failed to edit bot reply", "error", err)
+	}
+}
+
+// lock serializes the answers of a session
+func (b *Bot) lock(session string) func() {
+	b.mu.Lock()
+	l, ok := b.locks[session]
+	if !ok {
+		l = &sessionLock{}
+		b.locks[session] = l
+	}
+	l.refs++
+	b.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		b.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(b.locks, session)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// progressCallback edits the placeholder reply when the agent calls a tool
+type progressCallback struct {
+	callback.BaseCallback
+
+	bot      *Bot
+	chat     conversation
+	ref      string
+	ctx      context.Context
+	lastEdit time.Time
+}
+
+func (p *progressCallback) Name() string {
+	return "BotProgress"
+}
+
+func (p *progressCallback) OnToolCallStart(ctx map[string]interface{}) {
+	if time.Since(p.lastEdit) < p.bot.minEditInterval {
+		return
+	}
+	toolName, _ := ctx["tool_name"].(string)
+	p.lastEdit = time.Now()
+	p.bot.sendEdit(p.ctx, p.chat, p.ref, fmt.Sprintf("%s (using %s)", p.bot.thinking, toolName))
+}
+
+// splitText cuts text into chunks of at most maxLength characters, preferring line and word boundaries
+func splitText(text string, maxLength int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(text) > maxLength {
+		runes := []rune(text)
+		cut := string(runes[:maxLength])
+		if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+			cut = cut[:i]
+		} else if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+		chunks = append(chunks, strings.TrimRight(cut, " \n"))
+		text = strings.TrimLeft(text[len(cut):], " \n")
+	}
+	return append(chunks, text)
+}
@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM is an OpenAI compatible server answering from a script, a reply starting with "tool:" calls that tool
+type fakeLLM struct {
+	mu       sync.Mutex
+	replies  []string
+	requests []string
+}
+
+func (f *fakeLLM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.requests = append(f.requests, string(body))
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	f.mu.Unlock()
+
+	message := map[string]any{"role": "assistant", "content": reply}
+	finishReason := "stop"
+	if name, ok := strings.CutPrefix(reply, "tool:"); ok {
+		message = map[string]any{"role": "assistant", "tool_calls": []map[string]any{{
+			"id": "call_1", "type": "function", "function": map[string]any{"name": name, "arguments": "{}"},
+		}}}
+		finishReason = "tool_calls"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"id": "chatcmpl-test", "object": "chat.completion", "model": "test-model",
+		"choices": []map[string]any{{"index": 0, "finish_reason": finishReason, "message": message}},
+	})
+}
+
+func (f *fakeLLM) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+type lookupTool struct {
+	kit.BaseTool
+}
+
+func (t *lookupTool) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{Name: "lookup", Description: "Look something up"}
+}
+
+func (t *lookupTool) Execute(*kit.Context) (any, error) {
+	return "found", nil
+}
+
+func newTestBot(t *testing.T, replies ...string) (*Bot, *fakeLLM) {
+	llm := &fakeLLM{replies: replies}
+	server := httptest.NewServer(llm)
+	t.Cleanup(server.Close)
+
+	client := kit.NewClient(kit.WithAPIKey("test"), kit.WithBaseURL(server.URL), kit.WithDefaultModel("test-model"))
+	return New(kit.CreateAgent(client, &lookupTool{}), nil).WithMinEditInterval(0), llm
+}
+
+// apiCall is a request received by a fake platform API
+type apiCall struct {
+	Method  string
+	Payload map[string]any
+}
+
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []apiCall
+}
+
+func (f *fakeAPI) record(method string, r *http.Request) {
+	var payload map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	f.mu.Lock()
+	f.calls = append(f.calls, apiCall{Method: method, Payload: payload})
+	f.mu.Unlock()
+}
+
+func (f *fakeAPI) Calls() []apiCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]apiCall(nil), f.calls...)
+}
+
+func TestMemorySessionsTrimToUserMessage(t *testing.T) {
+	sessions := NewMemorySessions(3)
+	ctx := context.Background()
+
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.UserMessage("u1"), openai.AssistantMessage("a1"), openai.UserMessage("u2"), openai.AssistantMessage("a2"),
+	}
+	require.NoError(t, sessions.Save(ctx, "s", messages))
+
+	loaded, err := sessions.Load(ctx, "s")
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	require.NotNil(t, loaded[0].OfUser)
+}
+
+func TestSplitText(t *testing.T) {
+	require.Equal(t, []string{"short"}, splitText("short", 10))
+	require.Equal(t, []string{"first line", "second line"}, splitText("first line\nsecond line", 12))
+	require.Equal(t, []string{"aaaaa", "bbbbb"}, splitText("aaaaabbbbb", 5))
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// defaultMaxFileBytes caps the size of a downloaded attachment
+const defaultMaxFileBytes = 20 << 20
+
+// download fetches an attachment, header adds authentication when the platform needs it
+func download(ctx context.Context, client *http.Client, url string, header http.Header, maxBytes int64) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file request: %w", err)
+	}
+	for k, v := range header {
+		request.Header[k] = v
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("file download returned %s", response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+// toFile converts an attachment the agent can read (images and PDFs), ok is false for other types
+func toFile(name, mimeType string, data []byte) (kit.File, bool) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		file := kit.FileImage(mimeType, data)
+		file.Name = name
+		return file, true
+	case mimeType == "application/pdf":
+		return kit.FilePDF(name, data), true
+	}
+	slog.Debug("skipping unsupported bot attachment", "name", name, "mime_type", mimeType)
+	return kit.File{}, false
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openai/openai-go"
+)
+
+// SessionStore keeps the conversation of every session (a chat, channel or thread)
+type SessionStore interface {
+	Load(ctx context.Context, key string) ([]openai.ChatCompletionMessageParamUnion, error)
+	Save(ctx context.Context, key string, messages []openai.ChatCompletionMessageParamUnion) error
+}
+
+// MemorySessions keeps conversations in process memory
+// Conversations are lost on restart, implement SessionStore on a database when they must survive it
+type MemorySessions struct {
+	mu          sync.Mutex
+	sessions    map[string][]openai.ChatCompletionMessageParamUnion
+	maxMessages int
+}
+
+// NewMemorySessions creates an in-memory store keeping the last maxMessages messages of each session (defaults to 50)
+func NewMemorySessions(maxMessages int) *MemorySessions {
+	if maxMessages <= 0 {
+		maxMessages = 50
+	}
+	return &MemorySessions{
+		sessions:    make(map[string][]openai.ChatCompletionMessageParamUnion),
+		maxMessages: maxMessages,
+	}
+}
+
+func (m *MemorySessions) Load(_ context.Context, key string) ([]openai.ChatCompletionMessageParamUnion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]openai.ChatCompletionMessageParamUnion(nil), m.sessions[key]...), nil
+}
+
+func (m *MemorySessions) Save(_ context.Context, key string, messages []openai.ChatCompletionMessageParamUnion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(messages) > m.maxMessages {
+		messages = messages[len(messages)-m.maxMessages:]
+	}
+	// a trimmed conversation still starts with a user message
+	for len(messages) > 0 && messages[0].OfUser == nil {
+		messages = messages[1:]
+	}
+	m.sessions[key] = append([]openai.ChatCompletionMessageParamUnion(nil), messages...)
+	return nil
+}
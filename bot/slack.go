@@ -0,0 +1,261 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// SlackConfig configures a Slack Events API adapter
+type SlackConfig struct {
+	// BotToken is the bot user OAuth token (xoxb-...) used to post messages and download files (required)
+	BotToken string
+
+	// SigningSecret verifies that requests come from Slack (required)
+	SigningSecret string
+
+	// SessionKey maps a channel and thread to a session (optional, defaults to one session per channel,
+	// and one per thread for messages in threads)
+	SessionKey func(channel, threadTS string) string
+
+	// APIURL is the Slack Web API base URL (optional, defaults to https://slack.com/api)
+	APIURL string
+
+	// HTTPClient calls the Web API (optional, defaults to a client with a 30s timeout)
+	HTTPClient *http.Client
+
+	// MaxFileBytes skips larger attachments (optional, defaults to 20MB)
+	MaxFileBytes int64
+}
+
+// Slack answers direct messages and mentions received from the Slack Events API
+// Subscribe the app to message.im and app_mention events and point the request URL at it
+type Slack struct {
+	bot    *Bot
+	config SlackConfig
+	wg     sync.WaitGroup
+}
+
+// NewSlack creates a Slack adapter for b
+func NewSlack(b *Bot, config SlackConfig) (*Slack, error) {
+	if config.BotToken == "" || config.SigningSecret == "" {
+		return nil, fmt.Errorf("slack BotToken and SigningSecret are required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = "https://slack.com/api"
+	}
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = defaultMaxFileBytes
+	}
+	if config.SessionKey == nil {
+		config.SessionKey = func(channel, threadTS string) string {
+			if threadTS != "" {
+				return "slack:" + channel + ":" + threadTS
+			}
+			return "slack:" + channel
+		}
+	}
+	return &Slack{bot: b, config: config}, nil
+}
+
+type slackEnvelope struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge"`
+	Event     slackEvent `json:"event"`
+}
+
+type slackEvent struct {
+	Type        string      `json:"type"`
+	Subtype     string      `json:"subtype"`
+	BotID       string      `json:"bot_id"`
+	Text        string      `json:"text"`
+	Channel     string      `json:"channel"`
+	ChannelType string      `json:"channel_type"`
+	TS          string      `json:"ts"`
+	ThreadTS    string      `json:"thread_ts"`
+	Files       []slackFile `json:"files"`
+}
+
+type slackFile struct {
+	Name               string `json:"name"`
+	Mimetype           string `json:"mimetype"`
+	URLPrivateDownload string `json:"url_private_download"`
+}
+
+var slackMention = regexp.MustCompile(`<@[A-Z0-9]+>\s*`)
+
+// ServeHTTP verifies and acknowledges an event right away and answers it in the background
+func (s *Slack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !s.verify(r.Header.Get("X-Slack-Request-Timestamp"), body, r.Header.Get("X-Slack-Signature")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var envelope slackEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	if envelope.Type == "url_verification" {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(envelope.Challenge))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	// Slack retries events it didn't get an answer for in 3 seconds, they were already handled
+	if envelope.Type != "event_callback" || r.Header.Get("X-Slack-Retry-Num") != "" {
+		return
+	}
+
+	event := envelope.Event
+	if event.BotID != "" || (event.Subtype != "" && event.Subtype != "file_share") {
+		return
+	}
+	// mentions in channels also arrive as message events, only direct messages are answered from those
+	if event.Type == "message" && event.ChannelType != "im" {
+		return
+	}
+	if event.Type != "message" && event.Type != "app_mention" {
+		return
+	}
+
+	threadTS := event.ThreadTS
+	if threadTS == "" && event.ChannelType != "im" {
+		threadTS = event.TS
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx := context.Background()
+		chat := &slackConversation{slack: s, channel: event.Channel, threadTS: threadTS}
+		s.bot.reply(ctx, chat, incoming{
+			session: s.config.SessionKey(event.Channel, threadTS),
+			text:    strings.TrimSpace(slackMention.ReplaceAllString(event.Text, "")),
+			files:   s.files(ctx, event.Files),
+		})
+	}()
+}
+
+// Wait blocks until every event received so far is answered
+func (s *Slack) Wait() {
+	s.wg.Wait()
+}
+
+// verify checks the v0 signature Slack computes over "v0:<timestamp>:<body>", rejecting requests older than 5 minutes
+func (s *Slack) verify(timestamp string, body []byte, signature string) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(seconds, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.config.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *Slack) files(ctx context.Context, files []slackFile) []kit.File {
+	var converted []kit.File
+	header := http.Header{"Authorization": {"Bearer " + s.config.BotToken}}
+	for _, f := range files {
+		data, err := download(ctx, s.config.HTTPClient, f.URLPrivateDownload, header, s.config.MaxFileBytes)
+		if err != nil {
+			slog.Warn("failed to download slack file", "name", f.Name, "error", err)
+			continue
+		}
+		if file, ok := toFile(f.Name, f.Mimetype, data); ok {
+			converted = append(converted, file)
+		}
+	}
+	return converted
+}
+
+// call invokes a Web API method and decodes its response into result
+func (s *Slack) call(ctx context.Context, method string, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", method, err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.APIURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	request.Header.Set("Authorization", "Bearer "+s.config.BotToken)
+
+	response, err := s.config.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	defer response.Body.Close()
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	raw, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s failed: %s", method, status.Error)
+	}
+	if result != nil {
+		return json.Unmarshal(raw, result)
+	}
+	return nil
+}
+
+type slackConversation struct {
+	slack    *Slack
+	channel  string
+	threadTS string
+}
+
+func (c *slackConversation) post(ctx context.Context, text string) (string, error) {
+	payload := map[string]any{"channel": c.channel, "text": text}
+	if c.threadTS != "" {
+		payload["thread_ts"] = c.threadTS
+	}
+	var result struct {
+		TS string `json:"ts"`
+	}
+	err := c.slack.call(ctx, "chat.postMessage", payload, &result)
+	return result.TS, err
+}
+
+func (c *slackConversation) edit(ctx context.Context, ts, text string) error {
+	return c.slack.call(ctx, "chat.update", map[string]any{"channel": c.channel, "ts": ts, "text": text}, nil)
+}
+
+func (c *slackConversation) maxLength() int {
+	return 4000
+}
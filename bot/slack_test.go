@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeSlack(t *testing.T) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{}
+	var posted int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/chart.png" {
+			require.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte("png-bytes"))
+			return
+		}
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		api.record(method, r)
+		if method == "chat.postMessage" {
+			posted++
+			_, _ = fmt.Fprintf(w, `{"ok":true,"ts":"200.%d"}`, posted)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func signedSlackRequest(body string) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("signing-secret"))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	request := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(body))
+	request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	request.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return request
+}
+
+func TestSlackAnswersMentionsInThreads(t *testing.T) {
+	b, llm := newTestBot(t, "tool:lookup", "The chart shows growth.", "Still growth.")
+	api, server := newFakeSlack(t)
+	slack, err := NewSlack(b, SlackConfig{BotToken: "xoxb-test", SigningSecret: "signing-secret", APIURL: server.URL})
+	require.NoError(t, err)
+
+	mention := fmt.Sprintf(`{"type":"event_callback","event":{"type":"app_mention","text":"<@U123> what does this show?",
+		"channel":"C1","channel_type":"channel","ts":"100.1","files":[{"name":"chart.png","mimetype":"image/png",
+		"url_private_download":"%s/files/chart.png"}]}}`, server.URL)
+	recorder := httptest.NewRecorder()
+	slack.ServeHTTP(recorder, signedSlackRequest(mention))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	slack.Wait()
+
+	calls := api.Calls()
+	require.Equal(t, "chat.postMessage", calls[0].Method)
+	require.Equal(t, "Thinking…", calls[0].Payload["text"])
+	require.Equal(t, "100.1", calls[0].Payload["thread_ts"])
+	require.Equal(t, "Thinking… (using lookup)", calls[1].Payload["text"])
+	require.Equal(t, "chat.update", calls[2].Method)
+	require.Equal(t, "The chart shows growth.", calls[2].Payload["text"])
+	require.Equal(t, "200.1", calls[2].Payload["ts"])
+
+	first := llm.Requests()[0]
+	require.Contains(t, first, `"text":"what does this show?"`)
+	require.Contains(t, first, "data:image/png;base64,")
+
+	// a reply in the thread continues the thread's session
+	reply := `{"type":"event_callback","event":{"type":"app_mention","text":"<@U123> and now?","channel":"C1",
+		"channel_type":"channel","ts":"100.5","thread_ts":"100.1"}}`
+	slack.ServeHTTP(httptest.NewRecorder(), signedSlackRequest(reply))
+	slack.Wait()
+	require.Len(t, llm.Requests(), 3)
+	require.Contains(t, llm.Requests()[2], "The chart shows growth.")
+	require.Contains(t, llm.Requests()[2], "[attached chart.png]")
+}
+
+func TestSlackVerification(t *testing.T) {
+	b, _ := newTestBot(t)
+	slack, err := NewSlack(b, SlackConfig{BotToken: "xoxb-test", SigningSecret: "signing-secret"})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	slack.ServeHTTP(recorder, signedSlackRequest(`{"type":"url_verification","challenge":"abc"}`))
+	require.Equal(t, "abc", recorder.Body.String())
+
+	request := signedSlackRequest(`{"type":"url_verification","challenge":"abc"}`)
+	request.Header.Set("X-Slack-Signature", "v0=bad")
+	recorder = httptest.NewRecorder()
+	slack.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// bot messages and channel messages without a mention are ignored
+	for _, event := range []string{
+		`{"type":"event_callback","event":{"type":"message","bot_id":"B1","text":"hi","channel":"D1","channel_type":"im"}}`,
+		`{"type":"event_callback","event":{"type":"message","text":"hi","channel":"C1","channel_type":"channel"}}`,
+	} {
+		recorder = httptest.NewRecorder()
+		slack.ServeHTTP(recorder, signedSlackRequest(event))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+	slack.Wait()
+}
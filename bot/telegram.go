@@ -0,0 +1,286 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// TelegramConfig configures a Telegram Bot API webhook adapter
+type TelegramConfig struct {
+	// Token is the bot token from BotFather (required)
+	Token string
+
+	// SecretToken must match the secret_token passed to setWebhook (optional but recommended)
+	SecretToken string
+
+	// SessionKey maps a chat and forum topic to a session (optional, defaults to one session per chat and topic)
+	SessionKey func(chatID int64, threadID int64) string
+
+	// APIURL is the Bot API base URL (optional, defaults to https://api.telegram.org)
+	APIURL string
+
+	// HTTPClient calls the Bot API (optional, defaults to a client with a 30s timeout)
+	HTTPClient *http.Client
+
+	// MaxFileBytes skips larger attachments (optional, defaults to 20MB, the Bot API download limit)
+	MaxFileBytes int64
+}
+
+// Telegram answers messages received on a Telegram webhook
+type Telegram struct {
+	bot    *Bot
+	config TelegramConfig
+	wg     sync.WaitGroup
+}
+
+// NewTelegram creates a Telegram adapter for b
+func NewTelegram(b *Bot, config TelegramConfig) (*Telegram, error) {
+	if config.Token == "" {
+		return nil, fmt.Errorf("telegram Token is required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = "https://api.telegram.org"
+	}
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = defaultMaxFileBytes
+	}
+	if config.SessionKey == nil {
+		config.SessionKey = func(chatID int64, threadID int64) string {
+			key := "telegram:" + strconv.FormatInt(chatID, 10)
+			if threadID != 0 {
+				key += ":" + strconv.FormatInt(threadID, 10)
+			}
+			return key
+		}
+	}
+	return &Telegram{bot: b, config: config}, nil
+}
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	MessageID       int64               `json:"message_id"`
+	MessageThreadID int64               `json:"message_thread_id"`
+	Chat            telegramChat        `json:"chat"`
+	From            *telegramUser       `json:"from"`
+	Text            string              `json:"text"`
+	Caption         string              `json:"caption"`
+	Photo           []telegramPhotoSize `json:"photo"`
+	Document        *telegramDocument   `json:"document"`
+}
+
+type telegramChat struct {
+	ID int64 `json:"id"`
+}
+
+type telegramUser struct {
+	IsBot bool `json:"is_bot"`
+}
+
+type telegramPhotoSize struct {
+	FileID string `json:"file_id"`
+	Width  int    `json:"width"`
+}
+
+type telegramDocument struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+}
+
+// ServeHTTP acknowledges an update right away and answers it in the background
+func (t *Telegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if t.config.SecretToken != "" {
+		got := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(t.config.SecretToken)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var update telegramUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&update); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	message := update.Message
+	if message == nil || (message.From != nil && message.From.IsBot) {
+		return
+	}
+	text := message.Text
+	if text == "" {
+		text = message.Caption
+	}
+	if text == "" && len(message.Photo) == 0 && message.Document == nil {
+		return
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ctx := context.Background()
+		chat := &telegramConversation{telegram: t, chatID: message.Chat.ID, threadID: message.MessageThreadID, replyTo: message.MessageID}
+		t.bot.reply(ctx, chat, incoming{
+			session: t.config.SessionKey(message.Chat.ID, message.MessageThreadID),
+			text:    text,
+			files:   t.files(ctx, message),
+		})
+	}()
+}
+
+// Wait blocks until every update received so far is answered
+func (t *Telegram) Wait() {
+	t.wg.Wait()
+}
+
+func (t *Telegram) files(ctx context.Context, message *telegramMessage) []kit.File {
+	type attachment struct{ fileID, name, mimeType string }
+	var attachments []attachment
+	if len(message.Photo) > 0 {
+		// photos come in several sizes, the largest is last
+		attachments = append(attachments, attachment{fileID: message.Photo[len(message.Photo)-1].FileID, name: "photo.jpg", mimeType: "image/jpeg"})
+	}
+	if message.Document != nil {
+		attachments = append(attachments, attachment{fileID: message.Document.FileID, name: message.Document.FileName, mimeType: message.Document.MimeType})
+	}
+
+	var converted []kit.File
+	for _, a := range attachments {
+		var file struct {
+			FilePath string `json:"file_path"`
+		}
+		if err := t.call(ctx, "getFile", map[string]any{"file_id": a.fileID}, &file); err != nil {
+			slog.Warn("failed to locate telegram file", "name", a.name, "error", err)
+			continue
+		}
+		url := fmt.Sprintf("%s/file/bot%s/%s", t.config.APIURL, t.config.Token, file.FilePath)
+		data, err := download(ctx, t.config.HTTPClient, url, nil, t.config.MaxFileBytes)
+		if err != nil {
+			slog.Warn("failed to download telegram file", "name", a.name, "error", err)
+			continue
+		}
+		if f, ok := toFile(a.name, a.mimeType, data); ok {
+			converted = append(converted, f)
+		}
+	}
+	return converted
+}
+
+// call invokes a Bot API method and decodes its result
+func (t *Telegram) call(ctx context.Context, method string, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", method, err)
+	}
+	url := fmt.Sprintf("%s/bot%s/%s", t.config.APIURL, t.config.Token, method)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := t.config.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	defer response.Body.Close()
+
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, envelope.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(envelope.Result, result)
+	}
+	return nil
+}
+
+type telegramConversation struct {
+	telegram *Telegram
+	chatID   int64
+	threadID int64
+	replyTo  int64
+
+	mu       sync.Mutex
+	lastText map[string]string // Telegram rejects edits that don't change the text
+}
+
+func (c *telegramConversation) post(ctx context.Context, text string) (string, error) {
+	payload := map[string]any{"chat_id": c.chatID, "text": text}
+	if c.threadID != 0 {
+		payload["message_thread_id"] = c.threadID
+	}
+	if c.replyTo != 0 {
+		payload["reply_parameters"] = map[string]any{"message_id": c.replyTo, "allow_sending_without_reply": true}
+	}
+	var result struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if err := c.telegram.call(ctx, "sendMessage", payload, &result); err != nil {
+		return "", err
+	}
+
+	ref := strconv.FormatInt(result.MessageID, 10)
+	c.remember(ref, text)
+	return ref, nil
+}
+
+func (c *telegramConversation) edit(ctx context.Context, ref, text string) error {
+	c.mu.Lock()
+	unchanged := c.lastText[ref] == text
+	c.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	messageID, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram message reference %q", ref)
+	}
+	err = c.telegram.call(ctx, "editMessageText", map[string]any{"chat_id": c.chatID, "message_id": messageID, "text": text}, nil)
+	if err == nil {
+		c.remember(ref, text)
+	}
+	return err
+}
+
+func (c *telegramConversation) remember(ref, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastText == nil {
+		c.lastText = make(map[string]string)
+	}
+	c.lastText[ref] = text
+}
+
+func (c *telegramConversation) maxLength() int {
+	return 4096
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramAnswersAndSplitsLongReplies(t *testing.T) {
+	long := strings.Repeat("word ", 1000)
+	b, llm := newTestBot(t, long)
+
+	api := &fakeAPI{}
+	var sent int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/file/bot123:abc/docs/report.pdf" {
+			_, _ = w.Write([]byte("%PDF"))
+			return
+		}
+		method := strings.TrimPrefix(r.URL.Path, "/bot123:abc/")
+		api.record(method, r)
+		switch method {
+		case "sendMessage":
+			sent++
+			_, _ = fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, 50+sent)
+		case "getFile":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"file_path":"docs/report.pdf"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer server.Close()
+
+	telegram, err := NewTelegram(b, TelegramConfig{Token: "123:abc", SecretToken: "s3cret", APIURL: server.URL})
+	require.NoError(t, err)
+
+	update := `{"update_id":1,"message":{"message_id":7,"chat":{"id":42},"from":{"is_bot":false},"caption":"summarize",
+		"document":{"file_id":"f1","file_name":"report.pdf","mime_type":"application/pdf"}}}`
+	request := httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(update))
+	request.Header.Set("X-Telegram-Bot-Api-Secret-Token", "s3cret")
+	recorder := httptest.NewRecorder()
+	telegram.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	telegram.Wait()
+
+	calls := api.Calls()
+	require.Equal(t, "getFile", calls[0].Method)
+	require.Equal(t, "sendMessage", calls[1].Method)
+	require.Equal(t, float64(7), calls[1].Payload["reply_parameters"].(map[string]any)["message_id"])
+	require.Equal(t, "editMessageText", calls[2].Method)
+	require.Equal(t, float64(51), calls[2].Payload["message_id"])
+	require.LessOrEqual(t, len(calls[2].Payload["text"].(string)), 4096)
+	require.Equal(t, "sendMessage", calls[3].Method)
+	require.Len(t, calls, 4)
+
+	require.Contains(t, llm.Requests()[0], `"filename":"report.pdf"`)
+
+	request = httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(update))
+	recorder = httptest.NewRecorder()
+	telegram.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
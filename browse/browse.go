@@ -0,0 +1,268 @@
+// Package browse fetches web pages for agents and extracts their readable text and metadata
+package browse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ErrDisallowed is returned when robots.txt doesn't allow fetching a URL
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// ErrPrivateAddress is returned when a URL resolves to a loopback, private or link-local address
+var ErrPrivateAddress = errors.New("private network addresses are not allowed")
+
+// Screenshotter renders a page in a headless browser, e.g. with chromedp
+type Screenshotter interface {
+	Screenshot(ctx context.Context, url string) ([]byte, error)
+}
+
+// Config configures a Browser
+type Config struct {
+	// UserAgent is sent with every request and matched against robots.txt groups (optional, defaults to "goai-kit")
+	UserAgent string
+
+	// Timeout bounds a fetch including redirects and the robots.txt check (optional, defaults to 15s)
+	Timeout time.Duration
+
+	// MaxBytes caps the size of a downloaded page (optional, defaults to 2MB)
+	MaxBytes int64
+
+	// MaxChars caps the extracted text, longer text is cut and Page.Truncated is set (optional, defaults to 20000)
+	MaxChars int
+
+	// IgnoreRobots skips the robots.txt check (optional)
+	IgnoreRobots bool
+
+	// AllowPrivate allows URLs resolving to loopback, private or link-local addresses (optional)
+	// They are refused by default so an agent can't be steered into the internal network
+	AllowPrivate bool
+
+	// Screenshotter takes a screenshot of every fetched page (optional)
+	Screenshotter Screenshotter
+
+	// Transport is the HTTP transport (optional, defaults to a clone of http.DefaultTransport)
+	// The private address check is done by the default transport's dialer, a custom transport must do its own
+	Transport http.RoundTripper
+}
+
+// Page is the readable content of a fetched page
+type Page struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+	Published   string `json:"published,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Text        string `json:"text"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Links       []Link `json:"links,omitempty"`
+	Screenshot  []byte `json:"-"`
+}
+
+// Link is a link found in the main content of a page
+type Link struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Browser fetches pages, it's safe for concurrent use
+type Browser struct {
+	config Config
+	client *http.Client
+
+	mu     sync.Mutex
+	robots map[string]*robotsRules // by scheme://host
+}
+
+// New creates a browser
+func New(config Config) *Browser {
+	if config.UserAgent == "" {
+		config.UserAgent = "goai-kit"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 2 << 20
+	}
+	if config.MaxChars <= 0 {
+		config.MaxChars = 20000
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		if !config.AllowPrivate {
+			// checking the dialed address also covers redirects and DNS rebinding
+			dialer := &net.Dialer{Timeout: 10 * time.Second, Control: refusePrivate}
+			defaultTransport.DialContext = dialer.DialContext
+		}
+		transport = defaultTransport
+	}
+
+	return &Browser{
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return fmt.Errorf("stopped after 5 redirects")
+				}
+				return checkScheme(req.URL)
+			},
+		},
+		robots: make(map[string]*robotsRules),
+	}
+}
+
+// Fetch downloads a page and extracts its readable content
+// HTML pages are reduced to their main content, plain text and JSON are returned as is
+func (b *Browser) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.config.Timeout)
+	defer cancel()
+
+	if !b.config.IgnoreRobots {
+		allowed, err := b.allowed(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("fetching %s: %w", target, ErrDisallowed)
+		}
+	}
+
+	response, err := b.get(ctx, target.String(), "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("fetching %s: %s", target, response.Status)
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "text/html"
+	}
+	if !readable(mediaType) {
+		return nil, fmt.Errorf("fetching %s: unsupported content type %q", target, mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(response.Body, b.config.MaxBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", target, err)
+	}
+
+	page := &Page{
+		URL:         response.Request.URL.String(),
+		StatusCode:  response.StatusCode,
+		ContentType: mediaType,
+	}
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		if err := extract(body, response.Request.URL, page); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", target, err)
+		}
+	} else {
+		text, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		page.Text = strings.TrimSpace(string(text))
+	}
+	page.Text, page.Truncated = truncate(page.Text, b.config.MaxChars)
+
+	if b.config.Screenshotter != nil {
+		page.Screenshot, err = b.config.Screenshotter.Screenshot(ctx, page.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to screenshot %s: %w", page.URL, err)
+		}
+	}
+	return page, nil
+}
+
+func (b *Browser) get(ctx context.Context, target string, accept string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("User-Agent", b.config.UserAgent)
+	request.Header.Set("Accept", accept)
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", target, err)
+	}
+	return response, nil
+}
+
+func readable(mediaType string) bool {
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "text/plain", "text/markdown", "application/json":
+		return true
+	}
+	return false
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// refusePrivate is a dialer control refusing connections to non public addresses
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+	}
+	return nil
+}
+
+// truncate cuts text to at most maxChars runes, preferring a paragraph or word boundary
+func truncate(text string, maxChars int) (string, bool) {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text, false
+	}
+
+	cut := string(runes[:maxChars])
+	if i := strings.LastIndex(cut, "\n\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	} else if i := strings.LastIndexAny(cut, " \n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut), true
+}
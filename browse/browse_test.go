@@ -0,0 +1,141 @@
+package browse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const articlePage = `<!doctype html>
+<html lang="en">
+<head>
+	<title>Tides explained | Ocean Notes</title>
+	<meta name="description" content="Why the sea rises and falls twice a day">
+	<meta property="og:site_name" content="Ocean Notes">
+	<meta property="og:image" content="/img/tides.png">
+	<meta name="author" content="Jane Doe">
+	<meta property="article:published_time" content="2024-05-01">
+	<script>track()</script>
+</head>
+<body>
+	<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+	<div class="layout">
+		<div class="sidebar"><p>Subscribe to our newsletter for more ocean facts every week!</p></div>
+		<div class="post">
+			<h1>Tides explained</h1>
+			<p>Tides are caused by the <a href="/gravity">gravity</a> of the Moon and the Sun pulling on the oceans.
+			The Moon is much closer, so it has the larger effect.</p>
+			<p>Most coasts see two high tides and two low tides every lunar day, which lasts about 24 hours and 50 minutes.
+			The exact pattern depends on the shape of the coastline and the depth of the water.</p>
+			<ul><li>Spring tides happen at new and full moon</li><li>Neap tides happen at the quarter moons</li></ul>
+			<div class="share-buttons"><a href="https://social.example/share">Share</a></div>
+		</div>
+	</div>
+	<footer>Copyright Ocean Notes</footer>
+</body>
+</html>`
+
+func newTestSite(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\nAllow: /private/press$\n\nUser-agent: badbot\nDisallow: /\n"))
+		case "/tides":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(articlePage))
+		case "/old":
+			http.Redirect(w, r, "/tides", http.StatusMovedPermanently)
+		case "/notes.txt", "/private/press":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("  plain notes  "))
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchExtractsReadableContent(t *testing.T) {
+	site := newTestSite(t)
+	browser := New(Config{AllowPrivate: true})
+
+	page, err := browser.Fetch(context.Background(), site.URL+"/old")
+	require.NoError(t, err)
+	require.Equal(t, site.URL+"/tides", page.URL)
+	require.Equal(t, "Tides explained | Ocean Notes", page.Title)
+	require.Equal(t, "Why the sea rises and falls twice a day", page.Description)
+	require.Equal(t, "Jane Doe", page.Author)
+	require.Equal(t, "Ocean Notes", page.SiteName)
+	require.Equal(t, "2024-05-01", page.Published)
+	require.Equal(t, "en", page.Language)
+	require.Equal(t, site.URL+"/img/tides.png", page.Image)
+
+	require.True(t, strings.HasPrefix(page.Text, "# Tides explained\n\nTides are caused by the gravity of the Moon"), page.Text)
+	require.Contains(t, page.Text, "- Spring tides happen at new and full moon\n- Neap tides happen at the quarter moons")
+	for _, chrome := range []string{"Home", "newsletter", "Share", "Copyright", "track()"} {
+		require.NotContains(t, page.Text, chrome)
+	}
+	require.Equal(t, []Link{{Text: "gravity", URL: site.URL + "/gravity"}}, page.Links)
+
+	page, err = browser.Fetch(context.Background(), site.URL+"/notes.txt")
+	require.NoError(t, err)
+	require.Equal(t, "plain notes", page.Text)
+
+	_, err = browser.Fetch(context.Background(), site.URL+"/report.pdf")
+	require.ErrorContains(t, err, "unsupported content type")
+
+	_, err = browser.Fetch(context.Background(), site.URL+"/missing")
+	require.ErrorContains(t, err, "404")
+
+	_, err = browser.Fetch(context.Background(), "file:///etc/passwd")
+	require.ErrorContains(t, err, "unsupported URL scheme")
+}
+
+func TestFetchTruncatesLongText(t *testing.T) {
+	site := newTestSite(t)
+	page, err := New(Config{AllowPrivate: true, MaxChars: 80}).Fetch(context.Background(), site.URL+"/tides")
+	require.NoError(t, err)
+	require.True(t, page.Truncated)
+	require.Equal(t, "# Tides explained\n\nTides are caused by the gravity of the Moon and the Sun", page.Text)
+}
+
+func TestFetchRespectsRobots(t *testing.T) {
+	site := newTestSite(t)
+	browser := New(Config{AllowPrivate: true})
+
+	_, err := browser.Fetch(context.Background(), site.URL+"/private/data")
+	require.ErrorIs(t, err, ErrDisallowed)
+
+	page, err := browser.Fetch(context.Background(), site.URL+"/private/press")
+	require.NoError(t, err)
+	require.Equal(t, "plain notes", page.Text)
+
+	_, err = New(Config{AllowPrivate: true, UserAgent: "BadBot/1.0"}).Fetch(context.Background(), site.URL+"/tides")
+	require.ErrorIs(t, err, ErrDisallowed)
+
+	_, err = New(Config{AllowPrivate: true, UserAgent: "BadBot/1.0", IgnoreRobots: true}).Fetch(context.Background(), site.URL+"/tides")
+	require.NoError(t, err)
+}
+
+func TestFetchRefusesPrivateAddresses(t *testing.T) {
+	site := newTestSite(t)
+	_, err := New(Config{}).Fetch(context.Background(), site.URL+"/tides")
+	require.True(t, errors.Is(err, ErrPrivateAddress), err)
+}
+
+func TestRobotsMatch(t *testing.T) {
+	require.True(t, robotsMatch("/private", "/private/x"))
+	require.True(t, robotsMatch("/*.pdf$", "/docs/a.pdf"))
+	require.False(t, robotsMatch("/*.pdf$", "/docs/a.pdf?x=1"))
+	require.True(t, robotsMatch("/search*q=", "/search?x=1&q=go"))
+	require.False(t, robotsMatch("/a$", "/ab"))
+}
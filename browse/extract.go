@@ -0,0 +1,358 @@
+package browse
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxLinks caps the links reported from a page's main content
+const maxLinks = 50
+
+// skipped elements never hold readable content
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Iframe: true,
+	atom.Svg: true, atom.Canvas: true, atom.Form: true, atom.Button: true, atom.Select: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Head: true,
+}
+
+// boilerplate matches class names and ids of page chrome around the main content
+var boilerplate = regexp.MustCompile(`(?i)\b(comment|sidebar|footer|header|menu|nav|breadcrumb|share|social|related|promo|advert|ads?|banner|cookie|popup|modal|newsletter|subscribe)\b`)
+
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true, atom.Blockquote: true,
+	atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Table: true, atom.Tr: true, atom.Br: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Hr: true,
+}
+
+// extract parses an HTML document into page metadata, the text of its main content and the links in it
+func extract(r io.Reader, base *url.URL, page *Page) error {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	meta := metadata(doc)
+	page.Title = firstNonEmpty(meta["og:title"], meta["title"])
+	page.Description = firstNonEmpty(meta["description"], meta["og:description"])
+	page.Author = firstNonEmpty(meta["author"], meta["article:author"])
+	page.SiteName = meta["og:site_name"]
+	page.Published = firstNonEmpty(meta["article:published_time"], meta["date"])
+	page.Language = meta["lang"]
+	if image := meta["og:image"]; image != "" {
+		page.Image = resolve(base, image)
+	}
+
+	content := mainContent(doc)
+	if content == nil {
+		return nil
+	}
+
+	w := &textWriter{base: base, seen: make(map[string]bool)}
+	w.node(content)
+	page.Text = w.String()
+	page.Links = w.links
+	return nil
+}
+
+// metadata collects the title, the document language and name or property meta tags
+func metadata(doc *html.Node) map[string]string {
+	meta := make(map[string]string)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Html:
+				meta["lang"] = attr(n, "lang")
+			case atom.Title:
+				if _, ok := meta["title"]; !ok {
+					meta["title"] = collapse(textOf(n))
+				}
+			case atom.Meta:
+				key := strings.ToLower(firstNonEmpty(attr(n, "property"), attr(n, "name")))
+				if key != "" && meta[key] == "" {
+					meta[key] = strings.TrimSpace(attr(n, "content"))
+				}
+			case atom.Body:
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return meta
+}
+
+// mainContent returns the element holding the page's main text
+// <article> or <main> are taken when present, otherwise the element whose paragraphs hold the most text
+func mainContent(doc *html.Node) *html.Node {
+	var article, main, body *html.Node
+	scores := make(map[*html.Node]int)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if skipped[n.DataAtom] || isBoilerplate(n) {
+				return
+			}
+			switch n.DataAtom {
+			case atom.Article:
+				if article == nil {
+					article = n
+				}
+			case atom.Main:
+				if main == nil {
+					main = n
+				}
+			case atom.Body:
+				body = n
+			case atom.P, atom.Pre, atom.Blockquote, atom.Li, atom.Td:
+				if n.Parent != nil {
+					text := len(collapse(textOf(n)))
+					links := len(collapse(linkText(n)))
+					scores[n.Parent] += text - 2*links
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if article != nil {
+		return article
+	}
+	if main != nil {
+		return main
+	}
+
+	var best *html.Node
+	bestScore := 0
+	for n, score := range scores {
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best != nil && bestScore >= 200 {
+		return best
+	}
+	return body
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	if attr(n, "hidden") != "" || strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	switch strings.ToLower(attr(n, "role")) {
+	case "navigation", "banner", "contentinfo", "complementary", "dialog":
+		return true
+	}
+	return boilerplate.MatchString(attr(n, "class") + " " + attr(n, "id"))
+}
+
+// textWriter renders content as plain text with blank lines between blocks, # headings and - list items
+type textWriter struct {
+	base  *url.URL
+	b     strings.Builder
+	links []Link
+	seen  map[string]bool
+	pre   int
+}
+
+func (w *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.b.WriteString(n.Data)
+		} else {
+			w.inline(n.Data)
+		}
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] || isBoilerplate(n) {
+			return
+		}
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.node(c)
+		}
+		return
+	}
+
+	block := blocks[n.DataAtom]
+	if block {
+		w.breakLine(n.DataAtom)
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+	case atom.Li:
+		w.b.WriteString("- ")
+	case atom.Pre:
+		w.pre++
+		defer func() { w.pre-- }()
+	case atom.Td, atom.Th:
+		w.inline(" | ")
+	case atom.Img:
+		if alt := collapse(attr(n, "alt")); alt != "" {
+			w.inline("[image: " + alt + "]")
+		}
+	case atom.A:
+		w.link(n)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+	if block {
+		w.breakLine(n.DataAtom)
+	}
+}
+
+// inline appends text collapsing whitespace, a single space separates it from what came before
+func (w *textWriter) inline(text string) {
+	collapsed := collapse(text)
+	if collapsed == "" {
+		if text != "" && w.b.Len() > 0 && !strings.HasSuffix(w.b.String(), " ") && !strings.HasSuffix(w.b.String(), "\n") {
+			w.b.WriteByte(' ')
+		}
+		return
+	}
+
+	current := w.b.String()
+	startsWithSpace := strings.TrimLeft(text, " \t\r\n") != text
+	if startsWithSpace && current != "" && !strings.HasSuffix(current, " ") && !strings.HasSuffix(current, "\n") {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(collapsed)
+	if strings.TrimRight(text, " \t\r\n") != text {
+		w.b.WriteByte(' ')
+	}
+}
+
+// breakLine ends the current block, paragraphs and headings are separated by a blank line
+func (w *textWriter) breakLine(a atom.Atom) {
+	current := strings.TrimRight(w.b.String(), " ")
+	if len(current) != w.b.Len() {
+		w.b.Reset()
+		w.b.WriteString(current)
+	}
+	if current == "" {
+		return
+	}
+
+	separator := "\n\n"
+	switch a {
+	case atom.Li, atom.Tr, atom.Br, atom.Dt, atom.Dd:
+		separator = "\n"
+	}
+	trailing := len(current) - len(strings.TrimRight(current, "\n"))
+	if trailing < len(separator) {
+		w.b.WriteString(separator[trailing:])
+	}
+}
+
+func (w *textWriter) link(n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	if href == "" || strings.HasPrefix(href, "#") || len(w.links) >= maxLinks {
+		return
+	}
+	target := resolve(w.base, href)
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		return
+	}
+	text := collapse(textOf(n))
+	if text == "" || w.seen[target] {
+		return
+	}
+	w.seen[target] = true
+	w.links = append(w.links, Link{Text: text, URL: target})
+}
+
+func (w *textWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := strings.Join(lines, "\n")
+	for strings.Contains(text, "\n\n\n") {
+		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(text)
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		if n.Type == html.ElementNode && skipped[n.DataAtom] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func linkText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			b.WriteString(textOf(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func resolve(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
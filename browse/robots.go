@@ -0,0 +1,163 @@
+package browse
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// robotsRules are the Allow and Disallow rules of the group matching the browser's user agent
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// allowed reports whether robots.txt of the URL's host allows fetching it, the file is fetched once per host
+// A missing or unreachable robots.txt allows everything, like most crawlers do
+func (b *Browser) allowed(ctx context.Context, target *url.URL) (bool, error) {
+	origin := target.Scheme + "://" + target.Host
+
+	b.mu.Lock()
+	rules, ok := b.robots[origin]
+	b.mu.Unlock()
+
+	if !ok {
+		rules = &robotsRules{}
+		response, err := b.get(ctx, origin+"/robots.txt", "text/plain")
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, err
+			}
+		} else {
+			if response.StatusCode == 200 {
+				rules = parseRobots(io.LimitReader(response.Body, 512<<10), b.config.UserAgent)
+			}
+			response.Body.Close()
+		}
+
+		b.mu.Lock()
+		b.robots[origin] = rules
+		b.mu.Unlock()
+	}
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return rules.allows(path), nil
+}
+
+// parseRobots returns the rules of the most specific group matching userAgent, or of the * group
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i > 0 {
+		token = token[:i]
+	}
+
+	var specific, wildcard *robotsRules
+	var current []*robotsRules // groups the lines being read belong to
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+				inAgents = true
+			}
+			agent := strings.ToLower(value)
+			switch {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = &robotsRules{}
+				}
+				current = append(current, wildcard)
+			case agent != "" && strings.HasPrefix(token, agent):
+				if specific == nil {
+					specific = &robotsRules{}
+				}
+				current = append(current, specific)
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			for _, group := range current {
+				if key == "allow" {
+					group.allow = append(group.allow, value)
+				} else {
+					group.disallow = append(group.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if specific != nil {
+		return specific
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return &robotsRules{}
+}
+
+// allows applies the longest matching rule, Allow wins ties
+func (r *robotsRules) allows(path string) bool {
+	longest := func(patterns []string) int {
+		best := -1
+		for _, pattern := range patterns {
+			if len(pattern) > best && robotsMatch(pattern, path) {
+				best = len(pattern)
+			}
+		}
+		return best
+	}
+	return longest(r.allow) >= longest(r.disallow)
+}
+
+// robotsMatch matches a path against a robots.txt pattern supporting * and a trailing $
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	// the pattern must reach the end of the path
+	if len(parts) > 1 {
+		return strings.HasSuffix(path, parts[len(parts)-1])
+	}
+	return rest == ""
+}
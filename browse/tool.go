@@ -0,0 +1,48 @@
+package browse
+
+import (
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+var _ kit.ToolExecutor = &Tool{}
+
+// Tool is an agent tool that fetches a web page and returns its readable content
+type Tool struct {
+	kit.BaseTool
+	URL string `json:"url" jsonschema:"description=Absolute http or https URL of the page to read"`
+
+	browser *Browser
+}
+
+// NewTool creates a browsing tool fetching pages with browser
+func NewTool(browser *Browser) *Tool {
+	return &Tool{browser: browser}
+}
+
+func (t *Tool) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name: "browse",
+		Description: "Fetch a web page and return its title, metadata, main text and the links in it. " +
+			"Use it to read sources found while researching.",
+	}
+}
+
+func (t *Tool) Execute(ctx *kit.Context) (any, error) {
+	if t.browser == nil {
+		t.browser = New(Config{})
+	}
+	page, err := t.browser.Fetch(ctx, t.URL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// unreachable pages are common while researching, the model can pick another source
+		return fetchFailure{URL: t.URL, Error: err.Error()}, nil
+	}
+	return page, nil
+}
+
+type fetchFailure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
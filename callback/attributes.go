@@ -0,0 +1,34 @@
+package callback
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type attributesKey struct{}
+
+// WithAttributes returns a context carrying request scoped attributes, e.g. tenant ID or experiment variant
+// Attributes added by nested calls are appended to the ones already in the context
+func WithAttributes(ctx context.Context, kv ...attribute.KeyValue) context.Context {
+	existing := ContextAttributes(ctx)
+	merged := make([]attribute.KeyValue, 0, len(existing)+len(kv))
+	merged = append(merged, existing...)
+	merged = append(merged, kv...)
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+// ContextAttributes returns the attributes set with WithAttributes
+func ContextAttributes(ctx context.Context) []attribute.KeyValue {
+	if ctx == nil {
+		return nil
+	}
+	kv, _ := ctx.Value(attributesKey{}).([]attribute.KeyValue)
+	return kv
+}
+
+// callbackAttributes extracts the attributes a Manager added to a callback context
+func callbackAttributes(ctx map[string]interface{}) []attribute.KeyValue {
+	kv, _ := ctx["attributes"].([]attribute.KeyValue)
+	return kv
+}
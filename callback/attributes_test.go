@@ -0,0 +1,69 @@
+package callback
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithAttributesAppendsToParent(t *testing.T) {
+	tenant := attribute.String("tenant.id", "acme")
+	variant := attribute.String("experiment.variant", "b")
+
+	parent := WithAttributes(context.Background(), tenant)
+	child := WithAttributes(parent, variant)
+
+	require.Equal(t, []attribute.KeyValue{tenant}, ContextAttributes(parent))
+	require.Equal(t, []attribute.KeyValue{tenant, variant}, ContextAttributes(child))
+	require.Empty(t, ContextAttributes(context.Background()))
+}
+
+func TestManagerAddsContextAttributes(t *testing.T) {
+	tenant := attribute.String("tenant.id", "acme")
+	rec := &recordingCallback{}
+
+	NewManager([]AgentCallback{rec}, nil).WithContext(context.Background()).OnGenerationStart(1, nil, "gpt-4o")
+	NewManager([]AgentCallback{rec}, nil).
+		WithContext(WithAttributes(context.Background(), tenant)).
+		OnGenerationStart(1, nil, "gpt-4o")
+
+	require.Len(t, rec.starts, 2)
+	require.NotContains(t, rec.starts[0], "attributes")
+	require.Equal(t, []attribute.KeyValue{tenant}, rec.starts[1]["attributes"])
+}
+
+func TestLangfuseSpansCarryContextAttributes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	lc := NewLangfuseCallback(LangfuseCallbackConfig{Tracer: provider.Tracer("test")})
+
+	ctx := WithAttributes(context.Background(), attribute.String("tenant.id", "acme"))
+	cm := NewManager([]AgentCallback{lc}, nil).WithContext(ctx)
+	cm.OnRunStart("gpt-4o", "hi", false)
+	cm.OnGenerationStart(1, nil, "gpt-4o")
+	cm.OnGenerationEnd("tool_calls", "", nil, &openai.CompletionUsage{TotalTokens: 3})
+	cm.OnToolCallStart("lookup", map[string]interface{}{"q": "x"}, "call-1")
+	cm.OnToolCallEnd("lookup", map[string]interface{}{"q": "x"}, "found", "call-1", nil)
+	cm.OnRunEnd("done", 1)
+
+	spans := exporter.GetSpans()
+	require.NotEmpty(t, spans)
+	for _, span := range spans {
+		if span.Name == "trace" {
+			// the trace span is created before the run's context is known
+			continue
+		}
+		var tenant string
+		for _, kv := range span.Attributes {
+			if kv.Key == "tenant.id" {
+				tenant = kv.Value.AsString()
+			}
+		}
+		require.Equal(t, "acme", tenant, "span %s", span.Name)
+	}
+}
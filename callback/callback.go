@@ -2,6 +2,7 @@ package callback
 
 // AgentCallback defines the interface for agent lifecycle callbacks
 // Similar to LangChain's callback system for observability and tracing
+// Every context also contains attributes ([]attribute.KeyValue) when the run's context has WithAttributes set
 type AgentCallback interface {
 	Name() string
 	// OnRunStart is called when the agent starts execution
@@ -9,15 +10,15 @@ type AgentCallback interface {
 	OnRunStart(ctx map[string]interface{})
 
 	// OnRunEnd is called when the agent completes execution
-	// Context contains: output, total_iterations, run_id, parent_run_id
+	// Context contains: output, total_iterations, run_id, parent_run_id, cost (Cost, with a pricing table)
 	OnRunEnd(ctx map[string]interface{})
 
 	// OnGenerationStart is called before each LLM API call
-	// Context contains: iteration, messages, model, run_id, parent_run_id
+	// Context contains: iteration, messages, model, run_id, parent_run_id, generation_name (if set)
 	OnGenerationStart(ctx map[string]interface{})
 
 	// OnGenerationEnd is called after each LLM API call
-	// Context contains: finish_reason, content, tool_calls, usage, run_id, parent_run_id
+	// Context contains: finish_reason, content, tool_calls, usage, run_id, parent_run_id, cost (Cost, with a pricing table)
 	OnGenerationEnd(ctx map[string]interface{})
 
 	// OnToolCallStart is called before tool execution
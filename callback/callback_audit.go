@@ -0,0 +1,299 @@
+package callback
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AuditEvent is a single line of the audit log
+type AuditEvent struct {
+	Time        time.Time      `json:"time"`
+	Event       string         `json:"event"`
+	RunID       string         `json:"run_id"`
+	ParentRunID string         `json:"parent_run_id,omitempty"`
+	Data        map[string]any `json:"data,omitempty"`
+}
+
+// RedactionRule masks sensitive values before an event is written
+type RedactionRule struct {
+	// Keys masks the whole value of these object keys at any depth (case-insensitive), e.g. "password"
+	Keys []string
+
+	// Pattern masks matching substrings of every string value, e.g. emails or card numbers
+	Pattern *regexp.Regexp
+
+	// Replacement is written instead of the masked value (optional, defaults to "[REDACTED]")
+	Replacement string
+}
+
+// AuditConfig configures the audit logger
+type AuditConfig struct {
+	// Writer receives the JSON lines (mutually exclusive with Path)
+	Writer io.Writer
+
+	// Path is a file the JSON lines are appended to, rotated by size (mutually exclusive with Writer)
+	Path string
+
+	// MaxBytes rotates the file at Path once it would exceed this size (optional, defaults to 100MB)
+	MaxBytes int64
+
+	// MaxBackups is the number of rotated files kept as <path>.1 ... <path>.N (optional, defaults to 5)
+	MaxBackups int
+
+	// Redact rules applied to every event
+	Redact []RedactionRule
+
+	// OmitMessages leaves the message history out of generation_start events to keep lines small
+	OmitMessages bool
+}
+
+// AuditCallback appends every run, generation and tool event to a JSONL audit log
+// It's safe to share between concurrent runs
+type AuditCallback struct {
+	config AuditConfig
+
+	mu   sync.Mutex
+	out  io.Writer
+	file *rotatingFile
+	err  error
+}
+
+// NewAuditCallback creates a new audit logger
+func NewAuditCallback(config AuditConfig) (*AuditCallback, error) {
+	if (config.Writer == nil) == (config.Path == "") {
+		return nil, fmt.Errorf("exactly one of Writer or Path is required")
+	}
+	for i, rule := range config.Redact {
+		if rule.Replacement == "" {
+			config.Redact[i].Replacement = "[REDACTED]"
+		}
+	}
+
+	a := &AuditCallback{config: config, out: config.Writer}
+	if config.Path != "" {
+		if config.MaxBytes <= 0 {
+			config.MaxBytes = 100 << 20
+		}
+		if config.MaxBackups <= 0 {
+			config.MaxBackups = 5
+		}
+
+		file, err := openRotatingFile(config.Path, config.MaxBytes, config.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		a.file = file
+		a.out = file
+	}
+	return a, nil
+}
+
+func (a *AuditCallback) Name() string {
+	return "AuditCallback"
+}
+
+// Err returns the first error writing the log, callbacks can't return errors themselves
+func (a *AuditCallback) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Close closes the log file, it's a no-op when a Writer was configured
+func (a *AuditCallback) Close() error {
+	if a.file == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+func (a *AuditCallback) OnRunStart(ctx map[string]interface{}) { a.write("run_start", ctx) }
+func (a *AuditCallback) OnRunEnd(ctx map[string]interface{})   { a.write("run_end", ctx) }
+func (a *AuditCallback) OnGenerationStart(ctx map[string]interface{}) {
+	a.write("generation_start", ctx)
+}
+func (a *AuditCallback) OnGenerationEnd(ctx map[string]interface{}) { a.write("generation_end", ctx) }
+func (a *AuditCallback) OnToolCallStart(ctx map[string]interface{}) { a.write("tool_call_start", ctx) }
+func (a *AuditCallback) OnToolCallEnd(ctx map[string]interface{})   { a.write("tool_call_end", ctx) }
+func (a *AuditCallback) OnError(ctx map[string]interface{})         { a.write("error", ctx) }
+
+// write redacts and appends a single event
+func (a *AuditCallback) write(event string, ctx map[string]interface{}) {
+	ev := AuditEvent{Time: time.Now().UTC(), Event: event, Data: map[string]any{}}
+	ev.RunID, _ = ctx["run_id"].(string)
+	ev.ParentRunID, _ = ctx["parent_run_id"].(string)
+
+	for k, v := range ctx {
+		switch k {
+		case "run_id", "parent_run_id":
+			continue
+		case "messages":
+			if a.config.OmitMessages {
+				continue
+			}
+		case "attributes":
+			if kv, ok := v.([]attribute.KeyValue); ok {
+				attrs := make(map[string]any, len(kv))
+				for _, attr := range kv {
+					attrs[string(attr.Key)] = attr.Value.AsInterface()
+				}
+				v = attrs
+			}
+		}
+		ev.Data[k] = v
+	}
+
+	line, err := a.encode(ev)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err == nil {
+		_, err = a.out.Write(line)
+	}
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("failed to write %s audit event: %w", event, err)
+	}
+}
+
+// encode converts the event to generic JSON, applies the redaction rules and returns the line
+func (a *AuditCallback) encode(ev AuditEvent) ([]byte, error) {
+	if len(a.config.Redact) > 0 {
+		raw, err := json.Marshal(ev.Data)
+		if err != nil {
+			return nil, err
+		}
+		var data map[string]any
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		ev.Data = a.redact(data).(map[string]any)
+	}
+
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// redact walks a decoded JSON value and masks matching keys and patterns
+func (a *AuditCallback) redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if replacement, ok := a.redactedKey(k); ok {
+				val[k] = replacement
+				continue
+			}
+			val[k] = a.redact(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = a.redact(item)
+		}
+		return val
+	case string:
+		for _, rule := range a.config.Redact {
+			if rule.Pattern != nil {
+				val = rule.Pattern.ReplaceAllString(val, rule.Replacement)
+			}
+		}
+		return val
+	}
+	return v
+}
+
+// redactedKey returns the replacement of a key matched by a rule
+func (a *AuditCallback) redactedKey(key string) (string, bool) {
+	for _, rule := range a.config.Redact {
+		for _, k := range rule.Keys {
+			if strings.EqualFold(k, key) {
+				return rule.Replacement, true
+			}
+		}
+	}
+	return "", false
+}
+
+// rotatingFile is an append-only file that is rotated once it reaches maxBytes
+type rotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts <path>.N-1 to <path>.N, moves the current file to <path>.1 and starts a new one
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", r.path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil {
+				return fmt.Errorf("failed to rotate audit log: %w", err)
+			}
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	return r.file.Close()
+}
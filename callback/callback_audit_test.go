@@ -0,0 +1,68 @@
+package callback
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditCallbackRedactsEvents(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := NewAuditCallback(AuditConfig{
+		Writer: &buf,
+		Redact: []RedactionRule{
+			{Keys: []string{"password"}},
+			{Pattern: regexp.MustCompile(`[\w.]+@[\w.]+`), Replacement: "<email>"},
+		},
+	})
+	require.NoError(t, err)
+
+	cm := NewManager([]AgentCallback{audit}, nil)
+	cm.OnRunStart("gpt-4o", "mail bob@example.com", false)
+	cm.OnToolCallStart("login", map[string]interface{}{"user": "bob", "Password": "hunter2"}, "call-1")
+	require.NoError(t, audit.Err())
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var ev AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		events = append(events, ev)
+	}
+	require.Len(t, events, 2)
+
+	require.Equal(t, "run_start", events[0].Event)
+	require.NotEmpty(t, events[0].RunID)
+	require.Equal(t, "mail <email>", events[0].Data["input"])
+
+	require.Equal(t, "tool_call_start", events[1].Event)
+	require.Equal(t, events[0].RunID, events[1].ParentRunID)
+	require.Equal(t, map[string]any{"user": "bob", "Password": "[REDACTED]"}, events[1].Data["arguments"])
+}
+
+func TestAuditCallbackRotatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := NewAuditCallback(AuditConfig{Path: path, MaxBytes: 500, MaxBackups: 2})
+	require.NoError(t, err)
+
+	cm := NewManager([]AgentCallback{audit}, nil)
+	for range 10 {
+		cm.OnRunStart("gpt-4o", "some input that takes up space", false)
+	}
+	require.NoError(t, audit.Err())
+	require.NoError(t, audit.Close())
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(500))
+	}
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
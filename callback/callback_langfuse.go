@@ -139,6 +139,7 @@ func (lc *LangfuseCallback) OnRunStart(ctx map[string]interface{}) {
 		}
 
 		lc.rootSpan.SetAttributes(attribute.String("run_id", runID))
+		lc.rootSpan.SetAttributes(callbackAttributes(ctx)...)
 	}
 }
 
@@ -171,6 +172,13 @@ func (lc *LangfuseCallback) OnRunEnd(ctx map[string]interface{}) {
 		)
 	}
 
+	// Set total cost
+	if cost, ok := ctx["cost"].(Cost); ok {
+		lc.rootSpan.SetAttributes(
+			attribute.Float64("total_cost", cost.Total),
+		)
+	}
+
 	lc.rootSpan.SetStatus(codes.Ok, "")
 	lc.rootSpan.End()
 
@@ -212,10 +220,15 @@ func (lc *LangfuseCallback) OnGenerationStart(ctx map[string]interface{}) {
 	lc.currentIterationSpan = iterationSpan
 	lc.currentIterationCtx = iterationSpanCtx
 
+	generationName := "llm.generation"
+	if name, ok := ctx["generation_name"].(string); ok && name != "" {
+		generationName = name
+	}
+
 	// Start generation span - child of iteration span
 	spanCtx, span := lc.tracer.Start(
 		lc.currentIterationCtx,
-		"llm.generation",
+		generationName,
 		trace.WithSpanKind(trace.SpanKindClient),
 	)
 
@@ -224,6 +237,8 @@ func (lc *LangfuseCallback) OnGenerationStart(ctx map[string]interface{}) {
 
 	// Set iteration attributes
 	lc.currentIterationSpan.SetAttributes(attribute.Int("iteration", iterNum))
+	lc.currentIterationSpan.SetAttributes(callbackAttributes(ctx)...)
+	span.SetAttributes(callbackAttributes(ctx)...)
 
 	// Set generation attributes
 	if model, ok := ctx["model"].(string); ok {
@@ -239,6 +254,14 @@ func (lc *LangfuseCallback) OnGenerationStart(ctx map[string]interface{}) {
 			attribute.String("langfuse.observation.input", string(messagesJSON)),
 		)
 	}
+	if prompt, ok := ctx["prompt_template"].(PromptTemplate); ok {
+		variablesJSON, _ := json.Marshal(prompt.Variables)
+		span.SetAttributes(
+			attribute.String("langfuse.observation.metadata.prompt_name", prompt.Name),
+			attribute.String("langfuse.observation.metadata.prompt_version", prompt.Version),
+			attribute.String("langfuse.observation.metadata.prompt_variables", string(variablesJSON)),
+		)
+	}
 }
 
 // OnGenerationEnd completes the generation span with output and usage
@@ -264,6 +287,10 @@ func (lc *LangfuseCallback) OnGenerationEnd(ctx map[string]interface{}) {
 		output["content"] = content
 	}
 
+	if reasoning, ok := ctx["reasoning"].(string); ok {
+		output["reasoning"] = reasoning
+	}
+
 	// Add tool calls to output if present
 	if toolCalls := ctx["tool_calls"]; toolCalls != nil {
 		hasToolCalls = true
@@ -291,6 +318,14 @@ func (lc *LangfuseCallback) OnGenerationEnd(ctx map[string]interface{}) {
 		}
 	}
 
+	// Set cost, in USD
+	if cost, ok := ctx["cost"].(Cost); ok {
+		costJSON, _ := json.Marshal(cost)
+		lc.currentGenerationSpan.SetAttributes(
+			attribute.String("langfuse.observation.cost_details", string(costJSON)),
+		)
+	}
+
 	lc.currentGenerationSpan.SetStatus(codes.Ok, "")
 	lc.currentGenerationSpan.End()
 	lc.currentGenerationSpan = nil
@@ -329,6 +364,7 @@ func (lc *LangfuseCallback) OnToolCallStart(ctx map[string]interface{}) {
 		attribute.String("tool.name", toolName),
 		attribute.String("tool_call_id", toolCallID),
 	)
+	toolSpan.SetAttributes(callbackAttributes(ctx)...)
 
 	if arguments := ctx["arguments"]; arguments != nil {
 		argsJSON, _ := json.Marshal(arguments)
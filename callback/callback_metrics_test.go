@@ -0,0 +1,64 @@
+package callback
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+// simulateRun sends the events of a run with one generation and one failed tool call
+func simulateRun(cb AgentCallback) {
+	cm := NewManager([]AgentCallback{cb}, nil)
+	cm.OnRunStart("gpt-4o", "hi", false)
+	cm.OnGenerationStart(1, nil, "gpt-4o")
+	cm.OnGenerationEnd("tool_calls", "", nil, &openai.CompletionUsage{PromptTokens: 10, CompletionTokens: 5})
+	cm.OnToolCallStart("search", nil, "call-1")
+	cm.OnToolCallEnd("search", nil, nil, "call-1", errors.New("timeout"))
+	cm.OnRunEnd("done", 2)
+}
+
+func TestPrometheusCallbackRendersMetrics(t *testing.T) {
+	cb := NewPrometheusCallback(PrometheusConfig{})
+	simulateRun(cb)
+
+	rec := httptest.NewRecorder()
+	cb.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	require.Contains(t, body, "# TYPE goaikit_runs_total counter\n")
+	require.Contains(t, body, `goaikit_runs_total{status="ok"} 1`)
+	require.Contains(t, body, `goaikit_tokens_total{model="gpt-4o",type="input"} 10`)
+	require.Contains(t, body, `goaikit_tool_calls_total{status="error",tool="search"} 1`)
+	require.Contains(t, body, `goaikit_run_iterations_bucket{le="2"} 1`)
+	require.Contains(t, body, `goaikit_run_iterations_bucket{le="1"} 0`)
+	require.Contains(t, body, `goaikit_run_iterations_count 1`)
+	require.Contains(t, body, `goaikit_tool_duration_seconds_bucket{status="error",tool="search",le="+Inf"} 1`)
+}
+
+func TestPrometheusLabelEscaping(t *testing.T) {
+	require.Equal(t, `{a="x\"y\\z\n"}`, formatPromLabels(map[string]string{"a": "x\"y\\z\n"}, ""))
+}
+
+func TestStatsDCallbackWritesLines(t *testing.T) {
+	var buf bytes.Buffer
+	cb, err := NewStatsDCallback(StatsDConfig{Writer: &buf, Tags: true})
+	require.NoError(t, err)
+	simulateRun(cb)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Contains(t, lines, "goaikit.tokens_total:10|c|#model:gpt-4o,type:input")
+	require.Contains(t, lines, "goaikit.tool_calls_total:1|c|#status:error,tool:search")
+	require.Contains(t, lines, "goaikit.run_iterations:2|h")
+
+	buf.Reset()
+	cb, err = NewStatsDCallback(StatsDConfig{Writer: &buf, Prefix: "app"})
+	require.NoError(t, err)
+	simulateRun(cb)
+	require.Contains(t, buf.String(), "app.tool_calls_total.error.search:1|c\n")
+	require.Contains(t, buf.String(), "app.generation_duration.gpt-4o.ok:")
+}
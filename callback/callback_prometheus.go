@@ -0,0 +1,196 @@
+package callback
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrometheusCallback records run, generation, token and tool metrics and serves them in the
+// Prometheus text exposition format, mount it as an http.Handler on your metrics endpoint
+type PrometheusCallback struct {
+	*metricsRecorder
+	registry *promRegistry
+}
+
+// PrometheusConfig configures the Prometheus callback
+type PrometheusConfig struct {
+	// Namespace prefixes every metric name (optional, defaults to "goaikit")
+	Namespace string
+
+	// DurationBuckets are the histogram buckets of durations in seconds (optional)
+	DurationBuckets []float64
+}
+
+// NewPrometheusCallback creates a new Prometheus metrics callback
+func NewPrometheusCallback(config PrometheusConfig) *PrometheusCallback {
+	if config.Namespace == "" {
+		config.Namespace = "goaikit"
+	}
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+	}
+
+	registry := &promRegistry{
+		namespace: config.Namespace,
+		families: map[string]*promFamily{
+			metricRuns:               {help: "Agent runs by status", typ: "counter"},
+			metricRunDuration:        {help: "Agent run latency", typ: "histogram", buckets: config.DurationBuckets},
+			metricRunIterations:      {help: "Tool calling iterations per run", typ: "histogram", buckets: []float64{1, 2, 3, 5, 8, 13, 21}},
+			metricGenerationDuration: {help: "LLM generation latency", typ: "histogram", buckets: config.DurationBuckets},
+			metricTokens:             {help: "Tokens used by LLM generations", typ: "counter"},
+			metricToolCalls:          {help: "Tool calls by tool and status", typ: "counter"},
+			metricToolDuration:       {help: "Tool call latency", typ: "histogram", buckets: config.DurationBuckets},
+			metricErrors:             {help: "Errors by stage", typ: "counter"},
+		},
+	}
+
+	return &PrometheusCallback{
+		metricsRecorder: newMetricsRecorder(registry),
+		registry:        registry,
+	}
+}
+
+func (p *PrometheusCallback) Name() string {
+	return "PrometheusCallback"
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format
+func (p *PrometheusCallback) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(p.registry.render()))
+}
+
+// promRegistry is a minimal in-memory Prometheus registry
+type promRegistry struct {
+	namespace string
+
+	mu       sync.Mutex
+	families map[string]*promFamily
+}
+
+type promFamily struct {
+	help    string
+	typ     string
+	buckets []float64
+	series  map[string]*promSeries // rendered labels -> series
+}
+
+type promSeries struct {
+	labels  map[string]string
+	value   float64  // counter value or histogram sum
+	count   uint64   // histogram observations
+	buckets []uint64 // cumulative count per bucket
+}
+
+// series returns the series of a family for the given labels, creating it on first use
+func (r *promRegistry) series(name string, labels map[string]string) (*promFamily, *promSeries) {
+	family := r.families[name]
+	if family.series == nil {
+		family.series = make(map[string]*promSeries)
+	}
+
+	key := formatPromLabels(labels, "")
+	s, ok := family.series[key]
+	if !ok {
+		s = &promSeries{labels: labels, buckets: make([]uint64, len(family.buckets))}
+		family.series[key] = s
+	}
+	return family, s
+}
+
+func (r *promRegistry) count(name string, value float64, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, s := r.series(name, labels)
+	s.value += value
+}
+
+func (r *promRegistry) observe(name string, value float64, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, s := r.series(name, labels)
+	s.value += value
+	s.count++
+	for i, bound := range family.buckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// render formats every family in the text exposition format, sorted for stable output
+func (r *promRegistry) render() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		family := r.families[name]
+		fullName := r.namespace + "_" + name
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", fullName, family.help, fullName, family.typ)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := family.series[key]
+			if family.typ == "counter" {
+				fmt.Fprintf(&b, "%s%s %s\n", fullName, key, formatPromValue(s.value))
+				continue
+			}
+
+			for i, bound := range family.buckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", fullName,
+					formatPromLabels(s.labels, formatPromValue(bound)), s.buckets[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", fullName, formatPromLabels(s.labels, "+Inf"), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", fullName, key, formatPromValue(s.value))
+			fmt.Fprintf(&b, "%s_count%s %d\n", fullName, key, s.count)
+		}
+	}
+	return b.String()
+}
+
+// formatPromLabels renders labels as {k="v",...} sorted by key, le is added for histogram buckets
+func formatPromLabels(labels map[string]string, le string) string {
+	if len(labels) == 0 && le == "" {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		parts = append(parts, k+`="`+promLabelEscaper.Replace(labels[k])+`"`)
+	}
+	if le != "" {
+		parts = append(parts, `le="`+le+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// promLabelEscaper escapes label values as required by the text exposition format
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatPromValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
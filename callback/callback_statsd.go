@@ -0,0 +1,129 @@
+package callback
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StatsDCallback sends run, generation, token and tool metrics to a StatsD server over UDP
+// Durations are sent as timers in milliseconds, sends are fire-and-forget
+type StatsDCallback struct {
+	*metricsRecorder
+	conn io.WriteCloser
+}
+
+// StatsDConfig configures the StatsD callback
+type StatsDConfig struct {
+	// Addr is the StatsD server address, e.g. "127.0.0.1:8125" (required unless Writer is set)
+	Addr string
+
+	// Writer receives the metric lines instead of a UDP connection (optional)
+	Writer io.Writer
+
+	// Prefix is prepended to every metric name (optional, defaults to "goaikit")
+	Prefix string
+
+	// Tags sends labels as DogStatsD tags, otherwise label values are appended to the metric name
+	Tags bool
+}
+
+// NewStatsDCallback creates a new StatsD metrics callback
+func NewStatsDCallback(config StatsDConfig) (*StatsDCallback, error) {
+	if config.Prefix == "" {
+		config.Prefix = "goaikit"
+	}
+
+	var conn io.WriteCloser
+	if config.Writer == nil {
+		if config.Addr == "" {
+			return nil, fmt.Errorf("Addr or Writer is required")
+		}
+		c, err := net.Dial("udp", config.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial statsd: %w", err)
+		}
+		conn = c
+		config.Writer = c
+	}
+
+	return &StatsDCallback{
+		metricsRecorder: newMetricsRecorder(&statsdSink{config: config}),
+		conn:            conn,
+	}, nil
+}
+
+func (s *StatsDCallback) Name() string {
+	return "StatsDCallback"
+}
+
+// Close closes the UDP connection, it's a no-op when a Writer was configured
+func (s *StatsDCallback) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// statsdSink formats measurements as StatsD lines
+type statsdSink struct {
+	config StatsDConfig
+	mu     sync.Mutex
+}
+
+func (s *statsdSink) count(name string, value float64, labels map[string]string) {
+	s.send(name, formatPromValue(value), "c", labels)
+}
+
+func (s *statsdSink) observe(name string, value float64, labels map[string]string) {
+	if strings.HasSuffix(name, "_seconds") {
+		s.send(strings.TrimSuffix(name, "_seconds"), strconv.FormatFloat(value*1000, 'f', 3, 64), "ms", labels)
+		return
+	}
+	s.send(name, formatPromValue(value), "h", labels)
+}
+
+// send writes a single metric line, errors are ignored like any UDP metric client
+func (s *statsdSink) send(name, value, typ string, labels map[string]string) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	metric := s.config.Prefix + "." + name
+	var tags []string
+	for _, k := range keys {
+		if s.config.Tags {
+			tags = append(tags, statsdName(k)+":"+statsdName(labels[k]))
+		} else {
+			metric += "." + statsdName(labels[k])
+		}
+	}
+
+	line := metric + ":" + value + "|" + typ
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.config.Writer.Write([]byte(line + "\n"))
+}
+
+// statsdName replaces characters with a meaning in the StatsD protocol
+func statsdName(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '.', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
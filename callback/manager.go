@@ -1,16 +1,37 @@
 package callback
 
 import (
+	"context"
+	"sync"
+
 	"github.com/google/uuid"
 	"github.com/openai/openai-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Manager dispatches lifecycle events to callbacks
+// It's safe for concurrent use, events are delivered to callbacks one at a time
 type Manager struct {
+	mu            sync.Mutex
 	callbacks     []AgentCallback
+	attributes    []attribute.KeyValue
 	runID         string
 	parentRunID   *string
 	nestedRunID   map[string]string // tool_call_id -> nested_run_id for nested tool executions
 	nestedParents map[string]string // nested_run_id -> parent_run_id
+	prompt        *PromptTemplate
+
+	cost      CostFunc
+	model     string // model of the current generation
+	totalCost Cost
+	priced    bool // some generation of the run had a price
+}
+
+// PromptTemplate describes the template a run's prompt was rendered from
+type PromptTemplate struct {
+	Name      string
+	Version   string
+	Variables any
 }
 
 // NewManager creates a new callback manager
@@ -24,6 +45,34 @@ func NewManager(callbacks []AgentCallback, parentRunID *string) *Manager {
 	}
 }
 
+// WithContext attaches the attributes of the caller's context (see WithAttributes) to every event
+func (cm *Manager) WithContext(ctx context.Context) *Manager {
+	cm.attributes = ContextAttributes(ctx)
+	return cm
+}
+
+// WithPromptTemplate attaches the template the prompt was rendered from to every generation event
+func (cm *Manager) WithPromptTemplate(prompt *PromptTemplate) *Manager {
+	cm.prompt = prompt
+	return cm
+}
+
+// Cost is the USD cost of a generation or run
+type Cost struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	Total  float64 `json:"total"`
+}
+
+// CostFunc prices the token usage of a generation of model, ok is false for models without a price
+type CostFunc func(model string, usage *openai.CompletionUsage) (cost Cost, ok bool)
+
+// WithCost attaches the cost of every generation to OnGenerationEnd, and the run's total to OnRunEnd
+func (cm *Manager) WithCost(fn CostFunc) *Manager {
+	cm.cost = fn
+	return cm
+}
+
 // createNestedRun creates a nested run ID for tool execution
 func (cm *Manager) createNestedRun(toolCallID string) string {
 	nestedID := uuid.New().String()
@@ -56,11 +105,18 @@ func (cm *Manager) addRunContext(ctx map[string]interface{}, nestedRunID *string
 		}
 	}
 
+	if len(cm.attributes) > 0 {
+		ctx["attributes"] = cm.attributes
+	}
+
 	return ctx
 }
 
 // OnRunStart triggers OnRunStart for all callbacks
 func (cm *Manager) OnRunStart(model string, input interface{}, hasOutputClass bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	ctx := cm.addRunContext(map[string]interface{}{
 		"model":            model,
 		"input":            input,
@@ -74,33 +130,71 @@ func (cm *Manager) OnRunStart(model string, input interface{}, hasOutputClass bo
 
 // OnRunEnd triggers OnRunEnd for all callbacks
 func (cm *Manager) OnRunEnd(output interface{}, totalIterations int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	ctx := cm.addRunContext(map[string]interface{}{
 		"output":           output,
 		"total_iterations": totalIterations,
 	}, nil)
+	if cm.priced {
+		ctx["cost"] = cm.totalCost
+	}
 
 	for _, cb := range cm.callbacks {
 		cb.OnRunEnd(ctx)
 	}
 }
 
+// GenerationStartInfo describes an LLM call that is about to be made
+type GenerationStartInfo struct {
+	Iteration int
+	Messages  []openai.ChatCompletionMessageParamUnion
+	Model     string
+	Name      string // generation name for traces, empty leaves the name to the callbacks
+}
+
 // OnGenerationStart triggers OnGenerationStart for all callbacks
 func (cm *Manager) OnGenerationStart(
 	iteration int,
 	messages []openai.ChatCompletionMessageParamUnion,
 	model string,
 ) {
+	cm.OnGenerationStartInfo(GenerationStartInfo{Iteration: iteration, Messages: messages, Model: model})
+}
+
+// OnGenerationStartInfo triggers OnGenerationStart for all callbacks, with the optional fields of info
+func (cm *Manager) OnGenerationStartInfo(info GenerationStartInfo) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.model = info.Model
 	ctx := cm.addRunContext(map[string]interface{}{
-		"iteration": iteration,
-		"messages":  messages,
-		"model":     model,
+		"iteration": info.Iteration,
+		"messages":  info.Messages,
+		"model":     info.Model,
 	}, nil)
+	if info.Name != "" {
+		ctx["generation_name"] = info.Name
+	}
+	if cm.prompt != nil {
+		ctx["prompt_template"] = *cm.prompt
+	}
 
 	for _, cb := range cm.callbacks {
 		cb.OnGenerationStart(ctx)
 	}
 }
 
+// GenerationEndInfo describes a finished LLM call
+type GenerationEndInfo struct {
+	FinishReason string
+	Content      string
+	Reasoning    string // the model's thinking returned next to the content (e.g. DeepSeek's reasoning_content), if any
+	ToolCalls    []openai.ChatCompletionMessageToolCall
+	Usage        *openai.CompletionUsage
+}
+
 // OnGenerationEnd triggers OnGenerationEnd for all callbacks
 func (cm *Manager) OnGenerationEnd(
 	finishReason string,
@@ -108,12 +202,37 @@ func (cm *Manager) OnGenerationEnd(
 	toolCalls []openai.ChatCompletionMessageToolCall,
 	usage *openai.CompletionUsage,
 ) {
+	cm.OnGenerationEndInfo(GenerationEndInfo{
+		FinishReason: finishReason,
+		Content:      content,
+		ToolCalls:    toolCalls,
+		Usage:        usage,
+	})
+}
+
+// OnGenerationEndInfo triggers OnGenerationEnd for all callbacks, with the optional fields of info
+func (cm *Manager) OnGenerationEndInfo(info GenerationEndInfo) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	ctx := cm.addRunContext(map[string]interface{}{
-		"finish_reason": finishReason,
-		"content":       content,
-		"tool_calls":    toolCalls,
-		"usage":         usage,
+		"finish_reason": info.FinishReason,
+		"content":       info.Content,
+		"tool_calls":    info.ToolCalls,
+		"usage":         info.Usage,
 	}, nil)
+	if info.Reasoning != "" {
+		ctx["reasoning"] = info.Reasoning
+	}
+	if cm.cost != nil && info.Usage != nil {
+		if cost, ok := cm.cost(cm.model, info.Usage); ok {
+			ctx["cost"] = cost
+			cm.totalCost.Input += cost.Input
+			cm.totalCost.Output += cost.Output
+			cm.totalCost.Total += cost.Total
+			cm.priced = true
+		}
+	}
 
 	for _, cb := range cm.callbacks {
 		cb.OnGenerationEnd(ctx)
@@ -122,6 +241,9 @@ func (cm *Manager) OnGenerationEnd(
 
 // OnToolCallStart triggers OnToolCallStart for all callbacks
 func (cm *Manager) OnToolCallStart(toolName string, arguments map[string]interface{}, toolCallID string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	nestedRunID := cm.createNestedRun(toolCallID)
 	ctx := cm.addRunContext(map[string]interface{}{
 		"tool_name":    toolName,
@@ -142,6 +264,9 @@ func (cm *Manager) OnToolCallEnd(
 	toolCallID string,
 	err error,
 ) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	nestedRunID := cm.getNestedRunID(toolCallID)
 	ctx := cm.addRunContext(map[string]interface{}{
 		"tool_name":    toolName,
@@ -161,6 +286,9 @@ func (cm *Manager) OnToolCallEnd(
 
 // OnError triggers OnError for all callbacks
 func (cm *Manager) OnError(err error, stage string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	ctx := cm.addRunContext(map[string]interface{}{
 		"error": err.Error(),
 		"stage": stage,
@@ -0,0 +1,51 @@
+package callback
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCallback keeps the context of every generation event
+type recordingCallback struct {
+	BaseCallback
+	starts []map[string]interface{}
+	ends   []map[string]interface{}
+}
+
+func (r *recordingCallback) Name() string { return "recording" }
+
+func (r *recordingCallback) OnGenerationStart(ctx map[string]interface{}) {
+	r.starts = append(r.starts, ctx)
+}
+
+func (r *recordingCallback) OnGenerationEnd(ctx map[string]interface{}) {
+	r.ends = append(r.ends, ctx)
+}
+
+func TestManagerGenerationStartName(t *testing.T) {
+	rec := &recordingCallback{}
+	cm := NewManager([]AgentCallback{rec}, nil)
+
+	cm.OnGenerationStart(1, nil, "gpt-4o")
+	cm.OnGenerationStartInfo(GenerationStartInfo{Iteration: 2, Model: "gpt-4o", Name: "plan"})
+
+	require.Len(t, rec.starts, 2)
+	require.NotContains(t, rec.starts[0], "generation_name")
+	require.Equal(t, "plan", rec.starts[1]["generation_name"])
+	require.Equal(t, 2, rec.starts[1]["iteration"])
+}
+
+func TestManagerGenerationEndReasoning(t *testing.T) {
+	rec := &recordingCallback{}
+	cm := NewManager([]AgentCallback{rec}, nil)
+
+	cm.OnGenerationEnd("stop", "4", nil, &openai.CompletionUsage{TotalTokens: 3})
+	cm.OnGenerationEndInfo(GenerationEndInfo{FinishReason: "stop", Content: "4", Reasoning: "2+2 is 4"})
+
+	require.Len(t, rec.ends, 2)
+	require.NotContains(t, rec.ends[0], "reasoning")
+	require.Equal(t, "4", rec.ends[0]["content"])
+	require.Equal(t, "2+2 is 4", rec.ends[1]["reasoning"])
+}
@@ -0,0 +1,165 @@
+package callback
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// Metric names recorded by the Prometheus and StatsD callbacks, durations end in _seconds
+const (
+	metricRuns               = "runs_total"
+	metricRunDuration        = "run_duration_seconds"
+	metricRunIterations      = "run_iterations"
+	metricGenerationDuration = "generation_duration_seconds"
+	metricTokens             = "tokens_total"
+	metricToolCalls          = "tool_calls_total"
+	metricToolDuration       = "tool_duration_seconds"
+	metricErrors             = "errors_total"
+)
+
+// metricSink receives the measurements of a metricsRecorder
+type metricSink interface {
+	count(name string, value float64, labels map[string]string)
+	observe(name string, value float64, labels map[string]string)
+}
+
+// metricsRecorder turns lifecycle events into counters and histograms for a metricSink
+// It implements every AgentCallback method except Name
+type metricsRecorder struct {
+	sink metricSink
+
+	mu          sync.Mutex
+	runs        map[string]time.Time         // run_id -> start
+	generations map[string]pendingGeneration // run_id -> generation in flight
+	tools       map[string]time.Time         // tool_call_id -> start
+}
+
+type pendingGeneration struct {
+	start time.Time
+	model string
+}
+
+func newMetricsRecorder(sink metricSink) *metricsRecorder {
+	return &metricsRecorder{
+		sink:        sink,
+		runs:        make(map[string]time.Time),
+		generations: make(map[string]pendingGeneration),
+		tools:       make(map[string]time.Time),
+	}
+}
+
+func (m *metricsRecorder) OnRunStart(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[runID] = time.Now()
+}
+
+func (m *metricsRecorder) OnRunEnd(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	m.endRun(runID, "ok")
+
+	if iterations, ok := ctx["total_iterations"].(int); ok {
+		m.sink.observe(metricRunIterations, float64(iterations), nil)
+	}
+}
+
+func (m *metricsRecorder) OnGenerationStart(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	model, _ := ctx["model"].(string)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generations[runID] = pendingGeneration{start: time.Now(), model: model}
+}
+
+func (m *metricsRecorder) OnGenerationEnd(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	generation, ok := m.endGeneration(runID, "ok")
+	if !ok {
+		return
+	}
+
+	usage, ok := ctx["usage"].(*openai.CompletionUsage)
+	if !ok || usage == nil {
+		return
+	}
+	m.sink.count(metricTokens, float64(usage.PromptTokens), map[string]string{"model": generation.model, "type": "input"})
+	m.sink.count(metricTokens, float64(usage.CompletionTokens), map[string]string{"model": generation.model, "type": "output"})
+}
+
+func (m *metricsRecorder) OnToolCallStart(ctx map[string]interface{}) {
+	toolCallID, _ := ctx["tool_call_id"].(string)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools[toolCallID] = time.Now()
+}
+
+func (m *metricsRecorder) OnToolCallEnd(ctx map[string]interface{}) {
+	toolCallID, _ := ctx["tool_call_id"].(string)
+	toolName, _ := ctx["tool_name"].(string)
+
+	m.mu.Lock()
+	start, ok := m.tools[toolCallID]
+	delete(m.tools, toolCallID)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	status := "ok"
+	if _, failed := ctx["error"]; failed {
+		status = "error"
+	}
+
+	labels := map[string]string{"tool": toolName, "status": status}
+	m.sink.count(metricToolCalls, 1, labels)
+	m.sink.observe(metricToolDuration, time.Since(start).Seconds(), labels)
+}
+
+func (m *metricsRecorder) OnError(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	stage, _ := ctx["stage"].(string)
+	m.sink.count(metricErrors, 1, map[string]string{"stage": stage})
+
+	switch stage {
+	case "run":
+		m.endRun(runID, "error")
+	case "generation":
+		m.endGeneration(runID, "error")
+	}
+}
+
+// endRun records a finished run
+func (m *metricsRecorder) endRun(runID, status string) {
+	m.mu.Lock()
+	start, ok := m.runs[runID]
+	delete(m.runs, runID)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	labels := map[string]string{"status": status}
+	m.sink.count(metricRuns, 1, labels)
+	m.sink.observe(metricRunDuration, time.Since(start).Seconds(), labels)
+}
+
+// endGeneration records and returns the in-flight generation of a run
+func (m *metricsRecorder) endGeneration(runID, status string) (pendingGeneration, bool) {
+	m.mu.Lock()
+	generation, ok := m.generations[runID]
+	delete(m.generations, runID)
+	m.mu.Unlock()
+	if !ok {
+		return generation, false
+	}
+
+	m.sink.observe(metricGenerationDuration, time.Since(generation.start).Seconds(),
+		map[string]string{"model": generation.model, "status": status})
+	return generation, true
+}
@@ -0,0 +1,253 @@
+package callback
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// RunTranscript is a serializable record of a single agent run
+// It captures every iteration (messages sent, model response, tool calls and results)
+// so runs can be audited or replayed without an external tracing backend
+type RunTranscript struct {
+	RunID       string                `json:"run_id"`
+	ParentRunID string                `json:"parent_run_id,omitempty"`
+	Model       string                `json:"model"`
+	Input       any                   `json:"input,omitempty"`
+	Output      any                   `json:"output,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	ErrorStage  string                `json:"error_stage,omitempty"`
+	StartedAt   time.Time             `json:"started_at"`
+	EndedAt     time.Time             `json:"ended_at"`
+	Iterations  []TranscriptIteration `json:"iterations"`
+}
+
+// TranscriptIteration records one LLM call and the tool executions it triggered
+type TranscriptIteration struct {
+	Iteration    int                                      `json:"iteration"`
+	Model        string                                   `json:"model"`
+	Messages     []openai.ChatCompletionMessageParamUnion `json:"messages"`
+	FinishReason string                                   `json:"finish_reason,omitempty"`
+	Content      string                                   `json:"content,omitempty"`
+	Reasoning    string                                   `json:"reasoning,omitempty"`
+	ToolCalls    []openai.ChatCompletionMessageToolCall   `json:"tool_calls,omitempty"`
+	Usage        *openai.CompletionUsage                  `json:"usage,omitempty"`
+	ToolResults  []TranscriptToolResult                   `json:"tool_results,omitempty"`
+	StartedAt    time.Time                                `json:"started_at"`
+	EndedAt      time.Time                                `json:"ended_at"`
+}
+
+// TranscriptToolResult records a single tool execution
+type TranscriptToolResult struct {
+	ToolCallID string                 `json:"tool_call_id"`
+	ToolName   string                 `json:"tool_name"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Result     any                    `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	EndedAt    time.Time              `json:"ended_at"`
+}
+
+// WriteJSON writes the transcript as indented JSON
+func (t *RunTranscript) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// ReadTranscript decodes a transcript previously written with WriteJSON
+func ReadTranscript(r io.Reader) (*RunTranscript, error) {
+	var t RunTranscript
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	return &t, nil
+}
+
+// TranscriptCallback records a RunTranscript for the run it is attached to
+// Create a new one per Invoke (via InvokeConfig.Callbacks) and read it with Transcript() afterwards
+type TranscriptCallback struct {
+	BaseCallback
+
+	mu         sync.Mutex
+	transcript *RunTranscript
+	pending    map[string]int // tool_call_id -> index in current iteration's ToolResults
+}
+
+// NewTranscriptCallback creates a new transcript recorder
+func NewTranscriptCallback() *TranscriptCallback {
+	return &TranscriptCallback{
+		pending: make(map[string]int),
+	}
+}
+
+func (tc *TranscriptCallback) Name() string {
+	return "TranscriptCallback"
+}
+
+// Transcript returns the recorded transcript, or nil if no run was recorded
+func (tc *TranscriptCallback) Transcript() *RunTranscript {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.transcript
+}
+
+// OnRunStart starts a new transcript
+func (tc *TranscriptCallback) OnRunStart(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	runID, _ := ctx["run_id"].(string)
+	parentRunID, _ := ctx["parent_run_id"].(string)
+	model, _ := ctx["model"].(string)
+
+	tc.transcript = &RunTranscript{
+		RunID:       runID,
+		ParentRunID: parentRunID,
+		Model:       model,
+		Input:       ctx["input"],
+		StartedAt:   time.Now(),
+		Iterations:  []TranscriptIteration{},
+	}
+	tc.pending = make(map[string]int)
+}
+
+// OnRunEnd records the final output
+func (tc *TranscriptCallback) OnRunEnd(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.transcript == nil {
+		return
+	}
+
+	tc.transcript.Output = ctx["output"]
+	tc.transcript.EndedAt = time.Now()
+}
+
+// OnGenerationStart opens a new iteration with a snapshot of the messages sent
+func (tc *TranscriptCallback) OnGenerationStart(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.transcript == nil {
+		return
+	}
+
+	iteration, _ := ctx["iteration"].(int)
+	model, _ := ctx["model"].(string)
+
+	var messages []openai.ChatCompletionMessageParamUnion
+	if m, ok := ctx["messages"].([]openai.ChatCompletionMessageParamUnion); ok {
+		messages = make([]openai.ChatCompletionMessageParamUnion, len(m))
+		copy(messages, m)
+	}
+
+	tc.transcript.Iterations = append(tc.transcript.Iterations, TranscriptIteration{
+		Iteration: iteration,
+		Model:     model,
+		Messages:  messages,
+		StartedAt: time.Now(),
+	})
+	tc.pending = make(map[string]int)
+}
+
+// OnGenerationEnd records the model response of the current iteration
+func (tc *TranscriptCallback) OnGenerationEnd(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	current := tc.currentIteration()
+	if current == nil {
+		return
+	}
+
+	current.FinishReason, _ = ctx["finish_reason"].(string)
+	current.Content, _ = ctx["content"].(string)
+	current.Reasoning, _ = ctx["reasoning"].(string)
+	current.ToolCalls, _ = ctx["tool_calls"].([]openai.ChatCompletionMessageToolCall)
+	current.Usage, _ = ctx["usage"].(*openai.CompletionUsage)
+	current.EndedAt = time.Now()
+}
+
+// OnToolCallStart records the start of a tool execution
+func (tc *TranscriptCallback) OnToolCallStart(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	current := tc.currentIteration()
+	if current == nil {
+		return
+	}
+
+	toolCallID, _ := ctx["tool_call_id"].(string)
+	toolName, _ := ctx["tool_name"].(string)
+	arguments, _ := ctx["arguments"].(map[string]interface{})
+
+	current.ToolResults = append(current.ToolResults, TranscriptToolResult{
+		ToolCallID: toolCallID,
+		ToolName:   toolName,
+		Arguments:  arguments,
+		StartedAt:  time.Now(),
+	})
+	tc.pending[toolCallID] = len(current.ToolResults) - 1
+}
+
+// OnToolCallEnd records the result of a tool execution
+func (tc *TranscriptCallback) OnToolCallEnd(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	current := tc.currentIteration()
+	if current == nil {
+		return
+	}
+
+	toolCallID, _ := ctx["tool_call_id"].(string)
+	idx, ok := tc.pending[toolCallID]
+	if !ok {
+		// Tool failed before OnToolCallStart (e.g. invalid arguments)
+		toolName, _ := ctx["tool_name"].(string)
+		current.ToolResults = append(current.ToolResults, TranscriptToolResult{
+			ToolCallID: toolCallID,
+			ToolName:   toolName,
+			StartedAt:  time.Now(),
+		})
+		idx = len(current.ToolResults) - 1
+	}
+	delete(tc.pending, toolCallID)
+
+	result := &current.ToolResults[idx]
+	result.Result = ctx["result"]
+	result.Error, _ = ctx["error"].(string)
+	result.EndedAt = time.Now()
+}
+
+// OnError records the error and the stage it happened in
+func (tc *TranscriptCallback) OnError(ctx map[string]interface{}) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.transcript == nil {
+		return
+	}
+
+	// Keep the first error, later ones are the same failure bubbling up
+	if tc.transcript.Error == "" {
+		tc.transcript.Error, _ = ctx["error"].(string)
+		tc.transcript.ErrorStage, _ = ctx["stage"].(string)
+	}
+	tc.transcript.EndedAt = time.Now()
+}
+
+// currentIteration returns the last recorded iteration
+func (tc *TranscriptCallback) currentIteration() *TranscriptIteration {
+	if tc.transcript == nil || len(tc.transcript.Iterations) == 0 {
+		return nil
+	}
+	return &tc.transcript.Iterations[len(tc.transcript.Iterations)-1]
+}
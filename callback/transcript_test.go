@@ -0,0 +1,93 @@
+package callback
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptCallbackRecordsRun(t *testing.T) {
+	tc := NewTranscriptCallback()
+	cm := NewManager([]AgentCallback{tc}, nil)
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("what is 2+2?")}
+	toolCalls := []openai.ChatCompletionMessageToolCall{{ID: "call-1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "add"}}}
+
+	cm.OnRunStart("gpt-4o", "what is 2+2?", false)
+	cm.OnGenerationStart(1, messages, "gpt-4o")
+	cm.OnGenerationEndInfo(GenerationEndInfo{FinishReason: "tool_calls", Reasoning: "use the tool", ToolCalls: toolCalls})
+	cm.OnToolCallStart("add", map[string]interface{}{"a": 2, "b": 2}, "call-1")
+	cm.OnToolCallEnd("add", map[string]interface{}{"a": 2, "b": 2}, 4, "call-1", nil)
+	// a tool that failed before it started
+	cm.OnToolCallEnd("broken", nil, nil, "call-2", errors.New("invalid arguments"))
+	cm.OnGenerationStart(2, messages, "gpt-4o")
+	cm.OnGenerationEnd("stop", "4", nil, &openai.CompletionUsage{TotalTokens: 7})
+	cm.OnRunEnd("4", 2)
+
+	transcript := tc.Transcript()
+	require.NotNil(t, transcript)
+	require.NotEmpty(t, transcript.RunID)
+	require.Equal(t, "gpt-4o", transcript.Model)
+	require.Equal(t, "what is 2+2?", transcript.Input)
+	require.Equal(t, "4", transcript.Output)
+	require.Empty(t, transcript.Error)
+	require.Len(t, transcript.Iterations, 2)
+
+	first := transcript.Iterations[0]
+	require.Equal(t, 1, first.Iteration)
+	require.Len(t, first.Messages, 1)
+	require.Equal(t, "tool_calls", first.FinishReason)
+	require.Equal(t, "use the tool", first.Reasoning)
+	require.Equal(t, toolCalls, first.ToolCalls)
+	require.Len(t, first.ToolResults, 2)
+	require.Equal(t, "add", first.ToolResults[0].ToolName)
+	require.Equal(t, 4, first.ToolResults[0].Result)
+	require.Empty(t, first.ToolResults[0].Error)
+	require.Equal(t, "broken", first.ToolResults[1].ToolName)
+	require.Equal(t, "invalid arguments", first.ToolResults[1].Error)
+
+	second := transcript.Iterations[1]
+	require.Equal(t, "4", second.Content)
+	require.EqualValues(t, 7, second.Usage.TotalTokens)
+	require.Empty(t, second.ToolResults)
+}
+
+func TestTranscriptCallbackKeepsFirstError(t *testing.T) {
+	tc := NewTranscriptCallback()
+	cm := NewManager([]AgentCallback{tc}, nil)
+
+	cm.OnRunStart("gpt-4o", "hi", false)
+	cm.OnError(errors.New("rate limited"), "generation")
+	cm.OnError(errors.New("run failed: rate limited"), "run")
+
+	require.Equal(t, "rate limited", tc.Transcript().Error)
+	require.Equal(t, "generation", tc.Transcript().ErrorStage)
+}
+
+func TestTranscriptJSONRoundTrip(t *testing.T) {
+	tc := NewTranscriptCallback()
+	cm := NewManager([]AgentCallback{tc}, nil)
+
+	cm.OnRunStart("gpt-4o", "hi", false)
+	cm.OnGenerationStart(1, []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")}, "gpt-4o")
+	cm.OnGenerationEnd("stop", "hello", nil, nil)
+	cm.OnRunEnd("hello", 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, tc.Transcript().WriteJSON(&buf))
+
+	read, err := ReadTranscript(&buf)
+	require.NoError(t, err)
+	require.Equal(t, tc.Transcript().RunID, read.RunID)
+	require.Equal(t, "hello", read.Output)
+	require.Len(t, read.Iterations, 1)
+	require.Equal(t, "hello", read.Iterations[0].Content)
+	require.Len(t, read.Iterations[0].Messages, 1)
+	require.Equal(t, "hi", read.Iterations[0].Messages[0].OfUser.Content.OfString.Value)
+
+	_, err = ReadTranscript(bytes.NewBufferString("{"))
+	require.ErrorContains(t, err, "failed to decode transcript")
+}
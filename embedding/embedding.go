@@ -2,6 +2,26 @@ package embedding
 
 import "context"
 
+// Client embeds documents for storage and queries for search
+// Models like Cohere or Jina embed queries differently from documents, others treat both the same
 type Client interface {
 	EmbedTexts(ctx context.Context, texts []string) ([][]float64, error)
+	EmbedQuery(ctx context.Context, text string) ([]float64, error)
+}
+
+// Named is implemented by clients that can report the embedding model they use
+type Named interface {
+	Model() string
+}
+
+// Usage is the token usage of an embedding request
+type Usage struct {
+	PromptTokens int64
+	TotalTokens  int64
+}
+
+// UsageReporter is implemented by clients that can report the token usage of a request
+type UsageReporter interface {
+	EmbedTextsWithUsage(ctx context.Context, texts []string) ([][]float64, Usage, error)
+	EmbedQueryWithUsage(ctx context.Context, text string) ([]float64, Usage, error)
 }
@@ -0,0 +1,70 @@
+package embedding
+
+import (
+	"math"
+	"strings"
+)
+
+// Dimensioned is implemented by clients that know the native dimensions of their model
+type Dimensioned interface {
+	// Dimensions returns the length of the vectors the model produces, 0 if unknown
+	Dimensions() int
+
+	// Matryoshka reports whether the model is trained with Matryoshka representation learning,
+	// so a prefix of its vectors is a valid embedding once normalized (see Truncate)
+	Matryoshka() bool
+}
+
+// Truncate keeps the first dimensions of a Matryoshka embedding and L2 normalizes the result
+func Truncate(vector []float64, dimensions int) []float64 {
+	if dimensions <= 0 || dimensions >= len(vector) {
+		return vector
+	}
+
+	truncated := make([]float64, dimensions)
+	copy(truncated, vector[:dimensions])
+
+	var norm float64
+	for _, v := range truncated {
+		norm += v * v
+	}
+	if norm == 0 {
+		return truncated
+	}
+	norm = math.Sqrt(norm)
+	for i := range truncated {
+		truncated[i] /= norm
+	}
+	return truncated
+}
+
+// knownModel is the native size of a model served through an OpenAI compatible API
+type knownModel struct {
+	dimensions int
+	matryoshka bool
+}
+
+var knownModels = map[string]knownModel{
+	"text-embedding-3-small":  {dimensions: 1536, matryoshka: true},
+	"text-embedding-3-large":  {dimensions: 3072, matryoshka: true},
+	"text-embedding-ada-002":  {dimensions: 1536},
+	"gemini-embedding-001":    {dimensions: 3072, matryoshka: true},
+	"nomic-embed-text-v1.5":   {dimensions: 768, matryoshka: true},
+	"jina-embeddings-v3":      {dimensions: 1024, matryoshka: true},
+	"mxbai-embed-large-v1":    {dimensions: 1024, matryoshka: true},
+	"embed-english-v3.0":      {dimensions: 1024},
+	"embed-multilingual-v3.0": {dimensions: 1024},
+	"bge-small-en-v1.5":       {dimensions: 384},
+	"bge-large-en-v1.5":       {dimensions: 1024},
+	"all-minilm-l6-v2":        {dimensions: 384},
+}
+
+// lookupModel finds a known model by name, ignoring case and a provider prefix like "openai/"
+func lookupModel(model string) (knownModel, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	known, ok := knownModels[name]
+	return known, ok
+}
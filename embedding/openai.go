@@ -5,11 +5,21 @@ import (
 
 	"github.com/mhrlife/goai-kit/kit"
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
 type OpenAIEmbeddings struct {
 	client openai.Client
 	model  string
+
+	queryInputType    string
+	documentInputType string
+	queryPrefix       string
+	documentPrefix    string
+	normalize         func(string) string
+
+	dimensions int
+	matryoshka bool
 }
 
 // NewOpenAIEmbeddings creates a new OpenAI embeddings client.
@@ -18,15 +28,104 @@ func NewOpenAIEmbeddings(client *kit.Client, model string) *OpenAIEmbeddings {
 	if model == "" {
 		model = "text-embedding-3-small"
 	}
+	known, _ := lookupModel(model)
 	return &OpenAIEmbeddings{
-		client: client.GetOpenAI(),
-		model:  model,
+		client:     client.GetOpenAI(),
+		model:      model,
+		dimensions: known.dimensions,
+		matryoshka: known.matryoshka,
 	}
 }
 
+// WithInputType sends input_type with every request, e.g. "search_query" and "search_document" for Cohere
+// or "query" and "document" for Jina and Voyage on OpenAI compatible endpoints
+func (o *OpenAIEmbeddings) WithInputType(query, document string) *OpenAIEmbeddings {
+	o.queryInputType = query
+	o.documentInputType = document
+	return o
+}
+
+// WithPrefixes prepends instructions to the embedded texts, e.g. "query: " and "passage: " for E5 models
+func (o *OpenAIEmbeddings) WithPrefixes(query, document string) *OpenAIEmbeddings {
+	o.queryPrefix = query
+	o.documentPrefix = document
+	return o
+}
+
+// WithNormalizer rewrites documents and queries before they are embedded, e.g. textnorm.Normalize so Persian text
+// typed with Arabic letters or digits lands next to the same text typed with Persian ones
+func (o *OpenAIEmbeddings) WithNormalizer(normalize func(string) string) *OpenAIEmbeddings {
+	o.normalize = normalize
+	return o
+}
+
+// WithModelInfo sets the native dimensions of a model that isn't known to the package
+// and whether its vectors can be truncated (Matryoshka representation learning)
+func (o *OpenAIEmbeddings) WithModelInfo(dimensions int, matryoshka bool) *OpenAIEmbeddings {
+	o.dimensions = dimensions
+	o.matryoshka = matryoshka
+	return o
+}
+
+// Dimensions returns the native dimensions of the model, 0 if unknown
+func (o *OpenAIEmbeddings) Dimensions() int {
+	return o.dimensions
+}
+
+// Matryoshka reports whether the model's vectors can be truncated
+func (o *OpenAIEmbeddings) Matryoshka() bool {
+	return o.matryoshka
+}
+
+// Model returns the embedding model name
+func (o *OpenAIEmbeddings) Model() string {
+	return o.model
+}
+
 func (o *OpenAIEmbeddings) EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, _, err := o.EmbedTextsWithUsage(ctx, texts)
+	return embeddings, err
+}
+
+// EmbedQuery embeds a search query
+func (o *OpenAIEmbeddings) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	embedding, _, err := o.EmbedQueryWithUsage(ctx, text)
+	return embedding, err
+}
+
+// EmbedTextsWithUsage embeds texts and returns the token usage reported by the API
+func (o *OpenAIEmbeddings) EmbedTextsWithUsage(ctx context.Context, texts []string) ([][]float64, Usage, error) {
+	return o.embed(ctx, texts, o.documentPrefix, o.documentInputType)
+}
+
+// EmbedQueryWithUsage embeds a search query and returns the token usage reported by the API
+func (o *OpenAIEmbeddings) EmbedQueryWithUsage(ctx context.Context, text string) ([]float64, Usage, error) {
+	embeddings, usage, err := o.embed(ctx, []string{text}, o.queryPrefix, o.queryInputType)
+	if err != nil {
+		return nil, usage, err
+	}
+	return embeddings[0], usage, nil
+}
+
+func (o *OpenAIEmbeddings) embed(ctx context.Context, texts []string, prefix, inputType string) ([][]float64, Usage, error) {
 	if len(texts) == 0 {
-		return [][]float64{}, nil
+		return [][]float64{}, Usage{}, nil
+	}
+
+	if prefix != "" || o.normalize != nil {
+		prepared := make([]string, len(texts))
+		for i, text := range texts {
+			if o.normalize != nil {
+				text = o.normalize(text)
+			}
+			prepared[i] = prefix + text
+		}
+		texts = prepared
+	}
+
+	var opts []option.RequestOption
+	if inputType != "" {
+		opts = append(opts, option.WithJSONSet("input_type", inputType))
 	}
 
 	resp, err := o.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
@@ -34,9 +133,9 @@ func (o *OpenAIEmbeddings) EmbedTexts(ctx context.Context, texts []string) ([][]
 			OfArrayOfStrings: texts,
 		},
 		Model: o.model,
-	})
+	}, opts...)
 	if err != nil {
-		return nil, err
+		return nil, Usage{}, err
 	}
 
 	// Extract embeddings from response
@@ -45,5 +144,5 @@ func (o *OpenAIEmbeddings) EmbedTexts(ctx context.Context, texts []string) ([][]
 		embeddings[i] = data.Embedding
 	}
 
-	return embeddings, nil
+	return embeddings, Usage{PromptTokens: resp.Usage.PromptTokens, TotalTokens: resp.Usage.TotalTokens}, nil
 }
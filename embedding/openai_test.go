@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIEmbeddingsQueryAndDocumentInputs(t *testing.T) {
+	var bodies []map[string]any
+	server := newEmbeddingServer(t, &bodies)
+	client := kit.NewClient(kit.WithAPIKey("test"), kit.WithBaseURL(server.URL))
+	embedder := NewOpenAIEmbeddings(client, "embed-english-v3.0").
+		WithInputType("search_query", "search_document").
+		WithPrefixes("query: ", "passage: ")
+
+	vector, usage, err := embedder.EmbedQueryWithUsage(context.Background(), "what is go?")
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 0}, vector)
+	require.Equal(t, int64(3), usage.PromptTokens)
+
+	_, err = embedder.EmbedTexts(context.Background(), []string{"Go is a language", "Redis is a database"})
+	require.NoError(t, err)
+
+	require.Equal(t, "search_query", bodies[0]["input_type"])
+	require.Equal(t, []any{"query: what is go?"}, bodies[0]["input"])
+	require.Equal(t, "search_document", bodies[1]["input_type"])
+	require.Equal(t, []any{"passage: Go is a language", "passage: Redis is a database"}, bodies[1]["input"])
+}
+
+func TestOpenAIEmbeddingsNormalizer(t *testing.T) {
+	var bodies []map[string]any
+	server := newEmbeddingServer(t, &bodies)
+	client := kit.NewClient(kit.WithAPIKey("test"), kit.WithBaseURL(server.URL))
+	embedder := NewOpenAIEmbeddings(client, "").WithPrefixes("query: ", "").WithNormalizer(strings.ToLower)
+
+	_, err := embedder.EmbedQuery(context.Background(), "What Is Go?")
+	require.NoError(t, err)
+	_, err = embedder.EmbedTexts(context.Background(), []string{"Go Is A Language"})
+	require.NoError(t, err)
+
+	require.Equal(t, []any{"query: what is go?"}, bodies[0]["input"])
+	require.Equal(t, []any{"go is a language"}, bodies[1]["input"])
+}
+
+// newEmbeddingServer answers embedding requests with [1, 0] vectors and records their bodies
+func newEmbeddingServer(t *testing.T, bodies *[]map[string]any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*bodies = append(*bodies, body)
+
+		data := []map[string]any{}
+		for i := range body["input"].([]any) {
+			data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": []float64{1, 0}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"model":  body["model"],
+			"data":   data,
+			"usage":  map[string]any{"prompt_tokens": 3, "total_tokens": 3},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,29 @@
+package embedding
+
+import "context"
+
+// SparseVector is a sparse embedding (e.g. SPLADE or BM42) as parallel term indices and weights
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float64 `json:"values"`
+}
+
+// Dot returns the dot product of two sparse vectors, the relevance score of sparse retrieval
+func (v SparseVector) Dot(other SparseVector) float64 {
+	weights := make(map[uint32]float64, len(v.Indices))
+	for i, index := range v.Indices {
+		weights[index] = v.Values[i]
+	}
+
+	var score float64
+	for i, index := range other.Indices {
+		score += weights[index] * other.Values[i]
+	}
+	return score
+}
+
+// SparseEmbedder produces sparse embeddings for documents and queries
+type SparseEmbedder interface {
+	EmbedSparseTexts(ctx context.Context, texts []string) ([]SparseVector, error)
+	EmbedSparseQuery(ctx context.Context, text string) (SparseVector, error)
+}
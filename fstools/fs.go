@@ -0,0 +1,498 @@
+// Package fstools gives agents read, write, list and search tools over a set of root directories
+// Paths can't leave the roots, symlinks included
+package fstools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrOutsideRoot is returned for paths that resolve outside every root
+var ErrOutsideRoot = errors.New("path is outside the allowed roots")
+
+// Root is a directory the tools can access
+type Root struct {
+	// Name prefixes the paths of the root when there are several roots (optional, defaults to the directory name)
+	Name string
+
+	// Path is the directory (required)
+	Path string
+
+	// ReadOnly refuses writes in the root (optional)
+	ReadOnly bool
+}
+
+// Config configures an FS
+type Config struct {
+	// Roots are the directories the tools can access (required)
+	Roots []Root
+
+	// Extensions limits the files that can be read, written and searched, e.g. ".go" or ".md" (optional, all by default)
+	Extensions []string
+
+	// MaxFileBytes is the largest file that can be read or written (optional, defaults to 1MB)
+	MaxFileBytes int64
+
+	// MaxEntries caps the entries of a listing and the matches of a search (optional, defaults to 500)
+	MaxEntries int
+}
+
+// FS is a file system scoped to the configured roots
+type FS struct {
+	config Config
+	roots  []scopedRoot
+}
+
+type scopedRoot struct {
+	Root
+	resolved string // absolute path with symlinks evaluated
+}
+
+// Entry is a file or directory in a listing
+type Entry struct {
+	Path string `json:"path"`
+	Dir  bool   `json:"dir,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// Match is a line matching a search
+type Match struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// New creates a scoped file system, every root must be an existing directory
+func New(config Config) (*FS, error) {
+	if len(config.Roots) == 0 {
+		return nil, fmt.Errorf("at least one root is required")
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = 1 << 20
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 500
+	}
+	for i, ext := range config.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		config.Extensions[i] = strings.ToLower(ext)
+	}
+
+	f := &FS{config: config}
+	names := make(map[string]bool)
+	for _, root := range config.Roots {
+		abs, err := filepath.Abs(root.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %q: %w", root.Path, err)
+		}
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %q: %w", root.Path, err)
+		}
+		if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("root %q is not a directory", root.Path)
+		}
+
+		if root.Name == "" {
+			root.Name = filepath.Base(resolved)
+		}
+		if names[root.Name] {
+			return nil, fmt.Errorf("duplicate root name %q", root.Name)
+		}
+		names[root.Name] = true
+		f.roots = append(f.roots, scopedRoot{Root: root, resolved: resolved})
+	}
+	return f, nil
+}
+
+// Read returns the content of a text file
+func (f *FS) Read(p string) (string, error) {
+	root, full, err := f.resolve(p)
+	if err != nil {
+		return "", err
+	}
+	if err := f.checkExtension(p); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return "", f.pathError(root, full, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", p)
+	}
+	if info.Size() > f.config.MaxFileBytes {
+		return "", fmt.Errorf("%s is %d bytes, larger than the %d bytes limit", p, info.Size(), f.config.MaxFileBytes)
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return "", f.pathError(root, full, err)
+	}
+	if !isText(data) {
+		return "", fmt.Errorf("%s is not a text file", p)
+	}
+	return string(data), nil
+}
+
+// Write replaces or appends to a file, creating it and its parent directories when needed
+func (f *FS) Write(p string, content string, appendTo bool) error {
+	root, full, err := f.resolve(p)
+	if err != nil {
+		return err
+	}
+	if root.ReadOnly {
+		return fmt.Errorf("%s is in the read-only root %q", p, root.Name)
+	}
+	if err := f.checkExtension(p); err != nil {
+		return err
+	}
+
+	size := int64(len(content))
+	if appendTo {
+		if info, err := os.Stat(full); err == nil {
+			size += info.Size()
+		}
+	}
+	if size > f.config.MaxFileBytes {
+		return fmt.Errorf("writing %s would make it %d bytes, larger than the %d bytes limit", p, size, f.config.MaxFileBytes)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return f.pathError(root, full, err)
+	}
+	// the file is opened by its resolved path without following links, so a link created since the check
+	// can't redirect the write
+	target, err := resolveExisting(full)
+	if err != nil {
+		return f.pathError(root, full, err)
+	}
+	if !root.contains(target) {
+		return fmt.Errorf("%s: %w", p, ErrOutsideRoot)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC | openNoFollow
+	if appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND | openNoFollow
+	}
+	file, err := os.OpenFile(target, flags, 0o644)
+	if err != nil {
+		return f.pathError(root, full, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return f.pathError(root, full, err)
+	}
+	return file.Close()
+}
+
+// List returns the entries of a directory, recursively when asked to
+// Hidden directories are skipped in recursive listings, "" lists the roots when there are several
+func (f *FS) List(ctx context.Context, p string, recursive bool) ([]Entry, bool, error) {
+	if f.isTop(p) {
+		entries := make([]Entry, len(f.roots))
+		for i, root := range f.roots {
+			entries[i] = Entry{Path: root.Name, Dir: true}
+		}
+		return entries, false, nil
+	}
+
+	var entries []Entry
+	truncated := false
+	err := f.walk(ctx, p, recursive, func(rel string, d fs.DirEntry) error {
+		if len(entries) >= f.config.MaxEntries {
+			truncated = true
+			return fs.SkipAll
+		}
+		if d.IsDir() {
+			entries = append(entries, Entry{Path: rel, Dir: true})
+			return nil
+		}
+		if f.checkExtension(rel) != nil {
+			return nil
+		}
+		entry := Entry{Path: rel}
+		if info, err := d.Info(); err == nil {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, truncated, err
+}
+
+// Search returns the lines of text files under p matching pattern, a regular expression
+// glob optionally filters file names, e.g. "*_test.go"
+func (f *FS) Search(ctx context.Context, p string, pattern string, glob string) ([]Match, bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if glob != "" {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, false, fmt.Errorf("invalid glob: %w", err)
+		}
+	}
+
+	var matches []Match
+	truncated := false
+	search := func(rel string, d fs.DirEntry) error {
+		if d.IsDir() || f.checkExtension(rel) != nil {
+			return nil
+		}
+		if glob != "" {
+			if ok, _ := path.Match(glob, d.Name()); !ok {
+				return nil
+			}
+		}
+		if info, err := d.Info(); err != nil || info.Size() > f.config.MaxFileBytes {
+			return nil
+		}
+
+		_, full, err := f.resolve(rel)
+		if err != nil {
+			return nil
+		}
+		data, err := os.ReadFile(full)
+		if err != nil || !isText(data) {
+			return nil
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64<<10), int(f.config.MaxFileBytes))
+		for line := 1; scanner.Scan(); line++ {
+			if !re.Match(scanner.Bytes()) {
+				continue
+			}
+			if len(matches) >= f.config.MaxEntries {
+				truncated = true
+				return fs.SkipAll
+			}
+			matches = append(matches, Match{Path: rel, Line: line, Text: clip(scanner.Text(), 300)})
+		}
+		return nil
+	}
+
+	if f.isTop(p) {
+		for _, root := range f.roots {
+			if err := f.walk(ctx, root.Name, true, search); err != nil {
+				return matches, truncated, err
+			}
+			if truncated {
+				break
+			}
+		}
+		return matches, truncated, nil
+	}
+	return matches, truncated, f.walk(ctx, p, true, search)
+}
+
+// walk calls fn with the tool path of every entry under p
+func (f *FS) walk(ctx context.Context, p string, recursive bool, fn func(rel string, d fs.DirEntry) error) error {
+	root, full, err := f.resolve(p)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return f.pathError(root, full, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", p)
+	}
+
+	err = filepath.WalkDir(full, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if current == full {
+			return nil
+		}
+		// symlinks are listed but never followed out of the root
+		if d.Type()&fs.ModeSymlink != 0 {
+			if _, _, err := f.resolve(f.toolPath(root, current)); err != nil {
+				return nil
+			}
+		}
+
+		rel := f.toolPath(root, current)
+		if err := fn(rel, d); err != nil {
+			return err
+		}
+		if d.IsDir() && (!recursive || strings.HasPrefix(d.Name(), ".")) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// resolve maps a tool path to its root and absolute file path, refusing anything outside the roots
+func (f *FS) resolve(p string) (scopedRoot, string, error) {
+	p = filepath.ToSlash(strings.TrimSpace(p))
+	if path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return scopedRoot{}, "", fmt.Errorf("%s: %w, use a path relative to a root", p, ErrOutsideRoot)
+	}
+
+	root := f.roots[0]
+	rel := path.Clean(p)
+	if len(f.roots) > 1 {
+		name, rest, _ := strings.Cut(rel, "/")
+		found := false
+		for _, candidate := range f.roots {
+			if candidate.Name == name {
+				root, rel, found = candidate, rest, true
+				break
+			}
+		}
+		if !found {
+			return scopedRoot{}, "", fmt.Errorf("%s: %w, paths start with one of the roots %s", p, ErrOutsideRoot, f.rootNames())
+		}
+		rel = path.Clean("/" + rel)[1:]
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return scopedRoot{}, "", fmt.Errorf("%s: %w", p, ErrOutsideRoot)
+	}
+
+	full := filepath.Join(root.resolved, filepath.FromSlash(rel))
+	resolved, err := resolveExisting(full)
+	if err != nil {
+		return scopedRoot{}, "", err
+	}
+	if !root.contains(resolved) {
+		return scopedRoot{}, "", fmt.Errorf("%s: %w", p, ErrOutsideRoot)
+	}
+	return root, full, nil
+}
+
+// contains reports whether a resolved path is the root or inside it
+func (r scopedRoot) contains(resolved string) bool {
+	return resolved == r.resolved || strings.HasPrefix(resolved, r.resolved+string(filepath.Separator))
+}
+
+// maxLinks bounds the chain of dangling symlinks resolveExisting follows
+const maxLinks = 40
+
+// resolveExisting evaluates the symlinks of the longest existing prefix of a path
+// A dangling symlink is resolved to its target too, creating a file through it would follow the link
+func resolveExisting(full string) (string, error) {
+	return resolveExistingLinks(full, 0)
+}
+
+func resolveExistingLinks(full string, links int) (string, error) {
+	missing := ""
+	current := full
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if info, err := os.Lstat(current); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if links >= maxLinks {
+				return "", fmt.Errorf("%s: too many levels of symbolic links", current)
+			}
+			target, err := os.Readlink(current)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(current), target)
+			}
+			return resolveExistingLinks(filepath.Join(target, missing), links+1)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return full, nil
+		}
+		missing = filepath.Join(filepath.Base(current), missing)
+		current = parent
+	}
+}
+
+// toolPath is the path of a file as the tools show it
+func (f *FS) toolPath(root scopedRoot, full string) string {
+	rel, err := filepath.Rel(root.resolved, full)
+	if err != nil {
+		return full
+	}
+	rel = filepath.ToSlash(rel)
+	if len(f.roots) > 1 {
+		return path.Join(root.Name, rel)
+	}
+	return rel
+}
+
+// pathError rewrites an OS error so it shows the tool path instead of the absolute one
+func (f *FS) pathError(root scopedRoot, full string, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return fmt.Errorf("%s: %w", f.toolPath(root, full), pathErr.Err)
+	}
+	return err
+}
+
+func (f *FS) isTop(p string) bool {
+	p = strings.TrimSpace(p)
+	return len(f.roots) > 1 && (p == "" || p == "." || p == "/")
+}
+
+func (f *FS) checkExtension(p string) error {
+	if len(f.config.Extensions) == 0 {
+		return nil
+	}
+	ext := strings.ToLower(path.Ext(path.Clean(filepath.ToSlash(p))))
+	for _, allowed := range f.config.Extensions {
+		if ext == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: only %s files are allowed", p, strings.Join(f.config.Extensions, ", "))
+}
+
+func (f *FS) rootNames() string {
+	names := make([]string, len(f.roots))
+	for i, root := range f.roots {
+		names[i] = root.Name
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// isText reports whether data looks like UTF-8 text rather than a binary file
+func isText(data []byte) bool {
+	sample := data
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return !bytes.Contains(sample, []byte{0}) && (utf8.Valid(sample) || len(data) > len(sample))
+}
+
+func clip(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
@@ -0,0 +1,162 @@
+package fstools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+}
+
+func TestPathsStayInsideRoot(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "project")
+	writeFiles(t, base, map[string]string{
+		"secret.txt":          "password",
+		"project/main.go":     "package main\n",
+		"project/docs/a.md":   "# A\n",
+		"project/image.bin":   "\x00\x01",
+		"project/.git/config": "[core]",
+	})
+	require.NoError(t, os.Symlink(filepath.Join(base, "secret.txt"), filepath.Join(root, "link.go")))
+	require.NoError(t, os.Symlink(base, filepath.Join(root, "up")))
+
+	fsys, err := New(Config{Roots: []Root{{Path: root}}, Extensions: []string{"go", ".md"}, MaxFileBytes: 64})
+	require.NoError(t, err)
+
+	content, err := fsys.Read("docs/../main.go")
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", content)
+
+	for _, p := range []string{"../secret.txt", "/etc/passwd", "link.go", "up/secret.md"} {
+		_, err := fsys.Read(p)
+		require.ErrorIs(t, err, ErrOutsideRoot, p)
+	}
+	require.ErrorIs(t, fsys.Write("up/new.go", "x", false), ErrOutsideRoot)
+
+	_, err = fsys.Read("image.bin")
+	require.ErrorContains(t, err, "only .go, .md files are allowed")
+	require.ErrorContains(t, fsys.Write("notes.txt", "x", false), "only .go, .md files are allowed")
+	require.ErrorContains(t, fsys.Write("big.md", string(make([]byte, 65)), false), "larger than the 64 bytes limit")
+
+	_, err = fsys.Read("missing.go")
+	require.EqualError(t, err, "missing.go: no such file or directory")
+
+	require.NoError(t, fsys.Write("notes/todo.md", "- one\n", false))
+	require.NoError(t, fsys.Write("notes/todo.md", "- two\n", true))
+	content, err = fsys.Read("notes/todo.md")
+	require.NoError(t, err)
+	require.Equal(t, "- one\n- two\n", content)
+
+	entries, truncated, err := fsys.List(context.Background(), "", true)
+	require.NoError(t, err)
+	require.False(t, truncated)
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	// hidden directories aren't entered, files with other extensions and escaping links are left out
+	require.ElementsMatch(t, []string{".git", "docs", "docs/a.md", "main.go", "notes", "notes/todo.md"}, paths)
+}
+
+func TestWritesDontFollowDanglingSymlinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "project")
+	outside := filepath.Join(base, "outside")
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "pwned.txt"), filepath.Join(root, "link.txt")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing"), filepath.Join(root, "dir")))
+	require.NoError(t, os.Symlink("link.txt", filepath.Join(root, "chain.txt")))
+	require.NoError(t, os.Symlink("real.txt", filepath.Join(root, "alias.txt")))
+
+	fsys, err := New(Config{Roots: []Root{{Path: root}}})
+	require.NoError(t, err)
+
+	for _, p := range []string{"link.txt", "dir/pwned.txt", "chain.txt"} {
+		require.ErrorIs(t, fsys.Write(p, "x", false), ErrOutsideRoot, p)
+		require.ErrorIs(t, fsys.Write(p, "x", true), ErrOutsideRoot, p)
+	}
+	_, err = os.Stat(filepath.Join(outside, "pwned.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(filepath.Join(outside, "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// a dangling link to a file inside the root creates its target
+	require.NoError(t, fsys.Write("alias.txt", "hello", false))
+	content, err := os.ReadFile(filepath.Join(root, "real.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	// and writing through it again follows the now existing target
+	require.NoError(t, fsys.Write("alias.txt", " world", true))
+	text, err := fsys.Read("real.txt")
+	require.NoError(t, err)
+	require.Equal(t, "hello world", text)
+}
+
+func TestSeveralRoots(t *testing.T) {
+	src, docs := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"main.go": "package main\n\nfunc main() {\n\tTODO()\n}\n", "main_test.go": "// TODO test\n"})
+	writeFiles(t, docs, map[string]string{"guide.md": "TODO: write the guide\n"})
+
+	fsys, err := New(Config{Roots: []Root{{Name: "src", Path: src}, {Name: "docs", Path: docs, ReadOnly: true}}})
+	require.NoError(t, err)
+
+	entries, _, err := fsys.List(context.Background(), "", false)
+	require.NoError(t, err)
+	require.Equal(t, []Entry{{Path: "src", Dir: true}, {Path: "docs", Dir: true}}, entries)
+
+	matches, truncated, err := fsys.Search(context.Background(), "", `TODO`, "")
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Equal(t, []Match{
+		{Path: "src/main.go", Line: 4, Text: "TODO()"},
+		{Path: "src/main_test.go", Line: 1, Text: "// TODO test"},
+		{Path: "docs/guide.md", Line: 1, Text: "TODO: write the guide"},
+	}, matches)
+
+	matches, _, err = fsys.Search(context.Background(), "src", `TODO`, "*_test.go")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	require.ErrorContains(t, fsys.Write("docs/new.md", "x", false), `read-only root "docs"`)
+	_, err = fsys.Read("other/file.md")
+	require.ErrorContains(t, err, "paths start with one of the roots docs, src")
+}
+
+func TestTools(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"poem.txt": "one\ntwo\nthree\n"})
+
+	fsys, err := New(Config{Roots: []Root{{Path: root, ReadOnly: true}}})
+	require.NoError(t, err)
+
+	tools := fsys.Tools()
+	var names []string
+	for _, tool := range tools {
+		names = append(names, kit.GetAgentToolInfo(tool).Name)
+	}
+	require.Equal(t, []string{"read_file", "list_files", "search_files"}, names)
+
+	ctx := &kit.Context{Context: context.Background()}
+	read := &ReadFile{Path: "poem.txt", StartLine: 2, EndLine: 3, fs: fsys}
+	out, err := read.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "2\ttwo\n3\tthree\n", out)
+
+	read = &ReadFile{Path: "../poem.txt", fs: fsys}
+	out, err = read.Execute(ctx)
+	require.NoError(t, err, "mistakes are reported to the model")
+	require.Contains(t, out.(failure).Error, "outside the allowed roots")
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package fstools
+
+// openNoFollow is not available, writes rely on the symlink check of the path
+const openNoFollow = 0
@@ -0,0 +1,8 @@
+//go:build unix
+
+package fstools
+
+import "syscall"
+
+// openNoFollow makes opening a symlink fail
+const openNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,152 @@
+package fstools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// Tools returns the read_file, list_files and search_files tools, and write_file unless every root is read-only
+func (f *FS) Tools() []kit.ToolExecutor {
+	tools := []kit.ToolExecutor{&ReadFile{fs: f}, &ListFiles{fs: f}, &SearchFiles{fs: f}}
+	for _, root := range f.roots {
+		if !root.ReadOnly {
+			return append(tools, &WriteFile{fs: f})
+		}
+	}
+	return tools
+}
+
+// failure is returned to the model instead of failing the run, so it can correct the path and retry
+type failure struct {
+	Error string `json:"error"`
+}
+
+func result(ctx *kit.Context, value any, err error) (any, error) {
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return failure{Error: err.Error()}, nil
+	}
+	return value, nil
+}
+
+// ReadFile reads a text file, optionally a range of its lines
+type ReadFile struct {
+	kit.BaseTool
+	Path      string `json:"path" jsonschema:"description=Path of the file relative to a root"`
+	StartLine int    `json:"start_line" jsonschema:"description=First line to read starting at 1, 0 reads from the start"`
+	EndLine   int    `json:"end_line" jsonschema:"description=Last line to read, 0 reads to the end"`
+
+	fs *FS
+}
+
+func (t *ReadFile) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "read_file",
+		Description: "Read a text file. Lines are prefixed with their number.",
+	}
+}
+
+func (t *ReadFile) Execute(ctx *kit.Context) (any, error) {
+	content, err := t.fs.Read(t.Path)
+	if err != nil {
+		return result(ctx, nil, err)
+	}
+
+	numbered, err := Lines(content, t.StartLine, t.EndLine)
+	if err != nil {
+		return result(ctx, nil, fmt.Errorf("%s %w", t.Path, err))
+	}
+	return numbered, nil
+}
+
+// Lines returns the lines start to end of content prefixed with their number
+// start and end count from 1, 0 reads from the start or to the end
+func Lines(content string, start, end int) (string, error) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if start < 1 {
+		start = 1
+	}
+	if end < 1 || end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return "", fmt.Errorf("has %d lines, start_line %d is past the end", len(lines), start)
+	}
+
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		fmt.Fprintf(&b, "%d\t%s\n", i, lines[i-1])
+	}
+	return b.String(), nil
+}
+
+// WriteFile creates, replaces or appends to a file
+type WriteFile struct {
+	kit.BaseTool
+	Path    string `json:"path" jsonschema:"description=Path of the file relative to a root, parent directories are created"`
+	Content string `json:"content" jsonschema:"description=Full content of the file, or the text to append"`
+	Append  bool   `json:"append" jsonschema:"description=Append to the file instead of replacing it"`
+
+	fs *FS
+}
+
+func (t *WriteFile) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "write_file",
+		Description: "Create or overwrite a file with the given content, or append to it.",
+	}
+}
+
+func (t *WriteFile) Execute(ctx *kit.Context) (any, error) {
+	if err := t.fs.Write(t.Path, t.Content, t.Append); err != nil {
+		return result(ctx, nil, err)
+	}
+	return map[string]any{"path": t.Path, "bytes_written": len(t.Content)}, nil
+}
+
+// ListFiles lists a directory
+type ListFiles struct {
+	kit.BaseTool
+	Path      string `json:"path" jsonschema:"description=Directory relative to a root, empty for the top level"`
+	Recursive bool   `json:"recursive" jsonschema:"description=Also list the content of subdirectories"`
+
+	fs *FS
+}
+
+func (t *ListFiles) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "list_files",
+		Description: "List the files and directories in a directory.",
+	}
+}
+
+func (t *ListFiles) Execute(ctx *kit.Context) (any, error) {
+	entries, truncated, err := t.fs.List(ctx, t.Path, t.Recursive)
+	return result(ctx, map[string]any{"entries": entries, "truncated": truncated}, err)
+}
+
+// SearchFiles finds lines matching a regular expression
+type SearchFiles struct {
+	kit.BaseTool
+	Pattern string `json:"pattern" jsonschema:"description=Regular expression (RE2 syntax) to search for"`
+	Path    string `json:"path" jsonschema:"description=Directory to search relative to a root, empty for everything"`
+	Glob    string `json:"glob" jsonschema:"description=Only search files whose name matches this glob, e.g. *.go"`
+
+	fs *FS
+}
+
+func (t *SearchFiles) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "search_files",
+		Description: "Search text files for lines matching a regular expression.",
+	}
+}
+
+func (t *SearchFiles) Execute(ctx *kit.Context) (any, error) {
+	matches, truncated, err := t.fs.Search(ctx, t.Path, t.Pattern, t.Glob)
+	return result(ctx, map[string]any{"matches": matches, "truncated": truncated}, err)
+}
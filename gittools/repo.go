@@ -0,0 +1,323 @@
+// Package gittools gives agents read access to a git repository: files, history, grep and diffs
+// It runs the git binary, which must be installed
+package gittools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhrlife/goai-kit/fstools"
+)
+
+// Config configures a Repo
+type Config struct {
+	// Dir is the work tree of the repository (required)
+	Dir string
+
+	// AllowPull adds the git_pull tool, the tools can't change the repository otherwise (optional)
+	AllowPull bool
+
+	// MaxOutputBytes caps what a tool returns, longer output is cut (optional, defaults to 64KB)
+	MaxOutputBytes int
+
+	// Timeout bounds every git command (optional, defaults to 30s, clones and pulls get 5 minutes)
+	Timeout time.Duration
+
+	// GitPath is the git binary (optional, defaults to "git" looked up in PATH)
+	GitPath string
+}
+
+// Repo runs git commands in a repository
+type Repo struct {
+	config Config
+	files  *fstools.FS
+}
+
+// Commit is an entry of the history
+type Commit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+// Open opens an existing repository
+func Open(ctx context.Context, config Config) (*Repo, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("repository directory is required")
+	}
+	if config.MaxOutputBytes <= 0 {
+		config.MaxOutputBytes = 64 << 10
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.GitPath == "" {
+		config.GitPath = "git"
+	}
+
+	r := &Repo{config: config}
+	top, err := r.git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", config.Dir, err)
+	}
+	r.config.Dir = strings.TrimSpace(top)
+
+	// the work tree is read through fstools so paths can't leave the repository
+	r.files, err = fstools.New(fstools.Config{
+		Roots:        []fstools.Root{{Path: r.config.Dir, ReadOnly: true}},
+		MaxFileBytes: int64(config.MaxOutputBytes) * 16,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Clone clones url into config.Dir and opens it, branch is optional and depth 0 clones the full history
+func Clone(ctx context.Context, url string, branch string, depth int, config Config) (*Repo, error) {
+	if strings.HasPrefix(url, "-") || strings.HasPrefix(branch, "-") {
+		return nil, fmt.Errorf("invalid clone arguments")
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("repository directory is required")
+	}
+	if config.GitPath == "" {
+		config.GitPath = "git"
+	}
+
+	args := []string{"clone", "--quiet"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, "--", url, config.Dir)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	if _, err := run(ctx, config.GitPath, "", args...); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return Open(ctx, config)
+}
+
+// Dir returns the root of the work tree
+func (r *Repo) Dir() string {
+	return r.config.Dir
+}
+
+// Pull fast-forwards the current branch from its upstream
+func (r *Repo) Pull(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	return run(ctx, r.config.GitPath, r.config.Dir, "pull", "--ff-only")
+}
+
+// Files lists the tracked files under path, at ref or in the work tree when ref is empty
+func (r *Repo) Files(ctx context.Context, ref, path string) ([]string, error) {
+	if err := checkArgs(ref); err != nil {
+		return nil, err
+	}
+
+	var out string
+	var err error
+	if ref == "" {
+		out, err = r.git(ctx, "ls-files", "--", pathspec(path))
+	} else {
+		out, err = r.git(ctx, "ls-tree", "-r", "--name-only", ref, "--", pathspec(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// ReadFile returns the content of a file at ref, or in the work tree when ref is empty
+func (r *Repo) ReadFile(ctx context.Context, ref, path string) (string, error) {
+	if ref == "" {
+		return r.files.Read(path)
+	}
+	if err := checkArgs(ref); err != nil {
+		return "", err
+	}
+	return r.git(ctx, "show", ref+":"+strings.TrimPrefix(path, "/"))
+}
+
+// Grep returns "path:line:text" for every line matching an extended regular expression
+// It searches ref, or the tracked files of the work tree when ref is empty
+func (r *Repo) Grep(ctx context.Context, pattern, ref, path string, ignoreCase bool) ([]string, error) {
+	if err := checkArgs(ref); err != nil {
+		return nil, err
+	}
+
+	args := []string{"grep", "-n", "-I", "-E"}
+	if ignoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", pattern)
+	if ref != "" {
+		args = append(args, ref)
+	}
+	args = append(args, "--", pathspec(path))
+
+	out, err := r.git(ctx, args...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// git grep exits with 1 when nothing matches
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitLines(out)
+	if ref != "" {
+		// matches in a ref are prefixed with "<ref>:"
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, ref+":")
+		}
+	}
+	return lines, nil
+}
+
+// Diff returns the unified diff between two revisions
+// An empty head diffs base against the work tree, an empty base defaults to HEAD
+func (r *Repo) Diff(ctx context.Context, base, head, path string, stat bool) (string, error) {
+	if base == "" {
+		base = "HEAD"
+	}
+	if err := checkArgs(base, head); err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, base)
+	if head != "" {
+		args = append(args, head)
+	}
+	return r.git(ctx, append(args, "--", pathspec(path))...)
+}
+
+// Patch returns the commits in base..head as an mbox of patches, as git format-patch writes them
+func (r *Repo) Patch(ctx context.Context, base, head string) (string, error) {
+	if head == "" {
+		head = "HEAD"
+	}
+	if err := checkArgs(base, head); err != nil {
+		return "", err
+	}
+	if base == "" {
+		return "", fmt.Errorf("base revision is required")
+	}
+	return r.git(ctx, "format-patch", "--stdout", "--no-color", base+".."+head)
+}
+
+// Log returns the latest commits reachable from ref (HEAD when empty) that touch path
+func (r *Repo) Log(ctx context.Context, ref, path string, max int) ([]Commit, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := checkArgs(ref); err != nil {
+		return nil, err
+	}
+	if max <= 0 {
+		max = 20
+	}
+
+	out, err := r.git(ctx, "log", "--format=%H%x09%an%x09%ad%x09%s", "--date=short", "-n", strconv.Itoa(max), ref, "--", pathspec(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range splitLines(out) {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) == 4 {
+			commits = append(commits, Commit{Hash: parts[0], Author: parts[1], Date: parts[2], Subject: parts[3]})
+		}
+	}
+	return commits, nil
+}
+
+// git runs a git command in the repository with the configured timeout
+func (r *Repo) git(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+	return run(ctx, r.config.GitPath, r.config.Dir, args...)
+}
+
+func run(ctx context.Context, gitPath, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = dir
+	// never wait for credentials or open an editor or pager
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_PAGER=cat", "GIT_EDITOR=true", "LC_ALL=C")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", &gitError{command: args[0], message: message, err: err}
+	}
+	return stdout.String(), nil
+}
+
+// gitError keeps the exit error of a failed command so callers can check its code
+type gitError struct {
+	command string
+	message string
+	err     error
+}
+
+func (e *gitError) Error() string {
+	return fmt.Sprintf("git %s: %s", e.command, e.message)
+}
+
+func (e *gitError) Unwrap() error {
+	return e.err
+}
+
+// checkArgs refuses revisions that git would parse as options
+func checkArgs(revisions ...string) error {
+	for _, rev := range revisions {
+		if strings.HasPrefix(rev, "-") {
+			return fmt.Errorf("invalid revision %q", rev)
+		}
+	}
+	return nil
+}
+
+// pathspec turns an empty path into the whole tree and takes paths literally
+func pathspec(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" || path == "." {
+		return "."
+	}
+	return ":(literal)" + path
+}
+
+func splitLines(out string) []string {
+	out = strings.TrimRight(out, "\n")
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
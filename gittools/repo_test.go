@@ -0,0 +1,143 @@
+package gittools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/stretchr/testify/require"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com", "GIT_AUTHOR_DATE=2024-03-01T10:00:00Z",
+		"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com", "GIT_COMMITTER_DATE=2024-03-01T10:00:00Z",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func commitFile(t *testing.T, dir, name, content, message string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	gitCmd(t, dir, "add", name)
+	gitCmd(t, dir, "commit", "-q", "-m", message)
+}
+
+func newTestRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	commitFile(t, dir, "main.go", "package main\n\nfunc main() {}\n", "Add main")
+	commitFile(t, dir, "pkg/util.go", "package pkg\n\n// Max returns the larger value\nfunc Max(a, b int) int {\n\treturn a\n}\n", "Add util")
+	commitFile(t, dir, "pkg/util.go", "package pkg\n\n// Max returns the larger value\nfunc Max(a, b int) int {\n\tif a > b {\n\t\treturn a\n\t}\n\treturn b\n}\n", "Fix Max")
+	return dir
+}
+
+func TestRepoReads(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Open(ctx, Config{Dir: filepath.Join(dir, "pkg")})
+	require.NoError(t, err)
+
+	files, err := repo.Files(ctx, "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.go", "pkg/util.go"}, files)
+	files, err = repo.Files(ctx, "HEAD~2", "pkg")
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	old, err := repo.ReadFile(ctx, "HEAD~1", "pkg/util.go")
+	require.NoError(t, err)
+	require.Contains(t, old, "\treturn a\n}")
+	_, err = repo.ReadFile(ctx, "", "../outside.go")
+	require.Error(t, err)
+
+	matches, err := repo.Grep(ctx, "func [A-Z]", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pkg/util.go:4:func Max(a, b int) int {"}, matches)
+	matches, err = repo.Grep(ctx, "return a$", "HEAD~1", "pkg", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pkg/util.go:5:\treturn a"}, matches)
+	matches, err = repo.Grep(ctx, "nothing here", "", "", false)
+	require.NoError(t, err)
+	require.Empty(t, matches)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() { run() }\n"), 0o644))
+	diff, err := repo.Diff(ctx, "", "", "", false)
+	require.NoError(t, err)
+	require.Contains(t, diff, "-func main() {}\n+func main() { run() }")
+	diff, err = repo.Diff(ctx, "HEAD~1", "HEAD", "", true)
+	require.NoError(t, err)
+	require.Contains(t, diff, "pkg/util.go | 5 ++++-")
+
+	patch, err := repo.Patch(ctx, "HEAD~1", "")
+	require.NoError(t, err)
+	require.Contains(t, patch, "Subject: [PATCH] Fix Max")
+
+	commits, err := repo.Log(ctx, "", "pkg/util.go", 0)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, Commit{Hash: commits[0].Hash, Author: "Ada", Date: "2024-03-01", Subject: "Fix Max"}, commits[0])
+
+	_, err = repo.Diff(ctx, "--output=/tmp/x", "", "", false)
+	require.ErrorContains(t, err, "invalid revision")
+}
+
+func TestCloneAndPull(t *testing.T) {
+	upstream := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Clone(ctx, upstream, "main", 0, Config{Dir: filepath.Join(t.TempDir(), "clone"), AllowPull: true})
+	require.NoError(t, err)
+
+	commitFile(t, upstream, "README.md", "# Demo\n", "Add readme")
+	_, err = repo.Pull(ctx)
+	require.NoError(t, err)
+
+	content, err := repo.ReadFile(ctx, "", "README.md")
+	require.NoError(t, err)
+	require.Equal(t, "# Demo\n", content)
+
+	var names []string
+	for _, tool := range repo.Tools() {
+		names = append(names, kit.GetAgentToolInfo(tool).Name)
+	}
+	require.Contains(t, names, "git_pull")
+}
+
+func TestTools(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(context.Background(), Config{Dir: dir, MaxOutputBytes: 60})
+	require.NoError(t, err)
+
+	var names []string
+	for _, tool := range repo.Tools() {
+		names = append(names, kit.GetAgentToolInfo(tool).Name)
+	}
+	require.Equal(t, []string{"git_list_files", "git_read_file", "git_grep", "git_diff", "git_log", "git_format_patch"}, names)
+
+	ctx := &kit.Context{Context: context.Background()}
+	out, err := (&ReadFile{Path: "pkg/util.go", Ref: "HEAD", StartLine: 4, EndLine: 5, repo: repo}).Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "4\tfunc Max(a, b int) int {\n5\t\tif a > b {\n", out)
+
+	out, err = (&ReadFile{Path: "pkg/util.go", repo: repo}).Execute(ctx)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out.(string), "1\tpackage pkg\n"))
+	require.Contains(t, out, "[Output cut at 60 of")
+
+	out, err = (&ReadFile{Path: "missing.go", Ref: "HEAD", repo: repo}).Execute(ctx)
+	require.NoError(t, err, "mistakes are reported to the model")
+	require.Contains(t, out.(failure).Error, "missing.go")
+}
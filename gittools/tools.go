@@ -0,0 +1,223 @@
+package gittools
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/mhrlife/goai-kit/fstools"
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// Tools returns the read-only repository tools, and git_pull when Config.AllowPull is set
+func (r *Repo) Tools() []kit.ToolExecutor {
+	tools := []kit.ToolExecutor{
+		&ListFiles{repo: r}, &ReadFile{repo: r}, &Grep{repo: r}, &Diff{repo: r}, &Log{repo: r}, &FormatPatch{repo: r},
+	}
+	if r.config.AllowPull {
+		tools = append(tools, &Pull{repo: r})
+	}
+	return tools
+}
+
+// failure is returned to the model instead of failing the run, so it can fix its arguments and retry
+type failure struct {
+	Error string `json:"error"`
+}
+
+func (r *Repo) result(ctx *kit.Context, value any, err error) (any, error) {
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return failure{Error: err.Error()}, nil
+	}
+	if text, ok := value.(string); ok {
+		return r.clip(text), nil
+	}
+	return value, nil
+}
+
+// clip cuts text longer than MaxOutputBytes and says so
+func (r *Repo) clip(text string) string {
+	if len(text) <= r.config.MaxOutputBytes {
+		return text
+	}
+	cut := r.config.MaxOutputBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + fmt.Sprintf("\n[Output cut at %d of %d bytes, narrow the path or range.]", cut, len(text))
+}
+
+// limit caps a list at MaxOutputBytes worth of lines
+func (r *Repo) limit(lines []string) ([]string, bool) {
+	size := 0
+	for i, line := range lines {
+		size += len(line) + 1
+		if size > r.config.MaxOutputBytes {
+			return lines[:i], true
+		}
+	}
+	return lines, false
+}
+
+// ListFiles lists tracked files
+type ListFiles struct {
+	kit.BaseTool
+	Path string `json:"path" jsonschema:"description=Directory or file to list, empty for the whole repository"`
+	Ref  string `json:"ref" jsonschema:"description=Branch, tag or commit to list, empty for the working tree"`
+
+	repo *Repo
+}
+
+func (t *ListFiles) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{Name: "git_list_files", Description: "List the files tracked in the git repository."}
+}
+
+func (t *ListFiles) Execute(ctx *kit.Context) (any, error) {
+	files, err := t.repo.Files(ctx, t.Ref, t.Path)
+	if err != nil {
+		return t.repo.result(ctx, nil, err)
+	}
+	files, truncated := t.repo.limit(files)
+	return map[string]any{"files": files, "truncated": truncated}, nil
+}
+
+// ReadFile reads a range of lines of a file
+type ReadFile struct {
+	kit.BaseTool
+	Path      string `json:"path" jsonschema:"description=Path of the file in the repository"`
+	Ref       string `json:"ref" jsonschema:"description=Branch, tag or commit to read from, empty for the working tree"`
+	StartLine int    `json:"start_line" jsonschema:"description=First line to read starting at 1, 0 reads from the start"`
+	EndLine   int    `json:"end_line" jsonschema:"description=Last line to read, 0 reads to the end"`
+
+	repo *Repo
+}
+
+func (t *ReadFile) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "git_read_file",
+		Description: "Read a file of the git repository, optionally at a revision. Lines are prefixed with their number.",
+	}
+}
+
+func (t *ReadFile) Execute(ctx *kit.Context) (any, error) {
+	content, err := t.repo.ReadFile(ctx, t.Ref, t.Path)
+	if err != nil {
+		return t.repo.result(ctx, nil, err)
+	}
+	numbered, err := fstools.Lines(content, t.StartLine, t.EndLine)
+	if err != nil {
+		err = fmt.Errorf("%s %w", t.Path, err)
+	}
+	return t.repo.result(ctx, numbered, err)
+}
+
+// Grep searches tracked files
+type Grep struct {
+	kit.BaseTool
+	Pattern    string `json:"pattern" jsonschema:"description=Extended regular expression to search for"`
+	Path       string `json:"path" jsonschema:"description=Directory or file to search, empty for the whole repository"`
+	Ref        string `json:"ref" jsonschema:"description=Branch, tag or commit to search, empty for the working tree"`
+	IgnoreCase bool   `json:"ignore_case" jsonschema:"description=Match case insensitively"`
+
+	repo *Repo
+}
+
+func (t *Grep) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "git_grep",
+		Description: "Search the tracked files of the git repository. Matches are returned as path:line:text.",
+	}
+}
+
+func (t *Grep) Execute(ctx *kit.Context) (any, error) {
+	matches, err := t.repo.Grep(ctx, t.Pattern, t.Ref, t.Path, t.IgnoreCase)
+	if err != nil {
+		return t.repo.result(ctx, nil, err)
+	}
+	matches, truncated := t.repo.limit(matches)
+	return map[string]any{"matches": matches, "truncated": truncated}, nil
+}
+
+// Diff shows the changes between revisions or in the working tree
+type Diff struct {
+	kit.BaseTool
+	Base string `json:"base" jsonschema:"description=Revision to diff from, empty for HEAD"`
+	Head string `json:"head" jsonschema:"description=Revision to diff to, empty for the working tree"`
+	Path string `json:"path" jsonschema:"description=Limit the diff to a directory or file, empty for everything"`
+	Stat bool   `json:"stat" jsonschema:"description=Only show the changed files and line counts"`
+
+	repo *Repo
+}
+
+func (t *Diff) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "git_diff",
+		Description: "Show a unified diff between two revisions, or between a revision and the working tree.",
+	}
+}
+
+func (t *Diff) Execute(ctx *kit.Context) (any, error) {
+	diff, err := t.repo.Diff(ctx, t.Base, t.Head, t.Path, t.Stat)
+	return t.repo.result(ctx, diff, err)
+}
+
+// Log lists commits
+type Log struct {
+	kit.BaseTool
+	Ref      string `json:"ref" jsonschema:"description=Branch, tag or commit to start from, empty for HEAD"`
+	Path     string `json:"path" jsonschema:"description=Only list commits touching this directory or file"`
+	MaxCount int    `json:"max_count" jsonschema:"description=Number of commits to list, 0 lists 20"`
+
+	repo *Repo
+}
+
+func (t *Log) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{Name: "git_log", Description: "List the latest commits of the git repository."}
+}
+
+func (t *Log) Execute(ctx *kit.Context) (any, error) {
+	commits, err := t.repo.Log(ctx, t.Ref, t.Path, t.MaxCount)
+	return t.repo.result(ctx, commits, err)
+}
+
+// FormatPatch exports commits as patches
+type FormatPatch struct {
+	kit.BaseTool
+	Base string `json:"base" jsonschema:"description=Revision the patches apply on"`
+	Head string `json:"head" jsonschema:"description=Last revision to include, empty for HEAD"`
+
+	repo *Repo
+}
+
+func (t *FormatPatch) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "git_format_patch",
+		Description: "Export the commits in base..head as email patches that git am can apply.",
+	}
+}
+
+func (t *FormatPatch) Execute(ctx *kit.Context) (any, error) {
+	patch, err := t.repo.Patch(ctx, t.Base, t.Head)
+	return t.repo.result(ctx, patch, err)
+}
+
+// Pull updates the repository from its upstream
+type Pull struct {
+	kit.BaseTool
+
+	repo *Repo
+}
+
+func (t *Pull) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "git_pull",
+		Description: "Fast-forward the current branch to the latest commits of its upstream.",
+	}
+}
+
+func (t *Pull) Execute(ctx *kit.Context) (any, error) {
+	out, err := t.repo.Pull(ctx)
+	return t.repo.result(ctx, out, err)
+}
@@ -0,0 +1,169 @@
+// Package guardrail scans untrusted content (tool results, retrieved documents) for prompt injection
+// before it is added to an agent's conversation, and filters unsafe inputs and outputs by category
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+// Rule is a named injection pattern
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Finding is a match of a rule in scanned content
+type Finding struct {
+	Rule  string
+	Match string
+}
+
+// Policy decides what happens to content with findings
+type Policy string
+
+const (
+	PolicyBlock    Policy = "block"    // Replace the whole content with a notice
+	PolicySanitize Policy = "sanitize" // Replace only the matches with a marker
+	PolicyFlag     Policy = "flag"     // Keep the content and report the findings
+)
+
+// DefaultRules returns patterns of common injection attempts
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:    "ignore_instructions",
+			Pattern: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(previous|prior|above|earlier|all|any)\b.{0,40}\b(instructions?|prompts?|rules|directions|guidelines)\b`),
+		},
+		{
+			Name:    "role_override",
+			Pattern: regexp.MustCompile(`(?i)\b(you are now|from now on,? you (are|will)|new (system )?instructions\s*:)`),
+		},
+		{
+			Name:    "reveal_prompt",
+			Pattern: regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,30}\b(system prompt|your (instructions|prompt)|hidden prompt)\b`),
+		},
+		{
+			Name:    "chat_markup",
+			Pattern: regexp.MustCompile(`(?im)(<\|im_start\|>|<\|im_end\|>|<\|system\|>|\[/?INST\]|<</?SYS>>|^\s*(system|assistant)\s*:)`),
+		},
+		{
+			// Markdown images are fetched by chat UIs, a query string can carry conversation data out
+			Name:    "exfil_url",
+			Pattern: regexp.MustCompile(`(?i)!\[[^\]]*\]\(\s*https?://[^)\s]*\?[^)\s]*\)`),
+		},
+	}
+}
+
+// Detector scans content with a set of rules
+type Detector struct {
+	rules []Rule
+}
+
+// NewDetector creates a detector, it uses DefaultRules when no rules are given
+func NewDetector(rules ...Rule) *Detector {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Detector{rules: rules}
+}
+
+// Scan returns every rule match in content
+func (d *Detector) Scan(content string) []Finding {
+	var findings []Finding
+	for _, rule := range d.rules {
+		for _, match := range rule.Pattern.FindAllString(content, -1) {
+			findings = append(findings, Finding{Rule: rule.Name, Match: match})
+		}
+	}
+	return findings
+}
+
+// Sanitize replaces every rule match in content with a marker
+func (d *Detector) Sanitize(content string) string {
+	for _, rule := range d.rules {
+		content = rule.Pattern.ReplaceAllString(content, "[removed: "+rule.Name+"]")
+	}
+	return content
+}
+
+// Apply scans content and applies the policy, it returns the content to use and the findings
+func (d *Detector) Apply(policy Policy, content string) (string, []Finding) {
+	findings := d.Scan(content)
+	if len(findings) == 0 {
+		return content, nil
+	}
+
+	switch policy {
+	case PolicyBlock:
+		rules := make([]string, 0, len(findings))
+		seen := map[string]bool{}
+		for _, f := range findings {
+			if !seen[f.Rule] {
+				seen[f.Rule] = true
+				rules = append(rules, f.Rule)
+			}
+		}
+		return fmt.Sprintf("[content blocked: possible prompt injection (%s)]", strings.Join(rules, ", ")), findings
+	case PolicySanitize:
+		return d.Sanitize(content), findings
+	}
+	return content, findings
+}
+
+// GuardConfig configures InjectionGuard
+type GuardConfig struct {
+	// Detector scans the content (optional, defaults to NewDetector())
+	Detector *Detector
+
+	// Policy applied to content with findings (optional, defaults to PolicySanitize)
+	Policy Policy
+
+	// OnDetect is called with the findings of every flagged, sanitized or blocked content (optional)
+	OnDetect func(ctx context.Context, source string, findings []Finding)
+}
+
+// InjectionGuard returns a kit.Guard that scans tool results before they reach the model
+func InjectionGuard(config GuardConfig) kit.Guard {
+	if config.Detector == nil {
+		config.Detector = NewDetector()
+	}
+	if config.Policy == "" {
+		config.Policy = PolicySanitize
+	}
+
+	return func(ctx context.Context, source, content string) (string, error) {
+		content, findings := config.Detector.Apply(config.Policy, content)
+		if len(findings) > 0 && config.OnDetect != nil {
+			config.OnDetect(ctx, source, findings)
+		}
+		return content, nil
+	}
+}
+
+// FilterDocuments applies the policy to retrieved documents before they are put into a prompt
+// Blocked documents are dropped, the findings are keyed by document ID
+func (d *Detector) FilterDocuments(policy Policy, docs []vectordb.DocumentWithScore) ([]vectordb.DocumentWithScore, map[string][]Finding) {
+	kept := make([]vectordb.DocumentWithScore, 0, len(docs))
+	findings := map[string][]Finding{}
+	for _, doc := range docs {
+		content, found := d.Apply(policy, doc.Content)
+		if len(found) == 0 {
+			kept = append(kept, doc)
+			continue
+		}
+
+		findings[doc.ID] = found
+		if policy == PolicyBlock {
+			continue
+		}
+		doc.Content = content
+		kept = append(kept, doc)
+	}
+	return kept, findings
+}
@@ -0,0 +1,245 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrContentBlocked is returned by the content filter guard for inputs and outputs over a threshold
+var ErrContentBlocked = errors.New("content blocked by safety filter")
+
+// Category is a kind of harmful content
+type Category string
+
+const (
+	CategoryViolence   Category = "violence"
+	CategorySexual     Category = "sexual"
+	CategorySelfHarm   Category = "self-harm"
+	CategoryHate       Category = "hate"
+	CategoryHarassment Category = "harassment"
+	CategoryIllicit    Category = "illicit"
+)
+
+// DefaultThresholds are the scores from which content is flagged when no thresholds are configured
+func DefaultThresholds() map[Category]float64 {
+	return map[Category]float64{
+		CategoryViolence: 0.7,
+		CategorySexual:   0.7,
+		CategorySelfHarm: 0.5,
+	}
+}
+
+// Classifier scores content between 0 and 1 per category, categories it doesn't know are left out
+type Classifier interface {
+	Classify(ctx context.Context, content string) (map[Category]float64, error)
+}
+
+// ClassifierFunc adapts a function, e.g. a call to a local model, to Classifier
+type ClassifierFunc func(ctx context.Context, content string) (map[Category]float64, error)
+
+func (f ClassifierFunc) Classify(ctx context.Context, content string) (map[Category]float64, error) {
+	return f(ctx, content)
+}
+
+// openAIModeration classifies with the moderations endpoint
+type openAIModeration struct {
+	client openai.Client
+	model  string
+}
+
+// OpenAIModeration classifies content with the moderations endpoint of an OpenAI compatible provider
+// If model is empty, defaults to "omni-moderation-latest". Subcategories like "violence/graphic" count as their category
+func OpenAIModeration(client *kit.Client, model string) Classifier {
+	if model == "" {
+		model = openai.ModerationModelOmniModerationLatest
+	}
+	return &openAIModeration{client: client.GetOpenAI(), model: model}
+}
+
+func (m *openAIModeration) Classify(ctx context.Context, content string) (map[Category]float64, error) {
+	resp, err := m.client.Moderations.New(ctx, openai.ModerationNewParams{
+		Input: openai.ModerationNewParamsInputUnion{OfString: openai.String(content)},
+		Model: m.model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("moderation returned no results")
+	}
+
+	var raw map[string]float64
+	if err := json.Unmarshal([]byte(resp.Results[0].CategoryScores.RawJSON()), &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation scores: %w", err)
+	}
+	scores := map[Category]float64{}
+	for name, score := range raw {
+		category := Category(strings.SplitN(name, "/", 2)[0])
+		scores[category] = max(scores[category], score)
+	}
+	return scores, nil
+}
+
+// KeywordClassifier is a local classifier scoring 1 for categories with a matching pattern and 0 for the others
+type KeywordClassifier struct {
+	patterns map[Category][]*regexp.Regexp
+}
+
+// NewKeywordClassifier matches the words of every category case-insensitively on word boundaries
+func NewKeywordClassifier(words map[Category][]string) *KeywordClassifier {
+	patterns := map[Category][]*regexp.Regexp{}
+	for category, list := range words {
+		for _, word := range list {
+			patterns[category] = append(patterns[category], regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(word)+`\b`))
+		}
+	}
+	return &KeywordClassifier{patterns: patterns}
+}
+
+func (k *KeywordClassifier) Classify(_ context.Context, content string) (map[Category]float64, error) {
+	scores := map[Category]float64{}
+	for category, patterns := range k.patterns {
+		scores[category] = 0
+		for _, pattern := range patterns {
+			if pattern.MatchString(content) {
+				scores[category] = 1
+				break
+			}
+		}
+	}
+	return scores, nil
+}
+
+// Decision is the outcome of one content check
+type Decision struct {
+	Source  string               // kit.GuardSourceInput, kit.GuardSourceOutput or the tool name
+	Scores  map[Category]float64 // scores of the classifier
+	Flagged []Category           // categories at or over their threshold, sorted
+	Blocked bool                 // the content was rejected or replaced
+	Err     error                // the classifier failed, the content passed when FailOpen is set
+}
+
+// ContentFilterConfig configures a content filter
+type ContentFilterConfig struct {
+	// Classifier scores the content (required)
+	Classifier Classifier
+
+	// Thresholds flag a category from its score on, categories without a threshold aren't checked
+	// (optional, defaults to DefaultThresholds)
+	Thresholds map[Category]float64
+
+	// Policy applied to flagged content (optional, defaults to PolicyBlock)
+	// PolicyBlock fails the run for inputs and outputs and replaces tool results with a notice,
+	// PolicyFlag only records the decision, PolicySanitize is treated like PolicyBlock
+	Policy Policy
+
+	// FailOpen lets content pass when the classifier fails instead of failing the run
+	FailOpen bool
+
+	// OnDecision is called with every decision, flagged or not (optional)
+	OnDecision func(ctx context.Context, decision Decision)
+
+	// Tracer records every decision as a span (optional, defaults to the global tracer provider)
+	Tracer trace.Tracer
+}
+
+// ContentFilter checks inputs, outputs and tool results against category thresholds
+type ContentFilter struct {
+	config ContentFilterConfig
+}
+
+// NewContentFilter creates a content filter
+func NewContentFilter(config ContentFilterConfig) *ContentFilter {
+	if config.Thresholds == nil {
+		config.Thresholds = DefaultThresholds()
+	}
+	if config.Policy == "" {
+		config.Policy = PolicyBlock
+	}
+	if config.Tracer == nil {
+		config.Tracer = otel.Tracer("github.com/mhrlife/goai-kit/guardrail")
+	}
+	return &ContentFilter{config: config}
+}
+
+// Check classifies content and decides whether it's blocked, the error is only set when the classifier failed
+// and FailOpen isn't set
+func (f *ContentFilter) Check(ctx context.Context, source, content string) (Decision, error) {
+	ctx, span := f.config.Tracer.Start(ctx, "guardrail.content_filter", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	decision := Decision{Source: source}
+	decision.Scores, decision.Err = f.config.Classifier.Classify(ctx, content)
+	if decision.Err != nil {
+		decision.Blocked = !f.config.FailOpen
+		span.RecordError(decision.Err)
+		span.SetStatus(codes.Error, decision.Err.Error())
+	}
+
+	for category, threshold := range f.config.Thresholds {
+		if score, ok := decision.Scores[category]; ok && score >= threshold {
+			decision.Flagged = append(decision.Flagged, category)
+		}
+	}
+	sort.Slice(decision.Flagged, func(i, j int) bool { return decision.Flagged[i] < decision.Flagged[j] })
+	if len(decision.Flagged) > 0 && f.config.Policy != PolicyFlag {
+		decision.Blocked = true
+	}
+
+	flagged := make([]string, len(decision.Flagged))
+	for i, category := range decision.Flagged {
+		flagged[i] = string(category)
+	}
+	span.SetAttributes(
+		attribute.String("guardrail.source", source),
+		attribute.String("guardrail.policy", string(f.config.Policy)),
+		attribute.StringSlice("guardrail.flagged", flagged),
+		attribute.Bool("guardrail.blocked", decision.Blocked),
+	)
+	for category, score := range decision.Scores {
+		span.SetAttributes(attribute.Float64("guardrail.score."+string(category), score))
+	}
+
+	if f.config.OnDecision != nil {
+		f.config.OnDecision(ctx, decision)
+	}
+	if decision.Err != nil && !f.config.FailOpen {
+		return decision, fmt.Errorf("content filter failed: %w", decision.Err)
+	}
+	return decision, nil
+}
+
+// Guard returns a kit.Guard for WithInputGuards, WithOutputGuards and WithGuards
+// Blocked inputs and outputs fail the run with ErrContentBlocked, blocked tool results are replaced with a notice
+func (f *ContentFilter) Guard() kit.Guard {
+	return func(ctx context.Context, source, content string) (string, error) {
+		decision, err := f.Check(ctx, source, content)
+		if err != nil {
+			return "", err
+		}
+		if !decision.Blocked {
+			return content, nil
+		}
+
+		flagged := make([]string, len(decision.Flagged))
+		for i, category := range decision.Flagged {
+			flagged[i] = string(category)
+		}
+		if source == kit.GuardSourceInput || source == kit.GuardSourceOutput {
+			return "", fmt.Errorf("%w: %s", ErrContentBlocked, strings.Join(flagged, ", "))
+		}
+		return fmt.Sprintf("[content blocked: unsafe content (%s)]", strings.Join(flagged, ", ")), nil
+	}
+}
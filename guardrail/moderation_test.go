@@ -0,0 +1,107 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestContentFilterGuard(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	var decisions []Decision
+	filter := NewContentFilter(ContentFilterConfig{
+		Classifier: ClassifierFunc(func(ctx context.Context, content string) (map[Category]float64, error) {
+			if content == "violent" {
+				return map[Category]float64{CategoryViolence: 0.92, CategorySexual: 0.01, CategoryHate: 0.9}, nil
+			}
+			return map[Category]float64{CategoryViolence: 0.1}, nil
+		}),
+		OnDecision: func(ctx context.Context, decision Decision) { decisions = append(decisions, decision) },
+		Tracer:     tracer,
+	})
+	guard := filter.Guard()
+	ctx := context.Background()
+
+	content, err := guard(ctx, kit.GuardSourceInput, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", content)
+
+	_, err = guard(ctx, kit.GuardSourceOutput, "violent")
+	require.ErrorIs(t, err, ErrContentBlocked)
+	require.EqualError(t, err, "content blocked by safety filter: violence")
+
+	// hate has no default threshold
+	content, err = guard(ctx, "web_search", "violent")
+	require.NoError(t, err)
+	require.Equal(t, "[content blocked: unsafe content (violence)]", content)
+
+	require.Len(t, decisions, 3)
+	require.False(t, decisions[0].Blocked)
+	require.Equal(t, []Category{CategoryViolence}, decisions[1].Flagged)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "guardrail.content_filter", spans[1].Name)
+	require.Contains(t, spans[1].Attributes, attribute.String("guardrail.source", "output"))
+	require.Contains(t, spans[1].Attributes, attribute.Bool("guardrail.blocked", true))
+	require.Contains(t, spans[1].Attributes, attribute.Float64("guardrail.score.violence", 0.92))
+}
+
+func TestContentFilterPolicies(t *testing.T) {
+	ctx := context.Background()
+	classifier := NewKeywordClassifier(map[Category][]string{CategorySelfHarm: {"hurt myself"}})
+
+	// flagging only records the decision
+	filter := NewContentFilter(ContentFilterConfig{Classifier: classifier, Policy: PolicyFlag})
+	decision, err := filter.Check(ctx, kit.GuardSourceInput, "I want to HURT MYSELF")
+	require.NoError(t, err)
+	require.Equal(t, []Category{CategorySelfHarm}, decision.Flagged)
+	require.False(t, decision.Blocked)
+
+	decision, err = filter.Check(ctx, kit.GuardSourceInput, "the hurt myselfish cat")
+	require.NoError(t, err)
+	require.Empty(t, decision.Flagged)
+
+	// classifier failures fail closed unless FailOpen is set
+	failing := ClassifierFunc(func(ctx context.Context, content string) (map[Category]float64, error) {
+		return nil, errors.New("timeout")
+	})
+	_, err = NewContentFilter(ContentFilterConfig{Classifier: failing}).Guard()(ctx, kit.GuardSourceInput, "hi")
+	require.EqualError(t, err, "content filter failed: timeout")
+
+	content, err := NewContentFilter(ContentFilterConfig{Classifier: failing, FailOpen: true}).Guard()(ctx, kit.GuardSourceInput, "hi")
+	require.NoError(t, err)
+	require.Equal(t, "hi", content)
+}
+
+func TestOpenAIModeration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/moderations", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "omni-moderation-latest", body["model"])
+		require.Equal(t, "some text", body["input"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"modr-1","model":"omni-moderation-latest","results":[{"flagged":true,
+			"categories":{"violence":true},
+			"category_scores":{"violence":0.2,"violence/graphic":0.8,"sexual":0.01,"self-harm":0.0,"self-harm/intent":0.3}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := kit.NewClient(kit.WithAPIKey("test"), kit.WithBaseURL(server.URL))
+	scores, err := OpenAIModeration(client, "").Classify(context.Background(), "some text")
+	require.NoError(t, err)
+	require.Equal(t, map[Category]float64{CategoryViolence: 0.8, CategorySexual: 0.01, CategorySelfHarm: 0.3}, scores)
+}
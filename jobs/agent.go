@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+)
+
+// AgentPayload is the payload of tasks handled by AgentHandler
+type AgentPayload struct {
+	Prompt       string                                   `json:"prompt,omitempty"`
+	SystemPrompt string                                   `json:"system_prompt,omitempty"`
+	Messages     []openai.ChatCompletionMessageParamUnion `json:"messages,omitempty"`
+}
+
+// AgentHandler returns a handler that invokes the agent with an AgentPayload
+// The agent output becomes the job result
+func AgentHandler[Output any](agent *kit.Agent[Output]) Handler {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var p AgentPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal agent payload: %w", err)
+		}
+
+		return agent.Invoke(ctx, kit.InvokeConfig{
+			Prompt:       p.Prompt,
+			SystemPrompt: p.SystemPrompt,
+			Messages:     p.Messages,
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobNotFound is returned when a job ID is unknown to the backend
+var ErrJobNotFound = errors.New("job not found")
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Task describes work to be executed by a registered handler
+type Task struct {
+	Type    string
+	Payload any
+}
+
+// Job is a task together with its execution state and result
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     Status          `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+}
+
+// Done reports whether the job reached a terminal state
+func (j Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// DecodeResult unmarshals the job result into v
+func (j Job) DecodeResult(v any) error {
+	if len(j.Result) == 0 {
+		return fmt.Errorf("job %s has no result", j.ID)
+	}
+	return json.Unmarshal(j.Result, v)
+}
+
+// Handler executes a task payload and returns a JSON serializable result
+type Handler func(ctx context.Context, payload json.RawMessage) (any, error)
+
+// Backend stores jobs and hands them out to workers
+type Backend interface {
+	// Push stores a new job and makes it available to workers
+	Push(ctx context.Context, job Job) error
+	// Pop blocks until a job is available or ctx is done
+	Pop(ctx context.Context) (Job, error)
+	// Save persists the state of an existing job
+	Save(ctx context.Context, job Job) error
+	// Get loads a job by ID, returning ErrJobNotFound when unknown
+	Get(ctx context.Context, id string) (Job, error)
+}
+
+// Queue runs registered task handlers in the background on a pool of workers
+type Queue struct {
+	backend  Backend
+	workers  int
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewQueue creates a queue with the given backend and number of workers
+func NewQueue(backend Backend, workers int) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Queue{
+		backend:  backend,
+		workers:  workers,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register registers a handler for a task type
+func (q *Queue) Register(taskType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = handler
+}
+
+// Enqueue stores a task for background execution and returns its job ID
+func (q *Queue) Enqueue(ctx context.Context, task Task) (string, error) {
+	if task.Type == "" {
+		return "", fmt.Errorf("task type is required")
+	}
+
+	q.mu.RLock()
+	_, ok := q.handlers[task.Type]
+	q.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no handler registered for task type %q", task.Type)
+	}
+
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	job := Job{
+		ID:        uuid.New().String(),
+		Type:      task.Type,
+		Payload:   payload,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := q.backend.Push(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// Status returns the current state of a job
+func (q *Queue) Status(ctx context.Context, id string) (Job, error) {
+	return q.backend.Get(ctx, id)
+}
+
+// Wait polls a job until it reaches a terminal state or ctx is done
+func (q *Queue) Wait(ctx context.Context, id string, pollInterval time.Duration) (Job, error) {
+	if pollInterval <= 0 {
+		pollInterval = 500 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.backend.Get(ctx, id)
+		if err != nil {
+			return Job{}, err
+		}
+		if job.Done() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run starts the workers and blocks until ctx is cancelled and all running jobs are finished
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Backoff of a worker after the backend failed, doubled for every further failure in a row
+const (
+	popBackoff    = 100 * time.Millisecond
+	maxPopBackoff = 30 * time.Second
+)
+
+// work pops and executes jobs until ctx is done
+func (q *Queue) work(ctx context.Context) {
+	backoff := popBackoff
+	for {
+		job, err := q.backend.Pop(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, ErrJobNotFound) {
+			// the payload expired before a worker got to it, there is nothing left to run
+			slog.Warn("dropped job without payload", "error", err)
+			continue
+		}
+		if err != nil {
+			slog.Error("failed to pop job", "error", err, "retry_in", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxPopBackoff)
+			continue
+		}
+
+		backoff = popBackoff
+		q.execute(ctx, job)
+	}
+}
+
+// execute runs a single job and stores its outcome
+func (q *Queue) execute(ctx context.Context, job Job) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	job.Status = StatusRunning
+	job.StartedAt = time.Now().UTC()
+	if err := q.backend.Save(ctx, job); err != nil {
+		slog.Error("failed to save job state", "job_id", job.ID, "error", err)
+	}
+
+	var result any
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler registered for task type %q", job.Type)
+	} else {
+		result, err = runHandler(ctx, handler, job.Payload)
+	}
+
+	job.FinishedAt = time.Now().UTC()
+	if err == nil {
+		job.Result, err = json.Marshal(result)
+		if err != nil {
+			err = fmt.Errorf("failed to marshal job result: %w", err)
+		}
+	}
+
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusSucceeded
+	}
+
+	// Persist the outcome even if the queue is shutting down
+	if saveErr := q.backend.Save(context.WithoutCancel(ctx), job); saveErr != nil {
+		slog.Error("failed to save job result", "job_id", job.ID, "error", saveErr)
+	}
+}
+
+// runHandler executes a handler, converting panics into errors so one bad job can't kill a worker
+func runHandler(ctx context.Context, handler Handler, payload json.RawMessage) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, payload)
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueMemoryBackend(t *testing.T) {
+	queue := NewQueue(NewMemoryBackend(0), 2)
+	queue.Register("double", func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var n int
+		if err := json.Unmarshal(payload, &n); err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative input")
+		}
+		return n * 2, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(done)
+	}()
+
+	okID, err := queue.Enqueue(ctx, Task{Type: "double", Payload: 21})
+	require.NoError(t, err)
+	failID, err := queue.Enqueue(ctx, Task{Type: "double", Payload: -1})
+	require.NoError(t, err)
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+
+	job, err := queue.Wait(waitCtx, okID, time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, StatusSucceeded, job.Status)
+	var result int
+	require.NoError(t, job.DecodeResult(&result))
+	require.Equal(t, 42, result)
+
+	job, err = queue.Wait(waitCtx, failID, time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, StatusFailed, job.Status)
+	require.Equal(t, "negative input", job.Error)
+
+	_, err = queue.Enqueue(ctx, Task{Type: "unknown"})
+	require.Error(t, err)
+
+	cancel()
+	<-done
+}
+
+// failingBackend fails every Pop with err, counting the calls
+type failingBackend struct {
+	MemoryBackend
+	err   error
+	calls atomic.Int64
+}
+
+func (b *failingBackend) Pop(ctx context.Context) (Job, error) {
+	b.calls.Add(1)
+	return Job{}, b.err
+}
+
+func TestQueueBacksOffOnBackendErrors(t *testing.T) {
+	backend := &failingBackend{err: errors.New("connection refused")}
+	queue := NewQueue(backend, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	queue.Run(ctx)
+
+	// attempts at 0, 100ms and 300ms
+	require.LessOrEqual(t, backend.calls.Load(), int64(3))
+}
+
+func TestQueueDropsJobsWithoutPayload(t *testing.T) {
+	backend := &failingBackend{err: fmt.Errorf("load: %w", ErrJobNotFound)}
+	queue := NewQueue(backend, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	queue.Run(ctx)
+
+	// dropped without waiting
+	require.Greater(t, backend.calls.Load(), int64(3))
+}
@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBackend keeps jobs in process memory
+// Jobs are lost on restart, use RedisBackend when results must survive the process
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	jobs  map[string]Job
+	queue chan string
+}
+
+// NewMemoryBackend creates an in-process backend that can hold up to capacity pending jobs
+func NewMemoryBackend(capacity int) *MemoryBackend {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &MemoryBackend{
+		jobs:  make(map[string]Job),
+		queue: make(chan string, capacity),
+	}
+}
+
+func (m *MemoryBackend) Push(ctx context.Context, job Job) error {
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job.ID:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		delete(m.jobs, job.ID)
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (m *MemoryBackend) Pop(ctx context.Context) (Job, error) {
+	select {
+	case id := <-m.queue:
+		return m.Get(ctx, id)
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+func (m *MemoryBackend) Save(_ context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = job
+	return nil
+}
+
+func (m *MemoryBackend) Get(_ context.Context, id string) (Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// MemoryScheduleStore keeps schedule state in process memory
+// It only prevents overlapping runs within the process, use RedisScheduleStore when several replicas run a Scheduler
+type MemoryScheduleStore struct {
+	mu     sync.Mutex
+	states map[string]ScheduleState
+	locks  map[string]memoryLock
+}
+
+// memoryLock is a taken run lock
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemoryScheduleStore creates an in-process schedule store
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{
+		states: make(map[string]ScheduleState),
+		locks:  make(map[string]memoryLock),
+	}
+}
+
+func (m *MemoryScheduleStore) Load(_ context.Context, name string) (ScheduleState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[name]
+	if !ok {
+		return ScheduleState{Name: name}, nil
+	}
+	return state, nil
+}
+
+func (m *MemoryScheduleStore) Save(_ context.Context, state ScheduleState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.Name] = state
+	return nil
+}
+
+func (m *MemoryScheduleStore) Lock(_ context.Context, name string, ttl time.Duration) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lock, ok := m.locks[name]; ok && time.Now().Before(lock.expires) {
+		return "", false, nil
+	}
+	token := uuid.New().String()
+	m.locks[name] = memoryLock{token: token, expires: time.Now().Add(ttl)}
+	return token, true, nil
+}
+
+func (m *MemoryScheduleStore) Unlock(_ context.Context, name, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[name]; ok && lock.token == token {
+		delete(m.locks, name)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBackend stores jobs in Redis so they can be enqueued and polled from any process
+type RedisBackend struct {
+	client    *redis.Client
+	prefix    string
+	resultTTL time.Duration
+}
+
+// NewRedisBackend creates a Redis backed job store
+// Keys are namespaced with prefix; finished jobs expire after resultTTL (0 keeps them forever)
+func NewRedisBackend(client *redis.Client, prefix string, resultTTL time.Duration) *RedisBackend {
+	if prefix == "" {
+		prefix = "goaikit:jobs"
+	}
+	return &RedisBackend{
+		client:    client,
+		prefix:    prefix,
+		resultTTL: resultTTL,
+	}
+}
+
+func (r *RedisBackend) queueKey() string {
+	return r.prefix + ":queue"
+}
+
+func (r *RedisBackend) jobKey(id string) string {
+	return fmt.Sprintf("%s:job:%s", r.prefix, id)
+}
+
+func (r *RedisBackend) Push(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.jobKey(job.ID), data, 0)
+	pipe.LPush(ctx, r.queueKey(), job.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push job: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisBackend) Pop(ctx context.Context) (Job, error) {
+	for {
+		// Use a short blocking timeout so cancellation is noticed promptly
+		result, err := r.client.BRPop(ctx, time.Second, r.queueKey()).Result()
+		if errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return Job{}, ctx.Err()
+			}
+			continue
+		}
+		if err != nil {
+			return Job{}, fmt.Errorf("failed to pop job: %w", err)
+		}
+
+		// result is [key, value]
+		return r.Get(ctx, result[1])
+	}
+}
+
+func (r *RedisBackend) Save(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	var ttl time.Duration
+	if job.Done() {
+		ttl = r.resultTTL
+	}
+
+	if err := r.client.Set(ctx, r.jobKey(job.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisBackend) Get(ctx context.Context, id string) (Job, error) {
+	data, err := r.client.Get(ctx, r.jobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to load job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return job, nil
+}
+
+// RedisScheduleStore keeps schedule state and run locks in Redis, so replicas never run an entry concurrently
+type RedisScheduleStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisScheduleStore creates a Redis backed schedule store, keys are namespaced with prefix
+func NewRedisScheduleStore(client *redis.Client, prefix string) *RedisScheduleStore {
+	if prefix == "" {
+		prefix = "goaikit:schedules"
+	}
+	return &RedisScheduleStore{client: client, prefix: prefix}
+}
+
+func (r *RedisScheduleStore) stateKey(name string) string {
+	return fmt.Sprintf("%s:state:%s", r.prefix, name)
+}
+
+func (r *RedisScheduleStore) lockKey(name string) string {
+	return fmt.Sprintf("%s:lock:%s", r.prefix, name)
+}
+
+func (r *RedisScheduleStore) Load(ctx context.Context, name string) (ScheduleState, error) {
+	data, err := r.client.Get(ctx, r.stateKey(name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ScheduleState{Name: name}, nil
+	}
+	if err != nil {
+		return ScheduleState{}, fmt.Errorf("failed to load schedule state: %w", err)
+	}
+
+	var state ScheduleState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return ScheduleState{}, fmt.Errorf("failed to unmarshal schedule state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *RedisScheduleStore) Save(ctx context.Context, state ScheduleState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule state: %w", err)
+	}
+	if err := r.client.Set(ctx, r.stateKey(state.Name), data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save schedule state: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisScheduleStore) Lock(ctx context.Context, name string, ttl time.Duration) (string, bool, error) {
+	token := uuid.New().String()
+	ok, err := r.client.SetNX(ctx, r.lockKey(name), token, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to lock schedule: %w", err)
+	}
+	if !ok {
+		return "", false, nil
+	}
+	return token, true, nil
+}
+
+// unlockScript deletes a lock only while it holds the caller's token, so a run whose lock expired can't release the
+// lock another replica took since
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *RedisScheduleStore) Unlock(ctx context.Context, name, token string) error {
+	if err := unlockScript.Run(ctx, r.client, []string{r.lockKey(name)}, token).Err(); err != nil {
+		return fmt.Errorf("failed to unlock schedule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/webhook"
+)
+
+// ScheduleState is the persisted state of a scheduled entry
+type ScheduleState struct {
+	Name       string    `json:"name"`
+	LastRun    time.Time `json:"last_run"`
+	FinishedAt time.Time `json:"finished_at"`
+	Status     Status    `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	NextRun    time.Time `json:"next_run"`
+}
+
+// ScheduleStore persists schedule state and guards runs against overlapping, also across processes
+type ScheduleStore interface {
+	// Load returns the state of an entry, a zero state with Name set when it never ran
+	Load(ctx context.Context, name string) (ScheduleState, error)
+	// Save persists the state of an entry
+	Save(ctx context.Context, state ScheduleState) error
+	// Lock takes the run lock of an entry for at most ttl, ok is false when it's already taken
+	// The token identifies this holder of the lock
+	Lock(ctx context.Context, name string, ttl time.Duration) (token string, ok bool, err error)
+	// Unlock releases the run lock of an entry if it is still held with token, a lock that expired and was
+	// taken by another run is left alone
+	Unlock(ctx context.Context, name, token string) error
+}
+
+// Entry is a handler triggered on a cron schedule
+type Entry struct {
+	// Name identifies the entry and its persisted state (required)
+	Name string
+
+	// Cron is the schedule, see ParseCron (required)
+	Cron string
+
+	// Handler runs the entry, e.g. AgentHandler (required)
+	Handler Handler
+
+	// Payload is marshalled to JSON and passed to the handler (optional)
+	Payload any
+
+	// Timeout bounds a single run and the run lock (optional, defaults to 1h)
+	Timeout time.Duration
+
+	// RunMissed runs the entry once on start when an activation was missed while no scheduler was running (optional)
+	RunMissed bool
+}
+
+type scheduledEntry struct {
+	Entry
+	schedule Schedule
+	payload  json.RawMessage
+}
+
+// Scheduler triggers handlers on cron schedules
+// A run is skipped while the previous run of the same entry is still going, in any process sharing the store
+type Scheduler struct {
+	store    ScheduleStore
+	notifier *webhook.Notifier
+	location *time.Location
+	entries  []*scheduledEntry
+	runs     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler persisting its state in store
+func NewScheduler(store ScheduleStore) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		location: time.Local,
+	}
+}
+
+// WithNotifier sends a schedule.failed webhook event for every failed run
+func (s *Scheduler) WithNotifier(notifier *webhook.Notifier) *Scheduler {
+	s.notifier = notifier
+	return s
+}
+
+// WithLocation sets the time zone cron expressions are evaluated in (defaults to time.Local)
+func (s *Scheduler) WithLocation(location *time.Location) *Scheduler {
+	s.location = location
+	return s
+}
+
+// Add registers an entry, it must be called before Run
+func (s *Scheduler) Add(entry Entry) error {
+	if entry.Name == "" {
+		return fmt.Errorf("schedule entry name is required")
+	}
+	if entry.Handler == nil {
+		return fmt.Errorf("schedule entry %q has no handler", entry.Name)
+	}
+	for _, existing := range s.entries {
+		if existing.Name == entry.Name {
+			return fmt.Errorf("schedule entry %q is already registered", entry.Name)
+		}
+	}
+
+	schedule, err := ParseCron(entry.Cron)
+	if err != nil {
+		return fmt.Errorf("schedule entry %q: %w", entry.Name, err)
+	}
+	payload, err := json.Marshal(entry.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload of schedule entry %q: %w", entry.Name, err)
+	}
+	if entry.Timeout <= 0 {
+		entry.Timeout = time.Hour
+	}
+
+	s.entries = append(s.entries, &scheduledEntry{Entry: entry, schedule: schedule, payload: payload})
+	return nil
+}
+
+// State returns the persisted state of an entry
+func (s *Scheduler) State(ctx context.Context, name string) (ScheduleState, error) {
+	return s.store.Load(ctx, name)
+}
+
+// Run triggers the entries until ctx is cancelled, then waits for the runs in progress to finish
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, entry := range s.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, entry)
+		}()
+	}
+	wg.Wait()
+	s.runs.Wait()
+}
+
+// loop waits for every activation of an entry and starts its run
+func (s *Scheduler) loop(ctx context.Context, entry *scheduledEntry) {
+	next := s.next(entry, time.Now())
+
+	if entry.RunMissed {
+		state, err := s.store.Load(ctx, entry.Name)
+		if err != nil {
+			slog.Error("failed to load schedule state", "schedule", entry.Name, "error", err)
+		} else if !state.LastRun.IsZero() {
+			if missed := s.next(entry, state.LastRun); !missed.IsZero() && missed.Before(time.Now()) {
+				next = time.Now()
+			}
+		}
+	}
+
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		following := s.next(entry, time.Now())
+		s.trigger(ctx, entry, following)
+		next = following
+	}
+	slog.Warn("schedule has no future activations", "schedule", entry.Name)
+}
+
+func (s *Scheduler) next(entry *scheduledEntry, after time.Time) time.Time {
+	return entry.schedule.Next(after.In(s.location))
+}
+
+// trigger starts a run unless the previous one still holds the lock
+func (s *Scheduler) trigger(ctx context.Context, entry *scheduledEntry, next time.Time) {
+	token, locked, err := s.store.Lock(ctx, entry.Name, entry.Timeout+time.Minute)
+	if err != nil {
+		slog.Error("failed to lock schedule", "schedule", entry.Name, "error", err)
+		return
+	}
+	if !locked {
+		slog.Warn("skipping scheduled run, the previous run is still going", "schedule", entry.Name)
+		return
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		// the run finishes and records its outcome even if the scheduler is stopping
+		runCtx := context.WithoutCancel(ctx)
+		defer func() {
+			if err := s.store.Unlock(runCtx, entry.Name, token); err != nil {
+				slog.Error("failed to unlock schedule", "schedule", entry.Name, "error", err)
+			}
+		}()
+		s.run(runCtx, entry, next)
+	}()
+}
+
+func (s *Scheduler) run(ctx context.Context, entry *scheduledEntry, next time.Time) {
+	state := ScheduleState{
+		Name:    entry.Name,
+		LastRun: time.Now().UTC(),
+		Status:  StatusRunning,
+		NextRun: next.UTC(),
+	}
+	s.save(ctx, state)
+
+	runCtx, cancel := context.WithTimeout(ctx, entry.Timeout)
+	_, err := runHandler(runCtx, entry.Handler, entry.payload)
+	cancel()
+
+	state.FinishedAt = time.Now().UTC()
+	state.Status = StatusSucceeded
+	if err != nil {
+		state.Status = StatusFailed
+		state.Error = err.Error()
+		slog.Error("scheduled run failed", "schedule", entry.Name, "error", err)
+	}
+	s.save(ctx, state)
+
+	if err != nil {
+		s.notify(ctx, state)
+	}
+}
+
+func (s *Scheduler) save(ctx context.Context, state ScheduleState) {
+	if err := s.store.Save(ctx, state); err != nil {
+		slog.Error("failed to save schedule state", "schedule", state.Name, "error", err)
+	}
+}
+
+func (s *Scheduler) notify(ctx context.Context, state ScheduleState) {
+	if s.notifier == nil {
+		return
+	}
+
+	err := s.notifier.Send(ctx, webhook.Event{
+		Type: webhook.EventScheduleFailed,
+		Data: map[string]any{
+			"schedule":    state.Name,
+			"error":       state.Error,
+			"started_at":  state.LastRun,
+			"finished_at": state.FinishedAt,
+			"next_run":    state.NextRun,
+		},
+	})
+	if err != nil {
+		slog.Error("failed to deliver schedule failure", "schedule", state.Name, "error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2025, time.January, 30, 10, 7, 30, 0, time.UTC) // a Thursday
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, time.January, 30, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * sat,sun", time.Date(2025, time.February, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 7", time.Date(2025, time.February, 1, 12, 0, 0, 0, time.UTC)}, // day of month or Sunday
+		{"@monthly", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2025, time.January, 30, 10, 25, 0, 0, time.UTC)},
+		{"@every 90s", start.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.next, schedule.Next(start), tt.expr)
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * foo *", "*/0 * * * *", "@every -1s"} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	store := NewMemoryScheduleStore()
+	scheduler := NewScheduler(store)
+
+	var runs, concurrent, maxConcurrent int32
+	require.NoError(t, scheduler.Add(Entry{
+		Name: "slow",
+		Cron: "@every 10ms",
+		Handler: func(ctx context.Context, payload json.RawMessage) (any, error) {
+			atomic.AddInt32(&runs, 1)
+			n := atomic.AddInt32(&concurrent, 1)
+			defer atomic.AddInt32(&concurrent, -1)
+			if n > atomic.LoadInt32(&maxConcurrent) {
+				atomic.StoreInt32(&maxConcurrent, n)
+			}
+			time.Sleep(35 * time.Millisecond)
+			return nil, nil
+		},
+	}))
+	require.Error(t, scheduler.Add(Entry{Name: "slow", Cron: "@hourly", Handler: func(context.Context, json.RawMessage) (any, error) { return nil, nil }}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	scheduler.Run(ctx)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&maxConcurrent))
+	require.GreaterOrEqual(t, atomic.LoadInt32(&runs), int32(2))
+	require.Less(t, atomic.LoadInt32(&runs), int32(6))
+
+	state, err := scheduler.State(context.Background(), "slow")
+	require.NoError(t, err)
+	require.Equal(t, StatusSucceeded, state.Status)
+	require.False(t, state.LastRun.IsZero())
+}
+
+func TestSchedulerNotifiesFailures(t *testing.T) {
+	events := make(chan webhook.Event, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.True(t, webhook.Verify("secret", r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)))
+		var event webhook.Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		events <- event
+	}))
+	defer server.Close()
+
+	notifier, err := webhook.NewNotifier(webhook.Config{URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	store := NewMemoryScheduleStore()
+	// the previous process ran the entry long ago, so the missed run happens right away
+	require.NoError(t, store.Save(context.Background(), ScheduleState{Name: "report", LastRun: time.Now().Add(-48 * time.Hour)}))
+
+	scheduler := NewScheduler(store).WithNotifier(notifier)
+	require.NoError(t, scheduler.Add(Entry{
+		Name:      "report",
+		Cron:      "@daily",
+		Payload:   map[string]string{"topic": "sales"},
+		RunMissed: true,
+		Handler: func(ctx context.Context, payload json.RawMessage) (any, error) {
+			return nil, fmt.Errorf("no data for %s", payload)
+		},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case event := <-events:
+		require.Equal(t, webhook.EventScheduleFailed, event.Type)
+		require.Equal(t, "report", event.Data["schedule"])
+		require.Equal(t, `no data for {"topic":"sales"}`, event.Data["error"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no failure notification")
+	}
+	cancel()
+	<-done
+
+	state, err := scheduler.State(context.Background(), "report")
+	require.NoError(t, err)
+	require.Equal(t, StatusFailed, state.Status)
+	require.Greater(t, state.NextRun, time.Now())
+}
+
+func TestScheduleLockReleasesOnlyItsOwnHolder(t *testing.T) {
+	store := NewMemoryScheduleStore()
+	ctx := context.Background()
+
+	first, ok, err := store.Lock(ctx, "report", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+	time.Sleep(2 * time.Millisecond)
+
+	// the first run outlived its lock and another run took it
+	second, ok, err := store.Lock(ctx, "report", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEqual(t, first, second)
+
+	require.NoError(t, store.Unlock(ctx, "report", first))
+	_, ok, err = store.Lock(ctx, "report", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok, "a stale unlock must not release the new holder's lock")
+
+	require.NoError(t, store.Unlock(ctx, "report", second))
+	_, ok, err = store.Lock(ctx, "report", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -3,10 +3,13 @@ package kit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"reflect"
+	"sync"
+	"time"
 
 	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/prompt"
 	"github.com/mhrlife/goai-kit/schema"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -14,6 +17,9 @@ import (
 	"github.com/openai/openai-go/shared"
 )
 
+// ErrMaxIterations is returned when the model still calls tools after the iteration limit of a run
+var ErrMaxIterations = errors.New("max iterations reached without completion")
+
 // Agent represents an AI agent that can execute tasks with tools
 type Agent[Output any] struct {
 	client        *Client
@@ -23,6 +29,32 @@ type Agent[Output any] struct {
 	callbacks     []callback.AgentCallback
 	maxIterations int
 	temperature   *float64
+
+	generationName   GenerationNamer
+	toolResultPolicy *ToolResultPolicy
+	textFormat       TextFormat
+	stop             []string
+	formatPolicy     FormatPolicy
+	stepTimeout      time.Duration
+	guards           []Guard
+	inputGuards      []Guard
+	outputGuards     []Guard
+	toolConstraints  map[string][]ArgConstraint // tool name -> constraints
+	schemaDialect    *schema.Dialect
+	parallelTools    int
+	openRouter       *OpenRouterOptions
+	toolErrorPolicy  *ToolErrorPolicy
+	outputMode       OutputMode
+	cache            *agentCache
+	replyLanguage    *ReplyLanguage
+	normalizePrompt  func(string) string
+	toolChoice       ToolChoice
+	logprobs         bool
+	topLogprobs      int
+	n                int
+	scorer           CandidateScorer[Output]
+	partialOutput    func(Output)
+	retryPolicy      *RetryPolicy
 }
 
 // InvokeConfig contains configuration for agent invocation
@@ -33,6 +65,13 @@ type InvokeConfig struct {
 	// Messages is a list of OpenAI chat completion messages (mutually exclusive with Prompt)
 	Messages []openai.ChatCompletionMessageParamUnion
 
+	// Files (PDFs, images) sent in the same user message as Prompt (optional, requires Prompt)
+	Files []File
+
+	// Template is a prompt rendered with prompt.Template's ExecuteRendered (mutually exclusive with Prompt and Messages)
+	// Its name, version and variables are attached to every generation in traces
+	Template *prompt.Rendered
+
 	// Callbacks to be notified of agent lifecycle events
 	Callbacks []callback.AgentCallback
 
@@ -44,6 +83,31 @@ type InvokeConfig struct {
 
 	// MaxIterations for tool calling loop (optional, defaults to agent's maxIterations)
 	MaxIterations *int
+
+	// TextFormat constrains string outputs (optional, defaults to agent's text format)
+	TextFormat TextFormat
+
+	// Stop sequences for this run (optional, defaults to agent's stop sequences)
+	Stop []string
+
+	// GenerationName names each generation in traces (optional, defaults to agent's generation name)
+	GenerationName GenerationNamer
+
+	// StepTimeout limits every LLM and tool call of this run (optional, defaults to agent's step timeout)
+	StepTimeout time.Duration
+
+	// ToolChoice of the first turn of this run (optional, defaults to agent's tool choice)
+	ToolChoice ToolChoice
+}
+
+// loopConfig holds the per-run settings of the tool calling loop
+type loopConfig struct {
+	maxIterations  int
+	stop           []string
+	generationName GenerationNamer
+	stepTimeout    time.Duration
+	language       string // language string answers must be written in, "" accepts any
+	toolChoice     ToolChoice
 }
 
 // CreateAgent creates a new agent that returns string output
@@ -89,7 +153,8 @@ func (a *Agent[Output]) WithCallbacks(callbacks ...callback.AgentCallback) *Agen
 	return a
 }
 
-// WithMaxIterations sets the maximum number of tool calling iterations
+// WithMaxIterations sets the maximum number of tool calling iterations (defaults to 10),
+// runs that exceed it fail with ErrMaxIterations
 func (a *Agent[Output]) WithMaxIterations(max int) *Agent[Output] {
 	a.maxIterations = max
 	return a
@@ -101,22 +166,111 @@ func (a *Agent[Output]) WithTemperature(temp float64) *Agent[Output] {
 	return a
 }
 
+// WithStepTimeout limits every LLM and tool call, so one slow step can't spend a run's whole budget
+func (a *Agent[Output]) WithStepTimeout(timeout time.Duration) *Agent[Output] {
+	a.stepTimeout = timeout
+	return a
+}
+
+// WithPromptNormalizer rewrites InvokeConfig.Prompt and rendered templates before they are sent,
+// e.g. textnorm.Normalize to unify Persian text typed with Arabic letters or digits
+func (a *Agent[Output]) WithPromptNormalizer(normalize func(string) string) *Agent[Output] {
+	a.normalizePrompt = normalize
+	return a
+}
+
+// WithParallelTools runs up to n tool calls of one model response concurrently
+// Tool messages are still assembled in the order of the calls
+func (a *Agent[Output]) WithParallelTools(n int) *Agent[Output] {
+	a.parallelTools = n
+	return a
+}
+
+// WithSchemaDialect overrides the schema dialect picked from the model name, see schema.DialectForModel
+func (a *Agent[Output]) WithSchemaDialect(dialect schema.Dialect) *Agent[Output] {
+	a.schemaDialect = &dialect
+	return a
+}
+
+// SchemaDialect returns the dialect tool and response schemas are adapted to
+func (a *Agent[Output]) SchemaDialect() schema.Dialect {
+	if a.schemaDialect != nil {
+		return *a.schemaDialect
+	}
+	if preset := a.client.config.Preset; preset != nil && preset.Dialect != nil {
+		return *preset.Dialect
+	}
+	return schema.DialectForModel(a.model)
+}
+
 // Invoke executes the agent with the given configuration
 func (a *Agent[Output]) Invoke(ctx context.Context, config InvokeConfig) (Output, error) {
-	var zero Output
+	result, err := a.InvokeWithResult(ctx, config)
+	if err != nil {
+		// the output of a failed run may be partly decoded
+		var zero Output
+		return zero, err
+	}
+	return result.Output, nil
+}
+
+// InvokeWithResult executes the agent and returns the output together with the run's conversation
+func (a *Agent[Output]) InvokeWithResult(ctx context.Context, config InvokeConfig) (Result[Output], error) {
+	var result Result[Output]
 
 	// merge all callbacks but when there are two callbacks with the same name, only keep
 	// the invoke callback
 	allCallbacks := a.mergeCallbacks(config.Callbacks)
 
 	// Create callback manager
-	cbManager := callback.NewManager(allCallbacks, config.ParentRunID)
+	cbManager := callback.NewManager(allCallbacks, config.ParentRunID).WithContext(ctx)
+	if pricing := a.client.config.Pricing; pricing != nil {
+		cbManager.WithCost(pricing.costFunc())
+		result.pricing, result.model = pricing, a.model
+	}
+	if config.Template != nil {
+		if config.Prompt != "" {
+			err := fmt.Errorf("cannot specify both Prompt and Template")
+			cbManager.OnError(err, "run")
+			return result, err
+		}
+		config.Prompt = config.Template.Text
+		cbManager.WithPromptTemplate(&callback.PromptTemplate{
+			Name:      config.Template.Template,
+			Version:   config.Template.Version,
+			Variables: config.Template.Variables,
+		})
+	}
+
+	if a.normalizePrompt != nil {
+		config.Prompt = a.normalizePrompt(config.Prompt)
+	}
+	if len(a.inputGuards) > 0 {
+		guarded, err := a.guardInput(ctx, config)
+		if err != nil {
+			cbManager.OnError(err, "run")
+			return result, err
+		}
+		config.Prompt = guarded
+	}
 
 	// Build messages
 	messages, err := a.buildMessages(config)
 	if err != nil {
 		cbManager.OnError(err, "run")
-		return zero, err
+		return result, err
+	}
+
+	// Instruct the model to reply in the user's language, a failed detection only skips the instruction
+	var language string
+	if a.replyLanguage != nil {
+		language, err = a.replyLanguage.replyLanguageFor(ctx, messages)
+		if err != nil {
+			a.client.Logger.Error("Failed to detect reply language", "error", err)
+		}
+		if language != "" {
+			messages = languageInstruction(messages, language)
+		}
 	}
 
 	// Determine if we have a typed output
@@ -136,15 +290,90 @@ func (a *Agent[Output]) Invoke(ctx context.Context, config InvokeConfig) (Output
 		maxIter = *config.MaxIterations
 	}
 
+	stop := a.stop
+	if len(config.Stop) > 0 {
+		stop = config.Stop
+	}
+
+	generationName := a.generationName
+	if config.GenerationName != nil {
+		generationName = config.GenerationName
+	}
+
+	stepTimeout := a.stepTimeout
+	if config.StepTimeout > 0 {
+		stepTimeout = config.StepTimeout
+	}
+
+	toolChoice := a.toolChoice
+	if config.ToolChoice != "" {
+		toolChoice = config.ToolChoice
+	}
+	if err := toolChoice.check(a.schemas); err != nil {
+		cbManager.OnError(err, "run")
+		return result, err
+	}
+
+	// Answer from cache when the same run was made before, cached answers cost no tokens but still need a valid key
+	var cacheKey CachedPrompt
+	if a.cache != nil {
+		if quota := a.client.config.Quota; quota != nil {
+			if _, err := quota.authorize(ctx, a.model); err != nil {
+				cbManager.OnError(err, "run")
+				return result, err
+			}
+		}
+		cacheKey, err = a.cacheKey(config.Template, messages, stop, toolChoice)
+		if err != nil {
+			cbManager.OnError(err, "run")
+			return result, err
+		}
+		entry, err := a.cache.lookup(ctx, cacheKey, a.model)
+		if err != nil {
+			a.client.Logger.Error("Failed to read response cache", "key", cacheKey.Key, "error", err)
+		}
+		if entry != nil && json.Unmarshal(entry.Output, &result.Output) == nil {
+			result.Cached = true
+			result.Messages = append(messages, openai.AssistantMessage(entry.Content))
+			result.Dialogue, result.Scratchpad = splitDialogue(result.Messages)
+			if err := a.guardOutput(ctx, &result); err != nil {
+				cbManager.OnError(err, "run")
+				return result, err
+			}
+			cbManager.OnRunEnd(result.Output, 0)
+			return result, nil
+		}
+	}
+
 	// Execute the agent loop
-	result, iterations, err := a.executeLoop(ctx, messages, cbManager, maxIter)
+	err = a.executeLoop(ctx, messages, cbManager, loopConfig{
+		maxIterations:  maxIter,
+		stop:           stop,
+		generationName: generationName,
+		stepTimeout:    stepTimeout,
+		language:       language,
+		toolChoice:     toolChoice,
+	}, &result)
+	if err == nil {
+		err = a.selectCandidate(ctx, &result)
+	}
+	result.Dialogue, result.Scratchpad = splitDialogue(result.Messages)
 	if err != nil {
 		cbManager.OnError(err, "run")
-		return zero, err
+		return result, err
+	}
+
+	if err := a.guardOutput(ctx, &result); err != nil {
+		cbManager.OnError(err, "run")
+		return result, err
+	}
+
+	if a.cache != nil {
+		a.saveToCache(ctx, cacheKey, config.Template, &result)
 	}
 
 	// Trigger OnRunEnd
-	cbManager.OnRunEnd(result, iterations)
+	cbManager.OnRunEnd(result.Output, result.Iterations)
 
 	return result, nil
 }
@@ -174,12 +403,26 @@ func (a *Agent[Output]) buildMessages(config InvokeConfig) ([]openai.ChatComplet
 		messages = append(messages, openai.SystemMessage(config.SystemPrompt))
 	}
 
+	textFormat := a.textFormat
+	if config.TextFormat != TextFormatDefault {
+		textFormat = config.TextFormat
+	}
+	if instruction := textFormat.instruction(); instruction != "" {
+		messages = append(messages, openai.SystemMessage(instruction))
+	}
+
 	// Use either Prompt or Messages
 	if config.Prompt != "" && len(config.Messages) > 0 {
 		return nil, fmt.Errorf("cannot specify both Prompt and Messages")
 	}
 
-	if config.Prompt != "" {
+	if len(config.Files) > 0 && config.Prompt == "" {
+		return nil, fmt.Errorf("files can only be attached to a Prompt")
+	}
+
+	if len(config.Files) > 0 {
+		messages = append(messages, userMessageWithFiles(config.Prompt, config.Files))
+	} else if config.Prompt != "" {
 		messages = append(messages, openai.UserMessage(config.Prompt))
 	} else if len(config.Messages) > 0 {
 		messages = append(messages, config.Messages...)
@@ -190,34 +433,90 @@ func (a *Agent[Output]) buildMessages(config InvokeConfig) ([]openai.ChatComplet
 	return messages, nil
 }
 
-// executeLoop runs the agent's tool calling loop
+// executeLoop runs the agent's tool calling loop and fills result with the output and conversation
 func (a *Agent[Output]) executeLoop(
 	ctx context.Context,
 	messages []openai.ChatCompletionMessageParamUnion,
 	cbManager *callback.Manager,
-	maxIterations int,
-) (Output, int, error) {
-	var zero Output
+	loop loopConfig,
+	result *Result[Output],
+) error {
 	iteration := 0
+	defer func() {
+		result.Messages = messages
+		result.Iterations = iteration
+	}()
+	maxIterations := loop.maxIterations
+	toolsCalled := false
+
+	formatPolicy := a.formatPolicy
+	if formatPolicy == nil && a.client.config.Preset != nil {
+		formatPolicy = a.client.config.Preset.FormatPolicy
+	}
+	if formatPolicy == nil {
+		formatPolicy = FormatEveryIteration
+	}
+
+	dialect := a.SchemaDialect()
 
 	// Convert tool schemas to OpenAI tool definitions
 	tools := make([]openai.ChatCompletionToolParam, 0, len(a.schemas))
 	for _, toolSchema := range a.schemas {
-		tools = append(tools, openai.ChatCompletionToolParam{
-			Function: shared.FunctionDefinitionParam{
-				Name:        toolSchema.Name,
-				Description: param.NewOpt(toolSchema.Description),
-				Parameters:  toolSchema.JSONSchema,
-				Strict:      param.NewOpt(true),
-			},
-		})
+		function := shared.FunctionDefinitionParam{
+			Name:        toolSchema.Name,
+			Description: param.NewOpt(toolSchema.Description),
+			Parameters:  dialect.Adapt(toolSchema.JSONSchema),
+		}
+		if dialect.Strict {
+			function.Strict = param.NewOpt(true)
+		}
+		tools = append(tools, openai.ChatCompletionToolParam{Function: function})
 	}
 
-	for iteration < maxIterations {
+	// Structured answers are validated against the schema the model was given
+	var outputType Output
+	var outputSchema map[string]any
+	if !isStringType(outputType) {
+		outputSchema = dialect.Adapt(schema.MarshalToSchema(outputType))
+	}
+
+	// In tool mode the output schema is the submit_result tool instead of a response format
+	submitTool := !isStringType(outputType) && a.OutputMode() == OutputModeTool
+	if submitTool {
+		function := shared.FunctionDefinitionParam{
+			Name:        submitResultTool,
+			Description: param.NewOpt("Submit the final answer. Call it once you have everything needed to answer."),
+			Parameters:  outputSchema,
+		}
+		if dialect.Strict {
+			function.Strict = param.NewOpt(true)
+		}
+		tools = append(tools, openai.ChatCompletionToolParam{Function: function})
+	}
+
+	// finalTurn is set when a structured answer came without the schema and must be requested with it
+	finalTurn := false
+	languageRetries := 0
+
+	for iteration < maxIterations || finalTurn {
+		if err := budgetErr(ctx, nil); err != nil {
+			return err
+		}
 		iteration++
+		final := finalTurn
+		finalTurn = false
 
 		// Trigger OnGenerationStart
-		cbManager.OnGenerationStart(iteration, messages, a.model)
+		name := ""
+		if loop.generationName != nil {
+			name = loop.generationName(iteration)
+		}
+		cbManager.OnGenerationStartInfo(callback.GenerationStartInfo{
+			Iteration: iteration,
+			Messages:  messages,
+			Model:     a.model,
+			Name:      name,
+		})
 
 		// Build request params
 		params := openai.ChatCompletionNewParams{
@@ -228,39 +527,107 @@ func (a *Agent[Output]) executeLoop(
 		if a.temperature != nil {
 			params.Temperature = param.NewOpt(*a.temperature)
 		}
+		params.N = a.nParam()
+		if a.logprobs {
+			params.Logprobs = param.NewOpt(true)
+			if a.topLogprobs > 0 {
+				params.TopLogprobs = param.NewOpt(int64(a.topLogprobs))
+			}
+		}
 
 		// Add tools if available
 		if len(tools) > 0 {
 			params.Tools = tools
+			if !toolsCalled {
+				params.ToolChoice = loop.toolChoice.param()
+			}
+			if final {
+				params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{
+					OfAuto: param.NewOpt(string(openai.ChatCompletionToolChoiceOptionAutoNone)),
+				}
+			}
+			if submitTool && params.ToolChoice.OfChatCompletionNamedToolChoice == nil {
+				params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{
+					OfAuto: param.NewOpt(string(openai.ChatCompletionToolChoiceOptionAutoRequired)),
+				}
+			}
+		}
+
+		if len(loop.stop) > 0 {
+			params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: loop.stop}
 		}
 
 		// Check if Output is a struct type for response_format
-		var outputType Output
-		if !isStringType(outputType) {
+		sendFormat := !submitTool && formatPolicy(FormatTurn{
+			Iteration:      iteration,
+			ToolsAvailable: len(tools) > 0,
+			ToolsCalled:    toolsCalled,
+			Final:          final,
+		})
+		if !isStringType(outputType) && sendFormat {
 			// Add response format for structured output
-			outputSchema := schema.InferJSONSchema(outputType)
+			jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   "response",
+				Schema: outputSchema,
+			}
+			if dialect.Strict {
+				jsonSchema.Strict = param.NewOpt(true)
+			}
 			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
-				OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
-					JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
-						Strict: param.NewOpt(true),
-						Name:   "response",
-						Schema: outputSchema,
-					},
-				},
+				OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: jsonSchema},
 			}
 		}
 
+		if a.openRouter != nil {
+			params.SetExtraFields(a.openRouter.extraFields())
+		}
+
+		// Check the API key of the run before spending tokens on it
+		var quotaKey *APIKey
+		if quota := a.client.config.Quota; quota != nil {
+			key, err := quota.Check(ctx, a.model)
+			if err != nil {
+				cbManager.OnError(err, "generation")
+				return err
+			}
+			quotaKey = key
+		}
+
 		// Call OpenAI API
-		completion, err := a.client.client.Chat.Completions.New(ctx, params)
+		stepCtx, cancel := StepContext(ctx, loop.stepTimeout)
+		stepCtx, requestOpts := a.retryOptions(stepCtx)
+		if a.client.config.Quota != nil {
+			stepCtx = withCheckedQuota(stepCtx, quotaKey)
+		}
+		started := time.Now()
+		var completion *openai.ChatCompletion
+		var err error
+		if a.partialOutput != nil {
+			completion, err = a.streamCompletion(stepCtx, params, newPartialEmitter(a.partialOutput, submitTool).update, requestOpts...)
+		} else {
+			completion, err = a.client.client.Chat.Completions.New(stepCtx, params, requestOpts...)
+		}
+		cancel()
 		if err != nil {
 			cbManager.OnError(err, "generation")
-			return zero, iteration, fmt.Errorf("OpenAI API error: %w", err)
+			if exceeded := budgetErr(ctx, err); exceeded != nil {
+				return exceeded
+			}
+			return fmt.Errorf("OpenAI API error: %w", err)
+		}
+		// the client's middleware charges other responses, streams only have their usage once accumulated
+		if quotaKey != nil && a.partialOutput != nil {
+			if err := a.client.config.Quota.Record(ctx, quotaKey, completion.Usage.TotalTokens); err != nil {
+				a.client.Logger.Error("Failed to record token usage", "key", quotaKey.ID, "error", err)
+			}
 		}
+		result.Completions = append(result.Completions, completion)
+		result.Latencies = append(result.Latencies, time.Since(started))
 
 		if len(completion.Choices) == 0 {
 			err := fmt.Errorf("no choices in response")
 			cbManager.OnError(err, "generation")
-			return zero, iteration, err
+			return err
 		}
 
 		choice := completion.Choices[0]
@@ -269,7 +636,13 @@ func (a *Agent[Output]) executeLoop(
 		toolCalls := choice.Message.ToolCalls
 
 		// Trigger OnGenerationEnd
-		cbManager.OnGenerationEnd(finishReason, content, toolCalls, &completion.Usage)
+		cbManager.OnGenerationEndInfo(callback.GenerationEndInfo{
+			FinishReason: finishReason,
+			Content:      content,
+			Reasoning:    reasoningContent(choice.Message),
+			ToolCalls:    toolCalls,
+			Usage:        &completion.Usage,
+		})
 
 		// Add assistant message to history
 		messages = append(messages, choice.Message.ToParam())
@@ -278,115 +651,240 @@ func (a *Agent[Output]) executeLoop(
 		if len(toolCalls) == 0 {
 			// Parse output
 			if isStringType(outputType) {
+				// A reply in another language is sent back, text whose language can't be told passes
+				if loop.language != "" && languageRetries < a.replyLanguage.Retries {
+					if got := DetectLanguage(content); got != "" && got != loop.language {
+						languageRetries++
+						messages = append(messages, openai.UserMessage(languageFeedback(loop.language)))
+						finalTurn = true
+						continue
+					}
+				}
+
 				// Return string directly
-				return any(content).(Output), iteration, nil
+				result.Output = any(content).(Output)
+				return nil
+			}
+
+			// A JSON answer that violates the schema is sent back once with the violations
+			var violations *schema.ValidationError
+			if errors.As(schema.Validate(outputSchema, []byte(content)), &violations) {
+				if !final {
+					messages = append(messages, openai.UserMessage(schemaFeedback(violations)))
+					finalTurn = true
+					continue
+				}
+				err := fmt.Errorf("output does not match schema: %w", violations)
+				cbManager.OnError(err, "generation")
+				return err
 			}
 
 			// Parse JSON for structured output
-			var result Output
-			if err := json.Unmarshal([]byte(content), &result); err != nil {
+			err := json.Unmarshal([]byte(content), &result.Output)
+			if err != nil && !sendFormat && !final && !submitTool {
+				// Ask again for the same answer, this time with the schema
+				messages = messages[:len(messages)-1]
+				finalTurn = true
+				continue
+			}
+			if err != nil {
 				cbManager.OnError(err, "generation")
-				return zero, iteration, fmt.Errorf("failed to parse output JSON: %w", err)
+				return fmt.Errorf("failed to parse output JSON: %w", err)
+			}
+			return nil
+		}
+
+		// In tool mode a submit_result call ends the run, invalid arguments are sent back for correction
+		var submitFeedback *openai.ChatCompletionMessageParamUnion
+		if submitTool {
+			for i, toolCall := range toolCalls {
+				if toolCall.Function.Name != submitResultTool {
+					continue
+				}
+				err := schema.Validate(outputSchema, []byte(toolCall.Function.Arguments))
+				if err == nil {
+					err = json.Unmarshal([]byte(toolCall.Function.Arguments), &result.Output)
+				}
+				if err == nil {
+					// Keep the answer in the dialogue like a response_format answer
+					messages[len(messages)-1] = openai.AssistantMessage(toolCall.Function.Arguments)
+					return nil
+				}
+
+				var zero Output
+				result.Output = zero
+				feedback := openai.ToolMessage(fmt.Sprintf(
+					"Invalid result: %v. Call %s again with arguments matching its schema.", err, submitResultTool,
+				), toolCall.ID)
+				submitFeedback = &feedback
+				toolCalls = append(toolCalls[:i:i], toolCalls[i+1:]...)
+				break
 			}
-			return result, iteration, nil
 		}
 
 		// Execute tool calls
 		if len(toolCalls) > 0 {
-			toolMessages, err := a.executeToolCalls(ctx, toolCalls, cbManager)
+			toolMessages, err := a.executeToolCalls(ctx, toolCalls, cbManager, loop.stepTimeout)
 			if err != nil {
 				cbManager.OnError(err, "tool")
-				return zero, iteration, err
+				return err
 			}
 			messages = append(messages, toolMessages...)
+			toolsCalled = true
+		}
+		if submitFeedback != nil {
+			messages = append(messages, *submitFeedback)
 		}
 	}
 
-	err := fmt.Errorf("max iterations (%d) reached without completion", maxIterations)
-	cbManager.OnError(err, "run")
-	return zero, iteration, err
+	// The run level error is reported by Invoke
+	return fmt.Errorf("%w (%d)", ErrMaxIterations, maxIterations)
 }
 
-// executeToolCalls executes all tool calls and returns tool messages
+// executeToolCalls executes all tool calls and returns tool messages in the order of the calls
+// With WithParallelTools the calls run concurrently, the first error in call order is returned
 func (a *Agent[Output]) executeToolCalls(
 	ctx context.Context,
 	toolCalls []openai.ChatCompletionMessageToolCall,
 	cbManager *callback.Manager,
+	stepTimeout time.Duration,
 ) ([]openai.ChatCompletionMessageParamUnion, error) {
-	var toolMessages []openai.ChatCompletionMessageParamUnion
-
-	// Execute each tool call
-	for _, toolCall := range toolCalls {
-		toolName := toolCall.Function.Name
-		toolCallID := toolCall.ID
+	toolMessages := make([]openai.ChatCompletionMessageParamUnion, len(toolCalls))
 
-		// Parse arguments
-		var args map[string]interface{}
-		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-			cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
-			return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+	if a.parallelTools <= 1 || len(toolCalls) == 1 {
+		for i, toolCall := range toolCalls {
+			msg, err := a.executeToolCall(ctx, toolCall, cbManager, stepTimeout)
+			if err != nil {
+				return nil, err
+			}
+			toolMessages[i] = msg
 		}
+		return toolMessages, nil
+	}
 
-		// Trigger OnToolCallStart
-		cbManager.OnToolCallStart(toolName, args, toolCallID)
+	errs := make([]error, len(toolCalls))
+	sem := make(chan struct{}, a.parallelTools)
 
-		// Find tool by name in schemas and tools maps
-		var foundToolID string
-		for id, toolSchema := range a.schemas {
-			if toolSchema.Name == toolName {
-				foundToolID = id
-				break
-			}
-		}
+	var wg sync.WaitGroup
+	for i, toolCall := range toolCalls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			toolMessages[i], errs[i] = a.executeToolCall(ctx, toolCall, cbManager, stepTimeout)
+		}()
+	}
+	wg.Wait()
 
-		if foundToolID == "" {
-			err := fmt.Errorf("tool not found: %s", toolName)
-			cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
+	for _, err := range errs {
+		if err != nil {
 			return nil, err
 		}
+	}
+	return toolMessages, nil
+}
 
-		executor := a.tools[foundToolID]
+// executeToolCall executes a single tool call and returns its tool message
+func (a *Agent[Output]) executeToolCall(
+	ctx context.Context,
+	toolCall openai.ChatCompletionMessageToolCall,
+	cbManager *callback.Manager,
+	stepTimeout time.Duration,
+) (openai.ChatCompletionMessageParamUnion, error) {
+	toolName := toolCall.Function.Name
+	toolCallID := toolCall.ID
+
+	// Parse arguments
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
+		return a.toolFailure(ctx, toolName, toolCallID, fmt.Errorf("failed to parse tool arguments: %w", err))
+	}
 
-		// Create a copy of the tool struct to unmarshal args into
-		toolValue := reflect.ValueOf(executor)
-		if toolValue.Kind() == reflect.Ptr {
-			toolValue = toolValue.Elem()
-		}
+	// Trigger OnToolCallStart
+	cbManager.OnToolCallStart(toolName, args, toolCallID)
 
-		// Create a new instance of the tool
-		toolCopy := reflect.New(toolValue.Type()).Interface().(ToolExecutor)
+	// Send constraint violations back to the model instead of executing the call
+	if violations := a.checkToolConstraints(toolName, args); len(violations) > 0 {
+		feedback := constraintFeedback(toolName, violations)
+		cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, errors.New(feedback))
+		return openai.ToolMessage(feedback, toolCallID), nil
+	}
 
-		// Unmarshal args into the tool copy
-		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), toolCopy); err != nil {
-			cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
-			return nil, fmt.Errorf("failed to unmarshal tool arguments: %w", err)
+	// Find tool by name in schemas and tools maps
+	var foundToolID string
+	for id, toolSchema := range a.schemas {
+		if toolSchema.Name == toolName {
+			foundToolID = id
+			break
 		}
+	}
 
-		// Create Context wrapper
+	if foundToolID == "" {
+		err := fmt.Errorf("tool not found: %s", toolName)
+		cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
+		return a.toolFailure(ctx, toolName, toolCallID, err)
+	}
+
+	executor := a.tools[foundToolID]
+
+	// Create a new instance of the tool to unmarshal args into
+	toolCopy := newToolInstance(executor)
+
+	// Unmarshal args into the tool copy
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), toolCopy); err != nil {
+		cbManager.OnToolCallEnd(toolName, args, nil, toolCallID, err)
+		return a.toolFailure(ctx, toolName, toolCallID, fmt.Errorf("failed to unmarshal tool arguments: %w", err))
+	}
+
+	// Execute tool, failed attempts are retried as the tool error policy allows
+	var result any
+	var err error
+	for attempt := 0; ; attempt++ {
+		stepCtx, cancel := StepContext(ctx, stepTimeout)
 		ctxWrapper := &Context{
-			Context: ctx,
+			Context: stepCtx,
 			logger:  a.client.Logger,
 		}
+		if a.toolResultPolicy != nil {
+			ctxWrapper.WithValue(toolResultStoreKey{}, a.toolResultPolicy)
+		}
 
-		// Execute tool
-		result, err := toolCopy.Execute(ctxWrapper)
-		cbManager.OnToolCallEnd(toolName, args, result, toolCallID, err)
-
-		if err != nil {
-			return nil, fmt.Errorf("tool %s failed: %w", toolName, err)
+		result, err = toolCopy.Execute(ctxWrapper)
+		cancel()
+		if err == nil || !a.toolErrorPolicy.retry(ctx, err, attempt) {
+			break
 		}
+		a.client.Logger.Warn("Retrying failed tool call", "tool", toolName, "attempt", attempt+1, "error", err)
+	}
+	cbManager.OnToolCallEnd(toolName, args, result, toolCallID, err)
 
-		// Convert result to string
-		resultStr, err := resultToString(result)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert tool result to string: %w", err)
+	if err != nil {
+		if exceeded := budgetErr(ctx, err); exceeded != nil {
+			return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("tool %s failed: %w", toolName, exceeded)
 		}
+		return a.toolFailure(ctx, toolName, toolCallID, fmt.Errorf("tool %s failed: %w", toolName, err))
+	}
 
-		// Add tool message
-		toolMessages = append(toolMessages, openai.ToolMessage(resultStr, toolCallID))
+	// Convert result to string
+	resultStr, err := resultToString(result)
+	if err != nil {
+		return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("failed to convert tool result to string: %w", err)
 	}
 
-	return toolMessages, nil
+	resultStr, err = a.applyGuards(ctx, toolName, resultStr)
+	if err != nil {
+		return openai.ChatCompletionMessageParamUnion{}, err
+	}
+
+	resultStr, err = a.applyToolResultPolicy(ctx, toolName, resultStr)
+	if err != nil {
+		return openai.ChatCompletionMessageParamUnion{}, err
+	}
+
+	return openai.ToolMessage(resultStr, toolCallID), nil
 }
 
 // resultToString converts tool result to string representation
@@ -0,0 +1,326 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/prompt"
+	"github.com/mhrlife/goai-kit/textnorm"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAgentAdaptsSchemasToModelDialect(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: `{"average":1}`}, fakeReply{Content: `{"average":1}`})
+
+	gemini := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{}).
+		WithModel("google/gemini-2.0-flash")
+	_, err := gemini.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+
+	openaiAgent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{})
+	_, err = openaiAgent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+
+	requests := provider.Requests()
+	geminiTool := requests[0]["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	require.NotContains(t, geminiTool, "strict")
+	require.NotContains(t, geminiTool["parameters"], "additionalProperties")
+	geminiFormat := requests[0]["response_format"].(map[string]any)["json_schema"].(map[string]any)
+	require.NotContains(t, geminiFormat, "strict")
+
+	openaiTool := requests[1]["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	require.Equal(t, true, openaiTool["strict"])
+	require.Equal(t, false, openaiTool["parameters"].(map[string]any)["additionalProperties"])
+}
+
+// sleepTool waits before answering and records the order in which calls finished
+// Every call gets a fresh tool instance, so the record is kept at package level
+type sleepTool struct {
+	BaseTool
+	Label  string `json:"label"`
+	Millis int    `json:"millis"`
+}
+
+var (
+	sleepMu       sync.Mutex
+	sleepFinished []string
+)
+
+func (t *sleepTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "sleep", Description: "Wait and echo the label"}
+}
+
+func (t *sleepTool) Execute(ctx *Context) (any, error) {
+	time.Sleep(time.Duration(t.Millis) * time.Millisecond)
+	sleepMu.Lock()
+	sleepFinished = append(sleepFinished, t.Label)
+	sleepMu.Unlock()
+	return t.Label, nil
+}
+
+func TestParallelToolsKeepCallOrder(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{
+			{ID: "call-1", Name: "sleep", Arguments: `{"label":"slow","millis":100}`},
+			{ID: "call-2", Name: "sleep", Arguments: `{"label":"fast","millis":0}`},
+		}},
+		fakeReply{Content: "done"},
+	)
+
+	sleepFinished = nil
+	agent := CreateAgent(provider.client(), &sleepTool{}).WithParallelTools(2)
+
+	_, err := agent.InvokeSimple(context.Background(), "sleep twice")
+	require.NoError(t, err)
+	require.Equal(t, []string{"fast", "slow"}, sleepFinished)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 2)
+	var toolCallIDs []string
+	for _, m := range requests[1]["messages"].([]any) {
+		msg := m.(map[string]any)
+		if msg["role"] == "tool" {
+			toolCallIDs = append(toolCallIDs, msg["tool_call_id"].(string))
+		}
+	}
+	require.Equal(t, []string{"call-1", "call-2"}, toolCallIDs)
+}
+
+type greetTool struct {
+	BaseTool
+	Name string `json:"name"`
+
+	greeting string
+}
+
+func (t *greetTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "greet", Description: "Greet someone"}
+}
+
+func (t *greetTool) Execute(*Context) (any, error) {
+	return t.greeting + ", " + t.Name, nil
+}
+
+func TestToolCallsKeepRegisteredConfig(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada"}`}}},
+		fakeReply{Content: "done"},
+	)
+
+	tool := &greetTool{greeting: "Hello"}
+	_, err := CreateAgent(provider.client(), tool).InvokeSimple(context.Background(), "greet Ada")
+	require.NoError(t, err)
+	require.Empty(t, tool.Name, "arguments are unmarshalled into a copy")
+
+	var toolResult string
+	for _, m := range provider.Requests()[1]["messages"].([]any) {
+		if msg := m.(map[string]any); msg["role"] == "tool" {
+			toolResult = msg["content"].(string)
+		}
+	}
+	require.Equal(t, "Hello, Ada", toolResult)
+}
+
+type configuredTool struct {
+	BaseTool
+	Query string `json:"query"`
+	Page  int    `json:"page"`
+
+	Limit   int `json:"-"`
+	Options struct {
+		Region string
+	} `json:"-"`
+	prefix string
+}
+
+func (t *configuredTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "search", Description: "Search"}
+}
+
+func (t *configuredTool) Execute(*Context) (any, error) {
+	return fmt.Sprintf("%s %s page %d limit %d in %s", t.prefix, t.Query, t.Page, t.Limit, t.Options.Region), nil
+}
+
+func TestToolInstancesKeepConfigAndResetArguments(t *testing.T) {
+	tool := &configuredTool{Query: "stale", Page: 3, Limit: 10, prefix: "found"}
+	tool.Options.Region = "eu"
+
+	instance := newToolInstance(tool).(*configuredTool)
+	require.Equal(t, "found", instance.prefix, "unexported fields are kept")
+	require.Equal(t, 10, instance.Limit, `json:"-" fields are kept`)
+	require.Equal(t, "eu", instance.Options.Region)
+	require.Empty(t, instance.Query, "arguments start at their zero value")
+	require.Zero(t, instance.Page)
+
+	// the model leaves out page, it isn't taken from the registered tool
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "search", Arguments: `{"query":"go"}`}}},
+		fakeReply{Content: "done"},
+	)
+	_, err := CreateAgent(provider.client(), tool).InvokeSimple(context.Background(), "search go")
+	require.NoError(t, err)
+	require.Equal(t, "stale", tool.Query, "the registered tool is left alone")
+
+	var toolResult string
+	for _, m := range provider.Requests()[1]["messages"].([]any) {
+		if msg := m.(map[string]any); msg["role"] == "tool" {
+			toolResult = msg["content"].(string)
+		}
+	}
+	require.Equal(t, "found go page 0 limit 10 in eu", toolResult)
+}
+
+func TestMaxIterations(t *testing.T) {
+	greet := fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada"}`}}}
+	provider := newFakeProvider(t, greet, greet, greet)
+
+	_, err := CreateAgent(provider.client(), &greetTool{greeting: "Hello"}).
+		WithMaxIterations(2).
+		InvokeSimple(context.Background(), "greet Ada forever")
+	require.ErrorIs(t, err, ErrMaxIterations)
+	require.Len(t, provider.Requests(), 2)
+}
+
+type joinTool struct {
+	BaseTool
+	Words []string `json:"words"`
+
+	separator string
+}
+
+func (t *joinTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "join", Description: "Join words"}
+}
+
+func (t *joinTool) Execute(*Context) (any, error) {
+	time.Sleep(10 * time.Millisecond)
+	return strings.Join(t.Words, t.separator), nil
+}
+
+func TestParallelToolCallsDontShareArguments(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{
+			{ID: "call-1", Name: "join", Arguments: `{"words":["a","b","c"]}`},
+			{ID: "call-2", Name: "join", Arguments: `{"words":["x","y","z"]}`},
+		}},
+		fakeReply{Content: "done"},
+	)
+
+	// decoding into this slice would reuse its backing array in both calls
+	tool := &joinTool{Words: make([]string, 3), separator: "-"}
+	_, err := CreateAgent(provider.client(), tool).WithParallelTools(2).InvokeSimple(context.Background(), "join")
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "", ""}, tool.Words)
+
+	var results []string
+	for _, m := range provider.Requests()[1]["messages"].([]any) {
+		if msg := m.(map[string]any); msg["role"] == "tool" {
+			results = append(results, msg["content"].(string))
+		}
+	}
+	require.Equal(t, []string{"a-b-c", "x-y-z"}, results)
+}
+
+func TestTemplatePromptIsTraced(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hello"})
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	rendered := &prompt.Rendered{
+		Text:      "Say hello to Ali",
+		Template:  "greet.tpl",
+		Version:   "3f2a9c",
+		Variables: map[string]any{"data": map[string]any{"Name": "Ali"}},
+	}
+	agent := CreateAgent(provider.client())
+	_, err := agent.Invoke(context.Background(), InvokeConfig{
+		Template:  rendered,
+		Callbacks: []callback.AgentCallback{callback.NewLangfuseCallback(callback.LangfuseCallbackConfig{Tracer: tracer})},
+	})
+	require.NoError(t, err)
+	require.Contains(t, fmt.Sprint(provider.Requests()[0]["messages"]), "Say hello to Ali")
+
+	var attrs map[attribute.Key]attribute.Value
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "llm.generation" {
+			attrs = map[attribute.Key]attribute.Value{}
+			for _, kv := range span.Attributes {
+				attrs[kv.Key] = kv.Value
+			}
+		}
+	}
+	require.NotNil(t, attrs)
+	require.Equal(t, "greet.tpl", attrs["langfuse.observation.metadata.prompt_name"].AsString())
+	require.Equal(t, "3f2a9c", attrs["langfuse.observation.metadata.prompt_version"].AsString())
+	require.JSONEq(t, `{"data":{"Name":"Ali"}}`, attrs["langfuse.observation.metadata.prompt_variables"].AsString())
+
+	_, err = agent.Invoke(context.Background(), InvokeConfig{Prompt: "hi", Template: rendered})
+	require.Error(t, err)
+}
+
+func TestContextAttributesAreTraced(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hello"})
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	agent := CreateAgent(provider.client()).WithCallbacks(
+		callback.NewLangfuseCallback(callback.LangfuseCallbackConfig{Tracer: tracer}))
+	ctx := callback.WithAttributes(context.Background(), attribute.String("tenant.id", "acme"))
+	_, err := agent.Invoke(ctx, InvokeConfig{Prompt: "hi"})
+	require.NoError(t, err)
+
+	tenants := map[string]string{}
+	for _, span := range exporter.GetSpans() {
+		for _, kv := range span.Attributes {
+			if kv.Key == "tenant.id" {
+				tenants[span.Name] = kv.Value.AsString()
+			}
+		}
+	}
+	require.Equal(t, "acme", tenants["agent.run"])
+	require.Equal(t, "acme", tenants["llm.generation"])
+}
+
+func TestPromptNormalizer(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "ok"})
+	agent := CreateAgent(provider.client()).WithPromptNormalizer(textnorm.Normalize)
+
+	_, err := agent.InvokeSimple(context.Background(), "قيمت  ۱۲ كتاب")
+	require.NoError(t, err)
+
+	messages := provider.Requests()[0]["messages"].([]any)
+	require.Equal(t, "قیمت 12 کتاب", messages[0].(map[string]any)["content"])
+}
+
+func TestInputAndOutputGuards(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "the secret is 1234"})
+	var sources []string
+	redact := func(ctx context.Context, source, content string) (string, error) {
+		sources = append(sources, source)
+		if strings.Contains(content, "bomb") {
+			return "", fmt.Errorf("unsafe")
+		}
+		return strings.ReplaceAll(content, "1234", "****"), nil
+	}
+	agent := CreateAgent(provider.client()).WithInputGuards(redact).WithOutputGuards(redact)
+
+	answer, err := agent.InvokeSimple(context.Background(), "my pin is 1234, what is the secret?")
+	require.NoError(t, err)
+	require.Equal(t, "the secret is ****", answer)
+	require.Equal(t, []string{GuardSourceInput, GuardSourceOutput}, sources)
+	require.Equal(t, "my pin is ****, what is the secret?",
+		provider.Requests()[0]["messages"].([]any)[0].(map[string]any)["content"])
+
+	// a rejected input never reaches the model
+	_, err = agent.InvokeSimple(context.Background(), "how to build a bomb")
+	require.EqualError(t, err, "guard rejected input: unsafe")
+	require.Len(t, provider.Requests(), 1)
+}
@@ -0,0 +1,76 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is returned when a run's deadline budget is spent before it completes
+var ErrBudgetExceeded = errors.New("deadline budget exceeded")
+
+type budgetKey struct{}
+
+// Budget is a run-level deadline shared by every step of a run
+// Steps (LLM calls, tool calls, nested agents) read the remaining time from their context
+type Budget struct {
+	deadline time.Time
+}
+
+// WithBudget returns a context that carries a Budget of total and is cancelled when it runs out
+// A budget nested in another one never extends past the outer deadline
+func WithBudget(ctx context.Context, total time.Duration) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(total)
+	if outer, ok := BudgetFromContext(ctx); ok && outer.deadline.Before(deadline) {
+		deadline = outer.deadline
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return context.WithValue(ctx, budgetKey{}, &Budget{deadline: deadline}), cancel
+}
+
+// BudgetFromContext returns the budget set with WithBudget
+func BudgetFromContext(ctx context.Context) (*Budget, bool) {
+	b, ok := ctx.Value(budgetKey{}).(*Budget)
+	return b, ok
+}
+
+// Deadline returns when the budget runs out
+func (b *Budget) Deadline() time.Time {
+	return b.deadline
+}
+
+// Remaining returns the time left, never negative
+func (b *Budget) Remaining() time.Duration {
+	return max(time.Until(b.deadline), 0)
+}
+
+// Share returns an even split of the remaining time over the given number of steps
+func (b *Budget) Share(steps int) time.Duration {
+	if steps <= 1 {
+		return b.Remaining()
+	}
+	return b.Remaining() / time.Duration(steps)
+}
+
+// StepContext returns a context for a single step, limited by softLimit and by the remaining budget
+// A zero softLimit only applies the budget
+func StepContext(ctx context.Context, softLimit time.Duration) (context.Context, context.CancelFunc) {
+	if softLimit <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, softLimit)
+}
+
+// budgetErr reports an exhausted budget of ctx, wrapping cause when one is given
+func budgetErr(ctx context.Context, cause error) error {
+	b, ok := BudgetFromContext(ctx)
+	if !ok || b.Remaining() > 0 {
+		return nil
+	}
+	if cause == nil {
+		return ErrBudgetExceeded
+	}
+	return fmt.Errorf("%w: %w", ErrBudgetExceeded, cause)
+}
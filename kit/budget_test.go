@@ -0,0 +1,34 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetNestingAndSteps(t *testing.T) {
+	ctx, cancel := WithBudget(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// An inner budget can't outlive the outer one
+	inner, cancelInner := WithBudget(ctx, time.Hour)
+	defer cancelInner()
+	budget, ok := BudgetFromContext(inner)
+	require.True(t, ok)
+	require.LessOrEqual(t, budget.Remaining(), 50*time.Millisecond)
+	require.LessOrEqual(t, budget.Share(5), 10*time.Millisecond)
+
+	step, cancelStep := StepContext(inner, time.Millisecond)
+	defer cancelStep()
+	<-step.Done()
+	require.ErrorIs(t, step.Err(), context.DeadlineExceeded)
+	require.NoError(t, budgetErr(inner, step.Err()), "a step timeout doesn't exhaust the budget")
+
+	<-inner.Done()
+	err := budgetErr(inner, inner.Err())
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
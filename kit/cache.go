@@ -0,0 +1,275 @@
+package kit
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/prompt"
+	"github.com/openai/openai-go"
+)
+
+// ResponseCache stores the outputs of agent runs by key, see Agent.WithCache
+type ResponseCache interface {
+	// Get returns ok false for missing and expired entries
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value for ttl, 0 keeps it until it is evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// cacheEntry is what a cached run stores
+type cacheEntry struct {
+	Output   json.RawMessage `json:"output"`
+	Content  string          `json:"content"`            // the final assistant message
+	Model    string          `json:"model"`              // the model version that answered, as reported by the provider
+	Template string          `json:"template,omitempty"` // name and version of the prompt template
+	Version  string          `json:"version,omitempty"`
+	Created  time.Time       `json:"created"`
+}
+
+// agentCache is the cache of one agent
+// It remembers the model versions providers answered with, so entries of an older version of a model alias stop
+// matching as soon as the provider answers with a newer one
+type agentCache struct {
+	cache ResponseCache
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	versions map[string]string // requested model -> model version of the latest answer
+}
+
+// WithCache answers repeated runs from cache instead of calling the model
+// Entries are keyed by the model, the messages, the tools and the output type, and for InvokeConfig.Template
+// by the template's name and version, so editing a template or switching models never returns a stale answer
+// Tools aren't called again for a cached run
+func (a *Agent[Output]) WithCache(cache ResponseCache, ttl time.Duration) *Agent[Output] {
+	a.cache = &agentCache{cache: cache, ttl: ttl, versions: map[string]string{}}
+	return a
+}
+
+// CachedPrompt identifies a run for a PromptCache
+type CachedPrompt struct {
+	Key    string // Exact key of the run
+	Scope  string // Hash of everything but the prompt: model, parameters, tools, output type and the other messages
+	Prompt string // Text of the last user message
+}
+
+// PromptCache is a ResponseCache that also matches runs by their prompt, e.g. rag.SemanticCache answering
+// near-duplicate prompts. WithCache calls GetPrompt and SetPrompt instead of Get and Set when the cache has them
+// A cache must only match runs of the same Scope
+type PromptCache interface {
+	ResponseCache
+	GetPrompt(ctx context.Context, prompt CachedPrompt) (value []byte, ok bool, err error)
+	SetPrompt(ctx context.Context, prompt CachedPrompt, value []byte, ttl time.Duration) error
+}
+
+// cacheKey hashes everything that decides the answer of a run
+func (a *Agent[Output]) cacheKey(
+	template *prompt.Rendered,
+	messages []openai.ChatCompletionMessageParamUnion,
+	stop []string,
+	toolChoice ToolChoice,
+) (CachedPrompt, error) {
+	toolNames := make([]string, 0, len(a.schemas))
+	for name := range a.schemas {
+		toolNames = append(toolNames, name)
+	}
+	sort.Strings(toolNames)
+	tools := make([]ToolSchema, len(toolNames))
+	for i, name := range toolNames {
+		tools[i] = a.schemas[name]
+	}
+
+	// every parameter executeLoop sends is part of the key
+	var output Output
+	fields := map[string]any{
+		"model":       a.model,
+		"temperature": a.temperature,
+		"stop":        stop,
+		"output":      reflect.TypeOf(&output).Elem().String(),
+		"strict":      a.SchemaDialect().Strict,
+		"tools":       tools,
+		"tool_choice": toolChoice,
+		"n":           a.nParam().Value,
+		"logprobs":    a.logprobs,
+		"messages":    messages,
+	}
+	if a.logprobs {
+		fields["top_logprobs"] = a.topLogprobs
+	}
+	if a.openRouter != nil {
+		fields["extra"] = a.openRouter.extraFields()
+	}
+	hash := func() (string, error) {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return "", fmt.Errorf("failed to build cache key: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:16]), nil
+	}
+
+	key, err := hash()
+	if err != nil {
+		return CachedPrompt{}, err
+	}
+	// the scope leaves out the last user message
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].OfUser != nil {
+			fields["messages"] = append(slices.Clone(messages[:i]), messages[i+1:]...)
+			break
+		}
+	}
+	scope, err := hash()
+	if err != nil {
+		return CachedPrompt{}, err
+	}
+
+	prefix := "goai:" + a.model
+	if template != nil {
+		prefix = fmt.Sprintf("goai:%s@%s:%s", template.Template, template.Version, a.model)
+	}
+	return CachedPrompt{
+		Key:    prefix + ":" + key,
+		Scope:  prefix + ":" + scope,
+		Prompt: lastUserText(messages),
+	}, nil
+}
+
+// saveToCache stores the answer of a finished run, failures are logged since the run itself succeeded
+func (a *Agent[Output]) saveToCache(ctx context.Context, key CachedPrompt, template *prompt.Rendered, result *Result[Output]) {
+	raw := result.Raw()
+	if raw == nil || len(raw.Choices) == 0 {
+		return
+	}
+	output, err := json.Marshal(result.Output)
+	if err == nil {
+		entry := cacheEntry{Output: output, Content: raw.Choices[0].Message.Content, Model: raw.Model, Created: time.Now()}
+		if template != nil {
+			entry.Template, entry.Version = template.Template, template.Version
+		}
+		err = a.cache.store(ctx, key, a.model, entry)
+	}
+	if err != nil {
+		a.client.Logger.Error("Failed to cache response", "key", key.Key, "error", err)
+	}
+}
+
+// lookup returns the cached entry of key, if it was answered by the latest known version of model
+func (c *agentCache) lookup(ctx context.Context, key CachedPrompt, model string) (*cacheEntry, error) {
+	var data []byte
+	var ok bool
+	var err error
+	if pc, isPrompt := c.cache.(PromptCache); isPrompt {
+		data, ok, err = pc.GetPrompt(ctx, key)
+	} else {
+		data, ok, err = c.cache.Get(ctx, key.Key)
+	}
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil // an unreadable entry is a miss, the run overwrites it
+	}
+
+	c.mu.Lock()
+	latest, known := c.versions[model]
+	c.mu.Unlock()
+	if known && entry.Model != "" && entry.Model != latest {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// store caches an answer and records the model version it came from
+func (c *agentCache) store(ctx context.Context, key CachedPrompt, model string, entry cacheEntry) error {
+	if entry.Model != "" {
+		c.mu.Lock()
+		c.versions[model] = entry.Model
+		c.mu.Unlock()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if pc, ok := c.cache.(PromptCache); ok {
+		return pc.SetPrompt(ctx, key, data, c.ttl)
+	}
+	return c.cache.Set(ctx, key.Key, data, c.ttl)
+}
+
+// MemoryResponseCache is an in-process ResponseCache holding up to a fixed number of entries
+type MemoryResponseCache struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // most recently used first
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryResponseCache creates an in-memory LRU cache, the least recently used entries are evicted beyond
+// maxEntries (1000 when 0)
+func NewMemoryResponseCache(maxEntries int) *MemoryResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryResponseCache{maxEntries: maxEntries, now: time.Now, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+func (c *MemoryResponseCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+func (c *MemoryResponseCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+	if elem, exists := c.entries[key]; exists {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(entry)
+	}
+
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisResponseCache is a ResponseCache in Redis, shared by every process using the same keys
+type RedisResponseCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisResponseCache creates a cache storing its entries under prefix (optional, e.g. "myapp:")
+func NewRedisResponseCache(client redis.Cmdable, prefix string) *RedisResponseCache {
+	return &RedisResponseCache{client: client, prefix: prefix}
+}
+
+func (c *RedisResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	return value, true, nil
+}
+
+func (c *RedisResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package kit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/prompt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheAnswersRepeatedRuns(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: `{"city":"Paris","population":2100000}`},
+		fakeReply{Content: `{"city":"Tehran","population":9000000}`},
+	)
+	type answer struct {
+		City       string `json:"city"`
+		Population int    `json:"population"`
+	}
+	agent := CreateAgentWithOutput[answer](provider.client()).WithCache(NewMemoryResponseCache(0), time.Hour)
+	ctx := context.Background()
+
+	first, err := agent.InvokeWithResult(ctx, InvokeConfig{Prompt: "Capital of France?"})
+	require.NoError(t, err)
+	require.False(t, first.Cached)
+
+	second, err := agent.InvokeWithResult(ctx, InvokeConfig{Prompt: "Capital of France?"})
+	require.NoError(t, err)
+	require.True(t, second.Cached)
+	require.Equal(t, answer{City: "Paris", Population: 2100000}, second.Output)
+	require.Len(t, second.Dialogue, 2)
+	require.Len(t, provider.Requests(), 1)
+
+	// a different prompt or system prompt is a different entry
+	other, err := agent.Invoke(ctx, InvokeConfig{Prompt: "Capital of France?", SystemPrompt: "Answer about Iran."})
+	require.NoError(t, err)
+	require.Equal(t, "Tehran", other.City)
+	require.Len(t, provider.Requests(), 2)
+}
+
+func TestCacheKeyedByTemplateVersion(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "v1 answer"}, fakeReply{Content: "v2 answer"})
+	agent := CreateAgent(provider.client()).WithCache(NewMemoryResponseCache(0), 0)
+	ctx := context.Background()
+
+	rendered := func(version string) InvokeConfig {
+		return InvokeConfig{Template: &prompt.Rendered{Text: "Summarize: hello", Template: "summary.tmpl", Version: version}}
+	}
+
+	for range 2 {
+		out, err := agent.Invoke(ctx, rendered("aaa"))
+		require.NoError(t, err)
+		require.Equal(t, "v1 answer", out)
+	}
+
+	// the template was edited, even if it renders the same text the old answer isn't used
+	out, err := agent.Invoke(ctx, rendered("bbb"))
+	require.NoError(t, err)
+	require.Equal(t, "v2 answer", out)
+	require.Len(t, provider.Requests(), 2)
+}
+
+func TestCacheInvalidatedByModelVersion(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: "old", Extra: map[string]any{"model": "test-model-2024-05"}},
+		fakeReply{Content: "new", Extra: map[string]any{"model": "test-model-2024-09"}},
+		fakeReply{Content: "newer", Extra: map[string]any{"model": "test-model-2024-09"}},
+	)
+	cache := NewMemoryResponseCache(0)
+	agent := CreateAgent(provider.client()).WithCache(cache, 0)
+	ctx := context.Background()
+
+	out, err := agent.Invoke(ctx, InvokeConfig{Prompt: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "old", out)
+
+	// the alias now points at a newer version
+	out, err = agent.Invoke(ctx, InvokeConfig{Prompt: "b"})
+	require.NoError(t, err)
+	require.Equal(t, "new", out)
+
+	// so the answer of the older version is no longer used
+	out, err = agent.Invoke(ctx, InvokeConfig{Prompt: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "newer", out)
+
+	out, err = agent.Invoke(ctx, InvokeConfig{Prompt: "b"})
+	require.NoError(t, err)
+	require.Equal(t, "new", out)
+	require.Len(t, provider.Requests(), 3)
+}
+
+func TestMemoryResponseCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryResponseCache(2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+	require.NoError(t, cache.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ := cache.Get(ctx, "a")
+	require.False(t, ok, "evicted")
+	value, ok, _ := cache.Get(ctx, "b")
+	require.True(t, ok)
+	require.Equal(t, "2", string(value))
+
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), time.Minute))
+	now = now.Add(time.Minute)
+	_, ok, _ = cache.Get(ctx, "b")
+	require.False(t, ok, "expired")
+	_, ok, _ = cache.Get(ctx, "c")
+	require.True(t, ok)
+}
+
+func TestMemoryResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryResponseCache(2)
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := cache.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, cache.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = cache.Get(ctx, "b")
+	require.False(t, ok, "least recently used")
+	_, ok, _ = cache.Get(ctx, "a")
+	require.True(t, ok)
+}
+
+// promptRecorder is a PromptCache recording the prompts it was asked for
+type promptRecorder struct {
+	*MemoryResponseCache
+	prompts []CachedPrompt
+}
+
+func (c *promptRecorder) GetPrompt(ctx context.Context, prompt CachedPrompt) ([]byte, bool, error) {
+	c.prompts = append(c.prompts, prompt)
+	return c.Get(ctx, prompt.Key)
+}
+
+func (c *promptRecorder) SetPrompt(ctx context.Context, prompt CachedPrompt, value []byte, ttl time.Duration) error {
+	return c.Set(ctx, prompt.Key, value, ttl)
+}
+
+func TestPromptCacheScope(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "a"}, fakeReply{Content: "b"}, fakeReply{Content: "c"})
+	cache := &promptRecorder{MemoryResponseCache: NewMemoryResponseCache(0)}
+	agent := CreateAgent(provider.client()).WithCache(cache, 0)
+	ctx := context.Background()
+
+	for _, config := range []InvokeConfig{
+		{Prompt: "How do I reset my password?"},
+		{Prompt: "how can I reset my password"},
+		{Prompt: "How do I reset my password?", SystemPrompt: "Be brief."},
+	} {
+		_, err := agent.Invoke(ctx, config)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, cache.prompts, 3)
+	require.Equal(t, "how can I reset my password", cache.prompts[1].Prompt)
+	require.NotEqual(t, cache.prompts[0].Key, cache.prompts[1].Key)
+	require.Equal(t, cache.prompts[0].Scope, cache.prompts[1].Scope)
+	require.NotEqual(t, cache.prompts[0].Scope, cache.prompts[2].Scope)
+}
+
+func TestCacheKeyedByRequestParams(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: "auto answer"},
+		fakeReply{Content: "no tools answer"},
+		fakeReply{Content: "logprobs answer"},
+	)
+	agent := CreateAgent(provider.client(), &greetTool{greeting: "Hello"}).WithCache(NewMemoryResponseCache(0), 0)
+	ctx := context.Background()
+
+	out, err := agent.Invoke(ctx, InvokeConfig{Prompt: "Ada"})
+	require.NoError(t, err)
+	require.Equal(t, "auto answer", out)
+
+	// another tool choice is another request, the answer made with tools isn't reused
+	out, err = agent.Invoke(ctx, InvokeConfig{Prompt: "Ada", ToolChoice: ToolChoiceNone})
+	require.NoError(t, err)
+	require.Equal(t, "no tools answer", out)
+
+	out, err = agent.Invoke(ctx, InvokeConfig{Prompt: "Ada", ToolChoice: ToolChoiceNone})
+	require.NoError(t, err)
+	require.Equal(t, "no tools answer", out)
+	require.Len(t, provider.Requests(), 2)
+
+	out, err = agent.WithLogprobs().Invoke(ctx, InvokeConfig{Prompt: "Ada"})
+	require.NoError(t, err)
+	require.Equal(t, "logprobs answer", out)
+	require.Len(t, provider.Requests(), 3)
+}
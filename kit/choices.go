@@ -0,0 +1,94 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mhrlife/goai-kit/schema"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/param"
+)
+
+// CandidateScorer rates a candidate answer of WithN, the candidate with the highest score becomes the output
+type CandidateScorer[Output any] func(ctx context.Context, candidate Output) (float64, error)
+
+// WithN samples n choices per LLM call, the first one drives tool calls
+// Every valid choice of the final answer is returned in Result.Candidates
+func (a *Agent[Output]) WithN(n int) *Agent[Output] {
+	a.n = n
+	return a
+}
+
+// WithCandidateScorer picks the output among the candidates of WithN by score instead of taking the first choice
+func (a *Agent[Output]) WithCandidateScorer(scorer CandidateScorer[Output]) *Agent[Output] {
+	a.scorer = scorer
+	return a
+}
+
+// nParam returns the n request parameter, unset for a single choice
+func (a *Agent[Output]) nParam() param.Opt[int64] {
+	if a.n > 1 {
+		return param.NewOpt(int64(a.n))
+	}
+	return param.Opt[int64]{}
+}
+
+// selectCandidate collects the choices of the final answer as candidates and, with a scorer, makes the best one
+// the output. The first choice is already the output, choices that don't parse or match the schema are dropped
+func (a *Agent[Output]) selectCandidate(ctx context.Context, result *Result[Output]) error {
+	raw := result.Raw()
+	if raw == nil || len(raw.Choices) < 2 {
+		return nil
+	}
+
+	var outputType Output
+	var outputSchema map[string]any
+	if !isStringType(outputType) {
+		outputSchema = a.SchemaDialect().Adapt(schema.MarshalToSchema(outputType))
+	}
+
+	result.Candidates = []Output{result.Output}
+	contents := []string{""}
+	for _, choice := range raw.Choices[1:] {
+		content := choice.Message.Content
+		for _, call := range choice.Message.ToolCalls {
+			if call.Function.Name == submitResultTool {
+				content = call.Function.Arguments
+			}
+		}
+
+		var candidate Output
+		if isStringType(outputType) {
+			candidate = any(content).(Output)
+		} else if schema.Validate(outputSchema, []byte(content)) != nil || json.Unmarshal([]byte(content), &candidate) != nil {
+			continue
+		}
+		result.Candidates = append(result.Candidates, candidate)
+		contents = append(contents, content)
+	}
+
+	if a.scorer == nil {
+		return nil
+	}
+
+	best := 0
+	result.Scores = make([]float64, len(result.Candidates))
+	for i, candidate := range result.Candidates {
+		score, err := a.scorer(ctx, candidate)
+		if err != nil {
+			return fmt.Errorf("failed to score candidate %d: %w", i, err)
+		}
+		result.Scores[i] = score
+		if score > result.Scores[best] {
+			best = i
+		}
+	}
+
+	if best > 0 {
+		// The dialogue keeps the chosen answer
+		result.Output = result.Candidates[best]
+		result.Messages[len(result.Messages)-1] = openai.AssistantMessage(contents[best])
+	}
+	return nil
+}
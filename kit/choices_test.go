@@ -0,0 +1,46 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type verdict struct {
+	Label string `json:"label"`
+}
+
+func TestCandidates(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{
+		Content: `{"label":"spam"}`,
+		Choices: []string{`{"label":"ham"}`, `not json`, `{"label":"spam"}`},
+	})
+
+	result, err := CreateAgentWithOutput[verdict](provider.client()).WithN(4).
+		InvokeWithResult(context.Background(), InvokeConfig{Prompt: "Classify"})
+	require.NoError(t, err)
+	require.Equal(t, float64(4), provider.Requests()[0]["n"])
+	require.Equal(t, []verdict{{"spam"}, {"ham"}, {"spam"}}, result.Candidates)
+	require.Equal(t, verdict{"spam"}, result.Output)
+	require.Nil(t, result.Scores)
+}
+
+func TestCandidateScorer(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "A short answer.", Choices: []string{"A longer, better answer."}})
+
+	agent := CreateAgent(provider.client()).WithN(2).WithCandidateScorer(func(ctx context.Context, candidate string) (float64, error) {
+		return float64(len(candidate)), nil
+	})
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "Answer"})
+	require.NoError(t, err)
+	require.Equal(t, "A longer, better answer.", result.Output)
+	require.Equal(t, []float64{15, 24}, result.Scores)
+	require.Equal(t, "A longer, better answer.", result.Dialogue[1].OfAssistant.Content.OfString.Value)
+
+	// a single choice is not sent
+	provider = newFakeProvider(t, fakeReply{Content: "Hi"})
+	_, err = CreateAgent(provider.client()).WithN(1).InvokeSimple(context.Background(), "Hi")
+	require.NoError(t, err)
+	require.NotContains(t, provider.Requests()[0], "n")
+}
@@ -0,0 +1,204 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// ErrCircuitOpen is returned for requests made while a CircuitBreaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // requests go through
+	CircuitOpen                         // requests fail fast until the cooldown has passed
+	CircuitHalfOpen                     // one probe request decides whether to close or open again
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker
+type CircuitBreakerConfig struct {
+	// Failures is the number of consecutive failed requests that opens the circuit (optional, defaults to 5)
+	Failures int
+
+	// Cooldown is how long the circuit stays open before a probe request is let through (optional, defaults to 30s)
+	Cooldown time.Duration
+
+	// Fallback answers requests while the circuit is open instead of failing them with ErrCircuitOpen
+	// (optional, e.g. a backup provider)
+	Fallback option.MiddlewareNext
+
+	// OnStateChange is called after every transition, e.g. to log or alert (optional)
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker stops sending requests to a provider that keeps failing, so runs fail fast instead of
+// retrying against a dead endpoint for minutes
+// Transport errors and 5xx responses count as failures. 429s and other client errors don't: the provider is up, nor
+// do requests the caller cancelled or that ran out of its deadline
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	generation uint64 // counts transitions, see circuitTicket
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.Failures <= 0 {
+		config.Failures = 5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	return &CircuitBreaker{config: config, now: time.Now}
+}
+
+// WithCircuitBreaker routes every chat completion request of the client through the breaker
+// Share one breaker between clients that use the same provider
+func WithCircuitBreaker(b *CircuitBreaker) ClientOption {
+	return WithRequestOptions(option.WithMiddleware(b.Middleware()))
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.config.Cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Middleware fails chat completion requests fast while the circuit is open, requests to other endpoints are passed
+// through untouched
+// Each retry of the SDK passes through the middleware, so a retry storm opens the circuit and stops early
+func (b *CircuitBreaker) Middleware() option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if !strings.HasSuffix(request.URL.Path, "/chat/completions") {
+			return next(request)
+		}
+
+		ticket, ok := b.allow()
+		if !ok {
+			if b.config.Fallback != nil {
+				return b.config.Fallback(request)
+			}
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := next(request)
+		if err != nil && (errors.Is(err, context.Canceled) || request.Context().Err() != nil) {
+			// the caller gave up, that says nothing about the provider
+			b.release(ticket)
+			return resp, err
+		}
+		b.record(ticket, err != nil || resp.StatusCode >= http.StatusInternalServerError)
+		return resp, err
+	}
+}
+
+// circuitTicket is handed to a request let through by the breaker
+type circuitTicket struct {
+	generation uint64 // of the state the request started in
+	probe      bool
+}
+
+// allow reports whether a request may be sent, after the cooldown only one probe at a time is
+func (b *CircuitBreaker) allow() (circuitTicket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		return circuitTicket{generation: b.generation}, true
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			return circuitTicket{}, false
+		}
+		b.transitionLocked(CircuitHalfOpen)
+	}
+	if b.probing {
+		return circuitTicket{}, false
+	}
+	b.probing = true
+	return circuitTicket{generation: b.generation, probe: true}, true
+}
+
+// record counts the outcome of a request
+// Requests that started before the last transition are ignored, only the probe decides a half-open circuit
+func (b *CircuitBreaker) record(ticket circuitTicket, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ticket.generation != b.generation {
+		return
+	}
+	if ticket.probe {
+		b.probing = false
+		if failed {
+			b.openedAt = b.now()
+			b.transitionLocked(CircuitOpen)
+		} else {
+			b.failures = 0
+			b.transitionLocked(CircuitClosed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.config.Failures {
+		b.openedAt = b.now()
+		b.transitionLocked(CircuitOpen)
+	}
+}
+
+// release gives up a request without counting it, a released probe lets the next request probe
+func (b *CircuitBreaker) release(ticket circuitTicket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ticket.probe && ticket.generation == b.generation {
+		b.probing = false
+	}
+}
+
+// transitionLocked changes the state and notifies OnStateChange
+// The hook is called with the lock held, it must not use the breaker
+func (b *CircuitBreaker) transitionLocked(to CircuitState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	if b.config.OnStateChange != nil && from != to {
+		b.config.OnStateChange(from, to)
+	}
+}
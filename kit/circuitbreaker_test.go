@@ -0,0 +1,99 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Status: http.StatusInternalServerError, Body: `{"error":{"message":"down"}}`},
+		fakeReply{Status: http.StatusInternalServerError, Body: `{"error":{"message":"down"}}`},
+		fakeReply{Content: "back"},
+	)
+
+	var changes []CircuitState
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		Failures:      2,
+		Cooldown:      time.Minute,
+		OnStateChange: func(from, to CircuitState) { changes = append(changes, to) },
+	})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	agent := CreateAgent(provider.client(WithCircuitBreaker(breaker), WithRequestOptions(option.WithMaxRetries(0))))
+	for range 2 {
+		_, err := agent.InvokeSimple(context.Background(), "hi")
+		require.Error(t, err)
+	}
+	require.Equal(t, CircuitOpen, breaker.State())
+
+	_, err := agent.InvokeSimple(context.Background(), "hi")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Len(t, provider.Requests(), 2)
+
+	now = now.Add(time.Minute)
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+	answer, err := agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "back", answer)
+
+	require.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}, changes)
+}
+
+func TestCircuitBreakerHalfOpenLetsOneProbeThrough(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{Failures: 1})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	// started while closed, finishes after the circuit opened
+	stale, ok := breaker.allow()
+	require.True(t, ok)
+
+	ticket, ok := breaker.allow()
+	require.True(t, ok)
+	breaker.record(ticket, true)
+	_, ok = breaker.allow()
+	require.False(t, ok)
+
+	now = now.Add(30 * time.Second)
+	probe, ok := breaker.allow()
+	require.True(t, ok)
+	_, ok = breaker.allow()
+	require.False(t, ok)
+
+	breaker.record(stale, false)
+	require.Equal(t, CircuitHalfOpen, breaker.State(), "only the probe decides")
+
+	breaker.record(probe, true)
+	require.Equal(t, CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresCancelledRequests(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{Failures: 1})
+	mw := breaker.Middleware()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.com/v1/chat/completions", nil)
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = mw(req, func(*http.Request) (*http.Response, error) { return nil, context.Canceled })
+		require.ErrorIs(t, err, context.Canceled)
+	}
+	require.Equal(t, CircuitClosed, breaker.State())
+
+	// a cancelled probe lets the next request probe
+	breaker.record(circuitTicket{}, true)
+	breaker.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, err = mw(req, func(*http.Request) (*http.Response, error) { return nil, context.Canceled })
+	require.ErrorIs(t, err, context.Canceled)
+	_, ok := breaker.allow()
+	require.True(t, ok)
+}
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/mhrlife/goai-kit/secrets"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
@@ -23,6 +24,13 @@ type Config struct {
 	RequestOptions []option.RequestOption
 	DefaultModel   string
 	LogLevel       slog.Level
+	Preset         *ProviderPreset // set by WithProviderPreset
+	Quota          *QuotaManager   // set by WithQuota
+	APIKeySecret   *SecretKey      // set by WithAPIKeySecret, replaces ApiKey
+	Pricing        PricingTable    // set by WithPricing
+	RetryPolicy    *RetryPolicy    // set by WithRetryPolicy
+	RateLimiter    *RateLimiter    // set by WithRateLimiter
+	Scheduler      *Scheduler      // set by WithScheduler
 }
 
 // NewClient creates a new goaikit Client with the given options.
@@ -58,6 +66,25 @@ func NewClient(opts ...ClientOption) *Client {
 	if c.ApiBase != "" {
 		c.RequestOptions = append(c.RequestOptions, option.WithBaseURL(c.ApiBase))
 	}
+	if c.APIKeySecret != nil {
+		cache := secrets.NewCache(c.APIKeySecret.Provider, c.APIKeySecret.TTL)
+		c.RequestOptions = append(c.RequestOptions, option.WithMiddleware(secretKeyMiddleware(cache, c.APIKeySecret.Name)))
+	}
+
+	if c.RetryPolicy == nil {
+		// agents with a retry policy retry around every middleware of the client
+		c.RequestOptions = append([]option.RequestOption{option.WithMiddleware(agentRetryMiddleware)}, c.RequestOptions...)
+	}
+	if c.RateLimiter != nil && c.Scheduler != nil {
+		logger.Error("Invalid client configuration", "error", ErrLimiterConflict)
+		c.RequestOptions = append([]option.RequestOption{
+			option.WithMaxRetries(0), option.WithMiddleware(rejectRequests(ErrLimiterConflict)),
+		}, c.RequestOptions...)
+	}
+
+	if c.Quota != nil {
+		c.RequestOptions = append(c.RequestOptions, option.WithMiddleware(c.Quota.middleware(logger)))
+	}
 
 	// Add default middleware (like logging)
 	c.RequestOptions = append(
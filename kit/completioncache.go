@@ -0,0 +1,122 @@
+package kit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// CompletionCache answers chat completion requests it has seen before from a ResponseCache, so deterministic
+// prompts don't hit the API over and over during development and tests
+// Entries are keyed by a hash of the request body: the model, the messages and every parameter. Unlike
+// Agent.WithCache it caches every LLM call, tools still run
+// Streaming requests and failed responses are never cached, and cache errors never fail a request
+type CompletionCache struct {
+	cache ResponseCache
+	ttl   time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCompletionCache creates a completion cache keeping entries for ttl, 0 keeps them until they are evicted
+func NewCompletionCache(cache ResponseCache, ttl time.Duration) *CompletionCache {
+	return &CompletionCache{cache: cache, ttl: ttl}
+}
+
+// WithCompletionCache makes the client answer repeated chat completion requests from the cache
+func WithCompletionCache(cache *CompletionCache) ClientOption {
+	return WithRequestOptions(option.WithMiddleware(cache.Middleware()))
+}
+
+// Hits returns the number of requests answered from the cache
+func (c *CompletionCache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses returns the number of cacheable requests sent to the provider
+func (c *CompletionCache) Misses() int64 {
+	return c.misses.Load()
+}
+
+// Middleware returns an openai-go middleware that serves and stores chat completions
+// Requests to other endpoints are passed through untouched
+func (c *CompletionCache) Middleware() option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if !strings.HasSuffix(request.URL.Path, "/chat/completions") || request.Body == nil {
+			return next(request)
+		}
+
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("completion cache: failed to read request body: %w", err)
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, ok := completionCacheKey(body)
+		if !ok {
+			return next(request)
+		}
+
+		ctx := request.Context()
+		if cached, ok, err := c.cache.Get(ctx, key); err == nil && ok {
+			c.hits.Add(1)
+			return &http.Response{
+				Status:        "200 OK",
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				Body:          io.NopCloser(bytes.NewReader(cached)),
+				ContentLength: int64(len(cached)),
+				Request:       request,
+			}, nil
+		}
+		c.misses.Add(1)
+
+		resp, err := next(request)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		payload, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("completion cache: failed to read response body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(payload))
+
+		_ = c.cache.Set(ctx, key, payload, c.ttl)
+		return resp, nil
+	}
+}
+
+// completionCacheKey hashes a chat completion request body, ok is false for requests that can't be cached
+func completionCacheKey(body []byte) (string, bool) {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", false
+	}
+	if stream, _ := req["stream"].(bool); stream {
+		return "", false
+	}
+
+	// re-encoding sorts the keys, so the order the SDK wrote them in doesn't matter
+	canonical, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(canonical)
+	model, _ := req["model"].(string)
+	return fmt.Sprintf("goai:completion:%s:%s", model, hex.EncodeToString(sum[:16])), true
+}
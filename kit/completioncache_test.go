@@ -0,0 +1,44 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionCacheAnswersRepeatedRequests(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: "It's 2"},
+		fakeReply{Content: "Warmer"},
+	)
+	cache := NewCompletionCache(NewMemoryResponseCache(0), 0)
+	client := provider.client(WithCompletionCache(cache))
+
+	for range 2 {
+		out, err := CreateAgent(client, &averageTool{}).WithTemperature(0).InvokeSimple(context.Background(), "average of 1 and 3?")
+		require.NoError(t, err)
+		require.Equal(t, "It's 2", out)
+	}
+	require.Len(t, provider.Requests(), 2)
+	require.EqualValues(t, 2, cache.Hits())
+	require.EqualValues(t, 2, cache.Misses())
+
+	// any other parameter is another request
+	out, err := CreateAgent(client, &averageTool{}).WithTemperature(0.7).InvokeSimple(context.Background(), "average of 1 and 3?")
+	require.NoError(t, err)
+	require.Equal(t, "Warmer", out)
+	require.Len(t, provider.Requests(), 3)
+}
+
+func TestCompletionCacheKey(t *testing.T) {
+	a, ok := completionCacheKey([]byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":0}`))
+	require.True(t, ok)
+	b, _ := completionCacheKey([]byte(`{"temperature":0,"messages":[{"content":"hi","role":"user"}],"model":"m"}`))
+	require.Equal(t, a, b)
+	require.Contains(t, a, "goai:completion:m:")
+
+	_, ok = completionCacheKey([]byte(`{"model":"m","stream":true}`))
+	require.False(t, ok)
+}
@@ -0,0 +1,168 @@
+package kit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ArgConstraint restricts one argument of a tool call
+// Field is the JSON name of the argument, use dots for nested objects (e.g. "filter.country")
+// Calls that violate a constraint are not executed, the violation is sent back to the model instead
+type ArgConstraint struct {
+	Field string
+	check func(value any) error
+}
+
+// ArgPattern requires string arguments to match re
+func ArgPattern(field string, re *regexp.Regexp) ArgConstraint {
+	return ArgConstraint{Field: field, check: func(value any) error {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match %s", re)
+		}
+		return nil
+	}}
+}
+
+// ArgDenyPattern rejects string arguments that match re
+func ArgDenyPattern(field string, re *regexp.Regexp) ArgConstraint {
+	return ArgConstraint{Field: field, check: func(value any) error {
+		if s, ok := value.(string); ok && re.MatchString(s) {
+			return fmt.Errorf("must not match %s", re)
+		}
+		return nil
+	}}
+}
+
+// ArgRange requires numeric arguments to be within [min, max]
+func ArgRange(field string, min, max float64) ArgConstraint {
+	return ArgConstraint{Field: field, check: func(value any) error {
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return nil
+	}}
+}
+
+// ArgEnum allows only the given values
+func ArgEnum(field string, allowed ...any) ArgConstraint {
+	return ArgConstraint{Field: field, check: func(value any) error {
+		for _, a := range allowed {
+			if sameArg(value, a) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", formatArgs(allowed))
+	}}
+}
+
+// ArgDeny rejects the given values
+func ArgDeny(field string, denied ...any) ArgConstraint {
+	return ArgConstraint{Field: field, check: func(value any) error {
+		for _, d := range denied {
+			if sameArg(value, d) {
+				return fmt.Errorf("must not be %v", d)
+			}
+		}
+		return nil
+	}}
+}
+
+// WithToolConstraints validates the arguments of a tool before every execution
+func (a *Agent[Output]) WithToolConstraints(toolName string, constraints ...ArgConstraint) *Agent[Output] {
+	if a.toolConstraints == nil {
+		a.toolConstraints = make(map[string][]ArgConstraint)
+	}
+	a.toolConstraints[toolName] = append(a.toolConstraints[toolName], constraints...)
+	return a
+}
+
+// checkToolConstraints returns the violations of a tool call, one line per violation
+// Missing arguments are left to the JSON schema, list arguments are checked item by item
+func (a *Agent[Output]) checkToolConstraints(toolName string, args map[string]interface{}) []string {
+	var violations []string
+	for _, c := range a.toolConstraints[toolName] {
+		value, ok := lookupArg(args, c.Field)
+		if !ok {
+			continue
+		}
+
+		items, isList := value.([]any)
+		if !isList {
+			items = []any{value}
+		}
+		for i, item := range items {
+			if err := c.check(item); err != nil {
+				field := c.Field
+				if isList {
+					field = fmt.Sprintf("%s[%d]", c.Field, i)
+				}
+				violations = append(violations, fmt.Sprintf("%s %s (got %v)", field, err, item))
+			}
+		}
+	}
+	return violations
+}
+
+// constraintFeedback is the tool message sent instead of executing a call that violates constraints
+func constraintFeedback(toolName string, violations []string) string {
+	return fmt.Sprintf(
+		"Tool %s was not executed because its arguments violate constraints:\n- %s\nFix the arguments and call the tool again.",
+		toolName, strings.Join(violations, "\n- "),
+	)
+}
+
+// lookupArg resolves a dotted path in decoded JSON arguments
+func lookupArg(args map[string]interface{}, path string) (any, bool) {
+	var value any = args
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// sameArg compares a decoded JSON value with a Go value, numbers are compared as float64
+func sameArg(value, want any) bool {
+	if n, ok := value.(float64); ok {
+		if w, ok := toFloat64(want); ok {
+			return n == w
+		}
+	}
+	return value == want
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func formatArgs(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,51 @@
+package kit
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolConstraints(t *testing.T) {
+	a := CreateAgent(NewClient()).
+		WithToolConstraints("send_email",
+			ArgPattern("to", regexp.MustCompile(`@example\.com$`)),
+			ArgDenyPattern("body", regexp.MustCompile(`(?i)password`)),
+			ArgEnum("priority", "low", "normal"),
+			ArgRange("retry.count", 0, 3),
+			ArgDeny("cc", "ceo@example.com"),
+		)
+
+	ok := map[string]interface{}{
+		"to":       "bob@example.com",
+		"body":     "hello",
+		"priority": "low",
+		"retry":    map[string]interface{}{"count": float64(2)},
+		"cc":       []any{"ann@example.com"},
+	}
+	require.Empty(t, a.checkToolConstraints("send_email", ok))
+	require.Empty(t, a.checkToolConstraints("other_tool", map[string]interface{}{"to": "x"}))
+
+	bad := map[string]interface{}{
+		"to":       "bob@evil.com",
+		"body":     "my Password is 123",
+		"priority": "urgent",
+		"retry":    map[string]interface{}{"count": float64(9)},
+		"cc":       []any{"ann@example.com", "ceo@example.com"},
+	}
+	violations := a.checkToolConstraints("send_email", bad)
+	require.Len(t, violations, 5)
+	require.Contains(t, violations[0], "to must match")
+	require.Contains(t, violations[3], "retry.count must be between 0 and 3")
+	require.Contains(t, violations[4], "cc[1] must not be ceo@example.com")
+
+	feedback := constraintFeedback("send_email", violations)
+	require.Contains(t, feedback, "was not executed")
+}
+
+func TestArgEnumComparesNumbers(t *testing.T) {
+	c := ArgEnum("n", 1, 2)
+	require.NoError(t, c.check(float64(2)))
+	require.Error(t, c.check(float64(3)))
+}
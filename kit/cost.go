@@ -0,0 +1,100 @@
+package kit
+
+import (
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/openai/openai-go"
+)
+
+// ModelPrice is the USD price of a model per million tokens
+type ModelPrice struct {
+	Input  float64
+	Output float64 // Reasoning tokens are billed as output
+	// CachedInput is the price of prompt tokens read from the provider's prompt cache (optional, defaults to Input)
+	CachedInput float64
+}
+
+// Cost returns the USD cost of the usage
+func (p ModelPrice) Cost(usage Usage) callback.Cost {
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	cost := callback.Cost{
+		Input:  (float64(usage.PromptTokens-usage.CachedTokens)*p.Input + float64(usage.CachedTokens)*cachedPrice) / 1e6,
+		Output: float64(usage.CompletionTokens) * p.Output / 1e6,
+	}
+	cost.Total = cost.Input + cost.Output
+	return cost
+}
+
+// PricingTable maps model names to their prices, see WithPricing
+// A dated version of a model is priced like the model, e.g. "gpt-4o-2024-08-06" like "gpt-4o"
+type PricingTable map[string]ModelPrice
+
+// WithPricing prices every generation with the table: callbacks get the cost in OnGenerationEnd and OnRunEnd,
+// Langfuse traces show it, and Result.Cost sums it per run
+func WithPricing(table PricingTable) ClientOption {
+	return func(c *Config) {
+		c.Pricing = table
+	}
+}
+
+// Price returns the price of a model
+func (t PricingTable) Price(model string) (ModelPrice, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+	// "gpt-4o-2024-08-06" and "claude-3-5-sonnet-20241022" are versions, "gpt-4o-mini" is another model
+	for i := len(model) - 1; i > 0; i-- {
+		if model[i] != '-' || i+1 >= len(model) || model[i+1] < '0' || model[i+1] > '9' {
+			continue
+		}
+		if price, ok := t[model[:i]]; ok {
+			return price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// costFunc prices the generations of a run for the callbacks
+func (t PricingTable) costFunc() callback.CostFunc {
+	return func(model string, usage *openai.CompletionUsage) (callback.Cost, bool) {
+		price, ok := t.Price(model)
+		if !ok {
+			return callback.Cost{}, false
+		}
+		return price.Cost(completionUsage(usage)), true
+	}
+}
+
+// completionUsage converts the usage of one completion
+func completionUsage(usage *openai.CompletionUsage) Usage {
+	return Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CachedTokens:     usage.PromptTokensDetails.CachedTokens,
+		ReasoningTokens:  usage.CompletionTokensDetails.ReasoningTokens,
+	}
+}
+
+// Cost sums the USD cost of the run's LLM calls with the client's pricing table, priced by the model that answered
+// or else the requested one. ok is false without a table or when a model has no price. Cached results cost nothing
+func (r *Result[Output]) Cost() (usd float64, ok bool) {
+	if r.pricing == nil {
+		return 0, false
+	}
+	ok = true
+	for _, completion := range r.Completions {
+		price, found := r.pricing.Price(completion.Model)
+		if !found {
+			price, found = r.pricing.Price(r.model)
+		}
+		if !found {
+			ok = false
+			continue
+		}
+		usd += price.Cost(completionUsage(&completion.Usage)).Total
+	}
+	return usd, ok
+}
@@ -0,0 +1,90 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingTablePrice(t *testing.T) {
+	table := PricingTable{
+		"gpt-4o":      {Input: 2.5, Output: 10},
+		"gpt-4o-mini": {Input: 0.15, Output: 0.6},
+	}
+
+	price, ok := table.Price("gpt-4o-2024-08-06")
+	require.True(t, ok)
+	require.Equal(t, 2.5, price.Input)
+
+	price, ok = table.Price("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	require.Equal(t, 0.15, price.Input)
+
+	_, ok = table.Price("gpt-4o-audio-preview")
+	require.False(t, ok)
+
+	cost := ModelPrice{Input: 2, CachedInput: 1, Output: 8}.Cost(Usage{PromptTokens: 1000, CachedTokens: 400, CompletionTokens: 100})
+	require.InDelta(t, 0.0016, cost.Input, 1e-12)
+	require.InDelta(t, 0.0008, cost.Output, 1e-12)
+	require.InDelta(t, 0.0024, cost.Total, 1e-12)
+}
+
+type costCallback struct {
+	callback.BaseCallback
+	generations []callback.Cost
+	run         *callback.Cost
+}
+
+func (c *costCallback) Name() string { return "cost" }
+
+func (c *costCallback) OnGenerationEnd(ctx map[string]interface{}) {
+	c.generations = append(c.generations, ctx["cost"].(callback.Cost))
+}
+
+func (c *costCallback) OnRunEnd(ctx map[string]interface{}) {
+	if cost, ok := ctx["cost"].(callback.Cost); ok {
+		c.run = &cost
+	}
+}
+
+func TestRunCost(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: `{"average":2}`, Extra: map[string]any{
+			"model": "gpt-4o-2024-08-06",
+			"usage": map[string]any{
+				"prompt_tokens": 40, "completion_tokens": 12, "total_tokens": 52,
+				"prompt_tokens_details": map[string]any{"cached_tokens": 32},
+			},
+		}},
+		fakeReply{Content: `{"average":2}`},
+	)
+	pricing := WithPricing(PricingTable{"gpt-4o": {Input: 2.5, CachedInput: 1.25, Output: 10}})
+
+	costs := &costCallback{}
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(WithDefaultModel("gpt-4o"), pricing), &averageTool{})
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{
+		Prompt:    "average of 1 and 3?",
+		Callbacks: []callback.AgentCallback{costs},
+	})
+	require.NoError(t, err)
+
+	// 10 prompt and 5 completion tokens, then 8 prompt, 32 cached and 12 completion tokens
+	usd, ok := result.Cost()
+	require.True(t, ok)
+	require.InDelta(t, 0.000255, usd, 1e-12)
+
+	require.Len(t, costs.generations, 2)
+	require.InDelta(t, 0.000075, costs.generations[0].Total, 1e-12)
+	require.InDelta(t, 0.00006, costs.generations[1].Input, 1e-12)
+	require.NotNil(t, costs.run)
+	require.InDelta(t, 0.000255, costs.run.Total, 1e-12)
+
+	unpriced, err := CreateAgentWithOutput[averageAnswer](provider.client()).
+		InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 1 and 3?"})
+	require.NoError(t, err)
+	_, ok = unpriced.Cost()
+	require.False(t, ok)
+}
@@ -3,6 +3,9 @@ package kit
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go"
 )
 
 type File struct {
@@ -26,3 +29,35 @@ func FileImage(mime string, fileContent []byte) File {
 		Name:    "",
 	}
 }
+
+// contentPart converts the file to an image part, or a file part for anything that isn't an image
+func (f File) contentPart() openai.ChatCompletionContentPartUnionParam {
+	if strings.HasPrefix(f.DataURI, "data:image/") {
+		return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: f.DataURI})
+	}
+
+	file := openai.ChatCompletionContentPartFileFileParam{FileData: openai.String(f.DataURI)}
+	if f.Name != "" {
+		file.Filename = openai.String(f.Name)
+	}
+	return openai.FileContentPart(file)
+}
+
+// userMessageWithFiles builds a single user message with the prompt followed by every file
+func userMessageWithFiles(prompt string, files []File) openai.ChatCompletionMessageParamUnion {
+	parts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(files)+1)
+	parts = append(parts, openai.TextContentPart(prompt))
+	for _, f := range files {
+		parts = append(parts, f.contentPart())
+	}
+	return openai.UserMessage(parts)
+}
+
+// UserMessage builds a user message with the prompt followed by the files
+// Use it to continue a stored conversation with attachments, since InvokeConfig.Files requires Prompt
+func UserMessage(prompt string, files ...File) openai.ChatCompletionMessageParamUnion {
+	if len(files) == 0 {
+		return openai.UserMessage(prompt)
+	}
+	return userMessageWithFiles(prompt, files)
+}
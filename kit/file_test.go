@@ -0,0 +1,45 @@
+package kit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessagesWithFiles(t *testing.T) {
+	agent := CreateAgent(NewClient(WithAPIKey("test"))).WithTextFormat(TextFormatPlain)
+
+	messages, err := agent.buildMessages(InvokeConfig{
+		SystemPrompt: "You read documents",
+		Prompt:       "Summarize these",
+		Files: []File{
+			FileImage("image/png", []byte("png")),
+			FilePDF("report.pdf", []byte("pdf")),
+		},
+	})
+	require.NoError(t, err)
+
+	got, err := json.Marshal(messages)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"role": "system", "content": "You read documents"},
+		{"role": "system", "content": "Respond in plain text only. Do not use markdown, code fences, bullet symbols or any other markup."},
+		{"role": "user", "content": [
+			{"type": "text", "text": "Summarize these"},
+			{"type": "image_url", "image_url": {"url": "data:image/png;base64,cG5n"}},
+			{"type": "file", "file": {"file_data": "data:application/pdf;base64,cGRm", "filename": "report.pdf"}}
+		]}
+	]`, string(got))
+}
+
+func TestBuildMessagesFilesRequirePrompt(t *testing.T) {
+	agent := CreateAgent(NewClient(WithAPIKey("test")))
+
+	_, err := agent.buildMessages(InvokeConfig{
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		Files:    []File{FilePDF("report.pdf", []byte("pdf"))},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package kit
+
+import (
+	"strings"
+
+	"github.com/mhrlife/goai-kit/schema"
+)
+
+// TextFormat constrains the style of string outputs
+type TextFormat string
+
+const (
+	TextFormatDefault  TextFormat = ""         // No constraint
+	TextFormatPlain    TextFormat = "plain"    // Plain text without any markup
+	TextFormatMarkdown TextFormat = "markdown" // GitHub flavored markdown
+)
+
+// instruction returns the system instruction enforcing the format
+func (f TextFormat) instruction() string {
+	switch f {
+	case TextFormatPlain:
+		return "Respond in plain text only. Do not use markdown, code fences, bullet symbols or any other markup."
+	case TextFormatMarkdown:
+		return "Format your response as GitHub flavored markdown."
+	}
+	return ""
+}
+
+// FormatTurn describes a loop iteration to a FormatPolicy
+type FormatTurn struct {
+	Iteration      int  // 1-based iteration number
+	ToolsAvailable bool // the agent has tools the model may call
+	ToolsCalled    bool // at least one tool round finished before this iteration
+	Final          bool // the model already answered without the schema, this turn asks it to format the answer
+}
+
+// FormatPolicy decides whether the structured output schema is sent on an iteration
+type FormatPolicy func(turn FormatTurn) bool
+
+// FormatEveryIteration sends the schema on every request (default)
+func FormatEveryIteration(FormatTurn) bool {
+	return true
+}
+
+// FormatAfterToolCalls leaves the schema out until the model has used its tools,
+// so models that answer directly in JSON when a schema is present still call tools first
+// A final answer given before any tool call is parsed as JSON, or requested again with the schema
+func FormatAfterToolCalls(turn FormatTurn) bool {
+	return !turn.ToolsAvailable || turn.ToolsCalled || turn.Final
+}
+
+// FormatFinalTurn never sends the schema while the model can call tools, for providers that reject
+// response_format in the middle of a tool loop (e.g. Groq or Gemini through OpenRouter)
+// When the model answers without valid JSON, the answer is requested once more with the schema and tool calls disabled
+func FormatFinalTurn(turn FormatTurn) bool {
+	return !turn.ToolsAvailable || turn.Final
+}
+
+// OutputMode selects how structured output is requested from the model
+type OutputMode string
+
+const (
+	OutputModeAuto   OutputMode = ""       // Picked from the client's provider preset or the model name
+	OutputModeSchema OutputMode = "schema" // response_format with a JSON schema
+	OutputModeTool   OutputMode = "tool"   // a submit_result tool taking the output as arguments
+)
+
+// submitResultTool is the tool the model calls with its answer in OutputModeTool
+const submitResultTool = "submit_result"
+
+// OutputModeForModel returns the output mode for models without json_schema support but with good function calling
+func OutputModeForModel(model string) OutputMode {
+	if strings.Contains(strings.ToLower(model), "deepseek") {
+		return OutputModeTool
+	}
+	return OutputModeSchema
+}
+
+// schemaFeedback asks the model to correct the listed schema violations
+func schemaFeedback(violations *schema.ValidationError) string {
+	var b strings.Builder
+	b.WriteString("Your answer does not match the required JSON schema:\n")
+	for _, v := range violations.Violations {
+		b.WriteString("- " + v.String() + "\n")
+	}
+	b.WriteString("Answer again with the corrected JSON only.")
+	return b.String()
+}
+
+// WithTextFormat constrains string outputs to plain text or markdown
+func (a *Agent[Output]) WithTextFormat(format TextFormat) *Agent[Output] {
+	a.textFormat = format
+	return a
+}
+
+// WithStop sets stop sequences sent with every request
+func (a *Agent[Output]) WithStop(stop ...string) *Agent[Output] {
+	a.stop = stop
+	return a
+}
+
+// WithFormatPolicy controls on which iterations the structured output schema is sent
+func (a *Agent[Output]) WithFormatPolicy(policy FormatPolicy) *Agent[Output] {
+	a.formatPolicy = policy
+	return a
+}
+
+// WithOutputMode overrides how structured output is requested, see OutputModeForModel
+// In OutputModeTool the format policy isn't used, the model must call a tool on every turn and
+// the run ends once it calls submit_result
+func (a *Agent[Output]) WithOutputMode(mode OutputMode) *Agent[Output] {
+	a.outputMode = mode
+	return a
+}
+
+// OutputMode returns how structured output is requested from the model
+func (a *Agent[Output]) OutputMode() OutputMode {
+	if a.outputMode != OutputModeAuto {
+		return a.outputMode
+	}
+	if preset := a.client.config.Preset; preset != nil && preset.OutputMode != OutputModeAuto {
+		return preset.OutputMode
+	}
+	return OutputModeForModel(a.model)
+}
@@ -0,0 +1,230 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type averageAnswer struct {
+	Average float64 `json:"average"`
+}
+
+// rejectSchemaInToolLoop mimics providers (Groq, Gemini via OpenRouter) that refuse response_format
+// while tool calls are still possible
+func rejectSchemaInToolLoop(req map[string]any) string {
+	if req["response_format"] != nil && req["tools"] != nil && req["tool_choice"] != "none" {
+		return "response_format is not supported with tools"
+	}
+	return ""
+}
+
+func TestFormatFinalTurnSendsSchemaOnlyForTheAnswer(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[10,20,30]}`}}},
+		fakeReply{Content: "The average is 20."},
+		fakeReply{Content: `{"average":20}`},
+	)
+	provider.reject = rejectSchemaInToolLoop
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{}).
+		WithFormatPolicy(FormatFinalTurn)
+
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 10, 20, 30?"})
+	require.NoError(t, err)
+	require.Equal(t, 20.0, result.Output.Average)
+	require.Equal(t, 3, result.Iterations)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 3)
+	require.Nil(t, requests[0]["response_format"])
+	require.Nil(t, requests[1]["response_format"])
+	require.NotNil(t, requests[2]["response_format"])
+	require.Equal(t, "none", requests[2]["tool_choice"])
+
+	// The unformatted answer is replaced by the structured one
+	require.Len(t, result.Dialogue, 2)
+}
+
+func TestFormatFinalTurnKeepsValidJSONAnswer(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: `{"average":5}`})
+	provider.reject = rejectSchemaInToolLoop
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{}).
+		WithFormatPolicy(FormatFinalTurn)
+
+	out, err := agent.InvokeSimple(context.Background(), "average of 5?")
+	require.NoError(t, err)
+	require.Equal(t, 5.0, out.Average)
+	require.Len(t, provider.Requests(), 1)
+}
+
+func TestFormatEveryIterationIsRejectedMidToolLoop(t *testing.T) {
+	provider := newFakeProvider(t)
+	provider.reject = rejectSchemaInToolLoop
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{})
+	_, err := agent.InvokeSimple(context.Background(), "average of 5?")
+	require.ErrorContains(t, err, "response_format is not supported with tools")
+}
+
+func TestStringOutputToolLoopSendsNoSchema(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: "It's 2"},
+	)
+	provider.reject = rejectSchemaInToolLoop
+
+	out, err := CreateAgent(provider.client(), &averageTool{}).InvokeSimple(context.Background(), "average of 1 and 3?")
+	require.NoError(t, err)
+	require.Equal(t, "It's 2", out)
+
+	for _, req := range provider.Requests() {
+		require.Nil(t, req["response_format"])
+	}
+}
+
+func TestOutputModeToolSubmitsResult(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[2,4]}`}}},
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-2", Name: "submit_result", Arguments: `{"average":3}`}}},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{}).WithModel("deepseek-chat")
+	require.Equal(t, OutputModeTool, agent.OutputMode())
+
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 2 and 4?"})
+	require.NoError(t, err)
+	require.Equal(t, 3.0, result.Output.Average)
+
+	for _, req := range provider.Requests() {
+		require.Nil(t, req["response_format"])
+		require.Equal(t, "required", req["tool_choice"])
+		require.Len(t, req["tools"], 2)
+	}
+
+	// The submitted result is kept as the assistant's answer
+	last := result.Dialogue[len(result.Dialogue)-1]
+	require.Equal(t, `{"average":3}`, last.OfAssistant.Content.OfString.Value)
+}
+
+func TestOutputModeToolCorrectsInvalidResult(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "submit_result", Arguments: `{"average":"three"}`}}},
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-2", Name: "submit_result", Arguments: `{"average":3}`}}},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client()).WithOutputMode(OutputModeTool)
+	out, err := agent.InvokeSimple(context.Background(), "average of 2 and 4?")
+	require.NoError(t, err)
+	require.Equal(t, 3.0, out.Average)
+
+	messages := provider.Requests()[1]["messages"].([]any)
+	feedback := messages[len(messages)-1].(map[string]any)
+	require.Equal(t, "tool", feedback["role"])
+	require.Equal(t, "call-1", feedback["tool_call_id"])
+	require.Contains(t, feedback["content"], "Invalid result")
+}
+
+func TestOutputModeForModel(t *testing.T) {
+	require.Equal(t, OutputModeTool, OutputModeForModel("deepseek/deepseek-chat-v3"))
+	require.Equal(t, OutputModeSchema, OutputModeForModel("gpt-4o-mini"))
+}
+
+func TestSchemaViolationsAreSentBack(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: `{"average":"three"}`},
+		fakeReply{Content: `{"average":3}`},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client())
+	out, err := agent.InvokeSimple(context.Background(), "average of 2 and 4?")
+	require.NoError(t, err)
+	require.Equal(t, 3.0, out.Average)
+
+	messages := provider.Requests()[1]["messages"].([]any)
+	feedback := messages[len(messages)-1].(map[string]any)
+	require.Equal(t, "user", feedback["role"])
+	require.Contains(t, feedback["content"], "/average: expected number, got string")
+}
+
+func TestSchemaViolationsFailAfterOneRetry(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: `{"average":"three"}`},
+		fakeReply{Content: `{"average":"still three"}`},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client())
+	_, err := agent.InvokeSimple(context.Background(), "average of 2 and 4?")
+	var violations *schema.ValidationError
+	require.ErrorAs(t, err, &violations)
+	require.Equal(t, "/average", violations.Violations[0].Path)
+}
+
+func TestFormatAfterToolCallsSendsSchemaOnceToolsWereUsed(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: `{"average":2}`},
+	)
+
+	var turns []FormatTurn
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{}).
+		WithFormatPolicy(func(turn FormatTurn) bool {
+			turns = append(turns, turn)
+			return FormatAfterToolCalls(turn)
+		})
+
+	out, err := agent.InvokeSimple(context.Background(), "average of 1 and 3?")
+	require.NoError(t, err)
+	require.Equal(t, 2.0, out.Average)
+
+	require.Equal(t, []FormatTurn{
+		{Iteration: 1, ToolsAvailable: true},
+		{Iteration: 2, ToolsAvailable: true, ToolsCalled: true},
+	}, turns)
+	requests := provider.Requests()
+	require.Nil(t, requests[0]["response_format"])
+	require.NotNil(t, requests[1]["response_format"])
+}
+
+func TestFormatAfterToolCallsWithoutTools(t *testing.T) {
+	require.True(t, FormatAfterToolCalls(FormatTurn{Iteration: 1}))
+	require.False(t, FormatAfterToolCalls(FormatTurn{Iteration: 1, ToolsAvailable: true}))
+	require.True(t, FormatAfterToolCalls(FormatTurn{Iteration: 1, ToolsAvailable: true, Final: true}))
+}
+
+func TestTextFormatAddsInstruction(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "plain"}, fakeReply{Content: "# md"})
+	agent := CreateAgent(provider.client()).WithTextFormat(TextFormatPlain)
+
+	_, err := agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	_, err = agent.Invoke(context.Background(), InvokeConfig{Prompt: "hi", TextFormat: TextFormatMarkdown})
+	require.NoError(t, err)
+
+	requests := provider.Requests()
+	plain := requests[0]["messages"].([]any)[0].(map[string]any)
+	require.Equal(t, "system", plain["role"])
+	require.Contains(t, plain["content"], "Respond in plain text only")
+	markdown := requests[1]["messages"].([]any)[0].(map[string]any)
+	require.Equal(t, "Format your response as GitHub flavored markdown.", markdown["content"])
+}
+
+func TestStopSequences(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "a"}, fakeReply{Content: "b"}, fakeReply{Content: "c"})
+
+	_, err := CreateAgent(provider.client()).InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	agent := CreateAgent(provider.client()).WithStop("\n\n", "END")
+	_, err = agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	_, err = agent.Invoke(context.Background(), InvokeConfig{Prompt: "hi", Stop: []string{"STOP"}})
+	require.NoError(t, err)
+
+	requests := provider.Requests()
+	require.Nil(t, requests[0]["stop"])
+	require.Equal(t, []any{"\n\n", "END"}, requests[1]["stop"])
+	require.Equal(t, []any{"STOP"}, requests[2]["stop"], "the run's stop sequences replace the agent's")
+}
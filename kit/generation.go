@@ -0,0 +1,26 @@
+package kit
+
+import "fmt"
+
+// GenerationNamer returns the trace name of the generation made on an iteration
+type GenerationNamer func(iteration int) string
+
+// GenerationName names every generation of a run the same
+func GenerationName(name string) GenerationNamer {
+	return func(int) string {
+		return name
+	}
+}
+
+// GenerationNameWithIteration names generations "<name>.<iteration>"
+func GenerationNameWithIteration(name string) GenerationNamer {
+	return func(iteration int) string {
+		return fmt.Sprintf("%s.%d", name, iteration)
+	}
+}
+
+// WithGenerationName sets how the agent's generations are named in traces
+func (a *Agent[Output]) WithGenerationName(namer GenerationNamer) *Agent[Output] {
+	a.generationName = namer
+	return a
+}
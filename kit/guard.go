@@ -0,0 +1,97 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Sources passed to input and output guards, tool result guards get the tool name
+const (
+	GuardSourceInput  = "input"
+	GuardSourceOutput = "output"
+)
+
+// Guard inspects untrusted content before it is added to the conversation and returns the content to use
+// source is the name of the tool that produced the content, an error fails the run
+type Guard func(ctx context.Context, source, content string) (string, error)
+
+// WithGuards runs every tool result through the guards in order, see the guardrail package
+func (a *Agent[Output]) WithGuards(guards ...Guard) *Agent[Output] {
+	a.guards = guards
+	return a
+}
+
+// WithInputGuards runs the prompt through the guards before the run starts, with GuardSourceInput as source
+// A changed InvokeConfig.Prompt is sent instead, for Messages the text of the last user message is checked only
+func (a *Agent[Output]) WithInputGuards(guards ...Guard) *Agent[Output] {
+	a.inputGuards = guards
+	return a
+}
+
+// WithOutputGuards runs the final answer through the guards before it is returned, with GuardSourceOutput as source
+// Structured outputs are passed as JSON and can only be rejected, a changed string output replaces the answer
+func (a *Agent[Output]) WithOutputGuards(guards ...Guard) *Agent[Output] {
+	a.outputGuards = guards
+	return a
+}
+
+// guardInput runs the input guards, it returns the prompt to send
+func (a *Agent[Output]) guardInput(ctx context.Context, config InvokeConfig) (string, error) {
+	content := config.Prompt
+	if content == "" {
+		content = lastUserText(config.Messages)
+	}
+	for _, guard := range a.inputGuards {
+		var err error
+		content, err = guard(ctx, GuardSourceInput, content)
+		if err != nil {
+			return "", fmt.Errorf("guard rejected input: %w", err)
+		}
+	}
+	if config.Prompt == "" {
+		return "", nil
+	}
+	return content, nil
+}
+
+// guardOutput runs the output guards over the answer of a finished run
+func (a *Agent[Output]) guardOutput(ctx context.Context, result *Result[Output]) error {
+	if len(a.outputGuards) == 0 {
+		return nil
+	}
+	content, isString := any(result.Output).(string)
+	if !isString {
+		data, err := json.Marshal(result.Output)
+		if err != nil {
+			return fmt.Errorf("failed to encode output for guards: %w", err)
+		}
+		content = string(data)
+	}
+
+	for _, guard := range a.outputGuards {
+		var err error
+		content, err = guard(ctx, GuardSourceOutput, content)
+		if err != nil {
+			var zero Output
+			result.Output = zero
+			return fmt.Errorf("guard rejected output: %w", err)
+		}
+	}
+	if isString {
+		result.Output = any(content).(Output)
+	}
+	return nil
+}
+
+// applyGuards runs content through the agent's guards
+func (a *Agent[Output]) applyGuards(ctx context.Context, source, content string) (string, error) {
+	for _, guard := range a.guards {
+		var err error
+		content, err = guard(ctx, source, content)
+		if err != nil {
+			return "", fmt.Errorf("guard rejected %s result: %w", source, err)
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,258 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/openai/openai-go"
+)
+
+// LanguageDetector returns the ISO 639-1 code of the language text is written in, or "" when it can't tell
+type LanguageDetector func(ctx context.Context, text string) (string, error)
+
+// ReplyLanguage makes string answers use the user's language, or a fixed one
+type ReplyLanguage struct {
+	// Language is the ISO 639-1 code replies must use (optional, defaults to the language of the last user message)
+	Language string
+	// Detector finds the language of the user (optional, defaults to DetectLanguage)
+	// Replies are always checked with DetectLanguage, so a slow detector only runs once per run
+	Detector LanguageDetector
+	// Retries is how often a reply in another language is sent back (optional, defaults to 1, negative disables)
+	Retries int
+}
+
+// WithReplyLanguage adds an instruction to reply in the user's language and asks again when a string answer
+// comes in another one, e.g. an English answer to a Persian question
+func (a *Agent[Output]) WithReplyLanguage(config ReplyLanguage) *Agent[Output] {
+	if config.Retries == 0 {
+		config.Retries = 1
+	}
+	a.replyLanguage = &config
+	return a
+}
+
+// languageNames are the names used in reply instructions
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "el": "Greek", "en": "English", "es": "Spanish", "fa": "Persian",
+	"fr": "French", "he": "Hebrew", "hi": "Hindi", "it": "Italian", "ja": "Japanese", "ko": "Korean",
+	"nl": "Dutch", "pt": "Portuguese", "ru": "Russian", "th": "Thai", "tr": "Turkish", "uk": "Ukrainian",
+	"ur": "Urdu", "zh": "Chinese",
+}
+
+// LanguageName returns the English name of an ISO 639-1 code, or the code itself when it's unknown
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// stopwords tell apart languages written in the Latin script
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "you", "for", "with", "this", "what", "how", "can", "please"},
+	"es": {"el", "los", "las", "que", "es", "por", "para", "una", "con", "como", "qué", "pero", "del", "está"},
+	"fr": {"le", "les", "des", "est", "et", "une", "pour", "dans", "pas", "vous", "je", "qui", "avec", "sur"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "ein", "eine", "mit", "auf", "wie", "für"},
+	"it": {"il", "gli", "che", "è", "di", "e", "un", "per", "non", "sono", "con", "della", "come"},
+	"pt": {"os", "que", "é", "de", "um", "uma", "para", "não", "com", "você", "como", "mais", "está"},
+	"nl": {"het", "een", "en", "van", "niet", "dat", "ik", "je", "zijn", "wat", "hoe", "voor"},
+	"tr": {"ve", "bir", "bu", "için", "ne", "ile", "çok", "değil", "nasıl", "mı", "mi", "ben"},
+}
+
+var (
+	// codeAndLinks are left out of detection, an answer about code is still written in the user's language
+	codeAndLinks = regexp.MustCompile("(?s)```.*?```|`[^`]*`|https?://\\S+")
+	latinWord    = regexp.MustCompile(`\p{Latin}+`)
+)
+
+// DetectLanguage guesses the language of text from the scripts its words are written in
+// Text mixing scripts, like Persian with English terms, counts as the language with the most words
+// Latin script languages are told apart by common words, "" is returned for text too short to tell
+func DetectLanguage(text string) string {
+	text = codeAndLinks.ReplaceAllString(text, " ")
+
+	words := map[string]int{}
+	persian, arabic, urdu, ukrainian := 0, 0, 0, 0
+	previous := ""
+	for _, r := range text {
+		if unicode.IsMark(r) {
+			continue // vowel signs and diacritics are part of the word
+		}
+		script := scriptOf(r)
+		switch {
+		case script == "zh" || script == "ja":
+			words[script]++ // no spaces between words, every character counts
+		case script != "" && script != previous:
+			words[script]++
+		}
+		previous = script
+
+		switch r {
+		case 'پ', 'چ', 'ژ', 'گ', 'ک', 'ی':
+			persian++
+		case 'ي', 'ك', 'ة', 'ى':
+			arabic++
+		case 'ے', 'ٹ', 'ڈ', 'ڑ', 'ں', 'ھ':
+			urdu++
+		case 'і', 'ї', 'є', 'ґ', 'І', 'Ї', 'Є', 'Ґ':
+			ukrainian++
+		}
+	}
+
+	script, most := "", 0
+	for _, name := range []string{"latin", "arabic", "cyrillic", "zh", "ja", "ko", "el", "he", "hi", "th"} {
+		if words[name] > most {
+			script, most = name, words[name]
+		}
+	}
+	// Japanese is written with kanji too, any kana makes it Japanese
+	if script == "zh" && words["ja"] > 0 {
+		script = "ja"
+	}
+
+	switch script {
+	case "":
+		return ""
+	case "arabic":
+		switch {
+		case urdu > 0 && urdu >= persian:
+			return "ur"
+		case persian > 0 && persian >= arabic:
+			return "fa"
+		case arabic > 0:
+			return "ar"
+		}
+		return "" // no letter only one of them uses
+	case "cyrillic":
+		if ukrainian > 0 {
+			return "uk"
+		}
+		return "ru"
+	case "latin":
+		return latinLanguage(text)
+	}
+	return script
+}
+
+// scriptOf returns the script of a letter, with the languages of single language scripts by their code
+func scriptOf(r rune) string {
+	switch {
+	case !unicode.IsLetter(r):
+		return ""
+	case unicode.Is(unicode.Latin, r):
+		return "latin"
+	case unicode.Is(unicode.Arabic, r):
+		return "arabic"
+	case unicode.Is(unicode.Cyrillic, r):
+		return "cyrillic"
+	case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+		return "ja"
+	case unicode.Is(unicode.Han, r):
+		return "zh"
+	case unicode.Is(unicode.Hangul, r):
+		return "ko"
+	case unicode.Is(unicode.Greek, r):
+		return "el"
+	case unicode.Is(unicode.Hebrew, r):
+		return "he"
+	case unicode.Is(unicode.Devanagari, r):
+		return "hi"
+	case unicode.Is(unicode.Thai, r):
+		return "th"
+	}
+	return ""
+}
+
+// latinLanguage picks the Latin script language whose common words appear most in text
+func latinLanguage(text string) string {
+	hits := map[string]int{}
+	for _, word := range latinWord.FindAllString(strings.ToLower(text), -1) {
+		for language, words := range stopwords {
+			for _, stopword := range words {
+				if word == stopword {
+					hits[language]++
+				}
+			}
+		}
+	}
+
+	best, most := "", 0
+	for _, language := range []string{"en", "es", "fr", "de", "it", "pt", "nl", "tr"} {
+		if hits[language] > most {
+			best, most = language, hits[language]
+		}
+	}
+	return best
+}
+
+// LLMLanguageDetector detects languages with a model, for text the heuristic of DetectLanguage can't tell apart
+func LLMLanguageDetector(client *Client, model string) LanguageDetector {
+	type detection struct {
+		Language string `json:"language" jsonschema:"description=ISO 639-1 code of the language or empty when it can't be told"`
+	}
+	agent := CreateAgentWithOutput[detection](client).WithModel(model)
+	return func(ctx context.Context, text string) (string, error) {
+		out, err := agent.Invoke(ctx, InvokeConfig{
+			SystemPrompt: "Identify the language the user's text is written in. Ignore code, names and quoted terms.",
+			Prompt:       text,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to detect language: %w", err)
+		}
+		return strings.ToLower(strings.TrimSpace(out.Language)), nil
+	}
+}
+
+// replyLanguageFor resolves the language replies of a run must use, "" when there is none
+func (r *ReplyLanguage) replyLanguageFor(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+	if r.Language != "" {
+		return r.Language, nil
+	}
+	text := lastUserText(messages)
+	if r.Detector == nil {
+		return DetectLanguage(text), nil
+	}
+	return r.Detector(ctx, text)
+}
+
+// languageInstruction adds the reply language instruction after the leading system messages
+func languageInstruction(messages []openai.ChatCompletionMessageParamUnion, language string) []openai.ChatCompletionMessageParamUnion {
+	at := 0
+	for at < len(messages) && messages[at].OfSystem != nil {
+		at++
+	}
+	instruction := openai.SystemMessage(fmt.Sprintf(
+		"Always reply in %s, even when the user writes in or mixes in other languages.",
+		LanguageName(language),
+	))
+	return append(messages[:at:at], append([]openai.ChatCompletionMessageParamUnion{instruction}, messages[at:]...)...)
+}
+
+// languageFeedback asks for a reply again in the right language
+func languageFeedback(language string) string {
+	return fmt.Sprintf("Your reply is not in %[1]s. Write the same answer again in %[1]s.", LanguageName(language))
+}
+
+// lastUserText returns the text of the last user message
+func lastUserText(messages []openai.ChatCompletionMessageParamUnion) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		user := messages[i].OfUser
+		if user == nil {
+			continue
+		}
+		if user.Content.OfString.Valid() {
+			return user.Content.OfString.Value
+		}
+		var parts []string
+		for _, part := range user.Content.OfArrayOfContentParts {
+			if part.OfText != nil {
+				parts = append(parts, part.OfText.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
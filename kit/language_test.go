@@ -0,0 +1,97 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for text, want := range map[string]string{
+		"What is the capital of France?":                                    "en",
+		"سلام، پایتخت فرانسه کجاست؟":                                        "fa",
+		"لطفا این function رو refactor کن و test بنویس":                     "fa",
+		"please explain what مرغ means in this sentence":                    "en",
+		"ما هي عاصمة فرنسا؟ أريد الإجابة باختصار":                           "ar",
+		"¿Cuál es la capital de Francia? Dime por favor":                    "es",
+		"Quelle est la capitale de la France, pour vous ?":                  "fr",
+		"Wie ist das Wetter in Berlin und ist es kalt?":                     "de",
+		"Какая столица Франции?":                                            "ru",
+		"東京の天気はどうですか":                                                       "ja",
+		"北京今天天气怎么样":                                                         "zh",
+		"서울 날씨 어때요?":                                                        "ko",
+		"این کد رو ببین ```func main() { fmt.Println(\"hello world\") }```": "fa",
+		"ok":   "",
+		"42":   "",
+		"سلام": "",
+	} {
+		require.Equal(t, want, DetectLanguage(text), text)
+	}
+}
+
+func TestReplyLanguageFollowsUser(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "پایتخت فرانسه پاریس است."})
+	agent := CreateAgent(provider.client()).WithReplyLanguage(ReplyLanguage{})
+
+	answer, err := agent.InvokeWithResult(context.Background(), InvokeConfig{
+		SystemPrompt: "You are a helpful assistant.",
+		Prompt:       "سلام، capital فرانسه کجاست؟",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "پایتخت فرانسه پاریس است.", answer.Output)
+
+	messages := provider.Requests()[0]["messages"].([]any)
+	require.Len(t, messages, 3)
+	require.Equal(t, "system", messages[1].(map[string]any)["role"])
+	require.Contains(t, messages[1].(map[string]any)["content"], "Always reply in Persian")
+}
+
+func TestReplyLanguageAsksAgain(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: "The capital of France is Paris."},
+		fakeReply{Content: "پایتخت فرانسه پاریس است."},
+	)
+	agent := CreateAgent(provider.client()).WithReplyLanguage(ReplyLanguage{})
+
+	answer, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "پایتخت فرانسه کجاست؟"})
+	require.NoError(t, err)
+	require.Equal(t, "پایتخت فرانسه پاریس است.", answer.Output)
+	require.Equal(t, 2, answer.Iterations)
+
+	messages := provider.Requests()[1]["messages"].([]any)
+	require.Equal(t, "Your reply is not in Persian. Write the same answer again in Persian.",
+		messages[len(messages)-1].(map[string]any)["content"])
+}
+
+func TestReplyLanguageRetriesExhausted(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: "Paris is the capital of France."},
+		fakeReply{Content: "It is Paris, the capital of France."},
+	)
+	agent := CreateAgent(provider.client()).WithReplyLanguage(ReplyLanguage{Language: "fa"})
+
+	// the last reply is returned once the retries are used up
+	answer, err := agent.Invoke(context.Background(), InvokeConfig{Prompt: "What is the capital of France?"})
+	require.NoError(t, err)
+	require.Equal(t, "It is Paris, the capital of France.", answer)
+	require.Len(t, provider.Requests(), 2)
+}
+
+func TestReplyLanguageDetector(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Content: `{"language":"FA"}`},
+		fakeReply{Content: "سلام"},
+	)
+	client := provider.client()
+	agent := CreateAgent(client).WithReplyLanguage(ReplyLanguage{Detector: LLMLanguageDetector(client, "small-model")})
+
+	answer, err := agent.Invoke(context.Background(), InvokeConfig{Prompt: "salam, chetori?"})
+	require.NoError(t, err)
+	require.Equal(t, "سلام", answer)
+
+	requests := provider.Requests()
+	require.Equal(t, "small-model", requests[0]["model"])
+	messages := requests[1]["messages"].([]any)
+	require.Contains(t, messages[0].(map[string]any)["content"], "Always reply in Persian")
+}
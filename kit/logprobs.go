@@ -0,0 +1,63 @@
+package kit
+
+import "math"
+
+// WithLogprobs requests the log probability of every generated token, read them with Result.Logprobs
+func (a *Agent[Output]) WithLogprobs() *Agent[Output] {
+	a.logprobs = true
+	return a
+}
+
+// WithTopLogprobs requests the n most likely alternatives of every generated token (0 to 20), it enables logprobs
+func (a *Agent[Output]) WithTopLogprobs(n int) *Agent[Output] {
+	a.logprobs = true
+	a.topLogprobs = n
+	return a
+}
+
+// TokenLogprob is the log probability of a generated token
+type TokenLogprob struct {
+	Token   string       `json:"token"`
+	Logprob float64      `json:"logprob"`
+	Top     []TopLogprob `json:"top,omitempty"` // Most likely alternatives, set with WithTopLogprobs
+}
+
+// TopLogprob is an alternative to a generated token
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// Logprobs returns the token log probabilities of the final answer, nil when the agent didn't request them,
+// the provider doesn't return them or the answer came from the cache
+// Structured answers submitted as a tool call (OutputModeTool) have none
+func (r *Result[Output]) Logprobs() []TokenLogprob {
+	raw := r.Raw()
+	if raw == nil || len(raw.Choices) == 0 || len(raw.Choices[0].Logprobs.Content) == 0 {
+		return nil
+	}
+
+	content := raw.Choices[0].Logprobs.Content
+	tokens := make([]TokenLogprob, len(content))
+	for i, t := range content {
+		tokens[i] = TokenLogprob{Token: t.Token, Logprob: t.Logprob}
+		for _, top := range t.TopLogprobs {
+			tokens[i].Top = append(tokens[i].Top, TopLogprob{Token: top.Token, Logprob: top.Logprob})
+		}
+	}
+	return tokens
+}
+
+// Confidence is the geometric mean probability of the tokens of the final answer, between 0 and 1
+// It is 0 without logprobs
+func (r *Result[Output]) Confidence() float64 {
+	tokens := r.Logprobs()
+	if len(tokens) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, t := range tokens {
+		sum += t.Logprob
+	}
+	return math.Exp(sum / float64(len(tokens)))
+}
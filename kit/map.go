@@ -0,0 +1,83 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MapErrorPolicy decides what a failed item does to the rest of a Map
+type MapErrorPolicy int
+
+const (
+	// MapFailFast cancels the items still running or waiting and returns the first error
+	MapFailFast MapErrorPolicy = iota
+	// MapContinue runs every item, failures are only reported in MapResult.Err
+	MapContinue
+)
+
+// MapOptions configures Agent.Map
+type MapOptions struct {
+	// Concurrency is the number of items run in parallel (optional, defaults to 4)
+	Concurrency int
+
+	// OnError (optional, defaults to MapFailFast)
+	OnError MapErrorPolicy
+}
+
+// MapResult is the outcome of one item of a Map
+type MapResult[Output any] struct {
+	Result[Output]
+	Err error // nil for items that succeeded, context.Canceled for items skipped by MapFailFast
+}
+
+// Map runs the agent once per config concurrently, e.g. to classify or summarize every element of a slice
+// Results keep the order of configs. With MapFailFast the error is the first failure, with MapContinue it is only
+// set when ctx is done
+func (a *Agent[Output]) Map(ctx context.Context, configs []InvokeConfig, opts MapOptions) ([]MapResult[Output], error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]MapResult[Output], len(configs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(opts.Concurrency, len(configs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result, err := a.InvokeWithResult(ctx, configs[i])
+				results[i] = MapResult[Output]{Result: result, Err: err}
+				if err != nil && opts.OnError == MapFailFast {
+					cancel(fmt.Errorf("item %d: %w", i, err))
+				}
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(configs); next++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case indexes <- next:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := next; i < len(configs); i++ {
+		results[i].Err = context.Canceled
+	}
+	// the cause is the failed item with MapFailFast, or ctx's own error
+	if cause := context.Cause(ctx); cause != nil {
+		return results, cause
+	}
+	return results, nil
+}
@@ -0,0 +1,51 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rejectBadPrompts refuses requests whose messages mention "bad"
+func rejectBadPrompts(req map[string]any) string {
+	if strings.Contains(fmt.Sprint(req["messages"]), "bad") {
+		return "refused"
+	}
+	return ""
+}
+
+func TestAgentMapContinuesPastFailures(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "ok"}, fakeReply{Content: "ok"})
+	provider.reject = rejectBadPrompts
+
+	results, err := CreateAgent(provider.client()).Map(context.Background(), []InvokeConfig{
+		{Prompt: "first"}, {Prompt: "bad"}, {Prompt: "third"},
+	}, MapOptions{Concurrency: 3, OnError: MapContinue})
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	require.Equal(t, "ok", results[0].Output)
+	require.Error(t, results[1].Err)
+	require.NoError(t, results[2].Err)
+	require.Equal(t, "ok", results[2].Output)
+}
+
+func TestAgentMapFailsFast(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "ok"})
+	provider.reject = rejectBadPrompts
+
+	results, err := CreateAgent(provider.client()).Map(context.Background(), []InvokeConfig{
+		{Prompt: "first"}, {Prompt: "bad"}, {Prompt: "third"}, {Prompt: "fourth"},
+	}, MapOptions{Concurrency: 1})
+	require.ErrorContains(t, err, "item 1: ")
+
+	require.Equal(t, "ok", results[0].Output)
+	require.Error(t, results[1].Err)
+	require.ErrorIs(t, results[2].Err, context.Canceled)
+	require.ErrorIs(t, results[3].Err, context.Canceled)
+	require.Len(t, provider.Requests(), 2)
+}
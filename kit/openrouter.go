@@ -0,0 +1,76 @@
+package kit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// OpenRouter transforms
+const (
+	TransformMiddleOut = "middle-out" // compress the middle of prompts that exceed the context window
+)
+
+// OpenRouterOptions are OpenRouter specific request fields
+// See https://openrouter.ai/docs/api-reference/overview
+type OpenRouterOptions struct {
+	// Models are tried in order when the agent's model is unavailable, rate limited or refuses the request
+	Models []string
+
+	// Route selects the routing strategy, "fallback" when Models are set
+	Route string
+
+	// Transforms applied to the prompt, e.g. TransformMiddleOut
+	// A non-nil empty list disables OpenRouter's default middle-out for small context models
+	Transforms []string
+}
+
+// extraFields returns the non-empty options as request body fields
+func (o OpenRouterOptions) extraFields() map[string]any {
+	fields := make(map[string]any)
+	if len(o.Models) > 0 {
+		fields["models"] = o.Models
+	}
+	if o.Route != "" {
+		fields["route"] = o.Route
+	}
+	if o.Transforms != nil {
+		fields["transforms"] = o.Transforms
+	}
+	return fields
+}
+
+// WithOpenRouter sends OpenRouter's model fallbacks, route and transforms with every request
+func (a *Agent[Output]) WithOpenRouter(options OpenRouterOptions) *Agent[Output] {
+	a.openRouter = &options
+	return a
+}
+
+// OpenRouterMetadata describes how OpenRouter served one LLM call
+type OpenRouterMetadata struct {
+	ID       string        // generation ID, usable with OpenRouter's /generation endpoint
+	Model    string        // model that answered, differs from the requested one after a fallback
+	Provider string        // upstream provider, e.g. "OpenAI" or "Groq"
+	Latency  time.Duration // round trip measured by the client
+}
+
+// OpenRouter returns the metadata of every LLM call of the run in order
+func (r *Result[Output]) OpenRouter() []OpenRouterMetadata {
+	metadata := make([]OpenRouterMetadata, len(r.Completions))
+	for i, completion := range r.Completions {
+		metadata[i] = openRouterMetadata(completion)
+		if i < len(r.Latencies) {
+			metadata[i].Latency = r.Latencies[i]
+		}
+	}
+	return metadata
+}
+
+func openRouterMetadata(completion *openai.ChatCompletion) OpenRouterMetadata {
+	metadata := OpenRouterMetadata{ID: completion.ID, Model: completion.Model}
+	if field, ok := completion.JSON.ExtraFields["provider"]; ok {
+		_ = json.Unmarshal([]byte(field.Raw()), &metadata.Provider)
+	}
+	return metadata
+}
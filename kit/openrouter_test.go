@@ -0,0 +1,37 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRouterOptionsAndMetadata(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hi", Extra: map[string]any{"provider": "Groq"}})
+
+	agent := CreateAgent(provider.client()).WithOpenRouter(OpenRouterOptions{
+		Models:     []string{"meta-llama/llama-3.3-70b-instruct", "openai/gpt-4o-mini"},
+		Route:      "fallback",
+		Transforms: []string{TransformMiddleOut},
+	})
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "hi"})
+	require.NoError(t, err)
+
+	req := provider.Requests()[0]
+	require.Equal(t, []any{"meta-llama/llama-3.3-70b-instruct", "openai/gpt-4o-mini"}, req["models"])
+	require.Equal(t, "fallback", req["route"])
+	require.Equal(t, []any{"middle-out"}, req["transforms"])
+
+	metadata := result.OpenRouter()
+	require.Len(t, metadata, 1)
+	require.Equal(t, "Groq", metadata[0].Provider)
+	require.Equal(t, "test-model", metadata[0].Model)
+	require.Equal(t, "chatcmpl-test", metadata[0].ID)
+	require.Positive(t, metadata[0].Latency)
+}
+
+func TestOpenRouterEmptyTransformsAreSent(t *testing.T) {
+	require.Equal(t, map[string]any{"transforms": []string{}}, OpenRouterOptions{Transforms: []string{}}.extraFields())
+	require.Empty(t, OpenRouterOptions{}.extraFields())
+}
@@ -0,0 +1,214 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/param"
+)
+
+// WithPartialOutput streams every LLM call and calls fn with the output decoded from the answer received so far,
+// e.g. to render a structured result while it is generated. fn is called from the run's goroutine and only when
+// the partial output changed. String outputs get the text so far
+// The provider must support streaming, and cached answers are returned without partials
+func (a *Agent[Output]) WithPartialOutput(fn func(partial Output)) *Agent[Output] {
+	a.partialOutput = fn
+	return a
+}
+
+// PartialDecoder decodes an Output from a JSON document that is still streaming in
+// Fields appear as soon as their value can be read, strings grow as they stream
+type PartialDecoder[Output any] struct {
+	buf  strings.Builder
+	last string
+}
+
+func NewPartialDecoder[Output any]() *PartialDecoder[Output] {
+	return &PartialDecoder[Output]{}
+}
+
+// Write adds the next chunk of the document and returns the output decoded from everything received so far,
+// ok is false when the chunk didn't complete anything new
+func (d *PartialDecoder[Output]) Write(chunk string) (Output, bool) {
+	d.buf.WriteString(chunk)
+	return d.decode()
+}
+
+// Reset drops the received document, for decoding the next one
+func (d *PartialDecoder[Output]) Reset() {
+	d.buf.Reset()
+	d.last = ""
+}
+
+func (d *PartialDecoder[Output]) decode() (Output, bool) {
+	var out Output
+	completed := completeJSON(d.buf.String())
+	if completed == "" || completed == d.last {
+		return out, false
+	}
+	if err := json.Unmarshal([]byte(completed), &out); err != nil {
+		return out, false
+	}
+	d.last = completed
+	return out, true
+}
+
+// completeJSON turns the prefix of a JSON document into a valid document: an open string is closed, an
+// unfinished key or literal is dropped and open arrays and objects are closed. It returns "" for a prefix
+// without a value yet
+func completeJSON(prefix string) string {
+	// a chunk may end within a multi-byte character
+	for len(prefix) > 0 {
+		r, size := utf8.DecodeLastRuneInString(prefix)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		prefix = prefix[:len(prefix)-1]
+	}
+
+	var (
+		stack    []byte // closers of the open arrays and objects
+		inString bool
+		escaped  bool
+		// safe is the longest prefix ending after a complete value, with the closers it needs
+		safe       = -1
+		safeClosed []byte
+	)
+	markSafe := func(end int) {
+		safe = end
+		safeClosed = append(safeClosed[:0], stack...)
+	}
+
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+			markSafe(i + 1)
+		case '[':
+			stack = append(stack, ']')
+			markSafe(i + 1)
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			markSafe(i + 1)
+		case ',':
+			markSafe(i)
+		}
+	}
+
+	// the whole prefix closed as is, which keeps a growing string or number
+	candidate := prefix
+	if inString {
+		candidate = strings.TrimSuffix(candidate, `\`) + `"`
+	}
+	if completed := closeJSON(candidate, stack); json.Valid([]byte(completed)) {
+		return completed
+	}
+
+	if safe < 0 {
+		return ""
+	}
+	completed := closeJSON(strings.TrimRight(prefix[:safe], " \t\r\n"), safeClosed)
+	if !json.Valid([]byte(completed)) {
+		return ""
+	}
+	return completed
+}
+
+// closeJSON appends the closers of the open arrays and objects, innermost first
+func closeJSON(s string, stack []byte) string {
+	var b strings.Builder
+	b.WriteString(s)
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+	return b.String()
+}
+
+// partialEmitter turns the message streamed by one LLM call into partial outputs
+type partialEmitter[Output any] struct {
+	fn         func(Output)
+	submitTool bool
+	decoder    *PartialDecoder[Output]
+	text       string
+}
+
+func newPartialEmitter[Output any](fn func(Output), submitTool bool) *partialEmitter[Output] {
+	return &partialEmitter[Output]{fn: fn, submitTool: submitTool, decoder: NewPartialDecoder[Output]()}
+}
+
+// update is called with the message accumulated so far
+func (e *partialEmitter[Output]) update(message openai.ChatCompletionMessage) {
+	if _, ok := any(*new(Output)).(string); ok {
+		if message.Content != e.text {
+			e.text = message.Content
+			e.fn(any(message.Content).(Output))
+		}
+		return
+	}
+
+	// in tool mode the output streams in as the arguments of submit_result
+	doc := message.Content
+	if e.submitTool {
+		doc = ""
+		for _, call := range message.ToolCalls {
+			if call.Function.Name == submitResultTool {
+				doc = call.Function.Arguments
+			}
+		}
+	}
+	if !strings.HasPrefix(doc, e.text) {
+		e.decoder.Reset()
+		e.text = ""
+	}
+	chunk := doc[len(e.text):]
+	e.text = doc
+	if out, ok := e.decoder.Write(chunk); ok {
+		e.fn(out)
+	}
+}
+
+// streamCompletion runs a chat completion as a stream, calling onMessage with the first choice received so far,
+// and returns the accumulated completion
+func (a *Agent[Output]) streamCompletion(
+	ctx context.Context,
+	params openai.ChatCompletionNewParams,
+	onMessage func(openai.ChatCompletionMessage),
+	opts ...option.RequestOption,
+) (*openai.ChatCompletion, error) {
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: param.NewOpt(true)}
+	stream := a.client.client.Chat.Completions.NewStreaming(ctx, params, opts...)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 && len(acc.Choices) > 0 {
+			onMessage(acc.Choices[0].Message)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return &acc.ChatCompletion, nil
+}
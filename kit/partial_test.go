@@ -0,0 +1,101 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteJSON(t *testing.T) {
+	cases := map[string]string{
+		``:                             ``,
+		`{`:                            `{}`,
+		`{"na`:                         `{}`,
+		`{"name"`:                      `{}`,
+		`{"name":`:                     `{}`,
+		`{"name":"Ad`:                  `{"name":"Ad"}`,
+		`{"name":"Ada",`:               `{"name":"Ada"}`,
+		`{"name":"Ada","age":3`:        `{"name":"Ada","age":3}`,
+		`{"name":"Ada","ok":tr`:        `{"name":"Ada"}`,
+		`{"name":"a\`:                  `{"name":"a"}`,
+		`{"name":"a\"b`:                `{"name":"a\"b"}`,
+		`{"tags":["x","y`:              `{"tags":["x","y"]}`,
+		`{"tags":["x"],"items":[{"n":`: `{"tags":["x"],"items":[{}]}`,
+		`{"name":"é`:                   `{"name":"é"}`,
+		`{"name":"` + "\xc3":           `{"name":""}`,
+		`{"a":{"b":1}}`:                `{"a":{"b":1}}`,
+	}
+	for prefix, want := range cases {
+		require.Equal(t, want, completeJSON(prefix), prefix)
+	}
+}
+
+func TestPartialDecoder(t *testing.T) {
+	type person struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+
+	decoder := NewPartialDecoder[person]()
+	var partials []person
+	for _, chunk := range []string{`{"na`, `me":"A`, `da",`, ` "tags":["x`, `"`, `]}`} {
+		if out, ok := decoder.Write(chunk); ok {
+			partials = append(partials, out)
+		}
+	}
+
+	require.Equal(t, []person{
+		{},
+		{Name: "A"},
+		{Name: "Ada"},
+		{Name: "Ada", Tags: []string{"x"}},
+	}, partials)
+}
+
+func TestPartialOutputStreamsStructuredAnswer(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{
+		Content: `{"average":3}`,
+		Chunks:  []string{`{"aver`, `age":`, `3`, `}`},
+	})
+
+	var partials []averageAnswer
+	result, err := CreateAgentWithOutput[averageAnswer](provider.client()).
+		WithPartialOutput(func(partial averageAnswer) { partials = append(partials, partial) }).
+		InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 2 and 4?"})
+	require.NoError(t, err)
+	require.Equal(t, 3.0, result.Output.Average)
+	require.Equal(t, []averageAnswer{{}, {Average: 3}}, partials)
+	require.EqualValues(t, 15, result.Usage().TotalTokens)
+
+	req := provider.Requests()[0]
+	require.Equal(t, true, req["stream"])
+	require.NotNil(t, req["response_format"])
+}
+
+func TestPartialOutputStreamsSubmittedResult(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "submit_result", Arguments: `{"average":3}`}}},
+	)
+
+	var partials []averageAnswer
+	out, err := CreateAgentWithOutput[averageAnswer](provider.client()).
+		WithOutputMode(OutputModeTool).
+		WithPartialOutput(func(partial averageAnswer) { partials = append(partials, partial) }).
+		Invoke(context.Background(), InvokeConfig{Prompt: "average of 2 and 4?"})
+	require.NoError(t, err)
+	require.Equal(t, 3.0, out.Average)
+	require.Equal(t, []averageAnswer{{Average: 3}}, partials)
+}
+
+func TestPartialOutputStreamsText(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "Hello there", Chunks: []string{"Hel", "lo", " there"}})
+
+	var partials []string
+	out, err := CreateAgent(provider.client()).
+		WithPartialOutput(func(partial string) { partials = append(partials, partial) }).
+		InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "Hello there", out)
+	require.Equal(t, []string{"Hel", "Hello", "Hello there"}, partials)
+}
@@ -0,0 +1,90 @@
+package kit
+
+import (
+	"os"
+
+	"github.com/mhrlife/goai-kit/schema"
+)
+
+// ProviderPreset configures the client for an OpenAI compatible provider and its known quirks
+type ProviderPreset struct {
+	Name         string
+	BaseURL      string
+	APIKeyEnv    string // environment variable holding the API key, sent as a bearer token
+	DefaultModel string
+
+	// Dialect tool and response schemas are adapted to, nil picks it from the model name
+	Dialect *schema.Dialect
+
+	// FormatPolicy used by agents that don't set one, nil sends the schema on every iteration
+	FormatPolicy FormatPolicy
+
+	// OutputMode used by agents that don't set one, OutputModeAuto picks it from the model name
+	OutputMode OutputMode
+}
+
+var (
+	// ProviderGroq rejects response_format while tools can still be called and has no strict mode
+	ProviderGroq = ProviderPreset{
+		Name:         "groq",
+		BaseURL:      "https://api.groq.com/openai/v1",
+		APIKeyEnv:    "GROQ_API_KEY",
+		DefaultModel: "llama-3.3-70b-versatile",
+		Dialect:      &schema.DialectLenient,
+		FormatPolicy: FormatFinalTurn,
+	}
+
+	// ProviderTogether accepts JSON schemas without strict mode, its models tend to answer in JSON instead of calling tools
+	ProviderTogether = ProviderPreset{
+		Name:         "together",
+		BaseURL:      "https://api.together.xyz/v1",
+		APIKeyEnv:    "TOGETHER_API_KEY",
+		DefaultModel: "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		Dialect:      &schema.DialectLenient,
+		FormatPolicy: FormatAfterToolCalls,
+	}
+
+	// ProviderFireworks accepts JSON schemas without strict mode, its models tend to answer in JSON instead of calling tools
+	ProviderFireworks = ProviderPreset{
+		Name:         "fireworks",
+		BaseURL:      "https://api.fireworks.ai/inference/v1",
+		APIKeyEnv:    "FIREWORKS_API_KEY",
+		DefaultModel: "accounts/fireworks/models/llama-v3p3-70b-instruct",
+		Dialect:      &schema.DialectLenient,
+		FormatPolicy: FormatAfterToolCalls,
+	}
+
+	// ProviderXAI serves Grok models, reasoning models return their thinking in reasoning_content (see Result.Reasoning)
+	ProviderXAI = ProviderPreset{
+		Name:         "xai",
+		BaseURL:      "https://api.x.ai/v1",
+		APIKeyEnv:    "XAI_API_KEY",
+		DefaultModel: "grok-3-mini",
+		Dialect:      &schema.DialectLenient,
+	}
+
+	// ProviderDeepSeek serves deepseek-chat and deepseek-reasoner, the latter returns its thinking in reasoning_content
+	// It has no json_schema response format, structured output is submitted through a tool
+	ProviderDeepSeek = ProviderPreset{
+		Name:         "deepseek",
+		BaseURL:      "https://api.deepseek.com",
+		APIKeyEnv:    "DEEPSEEK_API_KEY",
+		DefaultModel: "deepseek-chat",
+		Dialect:      &schema.DialectLenient,
+		OutputMode:   OutputModeTool,
+	}
+)
+
+// WithProviderPreset points the client at a provider, reads its API key from the preset's environment variable
+// and lets agents apply the provider's schema dialect and format policy
+// Options given after the preset (e.g. WithAPIKey or WithDefaultModel) override it
+func WithProviderPreset(preset ProviderPreset) ClientOption {
+	return func(c *Config) {
+		c.ApiBase = preset.BaseURL
+		c.DefaultModel = preset.DefaultModel
+		if key := os.Getenv(preset.APIKeyEnv); preset.APIKeyEnv != "" && key != "" {
+			c.ApiKey = key
+		}
+		c.Preset = &preset
+	}
+}
@@ -0,0 +1,53 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderPresetConfiguresClient(t *testing.T) {
+	t.Setenv("GROQ_API_KEY", "gsk_test")
+
+	client := NewClient(WithProviderPreset(ProviderGroq))
+	require.Equal(t, "https://api.groq.com/openai/v1", client.config.ApiBase)
+	require.Equal(t, "gsk_test", client.config.ApiKey)
+	require.Equal(t, "llama-3.3-70b-versatile", client.config.DefaultModel)
+
+	client = NewClient(WithProviderPreset(ProviderGroq), WithDefaultModel("qwen/qwen3-32b"))
+	require.Equal(t, "qwen/qwen3-32b", client.config.DefaultModel)
+}
+
+func TestProviderPresetAppliesQuirks(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[2,4]}`}}},
+		fakeReply{Content: "The average is 3."},
+		fakeReply{Content: `{"average":3}`},
+	)
+	provider.reject = rejectSchemaInToolLoop
+
+	client := NewClient(WithProviderPreset(ProviderGroq), WithAPIKey("test"), WithBaseURL(provider.server.URL))
+	agent := CreateAgentWithOutput[averageAnswer](client, &averageTool{})
+	require.Equal(t, "lenient", agent.SchemaDialect().Name)
+
+	out, err := agent.InvokeSimple(context.Background(), "average of 2 and 4?")
+	require.NoError(t, err)
+	require.Equal(t, 3.0, out.Average)
+	require.Equal(t, "llama-3.3-70b-versatile", provider.Requests()[0]["model"])
+}
+
+func TestReasoningContentIsCaptured(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "42", Reasoning: "6 times 7 is 42"})
+
+	transcript := callback.NewTranscriptCallback()
+	client := NewClient(WithProviderPreset(ProviderDeepSeek), WithAPIKey("test"), WithBaseURL(provider.server.URL))
+	agent := CreateAgent(client).WithModel("deepseek-reasoner").WithCallbacks(transcript)
+
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "6 times 7?"})
+	require.NoError(t, err)
+	require.Equal(t, "42", result.Output)
+	require.Equal(t, []string{"6 times 7 is 42"}, result.Reasoning())
+	require.Equal(t, "6 times 7 is 42", transcript.Transcript().Iterations[0].Reasoning)
+}
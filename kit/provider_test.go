@@ -0,0 +1,207 @@
+package kit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReply is one scripted chat completion, Status other than 0/200 returns Body as an error response
+type fakeReply struct {
+	Content   string
+	ToolCalls []fakeToolCall
+	Status    int
+	Body      string
+	Extra     map[string]any // additional top-level response fields
+	Reasoning string         // returned as the message's reasoning_content
+	Logprobs  any            // returned as the choice's logprobs
+	Choices   []string       // contents of further choices
+	Chunks    []string       // content deltas of a streamed reply, defaults to Content in one delta
+}
+
+type fakeToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// fakeProvider is an OpenAI compatible chat completions server answering from a script
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	// reject returns a non-empty error message for requests the provider refuses
+	reject func(req map[string]any) string
+
+	mu       sync.Mutex
+	replies  []fakeReply
+	requests []map[string]any
+}
+
+func newFakeProvider(t *testing.T, replies ...fakeReply) *fakeProvider {
+	p := &fakeProvider{t: t, replies: replies}
+	p.server = httptest.NewServer(http.HandlerFunc(p.handle))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// client returns a kit client talking to the fake provider
+func (p *fakeProvider) client(opts ...ClientOption) *Client {
+	return NewClient(append([]ClientOption{
+		WithAPIKey("test"),
+		WithBaseURL(p.server.URL),
+		WithDefaultModel("test-model"),
+	}, opts...)...)
+}
+
+// Requests returns the decoded request bodies received so far
+func (p *fakeProvider) Requests() []map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]map[string]any(nil), p.requests...)
+}
+
+func (p *fakeProvider) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(p.t, err)
+	var req map[string]any
+	require.NoError(p.t, json.Unmarshal(body, &req))
+
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	if p.reject != nil {
+		if msg := p.reject(req); msg != "" {
+			p.mu.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"` + msg + `"}}`))
+			return
+		}
+	}
+	require.NotEmpty(p.t, p.replies, "unexpected request %d", len(p.requests))
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	p.mu.Unlock()
+
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		w.WriteHeader(reply.Status)
+		_, _ = w.Write([]byte(reply.Body))
+		return
+	}
+
+	if stream, _ := req["stream"].(bool); stream {
+		p.stream(w, req, reply)
+		return
+	}
+
+	message := map[string]any{"role": "assistant", "content": reply.Content}
+	if reply.Reasoning != "" {
+		message["reasoning_content"] = reply.Reasoning
+	}
+	finishReason := "stop"
+	if len(reply.ToolCalls) > 0 {
+		calls := make([]map[string]any, len(reply.ToolCalls))
+		for i, c := range reply.ToolCalls {
+			calls[i] = map[string]any{
+				"id":       c.ID,
+				"type":     "function",
+				"function": map[string]any{"name": c.Name, "arguments": c.Arguments},
+			}
+		}
+		message["tool_calls"] = calls
+		finishReason = "tool_calls"
+	}
+
+	choices := []map[string]any{{"index": 0, "finish_reason": finishReason, "message": message, "logprobs": reply.Logprobs}}
+	for i, content := range reply.Choices {
+		choices = append(choices, map[string]any{
+			"index":         i + 1,
+			"finish_reason": "stop",
+			"message":       map[string]any{"role": "assistant", "content": content},
+		})
+	}
+
+	response := map[string]any{
+		"id":      "chatcmpl-test",
+		"object":  "chat.completion",
+		"created": 0,
+		"model":   req["model"],
+		"choices": choices,
+		"usage":   map[string]any{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	}
+	for k, v := range reply.Extra {
+		response[k] = v
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(p.t, json.NewEncoder(w).Encode(response))
+}
+
+// stream sends the reply as server-sent chat completion chunks
+func (p *fakeProvider) stream(w http.ResponseWriter, req map[string]any, reply fakeReply) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	send := func(choices []map[string]any, usage any) {
+		chunk, err := json.Marshal(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion.chunk",
+			"created": 0,
+			"model":   req["model"],
+			"choices": choices,
+			"usage":   usage,
+		})
+		require.NoError(p.t, err)
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+	}
+
+	chunks := reply.Chunks
+	if len(chunks) == 0 {
+		chunks = []string{reply.Content}
+	}
+	for i, content := range chunks {
+		delta := map[string]any{"content": content}
+		if i == 0 {
+			delta["role"] = "assistant"
+		}
+		send([]map[string]any{{"index": 0, "delta": delta}}, nil)
+	}
+
+	finishReason := "stop"
+	if len(reply.ToolCalls) > 0 {
+		calls := make([]map[string]any, len(reply.ToolCalls))
+		for i, c := range reply.ToolCalls {
+			calls[i] = map[string]any{
+				"index":    i,
+				"id":       c.ID,
+				"type":     "function",
+				"function": map[string]any{"name": c.Name, "arguments": c.Arguments},
+			}
+		}
+		send([]map[string]any{{"index": 0, "delta": map[string]any{"tool_calls": calls}}}, nil)
+		finishReason = "tool_calls"
+	}
+	send([]map[string]any{{"index": 0, "delta": map[string]any{}, "finish_reason": finishReason}}, nil)
+	send([]map[string]any{}, map[string]any{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15})
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// averageTool is a tool used by the agent tests
+type averageTool struct {
+	BaseTool
+	Numbers []float64 `json:"numbers"`
+}
+
+func (t *averageTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "average_numbers", Description: "Average a list of numbers"}
+}
+
+func (t *averageTool) Execute(ctx *Context) (any, error) {
+	sum := 0.0
+	for _, n := range t.Numbers {
+		sum += n
+	}
+	return map[string]any{"average": sum / float64(len(t.Numbers))}, nil
+}
@@ -0,0 +1,385 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+var (
+	// ErrInvalidKey is returned for a missing, unknown or disabled API key
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrModelNotAllowed is returned when a key isn't allowed to use the requested model
+	ErrModelNotAllowed = errors.New("model not allowed for API key")
+	// ErrQuotaExceeded is returned when a key has used its daily token budget
+	ErrQuotaExceeded = errors.New("daily token quota exceeded")
+)
+
+// keyPrefix starts every issued secret, so leaked keys are easy to recognise
+const keyPrefix = "gk_"
+
+// APIKey is an internal key issued to a consumer of a goai-kit powered endpoint
+type APIKey struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`         // who the key belongs to, e.g. a team or service
+	Models      []string  `json:"models"`       // models the key may use, empty allows every model
+	DailyTokens int64     `json:"daily_tokens"` // total tokens per day, 0 is unlimited
+	Disabled    bool      `json:"disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	SecretHash  string    `json:"secret_hash"` // SHA-256 of the secret, the secret itself is never stored
+}
+
+// AllowsModel reports whether the key may use model
+func (k APIKey) AllowsModel(model string) bool {
+	return len(k.Models) == 0 || slices.Contains(k.Models, model)
+}
+
+// QuotaStore persists API keys and their daily token usage
+// Share one store between every replica that serves the same consumers
+type QuotaStore interface {
+	SaveKey(ctx context.Context, key APIKey) error
+	// LoadKey returns nil when the key doesn't exist
+	LoadKey(ctx context.Context, id string) (*APIKey, error)
+	DeleteKey(ctx context.Context, id string) error
+	// AddUsage adds tokens to the usage of a key on day (YYYY-MM-DD) and returns the new total
+	AddUsage(ctx context.Context, id, day string, tokens int64) (int64, error)
+	Usage(ctx context.Context, id, day string) (int64, error)
+}
+
+// QuotaConfig configures a QuotaManager
+type QuotaConfig struct {
+	// Store holds keys and usage (optional, defaults to an in-memory store)
+	Store QuotaStore
+
+	// Location decides when a day starts (optional, defaults to UTC)
+	Location *time.Location
+
+	// RequireKey rejects requests without a key in their context (optional, by default they aren't limited)
+	RequireKey bool
+}
+
+// QuotaManager issues internal API keys and enforces their model allowlists and daily token budgets
+// A request is checked before it is sent, so the one that crosses the budget still completes
+type QuotaManager struct {
+	config QuotaConfig
+	now    func() time.Time
+}
+
+// NewQuotaManager creates a new quota manager
+func NewQuotaManager(config QuotaConfig) *QuotaManager {
+	if config.Store == nil {
+		config.Store = NewMemoryQuotaStore()
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+	return &QuotaManager{config: config, now: time.Now}
+}
+
+// WithQuota checks every request of the client against the key in its context, see WithQuotaKey
+// Chat completions of agents are checked before each generation, embeddings, moderation and any other request
+// made through the client are checked and charged by the client's middleware
+func WithQuota(m *QuotaManager) ClientOption {
+	return func(c *Config) {
+		c.Quota = m
+	}
+}
+
+type quotaKey struct{}
+
+// quotaCheckedKey marks requests an agent already checked, with the *APIKey they are charged to
+type quotaCheckedKey struct{}
+
+// withCheckedQuota marks the requests made with ctx as checked against key, nil for requests without a key
+func withCheckedQuota(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, quotaCheckedKey{}, key)
+}
+
+// WithQuotaKey sets the API key secret that requests made with ctx are charged to
+func WithQuotaKey(ctx context.Context, secret string) context.Context {
+	return context.WithValue(ctx, quotaKey{}, secret)
+}
+
+// QuotaKeyFromContext returns the secret set with WithQuotaKey
+func QuotaKeyFromContext(ctx context.Context) (string, bool) {
+	secret, ok := ctx.Value(quotaKey{}).(string)
+	return secret, ok && secret != ""
+}
+
+// Issue creates a key and returns it with its secret, which is only available now
+func (m *QuotaManager) Issue(ctx context.Context, key APIKey) (APIKey, string, error) {
+	id, err := randomHex(6)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	random, err := randomHex(24)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	key.ID = id
+	key.CreatedAt = m.now()
+	secret := keyPrefix + id + "_" + random
+	key.SecretHash = hashSecret(secret)
+	if err := m.config.Store.SaveKey(ctx, key); err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to save key: %w", err)
+	}
+	return key, secret, nil
+}
+
+// Update changes the name, models, budget or disabled flag of an existing key
+func (m *QuotaManager) Update(ctx context.Context, key APIKey) error {
+	stored, err := m.config.Store.LoadKey(ctx, key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load key: %w", err)
+	}
+	if stored == nil {
+		return fmt.Errorf("key %s: %w", key.ID, ErrInvalidKey)
+	}
+
+	key.CreatedAt = stored.CreatedAt
+	key.SecretHash = stored.SecretHash
+	if err := m.config.Store.SaveKey(ctx, key); err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	return nil
+}
+
+// Revoke deletes a key, requests with its secret fail with ErrInvalidKey
+func (m *QuotaManager) Revoke(ctx context.Context, id string) error {
+	if err := m.config.Store.DeleteKey(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+	return nil
+}
+
+// Authenticate returns the enabled key a secret belongs to
+func (m *QuotaManager) Authenticate(ctx context.Context, secret string) (APIKey, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(secret, keyPrefix), "_")
+	if !ok || !strings.HasPrefix(secret, keyPrefix) {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	key, err := m.config.Store.LoadKey(ctx, id)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("failed to load key: %w", err)
+	}
+	if key == nil || key.Disabled || subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return APIKey{}, ErrInvalidKey
+	}
+	return *key, nil
+}
+
+// Usage returns the tokens a key used today and how many are left, remaining is -1 for unlimited keys
+func (m *QuotaManager) Usage(ctx context.Context, id string) (used, remaining int64, err error) {
+	key, err := m.config.Store.LoadKey(ctx, id)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load key: %w", err)
+	}
+	if key == nil {
+		return 0, 0, fmt.Errorf("key %s: %w", id, ErrInvalidKey)
+	}
+
+	used, err = m.config.Store.Usage(ctx, id, m.today())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load usage: %w", err)
+	}
+	if key.DailyTokens <= 0 {
+		return used, -1, nil
+	}
+	return used, max(key.DailyTokens-used, 0), nil
+}
+
+// Check returns the key charged for a request to model, or nil when the context has no key and none is required
+func (m *QuotaManager) Check(ctx context.Context, model string) (*APIKey, error) {
+	key, err := m.authorize(ctx, model)
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	if key.DailyTokens > 0 {
+		used, err := m.config.Store.Usage(ctx, key.ID, m.today())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load usage: %w", err)
+		}
+		if used >= key.DailyTokens {
+			return nil, fmt.Errorf("key %s used %d of %d tokens: %w", key.ID, used, key.DailyTokens, ErrQuotaExceeded)
+		}
+	}
+	return key, nil
+}
+
+// authorize is Check without the budget, for answers that cost no tokens such as cached ones
+func (m *QuotaManager) authorize(ctx context.Context, model string) (*APIKey, error) {
+	secret, ok := QuotaKeyFromContext(ctx)
+	if !ok {
+		if m.config.RequireKey {
+			return nil, ErrInvalidKey
+		}
+		return nil, nil
+	}
+
+	key, err := m.Authenticate(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !key.AllowsModel(model) {
+		return nil, fmt.Errorf("%s: %w", model, ErrModelNotAllowed)
+	}
+	return &key, nil
+}
+
+// middleware checks every request against the key in its context and charges the tokens of its response
+// Streamed responses aren't charged, agents charge their streams themselves
+func (m *QuotaManager) middleware(logger *slog.Logger) option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		ctx := request.Context()
+		key, checked := ctx.Value(quotaCheckedKey{}).(*APIKey)
+		if !checked {
+			model, err := requestModel(request)
+			if err != nil {
+				return nil, err
+			}
+			if key, err = m.Check(ctx, model); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := next(request)
+		if err != nil || key == nil || resp.StatusCode != http.StatusOK ||
+			strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			return resp, err
+		}
+
+		payload, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("quota: failed to read response body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(payload))
+
+		var body struct {
+			Usage struct {
+				TotalTokens int64 `json:"total_tokens"`
+			} `json:"usage"`
+		}
+		if json.Unmarshal(payload, &body) == nil {
+			if err := m.Record(ctx, key, body.Usage.TotalTokens); err != nil {
+				logger.Error("Failed to record token usage", "key", key.ID, "error", err)
+			}
+		}
+		return resp, nil
+	}
+}
+
+// requestModel returns the model of a JSON request body, empty when it has none
+func requestModel(request *http.Request) (string, error) {
+	if request.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", fmt.Errorf("quota: failed to read request body: %w", err)
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	_ = json.Unmarshal(body, &req)
+	return req.Model, nil
+}
+
+// Record charges tokens to a key for today
+func (m *QuotaManager) Record(ctx context.Context, key *APIKey, tokens int64) error {
+	if key == nil || tokens <= 0 {
+		return nil
+	}
+	if _, err := m.config.Store.AddUsage(ctx, key.ID, m.today(), tokens); err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+func (m *QuotaManager) today() string {
+	return m.now().In(m.config.Location).Format("2006-01-02")
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryQuotaStore keeps keys and usage in memory, for a single process
+type MemoryQuotaStore struct {
+	mu    sync.Mutex
+	keys  map[string]APIKey
+	usage map[string]int64
+}
+
+// NewMemoryQuotaStore creates an empty in-memory quota store
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{keys: map[string]APIKey{}, usage: map[string]int64{}}
+}
+
+func (s *MemoryQuotaStore) SaveKey(_ context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key.Models = slices.Clone(key.Models)
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *MemoryQuotaStore) LoadKey(_ context.Context, id string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	key.Models = slices.Clone(key.Models)
+	return &key, nil
+}
+
+func (s *MemoryQuotaStore) DeleteKey(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, id)
+	return nil
+}
+
+func (s *MemoryQuotaStore) AddUsage(_ context.Context, id, day string, tokens int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage[id+"/"+day] += tokens
+	return s.usage[id+"/"+day], nil
+}
+
+func (s *MemoryQuotaStore) Usage(_ context.Context, id, day string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[id+"/"+day], nil
+}
@@ -0,0 +1,199 @@
+package kit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaManagerKeys(t *testing.T) {
+	ctx := context.Background()
+	m := NewQuotaManager(QuotaConfig{})
+
+	key, secret, err := m.Issue(ctx, APIKey{Name: "search-team", Models: []string{"gpt-4o-mini"}, DailyTokens: 100})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, "gk_"+key.ID+"_"))
+	require.NotContains(t, key.SecretHash, secret)
+
+	authenticated, err := m.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	require.Equal(t, "search-team", authenticated.Name)
+
+	for _, wrong := range []string{"", "gk_" + key.ID + "_guess", secret + "x", strings.TrimPrefix(secret, "gk_")} {
+		_, err = m.Authenticate(ctx, wrong)
+		require.ErrorIs(t, err, ErrInvalidKey, wrong)
+	}
+
+	key.Disabled = true
+	require.NoError(t, m.Update(ctx, key))
+	_, err = m.Authenticate(ctx, secret)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	key.Disabled = false
+	require.NoError(t, m.Update(ctx, key))
+	_, err = m.Authenticate(ctx, secret)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Revoke(ctx, key.ID))
+	_, err = m.Authenticate(ctx, secret)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.ErrorIs(t, m.Update(ctx, key), ErrInvalidKey)
+}
+
+func TestQuotaEnforcedOnAgents(t *testing.T) {
+	ctx := context.Background()
+	quota := NewQuotaManager(QuotaConfig{})
+	now := time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC)
+	quota.now = func() time.Time { return now }
+
+	key, secret, err := quota.Issue(ctx, APIKey{Name: "billing", Models: []string{"test-model"}, DailyTokens: 30})
+	require.NoError(t, err)
+
+	provider := newFakeProvider(t, fakeReply{Content: "one"}, fakeReply{Content: "two"}, fakeReply{Content: "three"})
+	client := provider.client(WithQuota(quota))
+	agent := CreateAgent(client)
+	keyCtx := WithQuotaKey(ctx, secret)
+
+	// every reply of the fake provider uses 15 tokens
+	_, err = agent.InvokeSimple(keyCtx, "hi")
+	require.NoError(t, err)
+	_, err = agent.InvokeSimple(keyCtx, "hi")
+	require.NoError(t, err)
+
+	used, remaining, err := quota.Usage(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), used)
+	require.Equal(t, int64(0), remaining)
+
+	_, err = agent.InvokeSimple(keyCtx, "hi")
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.Len(t, provider.Requests(), 2)
+
+	// the budget resets the next day
+	now = now.Add(2 * time.Hour)
+	_, err = agent.InvokeSimple(keyCtx, "hi")
+	require.NoError(t, err)
+
+	_, err = CreateAgent(client).WithModel("gpt-4o").InvokeSimple(keyCtx, "hi")
+	require.ErrorIs(t, err, ErrModelNotAllowed)
+
+	_, err = agent.InvokeSimple(WithQuotaKey(ctx, "gk_nope_nope"), "hi")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.Len(t, provider.Requests(), 3)
+}
+
+func TestQuotaRequireKey(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "ok"})
+	agent := CreateAgent(provider.client(WithQuota(NewQuotaManager(QuotaConfig{}))))
+	_, err := agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+
+	agent = CreateAgent(provider.client(WithQuota(NewQuotaManager(QuotaConfig{RequireKey: true}))))
+	_, err = agent.InvokeSimple(context.Background(), "hi")
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestQuotaEnforcedOnOtherClientRequests(t *testing.T) {
+	ctx := context.Background()
+	quota := NewQuotaManager(QuotaConfig{})
+	key, secret, err := quota.Issue(ctx, APIKey{Name: "search", Models: []string{"text-embedding-3-small"}, DailyTokens: 20})
+	require.NoError(t, err)
+
+	provider := newFakeProvider(t, fakeReply{}, fakeReply{})
+	client := provider.client(WithQuota(quota), WithRequestOptions(option.WithMaxRetries(0)))
+	openaiClient := client.GetOpenAI()
+	keyCtx := WithQuotaKey(ctx, secret)
+
+	// embeddings through the client are charged with the usage of their response
+	_, err = openaiClient.Embeddings.New(keyCtx, openai.EmbeddingNewParams{
+		Model: "text-embedding-3-small",
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	require.NoError(t, err)
+	used, _, err := quota.Usage(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(15), used)
+
+	_, err = openaiClient.Embeddings.New(keyCtx, openai.EmbeddingNewParams{
+		Model: "text-embedding-3-large",
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	require.ErrorIs(t, err, ErrModelNotAllowed)
+	_, err = openaiClient.Embeddings.New(WithQuotaKey(ctx, "gk_nope_nope"), openai.EmbeddingNewParams{
+		Model: "text-embedding-3-small",
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.Len(t, provider.Requests(), 1)
+
+	_, err = openaiClient.Embeddings.New(keyCtx, openai.EmbeddingNewParams{
+		Model: "text-embedding-3-small",
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	require.NoError(t, err)
+	_, err = openaiClient.Embeddings.New(keyCtx, openai.EmbeddingNewParams{
+		Model: "text-embedding-3-small",
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.Len(t, provider.Requests(), 2)
+}
+
+func TestQuotaChargesAgentGenerationsOnce(t *testing.T) {
+	ctx := context.Background()
+	quota := NewQuotaManager(QuotaConfig{})
+	key, secret, err := quota.Issue(ctx, APIKey{Name: "billing"})
+	require.NoError(t, err)
+
+	provider := newFakeProvider(t, fakeReply{Content: "one"}, fakeReply{Content: "two"})
+	client := provider.client(WithQuota(quota))
+	_, err = CreateAgent(client).InvokeSimple(WithQuotaKey(ctx, secret), "hi")
+	require.NoError(t, err)
+	_, err = CreateAgent(client).WithPartialOutput(func(string) {}).InvokeSimple(WithQuotaKey(ctx, secret), "hi")
+	require.NoError(t, err)
+
+	used, remaining, err := quota.Usage(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), used)
+	require.Equal(t, int64(-1), remaining)
+}
+
+func TestQuotaCheckedBeforeCachedAnswers(t *testing.T) {
+	ctx := context.Background()
+	quota := NewQuotaManager(QuotaConfig{RequireKey: true})
+	key, secret, err := quota.Issue(ctx, APIKey{Name: "billing", Models: []string{"test-model"}, DailyTokens: 15})
+	require.NoError(t, err)
+	other, otherSecret, err := quota.Issue(ctx, APIKey{Name: "search", Models: []string{"gpt-4o"}})
+	require.NoError(t, err)
+	require.NotEqual(t, key.ID, other.ID)
+
+	provider := newFakeProvider(t, fakeReply{Content: "cached answer"})
+	agent := CreateAgent(provider.client(WithQuota(quota))).WithCache(NewMemoryResponseCache(0), time.Hour)
+
+	out, err := agent.InvokeSimple(WithQuotaKey(ctx, secret), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "cached answer", out)
+
+	// the budget is used up, but a cached answer costs nothing
+	result, err := agent.InvokeWithResult(WithQuotaKey(ctx, secret), InvokeConfig{Prompt: "hi"})
+	require.NoError(t, err)
+	require.True(t, result.Cached)
+
+	_, err = agent.InvokeSimple(ctx, "hi")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = agent.InvokeSimple(WithQuotaKey(ctx, "gk_nope_nope"), "hi")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = agent.InvokeSimple(WithQuotaKey(ctx, otherSecret), "hi")
+	require.ErrorIs(t, err, ErrModelNotAllowed)
+
+	key.Disabled = true
+	require.NoError(t, quota.Update(ctx, key))
+	_, err = agent.InvokeSimple(WithQuotaKey(ctx, secret), "hi")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.Len(t, provider.Requests(), 1)
+}
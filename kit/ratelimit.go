@@ -0,0 +1,216 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// RateLimiter keeps a client under a provider's requests and tokens per minute, so concurrent agents don't trip 429s
+// Requests wait for capacity in arrival order instead of failing. Both limits are token buckets that start full,
+// so a burst of a minute's capacity goes through at once
+// Use a Scheduler instead when requests need priorities, a concurrency cap or a pause after 429s, its
+// SchedulerConfig.TokensPerMinute is this token limit
+// The tokens of a request are estimated before it is sent (prompt size plus max_tokens) and corrected with the
+// usage of its response
+type RateLimiter struct {
+	requests *tokenBucket // nil is unlimited
+	tokens   *tokenBucket // nil is unlimited
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter, a limit of 0 is unlimited
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	return &RateLimiter{
+		requests: newTokenBucket(requestsPerMinute),
+		tokens:   newTokenBucket(tokensPerMinute),
+		now:      time.Now,
+	}
+}
+
+// WithRateLimit limits the client's chat completion requests and tokens per minute, 0 leaves a limit off
+// Every client gets its own limits, share a RateLimiter with WithRateLimiter when clients use the same account
+func WithRateLimit(requestsPerMinute, tokensPerMinute int) ClientOption {
+	return WithRateLimiter(NewRateLimiter(requestsPerMinute, tokensPerMinute))
+}
+
+// WithRateLimiter routes every chat completion request of the client through the limiter
+// It can't be combined with WithScheduler, see ErrLimiterConflict
+func WithRateLimiter(l *RateLimiter) ClientOption {
+	return func(c *Config) {
+		c.RateLimiter = l
+		WithRequestOptions(option.WithMiddleware(l.Middleware()))(c)
+	}
+}
+
+// ErrLimiterConflict fails every request of a client configured with both WithRateLimiter and WithScheduler, which
+// would limit the same requests twice. Set SchedulerConfig.TokensPerMinute instead
+var ErrLimiterConflict = errors.New("WithRateLimiter can't be combined with WithScheduler, " +
+	"set SchedulerConfig.TokensPerMinute instead")
+
+// rejectRequests fails every request with err
+func rejectRequests(err error) option.Middleware {
+	return func(*http.Request, option.MiddlewareNext) (*http.Response, error) {
+		return nil, err
+	}
+}
+
+// Middleware waits for capacity before each chat completion request and records its token usage
+// Requests to other endpoints are passed through untouched
+func (l *RateLimiter) Middleware() option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if !strings.HasSuffix(request.URL.Path, "/chat/completions") || request.Body == nil {
+			return next(request)
+		}
+
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("rate limiter: failed to read request body: %w", err)
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		estimate, stream := estimateRequestTokens(body)
+		if err := l.Wait(request.Context(), estimate); err != nil {
+			return nil, err
+		}
+
+		resp, err := next(request)
+		if err != nil || resp.StatusCode != http.StatusOK || stream {
+			// a failed request keeps its estimate, the provider may have counted it
+			return resp, err
+		}
+
+		payload, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("rate limiter: failed to read response body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(payload))
+
+		var completion struct {
+			Usage struct {
+				TotalTokens int `json:"total_tokens"`
+			} `json:"usage"`
+		}
+		if json.Unmarshal(payload, &completion) == nil && completion.Usage.TotalTokens > 0 {
+			l.Record(estimate, completion.Usage.TotalTokens)
+		}
+		return resp, nil
+	}
+}
+
+// Wait blocks until a request of the given tokens may start, it takes them from the limits even if ctx is done later
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	now := l.now()
+	wait := l.requests.reserve(now, 1)
+	wait = max(wait, l.tokens.reserve(now, float64(tokens)))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the capacity to the requests behind
+		now := l.now()
+		l.requests.refund(now, 1)
+		l.tokens.refund(now, float64(tokens))
+		return ctx.Err()
+	}
+}
+
+// Record corrects the estimated tokens of a request with the tokens it used
+func (l *RateLimiter) Record(estimated, used int) {
+	now := l.now()
+	if used > estimated {
+		l.tokens.reserve(now, float64(used-estimated))
+	} else {
+		l.tokens.refund(now, float64(estimated-used))
+	}
+}
+
+// estimateRequestTokens guesses the tokens a chat completion request will use: about 4 bytes of the body per prompt
+// token, plus the completion tokens it allows
+func estimateRequestTokens(body []byte) (tokens int, stream bool) {
+	var req struct {
+		Stream              bool `json:"stream"`
+		MaxTokens           int  `json:"max_tokens"`
+		MaxCompletionTokens int  `json:"max_completion_tokens"`
+		N                   int  `json:"n"`
+	}
+	_ = json.Unmarshal(body, &req)
+
+	completion := max(req.MaxCompletionTokens, req.MaxTokens)
+	return len(body)/4 + completion*max(req.N, 1), req.Stream
+}
+
+// tokenBucket refills perMinute tokens a minute up to perMinute
+// Reservations may take it below zero, later ones wait until the debt is refilled
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	perSec   float64
+	tokens   float64
+	updated  time.Time
+}
+
+// newTokenBucket returns nil for 0, which never waits
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+	}
+}
+
+// reserve takes n tokens and returns how long to wait until they are refilled
+func (b *tokenBucket) reserve(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refillLocked(now)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.perSec * float64(time.Second))
+}
+
+// refund gives back n tokens, e.g. of a cancelled request or an overestimate
+func (b *tokenBucket) refund(now time.Time, n float64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refillLocked(now)
+	b.tokens = min(b.capacity, b.tokens+n)
+}
+
+func (b *tokenBucket) refillLocked(now time.Time) {
+	if !b.updated.IsZero() && now.After(b.updated) {
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.perSec)
+	}
+	if now.After(b.updated) {
+		b.updated = now
+	}
+}
@@ -0,0 +1,48 @@
+package kit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketReservesInOrder(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(60)
+
+	require.Zero(t, b.reserve(start, 60))
+	require.Equal(t, time.Second, b.reserve(start, 1))
+	require.Equal(t, 2*time.Second, b.reserve(start, 1))
+
+	b.refund(start, 1)
+	require.Equal(t, 2*time.Second, b.reserve(start, 1))
+	require.Zero(t, b.reserve(start.Add(3*time.Second), 1))
+
+	var unlimited *tokenBucket
+	require.Zero(t, unlimited.reserve(start, 1000))
+}
+
+func TestRateLimiterCancelledWaitGivesBack(t *testing.T) {
+	l := NewRateLimiter(1, 0)
+	require.NoError(t, l.Wait(context.Background(), 100))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx, 100), context.DeadlineExceeded)
+	require.InDelta(t, 0, l.requests.tokens, 0.01)
+}
+
+func TestRateLimitRecordsUsage(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hello"})
+	start := time.Now()
+	l := NewRateLimiter(10, 1000)
+	l.now = func() time.Time { return start }
+
+	_, err := CreateAgent(provider.client(WithRateLimiter(l))).InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+
+	require.Equal(t, 9.0, l.requests.tokens)
+	require.Equal(t, 985.0, l.tokens.tokens) // the estimate is replaced by the 15 tokens of the response
+}
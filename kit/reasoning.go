@@ -0,0 +1,37 @@
+package kit
+
+import (
+	"encoding/json"
+
+	"github.com/openai/openai-go"
+)
+
+// reasoningFields are the nonstandard message fields providers return the model's thinking in
+// DeepSeek and xAI use reasoning_content, OpenRouter uses reasoning
+var reasoningFields = []string{"reasoning_content", "reasoning"}
+
+// reasoningContent returns the reasoning returned next to the message content, empty if there is none
+func reasoningContent(message openai.ChatCompletionMessage) string {
+	for _, name := range reasoningFields {
+		field, ok := message.JSON.ExtraFields[name]
+		if !ok {
+			continue
+		}
+		var reasoning string
+		if err := json.Unmarshal([]byte(field.Raw()), &reasoning); err == nil && reasoning != "" {
+			return reasoning
+		}
+	}
+	return ""
+}
+
+// Reasoning returns the reasoning of every LLM call of the run in order, empty for calls without any
+func (r *Result[Output]) Reasoning() []string {
+	reasoning := make([]string, len(r.Completions))
+	for i, completion := range r.Completions {
+		if len(completion.Choices) > 0 {
+			reasoning[i] = reasoningContent(completion.Choices[0].Message)
+		}
+	}
+	return reasoning
+}
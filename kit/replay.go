@@ -0,0 +1,178 @@
+package kit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/openai/openai-go/option"
+)
+
+// ErrReplayExhausted is returned when the agent asks for more completions than were recorded
+var ErrReplayExhausted = errors.New("replay: no recorded responses left")
+
+// ErrReplayDiverged is returned in strict mode when the request no longer matches the recording
+var ErrReplayDiverged = errors.New("replay: request diverged from recorded transcript")
+
+// ReplayStep describes one recorded completion served by a Replayer
+type ReplayStep struct {
+	Index     int
+	Iteration callback.TranscriptIteration
+	// RequestMessages is the number of messages the agent actually sent for this step
+	RequestMessages int
+}
+
+// Replayer serves chat completions from a recorded RunTranscript instead of calling the provider
+// Tools still execute locally, so the agent loop re-runs deterministically against recorded LLM decisions
+type Replayer struct {
+	transcript *callback.RunTranscript
+
+	// Strict makes the replay fail with ErrReplayDiverged when the agent sends a different
+	// number of messages or a different model than recorded
+	Strict bool
+
+	// OnStep is called before each recorded response is served, allowing step-through debugging
+	OnStep func(step ReplayStep)
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewReplayer creates a replayer for the given transcript
+func NewReplayer(transcript *callback.RunTranscript) *Replayer {
+	return &Replayer{transcript: transcript}
+}
+
+// Remaining returns the number of recorded responses not yet served
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.transcript.Iterations) - r.next
+}
+
+// Reset rewinds the replayer to the first recorded response
+func (r *Replayer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next = 0
+}
+
+// Middleware returns an openai-go middleware that answers chat completion requests from the transcript
+// Requests to other endpoints are passed through untouched
+func (r *Replayer) Middleware() option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if !strings.HasSuffix(request.URL.Path, "/chat/completions") {
+			return next(request)
+		}
+
+		var body struct {
+			Model    string            `json:"model"`
+			Messages []json.RawMessage `json:"messages"`
+		}
+		if request.Body != nil {
+			bodyBytes, err := io.ReadAll(request.Body)
+			if err != nil {
+				return nil, fmt.Errorf("replay: failed to read request body: %w", err)
+			}
+			if err := json.Unmarshal(bodyBytes, &body); err != nil {
+				return nil, fmt.Errorf("replay: failed to parse request body: %w", err)
+			}
+		}
+
+		r.mu.Lock()
+		if r.next >= len(r.transcript.Iterations) {
+			r.mu.Unlock()
+			return nil, ErrReplayExhausted
+		}
+		index := r.next
+		iteration := r.transcript.Iterations[index]
+
+		// a diverged request keeps its step, so a retry of it fails the same way
+		if r.Strict {
+			if len(body.Messages) != len(iteration.Messages) {
+				r.mu.Unlock()
+				return nil, fmt.Errorf("%w: step %d sent %d messages, recorded %d",
+					ErrReplayDiverged, index, len(body.Messages), len(iteration.Messages))
+			}
+			if iteration.Model != "" && body.Model != iteration.Model {
+				r.mu.Unlock()
+				return nil, fmt.Errorf("%w: step %d used model %s, recorded %s",
+					ErrReplayDiverged, index, body.Model, iteration.Model)
+			}
+		}
+		r.next++
+		r.mu.Unlock()
+
+		if r.OnStep != nil {
+			r.OnStep(ReplayStep{
+				Index:           index,
+				Iteration:       iteration,
+				RequestMessages: len(body.Messages),
+			})
+		}
+
+		payload, err := json.Marshal(r.completionFor(index, iteration))
+		if err != nil {
+			return nil, fmt.Errorf("replay: failed to encode recorded completion: %w", err)
+		}
+
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(payload)),
+			ContentLength: int64(len(payload)),
+			Request:       request,
+		}, nil
+	}
+}
+
+// completionFor rebuilds a chat completion response body from a recorded iteration
+func (r *Replayer) completionFor(index int, iteration callback.TranscriptIteration) map[string]any {
+	message := map[string]any{
+		"role":    "assistant",
+		"content": iteration.Content,
+	}
+	if len(iteration.ToolCalls) > 0 {
+		message["tool_calls"] = iteration.ToolCalls
+	}
+
+	finishReason := iteration.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+
+	completion := map[string]any{
+		"id":      fmt.Sprintf("replay-%s-%d", r.transcript.RunID, index),
+		"object":  "chat.completion",
+		"created": iteration.StartedAt.Unix(),
+		"model":   iteration.Model,
+		"choices": []any{
+			map[string]any{
+				"index":         0,
+				"finish_reason": finishReason,
+				"message":       message,
+			},
+		},
+	}
+	if iteration.Usage != nil {
+		completion["usage"] = iteration.Usage
+	}
+
+	return completion
+}
+
+// WithReplay makes the client answer chat completions from the given replayer instead of the provider
+// The SDK's retries are turned off, a replayed request fails the same way every time
+func WithReplay(replayer *Replayer) ClientOption {
+	return WithRequestOptions(option.WithMaxRetries(0), option.WithMiddleware(replayer.Middleware()))
+}
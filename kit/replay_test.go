@@ -0,0 +1,79 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/stretchr/testify/require"
+)
+
+// recordGreeting records a run that calls the greet tool once and then answers
+func recordGreeting(t *testing.T) *callback.RunTranscript {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada"}`}}},
+		fakeReply{Content: "I greeted Ada"},
+	)
+	recorder := callback.NewTranscriptCallback()
+
+	answer, err := CreateAgent(provider.client(), &greetTool{greeting: "Hello"}).Invoke(context.Background(), InvokeConfig{
+		Prompt:    "greet Ada",
+		Callbacks: []callback.AgentCallback{recorder},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "I greeted Ada", answer)
+	return recorder.Transcript()
+}
+
+func TestReplayServesRecordedCompletions(t *testing.T) {
+	transcript := recordGreeting(t)
+	require.Len(t, transcript.Iterations, 2)
+
+	// the provider has no replies left, every completion must come from the transcript
+	provider := newFakeProvider(t)
+	replayer := NewReplayer(transcript)
+	replayer.Strict = true
+	var steps []ReplayStep
+	replayer.OnStep = func(step ReplayStep) { steps = append(steps, step) }
+
+	tool := &greetTool{greeting: "Hi"}
+	agent := CreateAgent(provider.client(WithReplay(replayer)), tool)
+	answer, err := agent.InvokeSimple(context.Background(), "greet Ada")
+	require.NoError(t, err)
+	require.Equal(t, "I greeted Ada", answer)
+	require.Empty(t, provider.Requests())
+	require.Zero(t, replayer.Remaining())
+
+	require.Len(t, steps, 2)
+	require.Equal(t, 0, steps[0].Index)
+	require.Equal(t, "greet", steps[0].Iteration.ToolCalls[0].Function.Name)
+	require.Equal(t, steps[1].Iteration.Messages, transcript.Iterations[1].Messages)
+	require.Equal(t, 3, steps[1].RequestMessages, "the tool ran again locally")
+
+	_, err = agent.InvokeSimple(context.Background(), "greet Ada")
+	require.ErrorIs(t, err, ErrReplayExhausted)
+
+	replayer.Reset()
+	require.Equal(t, 2, replayer.Remaining())
+}
+
+func TestReplayStrictDetectsDivergence(t *testing.T) {
+	transcript := recordGreeting(t)
+	provider := newFakeProvider(t)
+
+	replayer := NewReplayer(transcript)
+	replayer.Strict = true
+	diverged := InvokeConfig{Prompt: "greet Ada", SystemPrompt: "be brief"}
+	agent := CreateAgent(provider.client(WithReplay(replayer)), &greetTool{greeting: "Hello"})
+	_, err := agent.Invoke(context.Background(), diverged)
+	require.ErrorIs(t, err, ErrReplayDiverged)
+	require.Equal(t, 2, replayer.Remaining(), "a diverged request doesn't use up its step")
+
+	// without Strict the recorded decisions are served anyway
+	replayer = NewReplayer(transcript)
+	agent = CreateAgent(provider.client(WithReplay(replayer)), &greetTool{greeting: "Hello"})
+	answer, err := agent.Invoke(context.Background(), diverged)
+	require.NoError(t, err)
+	require.Equal(t, "I greeted Ada", answer)
+	require.Empty(t, provider.Requests())
+}
@@ -0,0 +1,112 @@
+package kit
+
+import (
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// Result is the outcome of InvokeWithResult
+type Result[Output any] struct {
+	Output Output
+
+	// Dialogue is the user-visible conversation: user messages and assistant answers without tool calls
+	// Store it in memory or render it in a UI
+	Dialogue []openai.ChatCompletionMessageParamUnion
+
+	// Scratchpad is the internal transcript: assistant tool calls and tool results in order
+	Scratchpad []openai.ChatCompletionMessageParamUnion
+
+	// Messages is the full conversation sent to the model, including system prompts and the scratchpad
+	Messages []openai.ChatCompletionMessageParamUnion
+
+	// Iterations is the number of LLM calls made
+	Iterations int
+
+	// Completions are the raw provider responses in order, one per LLM call
+	// Fields the SDK doesn't know (e.g. OpenRouter's provider) are in JSON.ExtraFields, the body in RawJSON()
+	Completions []*openai.ChatCompletion
+
+	// Latencies are the round trips of Completions measured by the client
+	Latencies []time.Duration
+
+	// Cached is true when the output came from the agent's response cache, without calling the model
+	Cached bool
+
+	// Candidates are the valid choices of the final answer with WithN, starting with the first choice
+	// Output is the first one, or the best one with WithCandidateScorer
+	Candidates []Output
+
+	// Scores are the scores of Candidates with WithCandidateScorer
+	Scores []float64
+
+	pricing PricingTable // set by WithPricing, see Cost
+	model   string       // the requested model, prices completions whose model isn't in pricing
+}
+
+// Raw returns the last provider response, nil if no LLM call succeeded
+func (r *Result[Output]) Raw() *openai.ChatCompletion {
+	if len(r.Completions) == 0 {
+		return nil
+	}
+	return r.Completions[len(r.Completions)-1]
+}
+
+// Usage is the token usage of a run, summed over its LLM calls
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	CachedTokens     int64 `json:"cached_tokens"`    // prompt tokens read from the provider's prompt cache
+	ReasoningTokens  int64 `json:"reasoning_tokens"` // completion tokens spent on reasoning
+}
+
+// Usage sums the token usage of Completions, it is zero for cached results
+// Failed runs return the usage of the calls made before the error
+func (r *Result[Output]) Usage() Usage {
+	var usage Usage
+	for _, completion := range r.Completions {
+		usage.PromptTokens += completion.Usage.PromptTokens
+		usage.CompletionTokens += completion.Usage.CompletionTokens
+		usage.TotalTokens += completion.Usage.TotalTokens
+		usage.CachedTokens += completion.Usage.PromptTokensDetails.CachedTokens
+		usage.ReasoningTokens += completion.Usage.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+// FinishReason returns why the last LLM call stopped, e.g. "stop", "length" or "content_filter"
+// It is empty when no call succeeded
+func (r *Result[Output]) FinishReason() string {
+	raw := r.Raw()
+	if raw == nil || len(raw.Choices) == 0 {
+		return ""
+	}
+	return raw.Choices[0].FinishReason
+}
+
+// Model returns the model of the last LLM call as reported by the provider, which may be a dated version of the
+// requested alias or, behind a router, another model altogether. It is empty when no call succeeded
+func (r *Result[Output]) Model() string {
+	if raw := r.Raw(); raw != nil {
+		return raw.Model
+	}
+	return ""
+}
+
+// splitDialogue separates user-visible messages from the tool calling scratchpad, system prompts are dropped
+func splitDialogue(messages []openai.ChatCompletionMessageParamUnion) (dialogue, scratchpad []openai.ChatCompletionMessageParamUnion) {
+	for _, m := range messages {
+		switch {
+		case m.OfSystem != nil, m.OfDeveloper != nil:
+			continue
+		case m.OfTool != nil, m.OfFunction != nil:
+			scratchpad = append(scratchpad, m)
+		case m.OfAssistant != nil && len(m.OfAssistant.ToolCalls) > 0:
+			scratchpad = append(scratchpad, m)
+		default:
+			dialogue = append(dialogue, m)
+		}
+	}
+	return dialogue, scratchpad
+}
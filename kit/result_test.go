@@ -0,0 +1,128 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDialogue(t *testing.T) {
+	toolCall := openai.ChatCompletionMessage{
+		Role: "assistant",
+		ToolCalls: []openai.ChatCompletionMessageToolCall{{
+			ID:       "call-1",
+			Function: openai.ChatCompletionMessageToolCallFunction{Name: "search", Arguments: `{}`},
+		}},
+	}
+	answer := openai.ChatCompletionMessage{Role: "assistant", Content: "It's sunny"}
+
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("be nice"),
+		openai.UserMessage("weather?"),
+		toolCall.ToParam(),
+		openai.ToolMessage("sunny", "call-1"),
+		answer.ToParam(),
+	}
+
+	dialogue, scratchpad := splitDialogue(messages)
+	require.Len(t, dialogue, 2)
+	require.NotNil(t, dialogue[0].OfUser)
+	require.Equal(t, "It's sunny", dialogue[1].OfAssistant.Content.OfString.Value)
+
+	require.Len(t, scratchpad, 2)
+	require.NotNil(t, scratchpad[0].OfAssistant)
+	require.NotNil(t, scratchpad[1].OfTool)
+}
+
+func TestResultKeepsRawCompletions(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: `{"average":2}`, Extra: map[string]any{"provider": "Groq", "system_fingerprint": "fp_1"}},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{})
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 1 and 3?"})
+	require.NoError(t, err)
+
+	require.Len(t, result.Completions, 2)
+	raw := result.Raw()
+	require.Equal(t, "chatcmpl-test", raw.ID)
+	require.Equal(t, "fp_1", raw.SystemFingerprint)
+	require.Equal(t, `"Groq"`, raw.JSON.ExtraFields["provider"].Raw())
+}
+
+func TestResultUsageAndMetadata(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "average_numbers", Arguments: `{"numbers":[1,3]}`}}},
+		fakeReply{Content: `{"average":2}`, Extra: map[string]any{
+			"model": "test-model-2024-08-06",
+			"usage": map[string]any{
+				"prompt_tokens": 40, "completion_tokens": 12, "total_tokens": 52,
+				"prompt_tokens_details":     map[string]any{"cached_tokens": 32},
+				"completion_tokens_details": map[string]any{"reasoning_tokens": 8},
+			},
+		}},
+	)
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client(), &averageTool{})
+	result, err := agent.InvokeWithResult(context.Background(), InvokeConfig{Prompt: "average of 1 and 3?"})
+	require.NoError(t, err)
+
+	require.Equal(t, Usage{PromptTokens: 50, CompletionTokens: 17, TotalTokens: 67, CachedTokens: 32, ReasoningTokens: 8},
+		result.Usage())
+	require.Equal(t, "stop", result.FinishReason())
+	require.Equal(t, "test-model-2024-08-06", result.Model())
+
+	var empty Result[string]
+	require.Equal(t, Usage{}, empty.Usage())
+	require.Empty(t, empty.FinishReason())
+	require.Empty(t, empty.Model())
+}
+
+func TestResultLogprobs(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{
+		Content: "Yes",
+		Logprobs: map[string]any{"content": []map[string]any{
+			{"token": "Yes", "logprob": -0.1, "bytes": []int{89, 101, 115}, "top_logprobs": []map[string]any{
+				{"token": "Yes", "logprob": -0.1, "bytes": []int{89, 101, 115}},
+				{"token": "No", "logprob": -2.4, "bytes": []int{78, 111}},
+			}},
+		}},
+	})
+
+	result, err := CreateAgent(provider.client()).WithTopLogprobs(2).InvokeWithResult(context.Background(), InvokeConfig{Prompt: "Is it?"})
+	require.NoError(t, err)
+
+	request := provider.Requests()[0]
+	require.Equal(t, true, request["logprobs"])
+	require.Equal(t, float64(2), request["top_logprobs"])
+
+	require.Equal(t, []TokenLogprob{{
+		Token:   "Yes",
+		Logprob: -0.1,
+		Top:     []TopLogprob{{Token: "Yes", Logprob: -0.1}, {Token: "No", Logprob: -2.4}},
+	}}, result.Logprobs())
+	require.InDelta(t, math.Exp(-0.1), result.Confidence(), 1e-9)
+
+	// without logprobs
+	provider = newFakeProvider(t, fakeReply{Content: "Yes"})
+	result, err = CreateAgent(provider.client()).InvokeWithResult(context.Background(), InvokeConfig{Prompt: "Is it?"})
+	require.NoError(t, err)
+	require.NotContains(t, provider.Requests()[0], "logprobs")
+	require.Nil(t, result.Logprobs())
+	require.Zero(t, result.Confidence())
+}
+
+func TestInvokeReturnsZeroOutputOnError(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: `{"average":2}`})
+	reject := func(context.Context, string, string) (string, error) { return "", errors.New("not allowed") }
+
+	agent := CreateAgentWithOutput[averageAnswer](provider.client()).WithOutputGuards(reject)
+	output, err := agent.Invoke(context.Background(), InvokeConfig{Prompt: "average of 1 and 3?"})
+	require.ErrorContains(t, err, "not allowed")
+	require.Equal(t, averageAnswer{}, output)
+}
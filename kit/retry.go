@@ -0,0 +1,181 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// RetryPolicy decides which failed LLM requests are sent again and how long to wait in between
+// It replaces the SDK's built-in retries (2 retries of 408, 409, 429 and 5xx responses)
+type RetryPolicy struct {
+	// Retries sends a failed request up to this many times again, 0 never retries
+	Retries int
+
+	// Backoff is the wait before the first retry, doubled for every further one with up to 50% jitter
+	// (optional, defaults to 500ms)
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between attempts. A Retry-After longer than this ends the retries instead
+	// (optional, defaults to 30s)
+	MaxBackoff time.Duration
+
+	// Retryable reports whether a failed attempt is worth a retry, resp is nil when err is set
+	// (optional, defaults to DefaultRetryable)
+	Retryable func(resp *http.Response, err error) bool
+}
+
+// DefaultRetryable retries transport errors and 408, 409, 429 and 5xx responses, and follows the x-should-retry header
+// Client errors such as a 400 for an invalid schema are never retried, nor are cancelled requests, an open circuit
+// or requests refused by the quota
+func DefaultRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrInvalidKey) &&
+			!errors.Is(err, ErrModelNotAllowed) && !errors.Is(err, ErrQuotaExceeded)
+	}
+	switch resp.Header.Get("x-should-retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusConflict ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// WithRetryPolicy retries the client's requests with the policy instead of the SDK's retries
+// Middlewares added after it, such as WithCircuitBreaker, see every attempt
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Config) {
+		c.RetryPolicy = &policy
+		WithRequestOptions(option.WithMaxRetries(0), option.WithMiddleware(policy.middleware(true)))(c)
+	}
+}
+
+// WithRetryPolicy retries the agent's LLM calls with the policy instead of the client's
+// The agent's policy runs where the client's would: after the middlewares added before WithRetryPolicy, or before
+// every middleware of a client without one, so a circuit breaker, rate limiter or scheduler sees the same attempts
+func (a *Agent[Output]) WithRetryPolicy(policy RetryPolicy) *Agent[Output] {
+	a.retryPolicy = &policy
+	return a
+}
+
+type retryOverrideKey struct{}
+
+// retryOptions returns the request options of an LLM call of the agent, ctx carries the agent's policy to the
+// client's retry middleware
+func (a *Agent[Output]) retryOptions(ctx context.Context) (context.Context, []option.RequestOption) {
+	if a.retryPolicy == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, retryOverrideKey{}, a.retryPolicy), []option.RequestOption{option.WithMaxRetries(0)}
+}
+
+// agentRetryMiddleware runs the retry policy of an agent for a client without its own, it's the outermost middleware
+func agentRetryMiddleware(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	policy, _ := request.Context().Value(retryOverrideKey{}).(*RetryPolicy)
+	if policy == nil {
+		return next(request)
+	}
+	return policy.middleware(false)(request, next)
+}
+
+// Middleware sends failed requests again according to the policy, turn off the SDK's retries with
+// option.WithMaxRetries(0) when using it directly
+func (p RetryPolicy) Middleware() option.Middleware {
+	return p.middleware(false)
+}
+
+// middleware of a client runs the policy of an agent with its own instead
+func (p RetryPolicy) middleware(client bool) option.Middleware {
+	if p.Backoff <= 0 {
+		p.Backoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Retryable == nil {
+		p.Retryable = DefaultRetryable
+	}
+
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		ctx := request.Context()
+		if override, _ := ctx.Value(retryOverrideKey{}).(*RetryPolicy); override != nil && client {
+			return override.middleware(false)(request, next)
+		}
+		if p.Retries <= 0 {
+			return next(request)
+		}
+
+		var body []byte
+		if request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(request.Body); err != nil {
+				return nil, fmt.Errorf("retry policy: failed to read request body: %w", err)
+			}
+		}
+
+		for attempt := 0; ; attempt++ {
+			if body != nil {
+				request.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			resp, err := next(request)
+			if err != nil {
+				resp = nil
+			}
+			if attempt >= p.Retries || ctx.Err() != nil || !p.Retryable(resp, err) {
+				return resp, err
+			}
+
+			wait, ok := p.wait(attempt, resp)
+			if !ok {
+				return resp, err
+			}
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// wait returns the wait before the retry after attempt, ok is false when the provider asks for more than MaxBackoff
+func (p RetryPolicy) wait(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if ms, err := strconv.ParseFloat(resp.Header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+			d := time.Duration(ms * float64(time.Millisecond))
+			return d, d <= p.MaxBackoff
+		}
+		if header := resp.Header.Get("Retry-After"); header != "" {
+			if d := retryAfter(header, 0); d > 0 {
+				return d, d <= p.MaxBackoff
+			}
+		}
+	}
+
+	backoff := p.MaxBackoff
+	if attempt < 32 {
+		backoff = min(p.Backoff<<attempt, p.MaxBackoff)
+	}
+	// full backoff minus up to half of it, so concurrent clients don't retry in lockstep
+	return backoff - time.Duration(rand.Int64N(int64(backoff)/2+1)), true
+}
@@ -0,0 +1,106 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyRetriesTransientFailures(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{Status: http.StatusInternalServerError, Body: `{"error":{"message":"down"}}`},
+		fakeReply{Status: http.StatusTooManyRequests, Body: `{"error":{"message":"slow down"}}`},
+		fakeReply{Content: "ok"},
+	)
+	client := provider.client(WithRetryPolicy(RetryPolicy{Retries: 2, Backoff: time.Millisecond}))
+
+	answer, err := CreateAgent(client).InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "ok", answer)
+	require.Len(t, provider.Requests(), 3)
+}
+
+func TestRetryPolicySkipsClientErrors(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Status: http.StatusBadRequest, Body: `{"error":{"message":"invalid schema"}}`})
+	client := provider.client(WithRetryPolicy(RetryPolicy{Retries: 3, Backoff: time.Millisecond}))
+
+	_, err := CreateAgent(client).InvokeSimple(context.Background(), "hi")
+	require.ErrorContains(t, err, "invalid schema")
+	require.Len(t, provider.Requests(), 1)
+}
+
+func TestAgentRetryPolicyReplacesClientPolicy(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Status: http.StatusInternalServerError, Body: `{"error":{"message":"down"}}`})
+	client := provider.client(WithRetryPolicy(RetryPolicy{Retries: 3, Backoff: time.Millisecond}))
+
+	_, err := CreateAgent(client).WithRetryPolicy(RetryPolicy{}).InvokeSimple(context.Background(), "hi")
+	require.Error(t, err)
+	require.Len(t, provider.Requests(), 1)
+}
+
+// countingMiddleware counts the requests passing through it
+func countingMiddleware(count *atomic.Int32) option.RequestOption {
+	return option.WithMiddleware(func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		count.Add(1)
+		return next(request)
+	})
+}
+
+func TestAgentRetryPolicyRunsWhereClientPolicyWould(t *testing.T) {
+	down := fakeReply{Status: http.StatusInternalServerError, Body: `{"error":{"message":"down"}}`}
+
+	// without a client policy the agent's retries run around every middleware
+	provider := newFakeProvider(t, down, down, fakeReply{Content: "ok"})
+	var seen atomic.Int32
+	client := provider.client(WithRequestOptions(countingMiddleware(&seen)))
+	answer, err := CreateAgent(client).WithRetryPolicy(RetryPolicy{Retries: 2, Backoff: time.Millisecond}).
+		InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "ok", answer)
+	require.EqualValues(t, 3, seen.Load(), "middlewares see every attempt")
+
+	// with one, the agent's retries replace it at its position
+	provider = newFakeProvider(t, down, down)
+	var before, after atomic.Int32
+	client = provider.client(
+		WithRequestOptions(countingMiddleware(&before)),
+		WithRetryPolicy(RetryPolicy{Retries: 5, Backoff: time.Millisecond}),
+		WithRequestOptions(countingMiddleware(&after)),
+	)
+	_, err = CreateAgent(client).WithRetryPolicy(RetryPolicy{Retries: 1, Backoff: time.Millisecond}).
+		InvokeSimple(context.Background(), "hi")
+	require.ErrorContains(t, err, "down")
+	require.Len(t, provider.Requests(), 2)
+	require.EqualValues(t, 1, before.Load(), "middlewares added before the policy see one outcome per call")
+	require.EqualValues(t, 2, after.Load(), "middlewares added after the policy see every attempt")
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second} {
+		wait, ok := policy.wait(attempt, nil)
+		require.True(t, ok)
+		require.GreaterOrEqual(t, wait, want/2)
+		require.LessOrEqual(t, wait, want)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	wait, ok := policy.wait(0, resp)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, wait)
+
+	resp.Header.Set("Retry-After", "60")
+	_, ok = policy.wait(0, resp)
+	require.False(t, ok)
+
+	require.False(t, DefaultRetryable(nil, context.Canceled))
+	require.False(t, DefaultRetryable(nil, ErrCircuitOpen))
+	require.True(t, DefaultRetryable(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+}
@@ -0,0 +1,131 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Route is a downstream agent a Router can pick
+type Route[Output any] struct {
+	// Name identifies the route, the classifier answers with it
+	Name string
+	// Description tells the classifier which requests belong on this route
+	Description string
+	// Agent handles the routed requests, e.g. with a small or large model
+	Agent *Agent[Output]
+}
+
+// RouteDecision is the classifier's choice
+type RouteDecision struct {
+	Route  string `json:"route" jsonschema:"description=Name of the route that should handle the request"`
+	Reason string `json:"reason" jsonschema:"description=One short sentence explaining the choice"`
+}
+
+// Router classifies a request with a cheap model and hands it to the agent of the chosen route
+// The decision is attached to every span of the routed run as router.route and router.reason
+type Router[Output any] struct {
+	classifier *Agent[RouteDecision]
+	routes     []Route[Output]
+	fallback   string
+}
+
+// NewRouter creates a router that classifies requests with the given model
+// The first route is the fallback when the classifier answers with an unknown route
+func NewRouter[Output any](client *Client, classifierModel string, routes ...Route[Output]) *Router[Output] {
+	classifier := CreateAgentWithOutput[RouteDecision](client).
+		WithModel(classifierModel).
+		WithGenerationName(GenerationName("router.classify"))
+
+	r := &Router[Output]{
+		classifier: classifier,
+		routes:     routes,
+	}
+	if len(routes) > 0 {
+		r.fallback = routes[0].Name
+	}
+	return r
+}
+
+// WithFallback sets the route used when the classifier answers with an unknown route
+func (r *Router[Output]) WithFallback(name string) *Router[Output] {
+	r.fallback = name
+	return r
+}
+
+// Classifier returns the classifier agent, e.g. to set its temperature or callbacks
+func (r *Router[Output]) Classifier() *Agent[RouteDecision] {
+	return r.classifier
+}
+
+// Route classifies the request without running it
+func (r *Router[Output]) Route(ctx context.Context, config InvokeConfig) (RouteDecision, error) {
+	if len(r.routes) == 0 {
+		return RouteDecision{}, fmt.Errorf("router has no routes")
+	}
+
+	decision, err := r.classifier.Invoke(ctx, InvokeConfig{
+		Prompt:       config.Prompt,
+		Messages:     config.Messages,
+		Callbacks:    config.Callbacks,
+		ParentRunID:  config.ParentRunID,
+		SystemPrompt: r.instructions(),
+	})
+	if err != nil {
+		return RouteDecision{}, fmt.Errorf("failed to classify request: %w", err)
+	}
+
+	if _, ok := r.find(decision.Route); !ok {
+		decision = RouteDecision{
+			Route:  r.fallback,
+			Reason: fmt.Sprintf("fallback: classifier chose unknown route %q", decision.Route),
+		}
+	}
+	return decision, nil
+}
+
+// Invoke classifies the request and runs it on the chosen route
+func (r *Router[Output]) Invoke(ctx context.Context, config InvokeConfig) (Output, RouteDecision, error) {
+	var zero Output
+
+	decision, err := r.Route(ctx, config)
+	if err != nil {
+		return zero, decision, err
+	}
+
+	route, ok := r.find(decision.Route)
+	if !ok {
+		return zero, decision, fmt.Errorf("fallback route %q not found", decision.Route)
+	}
+
+	ctx = callback.WithAttributes(ctx,
+		attribute.String("router.route", decision.Route),
+		attribute.String("router.reason", decision.Reason),
+	)
+	output, err := route.Agent.Invoke(ctx, config)
+	return output, decision, err
+}
+
+// find returns the route with the given name
+func (r *Router[Output]) find(name string) (Route[Output], bool) {
+	for _, route := range r.routes {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return Route[Output]{}, false
+}
+
+// instructions builds the classifier system prompt listing every route
+func (r *Router[Output]) instructions() string {
+	var b strings.Builder
+	b.WriteString("Classify the user's request and pick the route that should handle it.\n")
+	b.WriteString("Answer with the exact route name.\n\nRoutes:\n")
+	for _, route := range r.routes {
+		fmt.Fprintf(&b, "- %s: %s\n", route.Name, route.Description)
+	}
+	return b.String()
+}
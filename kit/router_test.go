@@ -0,0 +1,83 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter(t *testing.T) {
+	cases := []struct {
+		name     string
+		decision string
+		fallback string
+		want     RouteDecision
+		wantErr  string
+	}{
+		{
+			name:     "chosen route",
+			decision: `{"route":"code","reason":"asks for a function"}`,
+			want:     RouteDecision{Route: "code", Reason: "asks for a function"},
+		},
+		{
+			name:     "unknown route falls back to the first route",
+			decision: `{"route":"poetry","reason":"wants a poem"}`,
+			want:     RouteDecision{Route: "chat", Reason: `fallback: classifier chose unknown route "poetry"`},
+		},
+		{
+			name:     "unknown route falls back to WithFallback",
+			decision: `{"route":"poetry","reason":"wants a poem"}`,
+			fallback: "code",
+			want:     RouteDecision{Route: "code", Reason: `fallback: classifier chose unknown route "poetry"`},
+		},
+		{
+			name:     "missing fallback route",
+			decision: `{"route":"poetry","reason":"wants a poem"}`,
+			fallback: "math",
+			want:     RouteDecision{Route: "math", Reason: `fallback: classifier chose unknown route "poetry"`},
+			wantErr:  `fallback route "math" not found`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			replies := []fakeReply{{Content: tc.decision}}
+			if tc.wantErr == "" {
+				replies = append(replies, fakeReply{Content: "answer from " + tc.want.Route})
+			}
+			provider := newFakeProvider(t, replies...)
+			client := provider.client()
+
+			router := NewRouter(client, "small-model",
+				Route[string]{Name: "chat", Description: "small talk", Agent: CreateAgent(client).WithModel("chat-model")},
+				Route[string]{Name: "code", Description: "programming", Agent: CreateAgent(client).WithModel("code-model")},
+			)
+			if tc.fallback != "" {
+				router.WithFallback(tc.fallback)
+			}
+
+			output, decision, err := router.Invoke(context.Background(), InvokeConfig{Prompt: "write a sort function"})
+			require.Equal(t, tc.want, decision)
+			requests := provider.Requests()
+			require.Equal(t, "small-model", requests[0]["model"])
+			require.Contains(t, requests[0]["messages"].([]any)[0].(map[string]any)["content"], "- code: programming")
+
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				require.Len(t, requests, 1)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "answer from "+tc.want.Route, output)
+			require.Equal(t, tc.want.Route+"-model", requests[1]["model"])
+		})
+	}
+}
+
+func TestRouterWithoutRoutes(t *testing.T) {
+	provider := newFakeProvider(t)
+	_, _, err := NewRouter[string](provider.client(), "small-model").Invoke(context.Background(), InvokeConfig{Prompt: "hi"})
+	require.ErrorContains(t, err, "router has no routes")
+	require.Empty(t, provider.Requests())
+}
@@ -0,0 +1,277 @@
+package kit
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// Priority orders requests waiting in a Scheduler, higher runs first
+type Priority int
+
+const (
+	PriorityLow      Priority = -1
+	PriorityNormal   Priority = 0
+	PriorityHigh     Priority = 1
+	PriorityCritical Priority = 2 // e.g. nodes on the critical path of a workflow
+)
+
+type priorityKey struct{}
+
+// WithPriority sets the scheduling priority of requests made with ctx
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, defaults to PriorityNormal
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// SchedulerConfig configures a Scheduler
+type SchedulerConfig struct {
+	// MaxConcurrent is the number of requests in flight at once (defaults to 4)
+	MaxConcurrent int
+
+	// RequestsPerMinute spaces out request starts (optional, defaults to unlimited)
+	RequestsPerMinute int
+
+	// TokensPerMinute keeps chat completion requests under the tokens per minute like a RateLimiter, once they got a
+	// slot (optional, defaults to unlimited)
+	TokensPerMinute int
+
+	// DefaultBackoff pauses the scheduler after a 429 without a Retry-After header (defaults to 1s)
+	DefaultBackoff time.Duration
+}
+
+// SchedulerStats is a snapshot of a Scheduler
+type SchedulerStats struct {
+	Running     int
+	Waiting     int
+	RateLimited int64 // 429 responses seen
+}
+
+// Scheduler shares a provider's rate limits between concurrent agents and workflow branches
+// Requests wait in a priority queue, so critical requests go first instead of every branch racing into 429s
+// A 429 response pauses all requests until its Retry-After has passed
+type Scheduler struct {
+	config   SchedulerConfig
+	interval time.Duration
+	tokens   *RateLimiter // nil without TokensPerMinute
+
+	mu          sync.Mutex
+	running     int
+	waiters     waiterHeap
+	seq         uint64
+	nextSlot    time.Time
+	pausedUntil time.Time
+	timer       *time.Timer
+	rateLimited int64
+}
+
+// NewScheduler creates a new request scheduler
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 4
+	}
+	if config.DefaultBackoff <= 0 {
+		config.DefaultBackoff = time.Second
+	}
+
+	s := &Scheduler{config: config}
+	if config.RequestsPerMinute > 0 {
+		s.interval = time.Minute / time.Duration(config.RequestsPerMinute)
+	}
+	if config.TokensPerMinute > 0 {
+		s.tokens = NewRateLimiter(0, config.TokensPerMinute)
+	}
+	return s
+}
+
+// WithScheduler routes every request of the client through the scheduler
+// Share one scheduler between clients that use the same provider account. It can't be combined with
+// WithRateLimiter, see ErrLimiterConflict
+func WithScheduler(s *Scheduler) ClientOption {
+	return func(c *Config) {
+		c.Scheduler = s
+		WithRequestOptions(option.WithMiddleware(s.Middleware()))(c)
+	}
+}
+
+// Middleware waits for a slot before each request and pauses the scheduler on 429 responses
+func (s *Scheduler) Middleware() option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		release, err := s.Acquire(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		var resp *http.Response
+		if s.tokens != nil {
+			resp, err = s.tokens.Middleware()(req, next)
+		} else {
+			resp, err = next(req)
+		}
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			s.pause(retryAfter(resp.Header.Get("Retry-After"), s.config.DefaultBackoff))
+		}
+		return resp, err
+	}
+}
+
+// Acquire blocks until the request may start and returns the function that frees its slot
+func (s *Scheduler) Acquire(ctx context.Context) (func(), error) {
+	w := &schedWaiter{priority: PriorityFromContext(ctx), ready: make(chan struct{})}
+
+	s.mu.Lock()
+	w.seq = s.seq
+	s.seq++
+	heap.Push(&s.waiters, w)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.running--
+			s.dispatchLocked()
+			s.mu.Unlock()
+		})
+	}
+
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.index >= 0 {
+			heap.Remove(&s.waiters, w.index)
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		s.mu.Unlock()
+
+		// Granted while being cancelled
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the scheduler
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SchedulerStats{
+		Running:     s.running,
+		Waiting:     len(s.waiters),
+		RateLimited: s.rateLimited,
+	}
+}
+
+// pause holds every waiting request for d
+func (s *Scheduler) pause(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimited++
+	if until := time.Now().Add(d); until.After(s.pausedUntil) {
+		s.pausedUntil = until
+	}
+}
+
+// dispatchLocked grants free slots to the highest priority waiters
+func (s *Scheduler) dispatchLocked() {
+	for len(s.waiters) > 0 && s.running < s.config.MaxConcurrent {
+		now := time.Now()
+		if wait := max(s.pausedUntil.Sub(now), s.nextSlot.Sub(now)); wait > 0 {
+			s.wakeLocked(wait)
+			return
+		}
+
+		w := heap.Pop(&s.waiters).(*schedWaiter)
+		s.running++
+		if s.interval > 0 {
+			s.nextSlot = now.Add(s.interval)
+		}
+		close(w.ready)
+	}
+}
+
+// wakeLocked dispatches again after d, unless a wake-up is already pending
+func (s *Scheduler) wakeLocked(d time.Duration) {
+	if s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.timer = nil
+		s.dispatchLocked()
+	})
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date
+func retryAfter(header string, fallback time.Duration) time.Duration {
+	if header == "" {
+		return fallback
+	}
+	if secs, err := strconv.ParseFloat(header, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// schedWaiter is a request waiting for a slot
+type schedWaiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int // position in the heap, -1 once granted
+}
+
+// waiterHeap orders waiters by priority, then arrival
+type waiterHeap []*schedWaiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x any) {
+	w := x.(*schedWaiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
@@ -0,0 +1,94 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerRunsHigherPriorityFirst(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxConcurrent: 1})
+
+	release, err := s.Acquire(context.Background())
+	require.NoError(t, err)
+
+	order := make(chan Priority, 3)
+	enqueue := func(p Priority, waiting int) {
+		go func() {
+			r, err := s.Acquire(WithPriority(context.Background(), p))
+			require.NoError(t, err)
+			order <- p
+			r()
+		}()
+		require.Eventually(t, func() bool { return s.Stats().Waiting == waiting }, time.Second, time.Millisecond)
+	}
+	enqueue(PriorityLow, 1)
+	enqueue(PriorityNormal, 2)
+	enqueue(PriorityCritical, 3)
+
+	release()
+	require.Equal(t, PriorityCritical, <-order)
+	require.Equal(t, PriorityNormal, <-order)
+	require.Equal(t, PriorityLow, <-order)
+}
+
+func TestSchedulerCancelledWaiterLeavesQueue(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxConcurrent: 1})
+	release, err := s.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 0, s.Stats().Waiting)
+
+	release()
+	require.Equal(t, 0, s.Stats().Running)
+}
+
+func TestSchedulerPausesOnTooManyRequests(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxConcurrent: 2})
+	mw := s.Middleware()
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+	_, err = mw(req, func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"0.05"}},
+		}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), s.Stats().RateLimited)
+
+	start := time.Now()
+	release, err := s.Acquire(context.Background())
+	require.NoError(t, err)
+	release()
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestSchedulerLimitsTokens(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hello"})
+	s := NewScheduler(SchedulerConfig{TokensPerMinute: 1000})
+	start := time.Now()
+	s.tokens.now = func() time.Time { return start }
+
+	_, err := CreateAgent(provider.client(WithScheduler(s))).InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, 985.0, s.tokens.tokens.tokens) // the estimate is replaced by the 15 tokens of the response
+	require.Nil(t, s.tokens.requests, "requests are limited by the scheduler alone")
+}
+
+func TestSchedulerAndRateLimiterAreRejected(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "hello"})
+	client := provider.client(WithRateLimiter(NewRateLimiter(10, 1000)), WithScheduler(NewScheduler(SchedulerConfig{})))
+
+	_, err := CreateAgent(client).InvokeSimple(context.Background(), "hi")
+	require.ErrorIs(t, err, ErrLimiterConflict)
+	require.Empty(t, provider.Requests())
+}
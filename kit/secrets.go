@@ -0,0 +1,62 @@
+package kit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mhrlife/goai-kit/secrets"
+	"github.com/openai/openai-go/option"
+)
+
+// SecretKey loads the API key from a secrets provider on every request
+type SecretKey struct {
+	Provider secrets.Provider
+	Name     string
+	TTL      time.Duration // how long the key is cached (optional, defaults to 5 minutes)
+}
+
+// WithAPIKeySecret reads the API key from a secrets provider instead of fixing it when the client is created
+// Rotated keys are used once the cache expires, or right away when the provider rejects the old key with a 401
+// The key is only ever set on outgoing requests, so it doesn't end up in the client config, logs or traces
+func WithAPIKeySecret(provider secrets.Provider, name string, ttl time.Duration) ClientOption {
+	return func(c *Config) {
+		c.ApiKey = ""
+		c.APIKeySecret = &SecretKey{Provider: provider, Name: name, TTL: ttl}
+	}
+}
+
+// secretKeyMiddleware sets the Authorization header from the cached secret and retries once with a fresh key on 401
+func secretKeyMiddleware(cache *secrets.Cache, name string) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		key, err := cache.Secret(req.Context(), name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load API key: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err := next(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		// The key may have been rotated since it was cached
+		cache.Invalidate(name)
+		fresh, freshErr := cache.Secret(req.Context(), name)
+		if freshErr != nil || fresh == key {
+			return resp, err
+		}
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			retry.Body = body
+		}
+		_ = resp.Body.Close()
+		retry.Header.Set("Authorization", "Bearer "+fresh)
+		return next(retry)
+	}
+}
@@ -0,0 +1,57 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeySecretRotation(t *testing.T) {
+	var mu sync.Mutex
+	current := "key-1"
+	provider := secrets.ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		require.Equal(t, "openai", name)
+		mu.Lock()
+		defer mu.Unlock()
+		return current, nil
+	})
+
+	fake := newFakeProvider(t, fakeReply{Content: "one"}, fakeReply{Content: "two"})
+	var rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		want := "Bearer " + current
+		mu.Unlock()
+		if r.Header.Get("Authorization") != want {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+			return
+		}
+		fake.handle(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(WithBaseURL(server.URL), WithAPIKeySecret(provider, "openai", 0), WithDefaultModel("test-model"))
+	require.Empty(t, client.config.ApiKey)
+
+	agent := CreateAgent(client)
+	answer, err := agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "one", answer)
+
+	// the provider rotated the key, the cached one is rejected once and replaced
+	mu.Lock()
+	current = "key-2"
+	mu.Unlock()
+	answer, err = agent.InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "two", answer)
+	require.Equal(t, int32(1), rejected.Load())
+}
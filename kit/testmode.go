@@ -0,0 +1,158 @@
+package kit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// TestModeConfig configures deterministic test mode for a client
+// All randomness (latency, injected errors) is derived from Seed so runs are reproducible
+type TestModeConfig struct {
+	// Seed is sent as the request seed and drives simulated latency and error injection
+	Seed int64
+
+	// KeepTemperature disables forcing temperature to 0
+	KeepTemperature bool
+
+	// Replayer serves recorded responses instead of calling the provider (optional)
+	Replayer *Replayer
+
+	// MinLatency and MaxLatency bound the simulated latency added to every request
+	MinLatency time.Duration
+	MaxLatency time.Duration
+
+	// ErrorRate is the probability (0..1) of failing a request with ErrorStatusCode
+	ErrorRate float64
+
+	// ErrorStatusCode is the status returned for injected errors (defaults to 503)
+	ErrorStatusCode int
+}
+
+// testMode implements the deterministic test mode middleware
+type testMode struct {
+	config TestModeConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// WithTestMode enables deterministic test mode: seeded requests, zero temperature,
+// optional recorded responses and seeded latency/error injection for chaos testing
+func WithTestMode(config TestModeConfig) ClientOption {
+	tm := newTestMode(config)
+
+	opts := []option.RequestOption{option.WithMiddleware(tm.middleware())}
+	if config.Replayer != nil {
+		opts = append(opts, option.WithMiddleware(config.Replayer.Middleware()))
+	}
+
+	return WithRequestOptions(opts...)
+}
+
+func newTestMode(config TestModeConfig) *testMode {
+	if config.ErrorStatusCode == 0 {
+		config.ErrorStatusCode = http.StatusServiceUnavailable
+	}
+	if config.MaxLatency < config.MinLatency {
+		config.MaxLatency = config.MinLatency
+	}
+
+	return &testMode{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)), // #nosec G404 -- deterministic by design
+	}
+}
+
+// draw returns the simulated latency and whether an error should be injected for the next request
+func (tm *testMode) draw() (time.Duration, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	latency := tm.config.MinLatency
+	if spread := tm.config.MaxLatency - tm.config.MinLatency; spread > 0 {
+		latency += time.Duration(tm.rng.Int63n(int64(spread)))
+	}
+
+	fail := tm.config.ErrorRate > 0 && tm.rng.Float64() < tm.config.ErrorRate
+	return latency, fail
+}
+
+func (tm *testMode) middleware() option.Middleware {
+	return func(request *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if request.Body != nil && strings.HasSuffix(request.URL.Path, "/chat/completions") {
+			if err := tm.rewriteBody(request); err != nil {
+				return nil, err
+			}
+		}
+
+		latency, fail := tm.draw()
+		if latency > 0 {
+			timer := time.NewTimer(latency)
+			select {
+			case <-request.Context().Done():
+				timer.Stop()
+				return nil, request.Context().Err()
+			case <-timer.C:
+			}
+		}
+
+		if fail {
+			payload := []byte(fmt.Sprintf(
+				`{"error":{"message":"injected failure (test mode)","type":"test_mode","code":%d}}`,
+				tm.config.ErrorStatusCode,
+			))
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", tm.config.ErrorStatusCode, http.StatusText(tm.config.ErrorStatusCode)),
+				StatusCode:    tm.config.ErrorStatusCode,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				Body:          io.NopCloser(bytes.NewReader(payload)),
+				ContentLength: int64(len(payload)),
+				Request:       request,
+			}, nil
+		}
+
+		return next(request)
+	}
+}
+
+// rewriteBody forces seed and temperature on a chat completion request
+func (tm *testMode) rewriteBody(request *http.Request) error {
+	bodyBytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		return fmt.Errorf("test mode: failed to read request body: %w", err)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return fmt.Errorf("test mode: failed to parse request body: %w", err)
+	}
+
+	body["seed"] = tm.config.Seed
+	if !tm.config.KeepTemperature {
+		body["temperature"] = 0
+	}
+
+	bodyBytes, err = json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("test mode: failed to encode request body: %w", err)
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+	}
+	request.ContentLength = int64(len(bodyBytes))
+	return nil
+}
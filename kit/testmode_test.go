@@ -0,0 +1,73 @@
+package kit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/require"
+)
+
+type testModeDraw struct {
+	latency time.Duration
+	fail    bool
+}
+
+func drawTestMode(config TestModeConfig, n int) []testModeDraw {
+	tm := newTestMode(config)
+	draws := make([]testModeDraw, n)
+	for i := range draws {
+		draws[i].latency, draws[i].fail = tm.draw()
+	}
+	return draws
+}
+
+func TestTestModeIsReproducibleFromSeed(t *testing.T) {
+	config := TestModeConfig{Seed: 42, MinLatency: 10 * time.Millisecond, MaxLatency: time.Second, ErrorRate: 0.3}
+
+	draws := drawTestMode(config, 50)
+	require.Equal(t, draws, drawTestMode(config, 50))
+
+	var failures int
+	for _, d := range draws {
+		require.GreaterOrEqual(t, d.latency, config.MinLatency)
+		require.Less(t, d.latency, config.MaxLatency)
+		if d.fail {
+			failures++
+		}
+	}
+	require.Positive(t, failures)
+	require.Less(t, failures, 50)
+
+	config.Seed = 43
+	require.NotEqual(t, draws, drawTestMode(config, 50))
+}
+
+func TestTestModeErrorRateBounds(t *testing.T) {
+	for _, d := range drawTestMode(TestModeConfig{Seed: 1}, 20) {
+		require.False(t, d.fail)
+		require.Zero(t, d.latency)
+	}
+	for _, d := range drawTestMode(TestModeConfig{Seed: 1, ErrorRate: 1}, 20) {
+		require.True(t, d.fail)
+	}
+}
+
+func TestTestModeRequests(t *testing.T) {
+	provider := newFakeProvider(t, fakeReply{Content: "ok"})
+
+	answer, err := CreateAgent(provider.client(WithTestMode(TestModeConfig{Seed: 7}))).
+		WithTemperature(0.9).
+		InvokeSimple(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "ok", answer)
+	require.EqualValues(t, 7, provider.Requests()[0]["seed"])
+	require.EqualValues(t, 0, provider.Requests()[0]["temperature"])
+
+	// injected errors never reach the provider
+	client := provider.client(WithTestMode(TestModeConfig{Seed: 7, ErrorRate: 1}), WithRequestOptions(option.WithMaxRetries(0)))
+	_, err = CreateAgent(client).InvokeSimple(context.Background(), "hi")
+	require.ErrorContains(t, err, "injected failure (test mode)")
+	require.Len(t, provider.Requests(), 1)
+}
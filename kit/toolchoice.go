@@ -0,0 +1,52 @@
+package kit
+
+import (
+	"fmt"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/param"
+)
+
+// ToolChoice controls whether the model calls a tool: auto, none, required or the name of a tool it must call
+type ToolChoice string
+
+const (
+	ToolChoiceAuto     ToolChoice = "auto"     // The model decides (default)
+	ToolChoiceNone     ToolChoice = "none"     // The model answers without calling tools
+	ToolChoiceRequired ToolChoice = "required" // The model calls at least one tool
+)
+
+// WithToolChoice sets the tool choice of the first turn, e.g. kit.ToolChoice("extract_invoice") for extraction flows
+// where the model must call exactly that tool
+// Once a tool was called the model decides again, so the run can end with an answer
+func (a *Agent[Output]) WithToolChoice(choice ToolChoice) *Agent[Output] {
+	a.toolChoice = choice
+	return a
+}
+
+// check reports a forced tool the agent doesn't have
+func (c ToolChoice) check(schemas map[string]ToolSchema) error {
+	switch c {
+	case "", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return nil
+	}
+	if _, ok := schemas[string(c)]; !ok {
+		return fmt.Errorf("tool choice %q: tool not found", c)
+	}
+	return nil
+}
+
+// param converts the choice to the request parameter, the zero value leaves the choice to the provider
+func (c ToolChoice) param() openai.ChatCompletionToolChoiceOptionUnionParam {
+	switch c {
+	case "", ToolChoiceAuto:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{}
+	case ToolChoiceNone, ToolChoiceRequired:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: param.NewOpt(string(c))}
+	}
+	return openai.ChatCompletionToolChoiceOptionUnionParam{
+		OfChatCompletionNamedToolChoice: &openai.ChatCompletionNamedToolChoiceParam{
+			Function: openai.ChatCompletionNamedToolChoiceFunctionParam{Name: string(c)},
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolChoice(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada"}`}}},
+		fakeReply{Content: "done"},
+	)
+	agent := CreateAgent(provider.client(), &greetTool{greeting: "Hello"}).WithToolChoice("greet")
+
+	_, err := agent.InvokeSimple(context.Background(), "Ada")
+	require.NoError(t, err)
+
+	requests := provider.Requests()
+	require.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "greet"}},
+		requests[0]["tool_choice"])
+	require.NotContains(t, requests[1], "tool_choice", "the model decides once a tool was called")
+
+	// the run's choice overrides the agent's
+	provider = newFakeProvider(t, fakeReply{Content: "Hi Ada"})
+	agent = CreateAgent(provider.client(), &greetTool{}).WithToolChoice("greet")
+	_, err = agent.Invoke(context.Background(), InvokeConfig{Prompt: "Ada", ToolChoice: ToolChoiceNone})
+	require.NoError(t, err)
+	require.Equal(t, "none", provider.Requests()[0]["tool_choice"])
+
+	_, err = agent.WithToolChoice("farewell").InvokeSimple(context.Background(), "Ada")
+	require.EqualError(t, err, `tool choice "farewell": tool not found`)
+}
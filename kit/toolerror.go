@@ -0,0 +1,81 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// ToolErrorAction is what happens to a tool call that failed
+type ToolErrorAction string
+
+const (
+	ToolErrorAbort    ToolErrorAction = "abort"    // Fail the run with the error (default)
+	ToolErrorFeedback ToolErrorAction = "feedback" // Send the error to the model as the tool result, so it can recover
+)
+
+// ToolErrorPolicy decides how failed tool calls are handled
+// Failures are errors returned by Execute, unknown tools and arguments that don't fit the tool
+// A cancelled context or an exceeded budget always fails the run
+type ToolErrorPolicy struct {
+	// Action applied once the retries are used up (optional, defaults to ToolErrorAbort)
+	Action ToolErrorAction
+
+	// Retries executes a call that returned an error up to this many times again before Action applies
+	Retries int
+
+	// Backoff is the wait before the first retry, doubled for every further one (optional)
+	Backoff time.Duration
+
+	// Retryable reports whether an Execute error is worth a retry (optional, defaults to every error)
+	Retryable func(err error) bool
+
+	// Message formats the tool result sent for ToolErrorFeedback
+	// (optional, defaults to "Error: <error>. Fix the arguments or try another approach.")
+	Message func(toolName string, err error) string
+}
+
+// WithToolErrorPolicy sets how tool failures are handled, by default they fail the run
+func (a *Agent[Output]) WithToolErrorPolicy(policy ToolErrorPolicy) *Agent[Output] {
+	a.toolErrorPolicy = &policy
+	return a
+}
+
+// retry reports whether the failed attempt of a call is run again, after waiting the backoff
+func (p *ToolErrorPolicy) retry(ctx context.Context, err error, attempt int) bool {
+	if p == nil || attempt >= p.Retries || ctx.Err() != nil || budgetErr(ctx, err) != nil {
+		return false
+	}
+	if p.Retryable != nil && !p.Retryable(err) {
+		return false
+	}
+	if p.Backoff <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(p.Backoff << attempt)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// toolFailure applies the policy to a failed call, it returns the tool message for the model or the run's error
+func (a *Agent[Output]) toolFailure(ctx context.Context, toolName, toolCallID string, err error) (openai.ChatCompletionMessageParamUnion, error) {
+	policy := a.toolErrorPolicy
+	if policy == nil || policy.Action != ToolErrorFeedback || ctx.Err() != nil || budgetErr(ctx, nil) != nil {
+		return openai.ChatCompletionMessageParamUnion{}, err
+	}
+
+	message := fmt.Sprintf("Error: %v. Fix the arguments or try another approach.", err)
+	if policy.Message != nil {
+		message = policy.Message(toolName, err)
+	}
+	a.client.Logger.Warn("Tool call failed, error sent to the model", "tool", toolName, "error", err)
+	return openai.ToolMessage(message, toolCallID), nil
+}
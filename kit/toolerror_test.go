@@ -0,0 +1,93 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flakyTool fails until it was called failures times
+type flakyTool struct {
+	BaseTool
+	City string `json:"city"`
+
+	calls    *atomic.Int32
+	failures int32
+}
+
+func (t *flakyTool) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{Name: "weather", Description: "Current weather of a city"}
+}
+
+func (t *flakyTool) Execute(ctx *Context) (any, error) {
+	if t.calls.Add(1) <= t.failures {
+		return nil, errors.New("weather service unavailable")
+	}
+	return "sunny in " + t.City, nil
+}
+
+func weatherCall(id string) fakeReply {
+	return fakeReply{ToolCalls: []fakeToolCall{{ID: id, Name: "weather", Arguments: `{"city":"Tehran"}`}}}
+}
+
+func TestToolErrorAbortsByDefault(t *testing.T) {
+	provider := newFakeProvider(t, weatherCall("call-1"))
+	tool := &flakyTool{calls: &atomic.Int32{}, failures: 1}
+
+	_, err := CreateAgent(provider.client(), tool).InvokeSimple(context.Background(), "weather?")
+	require.EqualError(t, err, "tool weather failed: weather service unavailable")
+}
+
+func TestToolErrorFeedback(t *testing.T) {
+	provider := newFakeProvider(t,
+		weatherCall("call-1"),
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-2", Name: "forecast", Arguments: `{}`}}},
+		fakeReply{Content: "The weather service is down, try later."},
+	)
+	tool := &flakyTool{calls: &atomic.Int32{}, failures: 5}
+	agent := CreateAgent(provider.client(), tool).WithToolErrorPolicy(ToolErrorPolicy{Action: ToolErrorFeedback})
+
+	answer, err := agent.InvokeSimple(context.Background(), "weather?")
+	require.NoError(t, err)
+	require.Equal(t, "The weather service is down, try later.", answer)
+
+	requests := provider.Requests()
+	messages := requests[1]["messages"].([]any)
+	require.Equal(t, map[string]any{
+		"role":         "tool",
+		"tool_call_id": "call-1",
+		"content":      "Error: tool weather failed: weather service unavailable. Fix the arguments or try another approach.",
+	}, messages[len(messages)-1])
+
+	messages = requests[2]["messages"].([]any)
+	require.Equal(t, "Error: tool not found: forecast. Fix the arguments or try another approach.",
+		messages[len(messages)-1].(map[string]any)["content"])
+}
+
+func TestToolErrorRetries(t *testing.T) {
+	provider := newFakeProvider(t, weatherCall("call-1"), fakeReply{Content: "It's sunny."})
+	calls := &atomic.Int32{}
+	tool := &flakyTool{calls: calls, failures: 2}
+	agent := CreateAgent(provider.client(), tool).WithToolErrorPolicy(ToolErrorPolicy{Retries: 2})
+
+	_, err := agent.InvokeSimple(context.Background(), "weather?")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+
+	messages := provider.Requests()[1]["messages"].([]any)
+	require.Equal(t, "sunny in Tehran", messages[len(messages)-1].(map[string]any)["content"])
+
+	// errors that aren't retryable abort right away
+	provider = newFakeProvider(t, weatherCall("call-1"))
+	calls.Store(0)
+	agent = CreateAgent(provider.client(), tool).WithToolErrorPolicy(ToolErrorPolicy{
+		Retries:   2,
+		Retryable: func(err error) bool { return false },
+	})
+	_, err = agent.InvokeSimple(context.Background(), "weather?")
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
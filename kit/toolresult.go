@@ -0,0 +1,169 @@
+package kit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// ToolResultStore keeps full tool results that were too large for the conversation
+type ToolResultStore interface {
+	Put(ctx context.Context, content string) (string, error)
+	Get(ctx context.Context, id string) (string, error)
+}
+
+// MemoryToolResultStore is an in-process ToolResultStore
+type MemoryToolResultStore struct {
+	mu      sync.RWMutex
+	results map[string]string
+}
+
+func NewMemoryToolResultStore() *MemoryToolResultStore {
+	return &MemoryToolResultStore{results: make(map[string]string)}
+}
+
+func (s *MemoryToolResultStore) Put(_ context.Context, content string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := "toolres_" + hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[id] = content
+	return id, nil
+}
+
+func (s *MemoryToolResultStore) Get(_ context.Context, id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	content, ok := s.results[id]
+	if !ok {
+		return "", fmt.Errorf("tool result not found: %s", id)
+	}
+	return content, nil
+}
+
+// ToolResultPolicy limits how much of a tool result is sent back to the model
+type ToolResultPolicy struct {
+	// MaxChars is the largest result passed through unchanged
+	MaxChars int
+
+	// Store keeps the full payload of larger results (defaults to an in-memory store)
+	Store ToolResultStore
+
+	// Summarize replaces a large result with a summary instead of truncating it (optional)
+	Summarize func(ctx context.Context, toolName, result string) (string, error)
+}
+
+// WithToolResultPolicy truncates or summarizes tool results larger than policy.MaxChars
+// The full payload is stored under a reference ID the model can page through with the
+// read_tool_result tool, which is added to the agent
+func (a *Agent[Output]) WithToolResultPolicy(policy ToolResultPolicy) *Agent[Output] {
+	if policy.Store == nil {
+		policy.Store = NewMemoryToolResultStore()
+	}
+	a.toolResultPolicy = &policy
+
+	readTool := &ReadToolResult{}
+	toolSchema := BuildToolSchema(readTool)
+	a.tools[toolSchema.ID] = readTool
+	a.schemas[toolSchema.ID] = toolSchema
+	return a
+}
+
+// ToolResultStore returns the store of the tool result policy, nil when none is set
+func (a *Agent[Output]) ToolResultStore() ToolResultStore {
+	if a.toolResultPolicy == nil {
+		return nil
+	}
+	return a.toolResultPolicy.Store
+}
+
+type toolResultStoreKey struct{}
+
+// applyToolResultPolicy shortens a result that exceeds the policy limit
+func (a *Agent[Output]) applyToolResultPolicy(ctx context.Context, toolName, result string) (string, error) {
+	policy := a.toolResultPolicy
+	if policy == nil || policy.MaxChars <= 0 || len(result) <= policy.MaxChars {
+		return result, nil
+	}
+	// pages of read_tool_result are already limited, shortening them again would store them again
+	if toolName == readToolResultName {
+		return result, nil
+	}
+
+	id, err := policy.Store.Put(ctx, result)
+	if err != nil {
+		return "", fmt.Errorf("failed to store tool result: %w", err)
+	}
+
+	if policy.Summarize != nil {
+		summary, err := policy.Summarize(ctx, toolName, result)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize tool result: %w", err)
+		}
+		return fmt.Sprintf("%s\n\n[Summary of a %d character result, the full result is stored as %q. "+
+			"Use read_tool_result to read it.]", summary, len(result), id), nil
+	}
+
+	cut := runeBoundary(result, policy.MaxChars)
+	return fmt.Sprintf("%s\n\n[Truncated: showing %d of %d characters, the full result is stored as %q. "+
+		"Use read_tool_result with offset %d to read more.]",
+		result[:cut], cut, len(result), id, cut), nil
+}
+
+// runeBoundary moves i back to the start of the UTF-8 sequence it points into
+func runeBoundary(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// ReadToolResult pages through a stored tool result
+type ReadToolResult struct {
+	ID     string `json:"id" jsonschema:"description=Reference ID of the stored tool result"`
+	Offset int    `json:"offset" jsonschema:"description=Character offset to start reading from"`
+}
+
+const readToolResultName = "read_tool_result"
+
+func (t *ReadToolResult) AgentToolInfo() AgentToolInfo {
+	return AgentToolInfo{
+		Name:        readToolResultName,
+		Description: "Read a part of a tool result that was too large to show in full.",
+	}
+}
+
+func (t *ReadToolResult) Execute(ctx *Context) (any, error) {
+	policy, ok := ctx.Value(toolResultStoreKey{}).(*ToolResultPolicy)
+	if !ok {
+		return nil, fmt.Errorf("no tool result store configured")
+	}
+
+	content, err := policy.Store.Get(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Offset < 0 || t.Offset > len(content) {
+		return nil, fmt.Errorf("offset %d out of range (result has %d characters)", t.Offset, len(content))
+	}
+
+	start := runeBoundary(content, t.Offset)
+	end := len(content)
+	if policy.MaxChars > 0 && start+policy.MaxChars < end {
+		end = runeBoundary(content, start+policy.MaxChars)
+	}
+
+	page := content[start:end]
+	if end < len(content) {
+		page += fmt.Sprintf("\n\n[Showing characters %d-%d of %d, continue with offset %d.]",
+			start, end, len(content), end)
+	}
+	return page, nil
+}
@@ -0,0 +1,93 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sequentialToolResultStore hands out predictable IDs, so a scripted model can read them back
+type sequentialToolResultStore map[string]string
+
+func (s sequentialToolResultStore) Put(_ context.Context, content string) (string, error) {
+	id := fmt.Sprintf("res_%d", len(s)+1)
+	s[id] = content
+	return id, nil
+}
+
+func (s sequentialToolResultStore) Get(_ context.Context, id string) (string, error) {
+	content, ok := s[id]
+	if !ok {
+		return "", fmt.Errorf("tool result not found: %s", id)
+	}
+	return content, nil
+}
+
+// lastToolMessage returns the content of the last tool message of a recorded request
+func lastToolMessage(req map[string]any) string {
+	var content string
+	for _, m := range req["messages"].([]any) {
+		if msg := m.(map[string]any); msg["role"] == "tool" {
+			content = msg["content"].(string)
+		}
+	}
+	return content
+}
+
+func TestToolResultPolicyTruncatesAndPages(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada Lovelace"}`}}},
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-2", Name: "read_tool_result", Arguments: `{"id":"res_1","offset":10}`}}},
+		fakeReply{Content: "done"},
+	)
+	store := sequentialToolResultStore{}
+
+	agent := CreateAgent(provider.client(), &greetTool{greeting: "Good morning"}).
+		WithToolResultPolicy(ToolResultPolicy{MaxChars: 10, Store: store})
+	_, err := agent.InvokeSimple(context.Background(), "greet Ada")
+	require.NoError(t, err)
+	require.Equal(t, "Good morning, Ada Lovelace", store["res_1"])
+
+	requests := provider.Requests()
+	require.Equal(t, "Good morni\n\n[Truncated: showing 10 of 26 characters, the full result is stored as \"res_1\". "+
+		"Use read_tool_result with offset 10 to read more.]", lastToolMessage(requests[1]))
+	require.Equal(t, "ng, Ada Lo\n\n[Showing characters 10-20 of 26, continue with offset 20.]",
+		lastToolMessage(requests[2]))
+}
+
+func TestToolResultPolicySummarizes(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada Lovelace"}`}}},
+		fakeReply{Content: "done"},
+	)
+
+	agent := CreateAgent(provider.client(), &greetTool{greeting: "Good morning"}).
+		WithToolResultPolicy(ToolResultPolicy{
+			MaxChars: 10,
+			Store:    sequentialToolResultStore{},
+			Summarize: func(_ context.Context, toolName, result string) (string, error) {
+				return fmt.Sprintf("%s said %d things", toolName, len(result)), nil
+			},
+		})
+	_, err := agent.InvokeSimple(context.Background(), "greet Ada")
+	require.NoError(t, err)
+	require.Equal(t, "greet said 26 things\n\n[Summary of a 26 character result, the full result is stored as \"res_1\". "+
+		"Use read_tool_result to read it.]", lastToolMessage(provider.Requests()[1]))
+}
+
+func TestToolResultPolicyKeepsShortResults(t *testing.T) {
+	provider := newFakeProvider(t,
+		fakeReply{ToolCalls: []fakeToolCall{{ID: "call-1", Name: "greet", Arguments: `{"name":"Ada"}`}}},
+		fakeReply{Content: "done"},
+	)
+	store := sequentialToolResultStore{}
+
+	agent := CreateAgent(provider.client(), &greetTool{greeting: "Hi"}).
+		WithToolResultPolicy(ToolResultPolicy{MaxChars: 100, Store: store})
+	_, err := agent.InvokeSimple(context.Background(), "greet Ada")
+	require.NoError(t, err)
+	require.Equal(t, "Hi, Ada", lastToolMessage(provider.Requests()[1]))
+	require.Empty(t, store)
+}
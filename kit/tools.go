@@ -14,6 +14,9 @@ type AgentToolInfo struct {
 }
 
 // ToolExecutor is the interface that all tools must implement
+// Exported fields of a tool struct are its arguments: every call decodes them into a fresh instance, starting from
+// their zero values. Configuration set on the registered tool, such as a client or a default, belongs in unexported
+// or `json:"-"` fields, which every call keeps
 type ToolExecutor interface {
 	AgentToolInfo() AgentToolInfo
 	Execute(ctx *Context) (any, error)
@@ -32,6 +35,45 @@ func (b BaseTool) AgentToolInfo() AgentToolInfo {
 	}
 }
 
+// newToolInstance returns an instance of a registered tool for one call
+// It keeps the tool's configuration (unexported and `json:"-"` fields) and starts every argument field at its zero
+// value, so arguments are decoded into fresh memory and never into slices or maps of the registered tool, which
+// parallel calls share. Configuration is shared as well, tools must not modify it in Execute
+func newToolInstance(tool ToolExecutor) ToolExecutor {
+	value := reflect.ValueOf(tool)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	instance := reflect.New(value.Type())
+	if value.Kind() == reflect.Struct {
+		instance.Elem().Set(value)
+		resetArguments(instance.Elem())
+	}
+	return instance.Interface().(ToolExecutor)
+}
+
+// resetArguments zeroes the fields of a tool struct that encoding/json decodes arguments into
+func resetArguments(v reflect.Value) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		value := v.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !value.CanSet() {
+			continue
+		}
+		// fields of embedded structs without a name are promoted into the arguments
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			resetArguments(value)
+			continue
+		}
+		if field.IsExported() {
+			value.SetZero()
+		}
+	}
+}
+
 // GetAgentToolInfo extracts AgentToolInfo from a tool, using reflection to generate name if needed
 func GetAgentToolInfo(tool ToolExecutor) AgentToolInfo {
 	info := tool.AgentToolInfo()
@@ -0,0 +1,204 @@
+// Package memory gives agents a long-term store of facts about their users, kept in a vector database
+// and searched by meaning, so "the user prefers metric units" can be recalled in a later session
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+// ErrNoNamespace is returned when neither the context nor the config name the namespace to use
+var ErrNoNamespace = errors.New("memory namespace is not set")
+
+// ErrNotFound is returned when forgetting a fact that isn't in the namespace
+var ErrNotFound = errors.New("fact not found")
+
+const (
+	fieldNamespace = "namespace"
+	fieldCreatedAt = "created_at"
+	fieldExpiresAt = "expires_at"
+)
+
+// neverExpires is the expiry stored for facts without a TTL, so one date filter covers every fact
+var neverExpires = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// FilterableFields must be part of the IndexConfig of the index facts are stored in
+var FilterableFields = []vectordb.FilterableField{
+	{Name: fieldNamespace, Type: vectordb.FilterFieldTypeTag, Required: true},
+	{Name: fieldExpiresAt, Type: vectordb.FilterFieldTypeDate},
+}
+
+type namespaceKey struct{}
+
+// WithNamespace sets the namespace, usually the user ID, that the memory tools use for requests made with ctx
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace set with WithNamespace
+func NamespaceFromContext(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(namespaceKey{}).(string)
+	return namespace, ok && namespace != ""
+}
+
+// Config configures a Memory
+type Config struct {
+	// TTL is how long facts are kept (optional, defaults to forever)
+	TTL time.Duration
+
+	// TopK is the number of facts recalled per query (optional, defaults to 5)
+	TopK int
+
+	// Namespace is used when the context has none (optional)
+	Namespace string
+}
+
+// Fact is a remembered statement
+type Fact struct {
+	ID        string     `json:"id"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil when the fact never expires
+	Score     string     `json:"score,omitempty"`      // distance to the query, set by Recall
+}
+
+// Memory stores and recalls facts, isolated per namespace
+type Memory struct {
+	db     vectordb.Client
+	config Config
+	now    func() time.Time
+}
+
+// New creates a Memory on db, whose index must include FilterableFields
+func New(db vectordb.Client, config Config) *Memory {
+	if config.TopK <= 0 {
+		config.TopK = 5
+	}
+	return &Memory{db: db, config: config, now: time.Now}
+}
+
+// namespace picks the namespace of ctx, falling back to Config.Namespace
+func (m *Memory) namespace(ctx context.Context) (string, error) {
+	if namespace, ok := NamespaceFromContext(ctx); ok {
+		return namespace, nil
+	}
+	if m.config.Namespace != "" {
+		return m.config.Namespace, nil
+	}
+	return "", ErrNoNamespace
+}
+
+// Save stores a fact in namespace, ttl overrides Config.TTL when positive
+// Saving the same content again refreshes it instead of adding a duplicate
+func (m *Memory) Save(ctx context.Context, namespace, content string, ttl time.Duration) (Fact, error) {
+	content = strings.TrimSpace(content)
+	if namespace == "" {
+		return Fact{}, ErrNoNamespace
+	}
+	if content == "" {
+		return Fact{}, fmt.Errorf("fact is empty")
+	}
+	if ttl <= 0 {
+		ttl = m.config.TTL
+	}
+
+	now := m.now().UTC()
+	fact := Fact{ID: factID(namespace, content), Content: content, CreatedAt: now}
+	expiresAt := neverExpires
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+		fact.ExpiresAt = &expiresAt
+	}
+
+	err := m.db.StoreDocument(ctx, vectordb.Document{
+		ID:      fact.ID,
+		Content: content,
+		Meta: map[string]any{
+			fieldNamespace: namespace,
+			fieldCreatedAt: now,
+			fieldExpiresAt: expiresAt,
+		},
+	})
+	if err != nil {
+		return Fact{}, fmt.Errorf("failed to save fact: %w", err)
+	}
+	return fact, nil
+}
+
+// Recall returns the facts of namespace closest to query that haven't expired, topK of 0 uses Config.TopK
+func (m *Memory) Recall(ctx context.Context, namespace, query string, topK int) ([]Fact, error) {
+	if namespace == "" {
+		return nil, ErrNoNamespace
+	}
+	if topK <= 0 {
+		topK = m.config.TopK
+	}
+
+	docs, err := m.db.SearchDocuments(ctx, vectordb.DocumentSearch{
+		Query: query,
+		TopK:  topK,
+		Filters: []vectordb.Filter{
+			{Field: fieldNamespace, Operator: vectordb.FilterOpEq, Value: namespace},
+			{Field: fieldExpiresAt, Operator: vectordb.FilterOpAfter, Value: m.now()},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to recall facts: %w", err)
+	}
+
+	facts := make([]Fact, 0, len(docs))
+	for _, doc := range docs {
+		fact := Fact{ID: doc.ID, Content: doc.Content, Score: doc.Score}
+		fact.CreatedAt, _ = metaTime(doc.Meta[fieldCreatedAt])
+		if expiresAt, ok := metaTime(doc.Meta[fieldExpiresAt]); ok && expiresAt.Before(neverExpires) {
+			fact.ExpiresAt = &expiresAt
+		}
+		facts = append(facts, fact)
+	}
+	return facts, nil
+}
+
+// Forget deletes a fact of namespace
+func (m *Memory) Forget(ctx context.Context, namespace, id string) error {
+	if namespace == "" {
+		return ErrNoNamespace
+	}
+	// IDs start with a hash of their namespace, so a fact of another namespace can't be deleted
+	if !strings.HasPrefix(id, namespacePrefix(namespace)) {
+		return fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	if err := m.db.DeleteDocument(ctx, id); err != nil {
+		return fmt.Errorf("failed to forget fact: %w", err)
+	}
+	return nil
+}
+
+// factID derives a stable ID from the namespace and content: "fact-<namespace hash>-<content hash>"
+func factID(namespace, content string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(content)))
+	return namespacePrefix(namespace) + hex.EncodeToString(sum[:8])
+}
+
+func namespacePrefix(namespace string) string {
+	sum := sha256.Sum256([]byte(namespace))
+	return "fact-" + hex.EncodeToString(sum[:6]) + "-"
+}
+
+// metaTime reads a time from metadata, which holds time.Time before and RFC 3339 strings after a round trip
+func metaTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
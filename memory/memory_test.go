@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB applies the namespace and expiry filters and ranks by shared words instead of embeddings
+type fakeDB struct {
+	vectordb.Client
+	docs map[string]vectordb.Document
+}
+
+func (f *fakeDB) StoreDocument(_ context.Context, doc vectordb.Document) error {
+	f.docs[doc.ID] = doc
+	return nil
+}
+
+func (f *fakeDB) DeleteDocument(_ context.Context, id string) error {
+	delete(f.docs, id)
+	return nil
+}
+
+func (f *fakeDB) SearchDocuments(_ context.Context, search vectordb.DocumentSearch) ([]vectordb.DocumentWithScore, error) {
+	var results []vectordb.DocumentWithScore
+	for _, doc := range f.docs {
+		matches := true
+		for _, filter := range search.Filters {
+			switch filter.Operator {
+			case vectordb.FilterOpEq:
+				matches = matches && doc.Meta[filter.Field] == filter.Value
+			case vectordb.FilterOpAfter:
+				matches = matches && doc.Meta[filter.Field].(time.Time).After(filter.Value.(time.Time))
+			}
+		}
+		for _, word := range strings.Fields(search.Query) {
+			if matches && strings.Contains(strings.ToLower(doc.Content), strings.ToLower(word)) {
+				results = append(results, vectordb.DocumentWithScore{Document: doc, Score: "0.1"})
+				break
+			}
+		}
+	}
+	if len(results) > search.TopK {
+		results = results[:search.TopK]
+	}
+	return results, nil
+}
+
+func newTestMemory(config Config) (*Memory, *fakeDB, *time.Time) {
+	db := &fakeDB{docs: map[string]vectordb.Document{}}
+	m := New(db, config)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, db, &now
+}
+
+func TestSaveAndRecall(t *testing.T) {
+	m, db, now := newTestMemory(Config{TTL: 30 * 24 * time.Hour})
+	ctx := context.Background()
+
+	fact, err := m.Save(ctx, "alice", "  The user prefers metric units ", 0)
+	require.NoError(t, err)
+	require.Equal(t, "The user prefers metric units", fact.Content)
+	require.Equal(t, now.Add(30*24*time.Hour), *fact.ExpiresAt)
+
+	// saving it again refreshes the same fact
+	_, err = m.Save(ctx, "alice", "the user prefers metric units", time.Hour)
+	require.NoError(t, err)
+	require.Len(t, db.docs, 1)
+
+	_, err = m.Save(ctx, "bob", "The user prefers imperial units", 0)
+	require.NoError(t, err)
+
+	facts, err := m.Recall(ctx, "alice", "units", 0)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	require.Equal(t, "the user prefers metric units", facts[0].Content)
+	require.Equal(t, fact.ID, facts[0].ID)
+
+	*now = now.Add(2 * time.Hour)
+	facts, err = m.Recall(ctx, "alice", "units", 0)
+	require.NoError(t, err)
+	require.Empty(t, facts)
+}
+
+func TestSaveWithoutTTLNeverExpires(t *testing.T) {
+	m, _, now := newTestMemory(Config{})
+	ctx := context.Background()
+
+	fact, err := m.Save(ctx, "alice", "Lives in Lisbon", 0)
+	require.NoError(t, err)
+	require.Nil(t, fact.ExpiresAt)
+
+	*now = now.AddDate(50, 0, 0)
+	facts, err := m.Recall(ctx, "alice", "Lisbon", 0)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	require.Nil(t, facts[0].ExpiresAt)
+}
+
+func TestForgetIsScopedToNamespace(t *testing.T) {
+	m, db, _ := newTestMemory(Config{})
+	ctx := context.Background()
+
+	fact, err := m.Save(ctx, "alice", "Allergic to peanuts", 0)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, m.Forget(ctx, "bob", fact.ID), ErrNotFound)
+	require.Len(t, db.docs, 1)
+
+	require.NoError(t, m.Forget(ctx, "alice", fact.ID))
+	require.Empty(t, db.docs)
+}
+
+func TestTools(t *testing.T) {
+	m, _, _ := newTestMemory(Config{})
+	tools := m.Tools()
+	require.Len(t, tools, 3)
+
+	ctx := &kit.Context{Context: WithNamespace(context.Background(), "alice")}
+	out, err := (&Remember{Fact: "Works night shifts", ExpiresInDays: 7, memory: m}).Execute(ctx)
+	require.NoError(t, err)
+	fact := out.(Fact)
+	require.Equal(t, "Works night shifts", fact.Content)
+
+	out, err = (&Recall{Query: "shifts", memory: m}).Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, out.(map[string]any)["facts"], 1)
+
+	out, err = (&Remember{Fact: " ", memory: m}).Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, failure{Error: "fact is empty"}, out)
+
+	out, err = (&Forget{ID: "fact-0000-1111", memory: m}).Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, failure{Error: "fact-0000-1111: fact not found"}, out)
+
+	// without a namespace the run fails instead of sharing one memory between users
+	_, err = (&Recall{Query: "shifts", memory: m}).Execute(&kit.Context{Context: context.Background()})
+	require.ErrorIs(t, err, ErrNoNamespace)
+
+	m.config.Namespace = "shared"
+	out, err = (&Recall{Query: "shifts", memory: m}).Execute(&kit.Context{Context: context.Background()})
+	require.NoError(t, err)
+	require.Empty(t, out.(map[string]any)["facts"])
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// Tools returns the remember, recall and forget tools, which use the namespace of the run's context
+func (m *Memory) Tools() []kit.ToolExecutor {
+	return []kit.ToolExecutor{&Remember{memory: m}, &Recall{memory: m}, &Forget{memory: m}}
+}
+
+// failure is returned to the model instead of failing the run, so it can fix its arguments and retry
+type failure struct {
+	Error string `json:"error"`
+}
+
+// result fails the run when it was cancelled or no namespace is configured, other errors go to the model
+func result(ctx *kit.Context, value any, err error) (any, error) {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrNoNamespace) {
+			return nil, err
+		}
+		return failure{Error: err.Error()}, nil
+	}
+	return value, nil
+}
+
+// Remember saves a fact about the user
+type Remember struct {
+	kit.BaseTool
+	Fact          string `json:"fact" jsonschema:"description=A short self-contained statement, e.g. The user prefers metric units"`
+	ExpiresInDays int    `json:"expires_in_days" jsonschema:"description=Forget the fact after this many days, 0 for the default"`
+
+	memory *Memory
+}
+
+func (t *Remember) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name: "remember",
+		Description: "Save a fact about the user to long-term memory, such as a preference or detail they asked you to remember. " +
+			"Facts are available in later conversations.",
+	}
+}
+
+func (t *Remember) Execute(ctx *kit.Context) (any, error) {
+	namespace, err := t.memory.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fact, err := t.memory.Save(ctx, namespace, t.Fact, time.Duration(t.ExpiresInDays)*24*time.Hour)
+	return result(ctx, fact, err)
+}
+
+// Recall searches the facts about the user
+type Recall struct {
+	kit.BaseTool
+	Query string `json:"query" jsonschema:"description=What to look for, e.g. preferred units"`
+
+	memory *Memory
+}
+
+func (t *Recall) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "recall",
+		Description: "Search long-term memory for facts about the user that were saved in earlier conversations.",
+	}
+}
+
+func (t *Recall) Execute(ctx *kit.Context) (any, error) {
+	namespace, err := t.memory.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	facts, err := t.memory.Recall(ctx, namespace, t.Query, 0)
+	return result(ctx, map[string]any{"facts": facts}, err)
+}
+
+// Forget deletes a fact about the user
+type Forget struct {
+	kit.BaseTool
+	ID string `json:"id" jsonschema:"description=ID of the fact as returned by recall"`
+
+	memory *Memory
+}
+
+func (t *Forget) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "forget",
+		Description: "Delete a fact from long-term memory, e.g. when the user asks you to forget it or it is no longer true.",
+	}
+}
+
+func (t *Forget) Execute(ctx *kit.Context) (any, error) {
+	namespace, err := t.memory.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = t.memory.Forget(ctx, namespace, t.ID)
+	return result(ctx, map[string]any{"forgotten": t.ID}, err)
+}
@@ -0,0 +1,142 @@
+package prompt
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenCounter counts the tokens of a text for the model the prompt is sent to
+type TokenCounter func(text string) int
+
+// Summarizer shortens a section that doesn't fit its budget, e.g. by asking a cheap model for a summary
+// The summary is still truncated if it exceeds maxTokens
+type Summarizer func(section, text string, maxTokens int) (string, error)
+
+// Ellipsis marks where a budgeted section was cut
+const Ellipsis = "…"
+
+// Option configures a Template
+type Option func(*options)
+
+type options struct {
+	countTokens TokenCounter
+	summarize   Summarizer
+}
+
+// WithTokenCounter sets how budgeted sections are measured, defaults to EstimateTokens
+func WithTokenCounter(counter TokenCounter) Option {
+	return func(o *options) {
+		o.countTokens = counter
+	}
+}
+
+// WithSummarizer summarizes budgeted sections that don't fit instead of only truncating them
+func WithSummarizer(summarizer Summarizer) Option {
+	return func(o *options) {
+		o.summarize = summarizer
+	}
+}
+
+// EstimateTokens approximates the token count of a text as one token per 4 characters
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// budgetFunc implements {{budget "name" maxTokens text}}, also usable as {{.Data.Context | budget "context" 800}}
+// A string is summarized or cut at a word boundary with an ellipsis. A []string (e.g. retrieved chunks) keeps
+// the leading items that fit, joined by blank lines, and notes how many were left out
+func (o options) budgetFunc(name string, maxTokens int, content any) (string, error) {
+	if maxTokens <= 0 {
+		return "", fmt.Errorf("budget of section %q must be positive, got %d", name, maxTokens)
+	}
+
+	switch content := content.(type) {
+	case string:
+		return o.fitText(name, content, maxTokens)
+	case []string:
+		return o.fitItems(name, content, maxTokens), nil
+	case nil:
+		return "", nil
+	}
+	return o.fitText(name, fmt.Sprint(content), maxTokens)
+}
+
+func (o options) count(text string) int {
+	if o.countTokens != nil {
+		return o.countTokens(text)
+	}
+	return EstimateTokens(text)
+}
+
+func (o options) fitText(name, text string, maxTokens int) (string, error) {
+	tokens := o.count(text)
+	if tokens <= maxTokens {
+		return text, nil
+	}
+	slog.Debug("Prompt section over budget", "section", name, "tokens", tokens, "budget", maxTokens)
+
+	if o.summarize != nil {
+		summary, err := o.summarize(name, text, maxTokens)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize section %q: %w", name, err)
+		}
+		if o.count(summary) <= maxTokens {
+			return summary, nil
+		}
+		text = summary
+	}
+	return o.truncate(text, maxTokens), nil
+}
+
+// truncate returns the longest prefix of text ending on a word boundary that fits with the ellipsis
+func (o options) truncate(text string, maxTokens int) string {
+	runes := []rune(text)
+
+	// binary search the longest fitting prefix, token counts grow with the prefix length
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if o.count(string(runes[:mid])+Ellipsis) <= maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	prefix := string(runes[:low])
+	if i := strings.LastIndexAny(prefix, " \n\t"); i > 0 {
+		prefix = prefix[:i]
+	}
+	prefix = strings.TrimRight(prefix, " \n\t")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + Ellipsis
+}
+
+func (o options) fitItems(name string, items []string, maxTokens int) string {
+	kept := make([]string, 0, len(items))
+	for i, item := range items {
+		candidate := append(kept, item)
+		note := ""
+		if rest := len(items) - i - 1; rest > 0 {
+			note = omittedNote(rest)
+		}
+		if o.count(strings.Join(candidate, "\n\n")+note) > maxTokens {
+			slog.Debug("Prompt section over budget", "section", name, "items", len(items), "kept", len(kept))
+			if len(kept) == 0 {
+				// even the first item doesn't fit, cut it instead of dropping everything
+				return o.truncate(item, maxTokens-o.count(note)) + note
+			}
+			return strings.Join(kept, "\n\n") + omittedNote(len(items)-len(kept))
+		}
+		kept = candidate
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+func omittedNote(n int) string {
+	return fmt.Sprintf("\n\n%s (%d more omitted)", Ellipsis, n)
+}
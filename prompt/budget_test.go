@@ -0,0 +1,85 @@
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// wordCount counts one token per word, ellipsis included
+func wordCount(text string) int {
+	return len(strings.Fields(strings.ReplaceAll(text, Ellipsis, " "+Ellipsis)))
+}
+
+func TestBudgetTruncatesText(t *testing.T) {
+	o := options{countTokens: wordCount}
+
+	text, err := o.budgetFunc("context", 10, "short enough")
+	require.NoError(t, err)
+	require.Equal(t, "short enough", text)
+
+	text, err = o.budgetFunc("context", 4, "one two three four five six")
+	require.NoError(t, err)
+	require.Equal(t, "one two three"+Ellipsis, text)
+
+	_, err = o.budgetFunc("context", 0, "x")
+	require.Error(t, err)
+}
+
+func TestBudgetEstimatesTokens(t *testing.T) {
+	text, err := options{}.budgetFunc("context", 5, strings.Repeat("word ", 20))
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(text, Ellipsis))
+	require.LessOrEqual(t, EstimateTokens(text), 5)
+}
+
+func TestBudgetKeepsWholeItems(t *testing.T) {
+	o := options{countTokens: wordCount}
+	items := []string{"alpha beta", "gamma delta", "epsilon zeta", "eta theta"}
+
+	text, err := o.budgetFunc("docs", 8, items)
+	require.NoError(t, err)
+	require.Equal(t, "alpha beta\n\ngamma delta\n\n"+Ellipsis+" (2 more omitted)", text)
+
+	text, err = o.budgetFunc("docs", 100, items)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join(items, "\n\n"), text)
+
+	text, err = o.budgetFunc("docs", 7, []string{"a b c d e f g h", "i"})
+	require.NoError(t, err)
+	require.Equal(t, "a b"+Ellipsis+"\n\n"+Ellipsis+" (1 more omitted)", text)
+}
+
+func TestBudgetSummarizes(t *testing.T) {
+	var summarized string
+	o := options{
+		countTokens: wordCount,
+		summarize: func(section, text string, maxTokens int) (string, error) {
+			summarized = section
+			return "a short summary", nil
+		},
+	}
+
+	text, err := o.budgetFunc("history", 3, "a very long conversation history")
+	require.NoError(t, err)
+	require.Equal(t, "a short summary", text)
+	require.Equal(t, "history", summarized)
+
+	o.summarize = func(string, string, int) (string, error) { return "", errors.New("model down") }
+	_, err = o.budgetFunc("history", 3, "a very long conversation history")
+	require.ErrorContains(t, err, "model down")
+}
+
+func TestBudgetInTemplate(t *testing.T) {
+	tpl := NewTemplate[struct{}](WithTokenCounter(wordCount))
+	require.NoError(t, tpl.Load(tplFS))
+
+	rendered, err := tpl.Execute("budget", Render[struct{}]{Data: map[string]any{
+		"Docs":     []string{"first doc", "second doc", "a third doc that is far too long to fit"},
+		"Question": "what?",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "Context:\nfirst doc\n\nsecond doc\n\n"+Ellipsis+" (1 more omitted)\nQuestion: what?", rendered)
+}
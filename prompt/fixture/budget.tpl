@@ -0,0 +1,3 @@
+Context:
+{{budget "context" 12 .Data.Docs}}
+Question: {{.Data.Question}}
\ No newline at end of file
@@ -0,0 +1,6 @@
+Answer with JSON matching:
+{{schema .Data}}
+Fields:
+{{fields .Data}}
+Example:
+{{example .Data}}
\ No newline at end of file
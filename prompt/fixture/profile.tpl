@@ -0,0 +1,5 @@
+{{define "greeting"}}Hi {{.Name}}{{end}}
+{{- with .Data.User}}{{template "greeting" .}}{{end}}
+{{- range $i, $order := .Data.Orders}} #{{$order.ID}}{{end}}
+{{- if .Context.Premium}} ({{.Context.Tier}}){{end}}
+{{- with .Context.Limits.Daily}} (limit {{.}}){{end}}
\ No newline at end of file
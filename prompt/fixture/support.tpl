@@ -0,0 +1,10 @@
+{{system}}
+You are a support agent for {{.Data.Product}}.
+{{range .Data.Examples}}
+{{user}}
+{{.Question}}
+{{assistant}}
+{{.Answer}}
+{{end}}
+{{user}}
+{{.Data.Question}}
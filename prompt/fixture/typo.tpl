@@ -0,0 +1 @@
+{{if .Context.Redy}}Ready: {{end}}Hello {{ .Data.Name }}
\ No newline at end of file
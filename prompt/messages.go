@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// Role markers split a template into messages: every {{system}}, {{user}} or {{assistant}} starts a new message
+// with that role, e.g. a system prompt followed by few-shot turns rendered in a range loop
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// roleMarker is written by the role funcs and never appears in ordinary template output
+const roleMarker = "\x00goaikit:role:"
+
+// Message is one message rendered from a template
+type Message struct {
+	Role    string
+	Content string
+}
+
+// OpenAI converts the messages to chat completion messages, e.g. for kit.InvokeConfig.Messages
+func OpenAI(messages []Message) []openai.ChatCompletionMessageParamUnion {
+	converted := make([]openai.ChatCompletionMessageParamUnion, len(messages))
+	for i, message := range messages {
+		switch message.Role {
+		case RoleSystem:
+			converted[i] = openai.SystemMessage(message.Content)
+		case RoleAssistant:
+			converted[i] = openai.AssistantMessage(message.Content)
+		default:
+			converted[i] = openai.UserMessage(message.Content)
+		}
+	}
+	return converted
+}
+
+func (m *manager[Context]) ExecuteMessages(name string, args Render[Context]) ([]Message, error) {
+	text, err := m.Execute(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := splitMessages(text)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %w", name, err)
+	}
+	return messages, nil
+}
+
+// splitMessages cuts rendered text at the role markers, the content of each message is trimmed
+func splitMessages(text string) ([]Message, error) {
+	parts := strings.Split(text, roleMarker)
+	if strings.TrimSpace(parts[0]) != "" {
+		return nil, fmt.Errorf("text before the first role marker, start the template with {{system}} or {{user}}")
+	}
+
+	messages := make([]Message, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		role, content, _ := strings.Cut(part, "\x00")
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return nil, fmt.Errorf("empty %s message (message %d)", role, len(messages)+1)
+		}
+		messages = append(messages, Message{Role: role, Content: content})
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no role markers found")
+	}
+	return messages, nil
+}
+
+func roleFunc(role string) func() string {
+	return func() string {
+		return roleMarker + role + "\x00"
+	}
+}
@@ -2,13 +2,17 @@ package prompt
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"path/filepath"
 	"text/template"
+
+	"github.com/mhrlife/goai-kit/schema"
 )
 
 type Render[Context any] struct {
@@ -16,17 +20,36 @@ type Render[Context any] struct {
 	Data    any     `json:"data"`
 }
 
+// Rendered is a prompt together with the template and variables it was rendered from
+type Rendered struct {
+	Text      string
+	Template  string // name of the template file
+	Version   string // hash of the template source, changes whenever the template does
+	Variables any    // the Render passed to the template
+}
+
 type Template[Context any] interface {
 	Load(fs embed.FS) error
 	Execute(name string, data Render[Context]) (string, error)
+	// ExecuteRendered is Execute that also returns the template's name, version and variables, for tracing
+	ExecuteRendered(name string, data Render[Context]) (Rendered, error)
+	// ExecuteMessages renders a template split into messages by its {{system}}, {{user}} and {{assistant}} markers
+	ExecuteMessages(name string, data Render[Context]) ([]Message, error)
+	// Validate checks the fields a template references against the Context type and sampleData without executing it
+	Validate(name string, sampleData any) error
 }
 
 type manager[Context any] struct {
 	templateSet *template.Template
+	options     options
 }
 
-func NewTemplate[Context any]() Template[Context] {
-	return &manager[Context]{}
+func NewTemplate[Context any](opts ...Option) Template[Context] {
+	m := &manager[Context]{}
+	for _, opt := range opts {
+		opt(&m.options)
+	}
+	return m
 }
 
 func (m *manager[Context]) Load(fileSystem embed.FS) error {
@@ -54,7 +77,10 @@ func (m *manager[Context]) Load(fileSystem embed.FS) error {
 
 	slog.Debug("Loading templates", "files", templateFiles)
 
-	tmplSet, err := template.New("").Funcs(funcMap).ParseFS(fileSystem, templateFiles...)
+	tmplSet, err := template.New("").
+		Funcs(funcMap).
+		Funcs(template.FuncMap{"budget": m.options.budgetFunc}).
+		ParseFS(fileSystem, templateFiles...)
 	if err != nil {
 		return err
 	}
@@ -68,11 +94,51 @@ func (m *manager[Context]) Execute(name string, args Render[Context]) (string, e
 		return "", fmt.Errorf("templates not loaded")
 	}
 
-	// Try to find the template by name
-	var tmpl *template.Template
+	tmpl, err := m.lookup(name)
+	if err != nil {
+		return "", err
+	}
 
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, args); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (m *manager[Context]) ExecuteRendered(name string, args Render[Context]) (Rendered, error) {
+	text, err := m.Execute(name, args)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	tmpl, err := m.lookup(name)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	return Rendered{
+		Text:      text,
+		Template:  tmpl.Name(),
+		Version:   templateVersion(tmpl),
+		Variables: args,
+	}, nil
+}
+
+// templateVersion hashes the parsed template source
+func templateVersion(tmpl *template.Template) string {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(tmpl.Tree.Root.String()))
+	return hex.EncodeToString(sum[:6])
+}
+
+// lookup finds a template by name, with or without its file extension
+func (m *manager[Context]) lookup(name string) (*template.Template, error) {
 	// First try the exact name
-	tmpl = m.templateSet.Lookup(name)
+	tmpl := m.templateSet.Lookup(name)
 
 	// If not found, try with .tpl extension
 	if tmpl == nil {
@@ -90,15 +156,9 @@ func (m *manager[Context]) Execute(name string, args Render[Context]) (string, e
 	}
 
 	if tmpl == nil {
-		return "", fmt.Errorf("template %q not found", name)
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, args); err != nil {
-		return "", err
+		return nil, fmt.Errorf("template %q not found", name)
 	}
-
-	return buf.String(), nil
+	return tmpl, nil
 }
 
 func toJSONwSchema(v interface{}) string {
@@ -107,7 +167,7 @@ func toJSONwSchema(v interface{}) string {
 		return "Error converting to JSON: " + err.Error()
 	}
 
-	jsonschema := MarshalToSchema(v)
+	jsonschema := schema.MarshalToSchema(v)
 	jsonSchemaBytes, err := json.MarshalIndent(jsonschema, "", "  ")
 	if err != nil {
 		return "Error converting schema to JSON: " + err.Error()
@@ -140,4 +200,10 @@ func toJSON(v interface{}) string {
 var funcMap = template.FuncMap{
 	"toJSON":        toJSON,
 	"toJSONwSchema": toJSONwSchema,
+	"schema":        schemaFunc,
+	"example":       exampleFunc,
+	"fields":        fieldsFunc,
+	"system":        roleFunc(RoleSystem),
+	"user":          roleFunc(RoleUser),
+	"assistant":     roleFunc(RoleAssistant),
 }
@@ -62,3 +62,104 @@ func TestToJson(t *testing.T) {
 	require.Contains(t, render, `"name"`)
 	require.Contains(t, render, `"Ali"`)
 }
+
+func TestValidate(t *testing.T) {
+	type Order struct {
+		ID int
+	}
+	type Context struct {
+		Ready   bool
+		Premium bool
+		Tier    string
+		Limits  map[string]int
+	}
+
+	tpl := NewTemplate[Context]()
+	require.NoError(t, tpl.Load(tplFS))
+
+	require.NoError(t, tpl.Validate("hello", map[string]any{"Name": "World"}))
+	require.NoError(t, tpl.Validate("json", nil))
+	require.NoError(t, tpl.Validate("profile", map[string]any{
+		"User":   map[string]any{"Name": "Ali"},
+		"Orders": []Order{{ID: 1}},
+	}))
+
+	err := tpl.Validate("profile", map[string]any{
+		"User":   map[string]any{"Nmae": "Ali"},
+		"Orders": []Order{{ID: 1}},
+		"Locale": "en",
+	})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Missing, 1)
+	require.Contains(t, validationErr.Missing[0], "profile.tpl:1:")
+	require.Contains(t, validationErr.Missing[0], ": .Name")
+	require.Equal(t, []string{".Data.Locale"}, validationErr.Unused)
+
+	err = tpl.Validate("typo", map[string]any{"Name": "World"})
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Missing, 1)
+	require.Contains(t, validationErr.Missing[0], ".Context.Redy")
+	require.Empty(t, validationErr.Unused)
+
+	// Struct data is checked by its fields
+	err = tpl.Validate("hello", struct{ Title string }{Title: "x"})
+	require.ErrorAs(t, err, &validationErr)
+	require.Contains(t, validationErr.Missing[0], ".Data.Name")
+	require.Equal(t, []string{".Data.Title"}, validationErr.Unused)
+
+	require.Error(t, tpl.Validate("unknown", nil))
+}
+
+func TestExecuteRendered(t *testing.T) {
+	type Context struct {
+		Ready bool
+	}
+
+	tpl := NewTemplate[Context]()
+	require.NoError(t, tpl.Load(tplFS))
+
+	args := Render[Context]{Context: Context{Ready: true}, Data: map[string]any{"Name": "Amir"}}
+	rendered, err := tpl.ExecuteRendered("hello", args)
+	require.NoError(t, err)
+	require.Equal(t, "Ready: Hello Amir", rendered.Text)
+	require.Equal(t, "hello.tpl", rendered.Template)
+	require.Equal(t, args, rendered.Variables)
+	require.Len(t, rendered.Version, 12)
+
+	other, err := tpl.ExecuteRendered("json", Render[Context]{})
+	require.NoError(t, err)
+	require.NotEqual(t, rendered.Version, other.Version)
+}
+
+func TestExecuteMessages(t *testing.T) {
+	type Example struct {
+		Question string
+		Answer   string
+	}
+
+	tpl := NewTemplate[struct{}]()
+	require.NoError(t, tpl.Load(tplFS))
+
+	messages, err := tpl.ExecuteMessages("support", Render[struct{}]{Data: map[string]any{
+		"Product":  "goai-kit",
+		"Examples": []Example{{Question: "Is it free?", Answer: "Yes."}},
+		"Question": "Does it stream?",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []Message{
+		{Role: RoleSystem, Content: "You are a support agent for goai-kit."},
+		{Role: RoleUser, Content: "Is it free?"},
+		{Role: RoleAssistant, Content: "Yes."},
+		{Role: RoleUser, Content: "Does it stream?"},
+	}, messages)
+	require.Len(t, OpenAI(messages), 4)
+
+	_, err = tpl.ExecuteMessages("json", Render[struct{}]{})
+	require.ErrorContains(t, err, "before the first role marker")
+}
+
+func TestSplitMessagesRejectsEmptyMessages(t *testing.T) {
+	_, err := splitMessages(roleFunc(RoleSystem)() + "\n  \n" + roleFunc(RoleUser)() + "hi")
+	require.ErrorContains(t, err, "empty system message")
+}
@@ -0,0 +1,195 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/schema"
+)
+
+// structSchema returns the JSON schema of v without the fields that only matter to validators
+func structSchema(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot describe a nil value")
+	}
+	s := schema.MarshalToSchema(v)
+	delete(s, "$id")
+	delete(s, "$schema")
+	return s, nil
+}
+
+// schemaFunc renders the JSON schema of a Go value, e.g. {{schema .Data.Output}}
+func schemaFunc(v any) (string, error) {
+	s, err := structSchema(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	return string(b), err
+}
+
+// exampleFunc renders a synthetic JSON instance of a Go value's type, e.g. {{example .Data.Output}}
+// Values come from the example, default or enum tags of a field, placeholders are used otherwise
+func exampleFunc(v any) (string, error) {
+	s, err := structSchema(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(exampleValue(s), "", "  ")
+	return string(b), err
+}
+
+// fieldsFunc renders a markdown table of a Go value's fields, e.g. {{fields .Data.Output}}
+// Nested fields are listed with their path, "a.b" for objects and "a[].b" for arrays of objects
+func fieldsFunc(v any) (string, error) {
+	s, err := structSchema(v)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("| Field | Type | Required | Description |\n")
+	b.WriteString("|-------|------|----------|-------------|\n")
+	writeFieldRows(&b, s, "")
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func writeFieldRows(b *strings.Builder, s map[string]any, prefix string) {
+	properties, _ := s["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := s["required"].([]any); ok {
+		for _, name := range list {
+			if key, ok := name.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, _ := properties[name].(map[string]any)
+		path := prefix + name
+
+		requiredCell := "no"
+		if required[name] {
+			requiredCell = "yes"
+		}
+		description, _ := property["description"].(string)
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", path, typeLabel(property), requiredCell, tableCell(description))
+
+		if _, ok := property["properties"]; ok {
+			writeFieldRows(b, property, path+".")
+		} else if items, ok := property["items"].(map[string]any); ok {
+			if _, ok := items["properties"]; ok {
+				writeFieldRows(b, items, path+"[].")
+			}
+		}
+	}
+}
+
+// typeLabel describes a property's type, e.g. "array of string" or "string (one of a, b)"
+func typeLabel(property map[string]any) string {
+	label := typeNames(property["type"])
+	if label == "" {
+		label = "any"
+	}
+	if items, ok := property["items"].(map[string]any); ok && label == "array" {
+		label = "array of " + typeLabel(items)
+	}
+	if enum, ok := property["enum"].([]any); ok {
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			values[i] = fmt.Sprint(v)
+		}
+		label += " (one of " + strings.Join(values, ", ") + ")"
+	}
+	return label
+}
+
+func typeNames(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []any:
+		names := make([]string, len(t))
+		for i, name := range t {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return ""
+}
+
+func tableCell(text string) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// exampleValue builds a value matching s
+func exampleValue(s map[string]any) any {
+	if examples, ok := s["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, keyword := range []string{"const", "default"} {
+		if v, ok := s[keyword]; ok {
+			return v
+		}
+	}
+	if enum, ok := s["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if options, ok := s[keyword].([]any); ok && len(options) > 0 {
+			if option, ok := options[0].(map[string]any); ok {
+				return exampleValue(option)
+			}
+		}
+	}
+
+	typ := s["type"]
+	if list, ok := typ.([]any); ok && len(list) > 0 {
+		typ = list[0]
+	}
+	switch typ {
+	case "object":
+		object := map[string]any{}
+		properties, _ := s["properties"].(map[string]any)
+		for name, property := range properties {
+			if p, ok := property.(map[string]any); ok {
+				object[name] = exampleValue(p)
+			}
+		}
+		return object
+	case "array":
+		items, _ := s["items"].(map[string]any)
+		return []any{exampleValue(items)}
+	case "string":
+		switch s["format"] {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uri":
+			return "https://example.com"
+		}
+		return "string"
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	case "null":
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type reportSource struct {
+	URL   string `json:"url" jsonschema:"format=uri"`
+	Title string `json:"title,omitempty"`
+}
+
+type report struct {
+	Summary    string         `json:"summary" jsonschema_description:"One paragraph | no markdown"`
+	Confidence float64        `json:"confidence" jsonschema:"example=0.8"`
+	Status     string         `json:"status" jsonschema:"enum=draft,enum=final"`
+	Sources    []reportSource `json:"sources"`
+}
+
+func TestExampleFunc(t *testing.T) {
+	example, err := exampleFunc(report{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"summary": "string",
+		"confidence": 0.8,
+		"status": "draft",
+		"sources": [{"url": "https://example.com", "title": "string"}]
+	}`, example)
+}
+
+func TestFieldsFunc(t *testing.T) {
+	fields, err := fieldsFunc(report{})
+	require.NoError(t, err)
+	require.Equal(t, `| Field | Type | Required | Description |
+|-------|------|----------|-------------|
+| confidence | number | yes |  |
+| sources | array of object | yes |  |
+| sources[].title | string | no |  |
+| sources[].url | string | yes |  |
+| status | string (one of draft, final) | yes |  |
+| summary | string | yes | One paragraph \| no markdown |`, fields)
+}
+
+func TestSchemaFuncs(t *testing.T) {
+	tpl := NewTemplate[struct{}]()
+	require.NoError(t, tpl.Load(tplFS))
+
+	rendered, err := tpl.Execute("describe", Render[struct{}]{Data: report{}})
+	require.NoError(t, err)
+	require.Contains(t, rendered, `"required": [`)
+	require.Contains(t, rendered, "| summary | string | yes |")
+	require.Contains(t, rendered, `"confidence": 0.8`)
+	require.NotContains(t, rendered, "$id")
+
+	_, err = tpl.Execute("describe", Render[struct{}]{})
+	require.ErrorContains(t, err, "nil value")
+}
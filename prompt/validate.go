@@ -0,0 +1,354 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ValidationError lists the problems Validate found in a template
+type ValidationError struct {
+	Template string
+	Missing  []string // references to fields that don't exist, prefixed by their location
+	Unused   []string // fields of the sample data no template action references
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	for _, m := range e.Missing {
+		parts = append(parts, "missing "+m)
+	}
+	for _, u := range e.Unused {
+		parts = append(parts, "unused "+u)
+	}
+	return fmt.Sprintf("template %q: %s", e.Template, strings.Join(parts, "; "))
+}
+
+// Validate walks the template without executing it and checks every field it references
+// .Context fields are checked against the Context type, .Data fields against sampleData (a map or a struct)
+// Top-level fields of sampleData the template never references are reported as unused, Context fields aren't
+// since a Context is usually shared by many templates. A nil sampleData skips the checks of .Data
+func (m *manager[Context]) Validate(name string, sampleData any) error {
+	if m.templateSet == nil {
+		return fmt.Errorf("templates not loaded")
+	}
+
+	tmpl, err := m.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	v := &validator{
+		set:      m.templateSet,
+		dataUsed: make(map[string]bool),
+		visited:  make(map[string]bool),
+	}
+	root := scopeOf(reflect.ValueOf(Render[Context]{Data: sampleData}), nil)
+	root.root = true
+	v.walkTemplate(tmpl, root)
+
+	result := &ValidationError{Template: tmpl.Name(), Missing: v.missing}
+	if !v.dataWhole {
+		for _, field := range dataFields(sampleData) {
+			if !v.dataUsed[field] {
+				result.Unused = append(result.Unused, ".Data."+field)
+			}
+		}
+	}
+
+	if len(result.Missing) == 0 && len(result.Unused) == 0 {
+		return nil
+	}
+	return result
+}
+
+// scope is what dot or a variable holds at some point of the template
+// typ is nil when it can't be known statically (e.g. an interface), such scopes are never checked
+// value is the sample value when there is one, it tells which keys a map has
+type scope struct {
+	typ   reflect.Type
+	value reflect.Value
+	root  bool // the scope is the Render passed to the template
+	data  bool // the scope is .Data itself, fields read from it are tracked for the unused check
+}
+
+func scopeOf(value reflect.Value, typ reflect.Type) scope {
+	for value.IsValid() && (value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer) {
+		if value.IsNil() {
+			typ = value.Type()
+			value = reflect.Value{}
+			break
+		}
+		value = value.Elem()
+	}
+	if value.IsValid() {
+		return scope{typ: value.Type(), value: value}
+	}
+
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ != nil && typ.Kind() == reflect.Interface {
+		typ = nil
+	}
+	return scope{typ: typ}
+}
+
+type validator struct {
+	set       *template.Template
+	tree      *parse.Tree
+	missing   []string
+	dataUsed  map[string]bool
+	dataWhole bool // .Data is used as a whole, e.g. printed or passed to a function
+	visited   map[string]bool
+}
+
+func (v *validator) walkTemplate(tmpl *template.Template, dot scope) {
+	if tmpl.Tree == nil || v.visited[tmpl.Name()] {
+		return
+	}
+	v.visited[tmpl.Name()] = true
+
+	previous := v.tree
+	v.tree = tmpl.Tree
+	v.walk(tmpl.Tree.Root, dot, map[string]scope{"$": dot})
+	v.tree = previous
+}
+
+func (v *validator) walk(node parse.Node, dot scope, vars map[string]scope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			v.walk(child, dot, vars)
+		}
+	case *parse.ActionNode:
+		v.use(v.pipe(n.Pipe, dot, vars))
+	case *parse.IfNode:
+		v.use(v.pipe(n.Pipe, dot, copyVars(vars)))
+		v.walk(n.List, dot, copyVars(vars))
+		v.walk(n.ElseList, dot, copyVars(vars))
+	case *parse.WithNode:
+		inner := copyVars(vars)
+		v.walk(n.List, v.pipe(n.Pipe, dot, inner), inner)
+		v.walk(n.ElseList, dot, copyVars(vars))
+	case *parse.RangeNode:
+		inner := copyVars(vars)
+		ranged := v.pipe(n.Pipe, dot, inner)
+		v.use(ranged)
+		elem := elemScope(ranged)
+		if len(n.Pipe.Decl) > 0 {
+			inner[n.Pipe.Decl[len(n.Pipe.Decl)-1].Ident[0]] = elem
+			if len(n.Pipe.Decl) == 2 {
+				inner[n.Pipe.Decl[0].Ident[0]] = scope{}
+			}
+		}
+		v.walk(n.List, elem, inner)
+		v.walk(n.ElseList, dot, copyVars(vars))
+	case *parse.TemplateNode:
+		arg := scope{}
+		if n.Pipe != nil {
+			arg = v.pipe(n.Pipe, dot, vars)
+			v.use(arg)
+		}
+		if tmpl := v.set.Lookup(n.Name); tmpl != nil {
+			v.walkTemplate(tmpl, arg)
+		}
+	}
+}
+
+// pipe checks a pipeline and returns the scope of its result
+func (v *validator) pipe(pipe *parse.PipeNode, dot scope, vars map[string]scope) scope {
+	if pipe == nil {
+		return scope{}
+	}
+
+	result := scope{}
+	for i, cmd := range pipe.Cmds {
+		if i > 0 {
+			// the previous result is the last argument of this command
+			v.use(result)
+		}
+		result = v.command(cmd, dot, vars)
+	}
+
+	for _, decl := range pipe.Decl {
+		vars[decl.Ident[0]] = result
+	}
+	return result
+}
+
+func (v *validator) command(cmd *parse.CommandNode, dot scope, vars map[string]scope) scope {
+	if len(cmd.Args) == 0 {
+		return scope{}
+	}
+
+	for _, arg := range cmd.Args[1:] {
+		v.use(v.arg(arg, dot, vars))
+	}
+	if _, isFunc := cmd.Args[0].(*parse.IdentifierNode); isFunc {
+		return scope{}
+	}
+	return v.arg(cmd.Args[0], dot, vars)
+}
+
+func (v *validator) arg(node parse.Node, dot scope, vars map[string]scope) scope {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return v.fields(n, dot, n.Ident)
+	case *parse.VariableNode:
+		return v.fields(n, vars[n.Ident[0]], n.Ident[1:])
+	case *parse.ChainNode:
+		return v.fields(n, v.arg(n.Node, dot, vars), n.Field)
+	case *parse.PipeNode:
+		return v.pipe(n, dot, copyVars(vars))
+	}
+	return scope{}
+}
+
+// fields resolves a chain of field names, reporting the first one that doesn't exist
+func (v *validator) fields(node parse.Node, s scope, names []string) scope {
+	for _, name := range names {
+		if s.data {
+			v.dataUsed[name] = true
+		}
+
+		next, ok := s.field(name)
+		if !ok {
+			location, _ := v.tree.ErrorContext(node)
+			v.missing = append(v.missing, location+": "+node.String())
+			return scope{}
+		}
+		s = next
+	}
+	return s
+}
+
+// use records a scope used as a value, which uses every field of .Data
+func (v *validator) use(s scope) {
+	if s.data {
+		v.dataWhole = true
+	}
+}
+
+// field returns the scope of a field, key or method of s, ok is false when it certainly doesn't exist
+func (s scope) field(name string) (scope, bool) {
+	if s.typ == nil {
+		return scope{}, true
+	}
+
+	if method, ok := methodType(s.typ, name); ok {
+		if method.NumOut() == 0 {
+			return scope{}, false
+		}
+		return scopeOf(reflect.Value{}, method.Out(0)), true
+	}
+
+	switch s.typ.Kind() {
+	case reflect.Struct:
+		field, ok := s.typ.FieldByName(name)
+		if !ok || !field.IsExported() {
+			return scope{}, false
+		}
+		var value reflect.Value
+		if s.value.IsValid() {
+			value, _ = s.value.FieldByIndexErr(field.Index)
+		}
+		next := scopeOf(value, field.Type)
+		next.data = s.root && name == "Data"
+		return next, true
+	case reflect.Map:
+		if s.typ.Key().Kind() != reflect.String {
+			return scope{}, true
+		}
+		if !s.value.IsValid() || s.value.IsNil() {
+			// without sample keys any key may exist
+			return scopeOf(reflect.Value{}, s.typ.Elem()), true
+		}
+		value := s.value.MapIndex(reflect.ValueOf(name).Convert(s.typ.Key()))
+		if !value.IsValid() {
+			return scope{}, false
+		}
+		return scopeOf(value, s.typ.Elem()), true
+	}
+	return scope{}, false
+}
+
+// elemScope is the scope of dot inside a range over s
+func elemScope(s scope) scope {
+	if s.typ == nil {
+		return scope{}
+	}
+
+	switch s.typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if s.value.IsValid() && s.value.Len() > 0 {
+			return scopeOf(s.value.Index(0), s.typ.Elem())
+		}
+		return scopeOf(reflect.Value{}, s.typ.Elem())
+	case reflect.Map:
+		if s.value.IsValid() && s.value.Len() > 0 {
+			iter := s.value.MapRange()
+			iter.Next()
+			return scopeOf(iter.Value(), s.typ.Elem())
+		}
+		return scopeOf(reflect.Value{}, s.typ.Elem())
+	case reflect.Chan:
+		return scopeOf(reflect.Value{}, s.typ.Elem())
+	}
+	return scope{}
+}
+
+func methodType(typ reflect.Type, name string) (reflect.Type, bool) {
+	if method, ok := typ.MethodByName(name); ok {
+		return method.Type, true
+	}
+	if typ.Kind() != reflect.Interface {
+		if method, ok := reflect.PointerTo(typ).MethodByName(name); ok {
+			return method.Type, true
+		}
+	}
+	return nil, false
+}
+
+// dataFields returns the top-level keys of a map or the exported fields of a struct
+func dataFields(data any) []string {
+	s := scopeOf(reflect.ValueOf(data), nil)
+	if s.typ == nil {
+		return nil
+	}
+
+	var fields []string
+	switch s.typ.Kind() {
+	case reflect.Map:
+		if s.typ.Key().Kind() != reflect.String || !s.value.IsValid() {
+			return nil
+		}
+		for _, key := range s.value.MapKeys() {
+			fields = append(fields, key.String())
+		}
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(s.typ) {
+			if field.IsExported() && !field.Anonymous {
+				fields = append(fields, field.Name)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func copyVars(vars map[string]scope) map[string]scope {
+	copied := make(map[string]scope, len(vars))
+	for k, val := range vars {
+		copied[k] = val
+	}
+	return copied
+}
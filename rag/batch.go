@@ -0,0 +1,376 @@
+package rag
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/openai/openai-go"
+)
+
+const defaultQASystemPrompt = `Answer the question using only the provided documents.
+Cite every document you use by its ID in square brackets, e.g. [doc-1].
+If the documents do not contain the answer, say that you don't know.`
+
+// BatchQAConfig configures BatchQA
+type BatchQAConfig struct {
+	// VectorDB is used to retrieve documents for each question (required)
+	VectorDB vectordb.Client
+
+	// Agent answers each question given the retrieved documents (required)
+	Agent *kit.Agent[string]
+
+	// TopK is the number of documents retrieved per question (defaults to 5)
+	TopK int
+
+	// Filters are applied to every retrieval (optional)
+	Filters []vectordb.Filter
+
+	// Concurrency is the number of questions processed in parallel (defaults to 4)
+	Concurrency int
+
+	// RequestsPerSecond limits how many questions are started per second (0 disables the limit)
+	RequestsPerSecond float64
+
+	// SystemPrompt overrides the default answering instructions (optional)
+	SystemPrompt string
+
+	// Verifier checks every answer against the retrieved documents (optional)
+	Verifier *GroundingVerifier
+
+	// OnUnsupported is applied to answers with unsupported claims (optional, defaults to GroundingAnnotate)
+	OnUnsupported GroundingAction
+}
+
+// Citation references a retrieved document used in an answer
+type Citation struct {
+	DocumentID string `json:"document_id"`
+	Score      string `json:"score"`
+	Cited      bool   `json:"cited"` // true when the answer referenced the document explicitly
+}
+
+// TokenUsage sums token usage over every generation of a run
+type TokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// QAResult is the outcome of a single question
+type QAResult struct {
+	Question  string        `json:"question"`
+	Answer    string        `json:"answer"`
+	Citations []Citation    `json:"citations"`
+	Usage     TokenUsage    `json:"usage"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+
+	// Cost is the USD cost of the question's generations with the client's pricing table (see kit.WithPricing),
+	// nil without a table, when a model has no price or when retrieval failed before any generation
+	Cost *float64 `json:"cost_usd,omitempty"`
+
+	// Grounding is the verdict on the claims of the answer, set when BatchQAConfig.Verifier is used
+	Grounding *GroundingReport `json:"grounding,omitempty"`
+}
+
+// BatchSummary totals the results of a BatchQA run
+type BatchSummary struct {
+	Questions int        `json:"questions"`
+	Failed    int        `json:"failed"`
+	Usage     TokenUsage `json:"usage"`
+	Cost      float64    `json:"cost_usd"` // sum of the priced questions
+	Unpriced  int        `json:"unpriced"` // questions without a cost, e.g. failed retrievals, see QAResult.Cost
+}
+
+// Summarize totals the usage and cost of results
+func Summarize(results []QAResult) BatchSummary {
+	summary := BatchSummary{Questions: len(results)}
+	for _, r := range results {
+		if r.Error != "" {
+			summary.Failed++
+		}
+		summary.Usage.PromptTokens += r.Usage.PromptTokens
+		summary.Usage.CompletionTokens += r.Usage.CompletionTokens
+		summary.Usage.TotalTokens += r.Usage.TotalTokens
+		if r.Cost == nil {
+			summary.Unpriced++
+			continue
+		}
+		summary.Cost += *r.Cost
+	}
+	return summary
+}
+
+// BatchQA answers every question with retrieval + agent concurrently
+// Failures are reported per question in QAResult.Error; results keep the order of questions
+func BatchQA(ctx context.Context, config BatchQAConfig, questions []string) ([]QAResult, error) {
+	if config.VectorDB == nil || config.Agent == nil {
+		return nil, fmt.Errorf("VectorDB and Agent are required")
+	}
+	if config.TopK <= 0 {
+		config.TopK = 5
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = defaultQASystemPrompt
+	}
+
+	var throttle <-chan time.Time
+	if config.RequestsPerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / config.RequestsPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	results := make([]QAResult, len(questions))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < config.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = answerQuestion(ctx, config, questions[i])
+			}
+		}()
+	}
+
+	var err error
+dispatch:
+	for i := range questions {
+		if throttle != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break dispatch
+			case <-throttle:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, err
+}
+
+// answerQuestion retrieves documents and asks the agent for a single question
+func answerQuestion(ctx context.Context, config BatchQAConfig, question string) QAResult {
+	start := time.Now()
+	result := QAResult{Question: question, Citations: []Citation{}}
+
+	docs, err := config.VectorDB.SearchDocuments(ctx, vectordb.DocumentSearch{
+		Query:   question,
+		TopK:    config.TopK,
+		Filters: config.Filters,
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("retrieval failed: %v", err)
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	usage := &usageCollector{}
+	cost := &runCost{}
+	prompt := formatQuestion(question, docs)
+	run, err := config.Agent.InvokeWithResult(ctx, kit.InvokeConfig{
+		SystemPrompt: config.SystemPrompt,
+		Prompt:       prompt,
+		Callbacks:    []callback.AgentCallback{usage},
+	})
+	cost.add(run)
+	answer := run.Output
+	if err == nil && config.Verifier != nil {
+		answer, result.Grounding, err = verifyAnswer(ctx, config, prompt, answer, docs, usage, cost)
+	}
+	result.Usage = usage.total()
+	result.Cost = cost.total()
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("answer failed: %v", err)
+		return result
+	}
+
+	result.Answer = answer
+	cited := citedIDs(answer)
+	for _, doc := range docs {
+		_, ok := cited[doc.ID]
+		result.Citations = append(result.Citations, Citation{
+			DocumentID: doc.ID,
+			Score:      doc.Score,
+			Cited:      ok,
+		})
+	}
+
+	return result
+}
+
+// verifyAnswer checks the grounding of an answer, asks once more with the unsupported claims when configured,
+// and annotates the claims that remain unsupported
+func verifyAnswer(
+	ctx context.Context,
+	config BatchQAConfig,
+	prompt, answer string,
+	docs []vectordb.DocumentWithScore,
+	usage *usageCollector,
+	cost *runCost,
+) (string, *GroundingReport, error) {
+	report, err := config.Verifier.Verify(ctx, answer, docs)
+	if err != nil {
+		return answer, nil, err
+	}
+
+	if !report.Grounded() && config.OnUnsupported == GroundingReask {
+		run, err := config.Agent.InvokeWithResult(ctx, kit.InvokeConfig{
+			SystemPrompt: config.SystemPrompt,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.UserMessage(prompt),
+				openai.AssistantMessage(answer),
+				openai.UserMessage(report.Feedback()),
+			},
+			Callbacks: []callback.AgentCallback{usage},
+		})
+		cost.add(run)
+		answer = run.Output
+		if err != nil {
+			return answer, &report, err
+		}
+		if report, err = config.Verifier.Verify(ctx, answer, docs); err != nil {
+			return answer, nil, err
+		}
+	}
+
+	return report.Annotate(answer), &report, nil
+}
+
+// formatQuestion renders the retrieved documents and the question into a prompt
+func formatQuestion(question string, docs []vectordb.DocumentWithScore) string {
+	var sb strings.Builder
+	sb.WriteString("Documents:\n\n")
+	for _, doc := range docs {
+		fmt.Fprintf(&sb, "[%s]\n%s\n\n", doc.ID, doc.Content)
+	}
+	fmt.Fprintf(&sb, "Question: %s", question)
+	return sb.String()
+}
+
+var citationPattern = regexp.MustCompile(`\[([^\[\]]+)\]`)
+
+// citedIDs extracts [id] references from an answer
+func citedIDs(answer string) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, id := range strings.Split(match[1], ",") {
+			ids[strings.TrimSpace(id)] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// WriteCSV writes results as a table with one row per question
+func WriteCSV(w io.Writer, results []QAResult) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"question", "answer", "citations", "prompt_tokens", "completion_tokens", "duration_ms", "error", "cost_usd",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		var cited []string
+		for _, c := range r.Citations {
+			if c.Cited {
+				cited = append(cited, c.DocumentID)
+			}
+		}
+		cost := ""
+		if r.Cost != nil {
+			cost = strconv.FormatFloat(*r.Cost, 'f', -1, 64)
+		}
+		row := []string{
+			r.Question,
+			r.Answer,
+			strings.Join(cited, ";"),
+			fmt.Sprint(r.Usage.PromptTokens),
+			fmt.Sprint(r.Usage.CompletionTokens),
+			fmt.Sprint(r.Duration.Milliseconds()),
+			r.Error,
+			cost,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// usageCollector sums the token usage reported by generation callbacks
+type usageCollector struct {
+	callback.BaseCallback
+
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+func (u *usageCollector) Name() string {
+	return "RAGUsageCollector"
+}
+
+func (u *usageCollector) OnGenerationEnd(ctx map[string]interface{}) {
+	usage, ok := ctx["usage"].(*openai.CompletionUsage)
+	if !ok || usage == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.usage.PromptTokens += usage.PromptTokens
+	u.usage.CompletionTokens += usage.CompletionTokens
+	u.usage.TotalTokens += usage.TotalTokens
+}
+
+func (u *usageCollector) total() TokenUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.usage
+}
+
+// runCost sums the cost of the agent runs of a question
+type runCost struct {
+	usd      float64
+	unpriced bool
+}
+
+func (c *runCost) add(run kit.Result[string]) {
+	usd, ok := run.Cost()
+	c.usd += usd
+	c.unpriced = c.unpriced || !ok
+}
+
+func (c *runCost) total() *float64 {
+	if c.unpriced {
+		return nil
+	}
+	return &c.usd
+}
@@ -0,0 +1,130 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+// staticVectorDB returns the same documents for every query, and fails for the queries in failing
+type staticVectorDB struct {
+	memoryVectorDB
+	docs    []vectordb.DocumentWithScore
+	failing map[string]bool
+}
+
+func (s *staticVectorDB) SearchDocuments(_ context.Context, search vectordb.DocumentSearch) ([]vectordb.DocumentWithScore, error) {
+	if s.failing[search.Query] {
+		return nil, errors.New("index unavailable")
+	}
+	return s.docs[:min(search.TopK, len(s.docs))], nil
+}
+
+func TestBatchQA(t *testing.T) {
+	db := &staticVectorDB{
+		docs: []vectordb.DocumentWithScore{
+			{Document: vectordb.Document{ID: "doc-1", Content: "Paris is the capital of France."}, Score: "0.1"},
+			{Document: vectordb.Document{ID: "doc-2", Content: "Berlin is the capital of Germany."}, Score: "0.2"},
+			{Document: vectordb.Document{ID: "doc-3", Content: "Rome is the capital of Italy."}, Score: "0.3"},
+		},
+		failing: map[string]bool{"capital of Spain?": true},
+	}
+	chat, client := newFakeChat(t, "Paris [doc-1]", "Berlin [doc-2, doc-1]")
+
+	results, err := BatchQA(context.Background(), BatchQAConfig{
+		VectorDB:    db,
+		Agent:       kit.CreateAgent(client),
+		TopK:        2,
+		Concurrency: 1,
+	}, []string{"capital of France?", "capital of Spain?", "capital of Germany?"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.Equal(t, "Paris [doc-1]", results[0].Answer)
+	require.Equal(t, []Citation{
+		{DocumentID: "doc-1", Score: "0.1", Cited: true},
+		{DocumentID: "doc-2", Score: "0.2", Cited: false},
+	}, results[0].Citations)
+
+	require.Equal(t, "capital of Spain?", results[1].Question)
+	require.Equal(t, "retrieval failed: index unavailable", results[1].Error)
+	require.Empty(t, results[1].Answer)
+
+	require.True(t, results[2].Citations[0].Cited)
+	require.True(t, results[2].Citations[1].Cited)
+
+	messages := chat.messages()
+	require.Len(t, messages, 2, "a failed retrieval doesn't call the model")
+	require.Equal(t, defaultQASystemPrompt, messages[0][0]["content"])
+	require.Equal(t, "Documents:\n\n[doc-1]\nParis is the capital of France.\n\n"+
+		"[doc-2]\nBerlin is the capital of Germany.\n\nQuestion: capital of France?", messages[0][1]["content"])
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, results))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	require.Equal(t, []string{"capital of France?", "Paris [doc-1]", "doc-1"}, rows[1][:3])
+	require.Equal(t, "doc-1;doc-2", rows[3][2], "in retrieval order")
+	require.Equal(t, "retrieval failed: index unavailable", rows[2][6])
+}
+
+func TestBatchQACost(t *testing.T) {
+	db := &staticVectorDB{
+		docs:    []vectordb.DocumentWithScore{{Document: vectordb.Document{ID: "doc-1", Content: "Paris."}, Score: "0.1"}},
+		failing: map[string]bool{"capital of Spain?": true},
+	}
+	questions := []string{"capital of France?", "capital of Spain?", "capital of Germany?"}
+
+	// $2 per million prompt tokens and $10 per million completion tokens
+	pricing := kit.PricingTable{"test-model": {Input: 2, Output: 10}}
+	_, client := newFakeChatWithOptions(t, []kit.ClientOption{kit.WithPricing(pricing)}, "Paris [doc-1]", "Berlin")
+	results, err := BatchQA(context.Background(), BatchQAConfig{
+		VectorDB: db, Agent: kit.CreateAgent(client), Concurrency: 1,
+	}, questions)
+	require.NoError(t, err)
+
+	require.NotNil(t, results[0].Cost)
+	require.InDelta(t, 0.0004, *results[0].Cost, 1e-12)
+	require.Equal(t, TokenUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, results[0].Usage)
+	require.Nil(t, results[1].Cost, "a failed retrieval runs no generation")
+
+	summary := Summarize(results)
+	require.Equal(t, 3, summary.Questions)
+	require.Equal(t, 1, summary.Failed)
+	require.Equal(t, int64(240), summary.Usage.TotalTokens)
+	require.InDelta(t, 0.0008, summary.Cost, 1e-12)
+	require.Equal(t, 1, summary.Unpriced)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, results))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "cost_usd", rows[0][7])
+	require.Equal(t, "0.0004", rows[1][7])
+
+	// without a pricing table nothing is priced
+	_, client = newFakeChat(t, "Paris [doc-1]", "Berlin")
+	results, err = BatchQA(context.Background(), BatchQAConfig{
+		VectorDB: db, Agent: kit.CreateAgent(client), Concurrency: 1,
+	}, questions)
+	require.NoError(t, err)
+	require.Nil(t, results[0].Cost)
+	require.Equal(t, 3, Summarize(results).Unpriced)
+}
+
+func TestBatchQARequiresVectorDBAndAgent(t *testing.T) {
+	_, err := BatchQA(context.Background(), BatchQAConfig{}, []string{"q"})
+	require.ErrorContains(t, err, "VectorDB and Agent are required")
+}
+
+func TestCitedIDs(t *testing.T) {
+	require.Equal(t, map[string]struct{}{"a": {}, "b": {}, "c": {}}, citedIDs("see [a] and [b, c]"))
+	require.Empty(t, citedIDs("no citations"))
+}
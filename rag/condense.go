@@ -0,0 +1,145 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/openai/openai-go"
+)
+
+const defaultCondensePrompt = `<conversation>
+%s
+</conversation>
+Rewrite the follow-up question below as a standalone search query, resolving references like "it", "that one" or "the cheaper one" from the conversation. Keep the language of the question. Answer only with the query and nothing else.
+<question>
+%s
+</question>`
+
+// CondenserConfig configures a QueryCondenser
+type CondenserConfig struct {
+	// Agent rewrites follow-up questions (required), a small, cheap model is usually enough
+	Agent *kit.Agent[string]
+
+	// Prompt overrides the default instructions, it receives the conversation and the question as two %s verbs
+	Prompt string
+
+	// MaxMessages is the number of most recent user and assistant messages shown to the agent (defaults to 6)
+	MaxMessages int
+
+	// MaxMessageChars truncates every message shown to the agent (defaults to 1000)
+	MaxMessageChars int
+}
+
+// QueryCondenser turns a follow-up question of a chat into a standalone retrieval query, so
+// "what about the cheaper one?" retrieves the documents of the product talked about
+type QueryCondenser struct {
+	config CondenserConfig
+}
+
+func NewQueryCondenser(config CondenserConfig) *QueryCondenser {
+	if config.Prompt == "" {
+		config.Prompt = defaultCondensePrompt
+	}
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = 6
+	}
+	if config.MaxMessageChars <= 0 {
+		config.MaxMessageChars = 1000
+	}
+	return &QueryCondenser{config: config}
+}
+
+// Condense rewrites the question using the chat history before it, without history it is returned as is
+func (c *QueryCondenser) Condense(ctx context.Context, history []openai.ChatCompletionMessageParamUnion, question string) (string, error) {
+	if c.config.Agent == nil {
+		return "", fmt.Errorf("condenser agent is required")
+	}
+
+	conversation := c.formatHistory(history)
+	if conversation == "" {
+		return question, nil
+	}
+
+	query, err := c.config.Agent.Invoke(ctx, kit.InvokeConfig{
+		Prompt: fmt.Sprintf(c.config.Prompt, conversation, question),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to condense question: %w", err)
+	}
+
+	query = strings.Trim(strings.TrimSpace(query), `"`)
+	if query == "" {
+		return question, nil
+	}
+	return query, nil
+}
+
+// Search condenses search.Query with the chat history and runs the search
+func (c *QueryCondenser) Search(
+	ctx context.Context,
+	db vectordb.Client,
+	history []openai.ChatCompletionMessageParamUnion,
+	search vectordb.DocumentSearch,
+) ([]vectordb.DocumentWithScore, error) {
+	query, err := c.Condense(ctx, history, search.Query)
+	if err != nil {
+		return nil, err
+	}
+	search.Query = query
+	return db.SearchDocuments(ctx, search)
+}
+
+// formatHistory renders the last user and assistant messages with text, tool calls and system prompts are left out
+func (c *QueryCondenser) formatHistory(history []openai.ChatCompletionMessageParamUnion) string {
+	var lines []string
+	for i := len(history) - 1; i >= 0 && len(lines) < c.config.MaxMessages; i-- {
+		role, text := messageText(history[i])
+		if text == "" {
+			continue
+		}
+		if len(text) > c.config.MaxMessageChars {
+			text = truncateText(text, c.config.MaxMessageChars) + "..."
+		}
+		lines = append(lines, role+": "+text)
+	}
+
+	var b strings.Builder
+	for i := len(lines) - 1; i >= 0; i-- {
+		b.WriteString(lines[i])
+		if i > 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// messageText returns the role and text of a user or assistant message, "" for other messages
+func messageText(m openai.ChatCompletionMessageParamUnion) (string, string) {
+	var parts []string
+	switch {
+	case m.OfUser != nil:
+		if m.OfUser.Content.OfString.Valid() {
+			return "user", strings.TrimSpace(m.OfUser.Content.OfString.Value)
+		}
+		for _, part := range m.OfUser.Content.OfArrayOfContentParts {
+			if part.OfText != nil {
+				parts = append(parts, part.OfText.Text)
+			}
+		}
+		return "user", strings.TrimSpace(strings.Join(parts, "\n"))
+	case m.OfAssistant != nil:
+		if m.OfAssistant.Content.OfString.Valid() {
+			return "assistant", strings.TrimSpace(m.OfAssistant.Content.OfString.Value)
+		}
+		for _, part := range m.OfAssistant.Content.OfArrayOfContentParts {
+			if part.OfText != nil {
+				parts = append(parts, part.OfText.Text)
+			}
+		}
+		return "assistant", strings.TrimSpace(strings.Join(parts, "\n"))
+	}
+	return "", ""
+}
@@ -0,0 +1,36 @@
+package rag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCondenser(t *testing.T) {
+	chat, client := newFakeChat(t, `"price of the Galaxy A15"`)
+	condenser := NewQueryCondenser(CondenserConfig{Agent: kit.CreateAgent(client), MaxMessages: 2})
+
+	history := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are a shop assistant."),
+		openai.UserMessage("Which Samsung phones do you have?"),
+		openai.AssistantMessage("The Galaxy S24 and the Galaxy A15."),
+		openai.UserMessage("Is the S24 waterproof?"),
+		openai.AssistantMessage("Yes, it is rated IP68."),
+	}
+	query, err := condenser.Condense(context.Background(), history, "what about the cheaper one?")
+	require.NoError(t, err)
+	require.Equal(t, "price of the Galaxy A15", query)
+
+	prompt := chat.messages()[0][0]["content"].(string)
+	require.Contains(t, prompt, "<conversation>\nuser: Is the S24 waterproof?\nassistant: Yes, it is rated IP68.\n</conversation>")
+	require.Contains(t, prompt, "<question>\nwhat about the cheaper one?\n</question>")
+
+	// a first question needs no rewriting
+	query, err = condenser.Condense(context.Background(), history[:1], "Which phones do you have?")
+	require.NoError(t, err)
+	require.Equal(t, "Which phones do you have?", query)
+	require.Len(t, chat.messages(), 1)
+}
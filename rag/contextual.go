@@ -0,0 +1,207 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+const defaultContextPrompt = `<document>
+%s
+</document>
+Here is the chunk we want to situate within the whole document
+<chunk>
+%s
+</chunk>
+Please give a short succinct context to situate this chunk within the overall document for the purposes of improving search retrieval of the chunk. Answer only with the succinct context and nothing else.`
+
+// ContextCache stores generated chunk contexts so re-ingesting unchanged content costs nothing
+type ContextCache interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+}
+
+// MemoryContextCache is an in-process ContextCache
+type MemoryContextCache struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func NewMemoryContextCache() *MemoryContextCache {
+	return &MemoryContextCache{values: make(map[string]string)}
+}
+
+func (c *MemoryContextCache) Get(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.values[key]
+	return v, ok
+}
+
+func (c *MemoryContextCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+// ContextualizerConfig configures a Contextualizer
+type ContextualizerConfig struct {
+	// Agent writes the context of each chunk (required), a small, cheap model is usually enough
+	Agent *kit.Agent[string]
+
+	// Cache keeps generated contexts keyed by document, chunk and prompt (defaults to an in-memory cache)
+	Cache ContextCache
+
+	// Prompt overrides the default instructions, it receives the document and the chunk as two %s verbs
+	Prompt string
+
+	// MaxDocumentChars truncates the document shown to the agent (defaults to 20000)
+	MaxDocumentChars int
+
+	// Concurrency is the number of chunks contextualized in parallel (defaults to 4)
+	Concurrency int
+}
+
+// Contextualizer implements contextual retrieval: every chunk is prefixed with a short
+// LLM generated description of where it sits in its document before it is embedded
+type Contextualizer struct {
+	config ContextualizerConfig
+}
+
+func NewContextualizer(config ContextualizerConfig) *Contextualizer {
+	if config.Cache == nil {
+		config.Cache = NewMemoryContextCache()
+	}
+	if config.Prompt == "" {
+		config.Prompt = defaultContextPrompt
+	}
+	if config.MaxDocumentChars <= 0 {
+		config.MaxDocumentChars = 20000
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	return &Contextualizer{config: config}
+}
+
+// Contextualize returns copies of the chunks with their context prepended to the content
+func (c *Contextualizer) Contextualize(
+	ctx context.Context,
+	parent vectordb.Document,
+	chunks []vectordb.Document,
+) ([]vectordb.Document, error) {
+	if c.config.Agent == nil {
+		return nil, fmt.Errorf("contextualizer agent is required")
+	}
+
+	document := truncateText(parent.Content, c.config.MaxDocumentChars)
+
+	result := make([]vectordb.Document, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, c.config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			chunkContext, err := c.chunkContext(ctx, document, chunk.Content)
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %s: %w", chunk.ID, err)
+				return
+			}
+
+			chunk.Content = chunkContext + "\n\n" + chunk.Content
+			result[i] = chunk
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// chunkContext generates the context of a chunk or loads it from the cache
+func (c *Contextualizer) chunkContext(ctx context.Context, document, chunk string) (string, error) {
+	sum := sha256.Sum256([]byte(c.config.Prompt + "\x00" + document + "\x00" + chunk))
+	key := hex.EncodeToString(sum[:])
+
+	if cached, ok := c.config.Cache.Get(key); ok {
+		return cached, nil
+	}
+
+	answer, err := c.config.Agent.Invoke(ctx, kit.InvokeConfig{
+		Prompt: fmt.Sprintf(c.config.Prompt, document, chunk),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate context: %w", err)
+	}
+
+	answer = strings.TrimSpace(answer)
+	c.config.Cache.Set(key, answer)
+	return answer, nil
+}
+
+// truncateText cuts text to at most n bytes without splitting a character
+func truncateText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// IngestConfig configures Ingest
+type IngestConfig struct {
+	// ChunkSize is the maximum chunk length in characters (defaults to 1000)
+	ChunkSize int
+
+	// Contextualizer prepends generated context to every chunk before embedding (optional)
+	Contextualizer *Contextualizer
+}
+
+// Ingest stores documents as parents and their (optionally contextualized) chunks,
+// ready for DocumentSearch.ReturnParents retrieval
+func Ingest(ctx context.Context, db *vectordb.RedisVectorDB, docs []vectordb.Document, config IngestConfig) error {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 1000
+	}
+
+	if err := db.StoreParents(ctx, docs); err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		chunks := vectordb.ChunkDocument(doc, config.ChunkSize)
+
+		if config.Contextualizer != nil {
+			var err error
+			chunks, err = config.Contextualizer.Contextualize(ctx, doc, chunks)
+			if err != nil {
+				return fmt.Errorf("failed to contextualize document %s: %w", doc.ID, err)
+			}
+		}
+
+		if err := db.StoreDocumentsBatch(ctx, chunks); err != nil {
+			return fmt.Errorf("failed to store chunks of document %s: %w", doc.ID, err)
+		}
+	}
+
+	return nil
+}
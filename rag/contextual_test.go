@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextualizePrependsContext(t *testing.T) {
+	chat, client := newFakeChat(t, "  From the pricing section.\n", "From the refunds section.")
+	contextualizer := NewContextualizer(ContextualizerConfig{Agent: kit.CreateAgent(client), Concurrency: 1})
+
+	parent := vectordb.Document{ID: "handbook", Content: "Pricing: 10$ a month. Refunds: within 30 days."}
+	chunks := []vectordb.Document{
+		{ID: "handbook#0", Content: "10$ a month", ParentID: "handbook"},
+		{ID: "handbook#1", Content: "within 30 days", ParentID: "handbook"},
+	}
+
+	result, err := contextualizer.Contextualize(context.Background(), parent, chunks)
+	require.NoError(t, err)
+	require.Equal(t, "From the pricing section.\n\n10$ a month", result[0].Content)
+	require.Equal(t, "From the refunds section.\n\nwithin 30 days", result[1].Content)
+	require.Equal(t, "handbook#1", result[1].ID)
+	require.Equal(t, "handbook", result[1].ParentID)
+	require.Equal(t, "10$ a month", chunks[0].Content, "the chunks passed in are not modified")
+
+	messages := chat.messages()
+	require.Len(t, messages, 2)
+	prompt := messages[0][len(messages[0])-1]["content"].(string)
+	require.Contains(t, prompt, "<document>\n"+parent.Content+"\n</document>")
+	require.Contains(t, prompt, "<chunk>\n10$ a month\n</chunk>")
+}
+
+func TestContextualizeCachesContexts(t *testing.T) {
+	chat, client := newFakeChat(t, "first context", "changed document context")
+	contextualizer := NewContextualizer(ContextualizerConfig{Agent: kit.CreateAgent(client)})
+	ctx := context.Background()
+
+	parent := vectordb.Document{ID: "doc", Content: "the whole document"}
+	chunks := []vectordb.Document{{ID: "doc#0", Content: "the whole"}}
+
+	for range 2 {
+		result, err := contextualizer.Contextualize(ctx, parent, chunks)
+		require.NoError(t, err)
+		require.Equal(t, "first context\n\nthe whole", result[0].Content)
+	}
+	require.Len(t, chat.messages(), 1, "re-ingesting unchanged content is served from the cache")
+
+	// the key includes the document, so the same chunk of an edited document gets a new context
+	parent.Content = "the whole document, edited"
+	result, err := contextualizer.Contextualize(ctx, parent, chunks)
+	require.NoError(t, err)
+	require.Equal(t, "changed document context\n\nthe whole", result[0].Content)
+	require.Len(t, chat.messages(), 2)
+}
+
+func TestContextualizeTruncatesDocument(t *testing.T) {
+	chat, client := newFakeChat(t, "context")
+	contextualizer := NewContextualizer(ContextualizerConfig{
+		Agent:            kit.CreateAgent(client),
+		Prompt:           "%s|%s",
+		MaxDocumentChars: 5,
+	})
+
+	_, err := contextualizer.Contextualize(context.Background(),
+		vectordb.Document{Content: "ab€cdef"}, []vectordb.Document{{Content: "chunk"}})
+	require.NoError(t, err)
+
+	messages := chat.messages()
+	prompt := messages[0][len(messages[0])-1]["content"].(string)
+	require.Equal(t, "ab€|chunk", prompt)
+}
+
+func TestContextualizeRequiresAgent(t *testing.T) {
+	_, err := NewContextualizer(ContextualizerConfig{}).Contextualize(context.Background(),
+		vectordb.Document{Content: "doc"}, []vectordb.Document{{Content: "chunk"}})
+	require.ErrorContains(t, err, "agent is required")
+}
@@ -0,0 +1,316 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+const groundingJudgePrompt = `You check whether claims of an answer are supported by their source documents.
+A claim is supported when the source states it or it follows directly from the source. Background knowledge doesn't count.
+Return one verdict for every claim, by its number.`
+
+// GroundingAction is what BatchQA does with an answer that has unsupported claims
+type GroundingAction string
+
+const (
+	GroundingAnnotate GroundingAction = "annotate" // Mark unsupported claims in the answer (default)
+	GroundingReask    GroundingAction = "reask"    // Ask once more with the unsupported claims, then annotate what is left
+)
+
+// unsupportedMarker is appended to unsupported claims by Annotate
+const unsupportedMarker = " [unsupported]"
+
+// GroundingConfig configures a GroundingVerifier
+type GroundingConfig struct {
+	// Embedder matches every claim with the retrieved chunks (required)
+	Embedder embedding.Client
+
+	// Client runs the entailment check of claims that match a chunk (optional, only similarity is checked without it)
+	Client *kit.Client
+
+	// Model runs the entailment check (optional, defaults to the client's model)
+	Model string
+
+	// MinSimilarity is the cosine similarity a claim needs with its best chunk to count as supported (defaults to 0.5)
+	MinSimilarity float64
+
+	// MinWords skips sentences shorter than this, like "Yes." (defaults to 3)
+	MinWords int
+}
+
+// GroundingVerifier checks the claims and citations of a RAG answer against the retrieved chunks
+type GroundingVerifier struct {
+	config GroundingConfig
+	judge  *kit.Agent[groundingVerdicts]
+}
+
+type groundingVerdicts struct {
+	Verdicts []struct {
+		Claim     int    `json:"claim" jsonschema:"description=Number of the claim"`
+		Supported bool   `json:"supported"`
+		Reason    string `json:"reason" jsonschema:"description=What the source is missing or contradicts, empty when supported"`
+	} `json:"verdicts"`
+}
+
+func NewGroundingVerifier(config GroundingConfig) *GroundingVerifier {
+	if config.MinSimilarity <= 0 {
+		config.MinSimilarity = 0.5
+	}
+	if config.MinWords <= 0 {
+		config.MinWords = 3
+	}
+
+	v := &GroundingVerifier{config: config}
+	if config.Client != nil {
+		v.judge = kit.CreateAgentWithOutput[groundingVerdicts](config.Client).
+			WithGenerationName(kit.GenerationName("rag.verify_grounding"))
+		if config.Model != "" {
+			v.judge = v.judge.WithModel(config.Model)
+		}
+	}
+	return v
+}
+
+// ClaimCheck is the verdict on one sentence of an answer
+type ClaimCheck struct {
+	Claim      string   `json:"claim"`
+	Citations  []string `json:"citations,omitempty"` // Document IDs the claim cites
+	SourceID   string   `json:"source_id"`           // Best matching of the cited, or else of all retrieved documents
+	Similarity float64  `json:"similarity"`
+	Supported  bool     `json:"supported"`
+	Reason     string   `json:"reason,omitempty"` // Why the claim is unsupported
+}
+
+// GroundingReport lists the verdicts on the claims of an answer
+type GroundingReport struct {
+	Claims []ClaimCheck `json:"claims"`
+}
+
+// Grounded reports whether every claim is supported
+func (r GroundingReport) Grounded() bool {
+	return len(r.Unsupported()) == 0
+}
+
+// Unsupported returns the claims no document supports
+func (r GroundingReport) Unsupported() []ClaimCheck {
+	var unsupported []ClaimCheck
+	for _, c := range r.Claims {
+		if !c.Supported {
+			unsupported = append(unsupported, c)
+		}
+	}
+	return unsupported
+}
+
+// Annotate marks every unsupported claim of the answer with [unsupported]
+func (r GroundingReport) Annotate(answer string) string {
+	var b strings.Builder
+	for _, c := range r.Unsupported() {
+		i := strings.Index(answer, c.Claim)
+		if i < 0 {
+			continue
+		}
+		end := i + len(c.Claim)
+		b.WriteString(answer[:end])
+		b.WriteString(unsupportedMarker)
+		answer = answer[end:]
+	}
+	b.WriteString(answer)
+	return b.String()
+}
+
+// Feedback asks the model to answer again without the unsupported claims
+func (r GroundingReport) Feedback() string {
+	var b strings.Builder
+	b.WriteString("These claims of your answer are not supported by the documents:\n")
+	for _, c := range r.Unsupported() {
+		fmt.Fprintf(&b, "- %s", c.Claim)
+		if c.Reason != "" {
+			fmt.Fprintf(&b, " (%s)", c.Reason)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Answer again using only what the documents say, and cite the document of every claim.")
+	return b.String()
+}
+
+// Verify splits the answer into claims and checks each one against the documents: cited documents must exist,
+// the best matching chunk must be similar enough and, with a Client, entail the claim
+func (v *GroundingVerifier) Verify(ctx context.Context, answer string, docs []vectordb.DocumentWithScore) (GroundingReport, error) {
+	report := GroundingReport{Claims: []ClaimCheck{}}
+	if v.config.Embedder == nil {
+		return report, fmt.Errorf("grounding verifier needs an Embedder")
+	}
+
+	claims := splitClaims(answer, v.config.MinWords)
+	if len(claims) == 0 {
+		return report, nil
+	}
+	if len(docs) == 0 {
+		for _, claim := range claims {
+			report.Claims = append(report.Claims, ClaimCheck{Claim: claim, Reason: "no documents were retrieved"})
+		}
+		return report, nil
+	}
+
+	texts := make([]string, 0, len(claims)+len(docs))
+	for _, claim := range claims {
+		texts = append(texts, strings.TrimSpace(citationPattern.ReplaceAllString(claim, "")))
+	}
+	for _, doc := range docs {
+		texts = append(texts, doc.Content)
+	}
+	vecs, err := v.config.Embedder.EmbedTexts(ctx, texts)
+	if err != nil {
+		return report, fmt.Errorf("failed to embed claims: %w", err)
+	}
+	if len(vecs) != len(texts) {
+		return report, fmt.Errorf("got %d embeddings for %d texts", len(vecs), len(texts))
+	}
+	claimVecs, docVecs := vecs[:len(claims)], vecs[len(claims):]
+
+	for i, claim := range claims {
+		report.Claims = append(report.Claims, matchClaim(claim, claimVecs[i], docs, docVecs, v.config.MinSimilarity))
+	}
+
+	if v.judge != nil {
+		if err := v.judgeClaims(ctx, report.Claims, docs); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// matchClaim finds the best source of a claim among its cited documents, or all documents when it cites none
+func matchClaim(claim string, claimVec []float64, docs []vectordb.DocumentWithScore, docVecs [][]float64, minSimilarity float64) ClaimCheck {
+	check := ClaimCheck{Claim: claim}
+	for id := range citedIDs(claim) {
+		check.Citations = append(check.Citations, id)
+	}
+	slices.Sort(check.Citations)
+
+	candidates := make([]int, 0, len(docs))
+	for j, doc := range docs {
+		if len(check.Citations) == 0 || slices.Contains(check.Citations, doc.ID) {
+			candidates = append(candidates, j)
+		}
+	}
+	for _, id := range check.Citations {
+		if !slices.ContainsFunc(docs, func(doc vectordb.DocumentWithScore) bool { return doc.ID == id }) {
+			check.Reason = fmt.Sprintf("cites %s, which is not a retrieved document", id)
+			return check
+		}
+	}
+
+	best := -1
+	for _, j := range candidates {
+		if sim := cosine(claimVec, docVecs[j]); best < 0 || sim > check.Similarity {
+			best, check.Similarity = j, sim
+		}
+	}
+	check.SourceID = docs[best].ID
+	check.Supported = check.Similarity >= minSimilarity
+	if !check.Supported {
+		check.Reason = "no document says this"
+	}
+	return check
+}
+
+// judgeClaims asks the model whether the source of every claim that passed the similarity check entails it
+func (v *GroundingVerifier) judgeClaims(ctx context.Context, claims []ClaimCheck, docs []vectordb.DocumentWithScore) error {
+	var prompt strings.Builder
+	judged := make(map[int]int) // claim number -> index in claims
+	for i, c := range claims {
+		if !c.Supported {
+			continue
+		}
+		idx := slices.IndexFunc(docs, func(doc vectordb.DocumentWithScore) bool { return doc.ID == c.SourceID })
+		judged[len(judged)+1] = i
+		fmt.Fprintf(&prompt, "Claim %d: %s\nSource:\n%s\n\n", len(judged), c.Claim, docs[idx].Content)
+	}
+	if len(judged) == 0 {
+		return nil
+	}
+
+	out, err := v.judge.Invoke(ctx, kit.InvokeConfig{
+		SystemPrompt: groundingJudgePrompt,
+		Prompt:       prompt.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check claims: %w", err)
+	}
+
+	for _, verdict := range out.Verdicts {
+		i, ok := judged[verdict.Claim]
+		if !ok || verdict.Supported {
+			continue
+		}
+		claims[i].Supported = false
+		claims[i].Reason = verdict.Reason
+		if claims[i].Reason == "" {
+			claims[i].Reason = "the source doesn't entail the claim"
+		}
+	}
+	return nil
+}
+
+var listMarker = regexp.MustCompile(`^([-*•]|\d+[.)])\s+`)
+
+// splitClaims splits an answer into sentences with at least minWords words, keeping their citations
+func splitClaims(answer string, minWords int) []string {
+	var claims []string
+	add := func(s string) {
+		s = listMarker.ReplaceAllString(strings.TrimSpace(s), "")
+		words := strings.Fields(citationPattern.ReplaceAllString(s, ""))
+		if len(words) >= minWords {
+			claims = append(claims, s)
+		}
+	}
+
+	for _, line := range strings.Split(answer, "\n") {
+		start := 0
+		for i := 0; i < len(line); i++ {
+			if !strings.ContainsRune(".!?", rune(line[i])) {
+				continue
+			}
+			// a sentence ends at punctuation followed by a space, with its trailing citations
+			end := i + 1
+			for end < len(line) && line[end] == ' ' && end+1 < len(line) && line[end+1] == '[' {
+				closing := strings.IndexByte(line[end:], ']')
+				if closing < 0 {
+					break
+				}
+				end += closing + 1
+			}
+			if end == len(line) || line[end] == ' ' {
+				add(line[start:end])
+				start = end
+				i = end
+			}
+		}
+		add(line[start:])
+	}
+	return claims
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
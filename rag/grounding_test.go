@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbedder embeds a text as the count of every topic word it contains
+type topicEmbedder []string
+
+func (e topicEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	vecs := make([][]float64, len(texts))
+	for i, text := range texts {
+		vecs[i] = make([]float64, len(e))
+		for j, topic := range e {
+			vecs[i][j] = float64(strings.Count(strings.ToLower(text), topic))
+		}
+	}
+	return vecs, nil
+}
+
+func (e topicEmbedder) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	vecs, err := e.EmbedTexts(ctx, []string{text})
+	return vecs[0], err
+}
+
+func TestSplitClaims(t *testing.T) {
+	answer := "Paris is the capital of France [doc-1]. It has 2.1 million people. [doc-2] Yes.\n" +
+		"- The Seine flows through it [doc-1, doc-3]\n1. 2024 hosted the Olympics!"
+
+	require.Equal(t, []string{
+		"Paris is the capital of France [doc-1].",
+		"It has 2.1 million people. [doc-2]",
+		"The Seine flows through it [doc-1, doc-3]",
+		"2024 hosted the Olympics!",
+	}, splitClaims(answer, 3))
+}
+
+func TestGroundingVerifier(t *testing.T) {
+	verifier := NewGroundingVerifier(GroundingConfig{
+		Embedder:      topicEmbedder{"paris", "river", "wine"},
+		MinSimilarity: 0.6,
+	})
+	docs := []vectordb.DocumentWithScore{
+		{Document: vectordb.Document{ID: "doc-1", Content: "Paris lies on a river."}},
+		{Document: vectordb.Document{ID: "doc-2", Content: "Wine is made in Bordeaux."}},
+	}
+
+	answer := "Paris has a river [doc-1]. Paris exports wine [doc-9]. The wine of Paris is famous [doc-1]. Rivers are wet."
+	report, err := verifier.Verify(context.Background(), answer, docs)
+	require.NoError(t, err)
+	require.Len(t, report.Claims, 4)
+
+	require.True(t, report.Claims[0].Supported)
+	require.Equal(t, "doc-1", report.Claims[0].SourceID)
+	require.InDelta(t, 1.0, report.Claims[0].Similarity, 1e-9)
+
+	require.False(t, report.Claims[1].Supported)
+	require.Equal(t, "cites doc-9, which is not a retrieved document", report.Claims[1].Reason)
+
+	// only the cited document counts, doc-2 about wine isn't considered
+	require.False(t, report.Claims[2].Supported)
+	require.Equal(t, []string{"doc-1"}, report.Claims[2].Citations)
+	require.InDelta(t, 0.5, report.Claims[2].Similarity, 1e-9)
+
+	require.True(t, report.Claims[3].Supported)
+	require.False(t, report.Grounded())
+
+	require.Equal(t, "Paris has a river [doc-1]. Paris exports wine [doc-9]. [unsupported] "+
+		"The wine of Paris is famous [doc-1]. [unsupported] Rivers are wet.", report.Annotate(answer))
+	require.Contains(t, report.Feedback(), "- Paris exports wine [doc-9]. (cites doc-9, which is not a retrieved document)\n")
+}
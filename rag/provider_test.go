@@ -0,0 +1,68 @@
+package rag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChat is an OpenAI compatible chat completions server answering with scripted contents
+type fakeChat struct {
+	mu       sync.Mutex
+	answers  []string
+	requests []map[string]any
+}
+
+// newFakeChat starts a fake chat server and returns a client talking to it
+func newFakeChat(t *testing.T, answers ...string) (*fakeChat, *kit.Client) {
+	return newFakeChatWithOptions(t, nil, answers...)
+}
+
+// newFakeChatWithOptions is newFakeChat with extra client options, every answer uses 100 prompt and 20 completion tokens
+func newFakeChatWithOptions(t *testing.T, opts []kit.ClientOption, answers ...string) (*fakeChat, *kit.Client) {
+	chat := &fakeChat{answers: answers}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		chat.mu.Lock()
+		chat.requests = append(chat.requests, req)
+		require.NotEmpty(t, chat.answers, "unexpected request %d", len(chat.requests))
+		answer := chat.answers[0]
+		chat.answers = chat.answers[1:]
+		chat.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   req["model"],
+			"choices": []map[string]any{{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": answer}}},
+			"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120},
+		}))
+	}))
+	t.Cleanup(server.Close)
+
+	opts = append([]kit.ClientOption{kit.WithAPIKey("test"), kit.WithBaseURL(server.URL), kit.WithDefaultModel("test-model")}, opts...)
+	return chat, kit.NewClient(opts...)
+}
+
+// messages returns the messages of every request received so far as role and content pairs
+func (c *fakeChat) messages() [][]map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var all [][]map[string]any
+	for _, req := range c.requests {
+		var messages []map[string]any
+		for _, m := range req["messages"].([]any) {
+			messages = append(messages, m.(map[string]any))
+		}
+		all = append(all, messages)
+	}
+	return all
+}
@@ -0,0 +1,247 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+const queryParserPrompt = `Turn the constraints in the user's search query into filters on the fields below, and return the rest of the query for semantic search.
+Only add a filter when the query clearly asks for it, and keep the topic in the query: "phones under $800" becomes the query "phones" and a price filter.
+Numbers are plain numbers, dates are YYYY-MM-DD and booleans are true or false.
+Use value for a single value, values for the in operator, and from and to for range and between.
+Today is %s.
+
+Fields:
+%s`
+
+// fieldOperators are the operators the model may use on each field type
+var fieldOperators = map[vectordb.FilterFieldType][]vectordb.FilterOp{
+	vectordb.FilterFieldTypeTag:     {vectordb.FilterOpEq, vectordb.FilterOpIn},
+	vectordb.FilterFieldTypeText:    {vectordb.FilterOpContains},
+	vectordb.FilterFieldTypeNumeric: {vectordb.FilterOpGte, vectordb.FilterOpLte, vectordb.FilterOpRange},
+	vectordb.FilterFieldTypeBool:    {vectordb.FilterOpIsTrue},
+	vectordb.FilterFieldTypeDate:    {vectordb.FilterOpBefore, vectordb.FilterOpAfter, vectordb.FilterOpBetween},
+}
+
+// parsedQuery is the model's answer
+type parsedQuery struct {
+	Query   string         `json:"query" jsonschema:"description=The search query without the parts turned into filters"`
+	Filters []parsedFilter `json:"filters"`
+}
+
+type parsedFilter struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator" jsonschema:"description=One of the operators listed for the field"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+}
+
+// QueryParserConfig configures a QueryParser
+type QueryParserConfig struct {
+	// Client runs the extraction (required)
+	Client *kit.Client
+
+	// Model extracts the filters (optional, defaults to the client's model), a small, cheap model is usually enough
+	Model string
+
+	// Fields the model may filter on (required), usually the FilterableFields of the index. Geo fields are ignored
+	Fields []vectordb.FilterableField
+
+	// Descriptions explain fields to the model by name, e.g. "price": "price in USD" (optional)
+	Descriptions map[string]string
+
+	// Now resolves relative dates like "last year" (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// QueryParser is a retrieval preprocessor that turns natural language constraints of a query into typed filters
+type QueryParser struct {
+	config QueryParserConfig
+	agent  *kit.Agent[parsedQuery]
+}
+
+func NewQueryParser(config QueryParserConfig) *QueryParser {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	agent := kit.CreateAgentWithOutput[parsedQuery](config.Client).
+		WithGenerationName(kit.GenerationName("rag.parse_query"))
+	if config.Model != "" {
+		agent = agent.WithModel(config.Model)
+	}
+	return &QueryParser{config: config, agent: agent}
+}
+
+// Parse appends the filters found in search.Query to search.Filters and replaces the query with the part left
+// for semantic search, e.g. "phones under $800 from last year" becomes "phones" with a price and a date filter
+// Filters that don't fit a field are left out
+func (p *QueryParser) Parse(ctx context.Context, search vectordb.DocumentSearch) (vectordb.DocumentSearch, error) {
+	if p.config.Client == nil || len(p.config.Fields) == 0 {
+		return search, fmt.Errorf("query parser needs a Client and Fields")
+	}
+
+	parsed, err := p.agent.Invoke(ctx, kit.InvokeConfig{
+		SystemPrompt: p.instructions(),
+		Prompt:       search.Query,
+	})
+	if err != nil {
+		return search, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	fields := make(map[string]vectordb.FilterableField, len(p.config.Fields))
+	for _, f := range p.config.Fields {
+		fields[f.Name] = f
+	}
+
+	filters := slices.Clone(search.Filters)
+	for _, pf := range parsed.Filters {
+		field, ok := fields[pf.Field]
+		if !ok {
+			p.config.Client.Logger.Warn("Query parser dropped a filter", "field", pf.Field, "error", "unknown field")
+			continue
+		}
+		filter, err := toFilter(field, pf)
+		if err != nil {
+			p.config.Client.Logger.Warn("Query parser dropped a filter", "field", pf.Field, "error", err)
+			continue
+		}
+		filters = append(filters, filter)
+	}
+
+	search.Filters = filters
+	if query := strings.TrimSpace(parsed.Query); query != "" {
+		search.Query = query
+	}
+	return search, nil
+}
+
+// instructions lists the fields and their operators for the model
+func (p *QueryParser) instructions() string {
+	var fields strings.Builder
+	for _, f := range p.config.Fields {
+		ops, ok := fieldOperators[f.Type]
+		if !ok {
+			continue
+		}
+		names := make([]string, len(ops))
+		for i, op := range ops {
+			names[i] = string(op)
+		}
+		fmt.Fprintf(&fields, "- %s (%s: %s)", f.Name, f.Type, strings.Join(names, ", "))
+		if d := p.config.Descriptions[f.Name]; d != "" {
+			fmt.Fprintf(&fields, ": %s", d)
+		}
+		fields.WriteString("\n")
+	}
+	return fmt.Sprintf(queryParserPrompt, p.config.Now().Format("2006-01-02 (Monday)"), fields.String())
+}
+
+// toFilter converts a filter of the model to a typed filter on the field
+func toFilter(field vectordb.FilterableField, pf parsedFilter) (vectordb.Filter, error) {
+	op := vectordb.FilterOp(pf.Operator)
+	if !slices.Contains(fieldOperators[field.Type], op) {
+		return vectordb.Filter{}, fmt.Errorf("operator %q is not supported on %s fields", pf.Operator, field.Type)
+	}
+	filter := vectordb.Filter{Field: field.Name, Operator: op}
+
+	switch op {
+	case vectordb.FilterOpEq, vectordb.FilterOpContains:
+		if strings.TrimSpace(pf.Value) == "" {
+			return filter, fmt.Errorf("missing value")
+		}
+		filter.Value = pf.Value
+
+	case vectordb.FilterOpIn:
+		if len(pf.Values) == 0 {
+			return filter, fmt.Errorf("missing values")
+		}
+		filter.Value = pf.Values
+
+	case vectordb.FilterOpGte, vectordb.FilterOpLte:
+		v, err := parseNumber(pf.Value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Value = v
+
+	case vectordb.FilterOpRange:
+		from, err := parseNumber(pf.From)
+		if err != nil {
+			return filter, err
+		}
+		to, err := parseNumber(pf.To)
+		if err != nil {
+			return filter, err
+		}
+		filter.Value = vectordb.NumericRange{Min: from, Max: to}
+
+	case vectordb.FilterOpIsTrue:
+		v, err := strconv.ParseBool(pf.Value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid bool %q", pf.Value)
+		}
+		filter.Value = v
+
+	case vectordb.FilterOpBefore:
+		t, err := parseDate(pf.Value, false)
+		if err != nil {
+			return filter, err
+		}
+		filter.Value = t
+
+	case vectordb.FilterOpAfter:
+		// after a day means after the end of that day
+		t, err := parseDate(pf.Value, true)
+		if err != nil {
+			return filter, err
+		}
+		filter.Value = t
+
+	case vectordb.FilterOpBetween:
+		from, err := parseDate(pf.From, false)
+		if err != nil {
+			return filter, err
+		}
+		to, err := parseDate(pf.To, true)
+		if err != nil {
+			return filter, err
+		}
+		filter.Value = vectordb.DateRange{From: from, To: to}
+	}
+	return filter, nil
+}
+
+// parseNumber parses a number, tolerating currency signs and thousands separators
+func parseNumber(s string) (float64, error) {
+	cleaned := strings.NewReplacer(",", "", "$", "", "€", "", "£", "").Replace(strings.TrimSpace(s))
+	v, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// parseDate parses a YYYY-MM-DD or RFC 3339 date, endOfDay moves a plain date to its last millisecond
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Millisecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryParser(t *testing.T) {
+	answer := `{"query":"phones","filters":[
+		{"field":"price","operator":"lte","value":"$800","values":[],"from":"","to":""},
+		{"field":"released","operator":"between","value":"","values":[],"from":"2023-01-01","to":"2023-12-31"},
+		{"field":"brand","operator":"in","value":"","values":["apple","samsung"],"from":"","to":""},
+		{"field":"color","operator":"eq","value":"red","values":[],"from":"","to":""},
+		{"field":"price","operator":"eq","value":"cheap","values":[],"from":"","to":""}
+	]}`
+
+	chat, client := newFakeChat(t, answer)
+
+	parser := NewQueryParser(QueryParserConfig{
+		Client: client,
+		Fields: []vectordb.FilterableField{
+			{Name: "price", Type: vectordb.FilterFieldTypeNumeric},
+			{Name: "released", Type: vectordb.FilterFieldTypeDate},
+			{Name: "brand", Type: vectordb.FilterFieldTypeTag},
+			{Name: "location", Type: vectordb.FilterFieldTypeGeo},
+		},
+		Descriptions: map[string]string{"price": "price in USD"},
+		Now:          func() time.Time { return time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC) },
+	})
+
+	existing := vectordb.Filter{Field: "in_stock", Operator: vectordb.FilterOpIsTrue}
+	search, err := parser.Parse(context.Background(), vectordb.DocumentSearch{
+		Query:   "apple or samsung phones under $800 from last year",
+		TopK:    5,
+		Filters: []vectordb.Filter{existing},
+	})
+	require.NoError(t, err)
+
+	system := chat.messages()[0][0]["content"].(string)
+	require.Contains(t, system, "Today is 2024-03-15 (Friday).")
+	require.Contains(t, system, "- price (numeric: gte, lte, range): price in USD\n")
+	require.NotContains(t, system, "location")
+
+	require.Equal(t, "phones", search.Query)
+	require.Equal(t, 5, search.TopK)
+	require.Equal(t, []vectordb.Filter{
+		existing,
+		{Field: "price", Operator: vectordb.FilterOpLte, Value: 800.0},
+		{Field: "released", Operator: vectordb.FilterOpBetween, Value: vectordb.DateRange{
+			From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2023, 12, 31, 23, 59, 59, int(999*time.Millisecond), time.UTC),
+		}},
+		{Field: "brand", Operator: vectordb.FilterOpIn, Value: []string{"apple", "samsung"}},
+	}, search.Filters)
+}
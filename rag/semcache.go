@@ -0,0 +1,206 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+// Metadata fields of the entries of a SemanticCache
+const (
+	semanticCacheScope   = "scope"
+	semanticCacheAnswer  = "answer"
+	semanticCacheExpires = "expires" // unix milliseconds, 0 never expires
+)
+
+// SemanticCacheIndex returns the index config a SemanticCache needs, for vectors of the given dimensions
+func SemanticCacheIndex(dimensions int) vectordb.IndexConfig {
+	return vectordb.IndexConfig{
+		Dimensions:     dimensions,
+		DistanceMetric: "COSINE",
+		FilterableFields: []vectordb.FilterableField{
+			{Name: semanticCacheScope, Type: vectordb.FilterFieldTypeTag, Required: true},
+		},
+	}
+}
+
+// SemanticCacheConfig configures a SemanticCache
+type SemanticCacheConfig struct {
+	// VectorDB stores the prompts with their answers (required), its index must be created with SemanticCacheIndex
+	VectorDB vectordb.Client
+
+	// MinSimilarity is the cosine similarity from which a prompt counts as a near duplicate of a cached one
+	// (optional, defaults to 0.95)
+	MinSimilarity float64
+
+	// Exact answers identical runs without embedding the prompt (optional, e.g. kit.NewMemoryResponseCache)
+	Exact kit.ResponseCache
+
+	// Now (optional, defaults to time.Now)
+	Now func() time.Time
+}
+
+// SemanticCache is a kit.PromptCache that answers a run from the cached answer of a near-duplicate prompt,
+// e.g. "How do I reset my password?" after "how can I reset my password". Plug it in with Agent.WithCache
+// Only runs with the same model, parameters, tools, output type and earlier messages are matched
+type SemanticCache struct {
+	config SemanticCacheConfig
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ kit.PromptCache = (*SemanticCache)(nil)
+
+func NewSemanticCache(config SemanticCacheConfig) *SemanticCache {
+	if config.MinSimilarity <= 0 {
+		config.MinSimilarity = 0.95
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &SemanticCache{config: config}
+}
+
+// Hits returns the number of runs answered from the cache
+func (c *SemanticCache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses returns the number of runs the cache had no answer for
+func (c *SemanticCache) Misses() int64 {
+	return c.misses.Load()
+}
+
+// Get looks up a run by its exact key in Exact, it misses without one
+func (c *SemanticCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if c.config.Exact == nil {
+		return nil, false, nil
+	}
+	return c.config.Exact.Get(ctx, key)
+}
+
+// Set stores a run by its exact key in Exact, if there is one
+func (c *SemanticCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.config.Exact == nil {
+		return nil
+	}
+	return c.config.Exact.Set(ctx, key, value, ttl)
+}
+
+// GetPrompt returns the answer of an identical run from Exact, or else of the most similar prompt of the same scope
+func (c *SemanticCache) GetPrompt(ctx context.Context, prompt kit.CachedPrompt) ([]byte, bool, error) {
+	if value, ok, err := c.Get(ctx, prompt.Key); err != nil || ok {
+		if ok {
+			c.hits.Add(1)
+		}
+		return value, ok, err
+	}
+	if c.config.VectorDB == nil {
+		return nil, false, fmt.Errorf("semantic cache needs a VectorDB")
+	}
+	if prompt.Prompt == "" {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+
+	docs, err := c.config.VectorDB.SearchDocuments(ctx, vectordb.DocumentSearch{
+		Query: prompt.Prompt,
+		TopK:  3,
+		Filters: []vectordb.Filter{
+			{Field: semanticCacheScope, Operator: vectordb.FilterOpEq, Value: prompt.Scope},
+		},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to search semantic cache: %w", err)
+	}
+
+	value, ok, err := c.match(ctx, docs)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return value, ok, err
+}
+
+// match returns the answer of the closest unexpired document similar enough to the prompt, docs are ordered by distance
+// Expired documents are deleted
+func (c *SemanticCache) match(ctx context.Context, docs []vectordb.DocumentWithScore) ([]byte, bool, error) {
+	now := c.config.Now().UnixMilli()
+	for _, doc := range docs {
+		if expires := metaInt(doc.Meta[semanticCacheExpires]); expires > 0 && expires <= now {
+			if err := c.config.VectorDB.DeleteDocument(ctx, doc.ID); err != nil {
+				return nil, false, fmt.Errorf("failed to delete expired cache entry %s: %w", doc.ID, err)
+			}
+			continue
+		}
+
+		distance, err := strconv.ParseFloat(doc.Score, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid score %q of cache entry %s: %w", doc.Score, doc.ID, err)
+		}
+		if 1-distance < c.config.MinSimilarity {
+			return nil, false, nil
+		}
+		answer, ok := doc.Meta[semanticCacheAnswer].(string)
+		if !ok {
+			continue
+		}
+		return []byte(answer), true, nil
+	}
+	return nil, false, nil
+}
+
+// SetPrompt stores the answer of a run in Exact and under its prompt
+func (c *SemanticCache) SetPrompt(ctx context.Context, prompt kit.CachedPrompt, value []byte, ttl time.Duration) error {
+	if err := c.Set(ctx, prompt.Key, value, ttl); err != nil {
+		return err
+	}
+	if c.config.VectorDB == nil {
+		return fmt.Errorf("semantic cache needs a VectorDB")
+	}
+	if prompt.Prompt == "" {
+		return nil
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = c.config.Now().Add(ttl).UnixMilli()
+	}
+	// a short ID, since it is embedded with the prompt
+	sum := sha256.Sum256([]byte(prompt.Key))
+	err := c.config.VectorDB.StoreDocument(ctx, vectordb.Document{
+		ID:      hex.EncodeToString(sum[:6]),
+		Content: prompt.Prompt,
+		Meta: map[string]any{
+			semanticCacheScope:   prompt.Scope,
+			semanticCacheAnswer:  string(value),
+			semanticCacheExpires: expires,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store semantic cache entry: %w", err)
+	}
+	return nil
+}
+
+// metaInt reads an integer metadata value, which comes back as a float64 from JSON
+func metaInt(val any) int64 {
+	switch v := val.(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
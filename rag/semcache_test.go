@@ -0,0 +1,99 @@
+package rag
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryVectorDB is a vectordb.Client keeping documents in memory, searched by cosine distance
+type memoryVectorDB struct {
+	embedder topicEmbedder
+	docs     map[string]vectordb.Document
+}
+
+func (m *memoryVectorDB) CreateIndex(ctx context.Context, config vectordb.IndexConfig) error {
+	return nil
+}
+
+func (m *memoryVectorDB) StoreDocument(ctx context.Context, doc vectordb.Document) error {
+	m.docs[doc.ID] = doc
+	return nil
+}
+
+func (m *memoryVectorDB) StoreDocumentsBatch(ctx context.Context, docs []vectordb.Document) error {
+	for _, doc := range docs {
+		m.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func (m *memoryVectorDB) UpdateDocument(ctx context.Context, doc vectordb.Document) error {
+	return m.StoreDocument(ctx, doc)
+}
+
+func (m *memoryVectorDB) DeleteDocument(ctx context.Context, id string) error {
+	delete(m.docs, id)
+	return nil
+}
+
+func (m *memoryVectorDB) SearchDocuments(ctx context.Context, search vectordb.DocumentSearch) ([]vectordb.DocumentWithScore, error) {
+	query, _ := m.embedder.EmbedQuery(ctx, search.Query)
+	var found []vectordb.DocumentWithScore
+	for _, doc := range m.docs {
+		if search.Filters[0].Value != doc.Meta[search.Filters[0].Field] {
+			continue
+		}
+		vec, _ := m.embedder.EmbedQuery(ctx, doc.Content)
+		distance := 1 - cosine(query, vec)
+		found = append(found, vectordb.DocumentWithScore{Document: doc, Score: strconv.FormatFloat(distance, 'f', -1, 64)})
+	}
+	return found, nil
+}
+
+func TestSemanticCache(t *testing.T) {
+	db := &memoryVectorDB{embedder: topicEmbedder{"reset", "password", "delete", "account"}, docs: map[string]vectordb.Document{}}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewSemanticCache(SemanticCacheConfig{VectorDB: db, Now: func() time.Time { return now }})
+
+	chat, client := newFakeChat(t, "Use the reset link.", "Go to settings.", "Reset.", "Use the new reset page.")
+	agent := kit.CreateAgent(client).WithCache(cache, time.Hour)
+
+	answer, err := agent.InvokeSimple(context.Background(), "How do I reset my password?")
+	require.NoError(t, err)
+	require.Equal(t, "Use the reset link.", answer)
+
+	// a near duplicate is answered from the cache
+	result, err := agent.InvokeWithResult(context.Background(), kit.InvokeConfig{Prompt: "how can I reset my password"})
+	require.NoError(t, err)
+	require.True(t, result.Cached)
+	require.Equal(t, "Use the reset link.", result.Output)
+
+	// another question or another system prompt is not
+	answer, err = agent.InvokeSimple(context.Background(), "How do I delete my account?")
+	require.NoError(t, err)
+	require.Equal(t, "Go to settings.", answer)
+	require.Len(t, chat.messages(), 2)
+
+	result, err = agent.InvokeWithResult(context.Background(), kit.InvokeConfig{
+		SystemPrompt: "Answer in one word.",
+		Prompt:       "How do I reset my password?",
+	})
+	require.NoError(t, err)
+	require.False(t, result.Cached)
+	require.EqualValues(t, 1, cache.Hits())
+	require.EqualValues(t, 3, cache.Misses())
+
+	// expired entries are deleted
+	now = now.Add(2 * time.Hour)
+	result, err = agent.InvokeWithResult(context.Background(), kit.InvokeConfig{Prompt: "How do I reset my password?"})
+	require.NoError(t, err)
+	require.False(t, result.Cached)
+	require.Equal(t, "Use the new reset page.", result.Output)
+	require.Len(t, db.docs, 2)
+}
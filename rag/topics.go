@@ -0,0 +1,31 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+	"github.com/mhrlife/goai-kit/vectordb"
+)
+
+const topicPrompt = `Here are documents from the same cluster of a collection
+%s
+Name the topic they share in 2 to 5 words. Answer only with the topic and nothing else.`
+
+// TopicLabeler returns a vectordb.ClusterLabeler that asks the agent for the shared topic of a cluster,
+// a small, cheap model is usually enough
+func TopicLabeler(agent *kit.Agent[string]) vectordb.ClusterLabeler {
+	return func(ctx context.Context, examples []vectordb.Document) (string, error) {
+		var docs strings.Builder
+		for _, doc := range examples {
+			fmt.Fprintf(&docs, "<document>\n%s\n</document>\n", truncateText(doc.Content, 1000))
+		}
+
+		answer, err := agent.Invoke(ctx, kit.InvokeConfig{Prompt: fmt.Sprintf(topicPrompt, docs.String())})
+		if err != nil {
+			return "", fmt.Errorf("failed to generate topic: %w", err)
+		}
+		return strings.Trim(strings.TrimSpace(answer), `"'.`), nil
+	}
+}
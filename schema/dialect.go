@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Dialect describes which JSON schema features a provider accepts in tool and response schemas
+type Dialect struct {
+	Name string
+
+	// Strict sends strict: true with tool and response schemas
+	Strict bool
+
+	// CloseObjects sets additionalProperties=false on every object and lists every property as required,
+	// properties that were optional become nullable
+	CloseObjects bool
+
+	// StripKeywords are removed from every (sub)schema
+	StripKeywords []string
+}
+
+var (
+	// DialectOpenAI is OpenAI strict mode
+	DialectOpenAI = Dialect{
+		Name:          "openai",
+		Strict:        true,
+		CloseObjects:  true,
+		StripKeywords: []string{"$schema", "$id", "default", "examples"},
+	}
+
+	// DialectGemini is Gemini's OpenAI compatible API, it rejects additionalProperties and strict
+	DialectGemini = Dialect{
+		Name: "gemini",
+		StripKeywords: []string{
+			"$schema", "$id", "$defs", "additionalProperties", "patternProperties",
+			"default", "examples", "const",
+		},
+	}
+
+	// DialectLenient keeps the schema but doesn't ask for strict validation (e.g. Groq, Mistral, Llama hosts)
+	DialectLenient = Dialect{
+		Name:          "lenient",
+		StripKeywords: []string{"$schema", "$id"},
+	}
+)
+
+// DialectForModel picks a dialect from a model name, with or without an OpenRouter style provider prefix
+func DialectForModel(model string) Dialect {
+	m := strings.ToLower(model)
+	switch {
+	case strings.Contains(m, "gemini"), strings.HasPrefix(m, "google/"):
+		return DialectGemini
+	case strings.HasPrefix(m, "openai/"), strings.HasPrefix(m, "gpt-"), strings.HasPrefix(m, "o1"),
+		strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"), strings.HasPrefix(m, "chatgpt-"):
+		return DialectOpenAI
+	case strings.Contains(m, "/"):
+		// Other providers behind a router rarely implement strict mode
+		return DialectLenient
+	}
+	return DialectOpenAI
+}
+
+// Adapt returns a copy of the schema rewritten for the dialect
+func (d Dialect) Adapt(schema map[string]any) map[string]any {
+	adapted, _ := d.adapt(schema).(map[string]any)
+	return adapted
+}
+
+// adapt walks a schema value and returns the rewritten copy
+func (d Dialect) adapt(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			if slices.Contains(d.StripKeywords, k) {
+				continue
+			}
+			switch k {
+			case "properties", "$defs", "definitions", "patternProperties":
+				// Keys of these objects are names, not keywords
+				props, ok := item.(map[string]any)
+				if !ok {
+					out[k] = item
+					continue
+				}
+				adapted := make(map[string]any, len(props))
+				for name, prop := range props {
+					adapted[name] = d.adapt(prop)
+				}
+				out[k] = adapted
+			default:
+				out[k] = d.adapt(item)
+			}
+		}
+		if d.CloseObjects {
+			closeObject(out)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = d.adapt(item)
+		}
+		return out
+	}
+	return v
+}
+
+// closeObject disallows extra properties and requires every property, optional ones become nullable
+func closeObject(s map[string]any) {
+	props, ok := s["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	s["additionalProperties"] = false
+
+	required := map[string]bool{}
+	switch r := s["required"].(type) {
+	case []any:
+		for _, name := range r {
+			if n, ok := name.(string); ok {
+				required[n] = true
+			}
+		}
+	case []string:
+		for _, n := range r {
+			required[n] = true
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for name, prop := range props {
+		names = append(names, name)
+		if !required[name] {
+			if p, ok := prop.(map[string]any); ok {
+				makeNullable(p)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	all := make([]any, len(names))
+	for i, name := range names {
+		all[i] = name
+	}
+	s["required"] = all
+}
+
+// makeNullable adds "null" to the type of a property schema
+func makeNullable(p map[string]any) {
+	switch t := p["type"].(type) {
+	case string:
+		if t != "null" {
+			p["type"] = []any{t, "null"}
+		}
+	case []any:
+		if !slices.Contains(t, any("null")) {
+			p["type"] = append(t, "null")
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dialectExample struct {
+	Query  string   `json:"query" jsonschema:"default=hi"`
+	Limit  int      `json:"limit,omitempty"`
+	Filter struct{} `json:"filter"`
+}
+
+func TestDialectOpenAIClosesObjects(t *testing.T) {
+	adapted := DialectOpenAI.Adapt(MarshalToSchema(dialectExample{}))
+
+	require.NotContains(t, adapted, "$id")
+	require.Equal(t, false, adapted["additionalProperties"])
+	require.Equal(t, []any{"filter", "limit", "query"}, adapted["required"])
+
+	props := adapted["properties"].(map[string]any)
+	require.Equal(t, []any{"integer", "null"}, props["limit"].(map[string]any)["type"])
+	require.Equal(t, "string", props["query"].(map[string]any)["type"])
+	require.NotContains(t, props["query"], "default")
+	require.Equal(t, false, props["filter"].(map[string]any)["additionalProperties"])
+}
+
+func TestDialectGeminiStripsUnsupportedKeywords(t *testing.T) {
+	original := MarshalToSchema(dialectExample{})
+	adapted := DialectGemini.Adapt(original)
+
+	require.NotContains(t, adapted, "additionalProperties")
+	require.NotContains(t, adapted["properties"].(map[string]any)["filter"], "additionalProperties")
+	require.Equal(t, []any{"query", "filter"}, adapted["required"])
+
+	// The original schema is left untouched
+	require.Contains(t, original, "additionalProperties")
+}
+
+func TestDialectKeepsPropertyNamedLikeKeyword(t *testing.T) {
+	s := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"default": map[string]any{"type": "string"}},
+		"required":   []any{"default"},
+	}
+	adapted := DialectOpenAI.Adapt(s)
+	require.Contains(t, adapted["properties"], "default")
+}
+
+func TestDialectForModel(t *testing.T) {
+	require.Equal(t, "openai", DialectForModel("gpt-4o-mini").Name)
+	require.Equal(t, "openai", DialectForModel("openai/gpt-4o").Name)
+	require.Equal(t, "gemini", DialectForModel("google/gemini-2.0-flash").Name)
+	require.Equal(t, "lenient", DialectForModel("meta-llama/llama-3.3-70b-instruct").Name)
+	require.Equal(t, "openai", DialectForModel("my-finetune").Name)
+}
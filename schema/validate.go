@@ -0,0 +1,256 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a single place where a JSON document doesn't match its schema
+type Violation struct {
+	Path    string // JSON pointer to the offending value, "" is the document root
+	Message string
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// ValidationError lists every violation found in a document
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validate checks a JSON document against a schema produced by MarshalToSchema (optionally adapted by a Dialect)
+// It returns a *ValidationError listing the JSON pointer of every violation, or an error if data isn't valid JSON
+// Supported keywords: type, enum, const, properties, required, additionalProperties, items, anyOf, oneOf,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, minItems and maxItems
+func Validate(schema map[string]any, data []byte) error {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var violations []Violation
+	validate(schema, doc, "", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func validate(schema map[string]any, value any, path string, violations *[]Violation) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		report("expected %s, got %s", typeNames(t), jsonType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !containsValue(enum, value) {
+		report("must be one of %s", compactJSON(enum))
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		report("must be %s", compactJSON(c))
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		options, ok := schema[keyword].([]any)
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, option := range options {
+			if sub, ok := option.(map[string]any); ok {
+				var nested []Violation
+				validate(sub, value, path, &nested)
+				if len(nested) == 0 {
+					matched++
+				}
+			}
+		}
+		if matched == 0 || (keyword == "oneOf" && matched > 1) {
+			report("must match exactly one schema in %s", keyword)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		validateObject(schema, v, path, violations)
+	case []any:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			report("must have at least %v items", n)
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			report("must have at most %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validate(items, item, path+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := number(schema["minLength"]); ok && length < n {
+			report("must be at least %v characters", n)
+		}
+		if n, ok := number(schema["maxLength"]); ok && length > n {
+			report("must be at most %v characters", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				report("must match pattern %q", pattern)
+			}
+		}
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && v < n {
+			report("must be >= %v", n)
+		}
+		if n, ok := number(schema["maximum"]); ok && v > n {
+			report("must be <= %v", n)
+		}
+		if n, ok := number(schema["exclusiveMinimum"]); ok && v <= n {
+			report("must be > %v", n)
+		}
+		if n, ok := number(schema["exclusiveMaximum"]); ok && v >= n {
+			report("must be < %v", n)
+		}
+	}
+}
+
+func validateObject(schema map[string]any, object map[string]any, path string, violations *[]Violation) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := object[key]; !present {
+					*violations = append(*violations, Violation{Path: path + "/" + escapePointer(key), Message: "is required"})
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		if sub, ok := properties[key].(map[string]any); ok {
+			validate(sub, object[key], childPath, violations)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, Violation{Path: childPath, Message: "is not allowed"})
+			}
+		case map[string]any:
+			validate(additional, object[key], childPath, violations)
+		}
+	}
+}
+
+// matchesType reports whether value has the schema type, t is a type name or a list of them
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value any) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return jsonType(value) == name
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeNames(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v any) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compactJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// escapePointer escapes a property name as a JSON pointer reference token (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
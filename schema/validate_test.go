@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validateExample struct {
+	Name  string   `json:"name" jsonschema:"minLength=2"`
+	Score float64  `json:"score" jsonschema:"minimum=0,maximum=1"`
+	Tags  []string `json:"tags,omitempty" jsonschema:"enum=a,enum=b"`
+	Owner struct {
+		Email string `json:"email" jsonschema:"pattern=@"`
+	} `json:"owner"`
+}
+
+func TestValidateReportsJSONPointers(t *testing.T) {
+	s := DialectOpenAI.Adapt(MarshalToSchema(validateExample{}))
+
+	err := Validate(s, []byte(`{"name":"x","score":2,"tags":["a","c"],"owner":{"email":"bob"},"extra":true}`))
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	paths := map[string]string{}
+	for _, v := range verr.Violations {
+		paths[v.Path] = v.Message
+	}
+	require.Equal(t, map[string]string{
+		"/name":        "must be at least 2 characters",
+		"/score":       "must be <= 1",
+		"/tags/1":      `must be one of ["a","b"]`,
+		"/owner/email": `must match pattern "@"`,
+		"/extra":       "is not allowed",
+	}, paths)
+}
+
+func TestValidateTypesAndRequired(t *testing.T) {
+	s := DialectOpenAI.Adapt(MarshalToSchema(validateExample{}))
+
+	err := Validate(s, []byte(`{"name":"bob","score":"high","tags":null}`))
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.ElementsMatch(t, []Violation{
+		{Path: "/owner", Message: "is required"},
+		{Path: "/score", Message: "expected number, got string"},
+	}, verr.Violations)
+	require.Contains(t, err.Error(), "/score: expected number, got string")
+
+	require.NoError(t, Validate(s, []byte(`{"name":"bob","score":0.5,"tags":null,"owner":{"email":"bob@x"}}`)))
+
+	err = Validate(s, []byte(`{"name":`))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &verr))
+}
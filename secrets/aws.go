@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager with static or environment credentials
+// Names are secret IDs or ARNs with an optional JSON field, e.g. "prod/llm#openai_api_key"
+type AWSSecretsManager struct {
+	// Region of the secrets (optional, defaults to $AWS_REGION or $AWS_DEFAULT_REGION)
+	Region string
+
+	// AccessKeyID, SecretAccessKey and SessionToken sign the requests
+	// (optional, default to $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN)
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the regional endpoint, e.g. for a VPC endpoint (optional)
+	Endpoint string
+
+	// HTTPClient sends the requests (optional, defaults to http.DefaultClient)
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+func (a AWSSecretsManager) Secret(ctx context.Context, name string) (string, error) {
+	region := firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	accessKey := firstNonEmpty(a.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := firstNonEmpty(a.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	sessionToken := a.SessionToken
+	if a.AccessKeyID == "" {
+		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if region == "" || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("AWS region and credentials are required")
+	}
+	endpoint := firstNonEmpty(a.Endpoint, "https://secretsmanager."+region+".amazonaws.com/")
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+
+	id, field := splitField(name)
+	body, _ := json.Marshal(map[string]string{"SecretId": id})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create AWS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	signV4(req, body, accessKey, secretKey, region, "secretsmanager", now().UTC())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret from AWS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read AWS response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("AWS secret %s: %w", id, ErrNotFound)
+		}
+		return "", fmt.Errorf("AWS returned %s: %s %s", resp.Status, failure.Type, failure.Message)
+	}
+
+	var payload struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", fmt.Errorf("failed to decode AWS response: %w", err)
+	}
+	if payload.SecretString == "" {
+		return "", fmt.Errorf("AWS secret %s has no string value: %w", id, ErrNotFound)
+	}
+	if field == "" {
+		return payload.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(payload.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("AWS secret %s is not a JSON object", id)
+	}
+	value, _ := fields[field].(string)
+	if value == "" {
+		return "", fmt.Errorf("AWS secret %s has no field %s: %w", id, field, ErrNotFound)
+	}
+	return value, nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("Host", req.URL.Host)
+
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	req.Header.Del("Host") // net/http sends req.Host
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()), canonicalHeaders.String(), signedHeaders, sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(signingKey(secretKey, date, region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func signingKey(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+// Package secrets loads credentials such as provider API keys from the environment, files, HashiCorp Vault
+// or AWS Secrets Manager, and caches them so they can be rotated without restarting
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a secret doesn't exist or is empty
+var ErrNotFound = errors.New("secret not found")
+
+// Provider returns the current value of a named secret
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+func (f ProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// Env reads secrets from environment variables, the name is the variable after Prefix
+type Env struct {
+	Prefix string
+}
+
+func (e Env) Secret(_ context.Context, name string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(e.Prefix + name))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s%s: %w", e.Prefix, name, ErrNotFound)
+	}
+	return value, nil
+}
+
+// File reads secrets from files, such as Kubernetes or Docker secret mounts
+// The name is a path relative to Dir, and the file is read again when the cache expires so rotated files are picked up
+type File struct {
+	Dir string
+}
+
+func (f File) Secret(_ context.Context, name string) (string, error) {
+	path := name
+	if f.Dir != "" {
+		if !filepath.IsLocal(name) {
+			return "", fmt.Errorf("secret %s is outside %s: %w", name, f.Dir, ErrNotFound)
+		}
+		path = filepath.Join(f.Dir, name)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("secret file %s: %w", path, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("secret file %s is empty: %w", path, ErrNotFound)
+	}
+	return value, nil
+}
+
+type cached struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// Cache keeps secrets of a provider for a while, so a rotated secret is used at most TTL after it changed
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	secrets map[string]cached
+}
+
+// NewCache caches the secrets of provider for ttl (5 minutes when 0)
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Cache{provider: provider, ttl: ttl, now: time.Now, secrets: map[string]cached{}}
+}
+
+// Secret returns the cached secret, fetching it when it is missing or expired
+// When a refresh fails the previous value is kept, so a provider outage doesn't fail requests with a working key
+func (c *Cache) Secret(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.secrets[name]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.value, nil
+	}
+
+	value, err := c.provider.Secret(ctx, name)
+	if err != nil {
+		if ok && entry.value != "" && !errors.Is(err, ErrNotFound) {
+			return entry.value, nil
+		}
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.secrets[name] = cached{value: value, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops a cached secret, e.g. after the provider rejected it, so the next call fetches it again
+func (c *Cache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.secrets, name)
+}
+
+// splitField splits "name#field" references into the secret name and the field of a JSON or key/value secret
+func splitField(reference string) (name, field string) {
+	name, field, _ = strings.Cut(reference, "#")
+	return name, field
+}
@@ -0,0 +1,165 @@
+package secrets
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvAndFile(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("APP_OPENAI_KEY", " sk-env\n")
+
+	value, err := Env{Prefix: "APP_"}.Secret(ctx, "OPENAI_KEY")
+	require.NoError(t, err)
+	require.Equal(t, "sk-env", value)
+	_, err = Env{}.Secret(ctx, "APP_MISSING")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "openai"), []byte("sk-file\n"), 0o600))
+	value, err = File{Dir: dir}.Secret(ctx, "openai")
+	require.NoError(t, err)
+	require.Equal(t, "sk-file", value)
+
+	_, err = File{Dir: dir}.Secret(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = File{Dir: dir}.Secret(ctx, "../openai")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	var failWith error
+	provider := ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		calls++
+		if failWith != nil {
+			return "", failWith
+		}
+		return name + "-" + string(rune('0'+calls)), nil
+	})
+
+	cache := NewCache(provider, time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	value, err := cache.Secret(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "key-1", value)
+	value, _ = cache.Secret(ctx, "key")
+	require.Equal(t, "key-1", value)
+	require.Equal(t, 1, calls)
+
+	now = now.Add(2 * time.Minute)
+	value, _ = cache.Secret(ctx, "key")
+	require.Equal(t, "key-2", value)
+
+	cache.Invalidate("key")
+	value, _ = cache.Secret(ctx, "key")
+	require.Equal(t, "key-3", value)
+
+	// an outage keeps the last value, a deleted secret doesn't
+	now = now.Add(2 * time.Minute)
+	failWith = errors.New("connection refused")
+	value, err = cache.Secret(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "key-3", value)
+
+	failWith = ErrNotFound
+	_, err = cache.Secret(ctx, "key")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/llm/openai" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"value":"sk-vault","org":"org-1"},"metadata":{"version":3}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	vault := Vault{Address: server.URL, Token: "root", Mount: "kv"}
+
+	value, err := vault.Secret(ctx, "llm/openai")
+	require.NoError(t, err)
+	require.Equal(t, "sk-vault", value)
+
+	value, err = vault.Secret(ctx, "llm/openai#org")
+	require.NoError(t, err)
+	require.Equal(t, "org-1", value)
+
+	_, err = vault.Secret(ctx, "llm/openai#missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = vault.Secret(ctx, "llm/other")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	vault.Token = "wrong"
+	_, err = vault.Secret(ctx, "llm/openai")
+	require.EqualError(t, err, `vault returned 403 Forbidden: {"errors":["permission denied"]}`)
+}
+
+func TestSigningKey(t *testing.T) {
+	// example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	require.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		require.Equal(t, "20240301T101500Z", r.Header.Get("X-Amz-Date"))
+		require.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		require.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKID/20240301/eu-west-1/secretsmanager/aws4_request, `+
+			`SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=[0-9a-f]{64}$`,
+			r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]string
+		require.NoError(t, json.Unmarshal(body, &request))
+		if request["SecretId"] != "prod/llm" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Name":"prod/llm","SecretString":"{\"openai\":\"sk-aws\"}"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	aws := AWSSecretsManager{
+		Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session", Endpoint: server.URL,
+		now: func() time.Time { return time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC) },
+	}
+
+	value, err := aws.Secret(ctx, "prod/llm#openai")
+	require.NoError(t, err)
+	require.Equal(t, "sk-aws", value)
+
+	value, err = aws.Secret(ctx, "prod/llm")
+	require.NoError(t, err)
+	require.Equal(t, `{"openai":"sk-aws"}`, value)
+
+	_, err = aws.Secret(ctx, "prod/other")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = aws.Secret(ctx, "prod/llm#anthropic")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Vault reads secrets from the KV version 2 engine of HashiCorp Vault
+// Names are secret paths with an optional field, e.g. "llm/openai#api_key"
+type Vault struct {
+	// Address of the Vault server (optional, defaults to $VAULT_ADDR)
+	Address string
+
+	// Token authenticates to Vault (optional, defaults to $VAULT_TOKEN)
+	Token string
+
+	// Mount is the path of the KV engine (optional, defaults to "secret")
+	Mount string
+
+	// Field is read when the name doesn't pick one (optional, defaults to "value")
+	Field string
+
+	// Namespace is the Vault Enterprise namespace (optional)
+	Namespace string
+
+	// HTTPClient sends the requests (optional, defaults to http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+func (v Vault) Secret(ctx context.Context, name string) (string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if address == "" {
+		return "", fmt.Errorf("vault address is not set")
+	}
+
+	path, field := splitField(name)
+	if field == "" {
+		field = v.Field
+	}
+	if field == "" {
+		field = "value"
+	}
+
+	endpoint, err := url.JoinPath(address, "v1", strings.Trim(mount, "/"), "data", strings.Trim(path, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret from vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("vault secret %s: %w", path, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the body only holds error messages, never the secret
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	value, _ := payload.Data.Data[field].(string)
+	if value == "" {
+		return "", fmt.Errorf("vault secret %s has no field %s: %w", path, field, ErrNotFound)
+	}
+	return value, nil
+}
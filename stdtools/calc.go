@@ -0,0 +1,439 @@
+package stdtools
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// precision is the mantissa size of every number, far beyond what any answer needs
+const precision = 512
+
+const (
+	piDigits = "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706798214808651"
+	eDigits  = "2.71828182845904523536028747135266249775724709369995957496696762772407663035354759457138217852516642742746639193"
+)
+
+// Calculate evaluates an arithmetic expression exactly
+type Calculate struct {
+	kit.BaseTool
+	Expression string `json:"expression" jsonschema:"description=Arithmetic expression with + - * / % ^ parentheses, the constants pi and e and the functions sqrt abs floor ceil round min max"`
+	Digits     int    `json:"digits" jsonschema:"description=Significant digits of the result, 0 for 30"`
+}
+
+func (t *Calculate) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "calculate",
+		Description: "Evaluate an arithmetic expression with arbitrary precision. Use it for every calculation instead of computing in your head.",
+	}
+}
+
+func (t *Calculate) Execute(*kit.Context) (any, error) {
+	value, approximate, err := Evaluate(t.Expression)
+	if err != nil {
+		return result(nil, err)
+	}
+	out := map[string]any{"expression": t.Expression, "result": FormatNumber(value, t.Digits)}
+	if approximate {
+		out["approximate"] = true
+	}
+	return out, nil
+}
+
+// Evaluate computes an arithmetic expression with 512 bit precision
+// approximate is true when a non-integer power had to be computed in float64
+func Evaluate(expression string) (value *big.Float, approximate bool, err error) {
+	p := &parser{input: []rune(expression)}
+	value, err = p.expression()
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.input) {
+			err = fmt.Errorf("unexpected %q at position %d", string(p.input[p.pos]), p.pos+1)
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, p.approximate, nil
+}
+
+// FormatNumber prints a number with at most digits significant digits (30 when digits is 0) and no trailing zeros
+// Integers are printed in full as long as they fit in the precision
+func FormatNumber(value *big.Float, digits int) string {
+	if digits <= 0 {
+		digits = 30
+	}
+	if value.IsInt() && value.MantExp(nil) <= precision {
+		i, _ := value.Int(nil)
+		return i.String()
+	}
+
+	text := value.Text('g', digits)
+	mantissa, exponent, hasExponent := strings.Cut(text, "e")
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	if hasExponent {
+		return mantissa + "e" + exponent
+	}
+	return mantissa
+}
+
+func newFloat() *big.Float {
+	return new(big.Float).SetPrec(precision)
+}
+
+type parser struct {
+	input       []rune
+	pos         int
+	approximate bool
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// peek returns the next operator, ** counts as ^ and × ÷ as * /
+func (p *parser) peek() (rune, int) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0, 0
+	}
+	switch r := p.input[p.pos]; r {
+	case '*':
+		if p.pos+1 < len(p.input) && p.input[p.pos+1] == '*' {
+			return '^', 2
+		}
+		return '*', 1
+	case '×':
+		return '*', 1
+	case '÷':
+		return '/', 1
+	default:
+		return r, 1
+	}
+}
+
+func (p *parser) expression() (*big.Float, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, width := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos += width
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == '+' {
+			left = newFloat().Add(left, right)
+		} else {
+			left = newFloat().Sub(left, right)
+		}
+		if left, err = finite(left); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) term() (*big.Float, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, width := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos += width
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case '*':
+			left = newFloat().Mul(left, right)
+		case '/':
+			if right.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			left = newFloat().Quo(left, right)
+		case '%':
+			if right.Sign() == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			quotient, err := finite(newFloat().Quo(left, right))
+			if err != nil {
+				return nil, err
+			}
+			truncated, _ := quotient.Int(nil)
+			left = newFloat().Sub(left, newFloat().Mul(right, newFloat().SetInt(truncated)))
+		}
+		if left, err = finite(left); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) unary() (*big.Float, error) {
+	op, width := p.peek()
+	if op == '-' || op == '+' {
+		p.pos += width
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == '-' {
+			value = newFloat().Neg(value)
+		}
+		return value, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (*big.Float, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	op, width := p.peek()
+	if op != '^' {
+		return base, nil
+	}
+	p.pos += width
+	// right associative, and -x binds tighter in the exponent: 2^-1
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return p.pow(base, exponent)
+}
+
+func (p *parser) pow(base, exponent *big.Float) (*big.Float, error) {
+	if !exponent.IsInt() {
+		b, _ := base.Float64()
+		e, _ := exponent.Float64()
+		result := math.Pow(b, e)
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return nil, fmt.Errorf("%s ^ %s is not a real number", FormatNumber(base, 0), FormatNumber(exponent, 0))
+		}
+		p.approximate = true
+		return newFloat().SetFloat64(result), nil
+	}
+
+	n, _ := exponent.Int64()
+	if n > 100000 || n < -100000 {
+		return nil, fmt.Errorf("exponent %s is too large", FormatNumber(exponent, 0))
+	}
+	if base.Sign() == 0 && n < 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	result := newFloat().SetInt64(1)
+	square := newFloat().Set(base)
+	for m := abs64(n); m > 0; m >>= 1 {
+		if m&1 == 1 {
+			if _, err := finite(result.Mul(result, square)); err != nil {
+				return nil, err
+			}
+		}
+		if m > 1 {
+			if _, err := finite(square.Mul(square, square)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if n < 0 {
+		result = newFloat().Quo(newFloat().SetInt64(1), result)
+	}
+	return result, nil
+}
+
+// finite rejects values beyond the exponent range of big.Float, which overflow to ±Inf and make
+// further arithmetic panic
+func finite(value *big.Float) (*big.Float, error) {
+	if value.IsInf() {
+		return nil, fmt.Errorf("number is too large")
+	}
+	return value, nil
+}
+
+func (p *parser) primary() (*big.Float, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	r := p.input[p.pos]
+	switch {
+	case r == '(':
+		p.pos++
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if op, _ := p.peek(); op != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return value, nil
+	case unicode.IsDigit(r) || r == '.':
+		return p.number()
+	case unicode.IsLetter(r):
+		return p.identifier()
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", string(r), p.pos+1)
+}
+
+func (p *parser) number() (*big.Float, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.' || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	// exponent, e.g. 1.5e-3
+	if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		next := p.pos + 1
+		if next < len(p.input) && (p.input[next] == '-' || p.input[next] == '+') {
+			next++
+		}
+		if next < len(p.input) && unicode.IsDigit(p.input[next]) {
+			p.pos = next
+			for p.pos < len(p.input) && unicode.IsDigit(p.input[p.pos]) {
+				p.pos++
+			}
+		}
+	}
+
+	text := strings.ReplaceAll(string(p.input[start:p.pos]), "_", "")
+	value, ok := newFloat().SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", text)
+	}
+	return finite(value)
+}
+
+func (p *parser) identifier() (*big.Float, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos])) {
+		p.pos++
+	}
+	name := strings.ToLower(string(p.input[start:p.pos]))
+
+	if op, _ := p.peek(); op != '(' {
+		switch name {
+		case "pi", "π":
+			value, _ := newFloat().SetString(piDigits)
+			return value, nil
+		case "e":
+			value, _ := newFloat().SetString(eDigits)
+			return value, nil
+		}
+		return nil, fmt.Errorf("unknown constant %q", name)
+	}
+
+	p.pos++
+	var args []*big.Float
+	if op, _ := p.peek(); op == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			op, _ := p.peek()
+			p.pos++
+			if op == ')' {
+				break
+			}
+			if op != ',' {
+				return nil, fmt.Errorf("expected , or ) in the arguments of %s", name)
+			}
+		}
+	}
+	return call(name, args)
+}
+
+func call(name string, args []*big.Float) (*big.Float, error) {
+	want := 1
+	if name == "min" || name == "max" {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s needs at least one argument", name)
+		}
+		want = len(args)
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", name, want, len(args))
+	}
+
+	x := args[0]
+	switch name {
+	case "sqrt":
+		if x.Sign() < 0 {
+			return nil, fmt.Errorf("square root of a negative number")
+		}
+		return newFloat().Sqrt(x), nil
+	case "abs":
+		return newFloat().Abs(x), nil
+	case "floor", "ceil", "round":
+		return integerPart(name, x), nil
+	case "min", "max":
+		best := x
+		for _, arg := range args[1:] {
+			if (name == "min" && arg.Cmp(best) < 0) || (name == "max" && arg.Cmp(best) > 0) {
+				best = arg
+			}
+		}
+		return best, nil
+	}
+	return nil, fmt.Errorf("unknown function %q", name)
+}
+
+// integerPart rounds x down, up or half away from zero
+func integerPart(mode string, x *big.Float) *big.Float {
+	truncated, _ := x.Int(nil)
+	t := newFloat().SetInt(truncated)
+	one := newFloat().SetInt64(1)
+
+	switch mode {
+	case "floor":
+		if x.Sign() < 0 && t.Cmp(x) != 0 {
+			t.Sub(t, one)
+		}
+	case "ceil":
+		if x.Sign() > 0 && t.Cmp(x) != 0 {
+			t.Add(t, one)
+		}
+	case "round":
+		half := newFloat().SetFloat64(0.5)
+		fraction := newFloat().Abs(newFloat().Sub(x, t))
+		if fraction.Cmp(half) >= 0 {
+			if x.Sign() < 0 {
+				t.Sub(t, one)
+			} else {
+				t.Add(t, one)
+			}
+		}
+	}
+	return t
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,273 @@
+package stdtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // IANA time zones even where the system has no zoneinfo
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// dateLayouts are the accepted input formats, times without an offset are read in the given time zone
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// now is replaced in tests
+var now = time.Now
+
+// ParseTime parses a date or time in one of the accepted layouts, in location unless it carries an offset
+func ParseTime(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "now") {
+		return now().In(location), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC 3339", value)
+}
+
+// LoadLocation loads an IANA time zone, an empty name is UTC
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q, use an IANA name like Europe/Berlin", name)
+	}
+	return location, nil
+}
+
+// describe is how the date tools return a point in time
+func describe(t time.Time) map[string]any {
+	zone, offset := t.Zone()
+	return map[string]any{
+		"time":       t.Format(time.RFC3339),
+		"date":       t.Format("2006-01-02"),
+		"weekday":    t.Weekday().String(),
+		"time_zone":  t.Location().String(),
+		"zone":       zone,
+		"utc_offset": formatOffset(offset),
+	}
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// CurrentTime returns the current date and time
+type CurrentTime struct {
+	kit.BaseTool
+	TimeZone string `json:"time_zone" jsonschema:"description=IANA time zone like America/New_York, empty for UTC"`
+}
+
+func (t *CurrentTime) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{Name: "current_time", Description: "Get the current date, time and weekday in a time zone."}
+}
+
+func (t *CurrentTime) Execute(*kit.Context) (any, error) {
+	location, err := LoadLocation(t.TimeZone)
+	if err != nil {
+		return result(nil, err)
+	}
+	return describe(now().In(location)), nil
+}
+
+// DateAdd moves a date by calendar units
+type DateAdd struct {
+	kit.BaseTool
+	Date     string `json:"date" jsonschema:"description=Start date as YYYY-MM-DD, YYYY-MM-DD HH:MM, RFC 3339 or now"`
+	TimeZone string `json:"time_zone" jsonschema:"description=IANA time zone the date is in, empty for UTC"`
+	Years    int    `json:"years" jsonschema:"description=Years to add, negative to subtract"`
+	Months   int    `json:"months" jsonschema:"description=Months to add, negative to subtract"`
+	Days     int    `json:"days" jsonschema:"description=Days to add, negative to subtract"`
+	Hours    int    `json:"hours" jsonschema:"description=Hours to add, negative to subtract"`
+	Minutes  int    `json:"minutes" jsonschema:"description=Minutes to add, negative to subtract"`
+	Weekdays int    `json:"weekdays" jsonschema:"description=Business days (Monday to Friday) to add, negative to subtract"`
+}
+
+func (t *DateAdd) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name: "date_add",
+		Description: "Add or subtract years, months, days, business days, hours and minutes to a date. " +
+			"Months are clamped to the end of the month, e.g. Jan 31 + 1 month is Feb 28 or 29. Daylight saving time is respected.",
+	}
+}
+
+func (t *DateAdd) Execute(*kit.Context) (any, error) {
+	location, err := LoadLocation(t.TimeZone)
+	if err != nil {
+		return result(nil, err)
+	}
+	start, err := ParseTime(t.Date, location)
+	if err != nil {
+		return result(nil, err)
+	}
+	return describe(AddDate(start, t.Years, t.Months, t.Days, t.Weekdays, t.Hours, t.Minutes)), nil
+}
+
+// AddDate adds calendar units to t, months are clamped to the last day of the target month instead of overflowing
+// Hours and minutes are added as elapsed time, the other units keep the wall clock time
+func AddDate(t time.Time, years, months, days, weekdays, hours, minutes int) time.Time {
+	if years != 0 || months != 0 {
+		first := time.Date(t.Year()+years, t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		lastDay := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		day := t.Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		t = time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	if days != 0 {
+		t = t.AddDate(0, 0, days)
+	}
+
+	step := 1
+	if weekdays < 0 {
+		step, weekdays = -1, -weekdays
+	}
+	// any 7 consecutive days hold 5 weekdays, whole weeks are skipped at once so a huge count doesn't spin
+	if weeks := (weekdays - 1) / 5; weeks > 0 {
+		t = t.AddDate(0, 0, step*weeks*7)
+		weekdays -= weeks * 5
+	}
+	for weekdays > 0 {
+		t = t.AddDate(0, 0, step)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			weekdays--
+		}
+	}
+
+	return t.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute)
+}
+
+// DateDiff measures the time between two dates
+type DateDiff struct {
+	kit.BaseTool
+	Start    string `json:"start" jsonschema:"description=Start date as YYYY-MM-DD, YYYY-MM-DD HH:MM, RFC 3339 or now"`
+	End      string `json:"end" jsonschema:"description=End date in the same formats"`
+	TimeZone string `json:"time_zone" jsonschema:"description=IANA time zone of dates without an offset, empty for UTC"`
+}
+
+func (t *DateDiff) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name: "date_diff",
+		Description: "Compute the time between two dates: years, months and days on the calendar, total days, " +
+			"business days and hours. Negative when end is before start.",
+	}
+}
+
+func (t *DateDiff) Execute(*kit.Context) (any, error) {
+	location, err := LoadLocation(t.TimeZone)
+	if err != nil {
+		return result(nil, err)
+	}
+	start, err := ParseTime(t.Start, location)
+	if err != nil {
+		return result(nil, err)
+	}
+	end, err := ParseTime(t.End, location)
+	if err != nil {
+		return result(nil, err)
+	}
+	return Diff(start, end), nil
+}
+
+// Diff breaks the time from start to end into calendar units and totals
+func Diff(start, end time.Time) map[string]any {
+	sign := 1
+	if end.Before(start) {
+		start, end, sign = end, start, -1
+	}
+	end = end.In(start.Location())
+
+	// whole calendar months first, then the remaining days and time
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	for months > 0 && AddDate(start, 0, months, 0, 0, 0, 0).After(end) {
+		months--
+	}
+	anchor := AddDate(start, 0, months, 0, 0, 0, 0)
+	days := 0
+	for !anchor.AddDate(0, 0, days+1).After(end) {
+		days++
+	}
+	remainder := end.Sub(anchor.AddDate(0, 0, days))
+
+	calendarDays := civilDays(start, end)
+	return map[string]any{
+		"years":         sign * (months / 12),
+		"months":        sign * (months % 12),
+		"days":          sign * days,
+		"hours":         sign * int(remainder.Hours()),
+		"minutes":       sign * (int(remainder.Minutes()) % 60),
+		"total_days":    sign * calendarDays,
+		"business_days": sign * businessDays(start, calendarDays),
+		"total_hours":   float64(sign) * end.Sub(start).Hours(),
+	}
+}
+
+// civilDays counts the calendar days between the dates of start and end
+func civilDays(start, end time.Time) int {
+	a := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// businessDays counts the Monday to Friday days after start within the next days days
+func businessDays(start time.Time, days int) int {
+	count := 0
+	weekday := start.Weekday()
+	for i := 0; i < days; i++ {
+		weekday = (weekday + 1) % 7
+		if weekday != time.Saturday && weekday != time.Sunday {
+			count++
+		}
+	}
+	return count
+}
+
+// ConvertTimezone shows a point in time in another time zone
+type ConvertTimezone struct {
+	kit.BaseTool
+	Time string `json:"time" jsonschema:"description=Time as YYYY-MM-DD HH:MM, RFC 3339 or now"`
+	From string `json:"from" jsonschema:"description=IANA time zone of the time when it has no offset, empty for UTC"`
+	To   string `json:"to" jsonschema:"description=IANA time zone to convert to"`
+}
+
+func (t *ConvertTimezone) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name:        "convert_timezone",
+		Description: "Convert a time from one time zone to another, respecting daylight saving time.",
+	}
+}
+
+func (t *ConvertTimezone) Execute(*kit.Context) (any, error) {
+	from, err := LoadLocation(t.From)
+	if err != nil {
+		return result(nil, err)
+	}
+	to, err := LoadLocation(t.To)
+	if err != nil {
+		return result(nil, err)
+	}
+	value, err := ParseTime(t.Time, from)
+	if err != nil {
+		return result(nil, err)
+	}
+	return describe(value.In(to)), nil
+}
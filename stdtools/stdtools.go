@@ -0,0 +1,31 @@
+// Package stdtools is a bundle of tools for what models get wrong when they do it in their head:
+// arithmetic, unit conversion and date math
+package stdtools
+
+import (
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// StandardTools returns the calculate, convert_units, current_time, date_add, date_diff and convert_timezone tools
+func StandardTools() []kit.ToolExecutor {
+	return []kit.ToolExecutor{
+		&Calculate{},
+		&ConvertUnits{},
+		&CurrentTime{},
+		&DateAdd{},
+		&DateDiff{},
+		&ConvertTimezone{},
+	}
+}
+
+// failure is returned to the model instead of failing the run, so it can fix its input and retry
+type failure struct {
+	Error string `json:"error"`
+}
+
+func result(value any, err error) (any, error) {
+	if err != nil {
+		return failure{Error: err.Error()}, nil
+	}
+	return value, nil
+}
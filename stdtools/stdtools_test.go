@@ -0,0 +1,237 @@
+package stdtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	cases := map[string]string{
+		"0.1 + 0.2":                            "0.3",
+		"1 / 3 * 3":                            "1",
+		"2 ^ 100":                              "1267650600228229401496703205376",
+		"2 ** 10":                              "1024",
+		"-2 ^ 2":                               "-4",
+		"2 ^ -1":                               "0.5",
+		"2 ^ 3 ^ 2":                            "512",
+		"(1 + 2) * 3 - 4 / 2":                  "7",
+		"17 % 5":                               "2",
+		"-7 % 3":                               "-1",
+		"1_000_000 × 3 ÷ 4":                    "750000",
+		"1.5e3 + 2E-1":                         "1500.2",
+		"sqrt(2)":                              "1.41421356237309504880168872421",
+		"round(2.5) + floor(-1.5) + ceil(1.2)": "3",
+		"max(1, 7, 3) - min(4, -2)":            "9",
+		"abs(-3.25)":                           "3.25",
+		"pi":                                   "3.14159265358979323846264338328",
+		"123456789 * 987654321":                "121932631112635269",
+	}
+	for expression, want := range cases {
+		value, approximate, err := Evaluate(expression)
+		require.NoError(t, err, expression)
+		require.False(t, approximate, expression)
+		require.Equal(t, want, FormatNumber(value, 0), expression)
+	}
+
+	value, approximate, err := Evaluate("2 ^ 0.5")
+	require.NoError(t, err)
+	require.True(t, approximate)
+	require.Equal(t, "1.414214", FormatNumber(value, 7))
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for expression, want := range map[string]string{
+		"1 / 0":       "division by zero",
+		"5 % (2 - 2)": "modulo by zero",
+		"(1 + 2":      "missing closing parenthesis",
+		"1 +":         "unexpected end of expression",
+		"2 3":         `unexpected "3" at position 3`,
+		"foo":         `unknown constant "foo"`,
+		"log(2)":      `unknown function "log"`,
+		"sqrt(-1)":    "square root of a negative number",
+		"(-8) ^ 0.5":  "-8 ^ 0.5 is not a real number",
+		"2 ^ 1000000": "exponent 1000000 is too large",
+	} {
+		_, _, err := Evaluate(expression)
+		require.EqualError(t, err, want, expression)
+	}
+}
+
+func TestEvaluateRejectsOverflow(t *testing.T) {
+	for _, expression := range []string{
+		"1e999999999",
+		"1e999999999 - 1e999999999",
+		"0 * 1e999999999",
+		"1e999999999 / 1e999999999",
+		"1e999999999 % 2",
+		"floor(1e999999999)",
+		"1e500000000 * 1e500000000",
+		"1e500000000 / 1e-500000000",
+		"1e300000000 ^ 100",
+		"1e600000000 % 1e-600000000",
+	} {
+		_, _, err := Evaluate(expression)
+		require.EqualError(t, err, "number is too large", expression)
+	}
+
+	value, _, err := Evaluate("1e300000000 / 1e300000000")
+	require.NoError(t, err)
+	require.Equal(t, "1", FormatNumber(value, 0))
+}
+
+func TestCalculateReturnsErrorsToModel(t *testing.T) {
+	out, err := (&Calculate{Expression: "1 / 0"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, failure{Error: "division by zero"}, out)
+
+	out, err = (&Calculate{Expression: "10 / 4"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"expression": "10 / 4", "result": "2.5"}, out)
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct{ value, from, to, want, dimension string }{
+		{"1", "mi", "km", "1.609344", "length"},
+		{"5", "feet", "inches", "60", "length"},
+		{"100", "kg", "lb", "220.462262184878", "mass"},
+		{"1", "gal", "l", "3.785411784", "volume"},
+		{"1", "GiB", "MB", "1073.741824", "data"},
+		{"100", "km/h", "mph", "62.1371192237334", "speed"},
+		{"1", "kWh", "J", "3600000", "energy"},
+		{"90", "minutes", "h", "1.5", "time"},
+		{"1", "acre", "m2", "4046.8564224", "area"},
+		{"1", "atm", "psi", "14.6959487755134", "pressure"},
+		{"100", "C", "F", "212", "temperature"},
+		{"-40", "fahrenheit", "celsius", "-40", "temperature"},
+		{"0", "K", "C", "-273.15", "temperature"},
+		{"1", "nm", "km", "1e-12", "length"},
+		{"1", "nm", "mi", "6.21371192237334e-13", "length"},
+	}
+	for _, c := range cases {
+		got, dimension, err := Convert(c.value, c.from, c.to)
+		require.NoError(t, err, "%s %s to %s", c.value, c.from, c.to)
+		require.Equal(t, c.want, got, "%s %s to %s", c.value, c.from, c.to)
+		require.Equal(t, c.dimension, dimension)
+	}
+
+	_, _, err := Convert("1", "kg", "m")
+	require.EqualError(t, err, "can't convert kg (mass) to m (length)")
+	_, _, err = Convert("1", "C", "kg")
+	require.EqualError(t, err, "can't convert between C and kg")
+	_, _, err = Convert("one", "kg", "g")
+	require.EqualError(t, err, `invalid value "one"`)
+	_, _, err = Convert("1", "furlong", "m")
+	require.ErrorContains(t, err, `unknown unit "furlong"`)
+}
+
+func TestAddDate(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	jan31, err := ParseTime("2024-01-31", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "2024-02-29", AddDate(jan31, 0, 1, 0, 0, 0, 0).Format("2006-01-02"))
+	require.Equal(t, "2025-02-28", AddDate(jan31, 1, 1, 0, 0, 0, 0).Format("2006-01-02"))
+	require.Equal(t, "2023-12-31", AddDate(jan31, 0, -1, 0, 0, 0, 0).Format("2006-01-02"))
+
+	// Friday plus one business day is Monday, and back again
+	friday, err := ParseTime("2024-03-01", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-04", AddDate(friday, 0, 0, 0, 1, 0, 0).Format("2006-01-02"))
+	require.Equal(t, "2024-02-23", AddDate(friday, 0, 0, 0, -5, 0, 0).Format("2006-01-02"))
+
+	// a day across the spring forward keeps the wall clock, 24 hours don't
+	saturday, err := ParseTime("2024-03-30 12:00", berlin)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-31T12:00:00+02:00", AddDate(saturday, 0, 0, 1, 0, 0, 0).Format(time.RFC3339))
+	require.Equal(t, "2024-03-31T13:00:00+02:00", AddDate(saturday, 0, 0, 0, 0, 24, 0).Format(time.RFC3339))
+}
+
+func TestAddDateWeekdaysSkipsWholeWeeks(t *testing.T) {
+	// reference: one day at a time
+	addWeekdays := func(t time.Time, n int) time.Time {
+		step := 1
+		if n < 0 {
+			step, n = -1, -n
+		}
+		for n > 0 {
+			t = t.AddDate(0, 0, step)
+			if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+				n--
+			}
+		}
+		return t
+	}
+
+	start, err := ParseTime("2024-03-01", time.UTC)
+	require.NoError(t, err)
+	for offset := range 7 {
+		day := start.AddDate(0, 0, offset)
+		for n := -23; n <= 23; n++ {
+			require.Equal(t, addWeekdays(day, n), AddDate(day, 0, 0, 0, n, 0, 0), "%s %+d", day.Weekday(), n)
+		}
+	}
+
+	done := make(chan time.Time)
+	go func() { done <- AddDate(start, 0, 0, 0, 2000000000, 0, 0) }()
+	select {
+	case got := <-done:
+		require.Equal(t, time.Friday, got.Weekday())
+	case <-time.After(time.Second):
+		t.Fatal("a huge weekday count must not step one day at a time")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	start, err := ParseTime("2024-01-31 09:00", time.UTC)
+	require.NoError(t, err)
+	end, err := ParseTime("2025-03-02T12:30:00Z", time.UTC)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]any{
+		"years": 1, "months": 1, "days": 2, "hours": 3, "minutes": 30,
+		"total_days": 396, "business_days": 282, "total_hours": 9507.5,
+	}, Diff(start, end))
+
+	reversed := Diff(end, start)
+	require.Equal(t, -1, reversed["years"])
+	require.Equal(t, -396, reversed["total_days"])
+}
+
+func TestDateTools(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 7, 4, 15, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	out, err := (&CurrentTime{TimeZone: "America/New_York"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"time": "2024-07-04T11:30:00-04:00", "date": "2024-07-04", "weekday": "Thursday",
+		"time_zone": "America/New_York", "zone": "EDT", "utc_offset": "-04:00",
+	}, out)
+
+	out, err = (&ConvertTimezone{Time: "2024-01-15 09:00", From: "Asia/Tokyo", To: "Europe/London"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-15T00:00:00Z", out.(map[string]any)["time"])
+
+	out, err = (&DateAdd{Date: "now", Days: 30}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024-08-03", out.(map[string]any)["date"])
+
+	out, err = (&DateDiff{Start: "now", End: "2024-12-25", TimeZone: "Mars/Olympus"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, failure{Error: `unknown time zone "Mars/Olympus", use an IANA name like Europe/Berlin`}, out)
+
+	out, err = (&DateAdd{Date: "July 4th"}).Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, failure{Error: `invalid date "July 4th", use YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC 3339`}, out)
+}
+
+func TestStandardToolsNames(t *testing.T) {
+	var names []string
+	for _, tool := range StandardTools() {
+		names = append(names, tool.AgentToolInfo().Name)
+	}
+	require.Equal(t, []string{"calculate", "convert_units", "current_time", "date_add", "date_diff", "convert_timezone"}, names)
+}
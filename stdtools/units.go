@@ -0,0 +1,220 @@
+package stdtools
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/kit"
+)
+
+// unit is a unit of measure, factor converts it to the base unit of its dimension
+type unit struct {
+	dimension string
+	factor    string // exact decimal or fraction
+}
+
+// units maps unit symbols and names to their definition, lookups fall back to lower case
+// Temperatures aren't proportional and are handled separately
+var units = map[string]unit{}
+
+func init() {
+	define := func(dimension string, factor string, names ...string) {
+		for _, name := range names {
+			units[name] = unit{dimension: dimension, factor: factor}
+		}
+	}
+
+	define("length", "1", "m", "meter", "meters", "metre", "metres")
+	define("length", "1000", "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	define("length", "1/100", "cm", "centimeter", "centimeters", "centimetre", "centimetres")
+	define("length", "1/1000", "mm", "millimeter", "millimeters", "millimetre", "millimetres")
+	define("length", "1/1000000", "um", "µm", "micrometer", "micrometers", "micron", "microns")
+	define("length", "1/1000000000", "nm", "nanometer", "nanometers")
+	define("length", "1609.344", "mi", "mile", "miles")
+	define("length", "0.9144", "yd", "yard", "yards")
+	define("length", "0.3048", "ft", "foot", "feet")
+	define("length", "0.0254", "in", "inch", "inches")
+	define("length", "1852", "nmi", "nautical mile", "nautical miles")
+
+	define("mass", "1", "kg", "kilogram", "kilograms")
+	define("mass", "1/1000", "g", "gram", "grams")
+	define("mass", "1/1000000", "mg", "milligram", "milligrams")
+	define("mass", "1/1000000000", "ug", "µg", "microgram", "micrograms")
+	define("mass", "1000", "t", "tonne", "tonnes", "metric ton", "metric tons")
+	define("mass", "0.45359237", "lb", "lbs", "pound", "pounds")
+	define("mass", "0.028349523125", "oz", "ounce", "ounces")
+	define("mass", "6.35029318", "st", "stone", "stones")
+
+	define("time", "1", "s", "sec", "second", "seconds")
+	define("time", "1/1000", "ms", "millisecond", "milliseconds")
+	define("time", "1/1000000", "us", "µs", "microsecond", "microseconds")
+	define("time", "1/1000000000", "ns", "nanosecond", "nanoseconds")
+	define("time", "60", "min", "minute", "minutes")
+	define("time", "3600", "h", "hr", "hour", "hours")
+	define("time", "86400", "d", "day", "days")
+	define("time", "604800", "wk", "week", "weeks")
+	define("time", "31557600", "yr", "year", "years") // Julian year of 365.25 days
+
+	define("area", "1", "m2", "m²", "square meter", "square meters")
+	define("area", "1000000", "km2", "km²", "square kilometer", "square kilometers")
+	define("area", "1/10000", "cm2", "cm²", "square centimeter", "square centimeters")
+	define("area", "10000", "ha", "hectare", "hectares")
+	define("area", "4046.8564224", "ac", "acre", "acres")
+	define("area", "0.09290304", "ft2", "ft²", "sq ft", "square foot", "square feet")
+	define("area", "0.00064516", "in2", "in²", "sq in", "square inch", "square inches")
+	define("area", "2589988.110336", "mi2", "mi²", "sq mi", "square mile", "square miles")
+
+	define("volume", "1", "m3", "m³", "cubic meter", "cubic meters")
+	define("volume", "1/1000", "l", "L", "liter", "liters", "litre", "litres")
+	define("volume", "1/1000000", "ml", "mL", "cm3", "cm³", "cc", "milliliter", "milliliters", "millilitre", "millilitres")
+	define("volume", "0.003785411784", "gal", "gallon", "gallons", "us gallon", "us gallons")
+	define("volume", "0.00454609", "imp gal", "imperial gallon", "imperial gallons")
+	define("volume", "0.000946352946", "qt", "quart", "quarts")
+	define("volume", "0.000473176473", "pt", "pint", "pints")
+	define("volume", "0.0002365882365", "cup", "cups")
+	define("volume", "0.0000295735295625", "fl oz", "floz", "fluid ounce", "fluid ounces")
+	define("volume", "0.00001478676478125", "tbsp", "tablespoon", "tablespoons")
+	define("volume", "0.00000492892159375", "tsp", "teaspoon", "teaspoons")
+	define("volume", "0.028316846592", "ft3", "ft³", "cubic foot", "cubic feet")
+	define("volume", "0.000016387064", "in3", "in³", "cubic inch", "cubic inches")
+
+	define("speed", "1", "m/s", "meters per second")
+	define("speed", "1000/3600", "km/h", "kmh", "kph", "kilometers per hour")
+	define("speed", "0.44704", "mph", "miles per hour")
+	define("speed", "1852/3600", "kn", "kt", "knot", "knots")
+	define("speed", "0.3048", "ft/s", "feet per second")
+
+	define("data", "1/8", "bit", "bits")
+	define("data", "1", "B", "byte", "bytes")
+	define("data", "1000", "kB", "KB", "kilobyte", "kilobytes")
+	define("data", "1000000", "MB", "megabyte", "megabytes")
+	define("data", "1000000000", "GB", "gigabyte", "gigabytes")
+	define("data", "1000000000000", "TB", "terabyte", "terabytes")
+	define("data", "1024", "KiB", "kibibyte", "kibibytes")
+	define("data", "1048576", "MiB", "mebibyte", "mebibytes")
+	define("data", "1073741824", "GiB", "gibibyte", "gibibytes")
+	define("data", "1099511627776", "TiB", "tebibyte", "tebibytes")
+
+	define("energy", "1", "J", "joule", "joules")
+	define("energy", "1000", "kJ", "kilojoule", "kilojoules")
+	define("energy", "4.184", "cal", "calorie", "calories")
+	define("energy", "4184", "kcal", "kilocalorie", "kilocalories")
+	define("energy", "3600", "Wh", "watt hour", "watt hours")
+	define("energy", "3600000", "kWh", "kilowatt hour", "kilowatt hours")
+	define("energy", "1055.05585262", "BTU", "btu")
+
+	define("power", "1", "W", "watt", "watts")
+	define("power", "1000", "kW", "kilowatt", "kilowatts")
+	define("power", "1000000", "MW", "megawatt", "megawatts")
+	define("power", "745.69987158227022", "hp", "horsepower")
+
+	define("pressure", "1", "Pa", "pascal", "pascals")
+	define("pressure", "1000", "kPa", "kilopascal", "kilopascals")
+	define("pressure", "100000", "bar")
+	define("pressure", "101325", "atm", "atmosphere", "atmospheres")
+	define("pressure", "44482216152605/6451600000", "psi")
+	define("pressure", "133.322387415", "mmHg")
+}
+
+// temperatures holds the conversion of each temperature scale to kelvin: K = (x + offset) * scale
+var temperatures = map[string]struct{ offset, scale string }{
+	"c": {"273.15", "1"}, "°c": {"273.15", "1"}, "celsius": {"273.15", "1"},
+	"f": {"459.67", "5/9"}, "°f": {"459.67", "5/9"}, "fahrenheit": {"459.67", "5/9"},
+	"k": {"0", "1"}, "kelvin": {"0", "1"},
+}
+
+// ConvertUnits converts a quantity between units of the same dimension
+type ConvertUnits struct {
+	kit.BaseTool
+	Value string `json:"value" jsonschema:"description=Quantity to convert, as a decimal number"`
+	From  string `json:"from" jsonschema:"description=Unit of the value, e.g. km, lb, gal, mph, GiB, kWh or F"`
+	To    string `json:"to" jsonschema:"description=Unit to convert to"`
+}
+
+func (t *ConvertUnits) AgentToolInfo() kit.AgentToolInfo {
+	return kit.AgentToolInfo{
+		Name: "convert_units",
+		Description: "Convert a quantity between units of length, mass, time, area, volume, speed, data, energy, " +
+			"power, pressure or temperature. US customary units are used for gallons, quarts, pints and cups.",
+	}
+}
+
+func (t *ConvertUnits) Execute(*kit.Context) (any, error) {
+	converted, dimension, err := Convert(t.Value, t.From, t.To)
+	if err != nil {
+		return result(nil, err)
+	}
+	return map[string]any{"value": t.Value, "from": t.From, "to": t.To, "result": converted, "dimension": dimension}, nil
+}
+
+// Convert converts a decimal value between two units with exact rational arithmetic
+func Convert(value, from, to string) (converted string, dimension string, err error) {
+	x, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return "", "", fmt.Errorf("invalid value %q", value)
+	}
+
+	fromTemp, fromIsTemp := temperatures[strings.ToLower(strings.TrimSpace(from))]
+	toTemp, toIsTemp := temperatures[strings.ToLower(strings.TrimSpace(to))]
+	if fromIsTemp || toIsTemp {
+		if !fromIsTemp || !toIsTemp {
+			return "", "", fmt.Errorf("can't convert between %s and %s", from, to)
+		}
+		kelvin := new(big.Rat).Mul(new(big.Rat).Add(x, rat(fromTemp.offset)), rat(fromTemp.scale))
+		scaled := new(big.Rat).Sub(new(big.Rat).Quo(kelvin, rat(toTemp.scale)), rat(toTemp.offset))
+		return formatRat(scaled), "temperature", nil
+	}
+
+	fromUnit, err := lookupUnit(from)
+	if err != nil {
+		return "", "", err
+	}
+	toUnit, err := lookupUnit(to)
+	if err != nil {
+		return "", "", err
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return "", "", fmt.Errorf("can't convert %s (%s) to %s (%s)", from, fromUnit.dimension, to, toUnit.dimension)
+	}
+
+	quantity := new(big.Rat).Quo(new(big.Rat).Mul(x, rat(fromUnit.factor)), rat(toUnit.factor))
+	return formatRat(quantity), fromUnit.dimension, nil
+}
+
+func lookupUnit(name string) (unit, error) {
+	name = strings.TrimSpace(name)
+	if u, ok := units[name]; ok {
+		return u, nil
+	}
+	if u, ok := units[strings.ToLower(name)]; ok {
+		return u, nil
+	}
+	// plural or singular names the table doesn't list, e.g. "Feet" or "kilograms"
+	if u, ok := units[strings.ToLower(strings.TrimSuffix(name, "s"))]; ok && len(name) > 2 {
+		return u, nil
+	}
+
+	var known []string
+	for symbol := range units {
+		if !strings.Contains(symbol, " ") && len(symbol) <= 4 {
+			known = append(known, symbol)
+		}
+	}
+	sort.Strings(known)
+	return unit{}, fmt.Errorf("unknown unit %q, known units include %s", name, strings.Join(known, ", "))
+}
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("stdtools: invalid unit factor " + s)
+	}
+	return r
+}
+
+// formatRat prints an exact value with 15 significant digits, like the calculator
+func formatRat(r *big.Rat) string {
+	return FormatNumber(newFloat().SetRat(r), 15)
+}
@@ -0,0 +1,210 @@
+// Package textnorm normalizes Persian and Arabic script text, so differently typed forms of the same word
+// embed and match the same, and truncates right-to-left text without breaking its rendering
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Digits selects the digits numbers are written with
+type Digits int
+
+const (
+	DigitsKeep    Digits = iota // Digits are left as they are
+	DigitsLatin                 // Persian and Arabic-Indic digits become 0-9
+	DigitsPersian               // 0-9 and Arabic-Indic digits become ۰-۹
+)
+
+// Normalizer is a set of normalization steps, the zero value changes nothing
+type Normalizer struct {
+	// UnifyLetters replaces Arabic forms with their Persian counterparts: ي and ى with ی, ك with ک, ة with ه
+	// and hamza forms of alef with ا
+	UnifyLetters bool
+	// Digits converts digits (optional, defaults to DigitsKeep)
+	Digits Digits
+	// RemoveDiacritics drops harakat, tanwin and other Arabic combining marks
+	RemoveDiacritics bool
+	// RemoveTatweel drops the kashida used to stretch words (ـ)
+	RemoveTatweel bool
+	// FixZWNJ drops zero width non-joiners that are repeated or next to spaces, where they join nothing
+	FixZWNJ bool
+	// RemoveBidiControls drops directional marks, embeddings and isolates
+	RemoveBidiControls bool
+	// CollapseSpaces replaces runs of whitespace with a single space and trims the text
+	CollapseSpaces bool
+}
+
+// Persian normalizes text for search over Persian corpora, with digits written as 0-9
+var Persian = Normalizer{
+	UnifyLetters:       true,
+	Digits:             DigitsLatin,
+	RemoveDiacritics:   true,
+	RemoveTatweel:      true,
+	FixZWNJ:            true,
+	RemoveBidiControls: true,
+	CollapseSpaces:     true,
+}
+
+const (
+	zwnj    = '\u200c'
+	tatweel = 'ـ'
+)
+
+// letters are the replacements of UnifyLetters
+var letters = map[rune]rune{
+	'ي': 'ی', 'ى': 'ی', 'ك': 'ک', 'ة': 'ه', 'ە': 'ه', 'أ': 'ا', 'إ': 'ا', 'ٱ': 'ا', 'ؤ': 'و',
+}
+
+// Normalize applies the Persian normalizer
+func Normalize(text string) string {
+	return Persian.Normalize(text)
+}
+
+// Normalize applies the enabled steps to text
+func (n Normalizer) Normalize(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case n.RemoveBidiControls && isBidiControl(r):
+			continue
+		case n.RemoveTatweel && r == tatweel:
+			continue
+		case n.RemoveDiacritics && isArabicMark(r):
+			continue
+		}
+		if n.UnifyLetters {
+			if replacement, ok := letters[r]; ok {
+				r = replacement
+			}
+		}
+		b.WriteRune(n.digit(r))
+	}
+	text = b.String()
+
+	if n.FixZWNJ {
+		text = fixZWNJ(text)
+	}
+	if n.CollapseSpaces {
+		text = strings.Join(strings.Fields(text), " ")
+	}
+	return text
+}
+
+// digit converts r when it's a digit of another script than the wanted one
+func (n Normalizer) digit(r rune) rune {
+	switch n.Digits {
+	case DigitsLatin:
+		if r >= '۰' && r <= '۹' {
+			return '0' + r - '۰'
+		}
+		if r >= '٠' && r <= '٩' {
+			return '0' + r - '٠'
+		}
+	case DigitsPersian:
+		if r >= '0' && r <= '9' {
+			return '۰' + r - '0'
+		}
+		if r >= '٠' && r <= '٩' {
+			return '۰' + r - '٠'
+		}
+	}
+	return r
+}
+
+// fixZWNJ keeps only the non-joiners between two letters
+func fixZWNJ(text string) string {
+	runes := []rune(text)
+	kept := runes[:0]
+	for i, r := range runes {
+		if r == zwnj {
+			if len(kept) == 0 || i == len(runes)-1 {
+				continue
+			}
+			previous, next := kept[len(kept)-1], runes[i+1]
+			if previous == zwnj || unicode.IsSpace(previous) || unicode.IsSpace(next) || next == zwnj {
+				continue
+			}
+		}
+		kept = append(kept, r)
+	}
+	return string(kept)
+}
+
+// isArabicMark reports whether r is a harakat, tanwin or other combining mark of the Arabic block
+func isArabicMark(r rune) bool {
+	return r >= '\u0610' && r <= '\u06ed' && unicode.Is(unicode.Mn, r)
+}
+
+// isBidiControl reports whether r is a directional formatting character
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u200e', r == '\u200f', r == '\u061c': // LRM, RLM, ALM
+		return true
+	case r >= '\u202a' && r <= '\u202e': // embeddings and overrides, PDF
+		return true
+	case r >= '\u2066' && r <= '\u2069': // isolates, PDI
+		return true
+	}
+	return false
+}
+
+// Truncate shortens text to at most limit characters, ending it with "…"
+// It cuts at a word boundary in the last fifth when there is one, never between a letter and its combining marks or
+// next to a joiner, and closes the directional embeddings and isolates left open, which aren't counted in limit
+func Truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+
+	cut := limit - 1 // room for the ellipsis
+	for cut > 0 && (unicode.Is(unicode.M, runes[cut]) || runes[cut] == zwnj || runes[cut] == '\u200d') {
+		cut--
+	}
+	for boundary := cut; boundary > cut-limit/5 && boundary > 0; boundary-- {
+		if unicode.IsSpace(runes[boundary]) {
+			cut = boundary
+			break
+		}
+	}
+
+	kept := strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || r == zwnj || r == '\u200d'
+	})
+	return kept + "…" + closeBidi(kept)
+}
+
+// closeBidi returns the PDF and PDI characters that close the embeddings and isolates left open in text
+func closeBidi(text string) string {
+	var open []rune // closing characters, innermost last
+	for _, r := range text {
+		switch {
+		case r >= '\u202a' && r <= '\u202e' && r != '\u202c':
+			open = append(open, '\u202c')
+		case r >= '\u2066' && r <= '\u2068':
+			open = append(open, '\u2069')
+		case r == '\u202c' || r == '\u2069':
+			// a closer ends the innermost matching opener, and for PDI everything opened inside the isolate
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == r {
+					open = open[:i]
+					break
+				}
+				if r == '\u202c' {
+					break // a PDF doesn't close anything beyond an isolate
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteRune(open[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package textnorm
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for text, want := range map[string]string{
+		"علي در كتابخانة است":                "علی در کتابخانه است",
+		"قیمت ۱۲۵ و ٣٤ تومان":                "قیمت 125 و 34 تومان",
+		"كِتَابٌ":                            "کتاب",
+		"خـــیلی خوب":                        "خیلی خوب",
+		"می\u200c\u200cروم \u200cخانه\u200c": "می\u200cروم خانه",
+		"\u202bسلام\u202c \u200fدنیا":        "سلام دنیا",
+		"  سلام \n\n  دنیا ":                 "سلام دنیا",
+		"Hello World 42":                     "Hello World 42",
+	} {
+		require.Equal(t, want, Normalize(text), text)
+	}
+}
+
+func TestNormalizerSteps(t *testing.T) {
+	require.Equal(t, "كتاب ۱۲۳", Normalizer{Digits: DigitsPersian}.Normalize("كتاب 1٢3"))
+	require.Equal(t, "  كتاب  ", Normalizer{}.Normalize("  كتاب  "))
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "سلام", Truncate("سلام", 10))
+	require.Equal(t, "", Truncate("سلام", 0))
+
+	// cut at the last space in reach
+	require.Equal(t, "این یک متن…", Truncate("این یک متن طولانی است", 12))
+
+	// the base letter isn't separated from its marks
+	truncated := Truncate("ابتدایی كِتَابٌ", 12)
+	require.Equal(t, "ابتدایی كِ…", truncated)
+	require.LessOrEqual(t, utf8.RuneCountInString(truncated), 12)
+
+	// open isolates and embeddings are closed
+	require.Equal(t, "\u2067سلام دنیای…\u2069", Truncate("\u2067سلام دنیای بزرگ\u2069", 12))
+	require.Equal(t, "\u202babc \u2066def…\u2069\u202c", Truncate("\u202babc \u2066defghijklmnop\u2069\u202c", 10))
+	require.Equal(t, "\u2066a\u2069 bcdefgh…", Truncate("\u2066a\u2069 bcdefghijklmnop", 12))
+}
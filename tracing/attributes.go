@@ -0,0 +1,14 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WithAttributes attaches request scoped attributes to every span and generation the kit creates
+// for agent runs invoked with the returned context
+func WithAttributes(ctx context.Context, kv ...attribute.KeyValue) context.Context {
+	return callback.WithAttributes(ctx, kv...)
+}
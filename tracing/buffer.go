@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ExportStats counts spans passing through the trace exporter
+type ExportStats struct {
+	Ended    int64 // Spans handed to the batch queue
+	Exported int64 // Spans accepted by Langfuse
+	Failed   int64 // Spans in batches that failed to export
+}
+
+// Pending returns spans still queued, in flight or dropped because the queue was full
+func (s ExportStats) Pending() int64 {
+	return s.Ended - s.Exported - s.Failed
+}
+
+// exportCounters is shared by countingProcessor and countingExporter
+type exportCounters struct {
+	ended    atomic.Int64
+	exported atomic.Int64
+	failed   atomic.Int64
+}
+
+func (c *exportCounters) stats() ExportStats {
+	return ExportStats{
+		Ended:    c.ended.Load(),
+		Exported: c.exported.Load(),
+		Failed:   c.failed.Load(),
+	}
+}
+
+// countingProcessor counts ended spans, it's registered before the batch processor
+type countingProcessor struct {
+	counters *exportCounters
+}
+
+func (p countingProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p countingProcessor) OnEnd(sdktrace.ReadOnlySpan) {
+	p.counters.ended.Add(1)
+}
+
+func (p countingProcessor) Shutdown(context.Context) error { return nil }
+
+func (p countingProcessor) ForceFlush(context.Context) error { return nil }
+
+// countingExporter counts exported and failed spans of the wrapped exporter
+type countingExporter struct {
+	sdktrace.SpanExporter
+	counters *exportCounters
+}
+
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		e.counters.failed.Add(int64(len(spans)))
+	} else {
+		e.counters.exported.Add(int64(len(spans)))
+	}
+	return err
+}
+
+// batchOptions converts the buffer settings of the config into batch span processor options
+func batchOptions(config LangfuseConfig) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if config.BatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(config.BatchSize))
+	}
+	if config.FlushInterval > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(config.FlushInterval))
+	}
+	if config.MaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(config.MaxQueueSize))
+	}
+	if config.ExportTimeout > 0 {
+		opts = append(opts, sdktrace.WithExportTimeout(config.ExportTimeout))
+	}
+	if config.BlockOnQueueFull {
+		opts = append(opts, sdktrace.WithBlocking())
+	}
+	return opts
+}
+
+// registerExportMetrics reports the export counters as observable counters
+func registerExportMetrics(meter metric.Meter, counters *exportCounters) error {
+	ended, err := meter.Int64ObservableCounter(
+		"goaikit.trace.spans.ended",
+		metric.WithDescription("Spans handed to the trace export queue"),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ended spans counter: %w", err)
+	}
+
+	exported, err := meter.Int64ObservableCounter(
+		"goaikit.trace.spans.exported",
+		metric.WithDescription("Spans exported to Langfuse"),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create exported spans counter: %w", err)
+	}
+
+	failed, err := meter.Int64ObservableCounter(
+		"goaikit.trace.spans.failed",
+		metric.WithDescription("Spans that failed to export"),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create failed spans counter: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := counters.stats()
+		o.ObserveInt64(ended, stats.Ended)
+		o.ObserveInt64(exported, stats.Exported)
+		o.ObserveInt64(failed, stats.Failed)
+		return nil
+	}, ended, exported, failed)
+	if err != nil {
+		return fmt.Errorf("failed to register export metrics: %w", err)
+	}
+	return nil
+}
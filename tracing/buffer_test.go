@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExportStatsCountSpans(t *testing.T) {
+	counters := &exportCounters{}
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(countingProcessor{counters: counters}),
+		sdktrace.WithBatcher(
+			countingExporter{SpanExporter: exporter, counters: counters},
+			batchOptions(LangfuseConfig{BatchSize: 2, MaxQueueSize: 10})...,
+		),
+	)
+
+	tracer := provider.Tracer("test")
+	for range 3 {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+	require.Equal(t, int64(3), counters.stats().Ended)
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	stats := counters.stats()
+	require.Equal(t, int64(3), stats.Exported)
+	require.Equal(t, int64(0), stats.Pending())
+	require.Len(t, exporter.GetSpans(), 3)
+}
@@ -0,0 +1,358 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/kit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DatasetItem is a single item of a Langfuse dataset
+type DatasetItem struct {
+	ID             string         `json:"id"`
+	Status         string         `json:"status"`
+	Input          any            `json:"input"`
+	ExpectedOutput any            `json:"expectedOutput"`
+	Metadata       map[string]any `json:"metadata"`
+}
+
+// Dataset is a Langfuse dataset with its active items
+type Dataset struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Metadata    map[string]any `json:"metadata"`
+	Items       []DatasetItem  `json:"-"`
+}
+
+// Score is an evaluation result attached to the trace of a dataset item
+type Score struct {
+	Name    string
+	Value   float64
+	Comment string
+}
+
+// DatasetTarget runs one dataset item and returns its output
+// ctx carries the span of the item, so traces started from it are linked to the dataset run
+type DatasetTarget func(ctx context.Context, item DatasetItem) (any, error)
+
+// DatasetScorer scores the output of a dataset item, e.g. by comparing it to item.ExpectedOutput
+type DatasetScorer func(ctx context.Context, item DatasetItem, output any) (Score, error)
+
+// DatasetRunConfig configures RunDataset
+type DatasetRunConfig struct {
+	// DatasetName is the Langfuse dataset to run (required)
+	DatasetName string
+
+	// RunName identifies the run in Langfuse (required)
+	RunName string
+
+	// Description of the run (optional)
+	Description string
+
+	// Metadata attached to every run item (optional)
+	Metadata map[string]any
+
+	// Target runs every item (required)
+	Target DatasetTarget
+
+	// Scorers evaluate every successful output (optional)
+	Scorers []DatasetScorer
+
+	// Concurrency is the number of items run in parallel (defaults to 1)
+	Concurrency int
+}
+
+// DatasetItemResult is the outcome of one dataset item
+type DatasetItemResult struct {
+	Item    DatasetItem
+	Output  any
+	TraceID string
+	Scores  []Score
+	// Err is set when the target, a scorer or reporting to Langfuse failed
+	Err error
+}
+
+// DatasetRunResult is the outcome of a dataset run
+type DatasetRunResult struct {
+	RunName string
+	Items   []DatasetItemResult
+}
+
+// AverageScores returns the mean value of every score name over the run
+func (r *DatasetRunResult) AverageScores() map[string]float64 {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, item := range r.Items {
+		for _, s := range item.Scores {
+			sums[s.Name] += s.Value
+			counts[s.Name]++
+		}
+	}
+	for name := range sums {
+		sums[name] /= float64(counts[name])
+	}
+	return sums
+}
+
+// AgentTarget runs every dataset item through an agent with a Langfuse callback nested in the item's trace
+// prompt builds the agent prompt from the item, defaults to the item input as a string or JSON
+func AgentTarget[Output any](
+	agent *kit.Agent[Output],
+	tracer trace.Tracer,
+	prompt func(item DatasetItem) string,
+) DatasetTarget {
+	if prompt == nil {
+		prompt = defaultDatasetPrompt
+	}
+
+	return func(ctx context.Context, item DatasetItem) (any, error) {
+		cb := callback.NewLangfuseCallback(callback.LangfuseCallbackConfig{
+			Tracer:        tracer,
+			ParentContext: ctx,
+		})
+
+		return agent.Invoke(ctx, kit.InvokeConfig{
+			Prompt:    prompt(item),
+			Callbacks: []callback.AgentCallback{cb},
+		})
+	}
+}
+
+// defaultDatasetPrompt uses string inputs as is and JSON encodes anything else
+func defaultDatasetPrompt(item DatasetItem) string {
+	if s, ok := item.Input.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(item.Input)
+	return string(data)
+}
+
+// GetDataset fetches a dataset and all of its active items
+func (t *OTELLangfuseTracer) GetDataset(ctx context.Context, name string) (*Dataset, error) {
+	var dataset Dataset
+	if err := t.langfuseAPI(ctx, http.MethodGet, "/api/public/v2/datasets/"+url.PathEscape(name), nil, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to fetch dataset %s: %w", name, err)
+	}
+
+	for page := 1; ; page++ {
+		var resp struct {
+			Data []DatasetItem `json:"data"`
+			Meta struct {
+				TotalPages int `json:"totalPages"`
+			} `json:"meta"`
+		}
+
+		query := url.Values{}
+		query.Set("datasetName", name)
+		query.Set("page", fmt.Sprint(page))
+		query.Set("limit", "50")
+		if err := t.langfuseAPI(ctx, http.MethodGet, "/api/public/dataset-items?"+query.Encode(), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch items of dataset %s: %w", name, err)
+		}
+
+		for _, item := range resp.Data {
+			if item.Status == "" || item.Status == "ACTIVE" {
+				dataset.Items = append(dataset.Items, item)
+			}
+		}
+		if page >= resp.Meta.TotalPages {
+			break
+		}
+	}
+
+	return &dataset, nil
+}
+
+// RunDataset runs every item of a dataset through the target, links the resulting traces to a
+// dataset run and pushes the scores back to Langfuse
+// Failures of single items are reported in their result and don't stop the run
+func (t *OTELLangfuseTracer) RunDataset(ctx context.Context, config DatasetRunConfig) (*DatasetRunResult, error) {
+	if config.DatasetName == "" || config.RunName == "" {
+		return nil, fmt.Errorf("DatasetName and RunName are required")
+	}
+	if config.Target == nil {
+		return nil, fmt.Errorf("Target is required")
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	dataset, err := t.GetDataset(ctx, config.DatasetName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DatasetRunResult{
+		RunName: config.RunName,
+		Items:   make([]DatasetItemResult, len(dataset.Items)),
+	}
+	sem := make(chan struct{}, config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, item := range dataset.Items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result.Items[i] = t.runDatasetItem(ctx, config, item)
+		}()
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// runDatasetItem runs, scores and reports a single dataset item
+func (t *OTELLangfuseTracer) runDatasetItem(
+	ctx context.Context,
+	config DatasetRunConfig,
+	item DatasetItem,
+) DatasetItemResult {
+	res := DatasetItemResult{Item: item}
+
+	itemCtx, span := t.tracer.Start(ctx, "dataset.item", trace.WithSpanKind(trace.SpanKindInternal))
+	res.TraceID = span.SpanContext().TraceID().String()
+
+	inputJSON, _ := json.Marshal(item.Input)
+	span.SetAttributes(
+		attribute.String("langfuse.observation.input", string(inputJSON)),
+		attribute.String("dataset.name", config.DatasetName),
+		attribute.String("dataset.item_id", item.ID),
+		attribute.String("dataset.run_name", config.RunName),
+	)
+
+	res.Output, res.Err = config.Target(itemCtx, item)
+	if res.Err != nil {
+		span.RecordError(res.Err)
+		span.SetStatus(codes.Error, res.Err.Error())
+	} else {
+		outputJSON, _ := json.Marshal(res.Output)
+		span.SetAttributes(attribute.String("langfuse.observation.output", string(outputJSON)))
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+
+	runItem := map[string]any{
+		"runName":       config.RunName,
+		"datasetItemId": item.ID,
+		"traceId":       res.TraceID,
+	}
+	if config.Description != "" {
+		runItem["runDescription"] = config.Description
+	}
+	if config.Metadata != nil {
+		runItem["metadata"] = config.Metadata
+	}
+	if err := t.langfuseAPI(ctx, http.MethodPost, "/api/public/dataset-run-items", runItem, nil); err != nil {
+		res.Err = fmt.Errorf("failed to create dataset run item: %w", err)
+		return res
+	}
+
+	if res.Err != nil {
+		return res
+	}
+
+	for _, scorer := range config.Scorers {
+		score, err := scorer(ctx, item, res.Output)
+		if err != nil {
+			res.Err = fmt.Errorf("scorer failed: %w", err)
+			return res
+		}
+		if err := t.PushScore(ctx, res.TraceID, score); err != nil {
+			res.Err = err
+			return res
+		}
+		res.Scores = append(res.Scores, score)
+	}
+
+	return res
+}
+
+// PushScore attaches a numeric score to a trace
+func (t *OTELLangfuseTracer) PushScore(ctx context.Context, traceID string, score Score) error {
+	body := map[string]any{
+		"traceId":  traceID,
+		"name":     score.Name,
+		"value":    score.Value,
+		"dataType": "NUMERIC",
+	}
+	if score.Comment != "" {
+		body["comment"] = score.Comment
+	}
+	if err := t.langfuseAPI(ctx, http.MethodPost, "/api/public/scores", body, nil); err != nil {
+		return fmt.Errorf("failed to push score %s: %w", score.Name, err)
+	}
+	return nil
+}
+
+// langfuseAPI calls the Langfuse public REST API and decodes the JSON response into out
+func (t *OTELLangfuseTracer) langfuseAPI(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.apiBaseURL()+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(t.config.PublicKey, t.config.SecretKey)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := t.config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("langfuse returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// apiBaseURL returns the REST API base URL, Host is used with https when it has no scheme
+func (t *OTELLangfuseTracer) apiBaseURL() string {
+	host := t.config.APIBaseURL
+	if host == "" {
+		host = t.config.Host
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return strings.TrimRight(host, "/")
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestRunDatasetReportsRunItemsAndScores(t *testing.T) {
+	var mu sync.Mutex
+	var runItems, scores []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "pk", user)
+		require.Equal(t, "sk", pass)
+
+		switch r.URL.Path {
+		case "/api/public/v2/datasets/qa":
+			_, _ = w.Write([]byte(`{"id":"ds-1","name":"qa"}`))
+		case "/api/public/dataset-items":
+			require.Equal(t, "qa", r.URL.Query().Get("datasetName"))
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"item-1","status":"ACTIVE","input":"2+2","expectedOutput":"4"},
+				{"id":"item-2","status":"ACTIVE","input":"fail","expectedOutput":"x"},
+				{"id":"item-3","status":"ARCHIVED","input":"old"}
+			],"meta":{"page":1,"totalPages":1}}`))
+		case "/api/public/dataset-run-items", "/api/public/scores":
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			mu.Lock()
+			if r.URL.Path == "/api/public/scores" {
+				scores = append(scores, body)
+			} else {
+				runItems = append(runItems, body)
+			}
+			mu.Unlock()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracer := &OTELLangfuseTracer{
+		tracer: sdktrace.NewTracerProvider().Tracer("test"),
+		config: LangfuseConfig{PublicKey: "pk", SecretKey: "sk", APIBaseURL: server.URL},
+	}
+
+	result, err := tracer.RunDataset(context.Background(), DatasetRunConfig{
+		DatasetName: "qa",
+		RunName:     "run-1",
+		Concurrency: 2,
+		Target: func(ctx context.Context, item DatasetItem) (any, error) {
+			if item.Input == "fail" {
+				return nil, errors.New("boom")
+			}
+			return "4", nil
+		},
+		Scorers: []DatasetScorer{
+			func(ctx context.Context, item DatasetItem, output any) (Score, error) {
+				if output == item.ExpectedOutput {
+					return Score{Name: "exact_match", Value: 1}, nil
+				}
+				return Score{Name: "exact_match", Value: 0}, nil
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+
+	require.NoError(t, result.Items[0].Err)
+	require.Equal(t, "4", result.Items[0].Output)
+	require.Error(t, result.Items[1].Err)
+	require.Equal(t, map[string]float64{"exact_match": 1}, result.AverageScores())
+
+	require.Len(t, runItems, 2)
+	for _, item := range runItems {
+		require.Equal(t, "run-1", item["runName"])
+		require.NotEmpty(t, item["traceId"])
+	}
+
+	require.Len(t, scores, 1)
+	require.Equal(t, result.Items[0].TraceID, scores[0]["traceId"])
+	require.Equal(t, "exact_match", scores[0]["name"])
+}
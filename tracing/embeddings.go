@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/embedding"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedEmbeddings wraps an embedding client, every request becomes a Langfuse generation with model and token usage
+// The span is a child of the span in the request context, so ingestion and retrieval show up inside agent traces
+type TracedEmbeddings struct {
+	client  embedding.Client
+	tracer  trace.Tracer
+	metrics *MetricsCallback
+}
+
+// NewTracedEmbeddings traces the client's requests with the given tracer
+func NewTracedEmbeddings(client embedding.Client, tracer trace.Tracer) *TracedEmbeddings {
+	return &TracedEmbeddings{client: client, tracer: tracer}
+}
+
+// TraceEmbeddings traces the client's requests, with EnableMetrics they're also counted in the generation metrics
+func (t *OTELLangfuseTracer) TraceEmbeddings(client embedding.Client) *TracedEmbeddings {
+	return &TracedEmbeddings{client: client, tracer: t.tracer, metrics: t.metrics}
+}
+
+// Model returns the model of the wrapped client, empty if it doesn't report one
+func (e *TracedEmbeddings) Model() string {
+	if named, ok := e.client.(embedding.Named); ok {
+		return named.Model()
+	}
+	return ""
+}
+
+// Dimensions returns the native dimensions of the wrapped client, 0 if it doesn't report them
+func (e *TracedEmbeddings) Dimensions() int {
+	if dimensioned, ok := e.client.(embedding.Dimensioned); ok {
+		return dimensioned.Dimensions()
+	}
+	return 0
+}
+
+// Matryoshka reports whether the wrapped client's vectors can be truncated
+func (e *TracedEmbeddings) Matryoshka() bool {
+	dimensioned, ok := e.client.(embedding.Dimensioned)
+	return ok && dimensioned.Matryoshka()
+}
+
+// EmbedTexts embeds texts inside an "embedding" span
+func (e *TracedEmbeddings) EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, _, err := e.EmbedTextsWithUsage(ctx, texts)
+	return embeddings, err
+}
+
+// EmbedQuery embeds a search query inside an "embedding" span
+func (e *TracedEmbeddings) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	embedding, _, err := e.EmbedQueryWithUsage(ctx, text)
+	return embedding, err
+}
+
+// EmbedTextsWithUsage embeds texts inside an "embedding" span and returns the usage of the wrapped client
+func (e *TracedEmbeddings) EmbedTextsWithUsage(ctx context.Context, texts []string) ([][]float64, embedding.Usage, error) {
+	return e.trace(ctx, "document", texts, func(ctx context.Context) ([][]float64, embedding.Usage, error) {
+		if reporter, ok := e.client.(embedding.UsageReporter); ok {
+			return reporter.EmbedTextsWithUsage(ctx, texts)
+		}
+		embeddings, err := e.client.EmbedTexts(ctx, texts)
+		return embeddings, embedding.Usage{}, err
+	})
+}
+
+// EmbedQueryWithUsage embeds a search query inside an "embedding" span and returns the usage of the wrapped client
+func (e *TracedEmbeddings) EmbedQueryWithUsage(ctx context.Context, text string) ([]float64, embedding.Usage, error) {
+	embeddings, usage, err := e.trace(ctx, "query", []string{text}, func(ctx context.Context) ([][]float64, embedding.Usage, error) {
+		if reporter, ok := e.client.(embedding.UsageReporter); ok {
+			vector, usage, err := reporter.EmbedQueryWithUsage(ctx, text)
+			return [][]float64{vector}, usage, err
+		}
+		vector, err := e.client.EmbedQuery(ctx, text)
+		return [][]float64{vector}, embedding.Usage{}, err
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+	return embeddings[0], usage, nil
+}
+
+// trace runs embed inside an "embedding" span, kind is "document" or "query"
+func (e *TracedEmbeddings) trace(
+	ctx context.Context,
+	kind string,
+	texts []string,
+	embed func(ctx context.Context) ([][]float64, embedding.Usage, error),
+) ([][]float64, embedding.Usage, error) {
+	model := e.Model()
+	ctx, span := e.tracer.Start(ctx, "embedding", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	inputJSON, _ := json.Marshal(texts)
+	span.SetAttributes(callback.ContextAttributes(ctx)...)
+	span.SetAttributes(
+		attribute.String("langfuse.observation.type", "generation"),
+		attribute.String("langfuse.observation.model.name", model),
+		attribute.String("gen_ai.request.model", model),
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("embedding.kind", kind),
+		attribute.Int("embedding.inputs", len(texts)),
+		attribute.String("langfuse.observation.input", string(inputJSON)),
+	)
+
+	start := time.Now()
+	embeddings, usage, err := embed(ctx)
+
+	if e.metrics != nil {
+		e.metrics.recordEmbedding(model, time.Since(start), usage, err)
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, usage, err
+	}
+
+	usageJSON, _ := json.Marshal(map[string]int64{"input": usage.PromptTokens, "total": usage.TotalTokens})
+	span.SetAttributes(
+		attribute.String("langfuse.observation.usage_details", string(usageJSON)),
+		attribute.Int64("gen_ai.usage.input_tokens", usage.PromptTokens),
+	)
+	if len(embeddings) > 0 {
+		span.SetAttributes(attribute.Int("embedding.dimensions", len(embeddings[0])))
+	}
+	span.SetStatus(codes.Ok, "")
+	return embeddings, usage, nil
+}
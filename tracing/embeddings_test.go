@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakeEmbedder struct {
+	err error
+}
+
+func (f fakeEmbedder) Model() string { return "text-embedding-3-small" }
+
+func (f fakeEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, _, err := f.EmbedTextsWithUsage(ctx, texts)
+	return embeddings, err
+}
+
+func (f fakeEmbedder) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	embedding, _, err := f.EmbedQueryWithUsage(ctx, text)
+	return embedding, err
+}
+
+func (f fakeEmbedder) EmbedQueryWithUsage(ctx context.Context, text string) ([]float64, embedding.Usage, error) {
+	embeddings, usage, err := f.EmbedTextsWithUsage(ctx, []string{text})
+	if err != nil {
+		return nil, usage, err
+	}
+	return embeddings[0], usage, nil
+}
+
+func (f fakeEmbedder) EmbedTextsWithUsage(_ context.Context, texts []string) ([][]float64, embedding.Usage, error) {
+	if f.err != nil {
+		return nil, embedding.Usage{}, f.err
+	}
+	out := make([][]float64, len(texts))
+	for i := range texts {
+		out[i] = []float64{1, 0, 0}
+	}
+	return out, embedding.Usage{PromptTokens: 7, TotalTokens: 7}, nil
+}
+
+func TestTracedEmbeddingsRecordsUsage(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	traced := NewTracedEmbeddings(fakeEmbedder{}, provider.Tracer("test"))
+
+	ctx := WithAttributes(context.Background(), attribute.String("tenant.id", "acme"))
+	embeddings, err := traced.EmbedTexts(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	require.Equal(t, "text-embedding-3-small", traced.Model())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	require.Equal(t, "embedding", spans[0].Name)
+	require.Equal(t, "generation", attrs["langfuse.observation.type"].AsString())
+	require.Equal(t, "text-embedding-3-small", attrs["gen_ai.request.model"].AsString())
+	require.Equal(t, `{"input":7,"total":7}`, attrs["langfuse.observation.usage_details"].AsString())
+	require.Equal(t, int64(3), attrs["embedding.dimensions"].AsInt64())
+	require.Equal(t, "acme", attrs["tenant.id"].AsString())
+	require.Equal(t, "document", attrs["embedding.kind"].AsString())
+
+	_, err = traced.EmbedQuery(ctx, "a")
+	require.NoError(t, err)
+	require.Len(t, exporter.GetSpans(), 2)
+	for _, kv := range exporter.GetSpans()[1].Attributes {
+		if kv.Key == "embedding.kind" {
+			require.Equal(t, "query", kv.Value.AsString())
+		}
+	}
+}
+
+func TestTracedEmbeddingsRecordsErrors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	traced := NewTracedEmbeddings(fakeEmbedder{err: errors.New("rate limited")}, provider.Tracer("test"))
+
+	_, err := traced.EmbedTexts(context.Background(), []string{"a"})
+	require.Error(t, err)
+	require.Equal(t, "rate limited", exporter.GetSpans()[0].Status.Description)
+}
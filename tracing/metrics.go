@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/openai/openai-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsCallback records request count, latency and token usage of agent generations as OTEL metrics
+type MetricsCallback struct {
+	callback.BaseCallback
+
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	tokens   metric.Int64Counter
+
+	mu      sync.Mutex
+	pending map[string]pendingGeneration // run_id -> generation in flight
+}
+
+type pendingGeneration struct {
+	start time.Time
+	model string
+}
+
+// NewMetricsCallback creates the instruments on the given meter
+func NewMetricsCallback(meter metric.Meter) (*MetricsCallback, error) {
+	requests, err := meter.Int64Counter(
+		"goaikit.generation.requests",
+		metric.WithDescription("Number of LLM generation requests"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create requests counter: %w", err)
+	}
+
+	duration, err := meter.Float64Histogram(
+		"goaikit.generation.duration",
+		metric.WithDescription("Latency of LLM generation requests"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+
+	tokens, err := meter.Int64Counter(
+		"goaikit.generation.tokens",
+		metric.WithDescription("Tokens used by LLM generations"),
+		metric.WithUnit("{token}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tokens counter: %w", err)
+	}
+
+	return &MetricsCallback{
+		requests: requests,
+		duration: duration,
+		tokens:   tokens,
+		pending:  make(map[string]pendingGeneration),
+	}, nil
+}
+
+func (m *MetricsCallback) Name() string {
+	return "MetricsCallback"
+}
+
+func (m *MetricsCallback) OnGenerationStart(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	model, _ := ctx["model"].(string)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[runID] = pendingGeneration{start: time.Now(), model: model}
+}
+
+func (m *MetricsCallback) OnGenerationEnd(ctx map[string]interface{}) {
+	runID, _ := ctx["run_id"].(string)
+	generation, ok := m.take(runID)
+	if !ok {
+		return
+	}
+
+	status, _ := ctx["finish_reason"].(string)
+	m.record(generation, "ok", status)
+
+	usage, ok := ctx["usage"].(*openai.CompletionUsage)
+	if !ok || usage == nil {
+		return
+	}
+
+	model := attribute.String("gen_ai.request.model", generation.model)
+	m.tokens.Add(context.Background(), usage.PromptTokens,
+		metric.WithAttributes(model, attribute.String("gen_ai.token.type", "input")))
+	m.tokens.Add(context.Background(), usage.CompletionTokens,
+		metric.WithAttributes(model, attribute.String("gen_ai.token.type", "output")))
+}
+
+func (m *MetricsCallback) OnError(ctx map[string]interface{}) {
+	if stage, _ := ctx["stage"].(string); stage != "generation" {
+		return
+	}
+
+	runID, _ := ctx["run_id"].(string)
+	if generation, ok := m.take(runID); ok {
+		m.record(generation, "error", "")
+	}
+}
+
+// take removes and returns the in-flight generation of a run
+func (m *MetricsCallback) take(runID string) (pendingGeneration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	generation, ok := m.pending[runID]
+	delete(m.pending, runID)
+	return generation, ok
+}
+
+// record adds a finished request to the request counter and latency histogram
+func (m *MetricsCallback) record(generation pendingGeneration, status, finishReason string) {
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.request.model", generation.model),
+		attribute.String("status", status),
+	}
+	if finishReason != "" {
+		attrs = append(attrs, attribute.String("finish_reason", finishReason))
+	}
+
+	m.requests.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+	m.duration.Record(context.Background(), time.Since(generation.start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// recordEmbedding records an embedding request with gen_ai.operation.name=embeddings
+func (m *MetricsCallback) recordEmbedding(model string, duration time.Duration, usage embedding.Usage, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("gen_ai.request.model", model),
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("status", status),
+	)
+
+	m.requests.Add(context.Background(), 1, attrs)
+	m.duration.Record(context.Background(), duration.Seconds(), attrs)
+	if err == nil {
+		m.tokens.Add(context.Background(), usage.PromptTokens, metric.WithAttributes(
+			attribute.String("gen_ai.request.model", model),
+			attribute.String("gen_ai.operation.name", "embeddings"),
+			attribute.String("gen_ai.token.type", "input"),
+		))
+	}
+}
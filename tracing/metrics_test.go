@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectMetrics returns the data points of every instrument by name
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+// sumByAttributes returns the value of every data point of a counter keyed by its attributes
+func sumByAttributes(t *testing.T, data metricdata.Aggregation) map[attribute.Distinct]int64 {
+	sum, ok := data.(metricdata.Sum[int64])
+	require.True(t, ok, "got %T", data)
+	values := map[attribute.Distinct]int64{}
+	for _, point := range sum.DataPoints {
+		values[point.Attributes.Equivalent()] = point.Value
+	}
+	return values
+}
+
+func attrs(kvs ...attribute.KeyValue) attribute.Distinct {
+	set := attribute.NewSet(kvs...)
+	return set.Equivalent()
+}
+
+func TestMetricsCallbackRecordsGenerations(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	callback, err := NewMetricsCallback(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+	require.NoError(t, err)
+
+	callback.OnGenerationStart(map[string]interface{}{"run_id": "run-1", "model": "gpt-4o"})
+	callback.OnGenerationStart(map[string]interface{}{"run_id": "run-2", "model": "gpt-4o-mini"})
+	callback.OnGenerationEnd(map[string]interface{}{
+		"run_id":        "run-1",
+		"finish_reason": "stop",
+		"usage":         &openai.CompletionUsage{PromptTokens: 100, CompletionTokens: 20},
+	})
+	callback.OnError(map[string]interface{}{"run_id": "run-2", "stage": "tool"})
+	callback.OnError(map[string]interface{}{"run_id": "run-2", "stage": "generation"})
+	// generations that didn't start aren't counted
+	callback.OnGenerationEnd(map[string]interface{}{"run_id": "run-3", "finish_reason": "stop"})
+
+	model := attribute.String("gen_ai.request.model", "gpt-4o")
+	metrics := collectMetrics(t, reader)
+
+	require.Equal(t, map[attribute.Distinct]int64{
+		attrs(model, attribute.String("status", "ok"), attribute.String("finish_reason", "stop")):           1,
+		attrs(attribute.String("gen_ai.request.model", "gpt-4o-mini"), attribute.String("status", "error")): 1,
+	}, sumByAttributes(t, metrics["goaikit.generation.requests"]))
+
+	require.Equal(t, map[attribute.Distinct]int64{
+		attrs(model, attribute.String("gen_ai.token.type", "input")):  100,
+		attrs(model, attribute.String("gen_ai.token.type", "output")): 20,
+	}, sumByAttributes(t, metrics["goaikit.generation.tokens"]))
+
+	duration, ok := metrics["goaikit.generation.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 2)
+	for _, point := range duration.DataPoints {
+		require.Equal(t, uint64(1), point.Count)
+	}
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
@@ -34,13 +39,55 @@ type LangfuseConfig struct {
 
 	// ServiceVersion is the version of the service (optional)
 	ServiceVersion string
+
+	// EnableMetrics also exports request count, latency and token metrics over OTLP (optional)
+	EnableMetrics bool
+
+	// MetricsHost is the OTLP metrics endpoint (optional, defaults to Host)
+	MetricsHost string
+
+	// MetricsURLPath is the OTLP metrics URL path (optional, defaults to the exporter default)
+	MetricsURLPath string
+
+	// MetricsInterval is how often metrics are exported (optional, defaults to 60s)
+	MetricsInterval time.Duration
+
+	// APIBaseURL is the Langfuse REST API used for datasets and scores (optional, defaults to https://<Host>)
+	APIBaseURL string
+
+	// HTTPClient is used for REST API calls (optional, defaults to a client with a 30s timeout)
+	HTTPClient *http.Client
+
+	// BatchSize is the maximum number of spans per export request (optional, defaults to 512)
+	BatchSize int
+
+	// FlushInterval is the maximum delay before queued spans are exported (optional, defaults to 5s)
+	FlushInterval time.Duration
+
+	// MaxQueueSize is the number of spans buffered before new spans are dropped (optional, defaults to 2048)
+	MaxQueueSize int
+
+	// ExportTimeout bounds a single export request (optional, defaults to 30s)
+	ExportTimeout time.Duration
+
+	// BlockOnQueueFull makes ending a span wait for queue space instead of dropping it
+	BlockOnQueueFull bool
+
+	// ShutdownTimeout bounds Flush and Shutdown (optional, defaults to no timeout)
+	ShutdownTimeout time.Duration
+
+	// OnShutdown is called after the final synchronous flush in Shutdown with its error (optional)
+	OnShutdown func(stats ExportStats, err error)
 }
 
 // OTELLangfuseTracer wraps the OpenTelemetry tracer provider for Langfuse
 type OTELLangfuseTracer struct {
-	provider *sdktrace.TracerProvider
-	tracer   trace.Tracer
-	config   LangfuseConfig
+	provider      *sdktrace.TracerProvider
+	tracer        trace.Tracer
+	meterProvider *sdkmetric.MeterProvider
+	metrics       *MetricsCallback
+	counters      *exportCounters
+	config        LangfuseConfig
 }
 
 // NewOTELLangfuseTracer creates a new OTEL tracer configured for Langfuse
@@ -73,17 +120,19 @@ func NewOTELLangfuseTracer(config LangfuseConfig) (*OTELLangfuseTracer, error) {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf(
+			"Basic %s",
+			base64.RawURLEncoding.EncodeToString([]byte(
+				fmt.Sprintf("%s:%s", config.PublicKey, config.SecretKey),
+			)),
+		),
+	}
+
 	// Create OTLP HTTP exporter for Langfuse
 	opts := []otlptracehttp.Option{
 		otlptracehttp.WithEndpoint(config.Host),
-		otlptracehttp.WithHeaders(map[string]string{
-			"Authorization": fmt.Sprintf(
-				"Basic %s",
-				base64.RawURLEncoding.EncodeToString([]byte(
-					fmt.Sprintf("%s:%s", config.PublicKey, config.SecretKey),
-				)),
-			),
-		}),
+		otlptracehttp.WithHeaders(headers),
 	}
 	if config.URLPath != "" {
 		opts = append(opts, otlptracehttp.WithURLPath(config.URLPath))
@@ -95,9 +144,11 @@ func NewOTELLangfuseTracer(config LangfuseConfig) (*OTELLangfuseTracer, error) {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
 
-	// Create tracer provider
+	// Create tracer provider, the counting processor runs before the batcher to see every span
+	counters := &exportCounters{}
 	provider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(countingProcessor{counters: counters}),
+		sdktrace.WithBatcher(countingExporter{SpanExporter: exporter, counters: counters}, batchOptions(config)...),
 		sdktrace.WithResource(res),
 	)
 
@@ -107,11 +158,88 @@ func NewOTELLangfuseTracer(config LangfuseConfig) (*OTELLangfuseTracer, error) {
 	// Create tracer
 	tracer := provider.Tracer(serviceName, trace.WithInstrumentationVersion(serviceVersion))
 
-	return &OTELLangfuseTracer{
+	t := &OTELLangfuseTracer{
 		provider: provider,
 		tracer:   tracer,
+		counters: counters,
 		config:   config,
-	}, nil
+	}
+
+	if config.EnableMetrics {
+		if err := t.initMetrics(res, headers, serviceName, serviceVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// initMetrics creates the meter provider sharing the tracer's resource and credentials
+func (t *OTELLangfuseTracer) initMetrics(
+	res *resource.Resource,
+	headers map[string]string,
+	serviceName, serviceVersion string,
+) error {
+	host := t.config.MetricsHost
+	if host == "" {
+		host = t.config.Host
+	}
+
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(host),
+		otlpmetrichttp.WithHeaders(headers),
+	}
+	if t.config.MetricsURLPath != "" {
+		opts = append(opts, otlpmetrichttp.WithURLPath(t.config.MetricsURLPath))
+	}
+	exporter, err := otlpmetrichttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	interval := t.config.MetricsInterval
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+
+	t.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(t.meterProvider)
+
+	meter := t.meterProvider.Meter(serviceName, metric.WithInstrumentationVersion(serviceVersion))
+	if err := registerExportMetrics(meter, t.counters); err != nil {
+		return err
+	}
+	t.metrics, err = NewMetricsCallback(meter)
+	return err
+}
+
+// ExportStats returns the span export counters, Pending growing over time means spans are dropped
+func (t *OTELLangfuseTracer) ExportStats() ExportStats {
+	if t.counters == nil {
+		return ExportStats{}
+	}
+	return t.counters.stats()
+}
+
+// shutdownContext returns a context bounded by ShutdownTimeout
+func (t *OTELLangfuseTracer) shutdownContext() (context.Context, context.CancelFunc) {
+	if t.config.ShutdownTimeout > 0 {
+		return context.WithTimeout(context.Background(), t.config.ShutdownTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// MeterProvider returns the meter provider, nil when metrics are disabled
+func (t *OTELLangfuseTracer) MeterProvider() *sdkmetric.MeterProvider {
+	return t.meterProvider
+}
+
+// MetricsCallback returns the agent callback recording generation metrics, nil when metrics are disabled
+func (t *OTELLangfuseTracer) MetricsCallback() *MetricsCallback {
+	return t.metrics
 }
 
 // Tracer returns the underlying OpenTelemetry tracer
@@ -130,7 +258,14 @@ func (t *OTELLangfuseTracer) Flush() error {
 		return nil
 	}
 
-	ctx := context.Background()
+	ctx, cancel := t.shutdownContext()
+	defer cancel()
+
+	if t.meterProvider != nil {
+		if err := t.meterProvider.ForceFlush(ctx); err != nil {
+			return err
+		}
+	}
 	return t.provider.ForceFlush(ctx)
 }
 
@@ -141,14 +276,27 @@ func (t *OTELLangfuseTracer) FlushOrPanic() {
 	}
 }
 
-// Shutdown shuts down the tracer provider
+// Shutdown synchronously flushes queued spans and shuts down the tracer provider
 func (t *OTELLangfuseTracer) Shutdown() error {
 	if t.provider == nil {
 		return nil
 	}
 
-	ctx := context.Background()
-	return t.provider.Shutdown(ctx)
+	ctx, cancel := t.shutdownContext()
+	defer cancel()
+
+	// Spans go first so the final export metrics include them
+	err := t.provider.Shutdown(ctx)
+	if t.meterProvider != nil {
+		if mErr := t.meterProvider.Shutdown(ctx); err == nil {
+			err = mErr
+		}
+	}
+
+	if t.config.OnShutdown != nil {
+		t.config.OnShutdown(t.ExportStats(), err)
+	}
+	return err
 }
 
 // IsEnabled returns whether tracing is enabled
@@ -0,0 +1,228 @@
+package uistream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Protocol is the wire format a Stream writes
+type Protocol int
+
+const (
+	// ProtocolDataStream is the Vercel AI SDK data stream protocol, consumed by useChat
+	ProtocolDataStream Protocol = iota
+	// ProtocolAGUI is the AG-UI event protocol over server-sent events
+	ProtocolAGUI
+)
+
+// usage is the token usage reported in finish events
+type usage struct {
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+}
+
+// toolCall is a tool call requested by the model, args is the raw JSON
+type toolCall struct {
+	id   string
+	name string
+	args string
+}
+
+// encoder writes the events of a run in one protocol
+type encoder interface {
+	setHeaders(header http.Header)
+	runStarted(w io.Writer, threadID, runID string) error
+	generation(w io.Writer, messageID, text, reasoning string, toolCalls []toolCall) error
+	stepFinished(w io.Writer, finishReason string, step usage) error
+	toolResult(w io.Writer, messageID, toolCallID string, result any) error
+	runFinished(w io.Writer, threadID, runID, finishReason string, total usage, output any) error
+	runError(w io.Writer, message string) error
+}
+
+func newEncoder(protocol Protocol) encoder {
+	if protocol == ProtocolAGUI {
+		return aguiEncoder{}
+	}
+	return dataStreamEncoder{}
+}
+
+// dataStreamEncoder writes "<type>:<json>\n" parts of the Vercel AI SDK data stream protocol
+type dataStreamEncoder struct{}
+
+func (dataStreamEncoder) setHeaders(header http.Header) {
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("X-Vercel-AI-Data-Stream", "v1")
+}
+
+func (e dataStreamEncoder) part(w io.Writer, code string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream part: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s:%s\n", code, encoded)
+	return err
+}
+
+func (dataStreamEncoder) runStarted(io.Writer, string, string) error {
+	return nil
+}
+
+func (e dataStreamEncoder) generation(w io.Writer, messageID, text, reasoning string, toolCalls []toolCall) error {
+	if err := e.part(w, "f", map[string]any{"messageId": messageID}); err != nil {
+		return err
+	}
+	if reasoning != "" {
+		if err := e.part(w, "g", reasoning); err != nil {
+			return err
+		}
+	}
+	if text != "" {
+		if err := e.part(w, "0", text); err != nil {
+			return err
+		}
+	}
+	for _, call := range toolCalls {
+		err := e.part(w, "9", map[string]any{
+			"toolCallId": call.id,
+			"toolName":   call.name,
+			"args":       rawArgs(call.args),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e dataStreamEncoder) stepFinished(w io.Writer, finishReason string, step usage) error {
+	return e.part(w, "e", map[string]any{
+		"finishReason": finishReason,
+		"usage":        step,
+		"isContinued":  false,
+	})
+}
+
+func (e dataStreamEncoder) toolResult(w io.Writer, _ string, toolCallID string, result any) error {
+	return e.part(w, "a", map[string]any{"toolCallId": toolCallID, "result": result})
+}
+
+func (e dataStreamEncoder) runFinished(w io.Writer, _, _ string, finishReason string, total usage, _ any) error {
+	return e.part(w, "d", map[string]any{"finishReason": finishReason, "usage": total})
+}
+
+func (e dataStreamEncoder) runError(w io.Writer, message string) error {
+	return e.part(w, "3", message)
+}
+
+// aguiEncoder writes AG-UI events as server-sent events
+type aguiEncoder struct{}
+
+func (aguiEncoder) setHeaders(header http.Header) {
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+}
+
+func (aguiEncoder) event(w io.Writer, eventType string, fields map[string]any) error {
+	fields["type"] = eventType
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", encoded)
+	return err
+}
+
+func (e aguiEncoder) runStarted(w io.Writer, threadID, runID string) error {
+	return e.event(w, "RUN_STARTED", map[string]any{"threadId": threadID, "runId": runID})
+}
+
+func (e aguiEncoder) generation(w io.Writer, messageID, text, _ string, toolCalls []toolCall) error {
+	if text != "" {
+		events := []struct {
+			eventType string
+			fields    map[string]any
+		}{
+			{"TEXT_MESSAGE_START", map[string]any{"messageId": messageID, "role": "assistant"}},
+			{"TEXT_MESSAGE_CONTENT", map[string]any{"messageId": messageID, "delta": text}},
+			{"TEXT_MESSAGE_END", map[string]any{"messageId": messageID}},
+		}
+		for _, event := range events {
+			if err := e.event(w, event.eventType, event.fields); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, call := range toolCalls {
+		err := e.event(w, "TOOL_CALL_START", map[string]any{
+			"toolCallId":      call.id,
+			"toolCallName":    call.name,
+			"parentMessageId": messageID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := e.event(w, "TOOL_CALL_ARGS", map[string]any{"toolCallId": call.id, "delta": call.args}); err != nil {
+			return err
+		}
+		if err := e.event(w, "TOOL_CALL_END", map[string]any{"toolCallId": call.id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (aguiEncoder) stepFinished(io.Writer, string, usage) error {
+	return nil
+}
+
+func (e aguiEncoder) toolResult(w io.Writer, messageID, toolCallID string, result any) error {
+	content, ok := result.(string)
+	if !ok {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode tool result: %w", err)
+		}
+		content = string(encoded)
+	}
+	return e.event(w, "TOOL_CALL_RESULT", map[string]any{
+		"messageId":  messageID,
+		"toolCallId": toolCallID,
+		"content":    content,
+		"role":       "tool",
+	})
+}
+
+func (e aguiEncoder) runFinished(w io.Writer, threadID, runID, _ string, _ usage, output any) error {
+	return e.event(w, "RUN_FINISHED", map[string]any{"threadId": threadID, "runId": runID, "result": output})
+}
+
+func (e aguiEncoder) runError(w io.Writer, message string) error {
+	return e.event(w, "RUN_ERROR", map[string]any{"message": message})
+}
+
+// rawArgs keeps valid JSON arguments as an object and falls back to the raw string
+func rawArgs(args string) any {
+	if json.Valid([]byte(args)) {
+		return json.RawMessage(args)
+	}
+	return args
+}
+
+// finishReason maps OpenAI finish reasons to the ones of the AI SDK
+func finishReason(reason string) string {
+	switch reason {
+	case "stop", "length":
+		return reason
+	case "tool_calls", "function_call":
+		return "tool-calls"
+	case "content_filter":
+		return "content-filter"
+	case "":
+		return "unknown"
+	}
+	return "other"
+}
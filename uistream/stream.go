@@ -0,0 +1,268 @@
+package uistream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/openai/openai-go"
+)
+
+// Stream is an AgentCallback that writes a run's events to an HTTP response, for chat frontends built on the
+// Vercel AI SDK (useChat) or AG-UI clients
+// Every generation is written once it completes: its text, reasoning and tool calls, then every tool result
+// Events of nested runs (agents used as tools) are skipped, only their tool results are written
+type Stream struct {
+	callback.BaseCallback
+
+	mu        sync.Mutex
+	w         io.Writer
+	flusher   http.Flusher
+	encoder   encoder
+	threadID  string
+	runID     string // AG-UI run ID, defaults to the agent's run ID
+	rootRunID string
+	messageID string
+	usage     usage
+	finish    string
+	done      bool // the run finished or failed, nothing else is written
+	err       error
+}
+
+// NewStream creates a stream writing to w in the given protocol and sets the response headers
+func NewStream(w http.ResponseWriter, protocol Protocol) *Stream {
+	s := &Stream{
+		w:        w,
+		encoder:  newEncoder(protocol),
+		threadID: uuid.New().String(),
+	}
+	s.flusher, _ = w.(http.Flusher)
+	s.encoder.setHeaders(w.Header())
+	return s
+}
+
+// WithThread sets the AG-UI thread and run IDs, an empty runID keeps the agent's run ID
+func (s *Stream) WithThread(threadID, runID string) *Stream {
+	if threadID != "" {
+		s.threadID = threadID
+	}
+	s.runID = runID
+	return s
+}
+
+func (s *Stream) Name() string {
+	return "UIStream"
+}
+
+// Err returns the first error writing to the response, e.g. when the client disconnected
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) OnRunStart(ctx map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rootRunID != "" {
+		return
+	}
+	s.rootRunID, _ = ctx["run_id"].(string)
+	if s.runID == "" {
+		s.runID = s.rootRunID
+	}
+	s.write(func(w io.Writer) error {
+		return s.encoder.runStarted(w, s.threadID, s.runID)
+	})
+}
+
+func (s *Stream) OnGenerationEnd(ctx map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRoot(ctx) {
+		return
+	}
+
+	content, _ := ctx["content"].(string)
+	reasoning, _ := ctx["reasoning"].(string)
+	var calls []toolCall
+	if toolCalls, ok := ctx["tool_calls"].([]openai.ChatCompletionMessageToolCall); ok {
+		for _, call := range toolCalls {
+			calls = append(calls, toolCall{id: call.ID, name: call.Function.Name, args: call.Function.Arguments})
+		}
+	}
+
+	var step usage
+	if u, ok := ctx["usage"].(*openai.CompletionUsage); ok && u != nil {
+		step = usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+	}
+	s.usage.PromptTokens += step.PromptTokens
+	s.usage.CompletionTokens += step.CompletionTokens
+
+	reason, _ := ctx["finish_reason"].(string)
+	s.finish = finishReason(reason)
+	s.messageID = uuid.New().String()
+
+	s.write(func(w io.Writer) error {
+		if err := s.encoder.generation(w, s.messageID, content, reasoning, calls); err != nil {
+			return err
+		}
+		return s.encoder.stepFinished(w, s.finish, step)
+	})
+}
+
+func (s *Stream) OnToolCallEnd(ctx map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// tool events run under a nested run ID whose parent is the agent's run
+	if parent, _ := ctx["parent_run_id"].(string); parent != s.rootRunID {
+		return
+	}
+
+	toolCallID, _ := ctx["tool_call_id"].(string)
+	result := ctx["result"]
+	if errMessage, ok := ctx["error"].(string); ok {
+		result = map[string]any{"error": errMessage}
+	}
+	s.write(func(w io.Writer) error {
+		return s.encoder.toolResult(w, s.messageID, toolCallID, result)
+	})
+}
+
+func (s *Stream) OnRunEnd(ctx map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRoot(ctx) {
+		return
+	}
+	s.write(func(w io.Writer) error {
+		return s.encoder.runFinished(w, s.threadID, s.runID, s.finish, s.usage, ctx["output"])
+	})
+	s.done = true
+}
+
+// OnError writes run level errors, generation and tool errors are always followed by one
+func (s *Stream) OnError(ctx map[string]interface{}) {
+	if stage, _ := ctx["stage"].(string); stage != "run" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRoot(ctx) {
+		return
+	}
+	message, _ := ctx["error"].(string)
+	s.fail(message)
+}
+
+// Fail writes an error event unless the run already finished or failed
+func (s *Stream) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail(err.Error())
+}
+
+func (s *Stream) fail(message string) {
+	if s.done {
+		return
+	}
+	s.write(func(w io.Writer) error {
+		return s.encoder.runError(w, message)
+	})
+	s.done = true
+}
+
+// isRoot reports whether an event belongs to the streamed run and not to a nested one
+// Runs that fail before OnRunStart have no root yet, their errors are still streamed
+func (s *Stream) isRoot(ctx map[string]interface{}) bool {
+	runID, _ := ctx["run_id"].(string)
+	return s.rootRunID == "" || runID == s.rootRunID
+}
+
+func (s *Stream) write(encode func(w io.Writer) error) {
+	if s.err != nil || s.done {
+		return
+	}
+	if err := encode(s.w); err != nil {
+		s.err = err
+		slog.Warn("failed to write UI stream event", "error", err)
+		return
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// ChatMessage is a message sent by a chat frontend
+type ChatMessage struct {
+	ID      string `json:"id,omitempty"`
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the body posted by useChat ({id, messages}) or an AG-UI client ({threadId, runId, messages})
+type ChatRequest struct {
+	ID       string        `json:"id,omitempty"`
+	ThreadID string        `json:"threadId,omitempty"`
+	RunID    string        `json:"runId,omitempty"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+// OpenAIMessages converts the conversation for kit.InvokeConfig.Messages, unknown roles are sent as user messages
+func (r ChatRequest) OpenAIMessages() []openai.ChatCompletionMessageParamUnion {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(r.Messages))
+	for _, message := range r.Messages {
+		switch message.Role {
+		case "system":
+			messages = append(messages, openai.SystemMessage(message.Content))
+		case "assistant":
+			messages = append(messages, openai.AssistantMessage(message.Content))
+		default:
+			messages = append(messages, openai.UserMessage(message.Content))
+		}
+	}
+	return messages
+}
+
+// RunFunc runs an agent for a chat request, passing stream in its InvokeConfig.Callbacks
+type RunFunc func(ctx context.Context, request ChatRequest, stream *Stream) error
+
+// Handler serves a chat endpoint: it decodes the request, streams the run and writes an error event when run
+// returns an error the stream hasn't reported yet
+func Handler(protocol Protocol, run RunFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("invalid chat request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		threadID := request.ThreadID
+		if threadID == "" {
+			threadID = request.ID
+		}
+		stream := NewStream(w, protocol).WithThread(threadID, request.RunID)
+		w.WriteHeader(http.StatusOK)
+
+		if err := run(r.Context(), request, stream); err != nil {
+			stream.Fail(err)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package uistream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mhrlife/goai-kit/callback"
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+)
+
+// simulateRun replays the events of a run with one tool call through a callback manager
+func simulateRun(stream *Stream) {
+	manager := callback.NewManager([]callback.AgentCallback{stream}, nil)
+	manager.OnRunStart("gpt-4o", "What is the average of 2 and 4?", false)
+
+	manager.OnGenerationStart(1, nil, "gpt-4o")
+	manager.OnGenerationEnd("tool_calls", "", []openai.ChatCompletionMessageToolCall{{
+		ID:       "call_1",
+		Function: openai.ChatCompletionMessageToolCallFunction{Name: "average", Arguments: `{"numbers":[2,4]}`},
+	}}, &openai.CompletionUsage{PromptTokens: 10, CompletionTokens: 5})
+	manager.OnToolCallStart("average", nil, "call_1")
+	manager.OnToolCallEnd("average", nil, map[string]any{"average": 3}, "call_1", nil)
+
+	manager.OnGenerationStart(2, nil, "gpt-4o")
+	manager.OnGenerationEnd("stop", "The average is 3.", nil, &openai.CompletionUsage{PromptTokens: 20, CompletionTokens: 7})
+	manager.OnRunEnd("The average is 3.", 2)
+}
+
+func TestDataStreamProtocol(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := NewStream(recorder, ProtocolDataStream)
+	simulateRun(stream)
+	require.NoError(t, stream.Err())
+
+	require.Equal(t, "v1", recorder.Header().Get("X-Vercel-AI-Data-Stream"))
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	require.Len(t, lines, 8)
+	require.True(t, strings.HasPrefix(lines[0], `f:{"messageId":"`))
+	require.Equal(t, `9:{"args":{"numbers":[2,4]},"toolCallId":"call_1","toolName":"average"}`, lines[1])
+	require.Equal(t, `e:{"finishReason":"tool-calls","isContinued":false,"usage":{"promptTokens":10,"completionTokens":5}}`, lines[2])
+	require.Equal(t, `a:{"result":{"average":3},"toolCallId":"call_1"}`, lines[3])
+	require.True(t, strings.HasPrefix(lines[4], "f:"))
+	require.Equal(t, `0:"The average is 3."`, lines[5])
+	require.Equal(t, `e:{"finishReason":"stop","isContinued":false,"usage":{"promptTokens":20,"completionTokens":7}}`, lines[6])
+	require.Equal(t, `d:{"finishReason":"stop","usage":{"promptTokens":30,"completionTokens":12}}`, lines[7])
+}
+
+func TestAGUIProtocol(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := NewStream(recorder, ProtocolAGUI).WithThread("thread-1", "run-1")
+	simulateRun(stream)
+
+	require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	var types []string
+	for _, event := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		require.True(t, strings.HasPrefix(event, "data: {"))
+		start := strings.Index(event, `"type":"`) + len(`"type":"`)
+		types = append(types, event[start:start+strings.Index(event[start:], `"`)])
+	}
+	require.Equal(t, []string{
+		"RUN_STARTED",
+		"TOOL_CALL_START", "TOOL_CALL_ARGS", "TOOL_CALL_END",
+		"TOOL_CALL_RESULT",
+		"TEXT_MESSAGE_START", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_END",
+		"RUN_FINISHED",
+	}, types)
+	require.Contains(t, body, `"runId":"run-1","threadId":"thread-1"`)
+	require.Contains(t, body, `"content":"{\"average\":3}"`)
+	require.Contains(t, body, `"delta":"The average is 3."`)
+}
+
+func TestStreamSkipsNestedRuns(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := NewStream(recorder, ProtocolDataStream)
+
+	root := callback.NewManager([]callback.AgentCallback{stream}, nil)
+	root.OnRunStart("gpt-4o", "hi", false)
+	parent := "some-tool-run"
+	nested := callback.NewManager([]callback.AgentCallback{stream}, &parent)
+	nested.OnRunStart("gpt-4o-mini", "nested", false)
+	nested.OnGenerationEnd("stop", "nested answer", nil, nil)
+	nested.OnRunEnd("nested answer", 1)
+	root.OnGenerationEnd("stop", "root answer", nil, nil)
+	root.OnRunEnd("root answer", 1)
+
+	body := recorder.Body.String()
+	require.NotContains(t, body, "nested answer")
+	require.Contains(t, body, `0:"root answer"`)
+	require.Equal(t, 1, strings.Count(body, "d:"))
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(ProtocolDataStream, func(ctx context.Context, request ChatRequest, stream *Stream) error {
+		require.Len(t, request.OpenAIMessages(), 2)
+		manager := callback.NewManager([]callback.AgentCallback{stream}, nil)
+		manager.OnRunStart("gpt-4o", "hi", false)
+		err := errors.New("provider unavailable")
+		manager.OnError(err, "run")
+		return err
+	})
+
+	body := `{"id":"chat-1","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}]}`
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "3:\"provider unavailable\"\n", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader("{")))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
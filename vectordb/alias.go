@@ -0,0 +1,219 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// AliasedIndex is a stable handle on the index version an alias points at, for zero-downtime re-embeds:
+// Reindex builds the next version in the background while searches keep hitting the current one,
+// then moves the alias and the handle to the new version at once
+type AliasedIndex struct {
+	alias string
+
+	// mu is held for reading by writes and for writing by swaps, so no write lands on a version being swapped out
+	mu      sync.RWMutex
+	current *RedisVectorDB
+	next    *RedisVectorDB // version being built, receives writes too
+	running bool
+}
+
+var _ Client = (*AliasedIndex)(nil)
+
+// NewAliasedIndex puts db behind alias, CreateIndex creates its index and points the alias at it
+func NewAliasedIndex(alias string, db *RedisVectorDB) *AliasedIndex {
+	return &AliasedIndex{alias: alias, current: db}
+}
+
+// Alias returns the name of the alias, FT.SEARCH on it searches the current version
+func (a *AliasedIndex) Alias() string {
+	return a.alias
+}
+
+// Current returns the version the alias points at, for the operations AliasedIndex doesn't wrap
+func (a *AliasedIndex) Current() *RedisVectorDB {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.current
+}
+
+// CreateIndex creates the index of the current version and points the alias at it
+func (a *AliasedIndex) CreateIndex(ctx context.Context, config IndexConfig) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.current.CreateIndex(ctx, config); err != nil {
+		return err
+	}
+	if err := a.current.client.FTAliasUpdate(ctx, a.current.index, a.alias).Err(); err != nil {
+		return fmt.Errorf("failed to point alias %s to %s: %w", a.alias, a.current.index, err)
+	}
+	return nil
+}
+
+func (a *AliasedIndex) StoreDocument(ctx context.Context, doc Document) error {
+	return a.write(func(db *RedisVectorDB) error { return db.StoreDocument(ctx, doc) })
+}
+
+func (a *AliasedIndex) StoreDocumentsBatch(ctx context.Context, docs []Document) error {
+	return a.write(func(db *RedisVectorDB) error { return db.StoreDocumentsBatch(ctx, docs) })
+}
+
+func (a *AliasedIndex) UpdateDocument(ctx context.Context, doc Document) error {
+	return a.write(func(db *RedisVectorDB) error { return db.UpdateDocument(ctx, doc) })
+}
+
+func (a *AliasedIndex) DeleteDocument(ctx context.Context, id string) error {
+	return a.write(func(db *RedisVectorDB) error { return db.DeleteDocument(ctx, id) })
+}
+
+func (a *AliasedIndex) StoreParents(ctx context.Context, parents []Document) error {
+	return a.write(func(db *RedisVectorDB) error { return db.StoreParents(ctx, parents) })
+}
+
+func (a *AliasedIndex) DeleteParent(ctx context.Context, id string) error {
+	return a.write(func(db *RedisVectorDB) error { return db.DeleteParent(ctx, id) })
+}
+
+func (a *AliasedIndex) SearchDocuments(ctx context.Context, search DocumentSearch) ([]DocumentWithScore, error) {
+	return a.Current().SearchDocuments(ctx, search)
+}
+
+// write applies a write to the current version and, during a Reindex, to the version being built
+func (a *AliasedIndex) write(fn func(db *RedisVectorDB) error) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if err := fn(a.current); err != nil {
+		return err
+	}
+	if a.next != nil {
+		if err := fn(a.next); err != nil {
+			return fmt.Errorf("failed to write to new version %s: %w", a.next.index, err)
+		}
+	}
+	return nil
+}
+
+// Reindex builds the next version of the index with newConfig in the background, like MigrateIndex, and swaps
+// the alias to it once every document is copied. Writes during the build go to both versions, the copy never
+// overwrites them nor brings back a deleted document
+// TargetIndex defaults to the next version name, e.g. "products_v3" after "products_v2" and "products_v2" after
+// "products", and opts.Alias is ignored. The old version is dropped unless KeepSource is set, which allows a Swap back
+func (a *AliasedIndex) Reindex(ctx context.Context, newConfig IndexConfig, reembed bool, opts MigrationOptions) *Migration {
+	m := &Migration{done: make(chan struct{})}
+
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		m.err = fmt.Errorf("alias %s is already being reindexed", a.alias)
+		close(m.done)
+		return m
+	}
+	a.running = true
+	source := a.current
+	a.mu.Unlock()
+
+	if opts.TargetIndex == "" {
+		opts.TargetIndex = nextVersion(source.index)
+	}
+	keepSource := opts.KeepSource
+	opts.Alias, opts.KeepSource = "", true
+
+	go func() {
+		defer close(m.done)
+
+		target, err := a.reindex(ctx, source, newConfig, reembed, opts, keepSource, &m.copied)
+
+		m.mu.Lock()
+		m.target, m.err = target, err
+		m.mu.Unlock()
+	}()
+
+	return m
+}
+
+func (a *AliasedIndex) reindex(
+	ctx context.Context,
+	source *RedisVectorDB,
+	newConfig IndexConfig,
+	reembed bool,
+	opts MigrationOptions,
+	keepSource bool,
+	copied *atomic.Int64,
+) (*RedisVectorDB, error) {
+	defer func() {
+		a.mu.Lock()
+		a.next, a.running = nil, false
+		a.mu.Unlock()
+	}()
+
+	// an existing index may be a version kept for a rollback
+	if err := source.client.FTInfo(ctx, opts.TargetIndex).Err(); err == nil {
+		return nil, fmt.Errorf("index %s already exists", opts.TargetIndex)
+	}
+
+	target, err := source.migrate(ctx, newConfig, reembed, opts, copied, func(target *RedisVectorDB) {
+		a.mu.Lock()
+		a.next = target
+		a.mu.Unlock()
+	})
+	if err != nil {
+		if target := a.building(); target != nil {
+			_ = target.dropIndex(ctx)
+		}
+		return nil, err
+	}
+
+	if _, err := a.Swap(ctx, target); err != nil {
+		return nil, err
+	}
+	if !keepSource {
+		if err := source.dropIndex(ctx); err != nil {
+			return target, fmt.Errorf("swapped to %s but failed to drop the old version: %w", target.index, err)
+		}
+	}
+	return target, nil
+}
+
+// building returns the version being built
+func (a *AliasedIndex) building() *RedisVectorDB {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.next
+}
+
+// Swap atomically points the alias at db and makes it the current version, e.g. to roll back to a version
+// kept with KeepSource. It returns the previous version
+func (a *AliasedIndex) Swap(ctx context.Context, db *RedisVectorDB) (*RedisVectorDB, error) {
+	if db.indexConfig == nil {
+		return nil, fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := db.client.FTAliasUpdate(ctx, db.index, a.alias).Err(); err != nil {
+		return nil, fmt.Errorf("failed to point alias %s to %s: %w", a.alias, db.index, err)
+	}
+	previous := a.current
+	a.current = db
+	return previous, nil
+}
+
+var versionSuffix = regexp.MustCompile(`^(.*)_v(\d+)$`)
+
+// nextVersion returns the name of the index version after index
+func nextVersion(index string) string {
+	if m := versionSuffix.FindStringSubmatch(index); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err == nil {
+			return fmt.Sprintf("%s_v%d", m[1], n+1)
+		}
+	}
+	return index + "_v2"
+}
@@ -0,0 +1,47 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextVersion(t *testing.T) {
+	require.Equal(t, "products_v2", nextVersion("products"))
+	require.Equal(t, "products_v3", nextVersion("products_v2"))
+	require.Equal(t, "products_v10", nextVersion("products_v9"))
+	require.Equal(t, "products_vx_v2", nextVersion("products_vx"))
+}
+
+func TestReindexKeepsWritesMadeDuringTheCopy(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	alias := NewAliasedIndex("docs_alias", db)
+	ctx := context.Background()
+	require.NoError(t, alias.StoreDocumentsBatch(ctx, []Document{
+		{ID: "a", Content: "alpha"},
+		{ID: "b", Content: "beta"},
+		{ID: "c", Content: "gamma"},
+	}))
+
+	// after the scan loaded the documents, b is deleted and c updated through the alias
+	var watches int
+	fake.onCommand = func(args []string) {
+		if args[0] == "watch" {
+			if watches++; watches == 1 {
+				require.NoError(t, alias.DeleteDocument(ctx, "b"))
+				require.NoError(t, alias.UpdateDocument(ctx, Document{ID: "c", Content: "gamma 2"}))
+			}
+		}
+	}
+
+	target, err := alias.Reindex(ctx, *db.indexConfig, true, MigrationOptions{}).Wait()
+	require.NoError(t, err)
+	require.Equal(t, "docs_v2", target.index)
+	require.Same(t, target, alias.Current())
+
+	require.NotNil(t, fake.hash("docs_v2:a"))
+	require.Nil(t, fake.hash("docs_v2:b"), "deleted documents must not come back")
+	require.Equal(t, "gamma 2", fake.hash("docs_v2:c")["content"])
+	require.Nil(t, fake.hash("docs:a"), "the old version is dropped")
+}
@@ -0,0 +1,395 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+// DuplicateAction is what StoreDocumentsBatchWithOptions does with a near duplicate
+type DuplicateAction string
+
+const (
+	DuplicateSkip  DuplicateAction = "skip"  // Leave the document out (default)
+	DuplicateFlag  DuplicateAction = "flag"  // Store it with the ID of the document it duplicates in Meta["duplicate_of"]
+	DuplicateMerge DuplicateAction = "merge" // Leave it out and merge its metadata into the document it duplicates
+)
+
+// BatchOptions tunes StoreDocumentsBatchWithOptions, zero values use the defaults
+type BatchOptions struct {
+	// DuplicateThreshold is the cosine similarity from which a document counts as a near duplicate of a stored
+	// document or of an earlier one in the batch (optional, 0 disables the check)
+	DuplicateThreshold float64
+	// OnDuplicate is applied to every near duplicate (optional, defaults to DuplicateSkip)
+	OnDuplicate DuplicateAction
+
+	// BatchSize splits the documents into batches embedded and stored one after the other (optional, 0 stores all at once)
+	BatchSize int
+	// ContinueOnError records the documents of a failed batch in BatchReport.Failed and goes on with the next batch,
+	// by default the first error ends the call
+	ContinueOnError bool
+	// OnProgress is called after every batch, e.g. to drive a progress bar (optional)
+	OnProgress func(BatchProgress)
+
+	// Atomic makes the call all-or-nothing: when a batch fails, the documents the call wrote are deleted again and
+	// the ones it overwrote are restored. It can't be combined with ContinueOnError or DuplicateMerge
+	Atomic bool
+	// IdempotencyKey marks a completed call, so a retried ingestion job returns the first report without writing
+	// again (optional). Calls that failed, or left documents in BatchReport.Failed, can be retried with the same key,
+	// while a crashed call blocks it with ErrBatchInProgress until IdempotencyTTL
+	IdempotencyKey string
+	// IdempotencyTTL is how long the mark of a completed call is kept (optional, defaults to 24 hours)
+	IdempotencyTTL time.Duration
+}
+
+// BatchProgress is the state of StoreDocumentsBatchWithOptions after a batch
+type BatchProgress struct {
+	Total      int // Documents of the call
+	Processed  int // Documents of the finished batches, stored or not
+	Stored     int
+	Duplicates int
+	Failed     int
+	Tokens     int64 // Embedding tokens, when the embedding client reports usage
+	Err        error // Error of the batch that just finished, nil on success
+
+	Elapsed   time.Duration
+	Remaining time.Duration // Estimated from the pace so far
+}
+
+// Duplicate is a document found to be a near duplicate while storing a batch
+type Duplicate struct {
+	ID          string
+	DuplicateOf string
+	Similarity  float64
+}
+
+// BatchReport lists what StoreDocumentsBatchWithOptions did with the documents of a batch
+type BatchReport struct {
+	Stored     []string
+	Duplicates []Duplicate
+	Failed     []string // Documents of failed batches with ContinueOnError
+	Tokens     int64    // Embedding tokens, when the embedding client reports usage
+	RolledBack bool     // An Atomic call failed and undid its writes
+	Replayed   bool     // The report of an earlier call with the same IdempotencyKey, nothing was written
+}
+
+// StoreDocumentsBatchWithOptions stores documents like StoreDocumentsBatch, optionally checking each one against the
+// stored vectors and the rest of its batch so re-ingested content doesn't bloat the index
+// A document is never a duplicate of the stored document with its own ID
+// With BatchSize, long ingestion jobs report their progress after every batch
+func (r *RedisVectorDB) StoreDocumentsBatchWithOptions(ctx context.Context, docs []Document, opts BatchOptions) (BatchReport, error) {
+	report := BatchReport{}
+	if len(docs) == 0 {
+		return report, nil
+	}
+
+	if r.indexConfig == nil {
+		return report, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if opts.DuplicateThreshold < 0 || opts.DuplicateThreshold > 1 {
+		return report, fmt.Errorf("DuplicateThreshold must be between 0 and 1, got %v", opts.DuplicateThreshold)
+	}
+	if opts.OnDuplicate == "" {
+		opts.OnDuplicate = DuplicateSkip
+	}
+	if opts.Atomic && (opts.ContinueOnError || opts.OnDuplicate == DuplicateMerge) {
+		return report, fmt.Errorf("Atomic can't be combined with ContinueOnError or DuplicateMerge")
+	}
+	if opts.IdempotencyTTL <= 0 {
+		opts.IdempotencyTTL = 24 * time.Hour
+	}
+
+	if opts.IdempotencyKey != "" {
+		done, err := r.claimBatch(ctx, opts.IdempotencyKey, opts.IdempotencyTTL)
+		if err != nil {
+			return report, err
+		}
+		if done != nil {
+			return *done, nil
+		}
+	}
+
+	report, err := r.storeBatches(ctx, docs, opts)
+
+	if opts.IdempotencyKey != "" {
+		failed := err != nil || len(report.Failed) > 0
+		if markErr := r.completeBatch(ctx, opts.IdempotencyKey, opts.IdempotencyTTL, report, failed); markErr != nil && err == nil {
+			err = fmt.Errorf("failed to mark batch %s: %w", opts.IdempotencyKey, markErr)
+		}
+	}
+	return report, err
+}
+
+// storeBatches stores the documents batch by batch
+func (r *RedisVectorDB) storeBatches(ctx context.Context, docs []Document, opts BatchOptions) (BatchReport, error) {
+	report := BatchReport{}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(docs)
+	}
+	var journal *batchJournal
+	if opts.Atomic {
+		journal = newBatchJournal()
+	}
+
+	start := time.Now()
+	for from := 0; from < len(docs); from += batchSize {
+		batch := docs[from:min(from+batchSize, len(docs))]
+
+		err := r.storeBatch(ctx, batch, opts, journal, &report)
+		if err != nil && journal != nil {
+			if rollbackErr := journal.rollback(ctx, r); rollbackErr != nil {
+				return report, errors.Join(err, rollbackErr)
+			}
+			report.Stored, report.RolledBack = nil, true
+		}
+		if err != nil {
+			if !opts.ContinueOnError || ctx.Err() != nil {
+				return report, err
+			}
+			for _, doc := range batch {
+				report.Failed = append(report.Failed, doc.ID)
+			}
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(batchProgress(len(docs), from+len(batch), report, err, time.Since(start)))
+		}
+	}
+	return report, nil
+}
+
+// batchProgress summarizes the report after processed of total documents
+func batchProgress(total, processed int, report BatchReport, err error, elapsed time.Duration) BatchProgress {
+	return BatchProgress{
+		Total:      total,
+		Processed:  processed,
+		Stored:     len(report.Stored),
+		Duplicates: len(report.Duplicates),
+		Failed:     len(report.Failed),
+		Tokens:     report.Tokens,
+		Err:        err,
+		Elapsed:    elapsed,
+		Remaining:  elapsed / time.Duration(processed) * time.Duration(total-processed),
+	}
+}
+
+// storeBatch embeds and stores one batch, adding its outcome to the report
+// A journal records what the batch overwrites, for an atomic call
+func (r *RedisVectorDB) storeBatch(
+	ctx context.Context,
+	docs []Document,
+	opts BatchOptions,
+	journal *batchJournal,
+	report *BatchReport,
+) error {
+	if err := r.validateDocuments(docs...); err != nil {
+		return err
+	}
+
+	embeddings, usage, err := r.embedDocuments(ctx, docs)
+	report.Tokens += usage.TotalTokens
+	if err != nil {
+		return err
+	}
+
+	var duplicates []*Duplicate
+	if opts.DuplicateThreshold > 0 {
+		for i := range embeddings {
+			embeddings[i] = r.fitVector(embeddings[i])
+		}
+
+		duplicates, err = findDuplicates(docs, embeddings, opts.DuplicateThreshold, func(i int) (string, float64, error) {
+			return r.nearestStored(ctx, docs[i].ID, embeddings[i])
+		})
+		if err != nil {
+			return err
+		}
+
+		docs, embeddings, err = r.applyDuplicates(ctx, docs, embeddings, duplicates, opts.OnDuplicate)
+		if err != nil {
+			return err
+		}
+		for _, dup := range duplicates {
+			if dup != nil {
+				report.Duplicates = append(report.Duplicates, *dup)
+			}
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+	}
+
+	sparse, err := r.embedSparse(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	if journal != nil {
+		if err := journal.record(ctx, r, docs); err != nil {
+			return err
+		}
+	}
+	if err := r.storeVectors(ctx, docs, embeddings, sparse); err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		report.Stored = append(report.Stored, doc.ID)
+	}
+	return nil
+}
+
+// embedDocuments embeds the content of documents, prefixed with their ID
+func (r *RedisVectorDB) embedDocuments(ctx context.Context, docs []Document) ([][]float64, embedding.Usage, error) {
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = fmt.Sprintf("#%s\n%s", doc.ID, doc.Content)
+	}
+
+	var embeddings [][]float64
+	var usage embedding.Usage
+	var err error
+	if reporter, ok := r.embedClient.(embedding.UsageReporter); ok {
+		embeddings, usage, err = reporter.EmbedTextsWithUsage(ctx, contents)
+	} else {
+		embeddings, err = r.embedClient.EmbedTexts(ctx, contents)
+	}
+	if err != nil {
+		return nil, usage, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(embeddings) != len(docs) {
+		return nil, usage, fmt.Errorf("got %d embeddings for %d documents", len(embeddings), len(docs))
+	}
+	return embeddings, usage, nil
+}
+
+// findDuplicates returns for every document the one it duplicates, nil for original documents
+// Earlier documents of the batch are compared first, then nearest returns the closest stored document
+func findDuplicates(
+	docs []Document,
+	vecs [][]float64,
+	threshold float64,
+	nearest func(i int) (string, float64, error),
+) ([]*Duplicate, error) {
+	duplicates := make([]*Duplicate, len(docs))
+	for i, doc := range docs {
+		for j := 0; j < i; j++ {
+			if duplicates[j] != nil || docs[j].ID == doc.ID {
+				continue
+			}
+			if sim := cosineSimilarity(vecs[i], vecs[j]); sim >= threshold {
+				duplicates[i] = &Duplicate{ID: doc.ID, DuplicateOf: docs[j].ID, Similarity: sim}
+				break
+			}
+		}
+		if duplicates[i] != nil {
+			continue
+		}
+
+		id, sim, err := nearest(i)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" && sim >= threshold {
+			duplicates[i] = &Duplicate{ID: doc.ID, DuplicateOf: id, Similarity: sim}
+		}
+	}
+	return duplicates, nil
+}
+
+// applyDuplicates handles the near duplicates of a batch and returns the documents left to store
+func (r *RedisVectorDB) applyDuplicates(
+	ctx context.Context,
+	docs []Document,
+	vecs [][]float64,
+	duplicates []*Duplicate,
+	action DuplicateAction,
+) ([]Document, [][]float64, error) {
+	keptDocs := make([]Document, 0, len(docs))
+	keptVecs := make([][]float64, 0, len(docs))
+	batch := make(map[string]int, len(docs)) // ID -> index in keptDocs
+
+	for i, doc := range docs {
+		dup := duplicates[i]
+		switch {
+		case dup == nil:
+		case action == DuplicateFlag:
+			doc.Meta = maps.Clone(doc.Meta)
+			if doc.Meta == nil {
+				doc.Meta = map[string]any{}
+			}
+			doc.Meta["duplicate_of"] = dup.DuplicateOf
+		case action == DuplicateMerge:
+			meta := make(map[string]any, len(doc.Meta))
+			for k, v := range doc.Meta {
+				if v != nil {
+					meta[k] = v
+				}
+			}
+			if j, ok := batch[dup.DuplicateOf]; ok {
+				merged := maps.Clone(keptDocs[j].Meta)
+				if merged == nil {
+					merged = map[string]any{}
+				}
+				maps.Copy(merged, meta)
+				keptDocs[j].Meta = merged
+				continue
+			}
+			if len(meta) > 0 {
+				if err := r.PatchMetadata(ctx, dup.DuplicateOf, meta); err != nil {
+					return nil, nil, fmt.Errorf("failed to merge doc %s into %s: %w", doc.ID, dup.DuplicateOf, err)
+				}
+			}
+			continue
+		case action == DuplicateSkip:
+			continue
+		default:
+			return nil, nil, fmt.Errorf("unknown duplicate action %q", action)
+		}
+
+		batch[doc.ID] = len(keptDocs)
+		keptDocs = append(keptDocs, doc)
+		keptVecs = append(keptVecs, vecs[i])
+	}
+	return keptDocs, keptVecs, nil
+}
+
+// nearestStored returns the stored document most similar to vec other than the one with the given ID,
+// and its cosine similarity. The ID is empty when the index has no other documents
+func (r *RedisVectorDB) nearestStored(ctx context.Context, id string, vec []float64) (string, float64, error) {
+	encoded, _ := encodeVector(vec, r.indexConfig.VectorType)
+
+	result, err := r.client.FTSearchWithArgs(
+		ctx,
+		r.index,
+		"*=>[KNN 2 @embedding $vec AS score]",
+		&redis.FTSearchOptions{
+			DialectVersion: 2,
+			Params:         map[string]interface{}{"vec": encoded},
+			Return:         []redis.FTSearchReturn{{FieldName: "id"}, {FieldName: "embedding"}, {FieldName: "embedding_scale"}},
+		},
+	).Result()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to search duplicates of doc %s: %w", id, err)
+	}
+
+	for _, doc := range result.Docs {
+		if doc.Fields["id"] == id {
+			continue
+		}
+		stored, err := r.decodeStoredDocument(doc.ID, doc.Fields)
+		if err != nil {
+			return "", 0, err
+		}
+		if stored.Vector == nil {
+			continue
+		}
+		return stored.ID, cosineSimilarity(vec, stored.Vector), nil
+	}
+	return "", 0, nil
+}
@@ -0,0 +1,165 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	docs := []Document{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "a"}}
+	vecs := [][]float64{{1, 0}, {0.99, 0.1}, {0, 1}, {0.1, 1}, {1, 0}}
+
+	var searched []int
+	duplicates, err := findDuplicates(docs, vecs, 0.95, func(i int) (string, float64, error) {
+		searched = append(searched, i)
+		if docs[i].ID == "c" {
+			return "stored-c", 0.97, nil
+		}
+		return "stored", 0.5, nil
+	})
+	require.NoError(t, err)
+
+	require.Nil(t, duplicates[0])
+	require.Equal(t, "a", duplicates[1].DuplicateOf)
+	require.InDelta(t, 0.995, duplicates[1].Similarity, 0.001)
+	require.Equal(t, &Duplicate{ID: "c", DuplicateOf: "stored-c", Similarity: 0.97}, duplicates[2])
+	// duplicates aren't matched again, and a document never duplicates its own ID
+	require.Nil(t, duplicates[3])
+	require.Nil(t, duplicates[4])
+	require.Equal(t, []int{0, 2, 3, 4}, searched)
+}
+
+func TestApplyDuplicates(t *testing.T) {
+	docs := []Document{
+		{ID: "a", Meta: map[string]any{"source": "web"}},
+		{ID: "b", Meta: map[string]any{"source": "pdf", "lang": "en"}},
+		{ID: "c"},
+	}
+	vecs := [][]float64{{1, 0}, {1, 0}, {0, 1}}
+	duplicates := []*Duplicate{nil, {ID: "b", DuplicateOf: "a", Similarity: 1}, nil}
+	r := &RedisVectorDB{}
+
+	kept, keptVecs, err := r.applyDuplicates(context.Background(), docs, vecs, duplicates, DuplicateSkip)
+	require.NoError(t, err)
+	require.Equal(t, []Document{docs[0], docs[2]}, kept)
+	require.Equal(t, [][]float64{{1, 0}, {0, 1}}, keptVecs)
+
+	kept, _, err = r.applyDuplicates(context.Background(), docs, vecs, duplicates, DuplicateFlag)
+	require.NoError(t, err)
+	require.Len(t, kept, 3)
+	require.Equal(t, "a", kept[1].Meta["duplicate_of"])
+	require.NotContains(t, docs[1].Meta, "duplicate_of")
+
+	kept, _, err = r.applyDuplicates(context.Background(), docs, vecs, duplicates, DuplicateMerge)
+	require.NoError(t, err)
+	require.Len(t, kept, 2)
+	require.Equal(t, map[string]any{"source": "pdf", "lang": "en"}, kept[0].Meta)
+	require.Equal(t, map[string]any{"source": "web"}, docs[0].Meta)
+}
+
+func TestBatchProgress(t *testing.T) {
+	report := BatchReport{
+		Stored:     []string{"a", "b", "c"},
+		Duplicates: []Duplicate{{ID: "d", DuplicateOf: "a"}},
+		Failed:     []string{"e", "f"},
+		Tokens:     120,
+	}
+	failure := errors.New("embedding timeout")
+
+	progress := batchProgress(24, 6, report, failure, 3*time.Second)
+	require.Equal(t, BatchProgress{
+		Total:      24,
+		Processed:  6,
+		Stored:     3,
+		Duplicates: 1,
+		Failed:     2,
+		Tokens:     120,
+		Err:        failure,
+		Elapsed:    3 * time.Second,
+		Remaining:  9 * time.Second,
+	}, progress)
+}
+
+func TestAtomicBatchOptions(t *testing.T) {
+	r := &RedisVectorDB{index: "docs", indexConfig: &IndexConfig{Dimensions: 2}}
+	docs := []Document{{ID: "a", Content: "a"}}
+
+	_, err := r.StoreDocumentsBatchWithOptions(context.Background(), docs, BatchOptions{Atomic: true, ContinueOnError: true})
+	require.ErrorContains(t, err, "Atomic can't be combined")
+	_, err = r.StoreDocumentsBatchWithOptions(context.Background(), docs, BatchOptions{
+		Atomic:             true,
+		DuplicateThreshold: 0.9,
+		OnDuplicate:        DuplicateMerge,
+	})
+	require.ErrorContains(t, err, "Atomic can't be combined")
+
+	require.Equal(t, "vectordb:batch:docs:job-7", batchMarkKey("docs", "job-7"))
+}
+
+func TestAtomicBatchRollsBackFailedCall(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	require.NoError(t, db.StoreDocument(ctx, Document{ID: "a", Content: "old a", Meta: map[string]any{"tags": "v1"}}))
+	before := fake.hash("docs:a")
+
+	// the first batch overwrites a and adds b, the second one fails validation
+	report, err := db.StoreDocumentsBatchWithOptions(ctx, []Document{
+		{ID: "a", Content: "new a"},
+		{ID: "b", Content: "b"},
+		{ID: "c", Content: "c", Meta: map[string]any{"tags": 5}},
+	}, BatchOptions{Atomic: true, BatchSize: 2})
+	require.Error(t, err)
+	require.True(t, report.RolledBack)
+	require.Empty(t, report.Stored)
+
+	require.Equal(t, before, fake.hash("docs:a"), "the overwritten document is restored")
+	require.Nil(t, fake.hash("docs:b"), "the new document is deleted")
+	require.Nil(t, fake.hash("docs:c"))
+}
+
+func TestBatchIdempotencyKey(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	docs := []Document{{ID: "a", Content: "a"}, {ID: "b", Content: "b"}}
+	opts := BatchOptions{Atomic: true, IdempotencyKey: "job-7"}
+
+	first, err := db.StoreDocumentsBatchWithOptions(ctx, docs, opts)
+	require.NoError(t, err)
+	require.False(t, first.Replayed)
+	require.Equal(t, 2, embedder.embedded())
+
+	// a retried job gets the first report without writing again
+	fake.del("docs:a")
+	replay, err := db.StoreDocumentsBatchWithOptions(ctx, docs, opts)
+	require.NoError(t, err)
+	require.True(t, replay.Replayed)
+	require.Equal(t, first.Stored, replay.Stored)
+	require.Equal(t, 2, embedder.embedded())
+	require.Nil(t, fake.hash("docs:a"))
+
+	// a call that is still running blocks the key
+	require.NoError(t, db.client.Set(ctx, batchMarkKey("docs", "job-8"), batchPending, time.Minute).Err())
+	_, err = db.StoreDocumentsBatchWithOptions(ctx, docs, BatchOptions{IdempotencyKey: "job-8"})
+	require.ErrorIs(t, err, ErrBatchInProgress)
+}
+
+func TestBatchIdempotencyKeyReleasedOnFailure(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	opts := BatchOptions{Atomic: true, IdempotencyKey: "job-9"}
+
+	_, err := db.StoreDocumentsBatchWithOptions(ctx, []Document{{ID: "a", Content: "a", Meta: map[string]any{"tags": 5}}}, opts)
+	require.Error(t, err)
+	require.ErrorIs(t, db.client.Get(ctx, batchMarkKey("docs", "job-9")).Err(), redis.Nil, "the mark is dropped")
+
+	// so the fixed job can run again under the same key
+	report, err := db.StoreDocumentsBatchWithOptions(ctx, []Document{{ID: "a", Content: "a"}}, opts)
+	require.NoError(t, err)
+	require.False(t, report.Replayed)
+	require.Equal(t, "a", fake.hash("docs:a")["content"])
+}
@@ -0,0 +1,322 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// ClusterLabeler names a cluster from the documents closest to its centroid
+type ClusterLabeler func(ctx context.Context, examples []Document) (string, error)
+
+// ClusterOptions tunes ClusterDocuments, zero values use the defaults
+type ClusterOptions struct {
+	// K is the number of clusters (optional, defaults to the K between 2 and MaxK with the best silhouette score)
+	K int
+	// MaxK bounds the automatic choice of K, it never exceeds the square root of the document count (optional, defaults to 10)
+	MaxK int
+	// Iterations caps the k-means iterations (optional, defaults to 50)
+	Iterations int
+	// Seed makes the k-means++ initialisation reproducible (optional, defaults to 1)
+	Seed int64
+	// Labeler names every cluster, e.g. rag.TopicLabeler (optional)
+	Labeler ClusterLabeler
+	// Examples is the number of documents closest to the centroid given to the Labeler (optional, defaults to 5)
+	Examples int
+	// MetaField stores the cluster ID of every document in this metadata field and its label in MetaField+"_label"
+	// (optional, assignments are only returned when empty)
+	MetaField string
+}
+
+func (o ClusterOptions) withDefaults() ClusterOptions {
+	if o.MaxK <= 0 {
+		o.MaxK = 10
+	}
+	if o.Iterations <= 0 {
+		o.Iterations = 50
+	}
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	if o.Examples <= 0 {
+		o.Examples = 5
+	}
+	return o
+}
+
+// Cluster is a group of documents with similar vectors
+type Cluster struct {
+	ID          int
+	Label       string    // Empty without a Labeler
+	DocumentIDs []string  // Ordered from the closest to the centroid
+	Centroid    []float64 // Unit length
+	// Cohesion is the mean cosine similarity of the documents to the centroid,
+	// values close to 1 point at near duplicates
+	Cohesion float64
+}
+
+// ClusterDocuments groups all documents of the index with spherical k-means over their stored vectors,
+// for corpus exploration and finding near duplicates
+// Documents with a missing or invalid vector are left out. Clusters are ordered by size, largest first
+func (r *RedisVectorDB) ClusterDocuments(ctx context.Context, opts ClusterOptions) ([]Cluster, error) {
+	if r.indexConfig == nil {
+		return nil, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if opts.K < 0 {
+		return nil, fmt.Errorf("K must not be negative, got %d", opts.K)
+	}
+	opts = opts.withDefaults()
+
+	var docs []StoredDocument
+	err := r.scanDocuments(ctx, 0, func(batch []StoredDocument) error {
+		for _, doc := range batch {
+			if validVector(doc.Vector) && len(doc.Vector) == r.indexConfig.Dimensions {
+				docs = append(docs, doc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return []Cluster{}, nil
+	}
+
+	ids := make([]string, len(docs))
+	vecs := make([][]float64, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+		vecs[i] = normalize(doc.Vector)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	k := opts.K
+	if k == 0 {
+		k = chooseK(vecs, opts.MaxK, opts.Iterations, rng)
+	}
+	assign, centroids := kMeans(vecs, k, opts.Iterations, rng)
+
+	clusters := buildClusters(ids, vecs, assign, centroids)
+	for i := range clusters {
+		if opts.Labeler == nil {
+			break
+		}
+		members := clusters[i].DocumentIDs[:min(opts.Examples, len(clusters[i].DocumentIDs))]
+		examples := make([]Document, 0, len(members))
+		for _, id := range members {
+			idx := slices.IndexFunc(docs, func(doc StoredDocument) bool { return doc.ID == id })
+			examples = append(examples, docs[idx].Document)
+		}
+
+		label, err := opts.Labeler(ctx, examples)
+		if err != nil {
+			return nil, fmt.Errorf("failed to label cluster %d: %w", clusters[i].ID, err)
+		}
+		clusters[i].Label = label
+	}
+
+	if opts.MetaField != "" {
+		for _, cluster := range clusters {
+			var label any
+			if cluster.Label != "" {
+				label = cluster.Label
+			}
+			for _, id := range cluster.DocumentIDs {
+				err := r.PatchMetadata(ctx, id, map[string]any{opts.MetaField: cluster.ID, opts.MetaField + "_label": label})
+				if err != nil {
+					return nil, fmt.Errorf("failed to store cluster of doc %s: %w", id, err)
+				}
+			}
+		}
+	}
+
+	return clusters, nil
+}
+
+// buildClusters collects the members of every non-empty cluster, largest cluster first and renumbered from 0
+func buildClusters(ids []string, vecs [][]float64, assign []int, centroids [][]float64) []Cluster {
+	members := make([][]int, len(centroids))
+	for i, c := range assign {
+		members[c] = append(members[c], i)
+	}
+
+	clusters := make([]Cluster, 0, len(centroids))
+	for c, idx := range members {
+		if len(idx) == 0 {
+			continue
+		}
+
+		sims := make(map[int]float64, len(idx))
+		total := 0.0
+		for _, i := range idx {
+			sims[i] = dot(vecs[i], centroids[c])
+			total += sims[i]
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return sims[idx[a]] > sims[idx[b]] })
+
+		memberIDs := make([]string, len(idx))
+		for j, i := range idx {
+			memberIDs[j] = ids[i]
+		}
+		clusters = append(clusters, Cluster{
+			DocumentIDs: memberIDs,
+			Centroid:    centroids[c],
+			Cohesion:    total / float64(len(idx)),
+		})
+	}
+
+	sort.SliceStable(clusters, func(a, b int) bool { return len(clusters[a].DocumentIDs) > len(clusters[b].DocumentIDs) })
+	for i := range clusters {
+		clusters[i].ID = i
+	}
+	return clusters
+}
+
+// chooseK runs k-means for every K from 2 to maxK and returns the one with the best silhouette score
+func chooseK(vecs [][]float64, maxK, iterations int, rng *rand.Rand) int {
+	maxK = min(maxK, int(math.Sqrt(float64(len(vecs)))))
+	best, bestScore := 1, math.Inf(-1)
+	for k := 2; k <= maxK; k++ {
+		assign, _ := kMeans(vecs, k, iterations, rng)
+		if score := silhouette(vecs, assign, k, rng); score > bestScore {
+			best, bestScore = k, score
+		}
+	}
+	return best
+}
+
+// kMeans clusters unit vectors by cosine similarity, seeded with k-means++
+// It returns the cluster of every vector and the unit length centroids
+func kMeans(vecs [][]float64, k, iterations int, rng *rand.Rand) ([]int, [][]float64) {
+	k = max(min(k, len(vecs)), 1)
+
+	// k-means++ picks every next centroid with a probability growing with its distance to the chosen ones
+	centroids := [][]float64{vecs[rng.Intn(len(vecs))]}
+	distances := make([]float64, len(vecs))
+	for len(centroids) < k {
+		total := 0.0
+		for i, vec := range vecs {
+			nearest := math.Inf(1)
+			for _, c := range centroids {
+				nearest = min(nearest, max(1-dot(vec, c), 0))
+			}
+			distances[i] = nearest * nearest
+			total += distances[i]
+		}
+		if total == 0 {
+			// fewer distinct vectors than clusters
+			break
+		}
+
+		target, next := rng.Float64()*total, -1
+		for i, d := range distances {
+			if d == 0 {
+				continue
+			}
+			next = i
+			if target -= d; target <= 0 {
+				break
+			}
+		}
+		centroids = append(centroids, vecs[next])
+	}
+
+	assign := make([]int, len(vecs))
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+		for i, vec := range vecs {
+			best, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if sim := dot(vec, centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if iter == 0 || assign[i] != best {
+				assign[i], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, len(centroids))
+		for c := range sums {
+			sums[c] = make([]float64, len(vecs[0]))
+		}
+		for i, vec := range vecs {
+			for d, v := range vec {
+				sums[assign[i]][d] += v
+			}
+		}
+		for c, sum := range sums {
+			if validVector(sum) {
+				centroids[c] = normalize(sum)
+			}
+		}
+	}
+	return assign, centroids
+}
+
+// silhouetteSample bounds the vectors scored by silhouette, the score is quadratic in their number
+const silhouetteSample = 500
+
+// silhouette returns the mean silhouette coefficient of a clustering under cosine distance, between -1 and 1
+func silhouette(vecs [][]float64, assign []int, k int, rng *rand.Rand) float64 {
+	sample := rng.Perm(len(vecs))
+	if len(sample) > silhouetteSample {
+		sample = sample[:silhouetteSample]
+	}
+
+	total := 0.0
+	for _, i := range sample {
+		sums := make([]float64, k)
+		counts := make([]int, k)
+		for _, j := range sample {
+			if i == j {
+				continue
+			}
+			sums[assign[j]] += 1 - dot(vecs[i], vecs[j])
+			counts[assign[j]]++
+		}
+
+		own := assign[i]
+		if counts[own] == 0 {
+			// singletons score 0
+			continue
+		}
+		a, b := sums[own]/float64(counts[own]), math.Inf(1)
+		for c := range sums {
+			if c != own && counts[c] > 0 {
+				b = min(b, sums[c]/float64(counts[c]))
+			}
+		}
+		if math.IsInf(b, 1) || max(a, b) == 0 {
+			continue
+		}
+		total += (b - a) / max(a, b)
+	}
+	return total / float64(len(sample))
+}
+
+// normalize returns a unit length copy of vec
+func normalize(vec []float64) []float64 {
+	norm := math.Sqrt(dot(vec, vec))
+	out := make([]float64, len(vec))
+	for i, v := range vec {
+		out[i] = v / norm
+	}
+	return out
+}
+
+// dot returns the dot product of two vectors of the same length
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,62 @@
+package vectordb
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// topicVectors returns n noisy copies of every direction
+func topicVectors(rng *rand.Rand, n int, directions ...[]float64) [][]float64 {
+	var vecs [][]float64
+	for _, dir := range directions {
+		for i := 0; i < n; i++ {
+			vec := make([]float64, len(dir))
+			for d, v := range dir {
+				vec[d] = v + rng.Float64()*0.1
+			}
+			vecs = append(vecs, normalize(vec))
+		}
+	}
+	return vecs
+}
+
+func TestKMeans(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	vecs := topicVectors(rng, 6, []float64{1, 0, 0}, []float64{0, 1, 0}, []float64{0, 0, 1})
+
+	require.Equal(t, 3, chooseK(vecs, 10, 50, rng))
+
+	assign, centroids := kMeans(vecs, 3, 50, rng)
+	require.Len(t, centroids, 3)
+	for topic := 0; topic < 3; topic++ {
+		for i := 1; i < 6; i++ {
+			require.Equal(t, assign[topic*6], assign[topic*6+i])
+		}
+	}
+	require.NotEqual(t, assign[0], assign[6])
+	require.NotEqual(t, assign[6], assign[12])
+	require.NotEqual(t, assign[0], assign[12])
+
+	// more clusters than distinct vectors
+	same := [][]float64{{1, 0}, {1, 0}, {1, 0}}
+	assign, centroids = kMeans(same, 3, 50, rng)
+	require.Len(t, centroids, 1)
+	require.Equal(t, []int{0, 0, 0}, assign)
+}
+
+func TestBuildClusters(t *testing.T) {
+	vecs := [][]float64{normalize([]float64{1, 0.2}), {0, 1}, {1, 0}, normalize([]float64{0.1, 1})}
+	centroids := [][]float64{{1, 0}, {0, 1}, {0.6, 0.8}}
+
+	// the larger cluster comes first
+	clusters := buildClusters([]string{"a", "b", "c", "d"}, vecs, []int{1, 1, 1, 0}, centroids)
+	require.Len(t, clusters, 2)
+	require.Equal(t, 0, clusters[0].ID)
+	require.Equal(t, []string{"b", "a", "c"}, clusters[0].DocumentIDs)
+	require.Equal(t, []float64{0, 1}, clusters[0].Centroid)
+	require.InDelta(t, (1+vecs[0][1])/3, clusters[0].Cohesion, 1e-9)
+	require.Equal(t, 1, clusters[1].ID)
+	require.Equal(t, []string{"d"}, clusters[1].DocumentIDs)
+}
@@ -0,0 +1,44 @@
+package vectordb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mhrlife/goai-kit/embedding"
+)
+
+// ErrDimensionMismatch is returned by CreateIndex when the embedding model can't produce vectors of the index size
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// checkDimensions compares the index size with the native size of the embedding model
+// It returns the length vectors must be truncated to, 0 when they're stored as they are
+func checkDimensions(client embedding.Client, dimensions int) (int, error) {
+	dimensioned, ok := client.(embedding.Dimensioned)
+	if !ok || dimensioned.Dimensions() == 0 || dimensioned.Dimensions() == dimensions {
+		return 0, nil
+	}
+
+	native := dimensioned.Dimensions()
+	if native > dimensions && dimensioned.Matryoshka() {
+		return dimensions, nil
+	}
+
+	model := "the embedding model"
+	if named, ok := client.(embedding.Named); ok {
+		model = named.Model()
+	}
+	if native > dimensions {
+		return 0, fmt.Errorf("index has %d dimensions but %s produces %d and doesn't support truncation: %w",
+			dimensions, model, native, ErrDimensionMismatch)
+	}
+	return 0, fmt.Errorf("index has %d dimensions but %s only produces %d: %w",
+		dimensions, model, native, ErrDimensionMismatch)
+}
+
+// fitVector truncates and normalizes a Matryoshka embedding to the index size, other vectors are returned as they are
+func (r *RedisVectorDB) fitVector(vec []float64) []float64 {
+	if r.truncateTo > 0 && len(vec) > r.truncateTo {
+		return embedding.Truncate(vec, r.truncateTo)
+	}
+	return vec
+}
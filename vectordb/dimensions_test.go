@@ -0,0 +1,50 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sizedEmbedder struct {
+	dimensions int
+	matryoshka bool
+}
+
+func (s sizedEmbedder) EmbedTexts(context.Context, []string) ([][]float64, error) { return nil, nil }
+func (s sizedEmbedder) EmbedQuery(context.Context, string) ([]float64, error)     { return nil, nil }
+func (s sizedEmbedder) Model() string                                             { return "test-embedder" }
+func (s sizedEmbedder) Dimensions() int                                           { return s.dimensions }
+func (s sizedEmbedder) Matryoshka() bool                                          { return s.matryoshka }
+
+func TestCheckDimensions(t *testing.T) {
+	truncateTo, err := checkDimensions(sizedEmbedder{dimensions: 1536}, 1536)
+	require.NoError(t, err)
+	require.Zero(t, truncateTo)
+
+	truncateTo, err = checkDimensions(sizedEmbedder{dimensions: 3072, matryoshka: true}, 256)
+	require.NoError(t, err)
+	require.Equal(t, 256, truncateTo)
+
+	_, err = checkDimensions(sizedEmbedder{dimensions: 1536}, 256)
+	require.True(t, errors.Is(err, ErrDimensionMismatch))
+	require.Contains(t, err.Error(), "test-embedder produces 1536 and doesn't support truncation")
+
+	_, err = checkDimensions(sizedEmbedder{dimensions: 768, matryoshka: true}, 1024)
+	require.True(t, errors.Is(err, ErrDimensionMismatch))
+
+	// Clients that don't know their size are checked when vectors are stored
+	truncateTo, err = checkDimensions(sizedEmbedder{}, 1024)
+	require.NoError(t, err)
+	require.Zero(t, truncateTo)
+}
+
+func TestFitVectorTruncatesAndNormalizes(t *testing.T) {
+	r := &RedisVectorDB{truncateTo: 2}
+	require.InDeltaSlice(t, []float64{0.6, 0.8}, r.fitVector([]float64{3, 4, 12}), 1e-9)
+
+	r = &RedisVectorDB{}
+	require.Equal(t, []float64{3, 4, 12}, r.fitVector([]float64{3, 4, 12}))
+}
@@ -0,0 +1,151 @@
+package vectordb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// VectorType is the element type used to store vectors
+type VectorType string
+
+const (
+	VectorTypeFloat32 VectorType = "FLOAT32" // 4 bytes per dimension
+	VectorTypeFloat16 VectorType = "FLOAT16" // 2 bytes per dimension, small precision loss
+	VectorTypeInt8    VectorType = "INT8"    // 1 byte per dimension, scalar quantized, COSINE only
+)
+
+// validVectorType reports whether the vector type is supported
+func validVectorType(vt VectorType) bool {
+	switch vt {
+	case VectorTypeFloat32, VectorTypeFloat16, VectorTypeInt8:
+		return true
+	}
+	return false
+}
+
+// encodeVector encodes a vector for storage
+// For INT8 the returned scale maps quantized values back: v = q * scale
+func encodeVector(vec []float64, vt VectorType) ([]byte, float64) {
+	switch vt {
+	case VectorTypeFloat16:
+		buf := make([]byte, len(vec)*2)
+		for i, v := range vec {
+			binary.NativeEndian.PutUint16(buf[i*2:], float32ToFloat16(float32(v)))
+		}
+		return buf, 0
+	case VectorTypeInt8:
+		maxAbs := 0.0
+		for _, v := range vec {
+			maxAbs = math.Max(maxAbs, math.Abs(v))
+		}
+		scale := maxAbs / 127
+		buf := make([]byte, len(vec))
+		if scale == 0 {
+			return buf, 0
+		}
+		for i, v := range vec {
+			buf[i] = byte(int8(math.Round(v / scale)))
+		}
+		return buf, scale
+	default:
+		fs := make([]float32, len(vec))
+		for i, v := range vec {
+			fs[i] = float32(v)
+		}
+		return encodeFloat32Vector(fs), 0
+	}
+}
+
+// decodeVector reverses encodeVector
+func decodeVector(buf []byte, vt VectorType, scale float64) ([]float64, error) {
+	switch vt {
+	case VectorTypeFloat16:
+		if len(buf)%2 != 0 {
+			return nil, fmt.Errorf("invalid FLOAT16 vector length %d", len(buf))
+		}
+		vec := make([]float64, len(buf)/2)
+		for i := range vec {
+			vec[i] = float64(float16ToFloat32(binary.NativeEndian.Uint16(buf[i*2:])))
+		}
+		return vec, nil
+	case VectorTypeInt8:
+		vec := make([]float64, len(buf))
+		for i, b := range buf {
+			vec[i] = float64(int8(b)) * scale
+		}
+		return vec, nil
+	default:
+		if len(buf)%4 != 0 {
+			return nil, fmt.Errorf("invalid FLOAT32 vector length %d", len(buf))
+		}
+		vec := make([]float64, len(buf)/4)
+		for i := range vec {
+			vec[i] = float64(math.Float32frombits(binary.NativeEndian.Uint32(buf[i*4:])))
+		}
+		return vec, nil
+	}
+}
+
+// float32ToFloat16 converts to IEEE 754 half precision with round-to-nearest-even
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff: // Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp-127 > 15: // overflow
+		return sign | 0x7c00
+	case exp-127 < -24: // underflow to zero
+		return sign
+	case exp-127 < -14: // subnormal
+		mant |= 0x800000
+		shift := uint32(-exp + 127 - 14 + 13)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+
+	half := uint32(exp-127+15)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++ // may carry into the exponent, which is still correct
+	}
+	return sign | uint16(half)
+}
+
+// float16ToFloat32 converts IEEE 754 half precision to float32
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// subnormal: normalize
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3ff
+		return math.Float32frombits(sign | e<<23 | mant<<13)
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
@@ -0,0 +1,56 @@
+package vectordb
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeVectorRoundTrip(t *testing.T) {
+	vec := []float64{0.5, -0.25, 0.125, 1, -1, 0, 0.3333, 6.1e-5}
+
+	tests := []struct {
+		vectorType VectorType
+		size       int
+		tolerance  float64
+	}{
+		{VectorTypeFloat32, 4, 1e-7},
+		{VectorTypeFloat16, 2, 1e-3},
+		{VectorTypeInt8, 1, 1.0 / 127},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.vectorType), func(t *testing.T) {
+			encoded, scale := encodeVector(vec, tt.vectorType)
+			require.Len(t, encoded, len(vec)*tt.size)
+
+			decoded, err := decodeVector(encoded, tt.vectorType, scale)
+			require.NoError(t, err)
+			require.Len(t, decoded, len(vec))
+			for i := range vec {
+				require.InDelta(t, vec[i], decoded[i], tt.tolerance, "dimension %d", i)
+			}
+		})
+	}
+}
+
+func TestFloat16SpecialValues(t *testing.T) {
+	require.Equal(t, uint16(0x7c00), float32ToFloat16(float32(math.Inf(1))))
+	require.Equal(t, uint16(0xfc00), float32ToFloat16(float32(math.Inf(-1))))
+	require.Equal(t, uint16(0x7c00), float32ToFloat16(1e6))
+	require.Equal(t, uint16(0x3c00), float32ToFloat16(1))
+	require.True(t, math.IsNaN(float64(float16ToFloat32(float32ToFloat16(float32(math.NaN()))))))
+	require.Equal(t, float32(5.9604645e-08), float16ToFloat32(0x0001))
+}
+
+func TestCreateIndexRejectsInt8WithoutCosine(t *testing.T) {
+	// fails before talking to Redis
+	db := NewRedisVectorDB("docs", sizedEmbedder{}, nil)
+
+	for _, metric := range []string{"L2", "IP"} {
+		err := db.CreateIndex(context.Background(), IndexConfig{Dimensions: 8, VectorType: VectorTypeInt8, DistanceMetric: metric})
+		require.ErrorContains(t, err, "INT8 requires the COSINE distance metric")
+	}
+}
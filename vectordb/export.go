@@ -0,0 +1,138 @@
+package vectordb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ExportRecord is one line of the JSONL export format
+type ExportRecord struct {
+	ID       string         `json:"id"`
+	ParentID string         `json:"parent_id,omitempty"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Vector   []float64      `json:"vector,omitempty"`
+	// Parent marks a parent document stored with StoreParents, it has no vector
+	Parent bool `json:"parent,omitempty"`
+}
+
+// Export writes every document of the index with its metadata and vector as JSONL, followed by its parents
+func (r *RedisVectorDB) Export(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := r.scanDocuments(ctx, 0, func(batch []StoredDocument) error {
+		for _, doc := range batch {
+			record := ExportRecord{
+				ID:       doc.ID,
+				ParentID: doc.ParentID,
+				Content:  doc.Content,
+				Metadata: doc.Meta,
+				Vector:   doc.Vector,
+			}
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("failed to write document %s: %w", doc.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.scanParents(ctx, 0, func(keys []string) error {
+		parents, err := r.loadParents(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for _, parent := range parents {
+			record := ExportRecord{ID: parent.ID, Content: parent.Content, Metadata: parent.Meta, Parent: true}
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("failed to write parent %s: %w", parent.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// Import reads a JSONL export and stores its documents
+// Records with a vector are stored as is, records without one are embedded and parent records are stored with
+// StoreParents
+func (r *RedisVectorDB) Import(ctx context.Context, rd io.Reader) error {
+	if r.indexConfig == nil {
+		return fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	const batchSize = 500
+	withVectors := make([]Document, 0, batchSize)
+	vectors := make([][]float64, 0, batchSize)
+	withoutVectors := make([]Document, 0, batchSize)
+	parents := make([]Document, 0, batchSize)
+
+	flush := func() error {
+		if len(withVectors) > 0 {
+			if err := r.validateDocuments(withVectors...); err != nil {
+				return err
+			}
+			if err := r.storeVectors(ctx, withVectors, vectors, nil); err != nil {
+				return err
+			}
+		}
+		if err := r.StoreDocumentsBatch(ctx, withoutVectors); err != nil {
+			return err
+		}
+		if len(parents) > 0 {
+			if err := r.StoreParents(ctx, parents); err != nil {
+				return err
+			}
+		}
+
+		withVectors, vectors, withoutVectors, parents = withVectors[:0], vectors[:0], withoutVectors[:0], parents[:0]
+		return nil
+	}
+
+	dec := json.NewDecoder(rd)
+	for line := 1; ; line++ {
+		var record ExportRecord
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read record %d: %w", line, err)
+		}
+		if record.ID == "" {
+			return fmt.Errorf("record %d has no id", line)
+		}
+
+		doc := Document{ID: record.ID, ParentID: record.ParentID, Content: record.Content, Meta: record.Metadata}
+		if record.Parent {
+			parents = append(parents, doc)
+		} else if len(record.Vector) > 0 {
+			withVectors = append(withVectors, doc)
+			vectors = append(vectors, record.Vector)
+		} else {
+			withoutVectors = append(withoutVectors, doc)
+		}
+
+		if len(withVectors)+len(withoutVectors)+len(parents) >= batchSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf("failed to import documents: %w", err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("failed to import documents: %w", err)
+	}
+
+	return nil
+}
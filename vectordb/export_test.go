@@ -0,0 +1,76 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source, _, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+
+	docs := []Document{
+		{ID: "guide", Content: "the whole guide"},
+		{ID: "guide#0", ParentID: "guide", Content: "first chunk", Meta: map[string]any{"tags": []string{"x", "y"}, "page": 3}},
+		{ID: "note", Content: "a note", Meta: map[string]any{"tags": "z"}},
+	}
+	vectors := [][]float64{{1, 0, 0}, {0, 0.5, 0.75}, {-0.25, 0, 1}}
+	require.NoError(t, source.storeVectors(ctx, docs, vectors, nil))
+	require.NoError(t, source.StoreParents(ctx, []Document{
+		{ID: "manual", Content: "the whole manual", Meta: map[string]any{"lang": "en"}},
+	}))
+
+	var exported bytes.Buffer
+	require.NoError(t, source.Export(ctx, &exported))
+
+	records := make(map[string]ExportRecord)
+	dec := json.NewDecoder(bytes.NewReader(exported.Bytes()))
+	for dec.More() {
+		var record ExportRecord
+		require.NoError(t, dec.Decode(&record))
+		records[record.ID] = record
+	}
+	require.Len(t, records, 4)
+	require.Equal(t, ExportRecord{
+		ID:       "manual",
+		Content:  "the whole manual",
+		Metadata: map[string]any{"lang": "en"},
+		Parent:   true,
+	}, records["manual"])
+	require.Equal(t, ExportRecord{
+		ID:       "guide#0",
+		ParentID: "guide",
+		Content:  "first chunk",
+		Metadata: map[string]any{"tags": []any{"x", "y"}, "page": float64(3)},
+		Vector:   []float64{0, 0.5, 0.75},
+	}, records["guide#0"])
+
+	// a fresh index gets the same documents and vectors without embedding them again
+	imported, fake, embedder := newFakeVectorDB(t, "imported")
+	require.NoError(t, imported.Import(ctx, bytes.NewReader(exported.Bytes())))
+	require.Zero(t, embedder.embedded())
+	require.Equal(t, "x,y", fake.hash("imported:guide#0")["meta_tags"])
+	require.Equal(t, "the whole manual", fake.hash("imported_parents:manual")["content"])
+	require.Nil(t, fake.hash("imported:manual"), "parents aren't indexed")
+
+	var reexported bytes.Buffer
+	require.NoError(t, imported.Export(ctx, &reexported))
+	require.Equal(t, exported.String(), reexported.String())
+}
+
+func TestImportEmbedsRecordsWithoutVector(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+
+	err := db.Import(context.Background(), strings.NewReader(`{"id":"a","content":"alpha"}`+"\n"))
+	require.NoError(t, err)
+	require.Equal(t, 1, embedder.embedded())
+	require.Equal(t, "alpha", fake.hash("docs:a")["content"])
+
+	err = db.Import(context.Background(), strings.NewReader(`{"content":"no id"}`))
+	require.ErrorContains(t, err, "record 1 has no id")
+}
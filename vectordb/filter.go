@@ -0,0 +1,282 @@
+package vectordb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// buildFilterQuery constructs a Redis Search filter query from filters
+// Every filter is checked against the field type declared in the index config,
+// numeric and geo values are passed as query parameters and tag/text values are escaped
+func (r *RedisVectorDB) buildFilterQuery(filters []Filter) (string, map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if len(filters) == 0 {
+		return "*", params, nil
+	}
+
+	fieldTypes := make(map[string]FilterFieldType, len(r.indexConfig.FilterableFields))
+	for _, f := range r.indexConfig.FilterableFields {
+		fieldTypes[f.Name] = f.Type
+	}
+
+	// param stores a numeric value and returns its placeholder
+	param := func(v float64) string {
+		name := fmt.Sprintf("f%d", len(params))
+		params[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		return "$" + name
+	}
+
+	parts := make([]string, 0, len(filters))
+	for _, f := range filters {
+		fieldType, ok := fieldTypes[f.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("field %q is not a filterable field of the index", f.Field)
+		}
+
+		part, err := buildFilterPart("meta_"+f.Field, fieldType, f, param)
+		if err != nil {
+			return "", nil, fmt.Errorf("filter on %q: %w", f.Field, err)
+		}
+		parts = append(parts, part)
+	}
+
+	// Combine with AND (space separated in Redis Search)
+	return "(" + strings.Join(parts, " ") + ")", params, nil
+}
+
+// buildFilterPart builds the query clause of a single filter
+func buildFilterPart(
+	fieldName string,
+	fieldType FilterFieldType,
+	f Filter,
+	param func(float64) string,
+) (string, error) {
+	requireType := func(types ...FilterFieldType) error {
+		for _, t := range types {
+			if fieldType == t {
+				return nil
+			}
+		}
+		return fmt.Errorf("operator %s is not supported on %s fields", f.Operator, fieldType)
+	}
+
+	switch f.Operator {
+	case FilterOpEq:
+		if err := requireType(FilterFieldTypeTag, FilterFieldTypeText); err != nil {
+			return "", err
+		}
+		v, ok := f.Value.(string)
+		if !ok || v == "" {
+			return "", fmt.Errorf("expected non-empty string value, got %T", f.Value)
+		}
+		if fieldType == FilterFieldTypeText {
+			// Text exact phrase: @field:"word1 word2"
+			words := strings.Fields(v)
+			if len(words) == 0 {
+				return "", fmt.Errorf("text value must contain at least one word")
+			}
+			for i, w := range words {
+				words[i] = escapeQueryValue(w)
+			}
+			return fmt.Sprintf(`@%s:"%s"`, fieldName, strings.Join(words, " ")), nil
+		}
+		// Tag exact match: @field:{value}
+		return fmt.Sprintf("@%s:{%s}", fieldName, escapeQueryValue(v)), nil
+
+	case FilterOpIn:
+		if err := requireType(FilterFieldTypeTag); err != nil {
+			return "", err
+		}
+		// Tag in list: @field:{val1|val2|val3}
+		vals, err := stringList(f.Value)
+		if err != nil {
+			return "", err
+		}
+		escaped := make([]string, len(vals))
+		for i, v := range vals {
+			escaped[i] = escapeQueryValue(v)
+		}
+		return fmt.Sprintf("@%s:{%s}", fieldName, strings.Join(escaped, "|")), nil
+
+	case FilterOpContains:
+		if err := requireType(FilterFieldTypeText); err != nil {
+			return "", err
+		}
+		// Text contains all words: @field:(word1 word2)
+		v, ok := f.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected string value, got %T", f.Value)
+		}
+		words := strings.Fields(v)
+		if len(words) == 0 {
+			return "", fmt.Errorf("text value must contain at least one word")
+		}
+		for i, w := range words {
+			words[i] = escapeQueryValue(w)
+		}
+		return fmt.Sprintf("@%s:(%s)", fieldName, strings.Join(words, " ")), nil
+
+	case FilterOpRange:
+		if err := requireType(FilterFieldTypeNumeric); err != nil {
+			return "", err
+		}
+		// Numeric range: @field:[min max]
+		rng, ok := f.Value.(NumericRange)
+		if !ok {
+			return "", fmt.Errorf("expected NumericRange value, got %T", f.Value)
+		}
+		if !(rng.Min <= rng.Max) {
+			return "", fmt.Errorf("range min %v is greater than max %v", rng.Min, rng.Max)
+		}
+		return fmt.Sprintf("@%s:[%s %s]", fieldName, param(rng.Min), param(rng.Max)), nil
+
+	case FilterOpGte, FilterOpLte:
+		if err := requireType(FilterFieldTypeNumeric); err != nil {
+			return "", err
+		}
+		v, ok := toFloat(f.Value)
+		if !ok {
+			return "", fmt.Errorf("expected numeric value, got %T", f.Value)
+		}
+		if f.Operator == FilterOpGte {
+			// Numeric >=: @field:[value +inf]
+			return fmt.Sprintf("@%s:[%s +inf]", fieldName, param(v)), nil
+		}
+		// Numeric <=: @field:[-inf value]
+		return fmt.Sprintf("@%s:[-inf %s]", fieldName, param(v)), nil
+
+	case FilterOpGeoRadius:
+		if err := requireType(FilterFieldTypeGeo); err != nil {
+			return "", err
+		}
+		// Geo radius: @field:[lon lat radius unit]
+		geo, ok := f.Value.(GeoRadius)
+		if !ok {
+			return "", fmt.Errorf("expected GeoRadius value, got %T", f.Value)
+		}
+		if reason := checkFieldType(FilterFieldTypeGeo, geo.Center); reason != "" {
+			return "", fmt.Errorf("invalid center: %s", reason)
+		}
+		if geo.Radius <= 0 {
+			return "", fmt.Errorf("radius must be positive, got %v", geo.Radius)
+		}
+		unit := geo.Unit
+		switch unit {
+		case "":
+			unit = GeoUnitKilometers
+		case GeoUnitMeters, GeoUnitKilometers, GeoUnitMiles, GeoUnitFeet:
+		default:
+			return "", fmt.Errorf("invalid geo unit: %s", unit)
+		}
+		return fmt.Sprintf("@%s:[%s %s %s %s]", fieldName,
+			param(geo.Center.Lon), param(geo.Center.Lat), param(geo.Radius), unit), nil
+
+	case FilterOpIsTrue:
+		if err := requireType(FilterFieldTypeBool); err != nil {
+			return "", err
+		}
+		// Boolean: @field:[1 1], a false Value matches @field:[0 0]
+		want := 1
+		if f.Value != nil {
+			b, ok := f.Value.(bool)
+			if !ok {
+				return "", fmt.Errorf("expected bool value, got %T", f.Value)
+			}
+			if !b {
+				want = 0
+			}
+		}
+		return fmt.Sprintf("@%s:[%d %d]", fieldName, want, want), nil
+
+	case FilterOpBefore, FilterOpAfter:
+		if err := requireType(FilterFieldTypeDate); err != nil {
+			return "", err
+		}
+		t, ok := toTime(f.Value)
+		if !ok {
+			return "", fmt.Errorf("expected time.Time value, got %T", f.Value)
+		}
+		if f.Operator == FilterOpBefore {
+			// Date <: @field:[-inf (ms]
+			return fmt.Sprintf("@%s:[-inf (%d]", fieldName, t.UnixMilli()), nil
+		}
+		// Date >: @field:[(ms +inf]
+		return fmt.Sprintf("@%s:[(%d +inf]", fieldName, t.UnixMilli()), nil
+
+	case FilterOpBetween:
+		if err := requireType(FilterFieldTypeDate); err != nil {
+			return "", err
+		}
+		// Date range: @field:[from to]
+		rng, ok := f.Value.(DateRange)
+		if !ok {
+			return "", fmt.Errorf("expected DateRange value, got %T", f.Value)
+		}
+		if rng.From.After(rng.To) {
+			return "", fmt.Errorf("date range starts after it ends")
+		}
+		return fmt.Sprintf("@%s:[%d %d]", fieldName, rng.From.UnixMilli(), rng.To.UnixMilli()), nil
+	}
+
+	return "", fmt.Errorf("unknown filter operator: %q", f.Operator)
+}
+
+// stringList converts a []string or []any of strings into a non-empty []string
+func stringList(val any) ([]string, error) {
+	var vals []string
+	switch v := val.(type) {
+	case []string:
+		vals = v
+	case []any:
+		vals = make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string list items, got %T", item)
+			}
+			vals[i] = s
+		}
+	default:
+		return nil, fmt.Errorf("expected []string value, got %T", val)
+	}
+
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("value list must not be empty")
+	}
+	for _, v := range vals {
+		if v == "" {
+			return nil, fmt.Errorf("value list must not contain empty strings")
+		}
+	}
+	return vals, nil
+}
+
+// toFloat converts a Go or JSON number to float64
+func toFloat(val any) (float64, bool) {
+	if !isNumeric(val) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(fmt.Sprintf("%v", val), 64)
+	return f, err == nil && !math.IsNaN(f)
+}
+
+// escapeQueryValue backslash-escapes every ASCII character that isn't a letter, digit or underscore,
+// so a value is always parsed as a single term by Redis Search
+func escapeQueryValue(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, c := range s {
+		if c < 0x80 && !isWordChar(byte(c)) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// isWordChar reports whether an ASCII character is safe to use unescaped in a query term
+func isWordChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
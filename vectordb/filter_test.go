@@ -0,0 +1,217 @@
+package vectordb
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFilterTestDB() *RedisVectorDB {
+	return &RedisVectorDB{
+		index: "test",
+		indexConfig: &IndexConfig{
+			Dimensions: 3,
+			FilterableFields: []FilterableField{
+				{Name: "category", Type: FilterFieldTypeTag},
+				{Name: "description", Type: FilterFieldTypeText},
+				{Name: "price", Type: FilterFieldTypeNumeric},
+				{Name: "location", Type: FilterFieldTypeGeo},
+				{Name: "in_stock", Type: FilterFieldTypeBool},
+				{Name: "published_at", Type: FilterFieldTypeDate},
+			},
+		},
+	}
+}
+
+func TestBuildFilterQuery(t *testing.T) {
+	db := newFilterTestDB()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	query, params, err := db.buildFilterQuery([]Filter{
+		{Field: "category", Operator: FilterOpEq, Value: "laptop-pro"},
+		{Field: "category", Operator: FilterOpIn, Value: []string{"a b", "c"}},
+		{Field: "description", Operator: FilterOpContains, Value: "fast  cpu"},
+		{Field: "price", Operator: FilterOpRange, Value: NumericRange{Min: 10, Max: 20.5}},
+		{Field: "price", Operator: FilterOpGte, Value: 5},
+		{Field: "location", Operator: FilterOpGeoRadius, Value: GeoRadius{Center: GeoPoint{Lat: 35.7, Lon: 51.4}, Radius: 5}},
+		{Field: "in_stock", Operator: FilterOpIsTrue},
+		{Field: "published_at", Operator: FilterOpAfter, Value: date},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "("+strings.Join([]string{
+		`@meta_category:{laptop\-pro}`,
+		`@meta_category:{a\ b|c}`,
+		`@meta_description:(fast cpu)`,
+		`@meta_price:[$f0 $f1]`,
+		`@meta_price:[$f2 +inf]`,
+		`@meta_location:[$f3 $f4 $f5 km]`,
+		`@meta_in_stock:[1 1]`,
+		`@meta_published_at:[(1704067200000 +inf]`,
+	}, " ")+")", query)
+	require.Equal(t, map[string]interface{}{
+		"f0": "10", "f1": "20.5", "f2": "5", "f3": "51.4", "f4": "35.7", "f5": "5",
+	}, params)
+}
+
+func TestBuildFilterQueryTextEq(t *testing.T) {
+	query, params, err := newFilterTestDB().buildFilterQuery([]Filter{
+		{Field: "description", Operator: FilterOpEq, Value: "fast  cpu-fan"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `(@meta_description:"fast cpu\-fan")`, query)
+	require.Empty(t, params)
+}
+
+func TestBuildFilterQueryNoFilters(t *testing.T) {
+	query, params, err := newFilterTestDB().buildFilterQuery(nil)
+	require.NoError(t, err)
+	require.Equal(t, "*", query)
+	require.Empty(t, params)
+}
+
+func TestBuildFilterQueryRejectsInvalidFilters(t *testing.T) {
+	db := newFilterTestDB()
+
+	cases := map[string]Filter{
+		"unknown field":       {Field: "color", Operator: FilterOpEq, Value: "red"},
+		"unknown operator":    {Field: "category", Operator: "like", Value: "x"},
+		"wrong field type":    {Field: "price", Operator: FilterOpEq, Value: "1"},
+		"string as number":    {Field: "price", Operator: FilterOpGte, Value: "1 +inf] | @x:[0"},
+		"inverted range":      {Field: "price", Operator: FilterOpRange, Value: NumericRange{Min: 2, Max: 1}},
+		"empty in list":       {Field: "category", Operator: FilterOpIn, Value: []string{}},
+		"non string tag":      {Field: "category", Operator: FilterOpEq, Value: 3},
+		"empty text":          {Field: "description", Operator: FilterOpContains, Value: "   "},
+		"empty text eq":       {Field: "description", Operator: FilterOpEq, Value: "  "},
+		"in on text":          {Field: "description", Operator: FilterOpIn, Value: []string{"a"}},
+		"bad geo unit":        {Field: "location", Operator: FilterOpGeoRadius, Value: GeoRadius{Radius: 1, Unit: "km] | *"}},
+		"out of range center": {Field: "location", Operator: FilterOpGeoRadius, Value: GeoRadius{Center: GeoPoint{Lat: 91}, Radius: 1}},
+	}
+
+	for name, f := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := db.buildFilterQuery([]Filter{f})
+			require.Error(t, err)
+		})
+	}
+}
+
+// unescapeQueryValue reverses escapeQueryValue and fails on unescaped special characters
+func unescapeQueryValue(t *testing.T, s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range s {
+		if escaped {
+			b.WriteRune(c)
+			escaped = false
+			continue
+		}
+		if c == '\\' {
+			escaped = true
+			continue
+		}
+		if c < 0x80 && !isWordChar(byte(c)) {
+			t.Fatalf("unescaped special character %q in %q", c, s)
+		}
+		b.WriteRune(c)
+	}
+	require.False(t, escaped, "dangling escape in %q", s)
+	return b.String()
+}
+
+func FuzzBuildFilterQueryTag(f *testing.F) {
+	for _, seed := range []string{"laptop", "a} | @other:{x", `\}`, "@x:[0 1]", "(*)", "é-ñ"} {
+		f.Add(seed)
+	}
+
+	db := newFilterTestDB()
+	f.Fuzz(func(t *testing.T, value string) {
+		if value == "" || !utf8.ValidString(value) {
+			return
+		}
+
+		query, params, err := db.buildFilterQuery([]Filter{{Field: "category", Operator: FilterOpEq, Value: value}})
+		require.NoError(t, err)
+		require.Empty(t, params)
+
+		prefix, suffix := "(@meta_category:{", "})"
+		require.True(t, strings.HasPrefix(query, prefix) && strings.HasSuffix(query, suffix), query)
+		inner := strings.TrimSuffix(strings.TrimPrefix(query, prefix), suffix)
+		require.Equal(t, value, unescapeQueryValue(t, inner))
+	})
+}
+
+func FuzzBuildFilterQueryText(f *testing.F) {
+	for _, seed := range []string{"fast cpu", ") | @meta_category:{x} (", "-negate", "~optional %fuzzy%"} {
+		f.Add(seed)
+	}
+
+	db := newFilterTestDB()
+	f.Fuzz(func(t *testing.T, value string) {
+		if !utf8.ValidString(value) || len(strings.Fields(value)) == 0 {
+			return
+		}
+
+		query, _, err := db.buildFilterQuery([]Filter{{Field: "description", Operator: FilterOpContains, Value: value}})
+		require.NoError(t, err)
+
+		prefix, suffix := "(@meta_description:(", "))"
+		require.True(t, strings.HasPrefix(query, prefix) && strings.HasSuffix(query, suffix), query)
+		inner := strings.TrimSuffix(strings.TrimPrefix(query, prefix), suffix)
+
+		words := strings.Split(inner, " ")
+		require.Len(t, words, len(strings.Fields(value)))
+		for i, w := range words {
+			require.Equal(t, strings.Fields(value)[i], unescapeQueryValue(t, w))
+		}
+	})
+}
+
+func TestBuildFilterQueryGeoUnits(t *testing.T) {
+	db := newFilterTestDB()
+	center := GeoPoint{Lat: -33.9, Lon: 151.2}
+
+	for unit, want := range map[GeoUnit]GeoUnit{"": GeoUnitKilometers, GeoUnitMeters: "m", GeoUnitMiles: "mi", GeoUnitFeet: "ft"} {
+		query, params, err := db.buildFilterQuery([]Filter{
+			{Field: "location", Operator: FilterOpGeoRadius, Value: GeoRadius{Center: center, Radius: 250, Unit: unit}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "(@meta_location:[$f0 $f1 $f2 "+string(want)+"])", query)
+		require.Equal(t, map[string]interface{}{"f0": "151.2", "f1": "-33.9", "f2": "250"}, params)
+	}
+
+	_, _, err := db.buildFilterQuery([]Filter{{Field: "location", Operator: FilterOpGeoRadius, Value: GeoRadius{Center: center}}})
+	require.ErrorContains(t, err, "radius must be positive")
+	_, _, err = db.buildFilterQuery([]Filter{{Field: "location", Operator: FilterOpGeoRadius, Value: center}})
+	require.ErrorContains(t, err, "expected GeoRadius value")
+	_, _, err = db.buildFilterQuery([]Filter{{Field: "price", Operator: FilterOpGeoRadius, Value: GeoRadius{Center: center, Radius: 1}}})
+	require.ErrorContains(t, err, "not supported on numeric fields")
+}
+
+func TestBuildFilterQueryBoolAndDate(t *testing.T) {
+	db := newFilterTestDB()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	query, _, err := db.buildFilterQuery([]Filter{
+		{Field: "in_stock", Operator: FilterOpIsTrue, Value: false},
+		{Field: "published_at", Operator: FilterOpBefore, Value: to},
+		{Field: "published_at", Operator: FilterOpBetween, Value: DateRange{From: from, To: to}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "(@meta_in_stock:[0 0] @meta_published_at:[-inf (1706745600000] "+
+		"@meta_published_at:[1704067200000 1706745600000])", query)
+
+	for _, f := range []Filter{
+		{Field: "in_stock", Operator: FilterOpIsTrue, Value: "yes"},
+		{Field: "published_at", Operator: FilterOpAfter, Value: "last week"},
+		{Field: "published_at", Operator: FilterOpBetween, Value: DateRange{From: to, To: from}},
+		{Field: "price", Operator: FilterOpBefore, Value: to},
+		{Field: "published_at", Operator: FilterOpIsTrue},
+	} {
+		_, _, err := db.buildFilterQuery([]Filter{f})
+		require.Error(t, err, "%+v", f)
+	}
+}
@@ -0,0 +1,292 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+// MigrationOptions controls how MigrateIndex moves documents to a new index
+type MigrationOptions struct {
+	// TargetIndex is the name of the new index, defaults to "<index>_<unix timestamp>"
+	TargetIndex string
+	// Embedder re-embeds documents when reembed is set, defaults to the source embedding client
+	Embedder embedding.Client
+	// Alias is pointed at the new index once all documents are copied
+	Alias string
+	// KeepSource keeps the old index and its documents instead of dropping them
+	KeepSource bool
+	// BatchSize is the number of documents copied per pipeline, defaults to 500
+	BatchSize int
+}
+
+// Migration tracks a running MigrateIndex call
+type Migration struct {
+	done   chan struct{}
+	copied atomic.Int64
+
+	mu     sync.Mutex
+	target *RedisVectorDB
+	err    error
+}
+
+// Done is closed when the migration finishes
+func (m *Migration) Done() <-chan struct{} {
+	return m.done
+}
+
+// Copied returns the number of documents copied so far
+func (m *Migration) Copied() int64 {
+	return m.copied.Load()
+}
+
+// Wait blocks until the migration finishes and returns the new index
+func (m *Migration) Wait() (*RedisVectorDB, error) {
+	<-m.done
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.target, m.err
+}
+
+// MigrateIndex creates a new index with newConfig and copies every document into it in the background
+// When reembed is set the documents are embedded again (e.g. with a new model or dimension),
+// otherwise the stored vectors are copied as is and the dimensions must match
+// After the copy the alias is moved to the new index and the old index is dropped
+// Documents added while the copy runs can be missed: pause writes until it finishes, or use AliasedIndex.Reindex,
+// which sends them to both indexes
+func (r *RedisVectorDB) MigrateIndex(
+	ctx context.Context,
+	newConfig IndexConfig,
+	reembed bool,
+	opts MigrationOptions,
+) *Migration {
+	m := &Migration{done: make(chan struct{})}
+
+	go func() {
+		defer close(m.done)
+
+		target, err := r.migrate(ctx, newConfig, reembed, opts, &m.copied, nil)
+
+		m.mu.Lock()
+		m.target, m.err = target, err
+		m.mu.Unlock()
+	}()
+
+	return m
+}
+
+func (r *RedisVectorDB) migrate(
+	ctx context.Context,
+	newConfig IndexConfig,
+	reembed bool,
+	opts MigrationOptions,
+	copied *atomic.Int64,
+	created func(target *RedisVectorDB),
+) (*RedisVectorDB, error) {
+	if r.indexConfig == nil {
+		return nil, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if !reembed && newConfig.Dimensions != r.indexConfig.Dimensions {
+		return nil, fmt.Errorf("dimension change from %d to %d requires re-embedding",
+			r.indexConfig.Dimensions, newConfig.Dimensions)
+	}
+
+	targetIndex := opts.TargetIndex
+	if targetIndex == "" {
+		targetIndex = fmt.Sprintf("%s_%d", r.index, time.Now().Unix())
+	}
+	if targetIndex == r.index {
+		return nil, fmt.Errorf("target index must differ from source index %s", r.index)
+	}
+
+	embedder := opts.Embedder
+	if embedder == nil {
+		embedder = r.embedClient
+	}
+
+	target := NewRedisVectorDB(targetIndex, embedder, r.client)
+	if err := target.CreateIndex(ctx, newConfig); err != nil {
+		return nil, fmt.Errorf("failed to create target index: %w", err)
+	}
+	if created != nil {
+		created(target)
+	}
+
+	err := r.scanDocuments(ctx, opts.BatchSize, func(batch []StoredDocument) error {
+		return r.copyDocuments(ctx, target, batch, reembed, copied)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy documents to %s: %w", targetIndex, err)
+	}
+	if err := r.copyParents(ctx, target, opts.BatchSize); err != nil {
+		return nil, fmt.Errorf("failed to copy parents to %s: %w", targetIndex, err)
+	}
+
+	if opts.Alias != "" {
+		if err := r.client.FTAliasUpdate(ctx, targetIndex, opts.Alias).Err(); err != nil {
+			return nil, fmt.Errorf("failed to point alias %s to %s: %w", opts.Alias, targetIndex, err)
+		}
+	}
+
+	if !opts.KeepSource {
+		if err := r.dropIndex(ctx); err != nil {
+			return nil, fmt.Errorf("failed to drop source index: %w", err)
+		}
+	}
+
+	return target, nil
+}
+
+// copyDocuments writes scanned documents to target, with their stored vectors or embedded again
+// Documents deleted from r since the scan are skipped and changed ones are loaded again, so the copy neither brings
+// back a deleted document nor overwrites a newer version, e.g. one written to both versions during a Reindex
+func (r *RedisVectorDB) copyDocuments(
+	ctx context.Context,
+	target *RedisVectorDB,
+	batch []StoredDocument,
+	reembed bool,
+	copied *atomic.Int64,
+) error {
+	for len(batch) > 0 {
+		docs := make([]Document, len(batch))
+		vecs := make([][]float64, len(batch))
+		for i, d := range batch {
+			docs[i] = d.Document
+			vecs[i] = d.Vector
+		}
+		if err := target.validateDocuments(docs...); err != nil {
+			return err
+		}
+
+		var sparse []embedding.SparseVector
+		if reembed {
+			var err error
+			if vecs, _, err = target.embedDocuments(ctx, docs); err != nil {
+				return err
+			}
+			if sparse, err = target.embedSparse(ctx, docs); err != nil {
+				return err
+			}
+		}
+
+		written, changed, err := r.writeUnchanged(ctx, target, batch, vecs, sparse)
+		if err != nil {
+			return err
+		}
+		copied.Add(int64(written))
+
+		if batch, err = r.loadDocuments(ctx, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeUnchanged writes the documents of batch that are still stored unchanged in r to target, in a transaction
+// that fails when r changes meanwhile. It returns the keys of the documents that changed since they were loaded
+func (r *RedisVectorDB) writeUnchanged(
+	ctx context.Context,
+	target *RedisVectorDB,
+	batch []StoredDocument,
+	vecs [][]float64,
+	sparse []embedding.SparseVector,
+) (written int, changed []string, err error) {
+	keys := make([]string, len(batch))
+	for i, d := range batch {
+		keys[i] = fmt.Sprintf("%s:%s", r.index, d.ID)
+	}
+
+	for {
+		written, changed = 0, nil
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			cmds := make([]*redis.SliceCmd, len(keys))
+			_, err := tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, key := range keys {
+					cmds[i] = pipe.HMGet(ctx, key, "id", "content_hash", "metadata", "parent_id")
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			docs := make([]Document, 0, len(batch))
+			keptVecs := make([][]float64, 0, len(batch))
+			var keptSparse []embedding.SparseVector
+			for i, d := range batch {
+				values := cmds[i].Val()
+				if values[0] == nil {
+					continue // deleted
+				}
+				if !storedUnchanged(d.Document, values[1:]) {
+					changed = append(changed, keys[i])
+					continue
+				}
+				docs = append(docs, d.Document)
+				keptVecs = append(keptVecs, vecs[i])
+				if sparse != nil {
+					keptSparse = append(keptSparse, sparse[i])
+				}
+			}
+			if len(docs) == 0 {
+				return nil
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return target.queueVectors(ctx, pipe, docs, keptVecs, keptSparse)
+			})
+			if err == nil {
+				written = len(docs)
+			}
+			return err
+		}, keys...)
+		if errors.Is(err, redis.TxFailedErr) {
+			// a document was written meanwhile, check the batch again
+			continue
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to store batch: %w", err)
+		}
+		return written, changed, nil
+	}
+}
+
+// storedUnchanged reports whether the content hash, metadata and parent stored for a document still match doc
+func storedUnchanged(doc Document, values []interface{}) bool {
+	hash, _ := values[0].(string)
+	metadata, _ := values[1].(string)
+	parentID, _ := values[2].(string)
+
+	// documents stored before content hashes were recorded have none
+	if (hash != "" && hash != contentHash(doc.Content)) || parentID != doc.ParentID {
+		return false
+	}
+	meta := map[string]any{}
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(meta, doc.Meta)
+}
+
+// dropIndex deletes the index with its documents, parents and recorded model
+func (r *RedisVectorDB) dropIndex(ctx context.Context) error {
+	err := r.client.FTDropIndexWithArgs(ctx, r.index, &redis.FTDropIndexOptions{DeleteDocs: true}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to drop index %s: %w", r.index, err)
+	}
+	if err := r.deleteParents(ctx); err != nil {
+		return err
+	}
+	r.client.Del(ctx, modelKey(r.index))
+	return nil
+}
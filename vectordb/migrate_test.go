@@ -0,0 +1,86 @@
+package vectordb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateIndexSkipsDocumentsChangedDuringCopy(t *testing.T) {
+	for _, reembed := range []bool{true, false} {
+		db, fake, _ := newFakeVectorDB(t, "docs")
+		ctx := context.Background()
+		require.NoError(t, db.StoreDocumentsBatch(ctx, []Document{
+			{ID: "a", Content: "alpha"},
+			{ID: "b", Content: "beta"},
+			{ID: "c", Content: "gamma"},
+		}))
+
+		// b is deleted and c updated after the scan, a is updated while the copy is written
+		var watches, transactions int
+		fake.onCommand = func(args []string) {
+			switch args[0] {
+			case "watch":
+				if watches++; watches == 1 {
+					fake.del("docs:b")
+					fake.set("docs:c", map[string]string{"content": "gamma 2", "content_hash": contentHash("gamma 2")})
+				}
+			case "multi":
+				if transactions++; transactions == 1 {
+					fake.set("docs:a", map[string]string{"metadata": `{"tags":["new"]}`})
+				}
+			}
+		}
+
+		migration := db.MigrateIndex(ctx, *db.indexConfig, reembed, MigrationOptions{TargetIndex: "docs_v2"})
+		target, err := migration.Wait()
+		require.NoError(t, err)
+		require.Equal(t, "docs_v2", target.index)
+		require.EqualValues(t, 2, migration.Copied())
+
+		require.Nil(t, fake.hash("docs_v2:b"), "deleted documents must not come back")
+		require.Equal(t, "gamma 2", fake.hash("docs_v2:c")["content"])
+		require.JSONEq(t, `{"tags":["new"]}`, fake.hash("docs_v2:a")["metadata"])
+		require.Nil(t, fake.hash("docs:a"), "the source is dropped")
+	}
+}
+
+func TestMigrateIndexMovesParents(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	require.NoError(t, db.StoreParents(ctx, []Document{
+		{ID: "guide", Content: "the whole guide", Meta: map[string]any{"lang": "en"}},
+		{ID: "manual", Content: "the whole manual"},
+		{ID: "faq", Content: "old answers"},
+	}))
+	require.NoError(t, db.StoreDocumentsBatch(ctx, []Document{{ID: "guide#0", ParentID: "guide", Content: "first chunk"}}))
+
+	// a newer faq is written to the target meanwhile and manual is deleted, neither is overwritten by the copy
+	var watches int
+	fake.onCommand = func(args []string) {
+		if args[0] == "watch" && strings.HasPrefix(args[1], "docs_parents:") {
+			if watches++; watches == 1 {
+				fake.set("docs_v2_parents:faq", map[string]string{"id": "faq", "content": "new answers"})
+				fake.del("docs_parents:manual")
+			}
+		}
+	}
+
+	target, err := db.MigrateIndex(ctx, *db.indexConfig, false, MigrationOptions{TargetIndex: "docs_v2"}).Wait()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{"id": "guide", "content": "the whole guide", "metadata": `{"lang":"en"}`},
+		fake.hash("docs_v2_parents:guide"))
+	require.Equal(t, "new answers", fake.hash("docs_v2_parents:faq")["content"])
+	require.Nil(t, fake.hash("docs_v2_parents:manual"), "deleted parents must not come back")
+
+	parents, err := target.loadParents(ctx, []string{target.parentKey("guide")})
+	require.NoError(t, err)
+	require.Equal(t, []Document{{ID: "guide", Content: "the whole guide", Meta: map[string]any{"lang": "en"}}}, parents)
+
+	for _, id := range []string{"guide", "manual", "faq"} {
+		require.Nil(t, fake.hash("docs_parents:"+id), "dropping the source deletes its parents")
+	}
+}
@@ -0,0 +1,62 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrModelMismatch is returned when an index is opened with a different embedding model than it was built with
+var ErrModelMismatch = errors.New("embedding model mismatch")
+
+// ModelIndexName derives an index name from a base name, embedding model and dimensions,
+// e.g. "docs__text-embedding-3-small__1536", so every model gets its own collection
+func ModelIndexName(base, model string, dimensions int) string {
+	sanitized := strings.Map(func(c rune) rune {
+		if c < 0x80 && !isWordChar(byte(c)) && c != '-' && c != '.' {
+			return '_'
+		}
+		return c
+	}, model)
+	return fmt.Sprintf("%s__%s__%d", base, sanitized, dimensions)
+}
+
+// modelKey is the key holding the model of an index, outside of the document key prefix
+func modelKey(index string) string {
+	return "vectordb:model:" + index
+}
+
+// checkIndexModel records the embedding model of a new index and rejects a different one on an existing index
+func (r *RedisVectorDB) checkIndexModel(ctx context.Context, config *IndexConfig) error {
+	if config.EmbeddingModel == "" {
+		if named, ok := r.embedClient.(embedding.Named); ok {
+			config.EmbeddingModel = named.Model()
+		}
+	}
+	if config.EmbeddingModel == "" {
+		return nil
+	}
+
+	want := fmt.Sprintf("%s/%d", config.EmbeddingModel, config.Dimensions)
+	key := modelKey(r.index)
+
+	stored, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		if err := r.client.SetNX(ctx, key, want, 0).Err(); err != nil {
+			return fmt.Errorf("failed to record index model: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load index model: %w", err)
+	}
+
+	if stored != want {
+		return fmt.Errorf("index %s was built with %s, not %s: %w", r.index, stored, want, ErrModelMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package vectordb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelIndexName(t *testing.T) {
+	require.Equal(t, "docs__text-embedding-3-small__1536", ModelIndexName("docs", "text-embedding-3-small", 1536))
+	require.Equal(t, "docs__openai_text-embedding-3-large__3072", ModelIndexName("docs", "openai/text-embedding-3-large", 3072))
+}
@@ -0,0 +1,273 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// parentKey is the key of a parent document, outside of the chunk key prefix so it isn't indexed
+func (r *RedisVectorDB) parentKey(id string) string {
+	return fmt.Sprintf("%s_parents:%s", r.index, id)
+}
+
+// StoreParents stores parent documents without embedding them
+// Their chunks are stored with StoreDocument(s) and reference them through Document.ParentID
+func (r *RedisVectorDB) StoreParents(ctx context.Context, parents []Document) error {
+	pipe := r.client.Pipeline()
+	for _, p := range parents {
+		b, _ := json.Marshal(p.Meta)
+		pipe.HSet(ctx, r.parentKey(p.ID), map[string]interface{}{
+			"id":       p.ID,
+			"content":  p.Content,
+			"metadata": string(b),
+		})
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store parents: %w", err)
+	}
+	return nil
+}
+
+// DeleteParent deletes a parent document, its chunks must be deleted separately
+func (r *RedisVectorDB) DeleteParent(ctx context.Context, id string) error {
+	if err := r.client.Del(ctx, r.parentKey(id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete parent: %w", err)
+	}
+	return nil
+}
+
+// scanParents walks the keys of every stored parent document in batches
+func (r *RedisVectorDB) scanParents(ctx context.Context, batchSize int, fn func(keys []string) error) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	iter := r.client.Scan(ctx, 0, r.parentKey("*"), int64(batchSize)).Iterator()
+	keys := make([]string, 0, batchSize)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == batchSize {
+			if err := fn(keys); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan parents: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return fn(keys)
+}
+
+// loadParents loads the parent documents stored under keys, keys that don't exist anymore are skipped
+func (r *RedisVectorDB) loadParents(ctx context.Context, keys []string) ([]Document, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load parents: %w", err)
+	}
+
+	parents := make([]Document, 0, len(keys))
+	for i, key := range keys {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			continue
+		}
+
+		parent, err := decodeParent(strings.TrimPrefix(key, r.parentKey("")), fields)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, parent)
+	}
+	return parents, nil
+}
+
+// decodeParent converts raw parent hash fields into a Document
+func decodeParent(id string, fields map[string]string) (Document, error) {
+	parent := Document{ID: id, Content: fields["content"], Meta: map[string]any{}}
+	if v := fields["id"]; v != "" {
+		parent.ID = v
+	}
+	if v := fields["metadata"]; v != "" {
+		if err := json.Unmarshal([]byte(v), &parent.Meta); err != nil {
+			return parent, fmt.Errorf("failed to unmarshal metadata for parent %s: %w", parent.ID, err)
+		}
+	}
+	return parent, nil
+}
+
+// copyParents copies every parent document of r to target
+// Parents already in target (written to both versions during a Reindex) are newer and kept, and the copy reads the
+// source inside a transaction that fails when it changes meanwhile, so deleted parents aren't brought back
+func (r *RedisVectorDB) copyParents(ctx context.Context, target *RedisVectorDB, batchSize int) error {
+	return r.scanParents(ctx, batchSize, func(keys []string) error {
+		targetKeys := make([]string, len(keys))
+		for i, key := range keys {
+			targetKeys[i] = target.parentKey(strings.TrimPrefix(key, r.parentKey("")))
+		}
+
+		for {
+			err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+				sources := make([]*redis.MapStringStringCmd, len(keys))
+				existing := make([]*redis.SliceCmd, len(keys))
+				_, err := tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+					for i := range keys {
+						sources[i] = pipe.HGetAll(ctx, keys[i])
+						existing[i] = pipe.HMGet(ctx, targetKeys[i], "id")
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					for i := range keys {
+						fields := sources[i].Val()
+						if len(fields) == 0 || existing[i].Val()[0] != nil {
+							continue
+						}
+						values := make(map[string]interface{}, len(fields))
+						for k, v := range fields {
+							values[k] = v
+						}
+						pipe.HSet(ctx, targetKeys[i], values)
+					}
+					return nil
+				})
+				return err
+			}, append(append([]string{}, keys...), targetKeys...)...)
+			if errors.Is(err, redis.TxFailedErr) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to copy parents: %w", err)
+			}
+			return nil
+		}
+	})
+}
+
+// deleteParents deletes every parent document of r
+func (r *RedisVectorDB) deleteParents(ctx context.Context) error {
+	return r.scanParents(ctx, 0, func(keys []string) error {
+		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to delete parents: %w", err)
+		}
+		return nil
+	})
+}
+
+// resolveParents replaces chunk results with their parents, keeping the best chunk order
+// Chunks without a parent (or with a missing one) are returned as is
+func (r *RedisVectorDB) resolveParents(ctx context.Context, chunks []DocumentWithScore) ([]DocumentWithScore, error) {
+	results := make([]DocumentWithScore, 0, len(chunks))
+	byParent := make(map[string]int)
+	parentIDs := make([]string, 0)
+
+	for _, chunk := range chunks {
+		if chunk.ParentID == "" {
+			results = append(results, chunk)
+			continue
+		}
+		if i, ok := byParent[chunk.ParentID]; ok {
+			results[i].Chunks = append(results[i].Chunks, chunk.Document)
+			continue
+		}
+
+		byParent[chunk.ParentID] = len(results)
+		parentIDs = append(parentIDs, chunk.ParentID)
+		results = append(results, DocumentWithScore{
+			Document: Document{ID: chunk.ParentID},
+			Score:    chunk.Score,
+			Chunks:   []Document{chunk.Document},
+		})
+	}
+
+	if len(parentIDs) == 0 {
+		return results, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(parentIDs))
+	for i, id := range parentIDs {
+		cmds[i] = pipe.HGetAll(ctx, r.parentKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load parents: %w", err)
+	}
+
+	for i, id := range parentIDs {
+		idx := byParent[id]
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			// Parent not stored, fall back to the best chunk
+			best := results[idx].Chunks[0]
+			results[idx].Document = best
+			results[idx].Chunks = results[idx].Chunks[1:]
+			continue
+		}
+
+		meta := make(map[string]any)
+		if v := fields["metadata"]; v != "" {
+			if err := json.Unmarshal([]byte(v), &meta); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata for parent %s: %w", id, err)
+			}
+		}
+		results[idx].Content = fields["content"]
+		results[idx].Meta = meta
+	}
+
+	return results, nil
+}
+
+// ChunkDocument splits a parent into chunks of whole sentences of at most maxChars characters
+// (a single longer sentence becomes its own chunk). Chunks get IDs "<parent>#<n>", the parent's
+// metadata and ParentID set
+func ChunkDocument(parent Document, maxChars int) []Document {
+	chunks := make([]Document, 0)
+	var current strings.Builder
+
+	emit := func() {
+		if current.Len() == 0 {
+			return
+		}
+		meta := make(map[string]any, len(parent.Meta))
+		for k, v := range parent.Meta {
+			meta[k] = v
+		}
+		chunks = append(chunks, Document{
+			ID:       fmt.Sprintf("%s#%d", parent.ID, len(chunks)),
+			Content:  current.String(),
+			Meta:     meta,
+			ParentID: parent.ID,
+		})
+		current.Reset()
+	}
+
+	for _, sentence := range splitSentences(parent.Content) {
+		if current.Len() > 0 && current.Len()+1+len(sentence) > maxChars {
+			emit()
+		}
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(sentence)
+	}
+	emit()
+
+	return chunks
+}
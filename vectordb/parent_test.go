@@ -0,0 +1,26 @@
+package vectordb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkDocument(t *testing.T) {
+	parent := Document{
+		ID:      "guide",
+		Content: "First sentence here. Second one. A third, much longer sentence that stands alone.",
+		Meta:    map[string]any{"category": "docs"},
+	}
+
+	chunks := ChunkDocument(parent, 40)
+
+	require.Len(t, chunks, 2)
+	require.Equal(t, "guide#0", chunks[0].ID)
+	require.Equal(t, "First sentence here. Second one.", chunks[0].Content)
+	require.Equal(t, "A third, much longer sentence that stands alone.", chunks[1].Content)
+	for _, c := range chunks {
+		require.Equal(t, "guide", c.ParentID)
+		require.Equal(t, "docs", c.Meta["category"])
+	}
+}
@@ -0,0 +1,104 @@
+package vectordb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Special DocumentSearch.Fields entries, any other entry names a filterable metadata field
+const (
+	FieldContent  = "content"  // Document content
+	FieldMetadata = "metadata" // Full JSON metadata blob
+)
+
+// searchReturnFields resolves DocumentSearch.Fields into the fields returned by Redis
+// No fields selects content and the full metadata
+func (r *RedisVectorDB) searchReturnFields(fields []string) ([]redis.FTSearchReturn, error) {
+	if len(fields) == 0 {
+		fields = []string{FieldContent, FieldMetadata}
+	}
+
+	ret := []redis.FTSearchReturn{{FieldName: "id"}, {FieldName: "score"}, {FieldName: "parent_id"}}
+	for _, name := range fields {
+		switch name {
+		case FieldContent, FieldMetadata:
+			ret = append(ret, redis.FTSearchReturn{FieldName: name})
+		default:
+			if _, ok := r.filterableField(name); !ok {
+				return nil, fmt.Errorf("field %q is neither content, metadata nor a filterable field", name)
+			}
+			ret = append(ret, redis.FTSearchReturn{FieldName: "meta_" + name})
+		}
+	}
+	return ret, nil
+}
+
+// filterableField looks up a filterable field of the index by name
+func (r *RedisVectorDB) filterableField(name string) (FilterableField, bool) {
+	for _, f := range r.indexConfig.FilterableFields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FilterableField{}, false
+}
+
+// projectMetaFields decodes returned meta_ fields into meta, values from the metadata blob win
+func (r *RedisVectorDB) projectMetaFields(fields map[string]string, meta map[string]any) error {
+	for _, f := range r.indexConfig.FilterableFields {
+		raw, ok := fields["meta_"+f.Name]
+		if !ok {
+			continue
+		}
+		if _, exists := meta[f.Name]; exists {
+			continue
+		}
+
+		val, err := decodeIndexValue(f.Type, raw)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		meta[f.Name] = val
+	}
+	return nil
+}
+
+// decodeIndexValue reverses indexValue
+func decodeIndexValue(fieldType FilterFieldType, raw string) (any, error) {
+	switch fieldType {
+	case FilterFieldTypeTag:
+		if strings.Contains(raw, ",") {
+			return strings.Split(raw, ","), nil
+		}
+		return raw, nil
+	case FilterFieldTypeNumeric:
+		return strconv.ParseFloat(raw, 64)
+	case FilterFieldTypeBool:
+		return raw == "1", nil
+	case FilterFieldTypeDate:
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	case FilterFieldTypeGeo:
+		lon, lat, ok := strings.Cut(raw, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid geo value %q", raw)
+		}
+		p := GeoPoint{}
+		var err error
+		if p.Lon, err = strconv.ParseFloat(lon, 64); err != nil {
+			return nil, err
+		}
+		if p.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,36 @@
+package vectordb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeIndexValueRoundTrip(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		fieldType FilterFieldType
+		value     any
+	}{
+		{FilterFieldTypeTag, "laptop"},
+		{FilterFieldTypeTag, []string{"a", "b"}},
+		{FilterFieldTypeNumeric, 12.5},
+		{FilterFieldTypeBool, true},
+		{FilterFieldTypeBool, false},
+		{FilterFieldTypeDate, date},
+		{FilterFieldTypeGeo, GeoPoint{Lat: 35.7, Lon: 51.4}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %v", c.fieldType, c.value), func(t *testing.T) {
+			raw := fmt.Sprint(indexValue(c.fieldType, c.value))
+
+			decoded, err := decodeIndexValue(c.fieldType, raw)
+			require.NoError(t, err)
+			require.Equal(t, c.value, decoded)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecencyOptions tunes SearchRecent, zero values use the defaults
+type RecencyOptions struct {
+	// Field is the metadata field with the document's time: a time.Time (date field), an RFC 3339 string
+	// or unix seconds (required). Documents without it get no recency boost
+	Field string
+	// HalfLife is the age at which a document's recency has halved (required)
+	HalfLife time.Duration
+	// Weight is the share of recency in the score between 0 and 1, defaults to 0.3
+	Weight float64
+	// Candidates is the number of nearest neighbours re-ranked by recency, defaults to 4*TopK
+	Candidates int
+	// Now is the time ages are measured from, defaults to time.Now()
+	Now time.Time
+}
+
+func (o RecencyOptions) withDefaults(topK int) RecencyOptions {
+	if o.Weight == 0 {
+		o.Weight = 0.3
+	}
+	if o.Candidates <= 0 {
+		o.Candidates = 4 * topK
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+	return o
+}
+
+// SearchRecent ranks the nearest neighbours of the query by similarity combined with an exponential recency decay,
+// for news or chat memory where fresh documents matter more
+// The score is (1-Weight)*similarity + Weight*0.5^(age/HalfLife), with similarity scaled to 0..1
+// Filters, TopK and Fields apply as in SearchDocuments, Snippet and ReturnParents are not supported
+// Score holds the combined score, higher is better
+func (r *RedisVectorDB) SearchRecent(ctx context.Context, search DocumentSearch, opts RecencyOptions) ([]DocumentWithScore, error) {
+	if r.indexConfig == nil {
+		return []DocumentWithScore{}, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if opts.Field == "" || opts.HalfLife <= 0 {
+		return []DocumentWithScore{}, fmt.Errorf("recency search needs a Field and a positive HalfLife")
+	}
+	if opts.Weight < 0 || opts.Weight > 1 {
+		return []DocumentWithScore{}, fmt.Errorf("recency Weight must be between 0 and 1, got %v", opts.Weight)
+	}
+	if search.TopK <= 0 {
+		return []DocumentWithScore{}, fmt.Errorf("TopK must be positive, got %d", search.TopK)
+	}
+	if search.Snippet != nil || search.ReturnParents {
+		return []DocumentWithScore{}, fmt.Errorf("recency search doesn't support Snippet or ReturnParents")
+	}
+	opts = opts.withDefaults(search.TopK)
+
+	candidates := search
+	candidates.TopK = max(opts.Candidates, search.TopK)
+	if len(search.Fields) > 0 && !slices.Contains(search.Fields, FieldMetadata) && !slices.Contains(search.Fields, opts.Field) {
+		// the time is read from its filterable field, or else from the metadata
+		field := FieldMetadata
+		for _, f := range r.indexConfig.FilterableFields {
+			if f.Name == opts.Field {
+				field = f.Name
+			}
+		}
+		candidates.Fields = append(slices.Clone(search.Fields), field)
+	}
+
+	docs, err := r.SearchDocuments(ctx, candidates)
+	if err != nil {
+		return []DocumentWithScore{}, err
+	}
+
+	docs, err = rankByRecency(docs, r.indexConfig.DistanceMetric, opts)
+	if err != nil {
+		return []DocumentWithScore{}, err
+	}
+	if len(docs) > search.TopK {
+		docs = docs[:search.TopK]
+	}
+	return docs, nil
+}
+
+// rankByRecency replaces the KNN distances of docs with the combined score and sorts them by it
+func rankByRecency(docs []DocumentWithScore, metric string, opts RecencyOptions) ([]DocumentWithScore, error) {
+	scores := make(map[string]float64, len(docs))
+	for i, doc := range docs {
+		distance, err := strconv.ParseFloat(doc.Score, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid score %q of doc %s: %w", doc.Score, doc.ID, err)
+		}
+
+		recency := 0.0
+		if t, ok := recencyTime(doc.Meta[opts.Field]); ok {
+			age := max(opts.Now.Sub(t), 0)
+			recency = math.Pow(0.5, float64(age)/float64(opts.HalfLife))
+		}
+
+		score := (1-opts.Weight)*similarity(metric, distance) + opts.Weight*recency
+		scores[doc.ID] = score
+		docs[i].Score = strconv.FormatFloat(score, 'f', -1, 64)
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return scores[docs[i].ID] > scores[docs[j].ID]
+	})
+	return docs, nil
+}
+
+// similarity converts a KNN distance to a similarity between 0 and 1
+func similarity(metric string, distance float64) float64 {
+	switch strings.ToUpper(metric) {
+	case "L2":
+		return 1 / (1 + distance)
+	case "IP":
+		return min(max(1-distance, 0), 1)
+	}
+	// cosine distance is between 0 and 2
+	return min(max(1-distance/2, 0), 1)
+}
+
+// recencyTime reads a document time from a date field, an RFC 3339 string or unix seconds
+func recencyTime(val any) (time.Time, bool) {
+	if t, ok := toTime(val); ok {
+		return t, true
+	}
+	switch v := val.(type) {
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,51 @@
+package vectordb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankByRecency(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	docs := []DocumentWithScore{
+		{Document: Document{ID: "old-close", Meta: map[string]any{"published": now.AddDate(0, 0, -30)}}, Score: "0.1"},
+		{Document: Document{ID: "fresh-far", Meta: map[string]any{"published": now.Add(-time.Hour).Format(time.RFC3339)}}, Score: "0.5"},
+		{Document: Document{ID: "undated", Meta: map[string]any{}}, Score: "0.05"},
+		{Document: Document{ID: "week-old", Meta: map[string]any{"published": float64(now.AddDate(0, 0, -7).Unix())}}, Score: "0.2"},
+	}
+
+	ranked, err := rankByRecency(docs, "COSINE", RecencyOptions{
+		Field: "published", HalfLife: 7 * 24 * time.Hour, Weight: 0.5, Now: now,
+	})
+	require.NoError(t, err)
+
+	ids := make([]string, len(ranked))
+	for i, doc := range ranked {
+		ids[i] = doc.ID
+	}
+	require.Equal(t, []string{"fresh-far", "week-old", "old-close", "undated"}, ids)
+
+	// a week old document has half the recency: 0.5*(1-0.2/2) + 0.5*0.5
+	require.Equal(t, "0.7", ranked[1].Score)
+}
+
+func TestRankByRecencyWeightZeroKeepsSimilarityOrder(t *testing.T) {
+	now := time.Now()
+	docs := []DocumentWithScore{
+		{Document: Document{ID: "a", Meta: map[string]any{"t": now.AddDate(-1, 0, 0)}}, Score: "0.1"},
+		{Document: Document{ID: "b", Meta: map[string]any{"t": now}}, Score: "0.3"},
+	}
+	ranked, err := rankByRecency(docs, "COSINE", RecencyOptions{Field: "t", HalfLife: time.Hour, Weight: 0, Now: now})
+	require.NoError(t, err)
+	require.Equal(t, "a", ranked[0].ID)
+}
+
+func TestSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, similarity("COSINE", 0))
+	require.Equal(t, 0.0, similarity("cosine", 2))
+	require.Equal(t, 0.5, similarity("L2", 1))
+	require.Equal(t, 0.75, similarity("IP", 0.25))
+	require.Equal(t, 0.0, similarity("IP", 1.5))
+}
@@ -2,11 +2,15 @@ package vectordb
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mhrlife/goai-kit/embedding"
 	"github.com/redis/go-redis/v9"
@@ -17,6 +21,9 @@ type RedisVectorDB struct {
 	embedClient embedding.Client
 	client      *redis.Client
 	indexConfig *IndexConfig
+	truncateTo  int // Matryoshka embeddings are truncated to the index size, 0 stores them as they are
+
+	sparseEmbedder embedding.SparseEmbedder
 }
 
 func NewRedisVectorDB(index string, embeddingClient embedding.Client, redisClient *redis.Client) *RedisVectorDB {
@@ -33,18 +40,61 @@ func (r *RedisVectorDB) CreateIndex(ctx context.Context, config IndexConfig) err
 		return fmt.Errorf("dimensions must be positive, got %d", config.Dimensions)
 	}
 
+	truncateTo, err := checkDimensions(r.embedClient, config.Dimensions)
+	if err != nil {
+		return err
+	}
+
 	distanceMetric := config.DistanceMetric
 	if distanceMetric == "" {
 		distanceMetric = "COSINE"
 	}
 
-	dataType := "FLOAT32"
+	if config.VectorType == "" {
+		config.VectorType = VectorTypeFloat32
+	}
+	if !validVectorType(config.VectorType) {
+		return fmt.Errorf("invalid vector type: %s (must be FLOAT32, FLOAT16, or INT8)", config.VectorType)
+	}
+	// INT8 vectors are scaled one by one, which only preserves the angle between them
+	if config.VectorType == VectorTypeInt8 && !strings.EqualFold(distanceMetric, "COSINE") {
+		return fmt.Errorf("vector type INT8 requires the COSINE distance metric, got %s", distanceMetric)
+	}
+	dataType := string(config.VectorType)
 
 	validMetrics := map[string]bool{"L2": true, "COSINE": true, "IP": true}
 	if !validMetrics[distanceMetric] {
 		return fmt.Errorf("invalid distance metric: %s (must be L2, COSINE, or IP)", distanceMetric)
 	}
 
+	vectorArgs := &redis.FTVectorArgs{}
+	switch config.Algorithm {
+	case "", IndexAlgorithmHNSW:
+		if config.HNSW.M < 0 || config.HNSW.EFConstruction < 0 || config.HNSW.EFRuntime < 0 {
+			return fmt.Errorf("HNSW parameters must not be negative")
+		}
+		vectorArgs.HNSWOptions = &redis.FTHNSWOptions{
+			Dim:                    config.Dimensions,
+			DistanceMetric:         distanceMetric,
+			Type:                   dataType,
+			InitialCapacity:        config.InitialCapacity,
+			MaxEdgesPerNode:        config.HNSW.M,
+			MaxAllowedEdgesPerNode: config.HNSW.EFConstruction,
+			EFRunTime:              config.HNSW.EFRuntime,
+			Epsilon:                config.HNSW.Epsilon,
+		}
+	case IndexAlgorithmFlat:
+		vectorArgs.FlatOptions = &redis.FTFlatOptions{
+			Dim:             config.Dimensions,
+			DistanceMetric:  distanceMetric,
+			Type:            dataType,
+			InitialCapacity: config.InitialCapacity,
+			BlockSize:       config.BlockSize,
+		}
+	default:
+		return fmt.Errorf("invalid index algorithm: %s (must be HNSW or FLAT)", config.Algorithm)
+	}
+
 	// Build field schemas
 	fields := []*redis.FieldSchema{
 		{
@@ -52,18 +102,19 @@ func (r *RedisVectorDB) CreateIndex(ctx context.Context, config IndexConfig) err
 			FieldType: redis.SearchFieldTypeText,
 		},
 		{
-			FieldName: "embedding",
-			FieldType: redis.SearchFieldTypeVector,
-			VectorArgs: &redis.FTVectorArgs{
-				HNSWOptions: &redis.FTHNSWOptions{
-					Dim:            config.Dimensions,
-					DistanceMetric: distanceMetric,
-					Type:           dataType,
-				},
-			},
+			FieldName:  "embedding",
+			FieldType:  redis.SearchFieldTypeVector,
+			VectorArgs: vectorArgs,
 		},
 	}
 
+	if r.sparseEmbedder != nil {
+		fields = append(fields, &redis.FieldSchema{
+			FieldName: "sparse_terms",
+			FieldType: redis.SearchFieldTypeTag,
+		})
+	}
+
 	// Add filterable fields to schema
 	for _, f := range config.FilterableFields {
 		fieldName := "meta_" + f.Name
@@ -79,18 +130,27 @@ func (r *RedisVectorDB) CreateIndex(ctx context.Context, config IndexConfig) err
 				FieldName: fieldName,
 				FieldType: redis.SearchFieldTypeTag,
 			}
-		case FilterFieldTypeNumeric:
+		case FilterFieldTypeNumeric, FilterFieldTypeBool, FilterFieldTypeDate:
 			schema = &redis.FieldSchema{
 				FieldName: fieldName,
 				FieldType: redis.SearchFieldTypeNumeric,
 			}
+		case FilterFieldTypeGeo:
+			schema = &redis.FieldSchema{
+				FieldName: fieldName,
+				FieldType: redis.SearchFieldTypeGeo,
+			}
 		default:
 			return fmt.Errorf("unsupported filter field type: %s", f.Type)
 		}
 		fields = append(fields, schema)
 	}
 
-	err := r.client.FTCreate(
+	if err := r.checkIndexModel(ctx, &config); err != nil {
+		return err
+	}
+
+	err = r.client.FTCreate(
 		ctx,
 		r.index,
 		&redis.FTCreateOptions{
@@ -105,6 +165,7 @@ func (r *RedisVectorDB) CreateIndex(ctx context.Context, config IndexConfig) err
 	}
 
 	r.indexConfig = &config
+	r.truncateTo = truncateTo
 	return nil
 }
 
@@ -113,40 +174,36 @@ func (r *RedisVectorDB) StoreDocument(ctx context.Context, doc Document) error {
 		return fmt.Errorf("index not created: call CreateIndex first")
 	}
 
+	if err := r.validateDocuments(doc); err != nil {
+		return err
+	}
+
 	embeddings, err := r.embedClient.EmbedTexts(ctx, []string{fmt.Sprintf("%s:%s", doc.ID, doc.Content)})
 	if err != nil {
 		return fmt.Errorf("failed to embed document: %w", err)
 	}
 
-	vec := embeddings[0]
+	vec := r.fitVector(embeddings[0])
 
 	if len(vec) != r.indexConfig.Dimensions {
 		return fmt.Errorf("embedding dimension mismatch: got %d, expected %d",
 			len(vec), r.indexConfig.Dimensions)
 	}
 
-	embedding32 := make([]float32, len(vec))
-	for i, v := range vec {
-		embedding32[i] = float32(v)
-	}
-	b, _ := json.Marshal(doc.Meta)
-
-	docData := map[string]interface{}{
-		"id":        doc.ID,
-		"content":   doc.Content,
-		"metadata":  string(b),
-		"embedding": encodeFloat32Vector(embedding32),
+	sparse, err := r.embedSparse(ctx, []Document{doc})
+	if err != nil {
+		return err
 	}
 
-	// Add filterable metadata fields with meta_ prefix
-	for _, f := range r.indexConfig.FilterableFields {
-		if val, ok := doc.Meta[f.Name]; ok {
-			docData["meta_"+f.Name] = val
+	fields := r.documentFields(doc, vec)
+	if sparse != nil {
+		for k, v := range sparseFields(sparse[0]) {
+			fields[k] = v
 		}
 	}
 
 	key := fmt.Sprintf("%s:%s", r.index, doc.ID)
-	err = r.client.HSet(ctx, key, docData).Err()
+	err = r.client.HSet(ctx, key, fields).Err()
 	if err != nil {
 		return fmt.Errorf("failed to store document: %w", err)
 	}
@@ -155,69 +212,171 @@ func (r *RedisVectorDB) StoreDocument(ctx context.Context, doc Document) error {
 }
 
 func (r *RedisVectorDB) StoreDocumentsBatch(ctx context.Context, docs []Document) error {
-	if len(docs) == 0 {
-		return nil
-	}
-
-	if r.indexConfig == nil {
-		return fmt.Errorf("index not created: call CreateIndex first")
-	}
+	_, err := r.StoreDocumentsBatchWithOptions(ctx, docs, BatchOptions{})
+	return err
+}
 
-	contents := make([]string, len(docs))
-	for i, doc := range docs {
-		contents[i] = fmt.Sprintf("#%s\n%s", doc.ID, doc.Content)
+// storeVectors writes documents with precomputed embeddings in a single pipeline
+// sparse vectors are optional, nil stores the dense embeddings only
+func (r *RedisVectorDB) storeVectors(ctx context.Context, docs []Document, embeddings [][]float64, sparse []embedding.SparseVector) error {
+	pipe := r.client.Pipeline()
+	if err := r.queueVectors(ctx, pipe, docs, embeddings, sparse); err != nil {
+		return err
 	}
 
-	embeddings, err := r.embedClient.EmbedTexts(ctx, contents)
+	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to embed documents: %w", err)
+		return fmt.Errorf("failed to store batch: %w", err)
 	}
 
-	pipe := r.client.Pipeline()
+	return nil
+}
+
+// queueVectors adds the writes of storeVectors to pipe
+func (r *RedisVectorDB) queueVectors(
+	ctx context.Context,
+	pipe redis.Pipeliner,
+	docs []Document,
+	embeddings [][]float64,
+	sparse []embedding.SparseVector,
+) error {
+	if len(embeddings) != len(docs) {
+		return fmt.Errorf("got %d embeddings for %d documents", len(embeddings), len(docs))
+	}
 
 	for i, doc := range docs {
-		vec := embeddings[i]
+		vec := r.fitVector(embeddings[i])
 
 		if len(vec) != r.indexConfig.Dimensions {
 			return fmt.Errorf("document %s: embedding dimension mismatch: got %d, expected %d",
 				doc.ID, len(vec), r.indexConfig.Dimensions)
 		}
 
-		embedding32 := make([]float32, len(vec))
-		for j, v := range vec {
-			embedding32[j] = float32(v)
+		fields := r.documentFields(doc, vec)
+		if sparse != nil {
+			for k, v := range sparseFields(sparse[i]) {
+				fields[k] = v
+			}
 		}
 
-		b, _ := json.Marshal(doc.Meta)
+		key := fmt.Sprintf("%s:%s", r.index, doc.ID)
+		pipe.HSet(ctx, key, fields)
+	}
+
+	return nil
+}
+
+// documentFields builds the hash fields stored for a document and its embedding
+func (r *RedisVectorDB) documentFields(doc Document, vec []float64) map[string]interface{} {
+	b, _ := json.Marshal(doc.Meta)
+	encoded, scale := encodeVector(vec, r.indexConfig.VectorType)
 
-		docData := map[string]interface{}{
-			"id":        doc.ID,
-			"content":   doc.Content,
-			"metadata":  string(b),
-			"embedding": encodeFloat32Vector(embedding32),
+	docData := map[string]interface{}{
+		"id":           doc.ID,
+		"content":      doc.Content,
+		"content_hash": contentHash(doc.Content),
+		"metadata":     string(b),
+		"embedding":    encoded,
+	}
+
+	if r.indexConfig.VectorType == VectorTypeInt8 {
+		docData["embedding_scale"] = scale
+	}
+
+	if doc.ParentID != "" {
+		docData["parent_id"] = doc.ParentID
+	}
+
+	// Add filterable metadata fields with meta_ prefix
+	for _, f := range r.indexConfig.FilterableFields {
+		if val, ok := doc.Meta[f.Name]; ok && val != nil {
+			docData["meta_"+f.Name] = indexValue(f.Type, val)
 		}
+	}
 
-		// Add filterable metadata fields with meta_ prefix
-		for _, f := range r.indexConfig.FilterableFields {
-			if val, ok := doc.Meta[f.Name]; ok {
-				docData["meta_"+f.Name] = val
-			}
+	return docData
+}
+
+// tagSeparator is the default separator of TAG fields, tag values can't contain it
+const tagSeparator = ","
+
+// indexValue converts a metadata value to its hash field representation
+// Tag lists are joined with the default TAG separator, geo points become "lon,lat",
+// booleans become 0/1 and dates unix milliseconds
+func indexValue(fieldType FilterFieldType, val any) any {
+	switch fieldType {
+	case FilterFieldTypeBool:
+		if b, ok := val.(bool); ok && b {
+			return 1
 		}
+		return 0
+	case FilterFieldTypeDate:
+		if t, ok := toTime(val); ok {
+			return t.UnixMilli()
+		}
+	}
 
-		key := fmt.Sprintf("%s:%s", r.index, doc.ID)
-		pipe.HSet(ctx, key, docData)
+	if p, ok := toGeoPoint(val); ok {
+		return formatGeoPoint(p)
 	}
 
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to store batch: %w", err)
+	switch v := val.(type) {
+	case []string:
+		return strings.Join(v, tagSeparator)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(parts, tagSeparator)
 	}
+	return val
+}
 
-	return nil
+// toGeoPoint accepts a GeoPoint or its JSON decoded form ({"Lat": .., "Lon": ..})
+func toGeoPoint(val any) (GeoPoint, bool) {
+	switch v := val.(type) {
+	case GeoPoint:
+		return v, true
+	case *GeoPoint:
+		if v == nil {
+			return GeoPoint{}, false
+		}
+		return *v, true
+	case map[string]any:
+		lat, latOK := v["Lat"].(float64)
+		lon, lonOK := v["Lon"].(float64)
+		return GeoPoint{Lat: lat, Lon: lon}, latOK && lonOK && len(v) == 2
+	}
+	return GeoPoint{}, false
+}
+
+// toTime accepts a time.Time or its JSON encoded form (RFC 3339)
+func toTime(val any) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// formatGeoPoint encodes a point the way RediSearch GEO fields expect it
+func formatGeoPoint(p GeoPoint) string {
+	return strconv.FormatFloat(p.Lon, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lat, 'f', -1, 64)
 }
 
-func (r *RedisVectorDB) UpdateDocument(ctx context.Context, doc Document) error {
-	return r.StoreDocument(ctx, doc)
+// contentHash returns the hex encoded SHA-256 of a document's content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
 
 func (r *RedisVectorDB) DeleteDocument(ctx context.Context, id string) error {
@@ -242,30 +401,31 @@ func (r *RedisVectorDB) SearchDocuments(ctx context.Context, search DocumentSear
 		return []DocumentWithScore{}, fmt.Errorf("query cannot be empty")
 	}
 
-	embeddings, err := r.embedClient.EmbedTexts(ctx, []string{search.Query})
+	filterPrefix, params, err := r.buildFilterQuery(search.Filters)
 	if err != nil {
-		return []DocumentWithScore{}, fmt.Errorf("failed to embed query: %w", err)
+		return []DocumentWithScore{}, fmt.Errorf("invalid filters: %w", err)
 	}
 
-	queryVec := embeddings[0]
+	returnFields, err := r.searchReturnFields(search.Fields)
+	if err != nil {
+		return []DocumentWithScore{}, err
+	}
+
+	queryVec, err := r.embedClient.EmbedQuery(ctx, search.Query)
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("failed to embed query: %w", err)
+	}
+	queryVec = r.fitVector(queryVec)
 
 	if len(queryVec) != r.indexConfig.Dimensions {
 		return []DocumentWithScore{}, fmt.Errorf("query vector dimension mismatch: got %d, expected %d",
 			len(queryVec), r.indexConfig.Dimensions)
 	}
 
-	queryVec32 := make([]float32, len(queryVec))
-	for i, v := range queryVec {
-		queryVec32[i] = float32(v)
-	}
-
-	// Build filter prefix
-	filterPrefix := "*"
-	if len(search.Filters) > 0 {
-		filterPrefix = r.buildFilterQuery(search.Filters)
-	}
+	encodedQuery, _ := encodeVector(queryVec, r.indexConfig.VectorType)
 
 	query := fmt.Sprintf("%s=>[KNN %d @embedding $vec AS score]", filterPrefix, search.TopK)
+	params["vec"] = encodedQuery
 
 	result, err := r.client.FTSearchWithArgs(
 		ctx,
@@ -273,15 +433,8 @@ func (r *RedisVectorDB) SearchDocuments(ctx context.Context, search DocumentSear
 		query,
 		&redis.FTSearchOptions{
 			DialectVersion: 2,
-			Params: map[string]interface{}{
-				"vec": encodeFloat32Vector(queryVec32),
-			},
-			Return: []redis.FTSearchReturn{
-				{FieldName: "id"},
-				{FieldName: "content"},
-				{FieldName: "metadata"},
-				{FieldName: "score"},
-			},
+			Params:         params,
+			Return:         returnFields,
 		},
 	).Result()
 
@@ -292,35 +445,61 @@ func (r *RedisVectorDB) SearchDocuments(ctx context.Context, search DocumentSear
 	docs := make([]DocumentWithScore, 0, len(result.Docs))
 
 	for _, doc := range result.Docs {
-		var id, content string
-		if v, ok := doc.Fields["id"]; ok {
-			id = v
-		}
-		if v, ok := doc.Fields["content"]; ok {
-			content = v
+		decoded, err := r.decodeSearchDocument(doc)
+		if err != nil {
+			return []DocumentWithScore{}, err
 		}
+		decoded.Score = doc.Fields["score"]
+		docs = append(docs, decoded)
+	}
 
-		metadata := make(map[string]interface{})
-		if v, ok := doc.Fields["metadata"]; ok && v != "" {
-			err := json.Unmarshal([]byte(v), &metadata)
-			if err != nil {
-				return []DocumentWithScore{}, fmt.Errorf("failed to unmarshal metadata for doc %s: %w", id, err)
-			}
+	if search.ReturnParents {
+		docs, err = r.resolveParents(ctx, docs)
+		if err != nil {
+			return []DocumentWithScore{}, err
 		}
+	}
 
-		docs = append(docs, DocumentWithScore{
-			Document: Document{
-				ID:      id,
-				Content: content,
-				Meta:    metadata,
-			},
-			Score: doc.Fields["score"],
-		})
+	if search.Snippet != nil {
+		if err := r.attachSnippets(ctx, queryVec, docs, *search.Snippet); err != nil {
+			return []DocumentWithScore{}, err
+		}
 	}
 
 	return docs, nil
 }
 
+// decodeSearchDocument converts a search hit into a DocumentWithScore without its score
+func (r *RedisVectorDB) decodeSearchDocument(doc redis.Document) (DocumentWithScore, error) {
+	var id, content string
+	if v, ok := doc.Fields["id"]; ok {
+		id = v
+	}
+	if v, ok := doc.Fields["content"]; ok {
+		content = v
+	}
+
+	metadata := make(map[string]interface{})
+	if v, ok := doc.Fields["metadata"]; ok && v != "" {
+		err := json.Unmarshal([]byte(v), &metadata)
+		if err != nil {
+			return DocumentWithScore{}, fmt.Errorf("failed to unmarshal metadata for doc %s: %w", id, err)
+		}
+	}
+	if err := r.projectMetaFields(doc.Fields, metadata); err != nil {
+		return DocumentWithScore{}, fmt.Errorf("failed to decode fields for doc %s: %w", id, err)
+	}
+
+	return DocumentWithScore{
+		Document: Document{
+			ID:       id,
+			Content:  content,
+			Meta:     metadata,
+			ParentID: doc.Fields["parent_id"],
+		},
+	}, nil
+}
+
 func encodeFloat32Vector(fs []float32) []byte {
 	buf := make([]byte, len(fs)*4)
 
@@ -331,92 +510,3 @@ func encodeFloat32Vector(fs []float32) []byte {
 
 	return buf
 }
-
-// buildFilterQuery constructs a Redis Search filter query from filters
-func (r *RedisVectorDB) buildFilterQuery(filters []Filter) string {
-	if len(filters) == 0 {
-		return "*"
-	}
-
-	parts := make([]string, 0, len(filters))
-	for _, f := range filters {
-		fieldName := "meta_" + f.Field
-		var part string
-
-		switch f.Operator {
-		case FilterOpEq:
-			// Tag exact match: @field:{value}
-			part = fmt.Sprintf("@%s:{%v}", fieldName, escapeTagValue(f.Value))
-		case FilterOpIn:
-			// Tag in list: @field:{val1|val2|val3}
-			if vals, ok := f.Value.([]string); ok {
-				escaped := make([]string, len(vals))
-				for i, v := range vals {
-					escaped[i] = escapeTagValue(v)
-				}
-				part = fmt.Sprintf("@%s:{%s}", fieldName, strings.Join(escaped, "|"))
-			}
-		case FilterOpContains:
-			// Text contains: @field:value
-			part = fmt.Sprintf("@%s:%v", fieldName, f.Value)
-		case FilterOpRange:
-			// Numeric range: @field:[min max]
-			if rng, ok := f.Value.(NumericRange); ok {
-				part = fmt.Sprintf("@%s:[%v %v]", fieldName, rng.Min, rng.Max)
-			}
-		case FilterOpGte:
-			// Numeric >=: @field:[value +inf]
-			part = fmt.Sprintf("@%s:[%v +inf]", fieldName, f.Value)
-		case FilterOpLte:
-			// Numeric <=: @field:[-inf value]
-			part = fmt.Sprintf("@%s:[-inf %v]", fieldName, f.Value)
-		}
-
-		if part != "" {
-			parts = append(parts, part)
-		}
-	}
-
-	if len(parts) == 0 {
-		return "*"
-	}
-
-	// Combine with AND (space separated in Redis Search)
-	return "(" + strings.Join(parts, " ") + ")"
-}
-
-// escapeTagValue escapes special characters in tag values for Redis Search
-func escapeTagValue(v interface{}) string {
-	s := fmt.Sprintf("%v", v)
-	// Escape special characters in Redis Search tag syntax
-	replacer := strings.NewReplacer(
-		",", "\\,",
-		".", "\\.",
-		"<", "\\<",
-		">", "\\>",
-		"{", "\\{",
-		"}", "\\}",
-		"[", "\\[",
-		"]", "\\]",
-		"\"", "\\\"",
-		"'", "\\'",
-		":", "\\:",
-		";", "\\;",
-		"!", "\\!",
-		"@", "\\@",
-		"#", "\\#",
-		"$", "\\$",
-		"%", "\\%",
-		"^", "\\^",
-		"&", "\\&",
-		"*", "\\*",
-		"(", "\\(",
-		")", "\\)",
-		"-", "\\-",
-		"+", "\\+",
-		"=", "\\=",
-		"~", "\\~",
-		" ", "\\ ",
-	)
-	return replacer.Replace(s)
-}
@@ -0,0 +1,422 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis keeps hashes in memory behind a go-redis hook, so the commands never reach a server
+// WATCH is honored: a transaction fails when a watched key was written after it
+type fakeRedis struct {
+	mu       sync.Mutex
+	hashes   map[string]map[string]string
+	strings  map[string]string
+	versions map[string]int
+	watched  map[string]int
+
+	// info answers FT.INFO, the index doesn't exist when nil
+	info *redis.FTInfoResult
+
+	// searchResult answers every FT.SEARCH
+	searchResult redis.FTSearchResult
+
+	// onCommand is called before every command with its arguments (optional)
+	onCommand func(args []string)
+}
+
+func newFakeRedis(t *testing.T) (*redis.Client, *fakeRedis) {
+	fake := &fakeRedis{
+		hashes:   make(map[string]map[string]string),
+		strings:  make(map[string]string),
+		versions: make(map[string]int),
+	}
+	client := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+	client.AddHook(fake)
+	t.Cleanup(func() { _ = client.Close() })
+	return client, fake
+}
+
+func (f *fakeRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeRedis) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		f.notify(cmd)
+		f.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (f *fakeRedis) ProcessPipelineHook(redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			f.notify(cmd)
+		}
+		if len(cmds) > 0 && cmds[0].Name() == "multi" {
+			if !f.exec() {
+				for _, cmd := range cmds {
+					cmd.SetErr(redis.TxFailedErr)
+				}
+				return redis.TxFailedErr
+			}
+		}
+		for _, cmd := range cmds {
+			f.process(cmd)
+		}
+		return nil
+	}
+}
+
+// hash returns a copy of a stored hash, nil if it doesn't exist
+func (f *fakeRedis) hash(key string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hashes[key] == nil {
+		return nil
+	}
+	fields := make(map[string]string, len(f.hashes[key]))
+	for k, v := range f.hashes[key] {
+		fields[k] = v
+	}
+	return fields
+}
+
+// set writes fields of a hash like HSET
+func (f *fakeRedis) set(key string, fields map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hashes[key] == nil {
+		f.hashes[key] = make(map[string]string)
+	}
+	for k, v := range fields {
+		f.hashes[key][k] = v
+	}
+	f.versions[key]++
+}
+
+// del deletes a hash like DEL
+func (f *fakeRedis) del(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.hashes, key)
+	f.versions[key]++
+}
+
+// exec reports whether the watched keys are unchanged and clears them, like EXEC
+func (f *fakeRedis) exec() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, version := range f.watched {
+		if f.versions[key] != version {
+			f.watched = nil
+			return false
+		}
+	}
+	f.watched = nil
+	return true
+}
+
+func (f *fakeRedis) notify(cmd redis.Cmder) {
+	if f.onCommand != nil {
+		f.onCommand(cmdArgs(cmd))
+	}
+}
+
+func cmdArgs(cmd redis.Cmder) []string {
+	args := make([]string, len(cmd.Args()))
+	for i, arg := range cmd.Args() {
+		if b, ok := arg.([]byte); ok {
+			args[i] = string(b)
+			continue
+		}
+		args[i] = fmt.Sprint(arg)
+	}
+	args[0] = strings.ToLower(args[0])
+	return args
+}
+
+func (f *fakeRedis) process(cmd redis.Cmder) {
+	args := cmdArgs(cmd)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch args[0] {
+	case "multi", "exec", "unwatch":
+	case "watch":
+		f.watched = make(map[string]int)
+		for _, key := range args[1:] {
+			f.watched[key] = f.versions[key]
+		}
+		cmd.(*redis.StatusCmd).SetVal("OK")
+	case "get":
+		v, ok := f.strings[args[1]]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.(*redis.StringCmd).SetVal(v)
+	case "set":
+		// SET key value [EX seconds | PX milliseconds] [NX], expiry is ignored
+		nx := strings.EqualFold(args[len(args)-1], "nx")
+		_, exists := f.strings[args[1]]
+		written := !nx || !exists
+		if written {
+			f.strings[args[1]] = args[2]
+			f.versions[args[1]]++
+		}
+		switch c := cmd.(type) {
+		case *redis.BoolCmd:
+			c.SetVal(written)
+		case *redis.StatusCmd:
+			c.SetVal("OK")
+		}
+	case "dump":
+		// hashes are serialized as JSON, with the values as base64 since vectors are binary
+		hash, ok := f.hashes[args[1]]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		values := make(map[string][]byte, len(hash))
+		for k, v := range hash {
+			values[k] = []byte(v)
+		}
+		data, _ := json.Marshal(values)
+		cmd.(*redis.StringCmd).SetVal(string(data))
+	case "restore":
+		var values map[string][]byte
+		if err := json.Unmarshal([]byte(args[3]), &values); err != nil {
+			cmd.SetErr(fmt.Errorf("DUMP payload version or checksum are wrong"))
+			return
+		}
+		hash := make(map[string]string, len(values))
+		for k, v := range values {
+			hash[k] = string(v)
+		}
+		f.hashes[args[1]] = hash
+		f.versions[args[1]]++
+		cmd.(*redis.StatusCmd).SetVal("OK")
+	case "setnx":
+		_, exists := f.strings[args[1]]
+		if !exists {
+			f.strings[args[1]] = args[2]
+			f.versions[args[1]]++
+		}
+		cmd.(*redis.BoolCmd).SetVal(!exists)
+	case "hget":
+		v, ok := f.hashes[args[1]][args[2]]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.(*redis.StringCmd).SetVal(v)
+	case "hmget":
+		values := make([]interface{}, len(args)-2)
+		for i, field := range args[2:] {
+			if v, ok := f.hashes[args[1]][field]; ok {
+				values[i] = v
+			}
+		}
+		cmd.(*redis.SliceCmd).SetVal(values)
+	case "scan":
+		// a single page, only "prefix*" patterns
+		var keys []string
+		for key := range f.hashes {
+			if strings.HasPrefix(key, strings.TrimSuffix(args[3], "*")) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		cmd.(*redis.ScanCmd).SetVal(keys, 0)
+	case "hgetall":
+		fields := make(map[string]string)
+		for k, v := range f.hashes[args[1]] {
+			fields[k] = v
+		}
+		cmd.(*redis.MapStringStringCmd).SetVal(fields)
+	case "hset":
+		if f.hashes[args[1]] == nil {
+			f.hashes[args[1]] = make(map[string]string)
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			f.hashes[args[1]][args[i]] = args[i+1]
+		}
+		f.versions[args[1]]++
+		cmd.(*redis.IntCmd).SetVal(int64(len(args)-2) / 2)
+	case "hdel":
+		for _, field := range args[2:] {
+			delete(f.hashes[args[1]], field)
+		}
+		f.versions[args[1]]++
+		cmd.(*redis.IntCmd).SetVal(int64(len(args) - 2))
+	case "del":
+		for _, key := range args[1:] {
+			delete(f.hashes, key)
+			delete(f.strings, key)
+			f.versions[key]++
+		}
+		cmd.(*redis.IntCmd).SetVal(int64(len(args) - 1))
+	case "ft.info":
+		if f.info == nil {
+			cmd.SetErr(fmt.Errorf("Unknown index name"))
+			break
+		}
+		cmd.(*redis.FTInfoCmd).SetVal(*f.info)
+	case "ft.search":
+		cmd.(*redis.FTSearchCmd).SetVal(f.searchResult)
+	case "ft.create", "ft.aliasupdate":
+		cmd.(*redis.StatusCmd).SetVal("OK")
+	case "ft.dropindex":
+		if args[len(args)-1] == "DD" {
+			for key := range f.hashes {
+				if strings.HasPrefix(key, args[1]+":") {
+					delete(f.hashes, key)
+					f.versions[key]++
+				}
+			}
+		}
+		cmd.(*redis.StatusCmd).SetVal("OK")
+	default:
+		cmd.SetErr(fmt.Errorf("fake redis: unsupported command %s", args[0]))
+	}
+}
+
+// countingEmbedder returns a constant vector and counts the embedded texts
+type countingEmbedder struct {
+	mu    sync.Mutex
+	texts int
+}
+
+func (e *countingEmbedder) EmbedTexts(_ context.Context, texts []string) ([][]float64, error) {
+	e.mu.Lock()
+	e.texts += len(texts)
+	e.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i := range vectors {
+		vectors[i] = []float64{1, 0, 0}
+	}
+	return vectors, nil
+}
+
+func (e *countingEmbedder) EmbedQuery(context.Context, string) ([]float64, error) {
+	return []float64{1, 0, 0}, nil
+}
+
+func (e *countingEmbedder) embedded() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.texts
+}
+
+// newFakeVectorDB returns a vector db on a fake redis with a 3 dimensional index and a "tags" tag field
+func newFakeVectorDB(t *testing.T, index string) (*RedisVectorDB, *fakeRedis, *countingEmbedder) {
+	client, fake := newFakeRedis(t)
+	embedder := &countingEmbedder{}
+	db := NewRedisVectorDB(index, embedder, client)
+	db.indexConfig = &IndexConfig{
+		Dimensions:       3,
+		VectorType:       VectorTypeFloat32,
+		FilterableFields: []FilterableField{{Name: "tags", Type: FilterFieldTypeTag}},
+	}
+	return db, fake, embedder
+}
+
+// createIndexArgs runs CreateIndex on a fake redis and returns the FT.CREATE arguments joined by spaces
+func createIndexArgs(t *testing.T, config IndexConfig) (string, error) {
+	client, fake := newFakeRedis(t)
+	var args []string
+	fake.onCommand = func(cmd []string) {
+		if cmd[0] == "ft.create" {
+			args = cmd
+		}
+	}
+	err := NewRedisVectorDB("docs", sizedEmbedder{}, client).CreateIndex(context.Background(), config)
+	return strings.Join(args, " "), err
+}
+
+func TestCreateIndexAlgorithms(t *testing.T) {
+	args, err := createIndexArgs(t, IndexConfig{
+		Dimensions:      3,
+		InitialCapacity: 1000,
+		HNSW:            HNSWParams{M: 32, EFConstruction: 400, EFRuntime: 20, Epsilon: 0.05},
+	})
+	require.NoError(t, err)
+	require.Contains(t, args, "embedding VECTOR HNSW 16 TYPE FLOAT32 DIM 3 DISTANCE_METRIC COSINE "+
+		"INITIAL_CAP 1000 M 32 EF_CONSTRUCTION 400 EF_RUNTIME 20 EPSILON 0.05")
+
+	// zero HNSW parameters keep the backend defaults
+	args, err = createIndexArgs(t, IndexConfig{Dimensions: 3, DistanceMetric: "L2"})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(args, "embedding VECTOR HNSW 6 TYPE FLOAT32 DIM 3 DISTANCE_METRIC L2"), args)
+
+	args, err = createIndexArgs(t, IndexConfig{Dimensions: 3, Algorithm: IndexAlgorithmFlat, BlockSize: 1024})
+	require.NoError(t, err)
+	require.Contains(t, args, "embedding VECTOR FLAT 8 TYPE FLOAT32 DIM 3 DISTANCE_METRIC COSINE BLOCK_SIZE 1024")
+}
+
+func TestCreateIndexRejectsInvalidAlgorithms(t *testing.T) {
+	_, err := createIndexArgs(t, IndexConfig{Dimensions: 3, Algorithm: "IVF"})
+	require.ErrorContains(t, err, "invalid index algorithm: IVF")
+
+	_, err = createIndexArgs(t, IndexConfig{Dimensions: 3, HNSW: HNSWParams{M: -1}})
+	require.ErrorContains(t, err, "HNSW parameters must not be negative")
+}
+
+func TestGeoFieldsAreStoredAsLonLat(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "places")
+	db.indexConfig.FilterableFields = []FilterableField{{Name: "location", Type: FilterFieldTypeGeo}}
+	ctx := context.Background()
+
+	require.NoError(t, db.StoreDocumentsBatch(ctx, []Document{
+		{ID: "tehran", Content: "Tehran", Meta: map[string]any{"location": GeoPoint{Lat: 35.7, Lon: 51.4}}},
+		// metadata decoded from JSON
+		{ID: "sydney", Content: "Sydney", Meta: map[string]any{"location": map[string]any{"Lat": -33.9, "Lon": 151.2}}},
+	}))
+	require.Equal(t, "51.4,35.7", fake.hash("places:tehran")["meta_location"])
+	require.Equal(t, "151.2,-33.9", fake.hash("places:sydney")["meta_location"])
+
+	err := db.StoreDocument(ctx, Document{ID: "nowhere", Content: "x", Meta: map[string]any{"location": "35.7,51.4"}})
+	require.ErrorContains(t, err, "expected GeoPoint for geo field")
+
+	args, err := createIndexArgs(t, IndexConfig{Dimensions: 3, FilterableFields: db.indexConfig.FilterableFields})
+	require.NoError(t, err)
+	require.Contains(t, args, "meta_location GEO")
+}
+
+func TestBoolAndDateFieldsAreStoredAsNumbers(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "posts")
+	db.indexConfig.FilterableFields = []FilterableField{
+		{Name: "draft", Type: FilterFieldTypeBool},
+		{Name: "published_at", Type: FilterFieldTypeDate},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, db.StoreDocumentsBatch(ctx, []Document{
+		{ID: "a", Content: "A", Meta: map[string]any{"draft": true, "published_at": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		// metadata decoded from JSON
+		{ID: "b", Content: "B", Meta: map[string]any{"draft": false, "published_at": "2024-01-01T03:30:00+03:30"}},
+	}))
+	require.Equal(t, "1", fake.hash("posts:a")["meta_draft"])
+	require.Equal(t, "1704067200000", fake.hash("posts:a")["meta_published_at"])
+	require.Equal(t, "0", fake.hash("posts:b")["meta_draft"])
+	require.Equal(t, "1704067200000", fake.hash("posts:b")["meta_published_at"])
+
+	err := db.StoreDocument(ctx, Document{ID: "c", Content: "C", Meta: map[string]any{"draft": "no"}})
+	require.ErrorContains(t, err, "expected bool for bool field")
+	err = db.StoreDocument(ctx, Document{ID: "c", Content: "C", Meta: map[string]any{"published_at": "2024-01-01"}})
+	require.ErrorContains(t, err, "expected time.Time or RFC 3339 string")
+
+	args, err := createIndexArgs(t, IndexConfig{Dimensions: 3, FilterableFields: db.indexConfig.FilterableFields})
+	require.NoError(t, err)
+	require.Contains(t, args, "meta_draft NUMERIC")
+	require.Contains(t, args, "meta_published_at NUMERIC")
+}
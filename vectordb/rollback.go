@@ -0,0 +1,135 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrBatchInProgress is returned when another call is storing a batch with the same IdempotencyKey
+var ErrBatchInProgress = errors.New("batch with this idempotency key is in progress")
+
+// batchPending is the value of an idempotency mark while its call runs
+const batchPending = "pending"
+
+// batchMarkKey is the key of the idempotency mark of a batch, outside of the document key prefix
+func batchMarkKey(index, idempotencyKey string) string {
+	return "vectordb:batch:" + index + ":" + idempotencyKey
+}
+
+// claimBatch marks a batch as pending, it returns the report of a call that already completed it
+func (r *RedisVectorDB) claimBatch(ctx context.Context, key string, ttl time.Duration) (*BatchReport, error) {
+	markKey := batchMarkKey(r.index, key)
+	claimed, err := r.client.SetNX(ctx, markKey, batchPending, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim batch %s: %w", key, err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	mark, err := r.client.Get(ctx, markKey).Result()
+	if errors.Is(err, redis.Nil) {
+		// the mark expired in between
+		return r.claimBatch(ctx, key, ttl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load batch %s: %w", key, err)
+	}
+	if mark == batchPending {
+		return nil, fmt.Errorf("%s: %w", key, ErrBatchInProgress)
+	}
+
+	var report BatchReport
+	if err := json.Unmarshal([]byte(mark), &report); err != nil {
+		return nil, fmt.Errorf("invalid mark of batch %s: %w", key, err)
+	}
+	report.Replayed = true
+	return &report, nil
+}
+
+// completeBatch replaces the pending mark of a batch with its report, or drops it so the batch can be retried
+func (r *RedisVectorDB) completeBatch(ctx context.Context, key string, ttl time.Duration, report BatchReport, failed bool) error {
+	markKey := batchMarkKey(r.index, key)
+	if failed {
+		return r.client.Del(ctx, markKey).Err()
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, markKey, data, ttl).Err()
+}
+
+// batchJournal remembers the keys written by an atomic batch call and what they held before, to roll them back
+type batchJournal struct {
+	keys     []string
+	seen     map[string]bool
+	previous map[string]string // key -> DUMP of the overwritten hash
+}
+
+func newBatchJournal() *batchJournal {
+	return &batchJournal{seen: map[string]bool{}, previous: map[string]string{}}
+}
+
+// record snapshots the keys of docs before they are written
+func (j *batchJournal) record(ctx context.Context, r *RedisVectorDB, docs []Document) error {
+	pipe := r.client.Pipeline()
+	dumps := make([]*redis.StringCmd, 0, len(docs))
+	keys := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		key := fmt.Sprintf("%s:%s", r.index, doc.ID)
+		if j.seen[key] {
+			continue
+		}
+		keys = append(keys, key)
+		dumps = append(dumps, pipe.Dump(ctx, key))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to snapshot documents: %w", err)
+	}
+
+	for i, key := range keys {
+		dump, err := dumps[i].Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			return fmt.Errorf("failed to snapshot %s: %w", key, err)
+		default:
+			j.previous[key] = dump
+		}
+		j.keys = append(j.keys, key)
+		j.seen[key] = true
+	}
+	return nil
+}
+
+// rollback deletes the keys written since the journal started and restores the hashes they overwrote
+// It runs on a context of its own, the call's context is often the reason the batch failed
+func (j *batchJournal) rollback(ctx context.Context, r *RedisVectorDB) error {
+	if len(j.keys) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	pipe := r.client.Pipeline()
+	for _, key := range j.keys {
+		if dump, ok := j.previous[key]; ok {
+			pipe.RestoreReplace(ctx, key, 0, dump)
+		} else {
+			pipe.Del(ctx, key)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to roll back batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StoredDocument is a document together with the vector stored for it
+type StoredDocument struct {
+	Document
+	Vector []float64 // nil when the stored vector is missing or corrupted
+}
+
+// errStopScan ends scanDocuments early without an error
+var errStopScan = errors.New("stop scan")
+
+// scanDocuments walks every document of the index in batches and decodes its vector
+// fn can return errStopScan to end the walk early
+func (r *RedisVectorDB) scanDocuments(
+	ctx context.Context,
+	batchSize int,
+	fn func(batch []StoredDocument) error,
+) error {
+	if r.indexConfig == nil {
+		return fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	prefix := r.index + ":"
+	iter := r.client.Scan(ctx, 0, prefix+"*", int64(batchSize)).Iterator()
+	keys := make([]string, 0, batchSize)
+
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		batch, err := r.loadDocuments(ctx, keys)
+		if err != nil {
+			return err
+		}
+
+		keys = keys[:0]
+		return fn(batch)
+	}
+	stop := func(err error) error {
+		if errors.Is(err, errStopScan) {
+			return nil
+		}
+		return err
+	}
+
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == batchSize {
+			if err := flush(); err != nil {
+				return stop(err)
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan documents: %w", err)
+	}
+
+	return stop(flush())
+}
+
+// loadDocuments loads and decodes the documents stored under keys, keys that don't exist anymore are skipped
+func (r *RedisVectorDB) loadDocuments(ctx context.Context, keys []string) ([]StoredDocument, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load documents: %w", err)
+	}
+
+	docs := make([]StoredDocument, 0, len(keys))
+	for i, key := range keys {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			// Deleted between SCAN and HGETALL
+			continue
+		}
+
+		doc, err := r.decodeStoredDocument(strings.TrimPrefix(key, r.index+":"), fields)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// decodeStoredDocument converts raw hash fields into a StoredDocument
+func (r *RedisVectorDB) decodeStoredDocument(id string, fields map[string]string) (StoredDocument, error) {
+	doc := StoredDocument{
+		Document: Document{
+			ID:      id,
+			Content: fields["content"],
+			Meta:    map[string]any{},
+		},
+	}
+	if v, ok := fields["id"]; ok && v != "" {
+		doc.ID = v
+	}
+	doc.ParentID = fields["parent_id"]
+
+	if v := fields["metadata"]; v != "" {
+		if err := json.Unmarshal([]byte(v), &doc.Meta); err != nil {
+			return doc, fmt.Errorf("failed to unmarshal metadata for doc %s: %w", doc.ID, err)
+		}
+	}
+
+	scale := 0.0
+	if v := fields["embedding_scale"]; v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return doc, fmt.Errorf("invalid embedding scale for doc %s: %w", doc.ID, err)
+		}
+		scale = parsed
+	}
+
+	// A vector that can't be decoded is left nil so callers can report or re-embed it
+	if vec, err := decodeVector([]byte(fields["embedding"]), r.indexConfig.VectorType, scale); err == nil {
+		doc.Vector = vec
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,130 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// SnippetOptions enables extraction of the passage that best matches the query
+type SnippetOptions struct {
+	// WindowSize is the number of consecutive sentences per candidate passage (defaults to 2)
+	WindowSize int
+}
+
+// attachSnippets picks the passage of each document closest to the query vector
+// Candidate passages of all documents are embedded in a single call
+func (r *RedisVectorDB) attachSnippets(
+	ctx context.Context,
+	queryVec []float64,
+	docs []DocumentWithScore,
+	opts SnippetOptions,
+) error {
+	window := opts.WindowSize
+	if window <= 0 {
+		window = 2
+	}
+
+	candidates := make([][]string, len(docs))
+	texts := make([]string, 0)
+	for i, doc := range docs {
+		candidates[i] = passages(splitSentences(doc.Content), window)
+		if len(candidates[i]) == 1 {
+			docs[i].Snippet = candidates[i][0]
+			continue
+		}
+		texts = append(texts, candidates[i]...)
+	}
+
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := r.embedClient.EmbedTexts(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed snippet passages: %w", err)
+	}
+
+	offset := 0
+	for i := range docs {
+		if len(candidates[i]) <= 1 {
+			continue
+		}
+
+		best, bestScore := 0, math.Inf(-1)
+		for j := range candidates[i] {
+			if score := cosineSimilarity(queryVec, r.fitVector(embeddings[offset+j])); score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		docs[i].Snippet = candidates[i][best]
+		offset += len(candidates[i])
+	}
+
+	return nil
+}
+
+// splitSentences splits text on sentence terminators followed by whitespace and on line breaks
+func splitSentences(text string) []string {
+	sentences := make([]string, 0)
+	runes := []rune(text)
+	start := 0
+
+	for i, c := range runes {
+		end := -1
+		switch {
+		case c == '\n':
+			end = i
+		case c == '.' || c == '!' || c == '?':
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				end = i + 1
+			}
+		}
+		if end < 0 {
+			continue
+		}
+
+		if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = end
+	}
+
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+
+	return sentences
+}
+
+// passages builds sliding windows of consecutive sentences
+func passages(sentences []string, window int) []string {
+	if len(sentences) <= window {
+		return []string{strings.Join(sentences, " ")}
+	}
+
+	result := make([]string, 0, len(sentences)-window+1)
+	for i := 0; i+window <= len(sentences); i++ {
+		result = append(result, strings.Join(sentences[i:i+window], " "))
+	}
+	return result
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,31 @@
+package vectordb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSentences(t *testing.T) {
+	text := "Redis is fast. It stores vectors!\nVersion 7.2 added more?  Yes"
+
+	require.Equal(t, []string{
+		"Redis is fast.",
+		"It stores vectors!",
+		"Version 7.2 added more?",
+		"Yes",
+	}, splitSentences(text))
+}
+
+func TestPassages(t *testing.T) {
+	sentences := []string{"a.", "b.", "c."}
+
+	require.Equal(t, []string{"a. b.", "b. c."}, passages(sentences, 2))
+	require.Equal(t, []string{"a. b. c."}, passages(sentences, 5))
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	require.InDelta(t, 1.0, cosineSimilarity([]float64{1, 2}, []float64{2, 4}), 1e-9)
+	require.InDelta(t, 0.0, cosineSimilarity([]float64{1, 0}, []float64{0, 1}), 1e-9)
+	require.Equal(t, 0.0, cosineSimilarity([]float64{0, 0}, []float64{1, 1}))
+}
@@ -0,0 +1,289 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+// WithSparseEmbedder stores a sparse embedding (e.g. SPLADE or BM42) next to the dense one of every document,
+// enabling SearchHybrid. Call it before CreateIndex
+// Redis has no sparse vector index: the terms are indexed as tags to find candidates, which are scored in the client
+func (r *RedisVectorDB) WithSparseEmbedder(embedder embedding.SparseEmbedder) *RedisVectorDB {
+	r.sparseEmbedder = embedder
+	return r
+}
+
+// HybridOptions tunes SearchHybrid, zero values use the defaults
+type HybridOptions struct {
+	// Candidates is the number of documents retrieved by each of the dense and sparse searches, defaults to 4*TopK
+	Candidates int
+	// DenseWeight and SparseWeight weight the two rankings in the fusion, default to 1
+	DenseWeight  float64
+	SparseWeight float64
+	// RRFK is the reciprocal rank fusion constant, defaults to 60
+	RRFK int
+	// MaxQueryTerms is the number of highest weighted query terms used to find sparse candidates, defaults to 32
+	MaxQueryTerms int
+}
+
+func (o HybridOptions) withDefaults(topK int) HybridOptions {
+	if o.Candidates <= 0 {
+		o.Candidates = 4 * topK
+	}
+	if o.DenseWeight == 0 {
+		o.DenseWeight = 1
+	}
+	if o.SparseWeight == 0 {
+		o.SparseWeight = 1
+	}
+	if o.RRFK <= 0 {
+		o.RRFK = 60
+	}
+	if o.MaxQueryTerms <= 0 {
+		o.MaxQueryTerms = 32
+	}
+	return o
+}
+
+// SearchHybrid combines dense KNN search with sparse retrieval using reciprocal rank fusion
+// Filters, TopK and Fields apply as in SearchDocuments, Snippet and ReturnParents are not supported
+// Score holds the fused score, higher is better
+func (r *RedisVectorDB) SearchHybrid(ctx context.Context, search DocumentSearch, opts HybridOptions) ([]DocumentWithScore, error) {
+	if r.indexConfig == nil {
+		return []DocumentWithScore{}, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if r.sparseEmbedder == nil {
+		return []DocumentWithScore{}, fmt.Errorf("hybrid search needs a sparse embedder: call WithSparseEmbedder")
+	}
+	if search.TopK <= 0 {
+		return []DocumentWithScore{}, fmt.Errorf("TopK must be positive, got %d", search.TopK)
+	}
+	if search.Query == "" {
+		return []DocumentWithScore{}, fmt.Errorf("query cannot be empty")
+	}
+	if search.Snippet != nil || search.ReturnParents {
+		return []DocumentWithScore{}, fmt.Errorf("hybrid search doesn't support Snippet or ReturnParents")
+	}
+	opts = opts.withDefaults(search.TopK)
+
+	filterPrefix, params, err := r.buildFilterQuery(search.Filters)
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	returnFields, err := r.searchReturnFields(search.Fields)
+	if err != nil {
+		return []DocumentWithScore{}, err
+	}
+	returnFields = append(returnFields, redis.FTSearchReturn{FieldName: "sparse"})
+
+	queryVec, err := r.embedClient.EmbedQuery(ctx, search.Query)
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("failed to embed query: %w", err)
+	}
+	queryVec = r.fitVector(queryVec)
+	if len(queryVec) != r.indexConfig.Dimensions {
+		return []DocumentWithScore{}, fmt.Errorf("query vector dimension mismatch: got %d, expected %d",
+			len(queryVec), r.indexConfig.Dimensions)
+	}
+
+	querySparse, err := r.sparseEmbedder.EmbedSparseQuery(ctx, search.Query)
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("failed to embed sparse query: %w", err)
+	}
+
+	// Dense candidates, in KNN order
+	denseParams := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		denseParams[k] = v
+	}
+	encodedQuery, _ := encodeVector(queryVec, r.indexConfig.VectorType)
+	denseParams["vec"] = encodedQuery
+
+	dense, err := r.client.FTSearchWithArgs(ctx, r.index,
+		fmt.Sprintf("%s=>[KNN %d @embedding $vec AS score]", filterPrefix, opts.Candidates),
+		&redis.FTSearchOptions{
+			DialectVersion: 2,
+			Params:         denseParams,
+			Return:         returnFields,
+			SortBy:         []redis.FTSearchSortBy{{FieldName: "score", Asc: true}},
+			Limit:          opts.Candidates,
+		},
+	).Result()
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("failed to search: %w", err)
+	}
+
+	candidates := make(map[string]DocumentWithScore)
+	sparseVectors := make(map[string]embedding.SparseVector)
+	add := func(doc redis.Document) error {
+		decoded, err := r.decodeSearchDocument(doc)
+		if err != nil {
+			return err
+		}
+		if _, seen := candidates[decoded.ID]; seen {
+			return nil
+		}
+		candidates[decoded.ID] = decoded
+		if raw := doc.Fields["sparse"]; raw != "" {
+			var v embedding.SparseVector
+			if err := json.Unmarshal([]byte(raw), &v); err != nil {
+				return fmt.Errorf("failed to decode sparse vector of doc %s: %w", decoded.ID, err)
+			}
+			sparseVectors[decoded.ID] = v
+		}
+		return nil
+	}
+
+	denseIDs := make([]string, 0, len(dense.Docs))
+	for _, doc := range dense.Docs {
+		if err := add(doc); err != nil {
+			return []DocumentWithScore{}, err
+		}
+		denseIDs = append(denseIDs, doc.Fields["id"])
+	}
+
+	// Sparse candidates share at least one of the query's strongest terms
+	if terms := topSparseTerms(querySparse, opts.MaxQueryTerms); len(terms) > 0 {
+		query := fmt.Sprintf("@sparse_terms:{%s}", strings.Join(terms, "|"))
+		if filterPrefix != "*" {
+			query = filterPrefix + " " + query
+		}
+		options := &redis.FTSearchOptions{
+			DialectVersion: 2,
+			Return:         returnFields,
+			Limit:          opts.Candidates,
+		}
+		if len(params) > 0 {
+			options.Params = params
+		}
+		sparse, err := r.client.FTSearchWithArgs(ctx, r.index, query, options).Result()
+		if err != nil {
+			return []DocumentWithScore{}, fmt.Errorf("failed to search sparse terms: %w", err)
+		}
+		for _, doc := range sparse.Docs {
+			if err := add(doc); err != nil {
+				return []DocumentWithScore{}, err
+			}
+		}
+	}
+
+	sparseScores := make(map[string]float64, len(sparseVectors))
+	for id, v := range sparseVectors {
+		sparseScores[id] = querySparse.Dot(v)
+	}
+
+	fused := fuseRanks(denseIDs, sparseScores, opts)
+	if len(fused) > search.TopK {
+		fused = fused[:search.TopK]
+	}
+
+	docs := make([]DocumentWithScore, 0, len(fused))
+	for _, f := range fused {
+		doc := candidates[f.id]
+		doc.Score = strconv.FormatFloat(f.score, 'f', -1, 64)
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+type fusedResult struct {
+	id    string
+	score float64
+}
+
+// fuseRanks merges the dense ranking with the ranking by sparse score using weighted reciprocal rank fusion
+func fuseRanks(denseIDs []string, sparseScores map[string]float64, opts HybridOptions) []fusedResult {
+	scores := make(map[string]float64)
+	for i, id := range denseIDs {
+		scores[id] += opts.DenseWeight / float64(opts.RRFK+i+1)
+	}
+
+	sparseIDs := make([]string, 0, len(sparseScores))
+	for id, score := range sparseScores {
+		if score > 0 {
+			sparseIDs = append(sparseIDs, id)
+		}
+	}
+	sort.Slice(sparseIDs, func(i, j int) bool {
+		if sparseScores[sparseIDs[i]] != sparseScores[sparseIDs[j]] {
+			return sparseScores[sparseIDs[i]] > sparseScores[sparseIDs[j]]
+		}
+		return sparseIDs[i] < sparseIDs[j]
+	})
+	for i, id := range sparseIDs {
+		scores[id] += opts.SparseWeight / float64(opts.RRFK+i+1)
+	}
+
+	fused := make([]fusedResult, 0, len(scores))
+	for id, score := range scores {
+		fused = append(fused, fusedResult{id: id, score: score})
+	}
+	sort.Slice(fused, func(i, j int) bool {
+		if fused[i].score != fused[j].score {
+			return fused[i].score > fused[j].score
+		}
+		return fused[i].id < fused[j].id
+	})
+	return fused
+}
+
+// topSparseTerms returns the indices of the highest weighted terms as tag values
+func topSparseTerms(v embedding.SparseVector, limit int) []string {
+	order := make([]int, 0, len(v.Indices))
+	for i := range v.Indices {
+		if v.Values[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return v.Values[order[a]] > v.Values[order[b]] })
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	terms := make([]string, len(order))
+	for i, idx := range order {
+		terms[i] = strconv.FormatUint(uint64(v.Indices[idx]), 10)
+	}
+	return terms
+}
+
+// sparseFields returns the hash fields storing a sparse vector and its indexed terms
+func sparseFields(v embedding.SparseVector) map[string]interface{} {
+	encoded, _ := json.Marshal(v)
+	terms := make([]string, len(v.Indices))
+	for i, index := range v.Indices {
+		terms[i] = strconv.FormatUint(uint64(index), 10)
+	}
+	return map[string]interface{}{
+		"sparse":       string(encoded),
+		"sparse_terms": strings.Join(terms, ","),
+	}
+}
+
+// embedSparse embeds document contents with the sparse embedder, nil without one
+func (r *RedisVectorDB) embedSparse(ctx context.Context, docs []Document) ([]embedding.SparseVector, error) {
+	if r.sparseEmbedder == nil {
+		return nil, nil
+	}
+
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.Content
+	}
+	vectors, err := r.sparseEmbedder.EmbedSparseTexts(ctx, contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sparse vectors: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d sparse vectors for %d documents", len(vectors), len(docs))
+	}
+	return vectors, nil
+}
@@ -0,0 +1,57 @@
+package vectordb
+
+import (
+	"testing"
+
+	"github.com/mhrlife/goai-kit/embedding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseDot(t *testing.T) {
+	query := embedding.SparseVector{Indices: []uint32{1, 5, 9}, Values: []float64{0.5, 1, 2}}
+	doc := embedding.SparseVector{Indices: []uint32{5, 9, 12}, Values: []float64{2, 0.25, 3}}
+
+	require.InDelta(t, 2.5, query.Dot(doc), 1e-9)
+	require.Zero(t, query.Dot(embedding.SparseVector{Indices: []uint32{7}, Values: []float64{1}}))
+}
+
+func TestTopSparseTerms(t *testing.T) {
+	v := embedding.SparseVector{Indices: []uint32{3, 8, 12, 40}, Values: []float64{0.2, 1.5, 0, 0.9}}
+
+	require.Equal(t, []string{"8", "40"}, topSparseTerms(v, 2))
+	require.Equal(t, []string{"8", "40", "3"}, topSparseTerms(v, 10))
+}
+
+func TestSparseFields(t *testing.T) {
+	fields := sparseFields(embedding.SparseVector{Indices: []uint32{4, 17}, Values: []float64{0.5, 1}})
+
+	require.Equal(t, `{"indices":[4,17],"values":[0.5,1]}`, fields["sparse"])
+	require.Equal(t, "4,17", fields["sparse_terms"])
+}
+
+func TestFuseRanks(t *testing.T) {
+	opts := HybridOptions{}.withDefaults(3)
+	require.Equal(t, 12, opts.Candidates)
+
+	// "b" ranks second on both sides and beats documents found by only one of them
+	fused := fuseRanks(
+		[]string{"a", "b", "c"},
+		map[string]float64{"b": 2, "d": 3, "c": 0},
+		opts,
+	)
+
+	ids := make([]string, len(fused))
+	for i, f := range fused {
+		ids[i] = f.id
+	}
+	require.Equal(t, []string{"b", "a", "d", "c"}, ids)
+	require.InDelta(t, 1.0/62+1.0/62, fused[0].score, 1e-12)
+}
+
+func TestFuseRanksWeights(t *testing.T) {
+	opts := HybridOptions{DenseWeight: 1, SparseWeight: 3}.withDefaults(2)
+
+	fused := fuseRanks([]string{"a"}, map[string]float64{"b": 1}, opts)
+	require.Equal(t, "b", fused[0].id)
+	require.Equal(t, "a", fused[1].id)
+}
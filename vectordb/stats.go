@@ -0,0 +1,173 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IndexStats describes the size and health of an index
+type IndexStats struct {
+	NumDocs          int
+	IndexingFailures int     // Hashes Redis failed to index, e.g. vectors of the wrong byte length
+	MemoryMB         float64 // Total index memory
+	VectorIndexMB    float64
+
+	Dimensions     int
+	DistanceMetric string
+	VectorType     VectorType
+	EmbeddingModel string
+
+	Sample SampleReport
+}
+
+// SampleReport is the result of checking a sample of stored vectors
+type SampleReport struct {
+	Size int
+
+	// DimensionMismatches lists documents whose vector doesn't have the index dimension
+	DimensionMismatches []string
+	// InvalidVectors lists documents with a missing, undecodable, zero, NaN or infinite vector
+	InvalidVectors []string
+	// SelfMatchFailures lists documents not found by a nearest neighbour search with their own vector
+	SelfMatchFailures []string
+
+	// MeanPairwiseSimilarity is the mean cosine similarity between sampled vectors,
+	// values close to 1 mean the embeddings collapsed and can't tell documents apart
+	MeanPairwiseSimilarity float64
+}
+
+// Healthy reports whether the sample found no problems
+func (s SampleReport) Healthy() bool {
+	return len(s.DimensionMismatches) == 0 && len(s.InvalidVectors) == 0 && len(s.SelfMatchFailures) == 0
+}
+
+// IndexStats returns index statistics and a health report over sampleSize documents (defaults to 20)
+func (r *RedisVectorDB) IndexStats(ctx context.Context, sampleSize int) (IndexStats, error) {
+	stats := IndexStats{}
+	if r.indexConfig == nil {
+		return stats, fmt.Errorf("index not created: call CreateIndex first")
+	}
+	if sampleSize <= 0 {
+		sampleSize = 20
+	}
+
+	info, err := r.client.FTInfo(ctx, r.index).Result()
+	if err != nil {
+		return stats, fmt.Errorf("failed to load index info: %w", err)
+	}
+
+	stats.NumDocs = info.NumDocs
+	stats.IndexingFailures = info.HashIndexingFailures
+	stats.MemoryMB = info.TotalIndexMemorySzMB
+	stats.VectorIndexMB = info.VectorIndexSzMB
+	stats.Dimensions = r.indexConfig.Dimensions
+	stats.DistanceMetric = r.indexConfig.DistanceMetric
+	if stats.DistanceMetric == "" {
+		stats.DistanceMetric = "COSINE"
+	}
+	stats.VectorType = r.indexConfig.VectorType
+	stats.EmbeddingModel = r.indexConfig.EmbeddingModel
+
+	sample := make([]StoredDocument, 0, sampleSize)
+	err = r.scanDocuments(ctx, sampleSize, func(batch []StoredDocument) error {
+		sample = append(sample, batch...)
+		if len(sample) >= sampleSize {
+			sample = sample[:sampleSize]
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Sample, err = r.checkSample(ctx, sample)
+	return stats, err
+}
+
+// checkSample validates the sampled vectors and runs a self-match search for each valid one
+func (r *RedisVectorDB) checkSample(ctx context.Context, sample []StoredDocument) (SampleReport, error) {
+	report := SampleReport{Size: len(sample)}
+	valid := make([][]float64, 0, len(sample))
+
+	for _, doc := range sample {
+		switch {
+		case !validVector(doc.Vector):
+			report.InvalidVectors = append(report.InvalidVectors, doc.ID)
+			continue
+		case len(doc.Vector) != r.indexConfig.Dimensions:
+			report.DimensionMismatches = append(report.DimensionMismatches, doc.ID)
+			continue
+		}
+		valid = append(valid, doc.Vector)
+
+		found, err := r.selfMatch(ctx, doc)
+		if err != nil {
+			return report, err
+		}
+		if !found {
+			report.SelfMatchFailures = append(report.SelfMatchFailures, doc.ID)
+		}
+	}
+
+	report.MeanPairwiseSimilarity = meanPairwiseSimilarity(valid)
+	return report, nil
+}
+
+// selfMatch reports whether a nearest neighbour search with the document's own vector finds it
+// A few neighbours are requested so exact duplicates don't count as failures
+func (r *RedisVectorDB) selfMatch(ctx context.Context, doc StoredDocument) (bool, error) {
+	encoded, _ := encodeVector(doc.Vector, r.indexConfig.VectorType)
+
+	result, err := r.client.FTSearchWithArgs(
+		ctx,
+		r.index,
+		"*=>[KNN 3 @embedding $vec AS score]",
+		&redis.FTSearchOptions{
+			DialectVersion: 2,
+			Params:         map[string]interface{}{"vec": encoded},
+			Return:         []redis.FTSearchReturn{{FieldName: "id"}},
+		},
+	).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to search with vector of doc %s: %w", doc.ID, err)
+	}
+
+	return slices.ContainsFunc(result.Docs, func(d redis.Document) bool {
+		return d.Fields["id"] == doc.ID
+	}), nil
+}
+
+// validVector reports whether a vector is present, finite and non-zero
+func validVector(vec []float64) bool {
+	if len(vec) == 0 {
+		return false
+	}
+	norm := 0.0
+	for _, v := range vec {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+		norm += v * v
+	}
+	return norm > 0
+}
+
+// meanPairwiseSimilarity returns the mean cosine similarity over all vector pairs
+func meanPairwiseSimilarity(vecs [][]float64) float64 {
+	sum, pairs := 0.0, 0
+	for i := range vecs {
+		for j := i + 1; j < len(vecs); j++ {
+			sum += cosineSimilarity(vecs[i], vecs[j])
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return sum / float64(pairs)
+}
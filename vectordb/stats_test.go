@@ -0,0 +1,75 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexStats(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	db.indexConfig.EmbeddingModel = "test-embedder"
+	ctx := context.Background()
+
+	require.NoError(t, db.StoreDocumentsBatch(ctx, []Document{
+		{ID: "a", Content: "A"}, {ID: "b", Content: "B"}, {ID: "c", Content: "C"},
+		{ID: "short", Content: "short"}, {ID: "zero", Content: "zero"}, {ID: "corrupt", Content: "corrupt"},
+	}))
+	fake.set("docs:short", map[string]string{"embedding": string(encodeFloat32Vector([]float32{1, 0}))})
+	fake.set("docs:zero", map[string]string{"embedding": string(encodeFloat32Vector([]float32{0, 0, 0}))})
+	fake.set("docs:corrupt", map[string]string{"embedding": "xyz"})
+
+	fake.info = &redis.FTInfoResult{NumDocs: 6, HashIndexingFailures: 1, TotalIndexMemorySzMB: 1.5, VectorIndexSzMB: 0.5}
+	// the self-match search of b doesn't find it
+	fake.searchResult = redis.FTSearchResult{Docs: []redis.Document{
+		{Fields: map[string]string{"id": "a"}}, {Fields: map[string]string{"id": "c"}},
+	}}
+
+	stats, err := db.IndexStats(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, 6, stats.NumDocs)
+	require.Equal(t, 1, stats.IndexingFailures)
+	require.Equal(t, 1.5, stats.MemoryMB)
+	require.Equal(t, 0.5, stats.VectorIndexMB)
+	require.Equal(t, 3, stats.Dimensions)
+	require.Equal(t, "COSINE", stats.DistanceMetric)
+	require.Equal(t, "test-embedder", stats.EmbeddingModel)
+
+	sample := stats.Sample
+	require.Equal(t, 6, sample.Size)
+	require.Equal(t, []string{"short"}, sample.DimensionMismatches)
+	require.ElementsMatch(t, []string{"zero", "corrupt"}, sample.InvalidVectors)
+	require.Equal(t, []string{"b"}, sample.SelfMatchFailures)
+	require.InDelta(t, 1, sample.MeanPairwiseSimilarity, 1e-9, "every valid vector is the same")
+	require.False(t, sample.Healthy())
+
+	stats, err = db.IndexStats(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Sample.Size)
+}
+
+func TestIndexStatsOfHealthyIndex(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+
+	require.NoError(t, db.StoreDocument(ctx, Document{ID: "a", Content: "A"}))
+	fake.info = &redis.FTInfoResult{NumDocs: 1}
+	fake.searchResult = redis.FTSearchResult{Docs: []redis.Document{{Fields: map[string]string{"id": "a"}}}}
+
+	stats, err := db.IndexStats(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Sample.Size)
+	require.True(t, stats.Sample.Healthy())
+	require.Zero(t, stats.Sample.MeanPairwiseSimilarity, "a single vector has no pairs")
+}
+
+func TestIndexStatsWithoutIndex(t *testing.T) {
+	db, _, _ := newFakeVectorDB(t, "docs")
+
+	_, err := db.IndexStats(context.Background(), 10)
+	require.ErrorContains(t, err, "failed to load index info")
+	_, err = (&RedisVectorDB{}).IndexStats(context.Background(), 10)
+	require.ErrorContains(t, err, "index not created")
+}
@@ -0,0 +1,175 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DocumentSource provides the full, current set of documents of a knowledge base
+type DocumentSource interface {
+	Documents(ctx context.Context) ([]Document, error)
+}
+
+// DocumentSourceFunc adapts a function to the DocumentSource interface
+type DocumentSourceFunc func(ctx context.Context) ([]Document, error)
+
+func (f DocumentSourceFunc) Documents(ctx context.Context) ([]Document, error) {
+	return f(ctx)
+}
+
+// SyncReport lists the document IDs touched by SyncDocuments
+type SyncReport struct {
+	Added     []string
+	Updated   []string
+	Unchanged []string
+	Deleted   []string
+}
+
+// storedDocumentState is the subset of a stored document used for change detection
+type storedDocumentState struct {
+	contentHash string
+	metadata    string
+	parentID    string
+}
+
+// SyncDocuments makes the index mirror the source: new documents and changed content are (re-)embedded,
+// documents with only new metadata or parent get just those rewritten, unchanged ones are skipped and
+// documents missing from the source are deleted
+// Changes are detected with the content hash, metadata and parent stored alongside each document
+func (r *RedisVectorDB) SyncDocuments(ctx context.Context, source DocumentSource) (SyncReport, error) {
+	report := SyncReport{}
+
+	if r.indexConfig == nil {
+		return report, fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	docs, err := source.Documents(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to load source documents: %w", err)
+	}
+
+	stored, err := r.storedDocumentStates(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	toStore := make([]Document, 0)
+	metadataOnly := make([]Document, 0)
+	seen := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		if _, dup := seen[doc.ID]; dup {
+			return report, fmt.Errorf("duplicate document ID in source: %s", doc.ID)
+		}
+		seen[doc.ID] = struct{}{}
+
+		state, exists := stored[doc.ID]
+		if !exists {
+			report.Added = append(report.Added, doc.ID)
+			toStore = append(toStore, doc)
+			continue
+		}
+
+		if state.contentHash != contentHash(doc.Content) {
+			report.Updated = append(report.Updated, doc.ID)
+			toStore = append(toStore, doc)
+			continue
+		}
+
+		meta, _ := json.Marshal(doc.Meta)
+		if state.metadata == string(meta) && state.parentID == doc.ParentID {
+			report.Unchanged = append(report.Unchanged, doc.ID)
+			continue
+		}
+
+		// the content and so the embedding are unchanged
+		report.Updated = append(report.Updated, doc.ID)
+		metadataOnly = append(metadataOnly, doc)
+	}
+
+	if err := r.validateDocuments(metadataOnly...); err != nil {
+		return report, err
+	}
+	if err := r.StoreDocumentsBatch(ctx, toStore); err != nil {
+		return report, fmt.Errorf("failed to store changed documents: %w", err)
+	}
+	for _, doc := range metadataOnly {
+		if err := r.writeMetadata(ctx, doc.ID, doc.Meta, &doc.ParentID); err != nil {
+			return report, fmt.Errorf("failed to update document %s: %w", doc.ID, err)
+		}
+	}
+
+	for id := range stored {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if err := r.DeleteDocument(ctx, id); err != nil {
+			return report, err
+		}
+		report.Deleted = append(report.Deleted, id)
+	}
+
+	return report, nil
+}
+
+// storedDocumentStates scans the index key space and loads hash, metadata and parent of every document
+func (r *RedisVectorDB) storedDocumentStates(ctx context.Context) (map[string]storedDocumentState, error) {
+	states := make(map[string]storedDocumentState)
+	prefix := r.index + ":"
+
+	iter := r.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	keys := make([]string, 0, 500)
+
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.SliceCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.HMGet(ctx, key, "content_hash", "metadata", "parent_id")
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to load stored documents: %w", err)
+		}
+
+		for i, key := range keys {
+			values := cmds[i].Val()
+			state := storedDocumentState{}
+			if v, ok := values[0].(string); ok {
+				state.contentHash = v
+			}
+			if v, ok := values[1].(string); ok {
+				state.metadata = v
+			}
+			if v, ok := values[2].(string); ok {
+				state.parentID = v
+			}
+			states[strings.TrimPrefix(key, prefix)] = state
+		}
+
+		keys = keys[:0]
+		return nil
+	}
+
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan stored documents: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
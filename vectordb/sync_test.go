@@ -0,0 +1,55 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncDocuments(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+
+	docs := []Document{
+		{ID: "a", Content: "alpha", Meta: map[string]any{"tags": []string{"x"}}},
+		{ID: "b", Content: "beta"},
+		{ID: "c", Content: "gamma"},
+	}
+	sync := func() SyncReport {
+		report, err := db.SyncDocuments(ctx, DocumentSourceFunc(func(context.Context) ([]Document, error) {
+			return docs, nil
+		}))
+		require.NoError(t, err)
+		return report
+	}
+
+	require.ElementsMatch(t, []string{"a", "b", "c"}, sync().Added)
+	require.Equal(t, 3, embedder.embedded())
+	embedding := fake.hash("docs:a")["embedding"]
+
+	// only the metadata of a and the parent of b changed, c's content changed, d is new
+	docs = []Document{
+		{ID: "a", Content: "alpha", Meta: map[string]any{"tags": []string{"y"}}},
+		{ID: "b", ParentID: "p", Content: "beta"},
+		{ID: "d", Content: "delta"},
+		{ID: "c", Content: "gamma 2"},
+	}
+	report := sync()
+	require.Equal(t, []string{"d"}, report.Added)
+	require.ElementsMatch(t, []string{"a", "b", "c"}, report.Updated)
+	require.Empty(t, report.Unchanged)
+	require.Equal(t, 5, embedder.embedded(), "only c and d are embedded")
+
+	require.Equal(t, embedding, fake.hash("docs:a")["embedding"])
+	require.Equal(t, "y", fake.hash("docs:a")["meta_tags"])
+	require.Equal(t, "p", fake.hash("docs:b")["parent_id"])
+	require.Equal(t, contentHash("gamma 2"), fake.hash("docs:c")["content_hash"])
+
+	docs = docs[:2]
+	report = sync()
+	require.ElementsMatch(t, []string{"a", "b"}, report.Unchanged)
+	require.ElementsMatch(t, []string{"c", "d"}, report.Deleted)
+	require.Nil(t, fake.hash("docs:c"))
+	require.Equal(t, 5, embedder.embedded())
+}
@@ -0,0 +1,83 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SearchText runs a pure full-text BM25 search over the content field, without embedding the query
+// Query words are matched with OR semantics, Filters, TopK, Fields and ReturnParents apply as in
+// SearchDocuments. Score holds the BM25 score, higher is better
+func (r *RedisVectorDB) SearchText(ctx context.Context, search DocumentSearch) ([]DocumentWithScore, error) {
+	if r.indexConfig == nil {
+		return []DocumentWithScore{}, fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	if search.TopK <= 0 {
+		return []DocumentWithScore{}, fmt.Errorf("TopK must be positive, got %d", search.TopK)
+	}
+
+	words := strings.Fields(search.Query)
+	if len(words) == 0 {
+		return []DocumentWithScore{}, fmt.Errorf("query cannot be empty")
+	}
+	for i, w := range words {
+		words[i] = escapeQueryValue(w)
+	}
+
+	filterPrefix, params, err := r.buildFilterQuery(search.Filters)
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	returnFields, err := r.searchReturnFields(search.Fields)
+	if err != nil {
+		return []DocumentWithScore{}, err
+	}
+
+	query := fmt.Sprintf("@content:(%s)", strings.Join(words, "|"))
+	if filterPrefix != "*" {
+		query = filterPrefix + " " + query
+	}
+
+	options := &redis.FTSearchOptions{
+		DialectVersion: 2,
+		Scorer:         "BM25",
+		WithScores:     true,
+		Limit:          search.TopK,
+		Return:         returnFields,
+	}
+	if len(params) > 0 {
+		options.Params = params
+	}
+
+	result, err := r.client.FTSearchWithArgs(ctx, r.index, query, options).Result()
+	if err != nil {
+		return []DocumentWithScore{}, fmt.Errorf("failed to search text: %w", err)
+	}
+
+	docs := make([]DocumentWithScore, 0, len(result.Docs))
+	for _, doc := range result.Docs {
+		decoded, err := r.decodeSearchDocument(doc)
+		if err != nil {
+			return []DocumentWithScore{}, err
+		}
+		if doc.Score != nil {
+			decoded.Score = strconv.FormatFloat(*doc.Score, 'f', -1, 64)
+		}
+		docs = append(docs, decoded)
+	}
+
+	if search.ReturnParents {
+		docs, err = r.resolveParents(ctx, docs)
+		if err != nil {
+			return []DocumentWithScore{}, err
+		}
+	}
+
+	return docs, nil
+}
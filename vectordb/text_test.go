@@ -0,0 +1,69 @@
+package vectordb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchText(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	score := 2.5
+	fake.searchResult = redis.FTSearchResult{Total: 1, Docs: []redis.Document{{
+		ID:    "docs:refunds",
+		Score: &score,
+		Fields: map[string]string{
+			"id":        "refunds",
+			"content":   "Refunds are accepted within 30 days",
+			"metadata":  `{"tags":["billing"]}`,
+			"parent_id": "handbook",
+		},
+	}}}
+	var search []string
+	fake.onCommand = func(args []string) {
+		if args[0] == "ft.search" {
+			search = args
+		}
+	}
+
+	docs, err := db.SearchText(context.Background(), DocumentSearch{
+		Query:   "refund  policy-v2",
+		TopK:    3,
+		Filters: []Filter{{Field: "tags", Operator: FilterOpEq, Value: "billing"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []DocumentWithScore{{
+		Document: Document{
+			ID:       "refunds",
+			Content:  "Refunds are accepted within 30 days",
+			Meta:     map[string]any{"tags": []any{"billing"}},
+			ParentID: "handbook",
+		},
+		Score: "2.5",
+	}}, docs)
+	require.Zero(t, embedder.embedded(), "the query is not embedded")
+
+	args := strings.Join(search, " ")
+	require.Contains(t, args, `ft.search docs (@meta_tags:{billing}) @content:(refund|policy\-v2)`)
+	require.Contains(t, args, "WITHSCORES")
+	require.Contains(t, args, "SCORER BM25")
+	require.Contains(t, args, "LIMIT 0 3")
+	require.Contains(t, args, "DIALECT 2")
+}
+
+func TestSearchTextRejectsInvalidSearches(t *testing.T) {
+	db, _, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+
+	_, err := db.SearchText(ctx, DocumentSearch{Query: "  ", TopK: 3})
+	require.ErrorContains(t, err, "query cannot be empty")
+	_, err = db.SearchText(ctx, DocumentSearch{Query: "refund", TopK: 0})
+	require.ErrorContains(t, err, "TopK must be positive")
+	_, err = db.SearchText(ctx, DocumentSearch{Query: "refund", TopK: 3, Filters: []Filter{{Field: "unknown", Operator: FilterOpEq, Value: "x"}}})
+	require.ErrorContains(t, err, "invalid filters")
+	_, err = (&RedisVectorDB{}).SearchText(ctx, DocumentSearch{Query: "refund", TopK: 3})
+	require.ErrorContains(t, err, "index not created")
+}
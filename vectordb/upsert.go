@@ -0,0 +1,115 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrDocumentNotFound is returned when a document doesn't exist in the index
+var ErrDocumentNotFound = errors.New("document not found")
+
+// UpdateDocument upserts a document, re-embedding it only when its content changed
+func (r *RedisVectorDB) UpdateDocument(ctx context.Context, doc Document) error {
+	if r.indexConfig == nil {
+		return fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	key := fmt.Sprintf("%s:%s", r.index, doc.ID)
+	storedHash, err := r.client.HGet(ctx, key, "content_hash").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+
+	// Unchanged content keeps its embedding, only the metadata and parent are rewritten
+	if storedHash == "" || storedHash != contentHash(doc.Content) {
+		return r.StoreDocument(ctx, doc)
+	}
+
+	if err := r.validateDocuments(doc); err != nil {
+		return err
+	}
+
+	return r.writeMetadata(ctx, doc.ID, doc.Meta, &doc.ParentID)
+}
+
+// PatchMetadata merges meta into the stored metadata of a document without touching its content or vector
+// A nil value removes the key
+func (r *RedisVectorDB) PatchMetadata(ctx context.Context, id string, meta map[string]any) error {
+	if r.indexConfig == nil {
+		return fmt.Errorf("index not created: call CreateIndex first")
+	}
+
+	key := fmt.Sprintf("%s:%s", r.index, id)
+	raw, err := r.client.HGet(ctx, key, "metadata").Result()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("document %s: %w", id, ErrDocumentNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+
+	var merged map[string]any
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &merged); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata for doc %s: %w", id, err)
+		}
+	}
+	// documents stored without metadata have "null"
+	if merged == nil {
+		merged = make(map[string]any)
+	}
+	for k, v := range meta {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+
+	if err := r.validateDocuments(Document{ID: id, Meta: merged}); err != nil {
+		return err
+	}
+
+	return r.writeMetadata(ctx, id, merged, nil)
+}
+
+// writeMetadata replaces the metadata blob and the indexed meta_ fields of a document, and its parent unless parentID
+// is nil. An empty parentID unlinks the document from its parent
+func (r *RedisVectorDB) writeMetadata(ctx context.Context, id string, meta map[string]any, parentID *string) error {
+	key := fmt.Sprintf("%s:%s", r.index, id)
+	b, _ := json.Marshal(meta)
+
+	fields := map[string]interface{}{"metadata": string(b)}
+	removed := make([]string, 0)
+	for _, f := range r.indexConfig.FilterableFields {
+		if val, ok := meta[f.Name]; ok && val != nil {
+			fields["meta_"+f.Name] = indexValue(f.Type, val)
+			continue
+		}
+		removed = append(removed, "meta_"+f.Name)
+	}
+	if parentID != nil {
+		if *parentID != "" {
+			fields["parent_id"] = *parentID
+		} else {
+			removed = append(removed, "parent_id")
+		}
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		if len(removed) > 0 {
+			pipe.HDel(ctx, key, removed...)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentReembedsChangedContent(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	require.NoError(t, db.StoreDocument(ctx, Document{ID: "a", ParentID: "p1", Content: "old"}))
+
+	require.NoError(t, db.UpdateDocument(ctx, Document{ID: "a", ParentID: "p2", Content: "new"}))
+	require.Equal(t, 2, embedder.embedded())
+
+	stored := fake.hash("docs:a")
+	require.Equal(t, "new", stored["content"])
+	require.Equal(t, contentHash("new"), stored["content_hash"])
+	require.Equal(t, "p2", stored["parent_id"])
+}
+
+func TestUpdateDocumentUnchangedContentKeepsEmbedding(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	require.NoError(t, db.StoreDocument(ctx, Document{
+		ID: "a", ParentID: "p1", Content: "same", Meta: map[string]any{"tags": []string{"x"}},
+	}))
+	embedding := fake.hash("docs:a")["embedding"]
+
+	require.NoError(t, db.UpdateDocument(ctx, Document{
+		ID: "a", ParentID: "p2", Content: "same", Meta: map[string]any{"tags": []string{"y"}},
+	}))
+	require.Equal(t, 1, embedder.embedded())
+
+	stored := fake.hash("docs:a")
+	require.Equal(t, embedding, stored["embedding"])
+	require.Equal(t, "p2", stored["parent_id"])
+	require.Equal(t, "y", stored["meta_tags"])
+	require.JSONEq(t, `{"tags":["y"]}`, stored["metadata"])
+
+	// clearing the parent and the metadata removes their fields
+	require.NoError(t, db.UpdateDocument(ctx, Document{ID: "a", Content: "same"}))
+	require.Equal(t, 1, embedder.embedded())
+	stored = fake.hash("docs:a")
+	require.NotContains(t, stored, "parent_id")
+	require.NotContains(t, stored, "meta_tags")
+}
+
+func TestPatchMetadataKeepsParent(t *testing.T) {
+	db, fake, _ := newFakeVectorDB(t, "docs")
+	ctx := context.Background()
+	require.NoError(t, db.StoreDocument(ctx, Document{ID: "a", ParentID: "p", Content: "c"}))
+
+	require.NoError(t, db.PatchMetadata(ctx, "a", map[string]any{"tags": []string{"x"}}))
+	stored := fake.hash("docs:a")
+	require.Equal(t, "p", stored["parent_id"])
+	require.Equal(t, "x", stored["meta_tags"])
+
+	require.ErrorIs(t, db.PatchMetadata(ctx, "missing", map[string]any{"tags": "x"}), ErrDocumentNotFound)
+}
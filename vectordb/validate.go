@@ -0,0 +1,129 @@
+package vectordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a document whose metadata doesn't match the index schema
+type ValidationError struct {
+	DocumentID string
+	Field      string
+	Reason     string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("document %s: field %q: %s", e.DocumentID, e.Field, e.Reason)
+}
+
+// ValidationErrors collects every violation found in a store call
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d metadata validation error(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// ValidateDocument checks a document's metadata against the filterable fields of an index
+func ValidateDocument(config IndexConfig, doc Document) ValidationErrors {
+	var errs ValidationErrors
+
+	for _, f := range config.FilterableFields {
+		val, ok := doc.Meta[f.Name]
+		if !ok || val == nil {
+			if f.Required {
+				errs = append(errs, ValidationError{DocumentID: doc.ID, Field: f.Name, Reason: "required field is missing"})
+			}
+			continue
+		}
+
+		if reason := checkFieldType(f.Type, val); reason != "" {
+			errs = append(errs, ValidationError{DocumentID: doc.ID, Field: f.Name, Reason: reason})
+		}
+	}
+
+	return errs
+}
+
+// checkFieldType returns a non-empty reason when val can't be indexed as the given type
+func checkFieldType(fieldType FilterFieldType, val any) string {
+	switch fieldType {
+	case FilterFieldTypeText:
+		if _, ok := val.(string); !ok {
+			return fmt.Sprintf("expected string for text field, got %T", val)
+		}
+	case FilterFieldTypeTag:
+		var tags []string
+		switch v := val.(type) {
+		case string:
+			tags = []string{v}
+		case []string:
+			tags = v
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Sprintf("expected string tag values, got %T", item)
+				}
+				tags = append(tags, s)
+			}
+		default:
+			return fmt.Sprintf("expected string or []string for tag field, got %T", val)
+		}
+		// tags are stored joined with the TAG separator, see indexValue
+		for _, tag := range tags {
+			if strings.Contains(tag, tagSeparator) {
+				return fmt.Sprintf("tag value %q must not contain %q", tag, tagSeparator)
+			}
+		}
+	case FilterFieldTypeNumeric:
+		if !isNumeric(val) {
+			return fmt.Sprintf("expected number for numeric field, got %T", val)
+		}
+	case FilterFieldTypeBool:
+		if _, ok := val.(bool); !ok {
+			return fmt.Sprintf("expected bool for bool field, got %T", val)
+		}
+	case FilterFieldTypeDate:
+		if _, ok := toTime(val); !ok {
+			return fmt.Sprintf("expected time.Time or RFC 3339 string for date field, got %T", val)
+		}
+	case FilterFieldTypeGeo:
+		p, ok := toGeoPoint(val)
+		if !ok {
+			return fmt.Sprintf("expected GeoPoint for geo field, got %T", val)
+		}
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return fmt.Sprintf("coordinates out of range: lat %v, lon %v", p.Lat, p.Lon)
+		}
+	}
+	return ""
+}
+
+// isNumeric reports whether val is a Go or JSON number
+func isNumeric(val any) bool {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	case json.Number:
+		_, err := v.Float64()
+		return err == nil
+	}
+	return false
+}
+
+// validateDocuments validates a set of documents against the index schema
+func (r *RedisVectorDB) validateDocuments(docs ...Document) error {
+	var errs ValidationErrors
+	for _, doc := range docs {
+		errs = append(errs, ValidateDocument(*r.indexConfig, doc)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDocumentTagSeparator(t *testing.T) {
+	config := IndexConfig{FilterableFields: []FilterableField{{Name: "tags", Type: FilterFieldTypeTag}}}
+
+	for _, val := range []any{"a", []string{"a", "b c"}, []any{"a", "b"}} {
+		require.Empty(t, ValidateDocument(config, Document{ID: "ok", Meta: map[string]any{"tags": val}}))
+	}
+
+	// a comma would split the value into several tags when indexed
+	for _, val := range []any{"a,b", []string{"a", "b,c"}, []any{"a,"}} {
+		errs := ValidateDocument(config, Document{ID: "bad", Meta: map[string]any{"tags": val}})
+		require.Len(t, errs, 1)
+		require.Equal(t, "tags", errs[0].Field)
+		require.Contains(t, errs[0].Reason, `must not contain ","`)
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	config := IndexConfig{FilterableFields: []FilterableField{
+		{Name: "category", Type: FilterFieldTypeTag, Required: true},
+		{Name: "title", Type: FilterFieldTypeText},
+		{Name: "price", Type: FilterFieldTypeNumeric},
+		{Name: "location", Type: FilterFieldTypeGeo},
+		{Name: "in_stock", Type: FilterFieldTypeBool},
+		{Name: "published_at", Type: FilterFieldTypeDate},
+	}}
+
+	valid := Document{ID: "ok", Meta: map[string]any{
+		"category":     []any{"laptop"},
+		"title":        "MacBook",
+		"price":        json.Number("2499.5"),
+		"location":     map[string]any{"Lat": 35.7, "Lon": 51.4},
+		"in_stock":     true,
+		"published_at": "2024-01-01T00:00:00Z",
+		"unindexed":    struct{}{},
+	}}
+	require.Empty(t, ValidateDocument(config, valid))
+
+	errs := ValidateDocument(config, Document{ID: "bad", Meta: map[string]any{
+		"title":        3,
+		"price":        "cheap",
+		"location":     GeoPoint{Lat: 91},
+		"in_stock":     "yes",
+		"published_at": "yesterday",
+	}})
+	fields := make([]string, len(errs))
+	for i, err := range errs {
+		require.Equal(t, "bad", err.DocumentID)
+		fields[i] = err.Field
+	}
+	require.Equal(t, []string{"category", "title", "price", "location", "in_stock", "published_at"}, fields)
+	require.Equal(t, "required field is missing", errs[0].Reason)
+	require.Contains(t, errs[3].Reason, "out of range")
+	require.True(t, strings.HasPrefix(errs.Error(), "6 metadata validation error(s): document bad: field \"category\""))
+}
+
+func TestStoreRejectsInvalidMetadata(t *testing.T) {
+	db, fake, embedder := newFakeVectorDB(t, "docs")
+	db.indexConfig.FilterableFields = append(db.indexConfig.FilterableFields,
+		FilterableField{Name: "price", Type: FilterFieldTypeNumeric})
+
+	err := db.StoreDocumentsBatch(context.Background(), []Document{
+		{ID: "a", Content: "fine", Meta: map[string]any{"price": 1}},
+		{ID: "b", Content: "broken", Meta: map[string]any{"price": "1"}},
+	})
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	require.Equal(t, "b", errs[0].DocumentID)
+
+	// nothing is embedded or stored when a document of the batch is invalid
+	require.Zero(t, embedder.embedded())
+	require.Nil(t, fake.hash("docs:a"))
+}
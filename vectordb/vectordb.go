@@ -1,28 +1,45 @@
 package vectordb
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Document struct {
 	ID      string
 	Content string
 	Meta    map[string]any
+	// ParentID links a chunk to the parent document it was split from (optional)
+	ParentID string
 }
 
 type DocumentWithScore struct {
 	Document
 	Score string
+	// Snippet is the passage that best matches the query, set when DocumentSearch.Snippet is used
+	Snippet string
+	// Chunks are the matched chunks of a parent, set when DocumentSearch.ReturnParents is used
+	Chunks []Document
 }
 
 type DocumentSearch struct {
 	Query   string
 	TopK    int
 	Filters []Filter
+	// Snippet extracts the most relevant passage of every result (optional, costs one extra embedding call)
+	Snippet *SnippetOptions
+	// Fields limits what is returned: FieldContent, FieldMetadata or filterable field names,
+	// which are decoded into Meta. Defaults to content and the full metadata
+	Fields []string
+	// ReturnParents searches over chunks but returns their parent documents (see StoreParents),
+	// ordered by their best matching chunk. Results may be fewer than TopK
+	ReturnParents bool
 }
 
 // Filter represents a search filter condition
 type Filter struct {
-	Field    string      // Metadata field name to filter on
-	Operator FilterOp   // Filter operator
+	Field    string      // Metadata field name to filter on, one of the index's FilterableFields
+	Operator FilterOp    // Filter operator
 	Value    interface{} // Value to compare against
 }
 
@@ -30,12 +47,17 @@ type Filter struct {
 type FilterOp string
 
 const (
-	FilterOpEq       FilterOp = "eq"       // Equals (text/tag match)
-	FilterOpIn       FilterOp = "in"       // In list of values (tag match)
-	FilterOpRange    FilterOp = "range"    // Numeric range [min, max]
-	FilterOpGte      FilterOp = "gte"      // Greater than or equal
-	FilterOpLte      FilterOp = "lte"      // Less than or equal
-	FilterOpContains FilterOp = "contains" // Text contains
+	FilterOpEq        FilterOp = "eq"         // Equals (tag match, or exact phrase on text fields)
+	FilterOpIn        FilterOp = "in"         // In list of values (tag match)
+	FilterOpRange     FilterOp = "range"      // Numeric range [min, max]
+	FilterOpGte       FilterOp = "gte"        // Greater than or equal
+	FilterOpLte       FilterOp = "lte"        // Less than or equal
+	FilterOpContains  FilterOp = "contains"   // Text contains
+	FilterOpGeoRadius FilterOp = "geo_radius" // Within a radius of a point (geo)
+	FilterOpIsTrue    FilterOp = "is_true"    // Boolean is true, or equals the given bool value
+	FilterOpBefore    FilterOp = "before"     // Date strictly before a time.Time
+	FilterOpAfter     FilterOp = "after"      // Date strictly after a time.Time
+	FilterOpBetween   FilterOp = "between"    // Date within a DateRange (inclusive)
 )
 
 // NumericRange represents a numeric range for filtering
@@ -44,16 +66,80 @@ type NumericRange struct {
 	Max float64
 }
 
+// DateRange is the value of a FilterOpBetween filter
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// GeoPoint is a geographic coordinate, use it as the metadata value of geo fields
+type GeoPoint struct {
+	Lat float64
+	Lon float64
+}
+
+// GeoUnit is the distance unit of a geo radius
+type GeoUnit string
+
+const (
+	GeoUnitMeters     GeoUnit = "m"
+	GeoUnitKilometers GeoUnit = "km"
+	GeoUnitMiles      GeoUnit = "mi"
+	GeoUnitFeet       GeoUnit = "ft"
+)
+
+// GeoRadius is the value of a FilterOpGeoRadius filter
+type GeoRadius struct {
+	Center GeoPoint
+	Radius float64
+	Unit   GeoUnit // Defaults to kilometers
+}
+
 type IndexConfig struct {
 	Dimensions       int
 	DistanceMetric   string
 	FilterableFields []FilterableField // Metadata fields that can be filtered
+
+	// Algorithm selects the vector index type (defaults to HNSW)
+	// FLAT is exact brute-force search, best for small corpora
+	Algorithm IndexAlgorithm
+	// HNSW tunes the HNSW graph, zero values keep the backend defaults
+	HNSW HNSWParams
+	// InitialCapacity pre-allocates room for this many vectors (optional)
+	InitialCapacity int
+	// BlockSize is the FLAT index allocation block size (optional)
+	BlockSize int
+
+	// EmbeddingModel pins the index to one embedding model, defaults to the client's model when it
+	// implements embedding.Named. CreateIndex fails with ErrModelMismatch for a different model
+	EmbeddingModel string
+
+	// VectorType sets the stored element type (defaults to FLOAT32)
+	// FLOAT16 halves and INT8 quarters vector memory, encoding is handled transparently
+	VectorType VectorType
+}
+
+// IndexAlgorithm is the vector index algorithm
+type IndexAlgorithm string
+
+const (
+	IndexAlgorithmHNSW IndexAlgorithm = "HNSW" // Approximate nearest neighbour graph
+	IndexAlgorithmFlat IndexAlgorithm = "FLAT" // Exact brute-force search
+)
+
+// HNSWParams are the HNSW tuning parameters
+type HNSWParams struct {
+	M              int     // Max outgoing edges per node (recall vs memory)
+	EFConstruction int     // Candidate list size while building (recall vs indexing speed)
+	EFRuntime      int     // Candidate list size while searching (recall vs latency)
+	Epsilon        float64 // Range query boundary factor
 }
 
 // FilterableField defines a metadata field that can be filtered
 type FilterableField struct {
-	Name string          // Field name in metadata
-	Type FilterFieldType // Field type for indexing
+	Name     string          // Field name in metadata
+	Type     FilterFieldType // Field type for indexing
+	Required bool            // Reject documents that don't set this field
 }
 
 // FilterFieldType represents the type of a filterable field
@@ -63,6 +149,9 @@ const (
 	FilterFieldTypeText    FilterFieldType = "text"    // Full-text searchable
 	FilterFieldTypeTag     FilterFieldType = "tag"     // Exact match (like category)
 	FilterFieldTypeNumeric FilterFieldType = "numeric" // Numeric range queries
+	FilterFieldTypeGeo     FilterFieldType = "geo"     // Lat/lon point for radius queries
+	FilterFieldTypeBool    FilterFieldType = "bool"    // Boolean, stored as numeric 0/1
+	FilterFieldTypeDate    FilterFieldType = "date"    // time.Time or RFC 3339 string, stored as unix milliseconds
 )
 
 type Client interface {
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mhrlife/goai-kit/callback"
+)
+
+// Callback is an AgentCallback that reports run lifecycle events to a webhook
+// Events are delivered in the background so the agent loop is never blocked by slow endpoints
+type Callback struct {
+	callback.BaseCallback
+
+	notifier *Notifier
+	timeout  time.Duration
+	wg       sync.WaitGroup
+}
+
+// NewCallback creates a webhook callback
+// timeout bounds each delivery including retries (defaults to 30s)
+func NewCallback(notifier *Notifier, timeout time.Duration) *Callback {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Callback{
+		notifier: notifier,
+		timeout:  timeout,
+	}
+}
+
+func (c *Callback) Name() string {
+	return "WebhookCallback"
+}
+
+// OnRunStart sends a run.started event
+func (c *Callback) OnRunStart(ctx map[string]interface{}) {
+	c.send(EventRunStarted, ctx, map[string]any{
+		"model": ctx["model"],
+		"input": ctx["input"],
+	})
+}
+
+// OnRunEnd sends a run.finished event
+func (c *Callback) OnRunEnd(ctx map[string]interface{}) {
+	c.send(EventRunFinished, ctx, map[string]any{
+		"output":           ctx["output"],
+		"total_iterations": ctx["total_iterations"],
+	})
+}
+
+// OnError sends a run.failed event for run level errors
+// Generation and tool errors are always followed by a run level error, so they are not reported twice
+func (c *Callback) OnError(ctx map[string]interface{}) {
+	if stage, _ := ctx["stage"].(string); stage != "run" {
+		return
+	}
+	c.send(EventRunFailed, ctx, map[string]any{
+		"error": ctx["error"],
+	})
+}
+
+// Flush waits until all pending deliveries are done
+func (c *Callback) Flush() {
+	c.wg.Wait()
+}
+
+func (c *Callback) send(eventType EventType, ctx map[string]interface{}, data map[string]any) {
+	runID, _ := ctx["run_id"].(string)
+	parentRunID, _ := ctx["parent_run_id"].(string)
+
+	event := Event{
+		Type:        eventType,
+		Timestamp:   time.Now().UTC(),
+		RunID:       runID,
+		ParentRunID: parentRunID,
+		Data:        data,
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		sendCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+
+		if err := c.notifier.Send(sendCtx, event); err != nil {
+			slog.Error("failed to deliver webhook event",
+				"event_type", eventType,
+				"run_id", runID,
+				"error", err,
+			)
+		}
+	}()
+}
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType identifies a lifecycle event
+type EventType string
+
+const (
+	EventRunStarted  EventType = "run.started"
+	EventRunFinished EventType = "run.finished"
+	EventRunFailed   EventType = "run.failed"
+
+	// EventScheduleFailed is sent by jobs.Scheduler when a scheduled run fails
+	EventScheduleFailed EventType = "schedule.failed"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+	SignatureHeader = "X-GoAIKit-Signature"
+	// TimestampHeader carries the unix timestamp used in the signature
+	TimestampHeader = "X-GoAIKit-Timestamp"
+	// EventTypeHeader carries the event type for routing without parsing the body
+	EventTypeHeader = "X-GoAIKit-Event"
+)
+
+// Event is the payload delivered to webhook endpoints
+type Event struct {
+	ID          string         `json:"id"`
+	Type        EventType      `json:"type"`
+	Timestamp   time.Time      `json:"timestamp"`
+	RunID       string         `json:"run_id,omitempty"`
+	ParentRunID string         `json:"parent_run_id,omitempty"`
+	Data        map[string]any `json:"data,omitempty"`
+}
+
+// Config configures a Notifier
+type Config struct {
+	// URL is the endpoint events are POSTed to (required)
+	URL string
+
+	// Secret is used to sign payloads with HMAC-SHA256 (optional but recommended)
+	Secret string
+
+	// MaxRetries is the number of retries after the first attempt (defaults to 3)
+	MaxRetries int
+
+	// DisableRetries delivers every event in a single attempt, MaxRetries is ignored
+	DisableRetries bool
+
+	// InitialBackoff is the delay before the first retry, doubled on every attempt (defaults to 500ms)
+	InitialBackoff time.Duration
+
+	// HTTPClient is used to deliver events (defaults to a client with a 10s timeout)
+	HTTPClient *http.Client
+}
+
+// Notifier delivers signed events to a webhook endpoint with retries
+type Notifier struct {
+	config Config
+}
+
+// NewNotifier creates a new webhook notifier
+func NewNotifier(config Config) (*Notifier, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("MaxRetries must not be negative, got %d", config.MaxRetries)
+	}
+	if config.DisableRetries {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 500 * time.Millisecond
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Notifier{config: config}, nil
+}
+
+// Send delivers an event, retrying on network errors, 429 and 5xx responses
+// ID and Timestamp are filled in when empty
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	backoff := n.config.InitialBackoff
+	var lastErr error
+	for attempt := 0; attempt <= n.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("webhook delivery cancelled after %d attempts: %w", attempt, ctx.Err())
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+
+		retryable, err := n.deliver(ctx, event.Type, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	return fmt.Errorf("webhook delivery failed: %w", lastErr)
+}
+
+// deliver performs a single delivery attempt and reports whether a failure is worth retrying
+func (n *Notifier) deliver(ctx context.Context, eventType EventType, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(EventTypeHeader, string(eventType))
+	if n.config.Secret != "" {
+		request.Header.Set(SignatureHeader, "sha256="+Sign(n.config.Secret, timestamp, body))
+	}
+
+	response, err := n.config.HTTPClient.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retryable, fmt.Errorf("webhook endpoint returned %s", response.Status)
+}
+
+// Sign computes the hex encoded HMAC-SHA256 signature of "<timestamp>.<body>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value produced by a Notifier
+// Receivers should also reject timestamps that are too old to prevent replays
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	expected := "sha256=" + Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
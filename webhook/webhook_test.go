@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifierSignsAndRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.True(t, Verify("secret", r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader)))
+		require.Equal(t, string(EventRunFinished), r.Header.Get(EventTypeHeader))
+
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{
+		URL:            server.URL,
+		Secret:         "secret",
+		InitialBackoff: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Event{Type: EventRunFinished, RunID: "run-1"})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestNotifierDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{URL: server.URL, InitialBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Event{Type: EventRunFailed})
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestNotifierDisableRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{URL: server.URL, DisableRetries: true, MaxRetries: 5, InitialBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Event{Type: EventRunFailed})
+	require.ErrorContains(t, err, "503")
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestNotifierRetriesByDefault(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{URL: server.URL, InitialBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	require.Error(t, notifier.Send(context.Background(), Event{Type: EventRunFailed}))
+	require.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}